@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/justmike1/ovad/prompts"
+)
+
+const adminUsage = `Usage: arbetern admin [flags] <command> [args]
+
+Runs operator commands against a running server's API.
+
+Commands:
+  agents                                    List agents
+  reload [agent]                            Reload prompts.yaml for all agents or one
+  prompt-history <agent>                    List an agent's prompt versions
+  rollback <agent> <version>                Restore an earlier prompt version
+  sync                                      Pull the agents repository now (AGENTS_GIT_URL)
+  sessions                                  Show thread session stats
+  sessions close [-agent ID] [-channel ID -thread TS]
+                                            Close all sessions, one agent's, or one thread's
+  spend-caps                                Show today's spend caps, usage and exemptions
+  spend-caps exempt|unexempt -user ID|-channel ID
+                                            Lift a user's or channel's caps for today, or restore them
+  read-only                                 Show the read-only flags
+  read-only [-agent ID] on|off              Switch read-only mode globally or for one agent
+  integrations refresh                      Re-check integration permissions now
+
+Flags:
+`
+
+// runAdmin runs "arbetern admin" with args and returns the exit code.
+func runAdmin(args []string) int {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), adminUsage)
+		fs.PrintDefaults()
+	}
+	baseURL := fs.String("url", envOr("ARBETERN_URL", "http://localhost:8080"), "server URL (env ARBETERN_URL)")
+	certFile := fs.String("cert", os.Getenv("ARBETERN_CLIENT_CERT"), "client certificate for mTLS (env ARBETERN_CLIENT_CERT)")
+	keyFile := fs.String("key", os.Getenv("ARBETERN_CLIENT_KEY"), "client key for mTLS (env ARBETERN_CLIENT_KEY)")
+	caFile := fs.String("cacert", os.Getenv("ARBETERN_CA_CERT"), "CA bundle to verify the server with (env ARBETERN_CA_CERT)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	c, err := newAdminClient(*baseURL, *certFile, *keyFile, *caFile)
+	if err == nil {
+		err = c.run(fs.Arg(0), fs.Args()[1:])
+	}
+	if errors.Is(err, errAdminUsage) {
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "arbetern admin: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// errAdminUsage reports an unknown command or bad arguments.
+var errAdminUsage = errors.New("usage")
+
+// adminClient calls the server's /api endpoints.
+type adminClient struct {
+	base string
+	http *http.Client
+	out  io.Writer
+}
+
+func newAdminClient(baseURL, certFile, keyFile, caFile string) (*adminClient, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no PEM certificates", caFile)
+		}
+	}
+	return &adminClient{
+		base: strings.TrimRight(baseURL, "/"),
+		http: &http.Client{Timeout: 2 * time.Minute, Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+		out:  os.Stdout,
+	}, nil
+}
+
+func (c *adminClient) run(cmd string, args []string) error {
+	switch cmd {
+	case "agents":
+		return c.agents()
+	case "reload":
+		if len(args) > 1 {
+			return errAdminUsage
+		}
+		q := url.Values{}
+		if len(args) == 1 {
+			q.Set("agent", args[0])
+		}
+		return c.print(http.MethodPost, "/api/prompts/reload", q, nil)
+	case "prompt-history":
+		if len(args) != 1 {
+			return errAdminUsage
+		}
+		return c.promptHistory(args[0])
+	case "rollback":
+		if len(args) != 2 {
+			return errAdminUsage
+		}
+		if _, err := strconv.Atoi(args[1]); err != nil {
+			return errAdminUsage
+		}
+		return c.print(http.MethodPost, "/api/agents/"+url.PathEscape(args[0])+"/prompt-history/"+args[1]+"/rollback", nil, nil)
+	case "sync":
+		if len(args) != 0 {
+			return errAdminUsage
+		}
+		return c.print(http.MethodPost, "/api/agents/sync", nil, nil)
+	case "sessions":
+		if len(args) == 0 {
+			return c.print(http.MethodGet, "/api/sessions", nil, nil)
+		}
+		if args[0] != "close" {
+			return errAdminUsage
+		}
+		fs := flag.NewFlagSet("sessions close", flag.ContinueOnError)
+		agent := fs.String("agent", "", "close only this agent's sessions")
+		channel := fs.String("channel", "", "channel of the thread to close")
+		thread := fs.String("thread", "", "timestamp of the thread to close")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 || (*channel == "") != (*thread == "") {
+			return errAdminUsage
+		}
+		q := url.Values{}
+		for k, v := range map[string]string{"agent": *agent, "channel": *channel, "thread": *thread} {
+			if v != "" {
+				q.Set(k, v)
+			}
+		}
+		return c.print(http.MethodDelete, "/api/sessions", q, nil)
+	case "spend-caps":
+		if len(args) == 0 {
+			return c.print(http.MethodGet, "/api/spend-caps", nil, nil)
+		}
+		method := http.MethodPut
+		switch args[0] {
+		case "exempt":
+		case "unexempt":
+			method = http.MethodDelete
+		default:
+			return errAdminUsage
+		}
+		fs := flag.NewFlagSet("spend-caps "+args[0], flag.ContinueOnError)
+		user := fs.String("user", "", "user ID")
+		channel := fs.String("channel", "", "channel ID")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 || (*user == "") == (*channel == "") {
+			return errAdminUsage
+		}
+		q := url.Values{}
+		if *user != "" {
+			q.Set("user", *user)
+		} else {
+			q.Set("channel", *channel)
+		}
+		return c.print(method, "/api/spend-caps", q, nil)
+	case "read-only":
+		fs := flag.NewFlagSet("read-only", flag.ContinueOnError)
+		agent := fs.String("agent", "", "switch only this agent")
+		if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+			return errAdminUsage
+		}
+		if fs.NArg() == 0 {
+			return c.print(http.MethodGet, "/api/read-only", nil, nil)
+		}
+		var on bool
+		switch fs.Arg(0) {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return errAdminUsage
+		}
+		q := url.Values{}
+		if *agent != "" {
+			q.Set("agent", *agent)
+		}
+		return c.print(http.MethodPut, "/api/read-only", q, map[string]bool{"enabled": on})
+	case "integrations":
+		if len(args) != 1 || args[0] != "refresh" {
+			return errAdminUsage
+		}
+		return c.print(http.MethodPost, "/api/integrations/refresh", nil, nil)
+	}
+	return errAdminUsage
+}
+
+// agents prints the agents as a table.
+func (c *adminClient) agents() error {
+	body, err := c.do(http.MethodGet, "/api/agents", nil, nil)
+	if err != nil {
+		return err
+	}
+	var agents []prompts.AgentConfig
+	if err := json.Unmarshal(body, &agents); err != nil {
+		return fmt.Errorf("decoding agents: %w", err)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPROMPTS\tTOOLS")
+	for _, a := range agents {
+		tools := "all"
+		if len(a.Tools.Allow) > 0 || len(a.Tools.Deny) > 0 {
+			tools = fmt.Sprintf("allow=%v deny=%v", a.Tools.Allow, a.Tools.Deny)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", a.ID, a.Name, len(a.Prompts), tools)
+	}
+	return tw.Flush()
+}
+
+// promptHistory prints an agent's prompt versions as a table.
+func (c *adminClient) promptHistory(agentID string) error {
+	body, err := c.do(http.MethodGet, "/api/agents/"+url.PathEscape(agentID)+"/prompt-history", nil, nil)
+	if err != nil {
+		return err
+	}
+	var versions []prompts.PromptVersion
+	if err := json.Unmarshal(body, &versions); err != nil {
+		return fmt.Errorf("decoding prompt history: %w", err)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tTIME\tAUTHOR\tSOURCE\tHASH\tNOTE")
+	for _, v := range versions {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%.12s\t%s\n", v.Version, v.Time.Local().Format(time.DateTime), v.Author, v.Source, v.Hash, v.Note)
+	}
+	return tw.Flush()
+}
+
+// print calls the API and pretty-prints the JSON response.
+func (c *adminClient) print(method, path string, q url.Values, in any) error {
+	body, err := c.do(method, path, q, in)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if json.Indent(&buf, body, "", "  ") != nil {
+		_, err = c.out.Write(body)
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = buf.WriteTo(c.out)
+	return err
+}
+
+// do sends a request with in (if any) as the JSON body and returns the
+// response body. Non-2xx responses are returned as errors.
+func (c *adminClient) do(method, path string, q url.Values, in any) ([]byte, error) {
+	u := c.base + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var reqBody io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user := os.Getenv("USER"); user != "" {
+		req.Header.Set(AuthorHeader, user)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// envOr returns the environment variable key, or def when it is unset.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,431 @@
+package ado
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+const apiVersion = "7.1"
+
+// Client provides access to the Azure DevOps Boards (work item tracking)
+// REST API of one organization, authenticated with a personal access token.
+type Client struct {
+	orgURL     string // e.g. "https://dev.azure.com/myorg"
+	project    string // default project
+	pat        string
+	httpClient *http.Client
+}
+
+// NewClient creates an Azure DevOps client for the organization at orgURL.
+func NewClient(orgURL, pat, defaultProject string) *Client {
+	return &Client{
+		orgURL:  strings.TrimRight(orgURL, "/"),
+		project: defaultProject,
+		pat:     pat,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "ado"},
+		},
+	}
+}
+
+// DefaultProject returns the configured default project.
+func (c *Client) DefaultProject() string {
+	return c.project
+}
+
+// OrgURL returns the organization URL.
+func (c *Client) OrgURL() string {
+	return c.orgURL
+}
+
+// WorkItem is a Boards work item.
+type WorkItem struct {
+	ID          int
+	Type        string
+	Title       string
+	State       string
+	AssignedTo  string
+	AreaPath    string
+	Iteration   string
+	Tags        []string
+	Priority    int
+	Description string // plain text
+	Project     string
+	Created     time.Time
+	Changed     time.Time
+	URL         string // browser link
+}
+
+// CreateWorkItemInput holds parameters for creating a work item.
+type CreateWorkItemInput struct {
+	Project     string // empty = default project
+	Type        string // "Task", "Bug", "User Story", ...; empty = "Task"
+	Title       string
+	Description string // markdown
+	AssignedTo  string // email or display name known to the organization
+	AreaPath    string
+	Iteration   string
+	Tags        []string
+	Priority    int // 1 (highest) to 4; 0 = project default
+}
+
+// patchOp is one JSON Patch operation of a work item create/update.
+type patchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// fieldOps builds the JSON Patch operations that set fields (reference
+// name → value, e.g. "System.Title").
+func fieldOps(fields map[string]any) []patchOp {
+	ops := make([]patchOp, 0, len(fields))
+	for name, v := range fields {
+		ops = append(ops, patchOp{Op: "add", Path: "/fields/" + name, Value: v})
+	}
+	return ops
+}
+
+// CreateWorkItem creates a work item and returns it.
+func (c *Client) CreateWorkItem(ctx context.Context, input CreateWorkItemInput) (*WorkItem, error) {
+	project := input.Project
+	if project == "" {
+		project = c.project
+	}
+	if project == "" {
+		return nil, fmt.Errorf("no project given and no default project configured")
+	}
+	wiType := input.Type
+	if wiType == "" {
+		wiType = "Task"
+	}
+	fields := map[string]any{"System.Title": input.Title}
+	if input.Description != "" {
+		// Bugs show repro steps instead of the description.
+		if strings.EqualFold(wiType, "Bug") {
+			fields["Microsoft.VSTS.TCM.ReproSteps"] = MarkdownToHTML(input.Description)
+		} else {
+			fields["System.Description"] = MarkdownToHTML(input.Description)
+		}
+	}
+	if input.AssignedTo != "" {
+		fields["System.AssignedTo"] = input.AssignedTo
+	}
+	if input.AreaPath != "" {
+		fields["System.AreaPath"] = input.AreaPath
+	}
+	if input.Iteration != "" {
+		fields["System.IterationPath"] = input.Iteration
+	}
+	if len(input.Tags) > 0 {
+		fields["System.Tags"] = strings.Join(input.Tags, "; ")
+	}
+	if input.Priority > 0 {
+		fields["Microsoft.VSTS.Common.Priority"] = input.Priority
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/_apis/wit/workitems/$%s?api-version=%s", c.orgURL, url.PathEscape(project), url.PathEscape(wiType), apiVersion)
+	var raw rawWorkItem
+	if err := c.do(ctx, http.MethodPost, endpoint, "application/json-patch+json", fieldOps(fields), &raw); err != nil {
+		return nil, err
+	}
+	return raw.workItem(), nil
+}
+
+// UpdateWorkItem sets fields (reference name → value) on a work item.
+func (c *Client) UpdateWorkItem(ctx context.Context, id int, fields map[string]any) (*WorkItem, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wit/workitems/%d?api-version=%s", c.orgURL, id, apiVersion)
+	var raw rawWorkItem
+	if err := c.do(ctx, http.MethodPatch, endpoint, "application/json-patch+json", fieldOps(fields), &raw); err != nil {
+		return nil, err
+	}
+	return raw.workItem(), nil
+}
+
+// GetWorkItem fetches a single work item.
+func (c *Client) GetWorkItem(ctx context.Context, id int) (*WorkItem, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wit/workitems/%d?api-version=%s", c.orgURL, id, apiVersion)
+	var raw rawWorkItem
+	if err := c.do(ctx, http.MethodGet, endpoint, "", nil, &raw); err != nil {
+		return nil, err
+	}
+	return raw.workItem(), nil
+}
+
+// QueryWorkItems runs a WIQL query in project (empty = default project) and
+// returns up to top matching work items, in query order.
+func (c *Client) QueryWorkItems(ctx context.Context, project, wiql string, top int) ([]WorkItem, error) {
+	if project == "" {
+		project = c.project
+	}
+	if top <= 0 || top > 200 {
+		top = 50
+	}
+	base := c.orgURL
+	if project != "" {
+		base += "/" + url.PathEscape(project)
+	}
+	endpoint := fmt.Sprintf("%s/_apis/wit/wiql?api-version=%s&$top=%d", base, apiVersion, top)
+	var result struct {
+		WorkItems []struct {
+			ID int `json:"id"`
+		} `json:"workItems"`
+	}
+	if err := c.do(ctx, http.MethodPost, endpoint, "application/json", map[string]string{"query": wiql}, &result); err != nil {
+		return nil, err
+	}
+	if len(result.WorkItems) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(result.WorkItems))
+	for _, w := range result.WorkItems {
+		ids = append(ids, strconv.Itoa(w.ID))
+	}
+	endpoint = fmt.Sprintf("%s/_apis/wit/workitems?ids=%s&api-version=%s", c.orgURL, strings.Join(ids, ","), apiVersion)
+	var batch struct {
+		Value []rawWorkItem `json:"value"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, "", nil, &batch); err != nil {
+		return nil, err
+	}
+	items := make([]WorkItem, 0, len(batch.Value))
+	for _, raw := range batch.Value {
+		items = append(items, *raw.workItem())
+	}
+	return items, nil
+}
+
+// ListProjects returns the names of the organization's projects.
+func (c *Client) ListProjects(ctx context.Context) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/_apis/projects?api-version=%s&$top=500", c.orgURL, apiVersion)
+	var result struct {
+		Value []struct {
+			Name string `json:"name"`
+		} `json:"value"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, "", nil, &result); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(result.Value))
+	for _, p := range result.Value {
+		names = append(names, p.Name)
+	}
+	return names, nil
+}
+
+// rawWorkItem is the API representation of a work item.
+type rawWorkItem struct {
+	ID     int                        `json:"id"`
+	Fields map[string]json.RawMessage `json:"fields"`
+	Links  struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"_links"`
+}
+
+func (r rawWorkItem) str(name string) string {
+	var s string
+	if err := json.Unmarshal(r.Fields[name], &s); err == nil {
+		return s
+	}
+	// Identity fields are objects with a displayName.
+	var ident struct {
+		DisplayName string `json:"displayName"`
+		UniqueName  string `json:"uniqueName"`
+	}
+	if err := json.Unmarshal(r.Fields[name], &ident); err == nil {
+		if ident.UniqueName != "" && ident.UniqueName != ident.DisplayName {
+			return fmt.Sprintf("%s <%s>", ident.DisplayName, ident.UniqueName)
+		}
+		return ident.DisplayName
+	}
+	return ""
+}
+
+func (r rawWorkItem) workItem() *WorkItem {
+	w := &WorkItem{
+		ID:         r.ID,
+		Type:       r.str("System.WorkItemType"),
+		Title:      r.str("System.Title"),
+		State:      r.str("System.State"),
+		AssignedTo: r.str("System.AssignedTo"),
+		AreaPath:   r.str("System.AreaPath"),
+		Iteration:  r.str("System.IterationPath"),
+		Project:    r.str("System.TeamProject"),
+		URL:        r.Links.HTML.Href,
+	}
+	desc := r.str("System.Description")
+	if desc == "" {
+		desc = r.str("Microsoft.VSTS.TCM.ReproSteps")
+	}
+	w.Description = HTMLToText(desc)
+	for _, t := range strings.Split(r.str("System.Tags"), ";") {
+		if t = strings.TrimSpace(t); t != "" {
+			w.Tags = append(w.Tags, t)
+		}
+	}
+	_ = json.Unmarshal(r.Fields["Microsoft.VSTS.Common.Priority"], &w.Priority)
+	w.Created, _ = time.Parse(time.RFC3339, r.str("System.CreatedDate"))
+	w.Changed, _ = time.Parse(time.RFC3339, r.str("System.ChangedDate"))
+	return w
+}
+
+// do sends a request with a JSON body (when body is non-nil) and decodes
+// the JSON response into out (when non-nil).
+func (c *Client) do(ctx context.Context, method, endpoint, contentType string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+c.pat)))
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("azure devops API error (HTTP %d): %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("azure devops API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------------
+// Formatting helpers
+// --------------------------------------------------------------------------
+
+var (
+	mdBold   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	htmlTag  = regexp.MustCompile(`<[^>]*>`)
+	htmlGaps = regexp.MustCompile(`\n{3,}`)
+)
+
+// MarkdownToHTML converts the markdown the model writes (headings, bullet
+// and numbered lists, fenced code, bold, inline code, links) to the HTML
+// that work item rich-text fields expect.
+func MarkdownToHTML(md string) string {
+	var sb strings.Builder
+	list := ""
+	closeList := func() {
+		if list != "" {
+			sb.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			sb.WriteString("<" + tag + ">")
+			list = tag
+		}
+	}
+	inline := func(s string) string {
+		s = html.EscapeString(s)
+		s = mdLink.ReplaceAllString(s, `<a href="$2">$1</a>`)
+		s = mdBold.ReplaceAllString(s, "<b>$1</b>")
+		return mdCode.ReplaceAllString(s, "<code>$1</code>")
+	}
+
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " ")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			closeList()
+			sb.WriteString("<pre><code>")
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				sb.WriteString(html.EscapeString(lines[i]) + "\n")
+			}
+			sb.WriteString("</code></pre>")
+		case trimmed == "":
+			closeList()
+		case strings.HasPrefix(trimmed, "#"):
+			closeList()
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level > 6 {
+				level = 6
+			}
+			fmt.Fprintf(&sb, "<h%d>%s</h%d>", level, inline(strings.TrimSpace(trimmed[level:])), level)
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			openList("ul")
+			sb.WriteString("<li>" + inline(trimmed[2:]) + "</li>")
+		case orderedItem(trimmed) != "":
+			openList("ol")
+			sb.WriteString("<li>" + inline(orderedItem(trimmed)) + "</li>")
+		default:
+			closeList()
+			sb.WriteString("<p>" + inline(trimmed) + "</p>")
+		}
+	}
+	closeList()
+	return sb.String()
+}
+
+// orderedItem returns the text of a "1. item" or "1) item" line, or "".
+func orderedItem(line string) string {
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i == 0 || i+1 >= len(line) || (line[i] != '.' && line[i] != ')') || line[i+1] != ' ' {
+		return ""
+	}
+	return strings.TrimSpace(line[i+2:])
+}
+
+// HTMLToText strips a rich-text field down to readable plain text.
+func HTMLToText(s string) string {
+	for _, br := range []string{"<br>", "<br/>", "<br />", "</p>", "</div>", "</li>", "</h1>", "</h2>", "</h3>", "</h4>", "</pre>"} {
+		s = strings.ReplaceAll(s, br, br+"\n")
+	}
+	s = strings.ReplaceAll(s, "<li>", "<li>- ")
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, ""))
+	return strings.TrimSpace(htmlGaps.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,339 @@
+// Package agentsync keeps the local agents directory in sync with a
+// directory of a Git repository, so agent prompts and settings are reviewed
+// through pull requests instead of baked into the image. The repository is
+// read through the GitHub or GitLab API (head commit, then a tarball of
+// it), which needs no git binary.
+package agentsync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/fsutil"
+)
+
+const (
+	// maxArchiveBytes bounds the compressed archive downloaded per sync.
+	maxArchiveBytes = 100 << 20
+	// maxFileBytes bounds a single file copied from the repository.
+	maxFileBytes = 5 << 20
+)
+
+// Commit is the repository commit the directory was synced to.
+type Commit struct {
+	SHA     string `json:"sha"`
+	Author  string `json:"author"`
+	Message string `json:"message"` // first line
+}
+
+// Short returns the abbreviated SHA.
+func (c Commit) Short() string {
+	return c.SHA[:min(7, len(c.SHA))]
+}
+
+// Syncer mirrors a directory of a repository branch into a local directory.
+// Safe for concurrent use; syncs are serialized.
+type Syncer struct {
+	provider string // "github" or "gitlab"
+	api      string // API base URL
+	repo     string // owner/name (GitHub) or group/.../project (GitLab)
+	branch   string
+	token    string
+	subdir   string
+	dir      string
+	http     *http.Client
+
+	mu   sync.Mutex
+	head Commit // last commit synced
+}
+
+// New returns a Syncer for the repository at rawURL (https://host/owner/repo,
+// optionally ending in .git). provider is "github" or "gitlab"; when empty
+// it is "gitlab" for hosts containing "gitlab" and "github" otherwise.
+// subdir is the directory within the repository that holds the agents
+// ("" = the repository root); dir is the local directory it is copied to.
+func New(rawURL, provider, branch, token, subdir, dir string) (*Syncer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid repository URL %q", rawURL)
+	}
+	repo := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if strings.Count(repo, "/") < 1 {
+		return nil, fmt.Errorf("repository URL %q has no owner/name path", rawURL)
+	}
+	if provider == "" {
+		provider = "github"
+		if strings.Contains(u.Host, "gitlab") {
+			provider = "gitlab"
+		}
+	}
+	s := &Syncer{
+		provider: provider,
+		repo:     repo,
+		branch:   branch,
+		token:    token,
+		subdir:   strings.Trim(path.Clean("/"+subdir), "/"),
+		dir:      dir,
+		http:     &http.Client{Timeout: 2 * time.Minute},
+	}
+	switch provider {
+	case "github":
+		s.api = u.Scheme + "://" + u.Host + "/api/v3"
+		if u.Host == "github.com" {
+			s.api = "https://api.github.com"
+		}
+	case "gitlab":
+		s.api = u.Scheme + "://" + u.Host + "/api/v4"
+	default:
+		return nil, fmt.Errorf("unknown provider %q: must be github or gitlab", provider)
+	}
+	if s.branch == "" {
+		s.branch = "main"
+	}
+	return s, nil
+}
+
+// Dir returns the local directory.
+func (s *Syncer) Dir() string { return s.dir }
+
+// Head returns the last commit synced.
+func (s *Syncer) Head() Commit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head
+}
+
+// Matches reports whether a push to branch of fullName (owner/name) is a
+// push to the synced branch.
+func (s *Syncer) Matches(fullName, branch string) bool {
+	return strings.EqualFold(fullName, s.repo) && branch == s.branch
+}
+
+// String describes the source, e.g. "org/agents@main:agents".
+func (s *Syncer) String() string {
+	src := s.repo + "@" + s.branch
+	if s.subdir != "" {
+		src += ":" + s.subdir
+	}
+	return src
+}
+
+// Sync brings the directory up to date with the branch head. changed is
+// false when the head was already synced.
+func (s *Syncer) Sync(ctx context.Context) (head Commit, changed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	head, err = s.headCommit(ctx)
+	if err != nil {
+		return s.head, false, err
+	}
+	if head.SHA == s.head.SHA {
+		return head, false, nil
+	}
+	files, err := s.download(ctx, head.SHA)
+	if err != nil {
+		return s.head, false, err
+	}
+	if err := s.apply(files); err != nil {
+		return s.head, false, err
+	}
+	s.head = head
+	return head, true, nil
+}
+
+func (s *Syncer) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.api+endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		if s.provider == "gitlab" {
+			req.Header.Set("PRIVATE-TOKEN", s.token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+s.token)
+		}
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s: %s", endpoint, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// headCommit returns the branch's latest commit.
+func (s *Syncer) headCommit(ctx context.Context) (Commit, error) {
+	var c Commit
+	if s.provider == "gitlab" {
+		resp, err := s.get(ctx, "/projects/"+url.PathEscape(s.repo)+"/repository/commits/"+url.PathEscape(s.branch))
+		if err != nil {
+			return c, fmt.Errorf("failed to get head of %s: %w", s, err)
+		}
+		defer resp.Body.Close()
+		var out struct {
+			ID         string `json:"id"`
+			Title      string `json:"title"`
+			AuthorName string `json:"author_name"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return c, fmt.Errorf("failed to decode head of %s: %w", s, err)
+		}
+		return Commit{SHA: out.ID, Author: out.AuthorName, Message: out.Title}, nil
+	}
+
+	resp, err := s.get(ctx, "/repos/"+s.repo+"/commits/"+url.PathEscape(s.branch))
+	if err != nil {
+		return c, fmt.Errorf("failed to get head of %s: %w", s, err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		SHA    string `json:"sha"`
+		Commit struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name string `json:"name"`
+			} `json:"author"`
+		} `json:"commit"`
+		Author *struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return c, fmt.Errorf("failed to decode head of %s: %w", s, err)
+	}
+	c = Commit{SHA: out.SHA, Author: out.Commit.Author.Name}
+	c.Message, _, _ = strings.Cut(out.Commit.Message, "\n")
+	if out.Author != nil && out.Author.Login != "" {
+		c.Author = out.Author.Login
+	}
+	return c, nil
+}
+
+// download fetches the tarball of sha and returns the files under subdir,
+// keyed by their slash-separated path relative to it.
+func (s *Syncer) download(ctx context.Context, sha string) (map[string][]byte, error) {
+	endpoint := "/repos/" + s.repo + "/tarball/" + sha
+	if s.provider == "gitlab" {
+		endpoint = "/projects/" + url.PathEscape(s.repo) + "/repository/archive.tar.gz?sha=" + url.QueryEscape(sha)
+	}
+	resp, err := s.get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", s, err)
+	}
+	defer resp.Body.Close()
+	gz, err := gzip.NewReader(io.LimitReader(resp.Body, maxArchiveBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive of %s: %w", s, err)
+	}
+	prefix := ""
+	if s.subdir != "" {
+		prefix = s.subdir + "/"
+	}
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive of %s: %w", s, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Archives wrap everything in one top-level directory.
+		_, name, ok := strings.Cut(hdr.Name, "/")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(name, prefix)
+		if rel == "" || !fs.ValidPath(rel) {
+			continue
+		}
+		if hdr.Size > maxFileBytes {
+			return nil, fmt.Errorf("%s in %s is larger than %d bytes", name, s, maxFileBytes)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", name, s, err)
+		}
+		files[rel] = data
+	}
+	for rel := range files {
+		if path.Base(rel) == "prompts.yaml" && strings.Count(rel, "/") == 1 {
+			return files, nil
+		}
+	}
+	return nil, fmt.Errorf("%s contains no agents (no <agent>/prompts.yaml)", s)
+}
+
+// apply writes files into the directory and removes files that are no
+// longer in the repository. Each file is replaced atomically, so readers
+// never see a partial file, and the directory itself stays in place (it is
+// often a volume mount).
+func (s *Syncer) apply(files map[string][]byte) error {
+	for rel, data := range files {
+		dst := filepath.Join(s.dir, filepath.FromSlash(rel))
+		if current, err := os.ReadFile(dst); err == nil && bytes.Equal(current, data) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", rel, err)
+		}
+		if err := fsutil.WriteFileAtomic(dst, data, 0o644); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", rel, err)
+		}
+	}
+
+	var stale, dirs []string
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == s.dir {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+		} else if _, ok := files[filepath.ToSlash(rel)]; !ok {
+			stale = append(stale, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", s.dir, err)
+	}
+	for _, p := range stale {
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+	}
+	// Deepest first; non-empty directories stay.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+	return nil
+}
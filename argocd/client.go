@@ -0,0 +1,438 @@
+package argocd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// Client provides access to the Argo CD REST API.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates an Argo CD client. baseURL is the Argo CD server URL
+// (e.g. "https://argocd.example.com"); token is an API token of a local
+// account or project role.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "argocd"},
+		},
+	}
+}
+
+// Application is an Argo CD application and its sync and health status.
+type Application struct {
+	Name           string
+	Project        string
+	RepoURL        string
+	Path           string
+	TargetRevision string
+	Cluster        string
+	Namespace      string
+	SyncStatus     string // Synced, OutOfSync or Unknown
+	SyncRevision   string
+	Health         string // Healthy, Progressing, Degraded, Suspended, Missing or Unknown
+	HealthMessage  string
+	AutoSync       bool
+	Conditions     []string
+	Operation      *Operation // last (or running) sync operation
+	Resources      []Resource
+}
+
+// Operation is the state of a sync operation.
+type Operation struct {
+	Phase      string // Running, Succeeded, Failed, Error or Terminating
+	Message    string
+	Revision   string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	FailedSync []string // resources whose sync failed, with messages
+}
+
+// Resource is a Kubernetes resource managed by an application.
+type Resource struct {
+	Group         string
+	Kind          string
+	Namespace     string
+	Name          string
+	SyncStatus    string
+	Health        string
+	HealthMessage string
+}
+
+// ResourceDiff is the difference between a resource's live and desired state.
+type ResourceDiff struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Changes   []string // "path: live → desired" lines; "+path" / "-path" for added or removed fields
+	Created   bool     // not live yet; will be created
+	Deleted   bool     // live but no longer in Git; pruned on sync with prune
+}
+
+// ListApplications returns applications, optionally filtered by project and
+// a name substring, sorted by name.
+func (c *Client) ListApplications(ctx context.Context, project, search string) ([]Application, error) {
+	q := url.Values{}
+	if project != "" {
+		q.Set("projects", project)
+	}
+	var resp struct {
+		Items []application `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/applications?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	var out []Application
+	for _, a := range resp.Items {
+		if search != "" && !strings.Contains(strings.ToLower(a.Metadata.Name), strings.ToLower(search)) {
+			continue
+		}
+		out = append(out, a.toApplication())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// GetApplication returns one application with its resources.
+func (c *Client) GetApplication(ctx context.Context, name string) (*Application, error) {
+	var a application
+	if err := c.do(ctx, http.MethodGet, "/api/v1/applications/"+url.PathEscape(name), nil, &a); err != nil {
+		return nil, err
+	}
+	app := a.toApplication()
+	return &app, nil
+}
+
+// Diff returns the resources whose live state differs from the desired
+// state in Git.
+func (c *Client) Diff(ctx context.Context, name string) ([]ResourceDiff, error) {
+	var resp struct {
+		Items []struct {
+			Group               string `json:"group"`
+			Kind                string `json:"kind"`
+			Namespace           string `json:"namespace"`
+			Name                string `json:"name"`
+			NormalizedLiveState string `json:"normalizedLiveState"`
+			PredictedLiveState  string `json:"predictedLiveState"`
+			TargetState         string `json:"targetState"`
+			LiveState           string `json:"liveState"`
+			Modified            bool   `json:"modified"`
+		} `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/applications/"+url.PathEscape(name)+"/managed-resources", nil, &resp); err != nil {
+		return nil, err
+	}
+	var out []ResourceDiff
+	for _, it := range resp.Items {
+		live, desired := it.NormalizedLiveState, it.PredictedLiveState
+		if live == "" {
+			live = it.LiveState
+		}
+		if desired == "" {
+			desired = it.TargetState
+		}
+		d := ResourceDiff{Kind: it.Kind, Namespace: it.Namespace, Name: it.Name}
+		switch {
+		case isNull(live) && isNull(desired):
+			continue
+		case isNull(live):
+			d.Created = true
+		case isNull(desired):
+			d.Deleted = true
+		default:
+			if !it.Modified {
+				continue
+			}
+			changes, err := diffJSON(live, desired)
+			if err != nil {
+				return nil, fmt.Errorf("diff %s/%s: %w", it.Kind, it.Name, err)
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			d.Changes = changes
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Sync starts a sync of an application to its target revision and returns
+// the application with the new operation state.
+func (c *Client) Sync(ctx context.Context, name string, prune bool) (*Application, error) {
+	body := map[string]any{"prune": prune}
+	var a application
+	if err := c.do(ctx, http.MethodPost, "/api/v1/applications/"+url.PathEscape(name)+"/sync", body, &a); err != nil {
+		return nil, err
+	}
+	app := a.toApplication()
+	return &app, nil
+}
+
+// AppURL returns the Argo CD UI URL of an application.
+func (c *Client) AppURL(name string) string {
+	return c.baseURL + "/applications/" + url.PathEscape(name)
+}
+
+func isNull(s string) bool {
+	return s == "" || s == "null"
+}
+
+// --------------------------------------------------------------------------
+// Diff
+// --------------------------------------------------------------------------
+
+// ignoredDiffPaths are noisy fields that never matter for a sync.
+var ignoredDiffPaths = []string{"metadata.managedFields", "metadata.resourceVersion", "metadata.generation", "metadata.uid", "metadata.creationTimestamp", "status"}
+
+// diffJSON compares two JSON documents field by field and returns one line
+// per changed leaf, sorted by path.
+func diffJSON(live, desired string) ([]string, error) {
+	var l, d any
+	if err := json.Unmarshal([]byte(live), &l); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(desired), &d); err != nil {
+		return nil, err
+	}
+	lf, df := map[string]string{}, map[string]string{}
+	flatten("", l, lf)
+	flatten("", d, df)
+
+	var out []string
+	for p, dv := range df {
+		if ignoredPath(p) {
+			continue
+		}
+		lv, ok := lf[p]
+		switch {
+		case !ok:
+			out = append(out, fmt.Sprintf("+%s: %s", p, dv))
+		case lv != dv:
+			out = append(out, fmt.Sprintf("%s: %s → %s", p, lv, dv))
+		}
+	}
+	for p, lv := range lf {
+		if _, ok := df[p]; !ok && !ignoredPath(p) {
+			out = append(out, fmt.Sprintf("-%s: %s", p, lv))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.TrimLeft(out[i], "+-") < strings.TrimLeft(out[j], "+-") })
+	return out, nil
+}
+
+func ignoredPath(p string) bool {
+	for _, ig := range ignoredDiffPaths {
+		if p == ig || strings.HasPrefix(p, ig+".") || strings.HasPrefix(p, ig+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten records every leaf of v under its dotted path, e.g.
+// "spec.template.spec.containers[0].image".
+func flatten(prefix string, v any, out map[string]string) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			flatten(p, child, out)
+		}
+	case []any:
+		for i, child := range t {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	default:
+		b, _ := json.Marshal(t)
+		s := string(b)
+		if len(s) > 200 {
+			s = s[:200] + "…"
+		}
+		out[prefix] = s
+	}
+}
+
+// --------------------------------------------------------------------------
+// API types
+// --------------------------------------------------------------------------
+
+type application struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Project string `json:"project"`
+		Source  *struct {
+			RepoURL        string `json:"repoURL"`
+			Path           string `json:"path"`
+			Chart          string `json:"chart"`
+			TargetRevision string `json:"targetRevision"`
+		} `json:"source"`
+		Destination struct {
+			Server    string `json:"server"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"destination"`
+		SyncPolicy *struct {
+			Automated *struct{} `json:"automated"`
+		} `json:"syncPolicy"`
+	} `json:"spec"`
+	Status struct {
+		Sync struct {
+			Status   string `json:"status"`
+			Revision string `json:"revision"`
+		} `json:"sync"`
+		Health struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"health"`
+		Conditions []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+		OperationState *struct {
+			Phase      string    `json:"phase"`
+			Message    string    `json:"message"`
+			StartedAt  time.Time `json:"startedAt"`
+			FinishedAt time.Time `json:"finishedAt"`
+			SyncResult *struct {
+				Revision  string `json:"revision"`
+				Resources []struct {
+					Kind    string `json:"kind"`
+					Name    string `json:"name"`
+					Status  string `json:"status"`
+					Message string `json:"message"`
+				} `json:"resources"`
+			} `json:"syncResult"`
+		} `json:"operationState"`
+		Resources []struct {
+			Group     string `json:"group"`
+			Kind      string `json:"kind"`
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+			Status    string `json:"status"`
+			Health    *struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"health"`
+		} `json:"resources"`
+	} `json:"status"`
+}
+
+func (a application) toApplication() Application {
+	out := Application{
+		Name:          a.Metadata.Name,
+		Project:       a.Spec.Project,
+		Cluster:       a.Spec.Destination.Name,
+		Namespace:     a.Spec.Destination.Namespace,
+		SyncStatus:    a.Status.Sync.Status,
+		SyncRevision:  a.Status.Sync.Revision,
+		Health:        a.Status.Health.Status,
+		HealthMessage: a.Status.Health.Message,
+		AutoSync:      a.Spec.SyncPolicy != nil && a.Spec.SyncPolicy.Automated != nil,
+	}
+	if out.Cluster == "" {
+		out.Cluster = a.Spec.Destination.Server
+	}
+	if s := a.Spec.Source; s != nil {
+		out.RepoURL, out.Path, out.TargetRevision = s.RepoURL, s.Path, s.TargetRevision
+		if out.Path == "" {
+			out.Path = s.Chart
+		}
+	}
+	for _, cond := range a.Status.Conditions {
+		out.Conditions = append(out.Conditions, cond.Type+": "+cond.Message)
+	}
+	if op := a.Status.OperationState; op != nil {
+		o := &Operation{Phase: op.Phase, Message: op.Message, StartedAt: op.StartedAt, FinishedAt: op.FinishedAt}
+		if sr := op.SyncResult; sr != nil {
+			o.Revision = sr.Revision
+			for _, r := range sr.Resources {
+				if r.Status != "" && r.Status != "Synced" && r.Status != "Pruned" {
+					o.FailedSync = append(o.FailedSync, fmt.Sprintf("%s/%s %s: %s", r.Kind, r.Name, r.Status, r.Message))
+				}
+			}
+		}
+		out.Operation = o
+	}
+	for _, r := range a.Status.Resources {
+		res := Resource{Group: r.Group, Kind: r.Kind, Namespace: r.Namespace, Name: r.Name, SyncStatus: r.Status}
+		if r.Health != nil {
+			res.Health, res.HealthMessage = r.Health.Status, r.Health.Message
+		}
+		out.Resources = append(out.Resources, res)
+	}
+	return out
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("argocd API error (HTTP %d): %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("argocd API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
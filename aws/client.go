@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// Client calls a small set of read-only AWS APIs (ECS, ECR, CloudWatch,
+// CloudWatch Logs, Lambda) in one region, signing requests with Signature
+// Version 4.
+type Client struct {
+	region     string
+	cred       *Credential
+	httpClient *http.Client
+}
+
+// NewClient creates an AWS client for region.
+func NewClient(region string, cred *Credential) *Client {
+	return &Client{
+		region: region,
+		cred:   cred,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "aws"},
+		},
+	}
+}
+
+// Region returns the client's region.
+func (c *Client) Region() string { return c.region }
+
+// endpointPrefixes maps signing names to endpoint host prefixes where the
+// two differ.
+var endpointPrefixes = map[string]string{"ecr": "api.ecr"}
+
+// globalServices maps services with a single global endpoint to the region
+// that serves (and signs) them.
+var globalServices = map[string]string{"ce": "us-east-1"}
+
+func (c *Client) endpoint(service string) string {
+	region := c.regionFor(service)
+	if p, ok := endpointPrefixes[service]; ok {
+		service = p
+	}
+	return "https://" + service + "." + region + ".amazonaws.com"
+}
+
+func (c *Client) regionFor(service string) string {
+	if r, ok := globalServices[service]; ok {
+		return r
+	}
+	return c.region
+}
+
+// doJSON calls an AWS JSON 1.1 protocol API (e.g. ECS) action.
+func (c *Client) doJSON(ctx context.Context, service, target string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(service)+"/", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	return c.send(req, data, service, out, json.Unmarshal)
+}
+
+// doQuery calls an AWS Query protocol API (e.g. CloudWatch) action and
+// decodes the XML response.
+func (c *Client) doQuery(ctx context.Context, service, version, action string, params url.Values, out any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("Action", action)
+	params.Set("Version", version)
+	data := []byte(params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(service)+"/", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return c.send(req, data, service, out, xml.Unmarshal)
+}
+
+// doREST calls a REST-JSON API (e.g. Lambda) with GET.
+func (c *Client) doREST(ctx context.Context, service, path string, query url.Values, out any) error {
+	u := c.endpoint(service) + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	return c.send(req, nil, service, out, json.Unmarshal)
+}
+
+// Retries follow the SDK's standard retryer: up to maxAttempts attempts for
+// throttling, 5xx and network errors, with jittered exponential backoff.
+const (
+	maxAttempts    = 3
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 20 * time.Second
+)
+
+// throttleCodes are the error codes AWS APIs return when throttling.
+var throttleCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottledException":              true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"RequestLimitExceeded":                   true,
+	"RequestThrottled":                       true,
+	"LimitExceededException":                 true,
+	"SlowDown":                               true,
+}
+
+func (c *Client) send(req *http.Request, body []byte, service string, out any, decode func([]byte, any) error) error {
+	for attempt := 1; ; attempt++ {
+		respBody, retry, err := c.sendOnce(req, body, service)
+		if err == nil {
+			if out == nil {
+				return nil
+			}
+			if err := decode(respBody, out); err != nil {
+				return fmt.Errorf("unmarshal response: %w", err)
+			}
+			return nil
+		}
+		if !retry || attempt >= maxAttempts {
+			return err
+		}
+		select {
+		case <-req.Context().Done():
+			return err
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+// sendOnce signs and sends req once. It returns the response body of a
+// successful call, or the error and whether it is worth retrying.
+func (c *Client) sendOnce(req *http.Request, body []byte, service string) ([]byte, bool, error) {
+	creds, err := c.cred.Retrieve(req.Context())
+	if err != nil {
+		return nil, false, fmt.Errorf("AWS credentials: %w", err)
+	}
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	sign(req, body, creds, service, c.regionFor(service), time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, req.Context().Err() == nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := errorMessage(respBody)
+		code, _, _ := strings.Cut(msg, ":")
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 || throttleCodes[code]
+		return nil, retry, fmt.Errorf("%s API error (HTTP %d): %s", service, resp.StatusCode, msg)
+	}
+	return respBody, false, nil
+}
+
+// backoff returns the jittered delay before retry n (1-based).
+func backoff(n int) time.Duration {
+	d := retryBaseDelay << (n - 1)
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// errorMessage extracts "Code: message" from a JSON or XML AWS error body.
+func errorMessage(body []byte) string {
+	var j struct {
+		Type     string `json:"__type"`
+		Message  string `json:"message"`
+		MessageU string `json:"Message"`
+	}
+	if json.Unmarshal(body, &j) == nil && (j.Message != "" || j.MessageU != "") {
+		code := j.Type[strings.LastIndex(j.Type, "#")+1:]
+		return strings.TrimPrefix(code+": "+j.Message+j.MessageU, ": ")
+	}
+	var x struct {
+		Error struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Error"`
+	}
+	if xml.Unmarshal(body, &x) == nil && x.Error.Code != "" {
+		return x.Error.Code + ": " + x.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
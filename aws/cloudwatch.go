@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const cloudWatchVersion = "2010-08-01"
+
+// Alarm is a CloudWatch metric alarm.
+type Alarm struct {
+	Name        string
+	State       string // OK, ALARM or INSUFFICIENT_DATA
+	Reason      string
+	UpdatedAt   time.Time
+	Namespace   string
+	Metric      string
+	Description string
+}
+
+// AlarmHistory is a state change of an alarm.
+type AlarmHistory struct {
+	Alarm     string
+	Timestamp time.Time
+	Summary   string
+}
+
+// DescribeAlarms returns metric alarms, optionally filtered by state (OK,
+// ALARM, INSUFFICIENT_DATA) and name prefix, most recently changed first.
+func (c *Client) DescribeAlarms(ctx context.Context, state, prefix string, limit int) ([]Alarm, error) {
+	params := url.Values{"MaxRecords": {"100"}, "AlarmTypes.member.1": {"MetricAlarm"}}
+	if state != "" {
+		params.Set("StateValue", state)
+	}
+	if prefix != "" {
+		params.Set("AlarmNamePrefix", prefix)
+	}
+	var alarms []Alarm
+	for {
+		var out struct {
+			Result struct {
+				MetricAlarms []struct {
+					AlarmName             string    `xml:"AlarmName"`
+					AlarmDescription      string    `xml:"AlarmDescription"`
+					StateValue            string    `xml:"StateValue"`
+					StateReason           string    `xml:"StateReason"`
+					StateUpdatedTimestamp time.Time `xml:"StateUpdatedTimestamp"`
+					Namespace             string    `xml:"Namespace"`
+					MetricName            string    `xml:"MetricName"`
+				} `xml:"MetricAlarms>member"`
+				NextToken string `xml:"NextToken"`
+			} `xml:"DescribeAlarmsResult"`
+		}
+		if err := c.doQuery(ctx, "monitoring", cloudWatchVersion, "DescribeAlarms", params, &out); err != nil {
+			return nil, err
+		}
+		for _, a := range out.Result.MetricAlarms {
+			alarms = append(alarms, Alarm{
+				Name:        a.AlarmName,
+				State:       a.StateValue,
+				Reason:      a.StateReason,
+				UpdatedAt:   a.StateUpdatedTimestamp,
+				Namespace:   a.Namespace,
+				Metric:      a.MetricName,
+				Description: a.AlarmDescription,
+			})
+		}
+		if out.Result.NextToken == "" || len(alarms) >= 500 {
+			break
+		}
+		params.Set("NextToken", out.Result.NextToken)
+	}
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i].UpdatedAt.After(alarms[j].UpdatedAt) })
+	if limit > 0 && len(alarms) > limit {
+		alarms = alarms[:limit]
+	}
+	return alarms, nil
+}
+
+// AlarmStateChanges returns the alarm state changes since start, newest
+// first, optionally for one alarm.
+func (c *Client) AlarmStateChanges(ctx context.Context, alarm string, start time.Time, limit int) ([]AlarmHistory, error) {
+	params := url.Values{
+		"HistoryItemType": {"StateUpdate"},
+		"StartDate":       {start.UTC().Format(time.RFC3339)},
+		"EndDate":         {time.Now().UTC().Format(time.RFC3339)},
+		"MaxRecords":      {fmt.Sprint(min(max(limit, 1), 100))},
+		"ScanBy":          {"TimestampDescending"},
+	}
+	if alarm != "" {
+		params.Set("AlarmName", alarm)
+	}
+	var out struct {
+		Result struct {
+			Items []struct {
+				AlarmName       string    `xml:"AlarmName"`
+				Timestamp       time.Time `xml:"Timestamp"`
+				HistorySummary  string    `xml:"HistorySummary"`
+				HistoryItemType string    `xml:"HistoryItemType"`
+			} `xml:"AlarmHistoryItems>member"`
+		} `xml:"DescribeAlarmHistoryResult"`
+	}
+	if err := c.doQuery(ctx, "monitoring", cloudWatchVersion, "DescribeAlarmHistory", params, &out); err != nil {
+		return nil, err
+	}
+	var items []AlarmHistory
+	for _, it := range out.Result.Items {
+		items = append(items, AlarmHistory{Alarm: it.AlarmName, Timestamp: it.Timestamp, Summary: it.HistorySummary})
+	}
+	return items, nil
+}
+
+// Datapoint is one CloudWatch metric statistic sample.
+type Datapoint struct {
+	Timestamp time.Time
+	Sum       float64
+	Average   float64
+	Maximum   float64
+}
+
+// MetricStatistics returns Sum, Average and Maximum of a metric per period,
+// oldest first.
+func (c *Client) MetricStatistics(ctx context.Context, namespace, metric string, dimensions map[string]string, start, end time.Time, period time.Duration) ([]Datapoint, error) {
+	params := url.Values{
+		"Namespace":           {namespace},
+		"MetricName":          {metric},
+		"StartTime":           {start.UTC().Format(time.RFC3339)},
+		"EndTime":             {end.UTC().Format(time.RFC3339)},
+		"Period":              {fmt.Sprint(int(period.Seconds()))},
+		"Statistics.member.1": {"Sum"},
+		"Statistics.member.2": {"Average"},
+		"Statistics.member.3": {"Maximum"},
+	}
+	i := 1
+	for k, v := range dimensions {
+		params.Set(fmt.Sprintf("Dimensions.member.%d.Name", i), k)
+		params.Set(fmt.Sprintf("Dimensions.member.%d.Value", i), v)
+		i++
+	}
+	var out struct {
+		Result struct {
+			Datapoints []struct {
+				Timestamp time.Time `xml:"Timestamp"`
+				Sum       float64   `xml:"Sum"`
+				Average   float64   `xml:"Average"`
+				Maximum   float64   `xml:"Maximum"`
+			} `xml:"Datapoints>member"`
+		} `xml:"GetMetricStatisticsResult"`
+	}
+	if err := c.doQuery(ctx, "monitoring", cloudWatchVersion, "GetMetricStatistics", params, &out); err != nil {
+		return nil, err
+	}
+	points := make([]Datapoint, 0, len(out.Result.Datapoints))
+	for _, d := range out.Result.Datapoints {
+		points = append(points, Datapoint{Timestamp: d.Timestamp, Sum: d.Sum, Average: d.Average, Maximum: d.Maximum})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	return points, nil
+}
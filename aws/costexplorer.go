@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const ceTarget = "AWSInsightsIndexService."
+
+// ceDate is the date format Cost Explorer uses.
+const ceDate = "2006-01-02"
+
+// maxCostPages caps the GetCostAndUsage pages fetched per query.
+const maxCostPages = 10
+
+// CostRow is the unblended cost of one group on one day.
+type CostRow struct {
+	Day       time.Time
+	Key       string // service or account name; empty when not grouped
+	Amount    float64
+	Unit      string // currency, e.g. USD
+	Estimated bool   // the day's costs are not final yet
+}
+
+// Account is a linked account of an AWS Organization.
+type Account struct {
+	ID   string
+	Name string
+}
+
+// CostAndUsage returns daily unblended costs between start and end (end
+// exclusive), grouped by groupBy ("SERVICE", "LINKED_ACCOUNT" or "" for
+// totals) and optionally limited to one linked account. Cost Explorer bills
+// every request, so callers should cache the results.
+func (c *Client) CostAndUsage(ctx context.Context, start, end time.Time, groupBy, accountID string) ([]CostRow, error) {
+	in := map[string]any{
+		"TimePeriod":  map[string]string{"Start": start.Format(ceDate), "End": end.Format(ceDate)},
+		"Granularity": "DAILY",
+		"Metrics":     []string{"UnblendedCost"},
+	}
+	if groupBy != "" {
+		in["GroupBy"] = []map[string]string{{"Type": "DIMENSION", "Key": groupBy}}
+	}
+	if accountID != "" {
+		in["Filter"] = map[string]any{"Dimensions": map[string]any{"Key": "LINKED_ACCOUNT", "Values": []string{accountID}}}
+	}
+
+	type amount struct {
+		Amount string `json:"Amount"`
+		Unit   string `json:"Unit"`
+	}
+	var rows []CostRow
+	names := map[string]string{}
+	for page := 0; page < maxCostPages; page++ {
+		var out struct {
+			ResultsByTime []struct {
+				TimePeriod struct {
+					Start string `json:"Start"`
+				} `json:"TimePeriod"`
+				Total struct {
+					UnblendedCost amount `json:"UnblendedCost"`
+				} `json:"Total"`
+				Groups []struct {
+					Keys    []string `json:"Keys"`
+					Metrics struct {
+						UnblendedCost amount `json:"UnblendedCost"`
+					} `json:"Metrics"`
+				} `json:"Groups"`
+				Estimated bool `json:"Estimated"`
+			} `json:"ResultsByTime"`
+			DimensionValueAttributes []struct {
+				Value      string            `json:"Value"`
+				Attributes map[string]string `json:"Attributes"`
+			} `json:"DimensionValueAttributes"`
+			NextPageToken string `json:"NextPageToken"`
+		}
+		if err := c.doJSON(ctx, "ce", ceTarget+"GetCostAndUsage", in, &out); err != nil {
+			return nil, err
+		}
+		for _, d := range out.DimensionValueAttributes {
+			if n := d.Attributes["description"]; n != "" {
+				names[d.Value] = n
+			}
+		}
+		for _, r := range out.ResultsByTime {
+			day, _ := time.Parse(ceDate, r.TimePeriod.Start)
+			if groupBy == "" {
+				v, _ := strconv.ParseFloat(r.Total.UnblendedCost.Amount, 64)
+				rows = append(rows, CostRow{Day: day, Amount: v, Unit: r.Total.UnblendedCost.Unit, Estimated: r.Estimated})
+				continue
+			}
+			for _, g := range r.Groups {
+				v, _ := strconv.ParseFloat(g.Metrics.UnblendedCost.Amount, 64)
+				key := ""
+				if len(g.Keys) > 0 {
+					key = g.Keys[0]
+				}
+				rows = append(rows, CostRow{Day: day, Key: key, Amount: v, Unit: g.Metrics.UnblendedCost.Unit, Estimated: r.Estimated})
+			}
+		}
+		if out.NextPageToken == "" {
+			break
+		}
+		in["NextPageToken"] = out.NextPageToken
+	}
+	// Show accounts as "name (id)".
+	for i := range rows {
+		if n, ok := names[rows[i].Key]; ok {
+			rows[i].Key = n + " (" + rows[i].Key + ")"
+		}
+	}
+	return rows, nil
+}
+
+// LinkedAccounts returns the accounts that had costs between start and end.
+func (c *Client) LinkedAccounts(ctx context.Context, start, end time.Time) ([]Account, error) {
+	in := map[string]any{
+		"TimePeriod": map[string]string{"Start": start.Format(ceDate), "End": end.Format(ceDate)},
+		"Dimension":  "LINKED_ACCOUNT",
+	}
+	var out struct {
+		DimensionValues []struct {
+			Value      string            `json:"Value"`
+			Attributes map[string]string `json:"Attributes"`
+		} `json:"DimensionValues"`
+	}
+	if err := c.doJSON(ctx, "ce", ceTarget+"GetDimensionValues", in, &out); err != nil {
+		return nil, err
+	}
+	accounts := make([]Account, 0, len(out.DimensionValues))
+	for _, d := range out.DimensionValues {
+		accounts = append(accounts, Account{ID: d.Value, Name: d.Attributes["description"]})
+	}
+	return accounts, nil
+}
@@ -0,0 +1,296 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ecsCredentialsHost = "http://169.254.170.2"
+	imdsBaseURL        = "http://169.254.169.254"
+
+	// credentialRefreshSkew refreshes credentials this long before they expire.
+	credentialRefreshSkew = 5 * time.Minute
+)
+
+// Credentials are AWS access keys, usually temporary.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero = does not expire
+}
+
+// Credential obtains AWS credentials following the default SDK chain:
+//  1. Static keys — AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY (AWS_SESSION_TOKEN)
+//  2. Web identity — AWS_ROLE_ARN, AWS_WEB_IDENTITY_TOKEN_FILE (EKS IRSA)
+//  3. Container credentials — AWS_CONTAINER_CREDENTIALS_RELATIVE_URI / _FULL_URI (ECS task role, EKS Pod Identity)
+//  4. Instance profile — EC2 instance metadata service (IMDSv2)
+//
+// If roleARN is set, the chain's credentials are used to assume that role,
+// e.g. a dedicated read-only role. Credentials are cached and refreshed
+// shortly before expiry. Safe for concurrent use.
+type Credential struct {
+	region     string
+	roleARN    string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	creds Credentials
+}
+
+// NewCredentialFromEnv builds a Credential from the standard AWS_*
+// environment variables. roleARN is optional.
+func NewCredentialFromEnv(region, roleARN string) *Credential {
+	return &Credential{
+		region:     region,
+		roleARN:    roleARN,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Method returns a human-readable name for the credential source in use.
+func (c *Credential) Method() string {
+	var m string
+	switch {
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "":
+		m = "access keys"
+	case os.Getenv("AWS_ROLE_ARN") != "" && os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "":
+		m = "web identity"
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		m = "container credentials"
+	default:
+		m = "instance profile"
+	}
+	if c.roleARN != "" {
+		m += ", assuming " + c.roleARN
+	}
+	return m
+}
+
+// Retrieve returns valid credentials, fetching new ones when the cached
+// credentials are missing or about to expire.
+func (c *Credential) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.creds.AccessKeyID != "" && (c.creds.Expires.IsZero() || time.Now().Add(credentialRefreshSkew).Before(c.creds.Expires)) {
+		return c.creds, nil
+	}
+	creds, err := c.fetchBase(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if c.roleARN != "" {
+		if creds, err = c.assumeRole(ctx, creds); err != nil {
+			return Credentials{}, err
+		}
+	}
+	c.creds = creds
+	return c.creds, nil
+}
+
+func (c *Credential) fetchBase(ctx context.Context) (Credentials, error) {
+	switch {
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "":
+		return Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	case os.Getenv("AWS_ROLE_ARN") != "" && os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "":
+		return c.fetchWebIdentity(ctx)
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		return c.fetchContainer(ctx)
+	default:
+		return c.fetchInstanceProfile(ctx)
+	}
+}
+
+// fetchWebIdentity exchanges the projected service account token for role
+// credentials. The STS call is unsigned.
+func (c *Credential) fetchWebIdentity(ctx context.Context) (Credentials, error) {
+	token, err := os.ReadFile(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read web identity token file: %w", err)
+	}
+	q := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {os.Getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {sessionName()},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.stsURL(), strings.NewReader(q.Encode()))
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to create STS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var resp struct {
+		Result struct {
+			Credentials stsCredentials `xml:"Credentials"`
+		} `xml:"AssumeRoleWithWebIdentityResult"`
+	}
+	if err := c.doXML(req, &resp); err != nil {
+		return Credentials{}, err
+	}
+	return resp.Result.Credentials.toCredentials(), nil
+}
+
+// fetchContainer reads credentials from the ECS or EKS Pod Identity agent.
+func (c *Credential) fetchContainer(ctx context.Context) (Credentials, error) {
+	u := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		u = ecsCredentialsHost + rel
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to create container credentials request: %w", err)
+	}
+	auth := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if f := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); f != "" {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to read container authorization token file: %w", err)
+		}
+		auth = strings.TrimSpace(string(b))
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return c.doCredentialsJSON(req)
+}
+
+// fetchInstanceProfile reads the instance role's credentials from IMDSv2.
+func (c *Credential) fetchInstanceProfile(ctx context.Context) (Credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, imdsBaseURL+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to create IMDS token request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := c.doText(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("instance metadata service unavailable (no AWS credentials found): %w", err)
+	}
+
+	const credsPath = "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, imdsBaseURL+credsPath, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to create IMDS request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	role, err := c.doText(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get instance profile role: %w", err)
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, imdsBaseURL+credsPath+url.PathEscape(role), nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to create IMDS request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return c.doCredentialsJSON(req)
+}
+
+// assumeRole exchanges base credentials for credentials of c.roleARN.
+func (c *Credential) assumeRole(ctx context.Context, base Credentials) (Credentials, error) {
+	q := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {c.roleARN},
+		"RoleSessionName": {sessionName()},
+		"DurationSeconds": {"3600"},
+	}
+	body := q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.stsURL(), strings.NewReader(body))
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to create STS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	sign(req, []byte(body), base, "sts", c.region, time.Now())
+	var resp struct {
+		Result struct {
+			Credentials stsCredentials `xml:"Credentials"`
+		} `xml:"AssumeRoleResult"`
+	}
+	if err := c.doXML(req, &resp); err != nil {
+		return Credentials{}, fmt.Errorf("assume role %s: %w", c.roleARN, err)
+	}
+	return resp.Result.Credentials.toCredentials(), nil
+}
+
+func (c *Credential) stsURL() string {
+	return "https://sts." + c.region + ".amazonaws.com/"
+}
+
+type stsCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+func (s stsCredentials) toCredentials() Credentials {
+	return Credentials{AccessKeyID: s.AccessKeyID, SecretAccessKey: s.SecretAccessKey, SessionToken: s.SessionToken, Expires: s.Expiration}
+}
+
+func (c *Credential) doXML(req *http.Request, out any) error {
+	body, err := c.doText(req)
+	if err != nil {
+		return fmt.Errorf("STS request failed: %w", err)
+	}
+	if err := xml.Unmarshal([]byte(body), out); err != nil {
+		return fmt.Errorf("failed to parse STS response: %w", err)
+	}
+	return nil
+}
+
+func (c *Credential) doCredentialsJSON(req *http.Request) (Credentials, error) {
+	body, err := c.doText(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("credentials request failed: %w", err)
+	}
+	var cr struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal([]byte(body), &cr); err != nil {
+		return Credentials{}, fmt.Errorf("failed to parse credentials response: %w", err)
+	}
+	if cr.AccessKeyID == "" {
+		return Credentials{}, fmt.Errorf("credentials response contained no AccessKeyId")
+	}
+	return Credentials{AccessKeyID: cr.AccessKeyID, SecretAccessKey: cr.SecretAccessKey, SessionToken: cr.Token, Expires: cr.Expiration}, nil
+}
+
+func (c *Credential) doText(req *http.Request) (string, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return string(body), nil
+}
+
+func sessionName() string {
+	return fmt.Sprintf("ovad-%d", time.Now().Unix())
+}
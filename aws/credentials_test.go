@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clearCredentialEnv unsets the variables that pick a credential source.
+func clearCredentialEnv(t *testing.T) {
+	for _, k := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+	} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestCredentialStaticKeys(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "TOKEN")
+
+	c := NewCredentialFromEnv("us-east-1", "")
+	if m := c.Method(); m != "access keys" {
+		t.Errorf("Method() = %q, want access keys", m)
+	}
+	got, err := c.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessKeyID != "AKID" || got.SecretAccessKey != "SECRET" || got.SessionToken != "TOKEN" {
+		t.Errorf("Retrieve() = %+v", got)
+	}
+}
+
+func TestCredentialContainerRefresh(t *testing.T) {
+	clearCredentialEnv(t)
+	var calls atomic.Int32
+	var expires time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "pod-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		n := calls.Add(1)
+		fmt.Fprintf(w, `{"AccessKeyId":"AKID%d","SecretAccessKey":"SECRET","Token":"TOKEN","Expiration":%q}`, n, expires.Format(time.RFC3339))
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", srv.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "pod-token")
+
+	c := NewCredentialFromEnv("us-east-1", "")
+	if m := c.Method(); m != "container credentials" {
+		t.Errorf("Method() = %q, want container credentials", m)
+	}
+
+	// Credentials valid well beyond the refresh skew are cached.
+	expires = time.Now().Add(time.Hour)
+	for range 2 {
+		got, err := c.Retrieve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.AccessKeyID != "AKID1" || got.SessionToken != "TOKEN" {
+			t.Errorf("Retrieve() = %+v, want the first credentials", got)
+		}
+	}
+
+	// Credentials about to expire are fetched again.
+	c.creds.Expires = time.Now().Add(credentialRefreshSkew / 2)
+	got, err := c.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessKeyID != "AKID2" {
+		t.Errorf("Retrieve() = %+v, want refreshed credentials", got)
+	}
+}
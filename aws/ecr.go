@@ -0,0 +1,138 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const ecrTarget = "AmazonEC2ContainerRegistry_V20150921."
+
+// maxECRFindingPages caps the DescribeImageScanFindings pages fetched (1000
+// findings each).
+const maxECRFindingPages = 3
+
+// ImageScan is the result of an ECR image scan, basic or enhanced
+// (Amazon Inspector).
+type ImageScan struct {
+	Repository     string
+	Tag            string
+	Digest         string
+	Status         string // COMPLETE, IN_PROGRESS, FAILED, UNSUPPORTED_IMAGE, ACTIVE, FINDINGS_UNAVAILABLE, ...
+	StatusMessage  string
+	CompletedAt    time.Time
+	DBUpdatedAt    time.Time // when the vulnerability database was last updated
+	SeverityCounts map[string]int
+	Findings       []ImageFinding
+}
+
+// ImageFinding is one vulnerability found in an image.
+type ImageFinding struct {
+	ID       string // e.g. CVE-2024-1234
+	Severity string // CRITICAL, HIGH, MEDIUM, LOW, INFORMATIONAL, UNTRIAGED
+	Package  string
+	Version  string
+	FixedIn  string
+	Title    string
+	URL      string
+}
+
+// ImageScanFindings returns the scan findings of an image in repository,
+// identified by tag or digest ("sha256:..."). registryID is the account
+// that owns the registry; empty means the caller's account.
+func (c *Client) ImageScanFindings(ctx context.Context, registryID, repository, ref string) (*ImageScan, error) {
+	imageID := map[string]string{"imageTag": ref}
+	if strings.HasPrefix(ref, "sha256:") {
+		imageID = map[string]string{"imageDigest": ref}
+	}
+	scan := &ImageScan{Repository: repository, SeverityCounts: map[string]int{}}
+	var token string
+	for page := 0; page < maxECRFindingPages; page++ {
+		in := map[string]any{"repositoryName": repository, "imageId": imageID, "maxResults": 1000}
+		if registryID != "" {
+			in["registryId"] = registryID
+		}
+		if token != "" {
+			in["nextToken"] = token
+		}
+		var out struct {
+			ImageID struct {
+				ImageDigest string `json:"imageDigest"`
+				ImageTag    string `json:"imageTag"`
+			} `json:"imageId"`
+			ImageScanStatus struct {
+				Status      string `json:"status"`
+				Description string `json:"description"`
+			} `json:"imageScanStatus"`
+			ImageScanFindings struct {
+				ImageScanCompletedAt         epochSeconds   `json:"imageScanCompletedAt"`
+				VulnerabilitySourceUpdatedAt epochSeconds   `json:"vulnerabilitySourceUpdatedAt"`
+				FindingSeverityCounts        map[string]int `json:"findingSeverityCounts"`
+				Findings                     []struct {
+					Name       string `json:"name"`
+					URI        string `json:"uri"`
+					Severity   string `json:"severity"`
+					Attributes []struct {
+						Key   string `json:"key"`
+						Value string `json:"value"`
+					} `json:"attributes"`
+				} `json:"findings"`
+				EnhancedFindings []struct {
+					Severity                    string `json:"severity"`
+					Title                       string `json:"title"`
+					PackageVulnerabilityDetails struct {
+						VulnerabilityID    string `json:"vulnerabilityId"`
+						SourceURL          string `json:"sourceUrl"`
+						VulnerablePackages []struct {
+							Name           string `json:"name"`
+							Version        string `json:"version"`
+							FixedInVersion string `json:"fixedInVersion"`
+						} `json:"vulnerablePackages"`
+					} `json:"packageVulnerabilityDetails"`
+				} `json:"enhancedFindings"`
+			} `json:"imageScanFindings"`
+			NextToken string `json:"nextToken"`
+		}
+		if err := c.doJSON(ctx, "ecr", ecrTarget+"DescribeImageScanFindings", in, &out); err != nil {
+			return nil, err
+		}
+		f := out.ImageScanFindings
+		if page == 0 {
+			scan.Tag = out.ImageID.ImageTag
+			scan.Digest = out.ImageID.ImageDigest
+			scan.Status = out.ImageScanStatus.Status
+			scan.StatusMessage = out.ImageScanStatus.Description
+			scan.CompletedAt = f.ImageScanCompletedAt.Time()
+			scan.DBUpdatedAt = f.VulnerabilitySourceUpdatedAt.Time()
+			for sev, n := range f.FindingSeverityCounts {
+				scan.SeverityCounts[sev] = n
+			}
+		}
+		for _, b := range f.Findings {
+			finding := ImageFinding{ID: b.Name, Severity: b.Severity, URL: b.URI}
+			for _, a := range b.Attributes {
+				switch a.Key {
+				case "package_name":
+					finding.Package = a.Value
+				case "package_version":
+					finding.Version = a.Value
+				}
+			}
+			scan.Findings = append(scan.Findings, finding)
+		}
+		for _, e := range f.EnhancedFindings {
+			d := e.PackageVulnerabilityDetails
+			finding := ImageFinding{ID: d.VulnerabilityID, Severity: e.Severity, Title: e.Title, URL: d.SourceURL}
+			if len(d.VulnerablePackages) > 0 {
+				p := d.VulnerablePackages[0]
+				finding.Package, finding.Version, finding.FixedIn = p.Name, p.Version, p.FixedInVersion
+			}
+			scan.Findings = append(scan.Findings, finding)
+		}
+		if out.NextToken == "" {
+			break
+		}
+		token = out.NextToken
+	}
+	return scan, nil
+}
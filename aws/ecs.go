@@ -0,0 +1,230 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const ecsTarget = "AmazonEC2ContainerServiceV20141113."
+
+// ECSService is the state of an ECS service.
+type ECSService struct {
+	Name           string
+	ARN            string
+	Cluster        string
+	Status         string
+	LaunchType     string
+	TaskDefinition string
+	DesiredCount   int
+	RunningCount   int
+	PendingCount   int
+	CreatedAt      time.Time
+	Deployments    []ECSDeployment
+	Events         []ECSEvent // newest first
+}
+
+// ECSDeployment is one deployment (task definition rollout) of a service.
+type ECSDeployment struct {
+	Status         string // PRIMARY, ACTIVE or INACTIVE
+	RolloutState   string // IN_PROGRESS, COMPLETED or FAILED
+	RolloutReason  string
+	TaskDefinition string
+	DesiredCount   int
+	RunningCount   int
+	PendingCount   int
+	FailedTasks    int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ECSEvent is a service event message, e.g. "has reached a steady state".
+type ECSEvent struct {
+	CreatedAt time.Time
+	Message   string
+}
+
+// DescribeService returns an ECS service in cluster (name or ARN).
+func (c *Client) DescribeService(ctx context.Context, cluster, service string) (*ECSService, error) {
+	in := map[string]any{"cluster": cluster, "services": []string{service}}
+	var out struct {
+		Services []struct {
+			ServiceName    string       `json:"serviceName"`
+			ServiceARN     string       `json:"serviceArn"`
+			ClusterARN     string       `json:"clusterArn"`
+			Status         string       `json:"status"`
+			LaunchType     string       `json:"launchType"`
+			TaskDefinition string       `json:"taskDefinition"`
+			DesiredCount   int          `json:"desiredCount"`
+			RunningCount   int          `json:"runningCount"`
+			PendingCount   int          `json:"pendingCount"`
+			CreatedAt      epochSeconds `json:"createdAt"`
+			Deployments    []struct {
+				Status             string       `json:"status"`
+				RolloutState       string       `json:"rolloutState"`
+				RolloutStateReason string       `json:"rolloutStateReason"`
+				TaskDefinition     string       `json:"taskDefinition"`
+				DesiredCount       int          `json:"desiredCount"`
+				RunningCount       int          `json:"runningCount"`
+				PendingCount       int          `json:"pendingCount"`
+				FailedTasks        int          `json:"failedTasks"`
+				CreatedAt          epochSeconds `json:"createdAt"`
+				UpdatedAt          epochSeconds `json:"updatedAt"`
+			} `json:"deployments"`
+			Events []struct {
+				CreatedAt epochSeconds `json:"createdAt"`
+				Message   string       `json:"message"`
+			} `json:"events"`
+		} `json:"services"`
+		Failures []struct {
+			ARN    string `json:"arn"`
+			Reason string `json:"reason"`
+		} `json:"failures"`
+	}
+	if err := c.doJSON(ctx, "ecs", ecsTarget+"DescribeServices", in, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Services) == 0 {
+		if len(out.Failures) > 0 {
+			return nil, fmt.Errorf("service %q in cluster %q: %s", service, cluster, out.Failures[0].Reason)
+		}
+		return nil, fmt.Errorf("service %q not found in cluster %q", service, cluster)
+	}
+	s := out.Services[0]
+	svc := &ECSService{
+		Name:           s.ServiceName,
+		ARN:            s.ServiceARN,
+		Cluster:        s.ClusterARN[strings.LastIndex(s.ClusterARN, "/")+1:],
+		Status:         s.Status,
+		LaunchType:     s.LaunchType,
+		TaskDefinition: shortTaskDefinition(s.TaskDefinition),
+		DesiredCount:   s.DesiredCount,
+		RunningCount:   s.RunningCount,
+		PendingCount:   s.PendingCount,
+		CreatedAt:      s.CreatedAt.Time(),
+	}
+	for _, d := range s.Deployments {
+		svc.Deployments = append(svc.Deployments, ECSDeployment{
+			Status:         d.Status,
+			RolloutState:   d.RolloutState,
+			RolloutReason:  d.RolloutStateReason,
+			TaskDefinition: shortTaskDefinition(d.TaskDefinition),
+			DesiredCount:   d.DesiredCount,
+			RunningCount:   d.RunningCount,
+			PendingCount:   d.PendingCount,
+			FailedTasks:    d.FailedTasks,
+			CreatedAt:      d.CreatedAt.Time(),
+			UpdatedAt:      d.UpdatedAt.Time(),
+		})
+	}
+	for _, e := range s.Events {
+		svc.Events = append(svc.Events, ECSEvent{CreatedAt: e.CreatedAt.Time(), Message: e.Message})
+	}
+	return svc, nil
+}
+
+// ECSTask is a running or recently stopped ECS task.
+type ECSTask struct {
+	ID             string
+	TaskDefinition string
+	LastStatus     string // PROVISIONING, PENDING, RUNNING, STOPPED, ...
+	DesiredStatus  string
+	HealthStatus   string // HEALTHY, UNHEALTHY or UNKNOWN
+	StartedAt      time.Time
+	StoppedAt      time.Time
+	StopCode       string // e.g. EssentialContainerExited, TaskFailedToStart
+	StoppedReason  string
+	Containers     []ECSContainer
+}
+
+// ECSContainer is the state of one container in a task.
+type ECSContainer struct {
+	Name         string
+	LastStatus   string
+	HealthStatus string
+	ExitCode     *int
+	Reason       string // e.g. "OutOfMemoryError: Container killed due to memory usage"
+}
+
+// ListTasks returns the tasks of a service (all tasks in the cluster if
+// service is empty) with desiredStatus RUNNING or STOPPED. ECS keeps
+// stopped tasks for about an hour.
+func (c *Client) ListTasks(ctx context.Context, cluster, service, desiredStatus string, limit int) ([]ECSTask, error) {
+	in := map[string]any{"cluster": cluster, "desiredStatus": desiredStatus, "maxResults": min(max(limit, 1), 100)}
+	if service != "" {
+		in["serviceName"] = service
+	}
+	var list struct {
+		TaskARNs []string `json:"taskArns"`
+	}
+	if err := c.doJSON(ctx, "ecs", ecsTarget+"ListTasks", in, &list); err != nil {
+		return nil, err
+	}
+	if len(list.TaskARNs) == 0 {
+		return nil, nil
+	}
+
+	var out struct {
+		Tasks []struct {
+			TaskARN           string       `json:"taskArn"`
+			TaskDefinitionARN string       `json:"taskDefinitionArn"`
+			LastStatus        string       `json:"lastStatus"`
+			DesiredStatus     string       `json:"desiredStatus"`
+			HealthStatus      string       `json:"healthStatus"`
+			StartedAt         epochSeconds `json:"startedAt"`
+			StoppedAt         epochSeconds `json:"stoppedAt"`
+			StopCode          string       `json:"stopCode"`
+			StoppedReason     string       `json:"stoppedReason"`
+			Containers        []struct {
+				Name         string `json:"name"`
+				LastStatus   string `json:"lastStatus"`
+				HealthStatus string `json:"healthStatus"`
+				ExitCode     *int   `json:"exitCode"`
+				Reason       string `json:"reason"`
+			} `json:"containers"`
+		} `json:"tasks"`
+	}
+	if err := c.doJSON(ctx, "ecs", ecsTarget+"DescribeTasks", map[string]any{"cluster": cluster, "tasks": list.TaskARNs}, &out); err != nil {
+		return nil, err
+	}
+	tasks := make([]ECSTask, 0, len(out.Tasks))
+	for _, t := range out.Tasks {
+		task := ECSTask{
+			ID:             t.TaskARN[strings.LastIndex(t.TaskARN, "/")+1:],
+			TaskDefinition: shortTaskDefinition(t.TaskDefinitionARN),
+			LastStatus:     t.LastStatus,
+			DesiredStatus:  t.DesiredStatus,
+			HealthStatus:   t.HealthStatus,
+			StartedAt:      t.StartedAt.Time(),
+			StoppedAt:      t.StoppedAt.Time(),
+			StopCode:       t.StopCode,
+			StoppedReason:  t.StoppedReason,
+		}
+		for _, ct := range t.Containers {
+			task.Containers = append(task.Containers, ECSContainer{
+				Name: ct.Name, LastStatus: ct.LastStatus, HealthStatus: ct.HealthStatus, ExitCode: ct.ExitCode, Reason: ct.Reason,
+			})
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// shortTaskDefinition turns a task definition ARN into "family:revision".
+func shortTaskDefinition(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
+// epochSeconds is a timestamp in (fractional) Unix seconds, as the AWS JSON
+// protocols encode them.
+type epochSeconds float64
+
+func (e epochSeconds) Time() time.Time {
+	if e == 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(float64(e))
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
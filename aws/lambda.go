@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"time"
+)
+
+// LambdaFunction is the configuration and state of a Lambda function.
+type LambdaFunction struct {
+	Name             string
+	Runtime          string
+	Handler          string
+	MemoryMB         int
+	TimeoutSeconds   int
+	State            string // Pending, Active, Inactive or Failed
+	StateReason      string
+	LastUpdateStatus string // Successful, Failed or InProgress
+	LastUpdateReason string
+	LastModified     time.Time
+	Version          string
+	LogGroup         string // CloudWatch Logs group the function writes to
+}
+
+// LambdaMetrics are a function's invocation totals over a window.
+type LambdaMetrics struct {
+	Invocations float64
+	Errors      float64
+	Throttles   float64
+	AvgDuration time.Duration
+	MaxDuration time.Duration
+	// ErrorsByPeriod is the error count per period, oldest first.
+	ErrorsByPeriod []Datapoint
+}
+
+// GetFunction returns the configuration of a Lambda function (name or ARN).
+func (c *Client) GetFunction(ctx context.Context, name string) (*LambdaFunction, error) {
+	var out struct {
+		FunctionName         string `json:"FunctionName"`
+		Runtime              string `json:"Runtime"`
+		Handler              string `json:"Handler"`
+		MemorySize           int    `json:"MemorySize"`
+		Timeout              int    `json:"Timeout"`
+		State                string `json:"State"`
+		StateReason          string `json:"StateReason"`
+		LastUpdateStatus     string `json:"LastUpdateStatus"`
+		LastUpdateStatusText string `json:"LastUpdateStatusReason"`
+		LastModified         string `json:"LastModified"`
+		Version              string `json:"Version"`
+		LoggingConfig        struct {
+			LogGroup string `json:"LogGroup"`
+		} `json:"LoggingConfig"`
+	}
+	if err := c.doREST(ctx, "lambda", "/2015-03-31/functions/"+escape(name)+"/configuration", nil, &out); err != nil {
+		return nil, err
+	}
+	fn := &LambdaFunction{
+		Name:             out.FunctionName,
+		Runtime:          out.Runtime,
+		Handler:          out.Handler,
+		MemoryMB:         out.MemorySize,
+		TimeoutSeconds:   out.Timeout,
+		State:            out.State,
+		StateReason:      out.StateReason,
+		LastUpdateStatus: out.LastUpdateStatus,
+		LastUpdateReason: out.LastUpdateStatusText,
+		Version:          out.Version,
+		LogGroup:         out.LoggingConfig.LogGroup,
+	}
+	if fn.LogGroup == "" {
+		fn.LogGroup = lambdaLogGroup(out.FunctionName)
+	}
+	// LastModified looks like "2024-05-01T12:00:00.000+0000".
+	fn.LastModified, _ = time.Parse("2006-01-02T15:04:05.000-0700", out.LastModified)
+	return fn, nil
+}
+
+// FunctionMetrics returns a Lambda function's invocations, errors, throttles
+// and duration between start and end from CloudWatch.
+func (c *Client) FunctionMetrics(ctx context.Context, name string, start, end time.Time, period time.Duration) (*LambdaMetrics, error) {
+	dims := map[string]string{"FunctionName": name}
+	m := &LambdaMetrics{}
+	for _, metric := range []string{"Invocations", "Errors", "Throttles", "Duration"} {
+		points, err := c.MetricStatistics(ctx, "AWS/Lambda", metric, dims, start, end, period)
+		if err != nil {
+			return nil, err
+		}
+		var sum, maxMs float64
+		for _, p := range points {
+			sum += p.Sum
+			maxMs = max(maxMs, p.Maximum)
+		}
+		switch metric {
+		case "Invocations":
+			m.Invocations = sum
+		case "Errors":
+			m.Errors = sum
+			m.ErrorsByPeriod = points
+		case "Throttles":
+			m.Throttles = sum
+		case "Duration":
+			// Duration's Sum is total milliseconds; Average is per invocation.
+			var n float64
+			for _, p := range points {
+				if p.Average > 0 {
+					n += p.Sum / p.Average
+				}
+			}
+			if n > 0 {
+				m.AvgDuration = time.Duration(sum / n * float64(time.Millisecond))
+			}
+			m.MaxDuration = time.Duration(maxMs * float64(time.Millisecond))
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const logsTarget = "Logs_20140328."
+
+// logsPollInterval is how often QueryLogs checks whether a query finished.
+const logsPollInterval = time.Second
+
+// LogsQueryResult is the outcome of a CloudWatch Logs Insights query.
+type LogsQueryResult struct {
+	Status  string // Complete, Failed, Cancelled, Timeout or Running (if the wait ran out)
+	Fields  []string
+	Rows    []map[string]string
+	Matched float64
+	Scanned float64
+}
+
+// QueryLogs runs a CloudWatch Logs Insights query over logGroups between
+// start and end and waits up to wait for it to finish. A query still running
+// after that is stopped and its partial results returned.
+func (c *Client) QueryLogs(ctx context.Context, logGroups []string, query string, start, end time.Time, limit int, wait time.Duration) (*LogsQueryResult, error) {
+	in := map[string]any{
+		"logGroupNames": logGroups,
+		"queryString":   query,
+		"startTime":     start.Unix(),
+		"endTime":       end.Unix(),
+		"limit":         max(limit, 1),
+	}
+	var started struct {
+		QueryID string `json:"queryId"`
+	}
+	if err := c.doJSON(ctx, "logs", logsTarget+"StartQuery", in, &started); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		res, err := c.queryResults(ctx, started.QueryID)
+		if err != nil {
+			return nil, err
+		}
+		switch res.Status {
+		case "Complete", "Failed", "Cancelled", "Timeout":
+			return res, nil
+		}
+		if time.Now().After(deadline) {
+			// Don't leave the query scanning (and billing) in the background.
+			_ = c.doJSON(ctx, "logs", logsTarget+"StopQuery", map[string]any{"queryId": started.QueryID}, nil)
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+func (c *Client) queryResults(ctx context.Context, queryID string) (*LogsQueryResult, error) {
+	var out struct {
+		Status  string `json:"status"`
+		Results [][]struct {
+			Field string `json:"field"`
+			Value string `json:"value"`
+		} `json:"results"`
+		Statistics struct {
+			RecordsMatched float64 `json:"recordsMatched"`
+			RecordsScanned float64 `json:"recordsScanned"`
+		} `json:"statistics"`
+	}
+	if err := c.doJSON(ctx, "logs", logsTarget+"GetQueryResults", map[string]any{"queryId": queryID}, &out); err != nil {
+		return nil, err
+	}
+	res := &LogsQueryResult{Status: out.Status, Matched: out.Statistics.RecordsMatched, Scanned: out.Statistics.RecordsScanned}
+	seen := map[string]bool{}
+	for _, row := range out.Results {
+		r := make(map[string]string, len(row))
+		for _, f := range row {
+			// @ptr is an opaque record pointer, not useful to read.
+			if f.Field == "@ptr" {
+				continue
+			}
+			r[f.Field] = f.Value
+			if !seen[f.Field] {
+				seen[f.Field] = true
+				res.Fields = append(res.Fields, f.Field)
+			}
+		}
+		res.Rows = append(res.Rows, r)
+	}
+	return res, nil
+}
+
+// LambdaErrorQuery is a Logs Insights query for the error lines of a Lambda
+// function's log group.
+const LambdaErrorQuery = `fields @timestamp, @message, @requestId
+| filter @message like /(?i)(error|exception|traceback|task timed out|runtime exited)/
+| sort @timestamp desc`
+
+// lambdaLogGroup returns the default log group of a Lambda function.
+func lambdaLogGroup(name string) string {
+	return fmt.Sprintf("/aws/lambda/%s", name)
+}
@@ -0,0 +1,486 @@
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+const (
+	apiURL = "https://api.bitbucket.org/2.0"
+	// WebURL is the base of Bitbucket Cloud's web links.
+	WebURL = "https://bitbucket.org"
+)
+
+// maxPages caps how many pages a listing follows.
+const maxPages = 50
+
+// Client provides access to the Bitbucket Cloud REST API (2.0).
+type Client struct {
+	username   string // empty for access tokens (Bearer)
+	token      string // access token, API token or app password
+	httpClient *http.Client
+}
+
+// NewClient creates a Bitbucket Cloud client. With a username, token is an
+// API token or app password sent with basic auth; without one, it is a
+// workspace, project or repository access token.
+func NewClient(username, token string) *Client {
+	return &Client{
+		username: username,
+		token:    token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "bitbucket"},
+		},
+	}
+}
+
+// Repository is a Bitbucket repository.
+type Repository struct {
+	FullName   string `json:"full_name"` // "workspace/repo-slug"
+	MainBranch struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+}
+
+// Entry is a file or directory in a repository listing.
+type Entry struct {
+	Path string `json:"path"`
+	Type string `json:"type"` // "commit_file" or "commit_directory"
+}
+
+// PullRequest is a Bitbucket pull request.
+type PullRequest struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"` // OPEN, MERGED, DECLINED or SUPERSEDED
+	Draft       bool   `json:"draft"`
+	Author      struct {
+		DisplayName string `json:"display_name"`
+		Nickname    string `json:"nickname"`
+	} `json:"author"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// DiffStat is the change to one file in a pull request.
+type DiffStat struct {
+	Status       string `json:"status"` // added, removed, modified or renamed
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+	Old          *struct {
+		Path string `json:"path"`
+	} `json:"old"`
+	New *struct {
+		Path string `json:"path"`
+	} `json:"new"`
+}
+
+// Pipeline is a Bitbucket Pipelines run.
+type Pipeline struct {
+	UUID        string `json:"uuid"`
+	BuildNumber int64  `json:"build_number"`
+	State       State  `json:"state"`
+	Target      struct {
+		RefName string `json:"ref_name"`
+	} `json:"target"`
+}
+
+// Step is one step of a pipeline run.
+type Step struct {
+	UUID  string `json:"uuid"`
+	Name  string `json:"name"`
+	State State  `json:"state"`
+}
+
+// State is the state of a pipeline or step: PENDING, IN_PROGRESS or
+// COMPLETED, with a result (SUCCESSFUL, FAILED, ERROR, STOPPED, ...) once
+// completed.
+type State struct {
+	Name   string `json:"name"`
+	Result *struct {
+		Name string `json:"name"`
+	} `json:"result"`
+}
+
+// GetRepository returns a repository.
+func (c *Client) GetRepository(ctx context.Context, workspace, repo string) (*Repository, error) {
+	var r Repository
+	if err := c.do(ctx, http.MethodGet, repoPath(workspace, repo), nil, &r); err != nil {
+		return nil, fmt.Errorf("get repository %s/%s: %w", workspace, repo, err)
+	}
+	return &r, nil
+}
+
+// ListRepositories returns a workspace's repositories, most recently
+// updated first.
+func (c *Client) ListRepositories(ctx context.Context, workspace string) ([]Repository, error) {
+	var all []Repository
+	err := c.list(ctx, "/repositories/"+url.PathEscape(workspace)+"?pagelen=100&sort=-updated_on", func(raw json.RawMessage) error {
+		var page []Repository
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list repositories of %s: %w", workspace, err)
+	}
+	return all, nil
+}
+
+// GetFile returns the content of a file at ref.
+func (c *Client) GetFile(ctx context.Context, workspace, repo, ref, filePath string) (string, error) {
+	commit, err := c.resolveRef(ctx, workspace, repo, ref)
+	if err != nil {
+		return "", err
+	}
+	data, contentType, err := c.raw(ctx, srcPath(workspace, repo, commit, filePath))
+	if err != nil {
+		return "", fmt.Errorf("get file %s: %w", filePath, err)
+	}
+	// A directory comes back as a JSON listing instead of file content.
+	if strings.HasPrefix(contentType, "application/json") {
+		var listing struct {
+			Values []Entry `json:"values"`
+		}
+		if json.Unmarshal(data, &listing) == nil && len(listing.Values) > 0 && listing.Values[0].Type != "" {
+			return "", fmt.Errorf("path %s is a directory, not a file", filePath)
+		}
+	}
+	return string(data), nil
+}
+
+// ListDirectory returns the entries of a directory at ref; depth > 1 also
+// lists that many levels of subdirectories.
+func (c *Client) ListDirectory(ctx context.Context, workspace, repo, ref, dir string, depth int) ([]Entry, error) {
+	commit, err := c.resolveRef(ctx, workspace, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	p := srcPath(workspace, repo, commit, dir)
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	p += fmt.Sprintf("?pagelen=100&max_depth=%d", max(depth, 1))
+	var all []Entry
+	err = c.list(ctx, p, func(raw json.RawMessage) error {
+		var page []Entry
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list directory %s: %w", dir, err)
+	}
+	return all, nil
+}
+
+// CreateBranch creates branch at the head of base.
+func (c *Client) CreateBranch(ctx context.Context, workspace, repo, base, branch string) error {
+	commit, err := c.resolveRef(ctx, workspace, repo, base)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"name": branch, "target": map[string]string{"hash": commit}}
+	if err := c.do(ctx, http.MethodPost, repoPath(workspace, repo)+"/refs/branches", body, nil); err != nil {
+		return fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// Commit lands files and deletions on branch as a single commit.
+func (c *Client) Commit(ctx context.Context, workspace, repo, branch, message string, files map[string][]byte, deletes []string) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	_ = w.WriteField("message", message)
+	_ = w.WriteField("branch", branch)
+	for p, content := range files {
+		part, err := w.CreateFormFile(p, path.Base(p))
+		if err != nil {
+			return fmt.Errorf("create form file: %w", err)
+		}
+		if _, err := part.Write(content); err != nil {
+			return fmt.Errorf("write form file: %w", err)
+		}
+	}
+	for _, p := range deletes {
+		_ = w.WriteField("files", p)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close form: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, apiURL+repoPath(workspace, repo)+"/src", &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if _, _, err := c.send(req); err != nil {
+		return fmt.Errorf("commit to %s: %w", branch, err)
+	}
+	return nil
+}
+
+// CreatePullRequest opens a pull request from source into destination. The
+// source branch is closed when the pull request is merged.
+func (c *Client) CreatePullRequest(ctx context.Context, workspace, repo, source, destination, title, description string) (*PullRequest, error) {
+	body := map[string]any{
+		"title":               title,
+		"description":         description,
+		"source":              map[string]any{"branch": map[string]string{"name": source}},
+		"destination":         map[string]any{"branch": map[string]string{"name": destination}},
+		"close_source_branch": true,
+	}
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodPost, repoPath(workspace, repo)+"/pullrequests", body, &pr); err != nil {
+		return nil, fmt.Errorf("create pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+// GetPullRequest returns a pull request by ID.
+func (c *Client) GetPullRequest(ctx context.Context, workspace, repo string, id int) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/pullrequests/%d", repoPath(workspace, repo), id), nil, &pr); err != nil {
+		return nil, fmt.Errorf("get pull request #%d: %w", id, err)
+	}
+	return &pr, nil
+}
+
+// PullRequestDiffStat returns the files a pull request changes.
+func (c *Client) PullRequestDiffStat(ctx context.Context, workspace, repo string, id int) ([]DiffStat, error) {
+	var all []DiffStat
+	err := c.list(ctx, fmt.Sprintf("%s/pullrequests/%d/diffstat?pagelen=100", repoPath(workspace, repo), id), func(raw json.RawMessage) error {
+		var page []DiffStat
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get diffstat of pull request #%d: %w", id, err)
+	}
+	return all, nil
+}
+
+// PullRequestDiff returns a pull request's unified diff.
+func (c *Client) PullRequestDiff(ctx context.Context, workspace, repo string, id int) (string, error) {
+	data, _, err := c.raw(ctx, fmt.Sprintf("%s/pullrequests/%d/diff", repoPath(workspace, repo), id))
+	if err != nil {
+		return "", fmt.Errorf("get diff of pull request #%d: %w", id, err)
+	}
+	return string(data), nil
+}
+
+// ListPullRequests returns up to limit pull requests in any of states,
+// most recently updated first.
+func (c *Client) ListPullRequests(ctx context.Context, workspace, repo string, states []string, limit int) ([]PullRequest, error) {
+	q := url.Values{"pagelen": {fmt.Sprint(limit)}, "sort": {"-updated_on"}, "state": states}
+	var page struct {
+		Values []PullRequest `json:"values"`
+	}
+	if err := c.do(ctx, http.MethodGet, repoPath(workspace, repo)+"/pullrequests?"+q.Encode(), nil, &page); err != nil {
+		return nil, fmt.Errorf("list pull requests: %w", err)
+	}
+	return page.Values, nil
+}
+
+// GetPipeline returns a pipeline run by build number.
+func (c *Client) GetPipeline(ctx context.Context, workspace, repo string, buildNumber int64) (*Pipeline, error) {
+	var p Pipeline
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/pipelines/%d", repoPath(workspace, repo), buildNumber), nil, &p); err != nil {
+		return nil, fmt.Errorf("get pipeline #%d: %w", buildNumber, err)
+	}
+	return &p, nil
+}
+
+// PipelineSteps returns the steps of a pipeline run.
+func (c *Client) PipelineSteps(ctx context.Context, workspace, repo, pipelineUUID string) ([]Step, error) {
+	var all []Step
+	err := c.list(ctx, repoPath(workspace, repo)+"/pipelines/"+url.PathEscape(pipelineUUID)+"/steps/?pagelen=100", func(raw json.RawMessage) error {
+		var page []Step
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list steps: %w", err)
+	}
+	return all, nil
+}
+
+// StepLog opens the plain-text log of a pipeline step. The caller closes it.
+func (c *Client) StepLog(ctx context.Context, workspace, repo, pipelineUUID, stepUUID string) (io.ReadCloser, error) {
+	u := apiURL + repoPath(workspace, repo) + "/pipelines/" + url.PathEscape(pipelineUUID) + "/steps/" + url.PathEscape(stepUUID) + "/log"
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Logs can be large; don't let the client timeout cut the download.
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("bitbucket API error (HTTP %d) downloading step log", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// resolveRef turns a branch name into its head commit. The src endpoints
+// take a commit or a branch name, but a name with a slash is ambiguous
+// there.
+func (c *Client) resolveRef(ctx context.Context, workspace, repo, ref string) (string, error) {
+	if !strings.Contains(ref, "/") {
+		return ref, nil
+	}
+	var branch struct {
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	}
+	if err := c.do(ctx, http.MethodGet, repoPath(workspace, repo)+"/refs/branches/"+url.PathEscape(ref), nil, &branch); err != nil {
+		return "", fmt.Errorf("resolve branch %s: %w", ref, err)
+	}
+	return branch.Target.Hash, nil
+}
+
+func repoPath(workspace, repo string) string {
+	return "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(repo)
+}
+
+func srcPath(workspace, repo, commit, filePath string) string {
+	segments := strings.Split(strings.Trim(filePath, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return repoPath(workspace, repo) + "/src/" + url.PathEscape(commit) + "/" + strings.Join(segments, "/")
+}
+
+// ----- HTTP transport -----
+
+// list follows a paginated listing ("values" and "next") for up to
+// maxPages pages, passing each page's values to fn.
+func (c *Client) list(ctx context.Context, path string, fn func(json.RawMessage) error) error {
+	next := apiURL + path
+	for page := 0; next != "" && page < maxPages; page++ {
+		req, err := c.newRequest(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return err
+		}
+		body, _, err := c.send(req)
+		if err != nil {
+			return err
+		}
+		var p struct {
+			Values json.RawMessage `json:"values"`
+			Next   string          `json:"next"`
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+		if err := fn(p.Values); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+		next = p.Next
+	}
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, apiURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	respBody, _, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) raw(ctx context.Context, path string) ([]byte, string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, apiURL+path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return c.send(req)
+}
+
+// send performs req and returns the response body and content type.
+func (c *Client) send(req *http.Request) ([]byte, string, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, "", fmt.Errorf("bitbucket API error (HTTP %d): %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, "", fmt.Errorf("bitbucket API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,300 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+const baseURL = "https://api.cloudflare.com/client/v4"
+
+// Client provides access to the Cloudflare API.
+type Client struct {
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a Cloudflare client authenticated with an API token.
+func NewClient(token string) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "cloudflare"},
+		},
+	}
+}
+
+// Zone is a Cloudflare zone (domain) and the settings that matter when a
+// site is slow or blocking visitors.
+type Zone struct {
+	ID              string
+	Name            string
+	Status          string // active, pending, initializing, moved, deleted or deactivated
+	Paused          bool   // Cloudflare is bypassed (DNS only)
+	Plan            string
+	NameServers     []string
+	SecurityLevel   string // "under_attack" = I'm Under Attack mode
+	DevelopmentMode bool   // cache bypassed
+	AlwaysOnline    bool
+	SSLMode         string
+}
+
+// FirewallEvent is a request that a WAF, rate limiting or security rule
+// acted on.
+type FirewallEvent struct {
+	Time        time.Time
+	Action      string // block, challenge, managed_challenge, js_challenge, log, ...
+	Source      string // waf, firewallManaged, ratelimit, securitylevel, bic, ...
+	RuleID      string
+	Description string
+	ClientIP    string
+	Country     string
+	Host        string
+	Method      string
+	Path        string
+	UserAgent   string
+	RayID       string
+}
+
+// zoneIDPattern matches a 32-character hex zone ID.
+var zoneIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// GetZone returns a zone by name (e.g. "example.com") or ID, with its key
+// settings.
+func (c *Client) GetZone(ctx context.Context, nameOrID string) (*Zone, error) {
+	var z struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Status      string   `json:"status"`
+		Paused      bool     `json:"paused"`
+		NameServers []string `json:"name_servers"`
+		Plan        struct {
+			Name string `json:"name"`
+		} `json:"plan"`
+	}
+	nameOrID = strings.TrimSpace(strings.ToLower(nameOrID))
+	if zoneIDPattern.MatchString(nameOrID) {
+		if err := c.do(ctx, http.MethodGet, "/zones/"+nameOrID, nil, &z); err != nil {
+			return nil, err
+		}
+	} else {
+		var zones []json.RawMessage
+		if err := c.do(ctx, http.MethodGet, "/zones?"+url.Values{"name": {nameOrID}}.Encode(), nil, &zones); err != nil {
+			return nil, err
+		}
+		if len(zones) == 0 {
+			return nil, fmt.Errorf("zone %q not found (or not accessible with this token)", nameOrID)
+		}
+		if err := json.Unmarshal(zones[0], &z); err != nil {
+			return nil, fmt.Errorf("unmarshal zone: %w", err)
+		}
+	}
+	zone := &Zone{ID: z.ID, Name: z.Name, Status: z.Status, Paused: z.Paused, Plan: z.Plan.Name, NameServers: z.NameServers}
+
+	// Settings need Zone Settings:Read; without it the zone itself is still useful.
+	var settings []struct {
+		ID    string `json:"id"`
+		Value any    `json:"value"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/zones/"+z.ID+"/settings", nil, &settings); err == nil {
+		for _, s := range settings {
+			v, _ := s.Value.(string)
+			switch s.ID {
+			case "security_level":
+				zone.SecurityLevel = v
+			case "development_mode":
+				zone.DevelopmentMode = v == "on"
+			case "always_online":
+				zone.AlwaysOnline = v == "on"
+			case "ssl":
+				zone.SSLMode = v
+			}
+		}
+	}
+	return zone, nil
+}
+
+// FirewallEvents returns the most recent firewall events of a zone since
+// start, optionally only for one host or path prefix.
+func (c *Client) FirewallEvents(ctx context.Context, zoneID string, start time.Time, host, path string, limit int) ([]FirewallEvent, error) {
+	filter := map[string]any{
+		"datetime_geq": start.UTC().Format(time.RFC3339),
+		"datetime_leq": time.Now().UTC().Format(time.RFC3339),
+	}
+	if host != "" {
+		filter["clientRequestHTTPHost"] = host
+	}
+	if path != "" {
+		filter["clientRequestPath_like"] = path + "%"
+	}
+	const query = `query($zoneTag: string, $filter: FirewallEventsAdaptiveFilter_InputObject, $limit: uint64!) {
+  viewer { zones(filter: {zoneTag: $zoneTag}) {
+    firewallEventsAdaptive(filter: $filter, limit: $limit, orderBy: [datetime_DESC]) {
+      datetime action source ruleId description clientIP clientCountryName
+      clientRequestHTTPHost clientRequestHTTPMethodName clientRequestPath userAgent rayName
+    }
+  } }
+}`
+	body := map[string]any{
+		"query":     query,
+		"variables": map[string]any{"zoneTag": zoneID, "filter": filter, "limit": max(limit, 1)},
+	}
+	var resp struct {
+		Data struct {
+			Viewer struct {
+				Zones []struct {
+					Events []struct {
+						Datetime    time.Time `json:"datetime"`
+						Action      string    `json:"action"`
+						Source      string    `json:"source"`
+						RuleID      string    `json:"ruleId"`
+						Description string    `json:"description"`
+						ClientIP    string    `json:"clientIP"`
+						Country     string    `json:"clientCountryName"`
+						Host        string    `json:"clientRequestHTTPHost"`
+						Method      string    `json:"clientRequestHTTPMethodName"`
+						Path        string    `json:"clientRequestPath"`
+						UserAgent   string    `json:"userAgent"`
+						RayName     string    `json:"rayName"`
+					} `json:"firewallEventsAdaptive"`
+				} `json:"zones"`
+			} `json:"viewer"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/graphql", body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("cloudflare GraphQL error: %s", resp.Errors[0].Message)
+	}
+	var out []FirewallEvent
+	for _, z := range resp.Data.Viewer.Zones {
+		for _, e := range z.Events {
+			out = append(out, FirewallEvent{
+				Time: e.Datetime, Action: e.Action, Source: e.Source, RuleID: e.RuleID, Description: e.Description,
+				ClientIP: e.ClientIP, Country: e.Country, Host: e.Host, Method: e.Method, Path: e.Path,
+				UserAgent: e.UserAgent, RayID: e.RayName,
+			})
+		}
+	}
+	return out, nil
+}
+
+// PurgeRequest selects what PurgeCache removes. Exactly one of the fields
+// should be set.
+type PurgeRequest struct {
+	Files      []string // full URLs
+	Prefixes   []string // e.g. "www.example.com/assets/"
+	Hosts      []string
+	Everything bool
+}
+
+// PurgeCache removes cached content from a zone.
+func (c *Client) PurgeCache(ctx context.Context, zoneID string, p PurgeRequest) error {
+	body := map[string]any{}
+	switch {
+	case p.Everything:
+		body["purge_everything"] = true
+	case len(p.Files) > 0:
+		body["files"] = p.Files
+	case len(p.Prefixes) > 0:
+		body["prefixes"] = p.Prefixes
+	case len(p.Hosts) > 0:
+		body["hosts"] = p.Hosts
+	default:
+		return fmt.Errorf("nothing to purge")
+	}
+	return c.do(ctx, http.MethodPost, "/zones/"+url.PathEscape(zoneID)+"/purge_cache", body, nil)
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+// do calls a REST endpoint and decodes the "result" of the response
+// envelope into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var env struct {
+		Success bool            `json:"success"`
+		Result  json.RawMessage `json:"result"`
+		Errors  []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.send(ctx, method, path, body, &env); err != nil {
+		return err
+	}
+	if !env.Success {
+		if len(env.Errors) > 0 {
+			return fmt.Errorf("cloudflare API error %d: %s", env.Errors[0].Code, env.Errors[0].Message)
+		}
+		return fmt.Errorf("cloudflare API request failed")
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(env.Result, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			return fmt.Errorf("cloudflare API error (HTTP %d): %s", resp.StatusCode, apiErr.Errors[0].Message)
+		}
+		return fmt.Errorf("cloudflare API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// toolRepo extracts the "owner/repo" a repository tool call targets, either
+// from a URL argument (PR, issue or CI run) or from the repo argument combined with
+// the resolved owner. Returns "" when the call has no repository target.
+func (h *GeneralHandler) toolRepo(ctx context.Context, argsJSON string) (string, error) {
+	var args struct {
+		Repo string `json:"repo"`
+		URL  string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+		return "", nil // let the tool itself report malformed arguments
+	}
+	if args.URL != "" {
+		if owner, repo, _, err := h.scm.ParseChangeRequestURL(args.URL); err == nil {
+			return owner + "/" + repo, nil
+		}
+		if owner, repo, _, err := h.scm.ParsePipelineURL(args.URL); err == nil {
+			return owner + "/" + repo, nil
+		}
+		if owner, repo, err := github.ParseIssueOrPRURL(args.URL); err == nil {
+			return owner + "/" + repo, nil
+		}
+	}
+	if args.Repo == "" {
+		return "", nil
+	}
+	if strings.Contains(args.Repo, "/") {
+		return args.Repo, nil
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return "", err
+	}
+	return owner + "/" + args.Repo, nil
+}
+
+// toolTemplateRepo returns the "owner/repo" of a call's template argument,
+// or "" when it has none.
+func (h *GeneralHandler) toolTemplateRepo(ctx context.Context, argsJSON string) (string, error) {
+	var args struct {
+		Template string `json:"template"`
+	}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil || args.Template == "" {
+		return "", nil
+	}
+	if strings.Contains(args.Template, "/") {
+		return args.Template, nil
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return "", err
+	}
+	return owner + "/" + args.Template, nil
+}
+
+// checkRepoAccess enforces the agent's repository allowlist before a GitHub
+// tool runs. Returns a non-empty error message when the call must be refused.
+func (h *GeneralHandler) checkRepoAccess(ctx context.Context, userID, channelID string, def *ToolDef, argsJSON string) string {
+	name, level := def.Name, def.RepoAccess
+	if level == "" || !h.repoPolicy.Restricted() {
+		return ""
+	}
+	fullName, err := h.toolRepo(ctx, argsJSON)
+	if err != nil {
+		return fmt.Sprintf("Error resolving repository owner: %v", err)
+	}
+	// A template is copied from, so it needs read access on its own.
+	template, err := h.toolTemplateRepo(ctx, argsJSON)
+	if err != nil {
+		return fmt.Sprintf("Error resolving repository owner: %v", err)
+	}
+	if template != "" && !h.repoPolicy.AllowsRead(template) {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] blocked %s from template %s (read access not allowed for agent %s)", userID, channelID, name, template, h.agentID)
+		return fmt.Sprintf("Error: agent %s is not allowed read access to repository %s. Do not retry with this template.", h.agentID, template)
+	}
+	if fullName == "" {
+		return ""
+	}
+	allowed := h.repoPolicy.AllowsRead(fullName)
+	if level == "write" {
+		allowed = h.repoPolicy.AllowsWrite(fullName)
+	}
+	if allowed {
+		return ""
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] blocked %s on %s (%s access not allowed for agent %s)", userID, channelID, name, fullName, level, h.agentID)
+	return fmt.Sprintf("Error: agent %s is not allowed %s access to repository %s. Do not retry with this repository.", h.agentID, level, fullName)
+}
+
+// filterReadableRepos drops repositories the agent may not read from a list of "owner/repo" names.
+func (h *GeneralHandler) filterReadableRepos(repos []string) []string {
+	if !h.repoPolicy.Restricted() {
+		return repos
+	}
+	out := repos[:0]
+	for _, r := range repos {
+		if h.repoPolicy.AllowsRead(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// checkProtectedPaths refuses repository writes that touch a path protected
+// by the agent's policy, whatever the model decided. Returns a non-empty
+// error message when the call must be refused.
+func (h *GeneralHandler) checkProtectedPaths(ctx context.Context, userID, channelID string, def *ToolDef, argsJSON string) string {
+	if def.Class != ToolWrite || def.RepoAccess != "write" || len(h.repoPolicy.Protected) == 0 {
+		return ""
+	}
+	paths := toolPaths(argsJSON)
+	fullName, err := h.toolRepo(ctx, argsJSON)
+	if err != nil {
+		return fmt.Sprintf("Error resolving repository owner: %v", err)
+	}
+	for _, p := range paths {
+		if pat := h.repoPolicy.ProtectedMatch(fullName, p); pat != "" {
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] blocked %s on protected path %s in %s (pattern %q, agent %s)", userID, channelID, def.Name, p, fullName, pat, h.agentID)
+			return fmt.Sprintf("Error: %s is a protected path (policy pattern %q) and cannot be modified by agent %s. Do not retry or work around this; tell the user the change must be made manually through a normal pull request.", p, pat, h.agentID)
+		}
+	}
+	return ""
+}
+
+// toolPaths extracts the file paths a write tool call targets, from either a
+// "path" argument or a "files" list.
+func toolPaths(argsJSON string) []string {
+	var args struct {
+		Path  string `json:"path"`
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+		return nil // let the tool itself report malformed arguments
+	}
+	var paths []string
+	if args.Path != "" {
+		paths = append(paths, args.Path)
+	}
+	for _, f := range args.Files {
+		if f.Path != "" {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
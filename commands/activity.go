@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentRequests bounds how many requests are kept per user.
+const maxRecentRequests = 10
+
+// RecentRequest is a single slash-command invocation shown on the App Home tab.
+type RecentRequest struct {
+	AgentID   string
+	ChannelID string
+	Text      string
+	At        time.Time
+}
+
+// RequestLog keeps the most recent requests per user in memory. Safe for
+// concurrent use. History is lost on restart.
+type RequestLog struct {
+	mu     sync.RWMutex
+	byUser map[string][]RecentRequest
+}
+
+// NewRequestLog creates an empty request log.
+func NewRequestLog() *RequestLog {
+	return &RequestLog{byUser: make(map[string][]RecentRequest)}
+}
+
+// Record appends a request for userID, dropping the oldest entry once the
+// per-user limit is reached.
+func (l *RequestLog) Record(userID, agentID, channelID, text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	reqs := append(l.byUser[userID], RecentRequest{
+		AgentID:   agentID,
+		ChannelID: channelID,
+		Text:      text,
+		At:        time.Now(),
+	})
+	if len(reqs) > maxRecentRequests {
+		reqs = reqs[len(reqs)-maxRecentRequests:]
+	}
+	l.byUser[userID] = reqs
+}
+
+// Recent returns userID's requests, newest first.
+func (l *RequestLog) Recent(userID string) []RecentRequest {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	reqs := l.byUser[userID]
+	out := make([]RecentRequest, len(reqs))
+	for i, r := range reqs {
+		out[len(reqs)-1-i] = r
+	}
+	return out
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+)
+
+// approvalTTL is how long a staged action waits for a human's approval.
+const approvalTTL = time.Hour
+
+// gatedTools only run after a human approves them in the thread.
+var gatedTools = map[string]bool{
+	"sync_argocd_application": true,
+	"purge_cloudflare_cache":  true,
+}
+
+// PendingApproval is a gated action the model asked for, held until a human
+// approves it in the thread. It runs with exactly the staged arguments; the
+// model cannot approve or change it.
+type PendingApproval struct {
+	UserID  string // who asked for the action
+	Tool    string
+	Args    string // the staged tool arguments
+	Summary string // what the action will do, shown to the approver
+	SavedAt time.Time
+}
+
+// ApprovalStore holds at most one pending approval per thread.
+// Safe for concurrent use.
+type ApprovalStore struct {
+	mu      sync.Mutex
+	pending map[string]*PendingApproval // key: "channelID:threadTS"
+}
+
+// NewApprovalStore creates an empty store.
+func NewApprovalStore() *ApprovalStore {
+	return &ApprovalStore{pending: make(map[string]*PendingApproval)}
+}
+
+// Stage holds pa for the thread, replacing any action already pending there.
+func (s *ApprovalStore) Stage(channelID, threadTS string, pa *PendingApproval) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	pa.SavedAt = time.Now()
+	s.pending[sessionKey(channelID, threadTS)] = pa
+}
+
+// Has reports whether an action is waiting for approval in the thread.
+func (s *ApprovalStore) Has(channelID, threadTS string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	_, ok := s.pending[sessionKey(channelID, threadTS)]
+	return ok
+}
+
+// Take removes and returns the thread's pending action, or nil.
+func (s *ApprovalStore) Take(channelID, threadTS string) *PendingApproval {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	key := sessionKey(channelID, threadTS)
+	pa := s.pending[key]
+	delete(s.pending, key)
+	return pa
+}
+
+// prune drops expired approvals. Caller must hold s.mu.
+func (s *ApprovalStore) prune() {
+	for k, pa := range s.pending {
+		if time.Since(pa.SavedAt) > approvalTTL {
+			delete(s.pending, k)
+		}
+	}
+}
+
+// isApproveIntent returns true when a thread reply approves the pending action.
+func isApproveIntent(text string) bool {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!") {
+	case "approve", "approved", "i approve", "yes", "yes go ahead", "go ahead", "lgtm", "do it", "confirm", "confirmed", "ship it":
+		return true
+	}
+	return false
+}
+
+// isRejectIntent returns true when a thread reply turns the pending action down.
+func isRejectIntent(text string) bool {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!") {
+	case "no", "reject", "rejected", "deny", "cancel", "abort", "stop", "don't", "do not":
+		return true
+	}
+	return false
+}
+
+// requestApproval stages a gated tool call for a human to approve in the
+// thread and returns the message for the model. The call itself never runs;
+// runTool audits it as AuditPending.
+func (h *GeneralHandler) requestApproval(ctx context.Context, call ToolCall, tool, summary string) string {
+	if h.approvals == nil || call.AuditTS == "" {
+		return fmt.Sprintf("Error: %s needs a human's approval in a chat thread, and this request has none. Tell the user to ask for it from a thread.", tool)
+	}
+	h.approvals.Stage(call.ChannelID, call.AuditTS, &PendingApproval{UserID: call.UserID, Tool: tool, Args: call.Args, Summary: summary})
+	h.staged = true
+	logging.Ctx(ctx).Infof("[user=%s channel=%s thread=%s] %s waiting for approval", call.UserID, call.ChannelID, call.AuditTS, tool)
+	return fmt.Sprintf("NOT run — waiting for approval.\n%s\n\nPost this to the user and tell them to reply \"approve\" in this thread to run it, or anything else to cancel. It runs only on their reply; do not call %s again for it.", summary, tool)
+}
+
+// approvedRun reports whether call is the run of the action a human just
+// approved. The approval is used up by the first matching call.
+func (h *GeneralHandler) approvedRun(tool string, call ToolCall) bool {
+	if h.approved == nil || h.approved.Tool != tool || h.approved.Args != call.Args {
+		return false
+	}
+	h.approved = nil
+	return true
+}
+
+// RunApproved runs the thread's pending action after userID approved it and
+// posts the outcome in the thread. The usual policy checks apply to the
+// approver.
+func (h *GeneralHandler) RunApproved(channelID, userID, threadTS string, pa *PendingApproval) {
+	ctx := github.WithAttribution(logging.WithRequestID(context.Background(), h.requestID), github.Attribution{AgentID: h.agentID, UserID: userID, ChannelID: channelID})
+	h.currentChannelID = channelID
+	h.currentAuditTS = threadTS
+	h.currentTask = pa.Summary
+	h.approved = pa
+
+	h.logger().Infof("[user=%s channel=%s thread=%s] %s approved (requested by %s)", userID, channelID, threadTS, pa.Tool, pa.UserID)
+	result, outcome := h.executeToolOutcome(ctx, channelID, userID, threadTS, pa.Tool, pa.Args)
+	h.approved = nil
+	metrics.ToolCalls.Inc(h.agentID, pa.Tool)
+	if strings.HasPrefix(result, "Error") {
+		metrics.ToolErrors.Inc(h.agentID, pa.Tool)
+	}
+	h.recordChange(channelID, userID, pa.Tool, pa.Args, result, outcome)
+	h.memory.SetThreadResponse(channelID, threadTS, result)
+	h.replyDefault(channelID, "", threadTS, fmt.Sprintf("Approved by <@%s>.\n%s", userID, result))
+}
+
+// handleApprovalReply settles the thread's pending action with the human
+// message text: an approval runs it, anything else cancels it. It reports
+// whether the message was fully handled.
+func (r *Router) handleApprovalReply(reqID, channelID, threadTS, userID, text string) bool {
+	pa := r.approvals.Take(channelID, threadTS)
+	if pa == nil {
+		return false
+	}
+	lg := logging.Request(reqID)
+	if isApproveIntent(text) {
+		lg.Infof("[user=%s channel=%s thread=%s] thread routed to: approved %s", userID, channelID, threadTS, pa.Tool)
+		r.newGeneralHandler(reqID).RunApproved(channelID, userID, threadTS, pa)
+		return true
+	}
+	lg.Infof("[user=%s channel=%s thread=%s] pending %s cancelled", userID, channelID, threadTS, pa.Tool)
+	msg := fmt.Sprintf("Cancelled the pending %s; it was not approved.", pa.Tool)
+	_ = r.slackClient.PostThreadReply(channelID, threadTS, msg)
+	r.memory.SetThreadResponse(channelID, threadTS, msg)
+	r.sessions.Record(channelID, threadTS, TranscriptEntry{Kind: TranscriptBot, Text: msg})
+	return isRejectIntent(text)
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/jira"
+)
+
+// maxAssigneeSuggestions caps how many people a new ticket suggests.
+const maxAssigneeSuggestions = 3
+
+// assigneeSuggestion is a proposed assignee for a ticket and why.
+type assigneeSuggestion struct {
+	AccountID   string
+	DisplayName string
+	Reason      string
+}
+
+// suggestAssignees proposes assignees for an unassigned ticket from, in
+// order, the Jira component lead, the CODEOWNERS of repo/path, and the most
+// active recent committers there. GitHub people are mapped to Jira through
+// linked accounts, then by name.
+func (h *GeneralHandler) suggestAssignees(ctx context.Context, project string, components []string, repo, path string) []assigneeSuggestion {
+	var out []assigneeSuggestion
+	seen := make(map[string]bool)
+	add := func(u jira.JiraUser, reason string) {
+		if u.AccountID == "" || seen[u.AccountID] || len(out) >= maxAssigneeSuggestions {
+			return
+		}
+		seen[u.AccountID] = true
+		out = append(out, assigneeSuggestion{AccountID: u.AccountID, DisplayName: u.DisplayName, Reason: reason})
+	}
+
+	if len(components) > 0 {
+		if meta, err := h.jiraClient.GetProjectMetadata(project); err == nil {
+			for _, c := range components {
+				for name, lead := range meta.ComponentLeads {
+					if strings.EqualFold(name, c) {
+						add(lead, fmt.Sprintf("lead of component %s", name))
+					}
+				}
+			}
+		}
+	}
+	if repo == "" || h.ghClient == nil {
+		return out
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return out
+	}
+	if o, r, ok := strings.Cut(repo, "/"); ok {
+		owner, repo = o, r
+	}
+	// CODEOWNERS and committers are repository data; don't suggest from
+	// repositories the agent may not read.
+	if !h.repoPolicy.AllowsRead(owner + "/" + repo) {
+		return out
+	}
+
+	where := owner + "/" + repo
+	if path != "" {
+		where += ":" + path
+	}
+	if rules, file, err := h.ghClient.GetCodeowners(ctx, owner, repo, ""); err == nil && file != "" {
+		target := path
+		if target == "" {
+			target = "."
+		}
+		if rule := github.CodeownersFor(rules, target); rule != nil {
+			for _, o := range rule.Owners {
+				for _, login := range h.expandCodeowner(ctx, owner, o) {
+					if u, ok := h.jiraUserForGitHub(ctx, project, login); ok {
+						add(u, fmt.Sprintf("CODEOWNERS %s for %s", o, where))
+					}
+				}
+			}
+		}
+	}
+
+	commits, err := h.ghClient.ListCommits(ctx, owner, repo, github.CommitFilter{Path: path, Since: time.Now().Add(-ownerCommitWindow)}, 100)
+	if err == nil {
+		counts := make(map[string]int)
+		for _, c := range commits {
+			if c.Author != "" && !strings.HasSuffix(c.Author, "[bot]") {
+				counts[c.Author]++
+			}
+		}
+		authors := make([]string, 0, len(counts))
+		for a := range counts {
+			authors = append(authors, a)
+		}
+		sort.Slice(authors, func(i, j int) bool {
+			if counts[authors[i]] != counts[authors[j]] {
+				return counts[authors[i]] > counts[authors[j]]
+			}
+			return authors[i] < authors[j]
+		})
+		for _, a := range authors[:min(len(authors), maxAssigneeSuggestions)] {
+			if u, ok := h.jiraUserForGitHub(ctx, project, a); ok {
+				add(u, fmt.Sprintf("%d commits to %s in the last 90 days", counts[a], where))
+			}
+		}
+	}
+	return out
+}
+
+// expandCodeowner turns a CODEOWNERS entry into GitHub logins: "@user" is
+// the user, "@org/team" the first few team members. Emails are skipped.
+func (h *GeneralHandler) expandCodeowner(ctx context.Context, owner, entry string) []string {
+	if !strings.HasPrefix(entry, "@") {
+		return nil
+	}
+	entry = strings.TrimPrefix(entry, "@")
+	org, slug, isTeam := strings.Cut(entry, "/")
+	if !isTeam {
+		return []string{entry}
+	}
+	if !strings.EqualFold(org, owner) {
+		return nil
+	}
+	members, err := h.ghClient.TeamMembers(ctx, org, slug)
+	if err != nil {
+		return nil
+	}
+	var logins []string
+	for _, m := range members[:min(len(members), maxAssigneeSuggestions)] {
+		logins = append(logins, m.Login)
+	}
+	return logins
+}
+
+// jiraUserForGitHub finds the Jira account of a GitHub user: the linked
+// account when there is one, else an assignable user matching their
+// GitHub profile name.
+func (h *GeneralHandler) jiraUserForGitHub(ctx context.Context, project, login string) (jira.JiraUser, bool) {
+	if h.identities != nil {
+		if id, ok := h.identities.ByGitHubLogin(login); ok && id.JiraAccountID != "" {
+			return jira.JiraUser{AccountID: id.JiraAccountID, DisplayName: login, Active: true}, true
+		}
+	}
+	user, err := h.ghClient.GetUser(ctx, login)
+	if err != nil || user.Name == "" {
+		return jira.JiraUser{}, false
+	}
+	users, err := h.jiraClient.SearchAssignableUsers(user.Name, project)
+	if err != nil || len(users) == 0 {
+		return jira.JiraUser{}, false
+	}
+	best, good := jira.BestUserMatch(users, user.Name)
+	if !good {
+		return jira.JiraUser{}, false
+	}
+	return best, true
+}
+
+// formatAssigneeSuggestions tells the LLM to ask the requester to confirm
+// a suggested assignee for an issue created without one.
+func formatAssigneeSuggestions(issueKey string, suggestions []assigneeSuggestion) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n\nNo assignee was set. Suggested assignee(s) for %s, best first:\n", issueKey)
+	for _, s := range suggestions {
+		fmt.Fprintf(&sb, "- %s (accountId: %s) — %s\n", s.DisplayName, s.AccountID, s.Reason)
+	}
+	fmt.Fprintf(&sb, "Ask the requester in this thread whether to assign %s to %s (or someone else). Only after they confirm, call update_jira_issue with assignee_account_id.", issueKey, suggestions[0].DisplayName)
+	return sb.String()
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAuditEntries bounds the audit entries held in memory for queries. The
+// file keeps everything within the retention period.
+const maxAuditEntries = 50000
+
+// maxAuditErrorRunes bounds the error text kept per entry.
+const maxAuditErrorRunes = 300
+
+// Audit outcomes.
+const (
+	AuditOK     = "ok"      // the tool ran and succeeded
+	AuditError  = "error"   // the tool ran and reported an error
+	AuditDenied = "denied"  // a policy, access rule or freeze refused the call
+	AuditDryRun = "dry_run" // a write tool was previewed, not run, in dry-run mode
+	// AuditPending: a gated tool was staged for a human's approval, not run.
+	AuditPending = "pending_approval"
+)
+
+// AuditEntry is one tool invocation in the audit log.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	AgentID    string    `json:"agent_id"`
+	UserID     string    `json:"user_id"`
+	ChannelID  string    `json:"channel_id"`
+	Tool       string    `json:"tool"`
+	Class      string    `json:"class"` // "read" or "write"
+	ArgsHash   string    `json:"args_hash"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`    // first line of the error, for error and denied outcomes
+	Artifact   string    `json:"artifact,omitempty"` // PR, commit, run or ticket a successful write produced
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// AuditFilter selects audit entries. Empty fields match everything.
+type AuditFilter struct {
+	AgentID   string
+	UserID    string
+	ChannelID string
+	Tool      string
+	Outcome   string
+	Class     string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+func (f AuditFilter) matches(e AuditEntry) bool {
+	switch {
+	case f.AgentID != "" && e.AgentID != f.AgentID,
+		f.UserID != "" && e.UserID != f.UserID,
+		f.ChannelID != "" && e.ChannelID != f.ChannelID,
+		f.Tool != "" && e.Tool != f.Tool,
+		f.Outcome != "" && e.Outcome != f.Outcome,
+		f.Class != "" && e.Class != f.Class,
+		!f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// AuditLog records every tool invocation across agents, so it can be
+// reviewed what the bot did on whose behalf. When created with a path,
+// entries are appended to that JSONL file; on startup, entries older than
+// the retention period are compacted away and the rest reloaded. Safe for
+// concurrent use.
+type AuditLog struct {
+	mu        sync.RWMutex
+	file      jsonlFile[AuditEntry]
+	retention time.Duration
+	entries   []AuditEntry
+}
+
+// NewAuditLog creates an audit log, loading entries newer than retention
+// from path when it is non-empty and the file exists. A zero retention
+// keeps everything.
+func NewAuditLog(path string, retention time.Duration) (*AuditLog, error) {
+	a := &AuditLog{file: jsonlFile[AuditEntry]{path: path, name: "audit log", tag: "audit"}, retention: retention}
+	if path == "" {
+		return a, nil
+	}
+	kept, err := a.file.load(func(e AuditEntry) bool {
+		return retention <= 0 || time.Since(e.Time) <= retention
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(kept) > maxAuditEntries {
+		kept = kept[len(kept)-maxAuditEntries:]
+	}
+	a.entries = kept
+	return a, nil
+}
+
+// Len returns the number of entries held in memory.
+func (a *AuditLog) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.entries)
+}
+
+// Record stores an entry. Write failures are logged, not returned.
+func (a *AuditLog) Record(e AuditEntry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, e)
+	if len(a.entries) > maxAuditEntries {
+		a.entries = a.entries[len(a.entries)-maxAuditEntries:]
+	}
+
+	if a.file.path != "" {
+		a.file.append(e)
+	}
+}
+
+// Query returns the entries matching f, newest first.
+func (a *AuditLog) Query(f AuditFilter) []AuditEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := []AuditEntry{}
+	for i := len(a.entries) - 1; i >= 0; i-- {
+		if !f.matches(a.entries[i]) {
+			continue
+		}
+		out = append(out, a.entries[i])
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// WriteAuditCSV writes entries as CSV with a header row.
+func WriteAuditCSV(w io.Writer, entries []AuditEntry) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"time", "agent_id", "user_id", "channel_id", "tool", "class", "args_hash", "outcome", "error", "artifact", "duration_ms"})
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			e.Time.UTC().Format(time.RFC3339), e.AgentID, e.UserID, e.ChannelID, e.Tool, e.Class,
+			e.ArgsHash, e.Outcome, e.Error, e.Artifact, strconv.FormatInt(e.DurationMS, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// recordAudit stores a tool invocation in the audit log.
+func (h *GeneralHandler) recordAudit(channelID, userID string, def *ToolDef, name, argsJSON, result, outcome string, took time.Duration) {
+	if h.audit == nil {
+		return
+	}
+	e := AuditEntry{
+		AgentID:    h.agentID,
+		UserID:     userID,
+		ChannelID:  channelID,
+		Tool:       name,
+		Class:      "read",
+		ArgsHash:   hashArgs(argsJSON),
+		Outcome:    outcome,
+		DurationMS: took.Milliseconds(),
+	}
+	if def != nil && def.Class == ToolWrite {
+		e.Class = "write"
+	}
+	if (outcome == AuditOK || outcome == AuditDryRun) && strings.HasPrefix(result, "Error") {
+		e.Outcome = AuditError
+	}
+	switch e.Outcome {
+	case AuditOK:
+		if e.Class == "write" {
+			e.Artifact = artifactFromResult(result, artifactFallback(argsJSON))
+		}
+	case AuditPending:
+	default:
+		e.Error, _, _ = strings.Cut(result, "\n")
+		if r := []rune(e.Error); len(r) > maxAuditErrorRunes {
+			e.Error = string(r[:maxAuditErrorRunes]) + "…"
+		}
+	}
+	h.audit.Record(e)
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// benchUserID is the pseudo user ID used for tool calls during a benchmark run.
+const benchUserID = "bench"
+
+// BenchCase is a recorded user request replayed by the benchmark harness.
+type BenchCase struct {
+	Time    time.Time `json:"time"`
+	AgentID string    `json:"agent_id"`
+	Text    string    `json:"text"`
+}
+
+// BenchRecorder appends incoming general-handler requests to a JSONL corpus
+// file so they can later be replayed against candidate models. Safe for
+// concurrent use.
+type BenchRecorder struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewBenchRecorder creates a recorder that appends to the file at path.
+func NewBenchRecorder(path string) *BenchRecorder {
+	return &BenchRecorder{path: path}
+}
+
+// Record appends a request to the corpus. Failures are logged, not returned,
+// so recording never affects the user-facing request.
+func (b *BenchRecorder) Record(agentID, text string) {
+	line, err := json.Marshal(BenchCase{Time: time.Now().UTC(), AgentID: agentID, Text: text})
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		logging.Errorf("[bench] failed to open corpus %s: %v", b.path, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logging.Errorf("[bench] failed to record request: %v", err)
+	}
+}
+
+// LoadBenchCorpus reads recorded requests for agentID (all agents when empty),
+// returning at most limit of the most recent cases (all when limit <= 0).
+func LoadBenchCorpus(path, agentID string, limit int) ([]BenchCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bench corpus: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var cases []BenchCase
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var c BenchCase
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil || c.Text == "" {
+			continue
+		}
+		if agentID != "" && c.AgentID != agentID {
+			continue
+		}
+		cases = append(cases, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read bench corpus: %w", err)
+	}
+	if limit > 0 && len(cases) > limit {
+		cases = cases[len(cases)-limit:]
+	}
+	return cases, nil
+}
+
+// BenchRun is the outcome of replaying one case against one model.
+type BenchRun struct {
+	Model      string `json:"model"`
+	Rounds     int    `json:"rounds"`
+	ToolCalls  int    `json:"tool_calls"`
+	ToolErrors int    `json:"tool_errors"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Answer     string `json:"answer,omitempty"` // truncated final answer
+}
+
+// BenchComparison pairs the runs of both models for one case.
+type BenchComparison struct {
+	Case BenchCase `json:"case"`
+	A    BenchRun  `json:"a"`
+	B    BenchRun  `json:"b"`
+}
+
+// BenchSummary aggregates a model's runs across the corpus.
+type BenchSummary struct {
+	Model        string  `json:"model"`
+	Cases        int     `json:"cases"`
+	Successes    int     `json:"successes"`
+	SuccessRate  float64 `json:"success_rate"`
+	AvgRounds    float64 `json:"avg_rounds"`
+	AvgLatencyMS int64   `json:"avg_latency_ms"`
+	ToolErrors   int     `json:"tool_errors"`
+}
+
+// BenchReport is the result of comparing two models over a corpus.
+type BenchReport struct {
+	AgentID string            `json:"agent_id"`
+	A       BenchSummary      `json:"a"`
+	B       BenchSummary      `json:"b"`
+	Cases   []BenchComparison `json:"cases"`
+}
+
+// Bench replays cases through this agent's tool loop against models a and b.
+// Read-only tools execute for real; write tools are stubbed so a benchmark
+// never changes GitHub, Jira, or Slack.
+func (r *Router) Bench(ctx context.Context, cases []BenchCase, a, b *github.ModelsClient) BenchReport {
+	report := BenchReport{AgentID: r.agentID}
+	for i, c := range cases {
+		logging.Ctx(ctx).Infof("[bench] agent=%s case %d/%d: %q", r.agentID, i+1, len(cases), c.Text)
+		report.Cases = append(report.Cases, BenchComparison{
+			Case: c,
+			A:    r.newGeneralHandler(logging.RequestID(ctx)).runHeadless(ctx, a, c.Text),
+			B:    r.newGeneralHandler(logging.RequestID(ctx)).runHeadless(ctx, b, c.Text),
+		})
+	}
+	report.A = summarizeBench(a.Model(), report.Cases, func(c BenchComparison) BenchRun { return c.A })
+	report.B = summarizeBench(b.Model(), report.Cases, func(c BenchComparison) BenchRun { return c.B })
+	return report
+}
+
+func summarizeBench(model string, cases []BenchComparison, pick func(BenchComparison) BenchRun) BenchSummary {
+	s := BenchSummary{Model: model, Cases: len(cases)}
+	if len(cases) == 0 {
+		return s
+	}
+	var rounds int
+	var latency int64
+	for _, c := range cases {
+		run := pick(c)
+		if run.Success {
+			s.Successes++
+		}
+		rounds += run.Rounds
+		latency += run.LatencyMS
+		s.ToolErrors += run.ToolErrors
+	}
+	s.SuccessRate = float64(s.Successes) / float64(len(cases))
+	s.AvgRounds = float64(rounds) / float64(len(cases))
+	s.AvgLatencyMS = latency / int64(len(cases))
+	return s
+}
+
+// runHeadless runs the general tool loop for text against client without
+// Slack I/O, channel context, or conversation memory.
+func (h *GeneralHandler) runHeadless(ctx context.Context, client *github.ModelsClient, text string) BenchRun {
+	run := BenchRun{Model: client.Model()}
+	start := time.Now()
+	defer func() { run.LatencyMS = time.Since(start).Milliseconds() }()
+
+	h.activeBranches = make(map[string]*activeBranchInfo)
+	tools := h.buildTools()
+	toolTokens := estimateToolsTokens(tools)
+
+	systemMsg := h.systemPrompt()
+	systemMsg = strings.Replace(systemMsg, "{{MODEL}}", client.Model(), 1)
+	systemMsg = strings.Replace(systemMsg, "{{USER_ID}}", benchUserID, 1)
+
+	messages := []github.ChatMessage{
+		github.NewChatMessage("system", systemMsg),
+		github.NewChatMessage("user", text),
+	}
+
+	rounds := h.maxToolRounds
+	if rounds <= 0 {
+		rounds = 50
+	}
+
+	for i := 0; i < rounds; i++ {
+		run.Rounds = i + 1
+		trimMessagesToBudget(messages, toolTokens, h.tokenBudget())
+		resp, err := client.CompleteWithTools(ctx, messages, tools)
+		if err != nil {
+			run.Error = err.Error()
+			return run
+		}
+		if len(resp.Choices) == 0 {
+			run.Error = "no choices returned"
+			return run
+		}
+
+		choice := resp.Choices[0]
+		if len(choice.Message.ToolCalls) == 0 {
+			run.Answer = truncateBenchAnswer(choice.Message.Content)
+			run.Success = strings.TrimSpace(choice.Message.Content) != ""
+			if !run.Success {
+				run.Error = "empty answer"
+			}
+			return run
+		}
+
+		messages = append(messages, github.ChatMessage{
+			Role:      "assistant",
+			Content:   choice.Message.Content,
+			ToolCalls: choice.Message.ToolCalls,
+		})
+		for _, tc := range choice.Message.ToolCalls {
+			run.ToolCalls++
+			var result string
+			if IsWriteTool(tc.Function.Name) {
+				result = fmt.Sprintf("Benchmark mode: %s was not executed. Assume it succeeded and continue.", tc.Function.Name)
+			} else {
+				result = h.executeTool(ctx, "", benchUserID, "", tc.Function.Name, tc.Function.Arguments)
+			}
+			if strings.HasPrefix(result, "Error") {
+				run.ToolErrors++
+			}
+			messages = append(messages, github.NewToolResultMessage(tc.ID, result))
+		}
+	}
+
+	run.Error = "exceeded max tool rounds"
+	return run
+}
+
+func truncateBenchAnswer(s string) string {
+	const max = 500
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "…"
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+)
+
+const (
+	// maxBulkRepos bounds the repositories one bulk change may touch.
+	maxBulkRepos = 100
+	// bulkProgressEvery is how many repositories pass between progress
+	// updates in the request's thread.
+	bulkProgressEvery = 10
+	// bulkAttempts is how often a repository whose change failed for a
+	// possibly transient reason is tried.
+	bulkAttempts = 3
+)
+
+// bulkRetryDelay is the pause before retrying a failed repository, scaled
+// by the attempt number.
+var bulkRetryDelay = 2 * time.Second
+
+// bulkTools apply one change across many repositories. They are registered
+// in init because bulk_modify_file executes modify_file for each repository.
+// Like run_runbook it is not a write tool itself: every modify_file call
+// goes through the policy, read-only, dry-run and freeze checks, and is
+// audited and recorded on its own. It is hidden where modify_file is.
+var bulkTools = []*ToolDef{
+	{
+		Name:        "bulk_modify_file",
+		Description: "Apply the same find-and-replace change to a file in many repositories at once (e.g. bump a shared GitHub Action version or update a CI badge in every repo matching a pattern), opening one PR per repository. Each repository goes through modify_file with the usual policy checks. First call it without confirmed to get the list of repositories it would change, show that list to the user, and only after they explicitly approve call it again with the same arguments and confirmed=true. The result is a summary table of opened PRs, skipped and failed repositories; to retry failures, call it again with repos set to the failed ones.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo_pattern":{"type":"string","description":"Glob matched against repository names without owner, e.g. 'svc-*' or '*'. Ignored when repos is given."},
+				"repos":{"type":"array","items":{"type":"string"},"description":"Explicit repository names (without owner), e.g. to retry failed ones"},
+				"exclude":{"type":"array","items":{"type":"string"},"description":"Repository names or globs to leave out"},
+				"path":{"type":"string","description":"File path within each repository"},
+				"old_content":{"type":"string","description":"The exact text to find in the file. Repositories where it doesn't occur exactly once are skipped."},
+				"new_content":{"type":"string","description":"The replacement text"},
+				"description":{"type":"string","description":"Short description of the change (commit message and PR title)"},
+				"confirmed":{"type":"boolean","description":"Set to true only after the user approved the repository list"}
+			},
+			"required":["path","old_content","new_content","description"]
+		}`),
+		Available: (*GeneralHandler).bulkModifyAvailable,
+		Run:       (*GeneralHandler).toolBulkModifyFile,
+	},
+}
+
+func init() {
+	builtinTools.Register(bulkTools...)
+}
+
+// bulkModifyAvailable offers bulk_modify_file only where modify_file itself
+// could run: it is registered and available, the agent's tool policy allows
+// it and the agent is not read-only. Otherwise every repository would fail
+// as denied one by one.
+func (h *GeneralHandler) bulkModifyAvailable() bool {
+	d := h.registry().Lookup("modify_file")
+	if d == nil || (d.Available != nil && !d.Available(h)) {
+		return false
+	}
+	return h.toolPolicy.Allows(d.Name) && !(d.Class == ToolWrite && h.readOnly.Enabled(h.agentID))
+}
+
+// bulkResult is the outcome of a bulk change in one repository.
+type bulkResult struct {
+	repo, status, detail string
+}
+
+func (h *GeneralHandler) toolBulkModifyFile(ctx context.Context, call ToolCall) string {
+	var args struct {
+		RepoPattern string   `json:"repo_pattern"`
+		Repos       []string `json:"repos"`
+		Exclude     []string `json:"exclude"`
+		Path        string   `json:"path"`
+		OldContent  string   `json:"old_content"`
+		NewContent  string   `json:"new_content"`
+		Description string   `json:"description"`
+		Confirmed   bool     `json:"confirmed"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.RepoPattern == "" && len(args.Repos) == 0 {
+		return "Error: give repo_pattern or repos."
+	}
+	if args.OldContent == "" {
+		return "Error: old_content is empty."
+	}
+
+	repos, err := h.bulkTargets(ctx, args.RepoPattern, args.Repos, args.Exclude)
+	if err != nil {
+		return fmt.Sprintf("Error listing repositories: %v", err)
+	}
+	if len(repos) == 0 {
+		return "No repositories match (repositories this agent may not write to are left out)."
+	}
+	if len(repos) > maxBulkRepos {
+		return fmt.Sprintf("Error: %d repositories match, more than the %d one bulk change may touch. Narrow repo_pattern or use exclude.", len(repos), maxBulkRepos)
+	}
+	if !args.Confirmed {
+		return fmt.Sprintf("This would change %s in %d repositories, opening one PR each:\n%s\n\nShow this list to the user and ask them to approve. Call bulk_modify_file again with the same arguments and confirmed=true only after they explicitly approve.",
+			args.Path, len(repos), strings.Join(repos, ", "))
+	}
+
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] bulk change of %s in %d repos: %s", call.UserID, call.ChannelID, args.Path, len(repos), args.Description)
+	h.bulkProgress(call, fmt.Sprintf(":arrows_counterclockwise: Applying %q to %s in %d repositories…", args.Description, args.Path, len(repos)))
+
+	var results []bulkResult
+	counts := map[string]int{}
+	for i, repo := range repos {
+		res := h.bulkApply(ctx, call, repo, args.Path, args.OldContent, args.NewContent, args.Description)
+		results = append(results, res)
+		counts[res.status]++
+		if done := i + 1; done%bulkProgressEvery == 0 && done < len(repos) {
+			h.bulkProgress(call, fmt.Sprintf(":hourglass_flowing_sand: %d/%d repositories done (%s).", done, len(repos), bulkCounts(counts)))
+		}
+	}
+
+	table := &Table{Name: "bulk-change", Columns: []string{"Repository", "Status", "PR / reason"}}
+	var failed []string
+	for _, r := range results {
+		table.Rows = append(table.Rows, []string{r.repo, r.status, r.detail})
+		if r.status == "failed" {
+			failed = append(failed, r.repo)
+		}
+	}
+	summary := fmt.Sprintf("Bulk change %q to %s in %d repositories: %s.", args.Description, args.Path, len(repos), bulkCounts(counts))
+	if len(failed) > 0 {
+		retry, _ := json.Marshal(failed)
+		summary += fmt.Sprintf(" To retry the failed ones, call bulk_modify_file again with confirmed=true and repos=%s.", retry)
+	}
+	return h.presentTable(call, summary, table)
+}
+
+// bulkTargets resolves the repositories a bulk change applies to: repos, or
+// every repository matching pattern, minus exclude and the repositories the
+// agent may not write to. Names are returned without owner.
+func (h *GeneralHandler) bulkTargets(ctx context.Context, pattern string, repos, exclude []string) ([]string, error) {
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		all, err := h.scm.ListRepos(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		for _, full := range all {
+			name := full[strings.LastIndex(full, "/")+1:]
+			if ok, _ := path.Match(pattern, name); ok {
+				repos = append(repos, name)
+			}
+		}
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	for _, name := range repos {
+		if seen[name] || matchAnyGlob(exclude, name) {
+			continue
+		}
+		seen[name] = true
+		if h.repoPolicy.Restricted() && !h.repoPolicy.AllowsWrite(owner+"/"+name) {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// bulkApply runs modify_file for one repository, retrying failures that may
+// be transient. Refused calls and changes that don't apply are not retried.
+func (h *GeneralHandler) bulkApply(ctx context.Context, call ToolCall, repo, filePath, oldContent, newContent, description string) bulkResult {
+	stepJSON, _ := json.Marshal(map[string]string{
+		"repo": repo, "path": filePath, "old_content": oldContent, "new_content": newContent, "description": description,
+	})
+	for attempt := 1; ; attempt++ {
+		result, outcome := h.executeToolOutcome(ctx, call.ChannelID, call.UserID, call.AuditTS, "modify_file", string(stepJSON))
+		metrics.ToolCalls.Inc(h.agentID, "modify_file")
+		failed := strings.HasPrefix(result, "Error")
+		if failed {
+			metrics.ToolErrors.Inc(h.agentID, "modify_file")
+		}
+		h.recordChange(call.ChannelID, call.UserID, "modify_file", string(stepJSON), result, outcome)
+
+		reason, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(result, "Error"), ": "), "\n")
+		switch {
+		case outcome == AuditDryRun && !failed:
+			return bulkResult{repo, "dry run", "would open a PR"}
+		case !failed:
+			return bulkResult{repo, "PR opened", artifactFromResult(result, "done")}
+		case outcome == AuditDenied:
+			return bulkResult{repo, "denied", reason}
+		case strings.Contains(result, "old_content"):
+			return bulkResult{repo, "skipped", reason}
+		case attempt >= bulkAttempts:
+			return bulkResult{repo, "failed", reason}
+		}
+		logging.Ctx(ctx).Warnf("[user=%s channel=%s] bulk change: %s failed (attempt %d/%d), retrying: %s", call.UserID, call.ChannelID, repo, attempt, bulkAttempts, reason)
+		select {
+		case <-ctx.Done():
+			return bulkResult{repo, "failed", ctx.Err().Error()}
+		case <-time.After(time.Duration(attempt) * bulkRetryDelay):
+		}
+	}
+}
+
+// bulkProgress posts a progress update to the request's thread, if any.
+func (h *GeneralHandler) bulkProgress(call ToolCall, msg string) {
+	if call.AuditTS == "" {
+		return
+	}
+	if err := h.slackClient.PostThreadReply(call.ChannelID, call.AuditTS, msg); err != nil {
+		h.logger().Errorf("[user=%s channel=%s] failed to post bulk change progress: %v", call.UserID, call.ChannelID, err)
+	}
+}
+
+// bulkCounts renders status counts in a fixed order, e.g. "3 PR opened, 1 failed".
+func bulkCounts(counts map[string]int) string {
+	var parts []string
+	for _, status := range []string{"PR opened", "dry run", "skipped", "denied", "failed"} {
+		if n := counts[status]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matchAnyGlob reports whether name matches one of patterns.
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
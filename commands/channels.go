@@ -0,0 +1,38 @@
+package commands
+
+import "github.com/justmike1/ovad/logging"
+
+// ChannelAgents picks the agent that answers in a Slack channel when the user
+// didn't name one (e.g. an @mention), so #devops reaches the DevOps agent and
+// #qa the QA agent without knowing the right slash command.
+type ChannelAgents struct {
+	byChannel map[string]string
+	fallback  string
+}
+
+// NewChannelAgents creates a channel map that falls back to fallback for
+// unmapped channels.
+func NewChannelAgents(fallback string) *ChannelAgents {
+	return &ChannelAgents{byChannel: make(map[string]string), fallback: fallback}
+}
+
+// Map routes channelID to agentID. A later mapping of the same channel wins.
+func (c *ChannelAgents) Map(channelID, agentID string) {
+	if prev, ok := c.byChannel[channelID]; ok && prev != agentID {
+		logging.Infof("[channels] channel %s remapped from agent %q to %q", channelID, prev, agentID)
+	}
+	c.byChannel[channelID] = agentID
+}
+
+// Resolve returns the agent for channelID.
+func (c *ChannelAgents) Resolve(channelID string) string {
+	if agentID, ok := c.byChannel[channelID]; ok {
+		return agentID
+	}
+	return c.fallback
+}
+
+// Len returns the number of mapped channels.
+func (c *ChannelAgents) Len() int {
+	return len(c.byChannel)
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+)
+
+// checkpointTTL is how long a paused conversation can be resumed.
+const checkpointTTL = time.Hour
+
+// Checkpoint is the saved state of a tool loop that hit the max-rounds limit,
+// so the user can resume it from the same thread.
+type Checkpoint struct {
+	UserID    string
+	Task      string
+	Messages  []github.ChatMessage
+	CodeModel bool // whether the loop had switched to the code model
+	Branches  map[string]*activeBranchInfo
+	Rounds    int  // total rounds used so far across continuations
+	DryRun    bool // whether write tools were only previewed
+	SavedAt   time.Time
+}
+
+// CheckpointStore holds paused conversations keyed by Slack thread.
+// Safe for concurrent use.
+type CheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]*Checkpoint // key: "channelID:threadTS"
+}
+
+// NewCheckpointStore creates an empty store.
+func NewCheckpointStore() *CheckpointStore {
+	return &CheckpointStore{checkpoints: make(map[string]*Checkpoint)}
+}
+
+// Save stores cp for the thread, replacing any previous checkpoint.
+func (s *CheckpointStore) Save(channelID, threadTS string, cp *Checkpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	cp.SavedAt = time.Now()
+	s.checkpoints[sessionKey(channelID, threadTS)] = cp
+}
+
+// Has reports whether a resumable checkpoint exists for the thread.
+func (s *CheckpointStore) Has(channelID, threadTS string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	_, ok := s.checkpoints[sessionKey(channelID, threadTS)]
+	return ok
+}
+
+// Take removes and returns the checkpoint for the thread, or nil.
+func (s *CheckpointStore) Take(channelID, threadTS string) *Checkpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	key := sessionKey(channelID, threadTS)
+	cp := s.checkpoints[key]
+	delete(s.checkpoints, key)
+	return cp
+}
+
+// prune drops expired checkpoints. Caller must hold s.mu.
+func (s *CheckpointStore) prune() {
+	for k, cp := range s.checkpoints {
+		if time.Since(cp.SavedAt) > checkpointTTL {
+			delete(s.checkpoints, k)
+		}
+	}
+}
+
+// isContinueIntent returns true when a thread reply asks to resume a paused request.
+func isContinueIntent(text string) bool {
+	switch strings.Trim(strings.TrimSpace(text), ".!") {
+	case "continue", "go on", "keep going", "resume", "proceed", "yes continue", "please continue":
+		return true
+	}
+	return false
+}
+
+// progressFromMessages lists the tool calls made so far, used when the model
+// can't produce a progress summary itself.
+func progressFromMessages(messages []github.ChatMessage) string {
+	counts := make(map[string]int)
+	for _, m := range messages {
+		for _, tc := range m.ToolCalls {
+			counts[tc.Function.Name]++
+		}
+	}
+	if len(counts) == 0 {
+		return "No tools were called yet."
+	}
+	names := make([]string, 0, len(counts))
+	for n := range counts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString("Tool calls so far:\n")
+	for _, n := range names {
+		fmt.Fprintf(&sb, "  • %s ×%d\n", n, counts[n])
+	}
+	return sb.String()
+}
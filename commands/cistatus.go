@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// maxCIStatusRepos bounds the repositories one ci_status call checks.
+const maxCIStatusRepos = 50
+
+// ciStatusTools report the latest GitHub Actions results across repositories.
+var ciStatusTools = []*ToolDef{
+	{
+		Name:        "ci_status",
+		Description: "Show the latest GitHub Actions run of every workflow on a branch (default: each repository's default branch) across one or many repositories, e.g. 'is main green across the platform repos?' or a scheduled CI health digest. Returns a table of workflow, result, commit and run link per repository, plus a passing/failing/running count. Use get_workflow_run on a failing run's URL to dig into it.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repos":{"type":"array","items":{"type":"string"},"description":"Repository names (without owner)"},
+				"repo_pattern":{"type":"string","description":"Glob matched against repository names without owner, e.g. 'platform-*'. Ignored when repos is given."},
+				"branch":{"type":"string","description":"Branch to check (optional, default: each repository's default branch)"},
+				"workflow":{"type":"string","description":"Only workflows whose name contains this text (optional, case-insensitive)"},
+				"failing_only":{"type":"boolean","description":"Only list workflows whose latest run failed (default: false)"}
+			}
+		}`),
+		Available: (*GeneralHandler).githubConfigured,
+		Run:       (*GeneralHandler).toolCIStatus,
+	},
+}
+
+func (h *GeneralHandler) toolCIStatus(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repos       []string `json:"repos"`
+		RepoPattern string   `json:"repo_pattern"`
+		Branch      string   `json:"branch"`
+		Workflow    string   `json:"workflow"`
+		FailingOnly bool     `json:"failing_only"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if len(args.Repos) == 0 && args.RepoPattern == "" {
+		return "Error: give repos or repo_pattern."
+	}
+
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	repos := args.Repos
+	if len(repos) == 0 {
+		all, err := h.ghClient.ListOrgRepos(ctx, owner)
+		if err != nil {
+			return fmt.Sprintf("Error listing repositories: %v", err)
+		}
+		for _, full := range h.filterReadableRepos(all) {
+			name := full[strings.LastIndex(full, "/")+1:]
+			if ok, _ := path.Match(args.RepoPattern, name); ok {
+				repos = append(repos, name)
+			}
+		}
+	} else if h.repoPolicy.Restricted() {
+		var allowed []string
+		for _, name := range repos {
+			if h.repoPolicy.AllowsRead(owner + "/" + name) {
+				allowed = append(allowed, name)
+			}
+		}
+		repos = allowed
+	}
+	if len(repos) == 0 {
+		return "No repositories match (repositories this agent may not read are left out)."
+	}
+	if len(repos) > maxCIStatusRepos {
+		return fmt.Sprintf("Error: %d repositories match, more than the %d one call checks. Narrow repo_pattern or pass repos.", len(repos), maxCIStatusRepos)
+	}
+
+	table := &Table{Name: "ci-status", Columns: []string{"Repository", "Branch", "Workflow", "Result", "Commit", "Updated", "Run"}, Inline: 6}
+	counts := map[string]int{}
+	for _, repo := range repos {
+		branch := args.Branch
+		if branch == "" {
+			if branch, err = h.ghClient.GetDefaultBranch(ctx, owner, repo); err != nil {
+				table.Rows = append(table.Rows, []string{repo, "", "", "error", "", "", err.Error()})
+				counts["error"]++
+				continue
+			}
+		}
+		runs, err := h.ghClient.LatestWorkflowRuns(ctx, owner, repo, branch)
+		if err != nil {
+			table.Rows = append(table.Rows, []string{repo, branch, "", "error", "", "", err.Error()})
+			counts["error"]++
+			continue
+		}
+		listed := 0
+		for _, r := range runs {
+			if args.Workflow != "" && !strings.Contains(strings.ToLower(r.Workflow), strings.ToLower(args.Workflow)) {
+				continue
+			}
+			result := ciResult(r.Status, r.Conclusion)
+			counts[result]++
+			if args.FailingOnly && result != "failing" {
+				continue
+			}
+			listed++
+			table.Rows = append(table.Rows, []string{repo, branch, r.Workflow, ciResultLabel(r.Status, r.Conclusion), r.SHA[:min(7, len(r.SHA))], r.UpdatedAt.Format("2006-01-02 15:04"), r.URL})
+		}
+		if len(runs) == 0 && !args.FailingOnly {
+			table.Rows = append(table.Rows, []string{repo, branch, "", "no recent runs", "", "", ""})
+		} else if listed == 0 && !args.FailingOnly && args.Workflow != "" {
+			table.Rows = append(table.Rows, []string{repo, branch, "", "no matching workflow", "", "", ""})
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] checked CI status of %d repos", call.UserID, call.ChannelID, len(repos))
+
+	where := "the default branch"
+	if args.Branch != "" {
+		where = args.Branch
+	}
+	summary := fmt.Sprintf("Latest workflow runs on %s of %d repositories: %s.", where, len(repos), ciCounts(counts))
+	if len(table.Rows) == 0 {
+		return summary + " Nothing is failing."
+	}
+	return h.presentTable(call, summary, table)
+}
+
+// ciResult buckets a run into "passing", "failing", "running" or "other"
+// (cancelled, skipped, neutral, ...).
+func ciResult(status, conclusion string) string {
+	if status != "completed" {
+		return "running"
+	}
+	switch conclusion {
+	case "success":
+		return "passing"
+	case "failure", "timed_out", "startup_failure", "action_required":
+		return "failing"
+	}
+	return "other"
+}
+
+// ciResultLabel is the table cell for a run: its conclusion once completed,
+// else its status (e.g. "in progress").
+func ciResultLabel(status, conclusion string) string {
+	if status != "completed" {
+		return strings.ReplaceAll(status, "_", " ")
+	}
+	return conclusion
+}
+
+// ciCounts renders result counts in a fixed order, e.g. "12 passing, 1 failing".
+func ciCounts(counts map[string]int) string {
+	var parts []string
+	for _, result := range []string{"passing", "failing", "running", "other", "error"} {
+		if n := counts[result]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, result))
+		}
+	}
+	if len(parts) == 0 {
+		return "no recent runs"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// summarizerPrompt instructs the summarizer model how to compress tool output.
+const summarizerPrompt = `You compress tool output for another AI assistant that is working on a task.
+Keep ONLY the parts of the tool output relevant to the task: exact error messages, failing test names, file paths, line numbers, identifiers, URLs, versions, and code snippets that matter.
+Quote relevant lines verbatim. Drop boilerplate, repeated lines, progress output, and unrelated content.
+Never invent information. If nothing is relevant, say so in one line.`
+
+// nonCompressibleTools are never compressed: write-tool confirmations are short
+// and carry URLs, expand_result must return the full text by definition, and
+// run_runbook and bulk_modify_file report step outcomes and approval
+// instructions verbatim.
+var nonCompressibleTools = map[string]bool{
+	"expand_result":    true,
+	"run_runbook":      true,
+	"bulk_modify_file": true,
+}
+
+// compressResult runs a large tool result through the summarizer model,
+// caching the full text so the LLM can fetch it with expand_result.
+// Results below the threshold, errors, and write-tool results are returned unchanged.
+func (h *GeneralHandler) compressResult(ctx context.Context, userID, channelID, name, result string) string {
+	if h.summarizer == nil || h.compressThreshold <= 0 || nonCompressibleTools[name] || IsWriteTool(name) ||
+		strings.HasPrefix(result, "Error") {
+		return result
+	}
+	size := estimateTokens(result)
+	if size < h.compressThreshold {
+		return result
+	}
+
+	userPrompt := fmt.Sprintf("Task:\n%s\n\nTool: %s\n\nTool output:\n%s", h.currentTask, name, keepHeadAndTail(result, h.tokenBudget()/2))
+	summary, err := h.summarizer.Complete(ctx, summarizerPrompt, userPrompt)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		logging.Ctx(ctx).Warnf("[user=%s channel=%s] tool result compression failed for %s, using full result: %v", userID, channelID, name, err)
+		return result
+	}
+
+	if h.resultCache == nil {
+		h.resultCache = make(map[string]string)
+	}
+	id := fmt.Sprintf("r%d", len(h.resultCache)+1)
+	h.resultCache[id] = result
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] compressed %s result %s from ~%d to ~%d tokens",
+		userID, channelID, name, id, size, estimateTokens(summary))
+
+	return fmt.Sprintf("[Compressed %s output (id=%s, ~%d tokens). Call expand_result with id=%q if you need the full text.]\n\n%s",
+		name, id, size, id, summary)
+}
+
+// compressTools let the LLM retrieve a compressed tool result in full.
+var compressTools = []*ToolDef{
+	{
+		Name:        "expand_result",
+		Description: "Return the full, uncompressed output of an earlier tool call whose result was compressed. Use only when the compressed summary is missing details you need (e.g. exact lines of a file or log).",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"id":{"type":"string","description":"The result id from the compressed result header (e.g. 'r1')"}
+			},
+			"required":["id"]
+		}`),
+		Available: (*GeneralHandler).compressionEnabled,
+		Run:       (*GeneralHandler).toolExpandResult,
+	},
+}
+
+func (h *GeneralHandler) toolExpandResult(ctx context.Context, call ToolCall) string {
+	var args struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	full, ok := h.resultCache[args.ID]
+	if !ok {
+		return fmt.Sprintf("Error: no compressed result with id %q in this conversation.", args.ID)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] expanded compressed result %s", call.UserID, call.ChannelID, args.ID)
+	return full
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/prompts"
+)
+
+// confidenceInstruction asks the model to rate its final answer. The rating
+// line is stripped before the answer is posted.
+const confidenceInstruction = "\n\nEnd every final answer with a line of the form `Confidence: high`, `Confidence: medium` or `Confidence: low`. " +
+	"Use high only when the answer is backed by what you read with your tools or is certain; use low when you are guessing, " +
+	"could not verify key facts, or the tools failed. The line is removed before the user sees the answer."
+
+// lowConfidenceDisclaimer is prefixed to answers judged low-confidence.
+const lowConfidenceDisclaimer = ":grey_question: _I'm not confident about this answer; please verify it before relying on it._\n\n"
+
+// confidenceLineRe matches the trailing self-reported rating, tolerating
+// the bold or italic markup models like to add.
+var confidenceLineRe = regexp.MustCompile(`(?i)\n?[ \t]*[_*(]*confidence[_*]*:[_* \t]*(high|medium|low)[_*)]*[ \t.]*\s*$`)
+
+// splitConfidence removes the self-reported rating from the end of an
+// answer and returns it in lower case ("" when the model gave none).
+func splitConfidence(content string) (string, string) {
+	m := confidenceLineRe.FindStringSubmatchIndex(content)
+	if m == nil {
+		return content, ""
+	}
+	return strings.TrimRight(content[:m[0]], " \t\n"), strings.ToLower(content[m[2]:m[3]])
+}
+
+// toolEvidence counts the tool calls in a conversation and how many of them
+// succeeded. Successful calls are the evidence an answer can rest on.
+func toolEvidence(messages []github.ChatMessage) (calls, succeeded int) {
+	for _, m := range messages {
+		if m.Role != "tool" {
+			continue
+		}
+		calls++
+		if !strings.HasPrefix(m.Content, "Error") {
+			succeeded++
+		}
+	}
+	return calls, succeeded
+}
+
+// lowConfidence reports why an answer should be treated as low-confidence,
+// or "" when it shouldn't. The model's own rating counts most; a medium
+// rating with nothing read to back it, or an answer given after every tool
+// call failed, counts as low too. Answers without a rating and without
+// tool calls (greetings, refusals) are left alone.
+func lowConfidence(rating string, messages []github.ChatMessage) string {
+	calls, succeeded := toolEvidence(messages)
+	switch {
+	case rating == "low":
+		return "self-reported"
+	case calls > 0 && succeeded == 0:
+		return "every tool call failed"
+	case rating == "medium" && succeeded == 0:
+		return "no tool evidence"
+	}
+	return ""
+}
+
+// escalationMentions renders the Slack mentions for an escalation config,
+// or "" when nobody is configured.
+func escalationMentions(cfg prompts.EscalationConfig) string {
+	var mentions []string
+	for _, g := range cfg.Groups {
+		mentions = append(mentions, "<!subteam^"+g+">")
+	}
+	for _, u := range cfg.Users {
+		mentions = append(mentions, "<@"+u+">")
+	}
+	return strings.Join(mentions, " ")
+}
+
+// flagLowConfidence prefixes a low-confidence answer with the uncertainty
+// disclaimer and tags the agent's escalation contacts for review. rating is
+// the model's self-report from splitConfidence.
+func (h *GeneralHandler) flagLowConfidence(userID, channelID, content, rating string, messages []github.ChatMessage) string {
+	reason := lowConfidence(rating, messages)
+	if reason == "" {
+		return content
+	}
+	h.logger().Infof("[user=%s channel=%s] low-confidence answer (%s)", userID, channelID, reason)
+	metrics.LowConfidenceAnswers.Inc(h.agentID, reason)
+	content = lowConfidenceDisclaimer + content
+	if mentions := escalationMentions(h.escalation); mentions != "" {
+		content += "\n\n" + mentions + " could you take a look and confirm or correct this?"
+	}
+	return content
+}
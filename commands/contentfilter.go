@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+)
+
+// contentFilteredToolResult replaces tool output in the sanitized retry.
+const contentFilteredToolResult = "[Output omitted: the previous request was blocked by the model's content policy. Answer from the information you have; do not repeat this call.]"
+
+// sanitizeMessages rebuilds the conversation for a one-time retry after a
+// content-filter block. The most common triggers are untrusted text pulled in
+// as context (channel history, conversation memory, CI logs, file contents),
+// so the system prompt is reset to baseSystem and read-tool output is dropped.
+// Tool-call/result pairs are kept so the model does not re-run write tools,
+// and write-tool results (short confirmations with URLs) are preserved.
+func sanitizeMessages(messages []github.ChatMessage, baseSystem string) []github.ChatMessage {
+	toolNames := make(map[string]string)
+	out := make([]github.ChatMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			msg.Content = baseSystem
+		case "assistant":
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+			}
+		case "tool":
+			if !IsWriteTool(toolNames[msg.ToolCallID]) {
+				msg.Content = contentFilteredToolResult
+			}
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// contentPolicyMessage is the user-facing reply when the model's content
+// filter blocks a request even after the sanitized retry.
+func contentPolicyMessage(err error) string {
+	var cfe *github.ContentFilterError
+	if errors.As(err, &cfe) && len(cfe.Categories) > 0 {
+		return fmt.Sprintf(":no_entry: Request blocked by content policy (%s). Try rephrasing the request, or remove pasted logs or content that could be mistaken for harmful or prompt-injection text.",
+			strings.Join(cfe.Categories, ", "))
+	}
+	return ":no_entry: Request blocked by content policy. Try rephrasing the request, or remove pasted logs or content that could be mistaken for harmful or prompt-injection text."
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/justmike1/ovad/redact"
 	slacklib "github.com/slack-go/slack"
 )
 
@@ -16,7 +17,9 @@ const (
 )
 
 type ContextProvider struct {
-	slackClient SlackClient
+	slackClient ChatPlatform
+	redactor    *redact.Redactor
+	agentID     string
 	mu          sync.Mutex
 	cache       map[string]*contextEntry
 }
@@ -26,19 +29,26 @@ type contextEntry struct {
 	fetchedAt time.Time
 }
 
-func NewContextProvider(slackClient SlackClient) *ContextProvider {
+func NewContextProvider(slackClient ChatPlatform) *ContextProvider {
 	return &ContextProvider{
 		slackClient: slackClient,
 		cache:       make(map[string]*contextEntry),
 	}
 }
 
+// SetRedactor masks secrets in the channel context of agentID before it is
+// handed to the LLM.
+func (cp *ContextProvider) SetRedactor(r *redact.Redactor, agentID string) {
+	cp.redactor = r
+	cp.agentID = agentID
+}
+
 func (cp *ContextProvider) GetChannelContext(channelID string) (string, error) {
 	cp.mu.Lock()
 	entry, ok := cp.cache[channelID]
 	if ok && time.Since(entry.fetchedAt) < contextCacheTTL {
 		cp.mu.Unlock()
-		return formatMessages(entry.messages), nil
+		return redactText(cp.redactor, cp.agentID, "context", formatMessages(entry.messages)), nil
 	}
 	cp.mu.Unlock()
 
@@ -58,7 +68,7 @@ func (cp *ContextProvider) GetFreshChannelContext(channelID string) (string, err
 	}
 	cp.mu.Unlock()
 
-	return formatMessages(messages), nil
+	return redactText(cp.redactor, cp.agentID, "context", formatMessages(messages)), nil
 }
 
 func formatMessages(messages []slacklib.Message) string {
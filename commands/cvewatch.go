@@ -0,0 +1,405 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/fsutil"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/nvd"
+)
+
+// CVEWatch is the list of products a Slack channel wants CVE alerts for.
+type CVEWatch struct {
+	ChannelID string    `json:"channel_id"`
+	Keywords  []string  `json:"keywords"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+}
+
+// CVEWatchStore holds the per-channel CVE watchlists and the time NVD was
+// last polled. When created with a path, it is saved to that JSON file on
+// every change and loaded on startup. Safe for concurrent use.
+type CVEWatchStore struct {
+	mu        sync.RWMutex
+	path      string
+	byChannel map[string]CVEWatch
+	lastPoll  time.Time
+}
+
+// cveWatchFile is the on-disk form of a CVEWatchStore.
+type cveWatchFile struct {
+	LastPoll time.Time  `json:"last_poll"`
+	Watches  []CVEWatch `json:"watches"`
+}
+
+// NewCVEWatchStore creates a store, loading it from path when it is
+// non-empty and the file exists.
+func NewCVEWatchStore(path string) (*CVEWatchStore, error) {
+	s := &CVEWatchStore{path: path, byChannel: make(map[string]CVEWatch)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CVE watch store: %w", err)
+	}
+	var f cveWatchFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse CVE watch store %s: %w", path, err)
+	}
+	s.lastPoll = f.LastPoll
+	for _, w := range f.Watches {
+		if w.ChannelID != "" && len(w.Keywords) > 0 {
+			s.byChannel[w.ChannelID] = w
+		}
+	}
+	return s, nil
+}
+
+// Get returns the watchlist of a channel.
+func (s *CVEWatchStore) Get(channelID string) (CVEWatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.byChannel[channelID]
+	return w, ok
+}
+
+// List returns all watchlists, ordered by channel ID.
+func (s *CVEWatchStore) List() []CVEWatch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked()
+}
+
+// Watch adds keywords to a channel's watchlist and saves the store.
+func (s *CVEWatchStore) Watch(channelID, userID string, keywords []string) (CVEWatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.byChannel[channelID]
+	w.ChannelID = channelID
+	for _, k := range keywords {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k != "" && !containsFold(w.Keywords, k) {
+			w.Keywords = append(w.Keywords, k)
+		}
+	}
+	sort.Strings(w.Keywords)
+	w.UpdatedAt = time.Now().UTC()
+	w.UpdatedBy = userID
+	s.byChannel[channelID] = w
+	return w, s.saveLocked()
+}
+
+// Unwatch removes keywords from a channel's watchlist (all of them when
+// keywords is empty) and saves the store.
+func (s *CVEWatchStore) Unwatch(channelID, userID string, keywords []string) (CVEWatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.byChannel[channelID]
+	if !ok {
+		return CVEWatch{ChannelID: channelID}, nil
+	}
+	var kept []string
+	if len(keywords) > 0 {
+		for _, k := range w.Keywords {
+			if !containsFold(keywords, k) {
+				kept = append(kept, k)
+			}
+		}
+	}
+	w.Keywords = kept
+	w.UpdatedAt = time.Now().UTC()
+	w.UpdatedBy = userID
+	if len(kept) == 0 {
+		delete(s.byChannel, channelID)
+	} else {
+		s.byChannel[channelID] = w
+	}
+	return w, s.saveLocked()
+}
+
+// LastPoll returns the end of the last NVD window that was checked.
+func (s *CVEWatchStore) LastPoll() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastPoll
+}
+
+// SetLastPoll records the end of the last NVD window that was checked.
+func (s *CVEWatchStore) SetLastPoll(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastPoll = t.UTC()
+	return s.saveLocked()
+}
+
+func (s *CVEWatchStore) sortedLocked() []CVEWatch {
+	out := make([]CVEWatch, 0, len(s.byChannel))
+	for _, w := range s.byChannel {
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ChannelID < out[j].ChannelID })
+	return out
+}
+
+// saveLocked writes the store to a temporary file and renames it over path,
+// so a crash never leaves a truncated file. Callers hold s.mu.
+func (s *CVEWatchStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cveWatchFile{LastPoll: s.lastPoll, Watches: s.sortedLocked()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode CVE watch store: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save CVE watch store: %w", err)
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cveWatchTools manage the current channel's CVE watchlist. Offered when
+// NVD and the watch store are configured.
+var cveWatchTools = []*ToolDef{
+	{
+		Name:        "watch_cves",
+		Description: "Watch for new or updated CVEs affecting products, e.g. 'watch CVEs for nginx, openssl'. Matching CVEs from NVD are posted to the current Slack channel as they are published or modified. Keywords match product/vendor names in affected CPEs or whole words in the CVE description.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"keywords":{"type":"array","items":{"type":"string"},"description":"Product or vendor names to watch (e.g. ['nginx','openssl'])"}
+			},
+			"required":["keywords"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).cveWatchEnabled,
+		Run:       (*GeneralHandler).toolWatchCVEs,
+	},
+	{
+		Name:        "unwatch_cves",
+		Description: "Stop watching CVEs for some products in the current Slack channel, or for all of them when no keywords are given.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"keywords":{"type":"array","items":{"type":"string"},"description":"Keywords to remove; omit to clear the channel's watchlist"}
+			}
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).cveWatchEnabled,
+		Run:       (*GeneralHandler).toolUnwatchCVEs,
+	},
+	{
+		Name:        "list_cve_watches",
+		Description: "Show the products the current Slack channel watches for new CVEs.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).cveWatchEnabled,
+		Run:         (*GeneralHandler).toolListCVEWatches,
+	},
+}
+
+func (h *GeneralHandler) cveWatchEnabled() bool {
+	return h.nvdClient != nil && h.cveWatches != nil
+}
+
+func (h *GeneralHandler) toolWatchCVEs(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Keywords []string `json:"keywords"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if len(args.Keywords) == 0 {
+		return "Error: keywords is required."
+	}
+	if call.ChannelID == "" {
+		return "Error: CVE watches are per Slack channel; no channel in this context."
+	}
+	w, err := h.cveWatches.Watch(call.ChannelID, call.UserID, args.Keywords)
+	if err != nil {
+		return fmt.Sprintf("Error saving CVE watch: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] watching CVEs for %s", call.UserID, call.ChannelID, strings.Join(w.Keywords, ", "))
+	return fmt.Sprintf("This channel now watches CVEs for: %s. New and updated matching CVEs from NVD will be posted here.", strings.Join(w.Keywords, ", "))
+}
+
+func (h *GeneralHandler) toolUnwatchCVEs(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Keywords []string `json:"keywords"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	w, err := h.cveWatches.Unwatch(call.ChannelID, call.UserID, args.Keywords)
+	if err != nil {
+		return fmt.Sprintf("Error saving CVE watch: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] unwatched CVEs %v", call.UserID, call.ChannelID, args.Keywords)
+	if len(w.Keywords) == 0 {
+		return "This channel no longer watches any CVEs."
+	}
+	return fmt.Sprintf("This channel still watches CVEs for: %s.", strings.Join(w.Keywords, ", "))
+}
+
+func (h *GeneralHandler) toolListCVEWatches(ctx context.Context, call ToolCall) string {
+	w, ok := h.cveWatches.Get(call.ChannelID)
+	if !ok {
+		return "This channel doesn't watch any CVEs. Use watch_cves to add products."
+	}
+	return fmt.Sprintf("This channel watches CVEs for: %s (last changed %s by <@%s>).", strings.Join(w.Keywords, ", "), w.UpdatedAt.Format("2006-01-02"), w.UpdatedBy)
+}
+
+// maxCVEAlertsPerPost caps the CVEs listed in one alert message.
+const maxCVEAlertsPerPost = 20
+
+// CVEWatcher polls NVD for CVEs published or modified since the last poll
+// and posts the ones matching each channel's watchlist.
+type CVEWatcher struct {
+	nvd      *nvd.Client
+	slack    ChatPlatform
+	store    *CVEWatchStore
+	interval time.Duration
+}
+
+// NewCVEWatcher creates a watcher that polls every interval.
+func NewCVEWatcher(nvdClient *nvd.Client, slackClient ChatPlatform, store *CVEWatchStore, interval time.Duration) *CVEWatcher {
+	return &CVEWatcher{nvd: nvdClient, slack: slackClient, store: store, interval: interval}
+}
+
+// Start polls in the background until ctx is done.
+func (w *CVEWatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.Poll(ctx)
+			}
+		}
+	}()
+}
+
+// Poll checks the window since the last poll (one interval on the first
+// run) and posts matches. The window only advances when NVD answered, so a
+// failed poll is retried on the next tick.
+func (w *CVEWatcher) Poll(ctx context.Context) {
+	watches := w.store.List()
+	now := time.Now().UTC()
+	since := w.store.LastPoll()
+	if since.IsZero() {
+		since = now.Add(-w.interval)
+	}
+	if len(watches) == 0 {
+		_ = w.store.SetLastPoll(now)
+		return
+	}
+	cves, err := w.nvd.ModifiedBetween(ctx, since, now)
+	if err != nil {
+		logging.Ctx(ctx).Errorf("[cve-watch] NVD poll failed: %v", err)
+		return
+	}
+	for _, watch := range watches {
+		var matched []nvd.CVEItem
+		for _, cve := range cves {
+			for _, k := range watch.Keywords {
+				if cve.Mentions(k) {
+					matched = append(matched, cve)
+					break
+				}
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		if err := w.nvd.Enrich(ctx, matched); err != nil {
+			logging.Ctx(ctx).Infof("[cve-watch] EPSS/KEV enrichment incomplete: %v", err)
+		}
+		if _, err := w.slack.PostMessage(watch.ChannelID, FormatCVEAlert(matched, since)); err != nil {
+			logging.Ctx(ctx).Errorf("[cve-watch] failed to post %d CVE(s) to %s: %v", len(matched), watch.ChannelID, err)
+			continue
+		}
+		logging.Ctx(ctx).Infof("[cve-watch] posted %d CVE(s) to %s", len(matched), watch.ChannelID)
+	}
+	if err := w.store.SetLastPoll(now); err != nil {
+		logging.Ctx(ctx).Errorf("[cve-watch] failed to save poll time: %v", err)
+	}
+}
+
+// FormatCVEAlert renders CVEs matching a watchlist, known-exploited ones
+// first, then by severity. CVEs published before since are marked as updated.
+func FormatCVEAlert(cves []nvd.CVEItem, since time.Time) string {
+	sort.SliceStable(cves, func(i, j int) bool {
+		if (cves[i].KEV != nil) != (cves[j].KEV != nil) {
+			return cves[i].KEV != nil
+		}
+		a, _ := cves[i].Severity()
+		b, _ := cves[j].Severity()
+		return a > b
+	})
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ":rotating_light: *%d new or updated CVE(s) match this channel's watchlist*\n", len(cves))
+	for i, cve := range cves {
+		if i == maxCVEAlertsPerPost {
+			fmt.Fprintf(&sb, "…and %d more. Ask me to search for them for details.\n", len(cves)-i)
+			break
+		}
+		score, severity := cve.Severity()
+		label := "not yet scored"
+		if severity != "" {
+			label = fmt.Sprintf("%s %.1f", severity, score)
+		}
+		status := "new"
+		// NVD timestamps are UTC without a zone, e.g. "2024-05-01T12:15:09.827".
+		if published, err := time.Parse("2006-01-02T15:04:05", cve.Published); err == nil && published.Before(since) {
+			status = "updated"
+		}
+		desc := cve.Description()
+		if len(desc) > 200 {
+			desc = desc[:200] + "…"
+		}
+		if cve.EPSS != nil {
+			label += fmt.Sprintf(", EPSS %.1f%%", cve.EPSS.Score*100)
+		}
+		if cve.KEV != nil {
+			label += ", :warning: known exploited (CISA KEV)"
+		}
+		fmt.Fprintf(&sb, "%s <https://nvd.nist.gov/vuln/detail/%s|%s> — *%s* (%s)\n    %s\n", severityEmoji(severity), cve.ID, cve.ID, label, status, desc)
+	}
+	return sb.String()
+}
+
+func severityEmoji(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return ":red_circle:"
+	case "HIGH":
+		return ":large_orange_circle:"
+	case "MEDIUM":
+		return ":large_yellow_circle:"
+	case "LOW":
+		return ":large_green_circle:"
+	default:
+		return ":white_circle:"
+	}
+}
@@ -3,29 +3,52 @@ package commands
 import (
 	"context"
 	"fmt"
-	"log"
+	"strings"
 
 	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/redact"
 	ovadslack "github.com/justmike1/ovad/slack"
 )
 
 const channelHistoryLimit = 20
 
+// debugToolRounds bounds the read-only tool calls a debug analysis may make.
+// Analyses usually need one or two lookups; anything longer is an action for
+// the general handler.
+const debugToolRounds = 3
+
+// debugToolNames are the read-only tools offered to the debug handler.
+var debugToolNames = []string{"get_file_content", "get_workflow_run", "search_code"}
+
 type DebugHandler struct {
-	slackClient     SlackClient
+	slackClient     ChatPlatform
 	ghClient        *github.Client
 	modelsClient    *github.ModelsClient
 	contextProvider *ContextProvider
 	memory          *ConversationMemory
 	prompts         PromptProvider
+	agentID         string
+	redactor        *redact.Redactor
+	requestID       string
+	sessions        *SessionStore
+	// tools runs the read-only debug tools under the agent's tool and repo
+	// policies. nil disables tool access.
+	tools *GeneralHandler
+}
+
+// logger returns a logger tagged with the handler's request ID.
+func (h *DebugHandler) logger() logging.Logger {
+	return logging.Request(h.requestID)
 }
 
 func (h *DebugHandler) Execute(channelID, userID, text, responseURL, auditTS string) {
-	ctx := context.Background()
+	ctx := github.WithAttribution(logging.WithRequestID(context.Background(), h.requestID), github.Attribution{AgentID: h.agentID, UserID: userID, ChannelID: channelID})
 
 	channelContext, err := h.contextProvider.GetFreshChannelContext(channelID)
 	if err != nil {
-		log.Printf("[user=%s channel=%s] failed to fetch channel context: %v", userID, channelID, err)
+		h.logger().Errorf("[user=%s channel=%s] failed to fetch channel context: %v", userID, channelID, err)
 		h.reply(channelID, responseURL, auditTS, fmt.Sprintf("Failed to read channel history: %v", err))
 		return
 	}
@@ -35,7 +58,8 @@ func (h *DebugHandler) Execute(channelID, userID, text, responseURL, auditTS str
 		return
 	}
 
-	workflowLogs := h.fetchWorkflowLogs(ctx, channelContext+"\n"+text, userID, channelID)
+	workflowLogs := redactText(h.redactor, h.agentID, "workflow_logs", h.fetchWorkflowLogs(ctx, channelContext+"\n"+text, userID, channelID))
+	text = redactText(h.redactor, h.agentID, "request", text)
 
 	systemPrompt := h.prompts.MustGet("security") + "\n\n" + h.prompts.MustGet("debug")
 
@@ -44,33 +68,134 @@ func (h *DebugHandler) Execute(channelID, userID, text, responseURL, auditTS str
 		userPrompt += fmt.Sprintf("\n\nI also fetched the GitHub Actions workflow run details and logs for URLs found in the messages:\n\n%s", workflowLogs)
 	}
 
-	response, err := h.modelsClient.Complete(ctx, systemPrompt, userPrompt)
+	response, err := h.analyze(ctx, channelID, userID, auditTS, systemPrompt, userPrompt)
+	if err != nil && github.IsContentFiltered(err) && workflowLogs != "" {
+		// CI logs are the most likely trigger; retry once without them.
+		h.logger().Warnf("[user=%s channel=%s] content filter blocked request, retrying without workflow logs: %v", userID, channelID, err)
+		userPrompt = fmt.Sprintf("Here are the recent messages from the channel:\n\n%s\n\nUser request: %s", channelContext, text)
+		response, err = h.analyze(ctx, channelID, userID, auditTS, systemPrompt, userPrompt)
+	}
+	if err != nil && github.IsContentFiltered(err) {
+		h.logger().Infof("[user=%s channel=%s] content filter blocked request: %v", userID, channelID, err)
+		h.reply(channelID, responseURL, auditTS, contentPolicyMessage(err))
+		return
+	}
+	if err != nil && github.IsLLMUnavailable(err) {
+		h.logger().Infof("[user=%s channel=%s] LLM backend unavailable (circuit open): %v", userID, channelID, err)
+		h.reply(channelID, responseURL, auditTS, llmUnavailableMessage)
+		return
+	}
 	if err != nil {
-		log.Printf("[user=%s channel=%s] LLM completion failed: %v", userID, channelID, err)
+		h.logger().Errorf("[user=%s channel=%s] LLM completion failed: %v", userID, channelID, err)
 		_ = ovadslack.RespondToURL(responseURL, fmt.Sprintf("Failed to analyze messages: %v", err), true)
 		return
 	}
 
-	log.Printf("[user=%s channel=%s] debug analysis completed successfully", userID, channelID)
+	h.logger().Infof("[user=%s channel=%s] debug analysis completed successfully", userID, channelID)
 	h.memory.SetAssistantResponse(channelID, userID, response)
+	if auditTS != "" {
+		h.memory.SetThreadResponse(channelID, auditTS, response)
+	}
 	h.reply(channelID, responseURL, auditTS, response)
 }
 
+// AnalyzeWorkflowRun runs the debug analysis for a single failed workflow
+// run, without channel history, and posts the findings in auditTS's thread.
+func (h *DebugHandler) AnalyzeWorkflowRun(channelID, userID, runURL, auditTS string) {
+	ctx := github.WithAttribution(logging.WithRequestID(context.Background(), h.requestID), github.Attribution{AgentID: h.agentID, UserID: userID, ChannelID: channelID})
+
+	workflowLogs := redactText(h.redactor, h.agentID, "workflow_logs", h.fetchWorkflowLogs(ctx, runURL, userID, channelID))
+	if workflowLogs == "" {
+		h.reply(channelID, "", auditTS, fmt.Sprintf("Could not fetch the workflow run details for %s.", runURL))
+		return
+	}
+
+	systemPrompt := h.prompts.MustGet("security") + "\n\n" + h.prompts.MustGet("debug")
+	userPrompt := fmt.Sprintf("This GitHub Actions workflow run just failed: %s\n\nFind the root cause and suggest a fix. Here are the run details and logs:\n\n%s", runURL, workflowLogs)
+
+	response, err := h.analyze(ctx, channelID, userID, auditTS, systemPrompt, userPrompt)
+	switch {
+	case err != nil && github.IsContentFiltered(err):
+		h.logger().Infof("[user=%s channel=%s] content filter blocked request: %v", userID, channelID, err)
+		response = contentPolicyMessage(err)
+	case err != nil && github.IsLLMUnavailable(err):
+		h.logger().Infof("[user=%s channel=%s] LLM backend unavailable (circuit open): %v", userID, channelID, err)
+		response = llmUnavailableMessage
+	case err != nil:
+		h.logger().Errorf("[user=%s channel=%s] LLM completion failed: %v", userID, channelID, err)
+		response = fmt.Sprintf("Failed to analyze the workflow run: %v", err)
+	default:
+		h.logger().Infof("[user=%s channel=%s] workflow run analysis completed successfully", userID, channelID)
+	}
+	h.reply(channelID, "", auditTS, response)
+}
+
+// analyze asks the LLM for a debug analysis, letting it call the read-only
+// debug tools for up to debugToolRounds rounds before it must answer.
+func (h *DebugHandler) analyze(ctx context.Context, channelID, userID, auditTS, systemPrompt, userPrompt string) (string, error) {
+	var tools []github.Tool
+	if h.tools != nil {
+		tools = h.tools.buildTools()
+	}
+	if len(tools) == 0 {
+		return h.modelsClient.Complete(ctx, systemPrompt, userPrompt)
+	}
+
+	messages := []github.ChatMessage{
+		github.NewChatMessage("system", systemPrompt+"\n\nYou may call the read-only tools provided to look up files, workflow runs, or code when the messages alone are not enough. Do not call more tools than you need."),
+		github.NewChatMessage("user", userPrompt),
+	}
+	for i := 0; ; i++ {
+		if i == debugToolRounds {
+			// Out of rounds: withhold tools so the model has to answer.
+			tools = nil
+		}
+		resp, err := h.modelsClient.CompleteWithTools(ctx, messages, tools)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("no response choices returned")
+		}
+		choice := resp.Choices[0]
+		if len(choice.Message.ToolCalls) == 0 {
+			return choice.Message.Content, nil
+		}
+
+		messages = append(messages, github.ChatMessage{
+			Role:      "assistant",
+			Content:   choice.Message.Content,
+			ToolCalls: choice.Message.ToolCalls,
+		})
+		for _, tc := range choice.Message.ToolCalls {
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] debug LLM called tool: %s(%s)", userID, channelID, tc.Function.Name, tc.Function.Arguments)
+			result := h.tools.executeTool(ctx, channelID, userID, auditTS, tc.Function.Name, tc.Function.Arguments)
+			toolLabel := h.tools.metricToolName(tc.Function.Name)
+			metrics.ToolCalls.Inc(h.agentID, toolLabel)
+			if strings.HasPrefix(result, "Error") {
+				metrics.ToolErrors.Inc(h.agentID, toolLabel)
+			}
+			messages = append(messages, github.NewToolResultMessage(tc.ID, result))
+		}
+	}
+}
+
 func (h *DebugHandler) reply(channelID, responseURL, auditTS, text string) {
 	if auditTS != "" {
+		h.sessions.Record(channelID, auditTS, TranscriptEntry{Kind: TranscriptBot, Text: redactText(h.redactor, h.agentID, "transcript", text)})
 		if err := h.slackClient.PostThreadReply(channelID, auditTS, text); err != nil {
-			log.Printf("[channel=%s] failed to post thread reply: %v", channelID, err)
+			h.logger().Errorf("[channel=%s] failed to post thread reply: %v", channelID, err)
 		}
 		return
 	}
-	if err := ovadslack.RespondToURL(responseURL, text, false); err != nil {
-		log.Printf("[channel=%s] failed to respond: %v", channelID, err)
+	if err := ovadslack.RespondToURL(responseURL, redactText(h.redactor, h.agentID, "output", text), false); err != nil {
+		h.logger().Errorf("[channel=%s] failed to respond: %v", channelID, err)
 	}
 }
 
 func (h *DebugHandler) fetchWorkflowLogs(ctx context.Context, channelContext, userID, channelID string) string {
 	urls := github.ExtractWorkflowRunURLs(channelContext)
-	if len(urls) == 0 {
+	if len(urls) == 0 || h.ghClient == nil {
 		return ""
 	}
 
@@ -87,10 +212,10 @@ func (h *DebugHandler) fetchWorkflowLogs(ctx context.Context, channelContext, us
 			continue
 		}
 
-		log.Printf("[user=%s channel=%s] fetching workflow run %s/%s/%d", userID, channelID, owner, repo, runID)
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] fetching workflow run %s/%s/%d", userID, channelID, owner, repo, runID)
 		summary, err := h.ghClient.GetWorkflowRunSummary(ctx, owner, repo, runID)
 		if err != nil {
-			log.Printf("[user=%s channel=%s] failed to fetch workflow run summary: %v", userID, channelID, err)
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] failed to fetch workflow run summary: %v", userID, channelID, err)
 			continue
 		}
 
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/osv"
+)
+
+// depsTools scan a repository's dependency manifests for known
+// vulnerabilities. Offered when the OSV client is configured.
+var depsTools = []*ToolDef{
+	{
+		Name:        "scan_dependencies",
+		Description: "Scan a repository's dependency manifests (go.mod, package.json, requirements.txt) for known vulnerabilities using the OSV database (GitHub advisories, Go vuln DB, PyPA, and more). Returns findings ranked by severity with the fixed version for each, plus dependencies that could not be checked (unpinned versions). Use it for 'are our dependencies vulnerable?', before upgrades, or to check exposure to an advisory; follow up with lookup_cve on CVE aliases for details.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"Directory holding the manifests (default: repository root)"},
+				"manifests":{"type":"array","items":{"type":"string"},"description":"Explicit manifest file paths to scan instead of looking in path (e.g. ['services/api/go.mod'])"},
+				"ref":{"type":"string","description":"Branch, tag or commit (default: default branch)"},
+				"include_dev":{"type":"boolean","description":"Include package.json devDependencies (default: true)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).osvConfigured,
+		Run:        (*GeneralHandler).toolScanDependencies,
+	},
+}
+
+// osvConfigured needs GitHub too: manifests are read with its contents API.
+func (h *GeneralHandler) osvConfigured() bool { return h.osvClient != nil && h.ghClient != nil }
+
+func (h *GeneralHandler) toolScanDependencies(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo       string   `json:"repo"`
+		Path       string   `json:"path"`
+		Manifests  []string `json:"manifests"`
+		Ref        string   `json:"ref"`
+		IncludeDev *bool    `json:"include_dev"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	manifests := args.Manifests
+	explicit := len(manifests) > 0
+	if !explicit {
+		for _, m := range osv.Manifests {
+			manifests = append(manifests, path.Join(strings.Trim(args.Path, "/"), m))
+		}
+	}
+
+	var pkgs []osv.Package
+	var scanned, skipped []string
+	for _, m := range manifests {
+		content, found, err := h.ghClient.GetOptionalFile(ctx, owner, args.Repo, m, args.Ref)
+		if err != nil {
+			return fmt.Sprintf("Error reading %s: %v", m, err)
+		}
+		if !found {
+			if explicit {
+				return fmt.Sprintf("Error: %s not found in %s/%s.", m, owner, args.Repo)
+			}
+			continue
+		}
+		declared, skip, err := osv.ParseManifest(m, content)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		for _, p := range declared {
+			if p.Dev && args.IncludeDev != nil && !*args.IncludeDev {
+				continue
+			}
+			pkgs = append(pkgs, p)
+		}
+		for _, s := range skip {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", s, m))
+		}
+		scanned = append(scanned, fmt.Sprintf("%s (%d deps)", m, len(declared)))
+	}
+	if len(scanned) == 0 {
+		where := "the repository root"
+		if args.Path != "" {
+			where = args.Path
+		}
+		return fmt.Sprintf("No supported manifests (%s) found in %s of %s/%s. Pass manifests to scan files elsewhere.", strings.Join(osv.Manifests, ", "), where, owner, args.Repo)
+	}
+
+	findings, err := h.osvClient.Scan(ctx, pkgs)
+	if err != nil {
+		return fmt.Sprintf("Error querying OSV: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] scanned %d dependencies in %s/%s: %d finding(s)", call.UserID, call.ChannelID, len(pkgs), owner, args.Repo, len(findings))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Scanned %s in %s/%s.\n", strings.Join(scanned, ", "), owner, args.Repo)
+	if len(skipped) > 0 {
+		fmt.Fprintf(&sb, "Not checked (no pinned version): %s\n", strings.Join(skipped, ", "))
+	}
+	if len(findings) == 0 {
+		sb.WriteString("No known vulnerabilities found in OSV for the checked dependencies.")
+		return sb.String()
+	}
+
+	counts := make(map[string]int)
+	vulnerable := make(map[string]bool)
+	for _, f := range findings {
+		counts[f.Severity]++
+		vulnerable[f.Package.Name] = true
+	}
+	var bySeverity []string
+	for _, s := range []string{"CRITICAL", "HIGH", "MODERATE", "MEDIUM", "LOW", "UNKNOWN"} {
+		if counts[s] > 0 {
+			bySeverity = append(bySeverity, fmt.Sprintf("%d %s", counts[s], strings.ToLower(s)))
+		}
+	}
+	fmt.Fprintf(&sb, "%d known vulnerabilit(ies) in %d dependenc(ies): %s.", len(findings), len(vulnerable), strings.Join(bySeverity, ", "))
+
+	table := &Table{Name: "dependency-vulnerabilities", Columns: []string{"Severity", "Package", "Version", "ID", "Aliases", "Fixed In", "Summary", "Manifest"}, Inline: 7}
+	for _, f := range findings {
+		name := f.Package.Name
+		if f.Package.Dev {
+			name += " (dev)"
+		}
+		table.Rows = append(table.Rows, []string{f.Severity, name, f.Package.Version, f.ID, strings.Join(f.Aliases, ", "), strings.Join(f.Fixed, ", "), f.Summary, f.Package.Manifest})
+	}
+	return h.presentTable(call, sb.String(), table)
+}
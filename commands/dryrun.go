@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// maxPreviewDiffLines bounds the diff lines shown in a file change preview.
+const maxPreviewDiffLines = 60
+
+// dryRunPrefixRe matches a request that asks for a dry run, e.g.
+// "dry run: fix the typo in README" or "--dry-run close stale PRs".
+var dryRunPrefixRe = regexp.MustCompile(`(?i)^\s*(?:--dry-run|dry[ -]run)\b[\s:,\-]*`)
+
+// dryRunInstruction is added to the system prompt of a dry run.
+const dryRunInstruction = "\n\nDRY-RUN MODE: tools that change something (files, pull requests, CI runs, tickets, ...) are not executed. " +
+	"They return a preview of what they would have done instead. Carry out the request as usual, then tell the user exactly what would have changed, " +
+	"using the previews. Never claim that a change was made."
+
+// dryRunBanner prefixes answers given in dry-run mode.
+const dryRunBanner = ":test_tube: _Dry run: nothing was changed._\n\n"
+
+// parseDryRun reports whether text asks for a dry run and returns it
+// without the dry-run prefix.
+func parseDryRun(text string) (string, bool) {
+	loc := dryRunPrefixRe.FindStringIndex(text)
+	if loc == nil {
+		return text, false
+	}
+	return text[loc[1]:], true
+}
+
+// previewTool describes what a write tool would do, for dry-run mode.
+// Tools with a Preview validate their arguments against the current state
+// (e.g. that old_content still matches); the others echo their arguments.
+func (h *GeneralHandler) previewTool(ctx context.Context, def *ToolDef, call ToolCall) string {
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] dry run: previewing %s", call.UserID, call.ChannelID, def.Name)
+	if def.Preview != nil {
+		return def.Preview(h, ctx, call)
+	}
+	args := call.Args
+	var v any
+	if err := json.Unmarshal([]byte(call.Args), &v); err == nil {
+		if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+			args = string(b)
+		}
+	}
+	return fmt.Sprintf("Dry run: %s was not executed. It would have been called with:\n```\n%s\n```", def.Name, args)
+}
+
+// previewModifyFile checks that old_content matches the current file
+// exactly once and shows the change as a diff.
+func (h *GeneralHandler) previewModifyFile(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Path        string `json:"path"`
+		OldContent  string `json:"old_content"`
+		NewContent  string `json:"new_content"`
+		Description string `json:"description"`
+		Branch      string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	current, branch, errMsg := h.previewReadFile(ctx, args.Repo, args.Path, args.Branch)
+	if errMsg != "" {
+		return errMsg
+	}
+	switch n := strings.Count(current, args.OldContent); {
+	case n == 0:
+		return "Error: old_content not found in the file. Make sure old_content is an exact substring of the current file (including whitespace and indentation). Re-read the file with get_file_content and try again."
+	case n > 1:
+		return fmt.Sprintf("Error: old_content matches %d locations in the file. Include more surrounding context lines to make it unique.", n)
+	}
+	line := strings.Count(current[:strings.Index(current, args.OldContent)], "\n") + 1
+	return fmt.Sprintf("Dry run: modify_file was not executed. It would commit %q to %s/%s (off %s) and open or update a PR:\n```diff\n@@ line %d @@\n%s```",
+		args.Description, args.Repo, args.Path, branch, line, lineDiff(args.OldContent, args.NewContent))
+}
+
+// previewRewriteFile shows how a full-file rewrite changes the current file.
+func (h *GeneralHandler) previewRewriteFile(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Path        string `json:"path"`
+		Content     string `json:"content"`
+		Description string `json:"description"`
+		Branch      string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Content) == "" {
+		return "Error: content is empty. Provide the complete new file content."
+	}
+	current, branch, errMsg := h.previewReadFile(ctx, args.Repo, args.Path, args.Branch)
+	if errMsg != "" {
+		return errMsg
+	}
+	return fmt.Sprintf("Dry run: rewrite_file was not executed. It would commit %q to %s/%s (off %s) and open or update a PR:\n```diff\n%s```",
+		args.Description, args.Repo, args.Path, branch, lineDiff(current, args.Content))
+}
+
+// previewReadFile reads a file from branch (default: the repository's
+// default branch) for a change preview. On failure it returns the error
+// text for the model.
+func (h *GeneralHandler) previewReadFile(ctx context.Context, repo, path, branch string) (content, readBranch, errMsg string) {
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return "", "", fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	if branch == "" {
+		if branch, err = h.scm.DefaultBranch(ctx, owner, repo); err != nil {
+			return "", "", fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+	content, err = h.scm.FileContent(ctx, owner, repo, path, branch)
+	if err != nil {
+		return "", "", fmt.Sprintf("Error reading current file: %v", err)
+	}
+	return content, branch, ""
+}
+
+// previewCreateTicket shows the ticket create_jira_ticket would file.
+func (h *GeneralHandler) previewCreateTicket(_ context.Context, call ToolCall) string {
+	var args struct {
+		Project     string   `json:"project"`
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		IssueType   string   `json:"issue_type"`
+		Labels      []string `json:"labels"`
+		Assignee    string   `json:"assignee"`
+		Team        string   `json:"team"`
+		Components  []string `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.IssueType == "" {
+		args.IssueType = "Task"
+	}
+	project := args.Project
+	if project == "" {
+		project = "(default project)"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Dry run: create_jira_ticket was not executed. It would create this %s ticket:\n", h.tickets.Name())
+	fmt.Fprintf(&sb, "Project: %s\nType: %s\nSummary: %s\n", project, args.IssueType, args.Summary)
+	for _, f := range []struct{ name, value string }{
+		{"Labels", strings.Join(args.Labels, ", ")},
+		{"Components", strings.Join(args.Components, ", ")},
+		{"Assignee", args.Assignee},
+		{"Team", args.Team},
+	} {
+		if f.value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", f.name, f.value)
+		}
+	}
+	fmt.Fprintf(&sb, "\nDescription:\n%s", args.Description)
+	return sb.String()
+}
+
+// lineDiff renders the lines that differ between before and after as
+// "-"/"+" lines, keeping up to two unchanged lines of context on each side.
+// Long diffs are cut at maxPreviewDiffLines.
+func lineDiff(before, after string) string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []string
+	for _, l := range a[max(prefix-2, 0):prefix] {
+		lines = append(lines, " "+l)
+	}
+	for _, l := range a[prefix : len(a)-suffix] {
+		lines = append(lines, "-"+l)
+	}
+	for _, l := range b[prefix : len(b)-suffix] {
+		lines = append(lines, "+"+l)
+	}
+	for _, l := range a[len(a)-suffix : min(len(a)-suffix+2, len(a))] {
+		lines = append(lines, " "+l)
+	}
+	if len(lines) > maxPreviewDiffLines {
+		omitted := len(lines) - maxPreviewDiffLines
+		lines = append(lines[:maxPreviewDiffLines], fmt.Sprintf("... (%d more lines)", omitted))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
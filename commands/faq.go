@@ -0,0 +1,393 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/fsutil"
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+const (
+	// faqMatchSimilarity is the cosine similarity above which a saved FAQ
+	// entry is taken to answer a question.
+	faqMatchSimilarity = 0.88
+	// faqRepeatSimilarity is the similarity above which two requests count
+	// as the same question.
+	faqRepeatSimilarity = 0.85
+	// faqRepeatWindow is how far back repeated questions are counted.
+	faqRepeatWindow = 30 * 24 * time.Hour
+	// maxFAQCompared bounds how many past requests a question is compared to.
+	maxFAQCompared = 500
+	// maxFAQVectors bounds the in-memory embedding cache.
+	maxFAQVectors = 2000
+	// faqEmbedBatch is how many texts are embedded per API call.
+	faqEmbedBatch = 100
+)
+
+// FAQEntry is a curated answer to a recurring question.
+type FAQEntry struct {
+	ID        int       `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Hits      int       `json:"hits"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// FAQStore holds the curated answers per agent and spots recurring
+// questions by comparing request embeddings with the requests in the
+// activity store. When created with a path, entries are saved to that JSON
+// file on every change and loaded on startup. Safe for concurrent use.
+type FAQStore struct {
+	mu         sync.Mutex
+	path       string
+	entries    []FAQEntry
+	nextID     int
+	embedder   *github.ModelsClient
+	minRepeats int
+	vectors    map[string][]float64 // normalized request text → embedding
+}
+
+// NewFAQStore creates a store that embeds questions with embedder (an
+// embedding model) and suggests saving an answer once a question has been
+// asked minRepeats times in more than one channel.
+func NewFAQStore(path string, embedder *github.ModelsClient, minRepeats int) (*FAQStore, error) {
+	s := &FAQStore{path: path, nextID: 1, embedder: embedder, minRepeats: minRepeats, vectors: make(map[string][]float64)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FAQ store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse FAQ store %s: %w", path, err)
+	}
+	for _, e := range s.entries {
+		s.nextID = max(s.nextID, e.ID+1)
+	}
+	return s, nil
+}
+
+// Len returns the number of saved entries.
+func (s *FAQStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// List returns agentID's entries in the order they were saved.
+func (s *FAQStore) List(agentID string) []FAQEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []FAQEntry
+	for _, e := range s.entries {
+		if e.AgentID == agentID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Add saves a curated answer for agentID.
+func (s *FAQStore) Add(ctx context.Context, agentID, userID, question, answer string) (FAQEntry, error) {
+	vecs, err := s.embed(ctx, []string{question})
+	if err != nil {
+		return FAQEntry{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := FAQEntry{
+		ID:        s.nextID,
+		AgentID:   agentID,
+		Question:  question,
+		Answer:    answer,
+		CreatedBy: userID,
+		CreatedAt: time.Now().UTC(),
+		Embedding: vecs[0],
+	}
+	s.nextID++
+	s.entries = append(s.entries, e)
+	return e, s.saveLocked()
+}
+
+// Delete removes agentID's entry id.
+func (s *FAQStore) Delete(agentID string, id int) (FAQEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.entries {
+		if e.ID == id && e.AgentID == agentID {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return e, s.saveLocked()
+		}
+	}
+	return FAQEntry{}, fmt.Errorf("no FAQ entry #%d", id)
+}
+
+// Match returns agentID's entry closest to the question vector, if any is
+// similar enough, and counts the hit.
+func (s *FAQStore) Match(agentID string, vec []float64) (FAQEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	best, bestSim := -1, faqMatchSimilarity
+	for i, e := range s.entries {
+		if e.AgentID != agentID {
+			continue
+		}
+		if sim := cosine(vec, e.Embedding); sim >= bestSim {
+			best, bestSim = i, sim
+		}
+	}
+	if best < 0 {
+		return FAQEntry{}, false
+	}
+	s.entries[best].Hits++
+	if err := s.saveLocked(); err != nil {
+		logging.Infof("[faq] %v", err)
+	}
+	return s.entries[best], true
+}
+
+// Repeats counts the requests among events that ask the same as the
+// question vector, and the channels they were asked in.
+func (s *FAQStore) Repeats(ctx context.Context, vec []float64, events []ActivityEvent) (count, channels int, err error) {
+	if len(events) > maxFAQCompared {
+		events = events[len(events)-maxFAQCompared:]
+	}
+	texts := make([]string, len(events))
+	for i, ev := range events {
+		texts[i] = ev.Text
+	}
+	vecs, err := s.embed(ctx, texts)
+	if err != nil {
+		return 0, 0, err
+	}
+	seen := make(map[string]bool)
+	for i, ev := range events {
+		if cosine(vec, vecs[i]) >= faqRepeatSimilarity {
+			count++
+			seen[ev.ChannelID] = true
+		}
+	}
+	return count, len(seen), nil
+}
+
+// embed returns a vector per text, embedding only the ones not cached.
+func (s *FAQStore) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	keys := make([]string, len(texts))
+	out := make([][]float64, len(texts))
+	var missing []string
+	s.mu.Lock()
+	for i, t := range texts {
+		keys[i] = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if v, ok := s.vectors[keys[i]]; ok {
+			out[i] = v
+		} else if !slices.Contains(missing, keys[i]) {
+			missing = append(missing, keys[i])
+		}
+	}
+	s.mu.Unlock()
+
+	for start := 0; start < len(missing); start += faqEmbedBatch {
+		batch := missing[start:min(start+faqEmbedBatch, len(missing))]
+		vecs, err := s.embedder.Embed(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("embed questions: %w", err)
+		}
+		s.mu.Lock()
+		if len(s.vectors)+len(batch) > maxFAQVectors {
+			s.vectors = make(map[string][]float64)
+		}
+		for i, k := range batch {
+			s.vectors[k] = vecs[i]
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range out {
+		if out[i] == nil {
+			out[i] = s.vectors[keys[i]]
+		}
+	}
+	return out, nil
+}
+
+// saveLocked writes the store to a temporary file and renames it over path,
+// so a crash never leaves a truncated file. Callers hold s.mu.
+func (s *FAQStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode FAQ store: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save FAQ store: %w", err)
+	}
+	return nil
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// faqContext returns the system prompt addition for a request: the saved
+// answer when one matches, or a prompt to offer saving one when the
+// question keeps coming up across channels.
+func (h *GeneralHandler) faqContext(ctx context.Context, channelID, userID, text string) string {
+	if h.faq == nil {
+		return ""
+	}
+	vecs, err := h.faq.embed(ctx, []string{text})
+	if err != nil {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] FAQ lookup skipped: %v", userID, channelID, err)
+		return ""
+	}
+	if e, ok := h.faq.Match(h.agentID, vecs[0]); ok {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] request matches FAQ #%d", userID, channelID, e.ID)
+		return fmt.Sprintf("\n\nSaved FAQ answer #%d, curated by the team for this question:\nQ: %s\nA: %s\n\n"+
+			"Answer from this entry first and say it comes from the FAQ (#%d). Only investigate with tools if the user asks for more or the entry clearly does not cover their question.",
+			e.ID, e.Question, e.Answer, e.ID)
+	}
+
+	if h.activity == nil {
+		return ""
+	}
+	var asked []ActivityEvent
+	for _, ev := range h.activity.Events(nil, time.Now().Add(-faqRepeatWindow)) {
+		if ev.Kind == ActivityRequest && ev.AgentID == h.agentID && ev.Text != "" {
+			asked = append(asked, ev)
+		}
+	}
+	count, channels, err := h.faq.Repeats(ctx, vecs[0], asked)
+	if err != nil {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] FAQ repeat check skipped: %v", userID, channelID, err)
+		return ""
+	}
+	if count < h.faq.minRepeats || channels < 2 {
+		return ""
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] recurring question: asked %d times in %d channels", userID, channelID, count, channels)
+	return fmt.Sprintf("\n\nThis question has been asked %d times in %d channels over the last 30 days. "+
+		"After answering, offer to save a short, curated version of the answer to the FAQ so future askers get it directly. "+
+		"Call save_faq only after the user agrees, with the question and the answer text they approved.",
+		count, channels)
+}
+
+// faqTools curate the agent's FAQ. Offered when an embedding model is configured.
+var faqTools = []*ToolDef{
+	{
+		Name:        "save_faq",
+		Description: "Save a curated answer to a recurring question to this agent's FAQ. Future questions that mean the same are answered from it first. Only call this after the user explicitly agreed to save it, with the answer text they approved.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"question":{"type":"string","description":"The question in a general form, e.g. 'How do I get access to the staging cluster?'"},
+				"answer":{"type":"string","description":"The curated answer, self-contained and without details specific to one asker"}
+			},
+			"required":["question","answer"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).faqEnabled,
+		Run:       (*GeneralHandler).toolSaveFAQ,
+	},
+	{
+		Name:        "list_faqs",
+		Description: "List this agent's saved FAQ entries with their IDs and how often each was used.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).faqEnabled,
+		Run:         (*GeneralHandler).toolListFAQs,
+	},
+	{
+		Name:        "delete_faq",
+		Description: "Delete an outdated or wrong FAQ entry by ID (from list_faqs).",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"id":{"type":"integer","description":"FAQ entry ID"}
+			},
+			"required":["id"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).faqEnabled,
+		Run:       (*GeneralHandler).toolDeleteFAQ,
+	},
+}
+
+func (h *GeneralHandler) faqEnabled() bool { return h.faq != nil }
+
+func (h *GeneralHandler) toolSaveFAQ(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	args.Question, args.Answer = strings.TrimSpace(args.Question), strings.TrimSpace(args.Answer)
+	if args.Question == "" || args.Answer == "" {
+		return "Error: question and answer are required."
+	}
+	e, err := h.faq.Add(ctx, h.agentID, call.UserID, args.Question, args.Answer)
+	if err != nil {
+		return fmt.Sprintf("Error saving FAQ entry: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] saved FAQ #%d: %s", call.UserID, call.ChannelID, e.ID, e.Question)
+	return fmt.Sprintf("Saved FAQ #%d: %s", e.ID, e.Question)
+}
+
+func (h *GeneralHandler) toolListFAQs(_ context.Context, _ ToolCall) string {
+	entries := h.faq.List(h.agentID)
+	if len(entries) == 0 {
+		return "No FAQ entries saved yet."
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "#%d %s (used %d times, saved by <@%s> on %s)\n", e.ID, e.Question, e.Hits, e.CreatedBy, e.CreatedAt.Format("2006-01-02"))
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolDeleteFAQ(_ context.Context, call ToolCall) string {
+	var args struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	e, err := h.faq.Delete(h.agentID, args.ID)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	h.logger().Infof("[user=%s channel=%s] deleted FAQ #%d", call.UserID, call.ChannelID, e.ID)
+	return fmt.Sprintf("Deleted FAQ #%d: %s", e.ID, e.Question)
+}
@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/prompts"
+	"github.com/justmike1/ovad/scheduler"
+)
+
+// FreezeWindow is a change freeze: while it is active, repository write
+// tools are refused for matching repos and paths unless the requester is
+// listed in Override.
+type FreezeWindow struct {
+	Name     string
+	Reason   string
+	Override []string
+	repos    prompts.RepoPolicy // Read = repo globs (empty = all repos)
+	paths    prompts.RepoPolicy // Protected = path globs (empty = all paths)
+	window   *scheduler.Window
+}
+
+// NewFreezeWindows validates and builds the freezes from an agent's config.
+func NewFreezeWindows(cfgs []prompts.FreezeConfig) ([]*FreezeWindow, error) {
+	var out []*FreezeWindow
+	for i, c := range cfgs {
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("freeze-%d", i+1)
+		}
+		var (
+			w   *scheduler.Window
+			err error
+		)
+		switch {
+		case c.Start != "":
+			d, perr := time.ParseDuration(c.Duration)
+			if perr != nil {
+				return nil, fmt.Errorf("freeze %q: invalid duration %q: %w", c.Name, c.Duration, perr)
+			}
+			w, err = scheduler.NewRecurringWindow(c.Start, d, c.Timezone)
+		case c.From != "" && c.Until != "":
+			from, ferr := time.Parse(time.RFC3339, c.From)
+			until, uerr := time.Parse(time.RFC3339, c.Until)
+			if ferr != nil || uerr != nil {
+				return nil, fmt.Errorf("freeze %q: from/until must be RFC 3339 timestamps", c.Name)
+			}
+			w, err = scheduler.NewFixedWindow(from, until)
+		default:
+			return nil, fmt.Errorf("freeze %q: set either start+duration or from+until", c.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("freeze %q: %w", c.Name, err)
+		}
+		out = append(out, &FreezeWindow{
+			Name:     c.Name,
+			Reason:   c.Reason,
+			Override: c.Override,
+			repos:    prompts.RepoPolicy{Read: c.Repos},
+			paths:    prompts.RepoPolicy{Protected: c.Paths},
+			window:   w,
+		})
+	}
+	return out, nil
+}
+
+// covers reports whether the freeze applies to fullName and (when given) any of paths.
+func (f *FreezeWindow) covers(fullName string, paths []string) bool {
+	if !f.repos.AllowsRead(fullName) {
+		return false
+	}
+	if len(f.paths.Protected) == 0 || len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if f.paths.ProtectedMatch(fullName, p) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// activeFreeze returns the active freeze covering fullName/paths that ends
+// last, and its end time.
+func (h *GeneralHandler) activeFreeze(fullName string, paths []string, now time.Time) (*FreezeWindow, time.Time) {
+	var (
+		found  *FreezeWindow
+		latest time.Time
+	)
+	for _, f := range h.freezes {
+		end, ok := f.window.ActiveAt(now)
+		if ok && f.covers(fullName, paths) && end.After(latest) {
+			found, latest = f, end
+		}
+	}
+	return found, latest
+}
+
+// checkFreeze refuses repository writes during an active change freeze.
+// Returns a non-empty error message when the call must be refused.
+func (h *GeneralHandler) checkFreeze(ctx context.Context, userID, channelID string, def *ToolDef, argsJSON string) string {
+	if def.Class != ToolWrite || def.RepoAccess != "write" || len(h.freezes) == 0 {
+		return ""
+	}
+	fullName, err := h.toolRepo(ctx, argsJSON)
+	if err != nil {
+		return fmt.Sprintf("Error resolving repository owner: %v", err)
+	}
+	f, end := h.activeFreeze(fullName, toolPaths(argsJSON), time.Now())
+	if f == nil {
+		return ""
+	}
+	if slices.Contains(f.Override, userID) {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] %s on %s allowed during freeze %q (override)", userID, channelID, def.Name, fullName, f.Name)
+		return ""
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] blocked %s on %s: change freeze %q until %s", userID, channelID, def.Name, fullName, f.Name, end.Format(time.RFC3339))
+	msg := fmt.Sprintf("Error: change freeze %q is in effect for %s until %s", f.Name, fullName, end.UTC().Format("Mon Jan 2 15:04 MST"))
+	if f.Reason != "" {
+		msg += fmt.Sprintf(" (%s)", f.Reason)
+	}
+	msg += ". Writes to this repository are blocked. Do not retry or work around this; explain the freeze to the user"
+	if h.scheduler != nil {
+		msg += " and offer to run the change automatically when the freeze ends with schedule_after_freeze"
+	}
+	return msg + "."
+}
+
+// freezeTools let the LLM defer a blocked change until its freeze ends.
+var freezeTools = []*ToolDef{
+	{
+		Name:        "schedule_after_freeze",
+		Description: "Schedule a change that was blocked by a change freeze to run automatically right after the freeze ends. Only call this after a write tool reported a change freeze AND the user agreed to defer the change. The task runs with the same tools in this channel, on behalf of the current user.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner) the change targets"},
+				"task":{"type":"string","description":"A complete, self-contained description of the change to make (files, exact edits, PR description), since it runs later without this conversation"}
+			},
+			"required":["repo","task"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).freezeDeferralEnabled,
+		Run:       (*GeneralHandler).toolScheduleAfterFreeze,
+	},
+}
+
+func (h *GeneralHandler) freezeDeferralEnabled() bool {
+	return h.scheduler != nil && len(h.freezes) > 0
+}
+
+func (h *GeneralHandler) toolScheduleAfterFreeze(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo string `json:"repo"`
+		Task string `json:"task"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Task) == "" {
+		return "Error: task is required."
+	}
+	fullName, err := h.toolRepo(ctx, call.Args)
+	if err != nil {
+		return fmt.Sprintf("Error resolving repository owner: %v", err)
+	}
+	f, end := h.activeFreeze(fullName, nil, time.Now())
+	if f == nil {
+		return fmt.Sprintf("Error: no change freeze is active for %s; the change can be made now.", fullName)
+	}
+
+	at := end.Add(time.Minute)
+	prompt := fmt.Sprintf("Deferred change requested by <@%s> during change freeze %q. Make this change now:\n%s", call.UserID, f.Name, args.Task)
+	h.scheduler.Add(scheduler.NewOnceJob(h.agentID, "after freeze "+f.Name, at, prompt, call.ChannelID, call.UserID))
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] scheduled deferred change for %s at %s (freeze %q)", call.UserID, call.ChannelID, fullName, at.Format(time.RFC3339), f.Name)
+	return fmt.Sprintf("Scheduled: the change will run in this channel at %s, right after freeze %q ends. Note: scheduled changes are kept in memory and are lost if the bot restarts before then.", at.UTC().Format("Mon Jan 2 15:04 MST"), f.Name)
+}
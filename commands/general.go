@@ -2,38 +2,134 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 
+	"github.com/justmike1/ovad/ado"
+	"github.com/justmike1/ovad/argocd"
+	"github.com/justmike1/ovad/aws"
+	"github.com/justmike1/ovad/cloudflare"
+	"github.com/justmike1/ovad/cost"
+	"github.com/justmike1/ovad/directory"
+	"github.com/justmike1/ovad/gcp"
 	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/imagescan"
 	"github.com/justmike1/ovad/jira"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/notion"
 	"github.com/justmike1/ovad/nvd"
+	"github.com/justmike1/ovad/observability"
+	"github.com/justmike1/ovad/oncall"
+	"github.com/justmike1/ovad/osv"
+	"github.com/justmike1/ovad/pagerduty"
+	"github.com/justmike1/ovad/prompts"
+	"github.com/justmike1/ovad/redact"
+	"github.com/justmike1/ovad/scheduler"
+	"github.com/justmike1/ovad/scm"
 	ovadslack "github.com/justmike1/ovad/slack"
+	"github.com/justmike1/ovad/terraform"
+	"github.com/justmike1/ovad/ticketing"
+	"github.com/justmike1/ovad/vault"
 )
 
+// llmUnavailableMessage is the reply sent while the LLM circuit breaker is open.
+const llmUnavailableMessage = ":warning: The AI backend is currently unavailable (repeated rate-limit or server errors). Please try again in a minute or two."
+
 type GeneralHandler struct {
-	slackClient      SlackClient
-	ghClient         *github.Client
+	slackClient      ChatPlatform
+	ghClient         *github.Client // nil disables the GitHub-only tools
+	scm              scm.Provider   // backs the repository, pull request and pipeline tools; nil disables them
 	modelsClient     *github.ModelsClient
 	codeModelsClient *github.ModelsClient
 	jiraClient       *jira.Client
+	tickets          ticketing.Provider // backs the ticket tools; nil disables them
 	nvdClient        *nvd.Client
+	cveWatches       *CVEWatchStore // per-channel CVE watchlists; nil disables watch_cves
+	osvClient        *osv.Client
+	adoClient        *ado.Client           // Azure DevOps Boards; nil disables the ADO tools
+	notionClient     *notion.Client        // nil disables the Notion tools
+	postmortem       PostmortemTarget      // where publish_postmortem files documents
+	pagerDuty        *pagerduty.Client     // nil disables the incident tools
+	onCall           oncall.Provider       // Opsgenie or PagerDuty schedules; nil disables whos_on_call
+	directory        directory.Provider    // company directory; nil disables lookup_person
+	metricsBackend   observability.Backend // Datadog or Prometheus; nil disables query_metrics
+	vaultClient      *vault.Client         // metadata only; nil disables the Vault tools
+	awsClient        *aws.Client           // read-only AWS APIs; nil disables the AWS tools
+	argoCD           *argocd.Client        // nil disables the Argo CD tools
+	gcpClient        *gcp.Client           // read-only GCP APIs; nil disables the GCP tools
+	cloudflare       *cloudflare.Client    // nil disables the Cloudflare tools
+	terraform        *terraform.Client     // read-only; nil disables the Terraform Cloud tools
+	imageScanners    []imagescan.Scanner   // registries to look up image scans in, in order
+	costSources      []cost.Source         // nil disables query_cloud_costs
+	costCache        *cost.Cache           // shared across handlers; results last until the end of the day
 	contextProvider  *ContextProvider
 	memory           *ConversationMemory
 	prompts          PromptProvider
+	toolPolicy       prompts.ToolPolicy
+	access           *AccessControl           // who may use which tools; nil = everyone
+	readOnly         *ReadOnlySwitch          // disables every write tool when on; nil = never
+	spendCaps        *SpendCaps               // daily token and tool-call budgets; nil = unlimited
+	dryRun           bool                     // write tools return a preview instead of running
+	redactor         *redact.Redactor         // masks secrets in LLM inputs and replies; nil = off
+	escalation       prompts.EscalationConfig // who is tagged under low-confidence answers
+	repoPolicy       prompts.RepoPolicy
+	ledger           *ChangeLedger
+	checkpoints      *CheckpointStore
+	approvals        *ApprovalStore   // gated actions waiting for a human in the thread
+	approved         *PendingApproval // the action a human approved for this run; nil = none
+	staged           bool             // the running tool only staged its action for approval
+	sessions         *SessionStore    // thread sessions whose transcripts record this run; nil = none
+	tools            *ToolRegistry    // nil = builtinTools
+	freezes          []*FreezeWindow
+	runbooks         []*Runbook
+	scheduler        *scheduler.Scheduler // runs deferred changes; nil disables deferral
+	activity         *ActivityStore
+	audit            *AuditLog      // every tool invocation; nil disables auditing
+	faq              *FAQStore      // curated answers to recurring questions; nil disables them
+	identities       *IdentityStore // linked Slack → GitHub/Jira accounts; nil disables linking
 	agentID          string
+	requestID        string // correlates this request's log lines and API calls
 	appURL           string
 	maxToolRounds    int
-	currentChannelID string
-	currentAuditTS   string
+	contextBudget    int // approximate prompt token budget; 0 = default
+	// summarizer compresses tool results larger than compressThreshold
+	// tokens; the full text is kept in resultCache for expand_result.
+	summarizer        *github.ModelsClient
+	compressThreshold int
+	resultCache       map[string]string
+	currentTask       string
+	currentChannelID  string
+	currentAuditTS    string
 	// activeBranches tracks branches created during this Execute() run.
 	// Key: "owner/repo", Value: branch metadata. This ensures multiple
 	// modify_file calls for the same repo produce a single PR.
 	activeBranches map[string]*activeBranchInfo
 }
 
+// logger returns a logger tagged with the handler's request ID.
+func (h *GeneralHandler) logger() logging.Logger {
+	return logging.Request(h.requestID)
+}
+
+// displayName names a chat user for attribution outside chat, e.g. in PR
+// bodies and ticket stamps: "Jane Doe (U123)", or the bare ID when the
+// user can't be looked up.
+func (h *GeneralHandler) displayName(userID string) string {
+	u, err := h.slackClient.GetUserInfo(userID)
+	if err != nil {
+		return userID
+	}
+	name := u.RealName
+	if name == "" {
+		name = u.Name
+	}
+	if name == "" {
+		return userID
+	}
+	return fmt.Sprintf("%s (%s)", name, userID)
+}
+
 type activeBranchInfo struct {
 	branchName string
 	baseBranch string
@@ -41,9 +137,13 @@ type activeBranchInfo struct {
 }
 
 func (h *GeneralHandler) Execute(channelID, userID, text, responseURL, auditTS string) {
-	ctx := context.Background()
+	ctx := github.WithAttribution(logging.WithRequestID(context.Background(), h.requestID), github.Attribution{AgentID: h.agentID, UserID: userID, ChannelID: channelID})
+	if t, ok := parseDryRun(text); ok {
+		text, h.dryRun = t, true
+	}
 	h.currentChannelID = channelID
 	h.currentAuditTS = auditTS
+	h.currentTask = text
 	h.activeBranches = make(map[string]*activeBranchInfo)
 
 	tools := h.buildTools()
@@ -58,33 +158,121 @@ func (h *GeneralHandler) Execute(channelID, userID, text, responseURL, auditTS s
 	activeClient := h.modelsClient
 	if h.codeModelsClient != nil && isCodeIntent(strings.ToLower(text)) {
 		activeClient = h.codeModelsClient
-		log.Printf("[user=%s channel=%s] using code model (%s) for code-related request",
+		h.logger().Infof("[user=%s channel=%s] using code model (%s) for code-related request",
 			userID, channelID, h.codeModelsClient.Model())
 	}
 
 	systemMsg := h.systemPrompt()
 	systemMsg = strings.Replace(systemMsg, "{{MODEL}}", activeClient.Model(), 1)
 	systemMsg = strings.Replace(systemMsg, "{{USER_ID}}", userID, 1)
-	history := h.memory.GetHistory(channelID, userID)
-	if history != "" {
-		systemMsg += fmt.Sprintf("\n\nPrevious conversation with this user:\n%s", history)
+	baseSystemMsg := systemMsg
+	// In a thread, several people may take part; the shared thread history
+	// replaces the requester's own.
+	history, historyLabel := h.memory.GetHistory(channelID, userID), "Previous conversation with this user"
+	threadRecap := ""
+	if auditTS != "" {
+		threadRecap = h.memory.ThreadSummary(channelID, auditTS)
+		if th := h.memory.GetThreadHistory(channelID, auditTS); th != "" {
+			history, historyLabel = th, fmt.Sprintf("Previous conversation in this thread (the current message is from <@%s>)", userID)
+		}
 	}
-	if channelContext != "" && channelContext != "(no recent messages)" {
-		systemMsg += fmt.Sprintf("\n\nRecent channel messages for context:\n%s", channelContext)
+	if channelContext == "(no recent messages)" {
+		channelContext = ""
 	}
 
 	// Proactively fetch workflow run logs from GitHub Actions URLs found in the user's message
 	// (not channel context — channel context may contain unrelated CI notifications).
-	if workflowLogs := h.fetchWorkflowLogs(ctx, text, userID, channelID); workflowLogs != "" {
+	workflowLogs := h.fetchWorkflowLogs(ctx, text, userID, channelID)
+
+	// Secrets pasted into requests or printed in logs must not reach the LLM.
+	history = redactText(h.redactor, h.agentID, "history", history)
+	workflowLogs = redactText(h.redactor, h.agentID, "workflow_logs", workflowLogs)
+	request := redactText(h.redactor, h.agentID, "request", text)
+
+	// Trim history, channel context, and logs (oldest lines of the largest
+	// section first) so the prompt fits the context window.
+	toolTokens := estimateToolsTokens(tools)
+	fixed := estimateTokens(baseSystemMsg) + estimateTokens(request) + toolTokens
+	fitSections(h.tokenBudget()-fixed, &history, &threadRecap, &channelContext, &workflowLogs)
+
+	if threadRecap != "" {
+		systemMsg += fmt.Sprintf("\n\nRecap of an earlier session in this thread:\n%s", threadRecap)
+	}
+	if history != "" {
+		systemMsg += fmt.Sprintf("\n\n%s:\n%s", historyLabel, history)
+	}
+	if channelContext != "" {
+		systemMsg += fmt.Sprintf("\n\nRecent channel messages for context:\n%s", channelContext)
+	}
+	if workflowLogs != "" {
 		systemMsg += fmt.Sprintf("\n\nGitHub Actions workflow run details and logs (auto-fetched from URLs found in your message):\n\n%s", workflowLogs)
 	}
+	systemMsg += h.faqContext(ctx, channelID, userID, request)
+	systemMsg += confidenceInstruction
+	if h.dryRun {
+		systemMsg += dryRunInstruction
+	}
 
-	messages := []github.ChatMessage{
-		github.NewChatMessage("system", systemMsg),
-		github.NewChatMessage("user", text),
+	loop := &toolLoop{
+		channelID:     channelID,
+		userID:        userID,
+		responseURL:   responseURL,
+		auditTS:       auditTS,
+		tools:         tools,
+		toolTokens:    toolTokens,
+		client:        activeClient,
+		baseSystemMsg: baseSystemMsg,
+		messages: []github.ChatMessage{
+			github.NewChatMessage("system", systemMsg),
+			github.NewChatMessage("user", request),
+		},
 	}
+	h.runToolLoop(ctx, loop)
+}
 
-	repliedInThread := false
+// toolLoop is the state of one tool-calling conversation. It is saved in a
+// Checkpoint when the round limit is reached so the user can resume it.
+type toolLoop struct {
+	channelID, userID, responseURL, auditTS string
+
+	messages        []github.ChatMessage
+	tools           []github.Tool
+	toolTokens      int
+	client          *github.ModelsClient
+	baseSystemMsg   string
+	repliedInThread bool
+	sanitized       bool
+	priorRounds     int // rounds used by earlier runs of a resumed conversation
+	// tokenScale is the provider-reported prompt size of the last request
+	// divided by its estimate; 0 until a response reports usage.
+	tokenScale float64
+}
+
+// calibrate compares the prompt size the provider reported for the request
+// just sent with its estimate, so later rounds trim against real counts.
+func (l *toolLoop) calibrate(resp *github.ChatResponse) {
+	if resp == nil || resp.Usage == nil || resp.Usage.PromptTokens <= 0 {
+		return
+	}
+	if est := estimateMessagesTokens(l.messages) + l.toolTokens; est > 0 {
+		l.tokenScale = float64(resp.Usage.PromptTokens) / float64(est)
+	}
+}
+
+// scaledBudget converts budget, in real tokens, to estimated tokens once
+// the estimate is known to run low.
+func (l *toolLoop) scaledBudget(budget int) int {
+	if l.tokenScale <= 1 {
+		return budget
+	}
+	return int(float64(budget) / l.tokenScale)
+}
+
+// runToolLoop drives the LLM ↔ tool exchange until the model answers, an
+// error occurs, or the round limit is reached.
+func (h *GeneralHandler) runToolLoop(ctx context.Context, l *toolLoop) {
+	channelID, userID, responseURL, auditTS := l.channelID, l.userID, l.responseURL, l.auditTS
+	budget := h.tokenBudget()
 
 	rounds := h.maxToolRounds
 	if rounds <= 0 {
@@ -92,15 +280,34 @@ func (h *GeneralHandler) Execute(channelID, userID, text, responseURL, auditTS s
 	}
 
 	for i := 0; i < rounds; i++ {
-		resp, err := activeClient.CompleteWithTools(ctx, messages, tools)
+		trimMessagesToBudget(l.messages, l.toolTokens, l.scaledBudget(budget))
+		resp, err := l.client.CompleteWithTools(ctx, l.messages, l.tools)
+		if err != nil && github.IsContentFiltered(err) && !l.sanitized {
+			logging.Ctx(ctx).Warnf("[user=%s channel=%s] content filter blocked request, retrying once with sanitized context: %v", userID, channelID, err)
+			l.sanitized = true
+			l.messages = sanitizeMessages(l.messages, l.baseSystemMsg)
+			resp, err = l.client.CompleteWithTools(ctx, l.messages, l.tools)
+		}
+		if err != nil && github.IsContentFiltered(err) {
+			logging.Ctx(ctx).Warnf("[user=%s channel=%s] content filter blocked request after sanitized retry: %v", userID, channelID, err)
+			h.replyDefault(channelID, responseURL, auditTS, contentPolicyMessage(err))
+			return
+		}
+		if err != nil && github.IsLLMUnavailable(err) {
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] LLM backend unavailable (circuit open): %v", userID, channelID, err)
+			h.replyDefault(channelID, responseURL, auditTS, llmUnavailableMessage)
+			return
+		}
 		if err != nil {
-			log.Printf("[user=%s channel=%s] LLM completion failed for general query: %v", userID, channelID, err)
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] LLM completion failed for general query: %v", userID, channelID, err)
 			h.replyDefault(channelID, responseURL, auditTS, fmt.Sprintf("Failed to process request: %v", err))
 			return
 		}
 
+		l.calibrate(resp)
+
 		if len(resp.Choices) == 0 {
-			log.Printf("[user=%s channel=%s] LLM returned no choices", userID, channelID)
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] LLM returned no choices", userID, channelID)
 			h.replyDefault(channelID, responseURL, auditTS, "No response from the model.")
 			return
 		}
@@ -108,1274 +315,187 @@ func (h *GeneralHandler) Execute(channelID, userID, text, responseURL, auditTS s
 		choice := resp.Choices[0]
 
 		if len(choice.Message.ToolCalls) == 0 {
-			log.Printf("[user=%s channel=%s] general query completed successfully", userID, channelID)
-			h.memory.SetAssistantResponse(channelID, userID, choice.Message.Content)
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] general query completed successfully", userID, channelID)
+			answer, rating := splitConfidence(choice.Message.Content)
+			h.memory.SetAssistantResponse(channelID, userID, answer)
+			if auditTS != "" {
+				h.memory.SetThreadResponse(channelID, auditTS, answer)
+			}
 			// If we already replied in a specific thread, don't send a redundant follow-up.
-			if repliedInThread {
-				log.Printf("[user=%s channel=%s] skipping reply (already replied in thread)", userID, channelID)
+			if l.repliedInThread {
+				logging.Ctx(ctx).Infof("[user=%s channel=%s] skipping reply (already replied in thread)", userID, channelID)
 				return
 			}
-			h.replyDefault(channelID, responseURL, auditTS, choice.Message.Content)
+			content := answer
+			if choice.FinishReason == "length" {
+				content += "\n\n_(Response truncated: the model reached its output limit.)_"
+			}
+			content = h.verifyReferences(ctx, userID, channelID, content, l.messages)
+			content = h.flagLowConfidence(userID, channelID, content, rating, l.messages)
+			content += sourcesFooter(sourcesFromMessages(l.messages))
+			if h.dryRun {
+				content = dryRunBanner + content
+			}
+			h.replyDefault(channelID, responseURL, auditTS, content)
 			return
 		}
 
-		messages = append(messages, github.ChatMessage{
+		l.messages = append(l.messages, github.ChatMessage{
 			Role:      "assistant",
+			Content:   choice.Message.Content,
 			ToolCalls: choice.Message.ToolCalls,
 		})
 
 		for _, tc := range choice.Message.ToolCalls {
-			log.Printf("[user=%s channel=%s] LLM called tool: %s(%s)", userID, channelID, tc.Function.Name, tc.Function.Arguments)
-			result := h.executeTool(ctx, channelID, userID, auditTS, tc.Function.Name, tc.Function.Arguments)
-			messages = append(messages, github.NewToolResultMessage(tc.ID, result))
-			if tc.Function.Name == "reply_in_thread" && !strings.HasPrefix(result, "Error") {
-				repliedInThread = true
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] LLM called tool: %s(%s)", userID, channelID, tc.Function.Name, tc.Function.Arguments)
+			result, outcome := h.executeToolOutcome(ctx, channelID, userID, auditTS, tc.Function.Name, tc.Function.Arguments)
+			toolLabel := h.metricToolName(tc.Function.Name)
+			metrics.ToolCalls.Inc(h.agentID, toolLabel)
+			if strings.HasPrefix(result, "Error") {
+				metrics.ToolErrors.Inc(h.agentID, toolLabel)
 			}
+			l.messages = append(l.messages, github.NewToolResultMessage(tc.ID, h.compressResult(ctx, userID, channelID, tc.Function.Name, result)))
+			if tc.Function.Name == "reply_in_thread" && !h.dryRun && !strings.HasPrefix(result, "Error") {
+				l.repliedInThread = true
+			}
+			h.recordChange(channelID, userID, tc.Function.Name, tc.Function.Arguments, result, outcome)
+			h.warnPermissionFailure(channelID, auditTS, tc.Function.Name, result)
 			// Dynamically switch to the code model once code-related
 			// tools are invoked (covers cases where initial intent detection
 			// didn't trigger the code model).
 			codeTools := map[string]bool{
-				"modify_file": true, "get_file_content": true,
+				"modify_file": true, "rewrite_file": true, "commit_files": true, "get_file_content": true,
 				"search_code": true, "search_files": true,
 				"list_directory": true, "get_pull_request": true,
 			}
-			if codeTools[tc.Function.Name] && h.codeModelsClient != nil && activeClient != h.codeModelsClient {
-				activeClient = h.codeModelsClient
-				log.Printf("[user=%s channel=%s] switched to code model (%s) after %s call",
+			if codeTools[tc.Function.Name] && h.codeModelsClient != nil && l.client != h.codeModelsClient {
+				l.client = h.codeModelsClient
+				logging.Ctx(ctx).Infof("[user=%s channel=%s] switched to code model (%s) after %s call",
 					userID, channelID, h.codeModelsClient.Model(), tc.Function.Name)
 			}
 		}
 	}
 
-	log.Printf("[user=%s channel=%s] exceeded max tool rounds", userID, channelID)
-	h.replyDefault(channelID, responseURL, auditTS, "The request required too many steps. Please try a simpler query.")
-}
-
-func (h *GeneralHandler) systemPrompt() string {
-	return h.prompts.MustGet("security") + "\n\n" + h.prompts.MustGet("general")
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] exceeded max tool rounds", userID, channelID)
+	h.pauseAtRoundLimit(ctx, l, rounds)
 }
 
-func (h *GeneralHandler) buildTools() []github.Tool {
-	tools := []github.Tool{
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "list_org_repos",
-				Description: "List all repositories in the GitHub organization that the bot has access to.",
-				Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "list_user_repos",
-				Description: "List all repositories accessible by the authenticated GitHub user.",
-				Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "get_file_content",
-				Description: "Read the content of a file from a GitHub repository.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"path":{"type":"string","description":"File path within the repository"},
-						"branch":{"type":"string","description":"Branch name (optional, uses default branch if empty)"}
-					},
-					"required":["repo","path"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "get_repo_default_branch",
-				Description: "Get the default branch name of a repository.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"}
-					},
-					"required":["repo"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "get_authenticated_user",
-				Description: "Get the GitHub username of the authenticated bot user.",
-				Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "resolve_owner",
-				Description: "Resolve the GitHub organization or user that owns repositories.",
-				Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "fetch_channel_context",
-				Description: "Fetch recent messages from the current Slack channel for additional context about the ongoing conversation.",
-				Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "search_files",
-				Description: "Search for files in a repository by name or path pattern. Returns all file paths containing the search term. Use this FIRST when looking for a specific file — it is much faster than navigating directories one by one.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"pattern":{"type":"string","description":"Search term to match against file paths (case-insensitive, e.g. 'services.yaml' or 'amplify/main.tf')"},
-						"branch":{"type":"string","description":"Branch name (optional, uses default branch if empty)"}
-					},
-					"required":["repo","pattern"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "list_directory",
-				Description: "List the files and subdirectories at a path in a GitHub repository. Use this when get_file_content fails because a path is a directory, or when you need to discover what files exist under a path.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"path":{"type":"string","description":"Directory path within the repository"},
-						"branch":{"type":"string","description":"Branch name (optional, uses default branch if empty)"}
-					},
-					"required":["repo","path"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "modify_file",
-				Description: "Modify a file in a GitHub repository using a safe find-and-replace approach. Provide the exact text to find (old_content) and the replacement text (new_content). The tool reads the FULL file from GitHub, performs the replacement, then creates a branch, commits, and opens a PR. Multiple modify_file calls for the SAME repository are automatically grouped into a SINGLE pull request — so when implementing a change that touches several files, just call modify_file for each file and all changes will land in one PR. IMPORTANT: old_content must be an exact substring of the current file — include enough surrounding lines (3-5) will ensure a unique match. Only the matched section is replaced; the rest of the file is preserved.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"path":{"type":"string","description":"File path within the repository"},
-						"old_content":{"type":"string","description":"The exact text in the current file to find and replace. Include 3-5 surrounding context lines to ensure a unique match."},
-						"new_content":{"type":"string","description":"The replacement text that will replace old_content."},
-						"description":{"type":"string","description":"Short description of what was changed (used as commit message and PR title)"},
-						"branch":{"type":"string","description":"Base branch name (optional, uses default branch if empty)"}
-					},
-					"required":["repo","path","old_content","new_content","description"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "get_pull_request",
-				Description: "Get details, changed files, and diff of a GitHub pull request by number or URL. Use this to analyze what a PR changed, understand code patterns introduced or removed, and find old/new usage patterns.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"number":{"type":"integer","description":"Pull request number (e.g., 123)"},
-						"url":{"type":"string","description":"Full GitHub PR URL (alternative to repo+number). If provided, repo and number are extracted from it."}
-					},
-					"required":[]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "list_pull_requests",
-				Description: "List recent pull requests in a repository. Useful for finding relevant PRs by title, discovering recent changes, or identifying the PR that introduced a particular change.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"state":{"type":"string","description":"Filter by state: 'open', 'closed', or 'all' (default: 'all')"},
-						"limit":{"type":"integer","description":"Maximum number of PRs to return (default: 10, max: 30)"}
-					},
-					"required":["repo"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "search_code",
-				Description: "Search for code content within a GitHub repository. Unlike search_files (which matches file names/paths), this searches inside file contents. Use this to find usages of functions, classes, patterns, imports, or any code string across the entire repository. Returns matching files with code fragments showing the context around each match.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"repo":{"type":"string","description":"Repository name (without owner)"},
-						"query":{"type":"string","description":"Code search query. Can include the code pattern to find (e.g., 'db.session', 'SessionLocal()', 'def create_session'). Supports GitHub code search qualifiers like 'language:python', 'path:src/', 'extension:py'."}
-					},
-					"required":["repo","query"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "get_workflow_run",
-				Description: "Fetch details and logs for a GitHub Actions workflow run. Use this PROACTIVELY whenever you see a failed CI/CD notification, a GitHub Actions URL, or the user mentions a build/deploy/pipeline failure. Returns the run status, jobs, steps, annotations, and actual log output for any failed jobs so you can diagnose the root cause.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"url":{"type":"string","description":"Full GitHub Actions workflow run URL (e.g., 'https://github.com/org/repo/actions/runs/12345'). Extract this from channel context messages — look for 'View Workflow Run' button URLs or similar links."}
-					},
-					"required":["url"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "rerun_failed_jobs",
-				Description: "Re-run only the failed jobs (and their dependent jobs) in a GitHub Actions workflow run. This is equivalent to clicking 'Re-run failed jobs' in the GitHub Actions UI. Use this when the user asks to retry, rerun, or re-trigger a failed workflow. Only works on completed runs that have at least one failed job.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"url":{"type":"string","description":"Full GitHub Actions workflow run URL (e.g., 'https://github.com/org/repo/actions/runs/12345')."}
-					},
-					"required":["url"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "rerun_workflow",
-				Description: "Re-run an entire GitHub Actions workflow run (all jobs, not just failed ones). This is equivalent to clicking 'Re-run all jobs' in the GitHub Actions UI. Use this when the user wants to completely re-trigger a workflow from scratch.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"url":{"type":"string","description":"Full GitHub Actions workflow run URL (e.g., 'https://github.com/org/repo/actions/runs/12345')."}
-					},
-					"required":["url"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "reply_in_thread",
-				Description: "Post a message as a threaded reply to a specific Slack message. Use this when the user asks you to reply inside someone's thread or respond to a particular message. You need the thread_ts of the target message from the channel context. IMPORTANT: Messages marked [BOT] are this bot's own messages — never reply to those. Always use the thread_ts of the HUMAN user's message (e.g. the person mentioned by name like 'Shahar', 'John', etc.).",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"thread_ts":{"type":"string","description":"The thread_ts timestamp of the target human user's message to reply to. MUST be from a non-[BOT] message. Get this from the channel context."},
-						"text":{"type":"string","description":"The message text to post as a threaded reply. Supports Slack markdown formatting."}
-					},
-					"required":["thread_ts","text"]
-				}`),
-			},
-		},
-		{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "fetch_thread_context",
-				Description: "Fetch the full conversation from a Slack thread URL. Use this FIRST whenever the user provides a Slack thread/message link (https://...slack.com/archives/...) to read the thread's content before acting on it (e.g., creating a Jira ticket, summarizing, replying). Returns all messages in the thread. The response also includes the channel_id and thread_ts so you can reply_in_thread afterwards.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"url":{"type":"string","description":"Slack thread or message URL (e.g. 'https://yourorg.slack.com/archives/C01BS13KFL7/p1771847194296799')"}
-					},
-					"required":["url"]
-				}`),
-			},
-		},
-	}
-
-	// NVD CVE lookup tools are always available (NVD client is always created).
-	if h.nvdClient != nil {
-		tools = append(tools, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "lookup_cve",
-				Description: "Look up a specific CVE by its ID from the NVD (National Vulnerability Database). Returns full details: description, CVSS scores, affected products (CPEs), weaknesses (CWEs), and references. ALWAYS call this tool FIRST when the user mentions a CVE ID (e.g. CVE-2025-13836) to get authoritative data before searching code.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"cve_id":{"type":"string","description":"The CVE identifier (e.g. 'CVE-2025-13836')"}
-					},
-					"required":["cve_id"]
-				}`),
-			},
-		}, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "search_cve",
-				Description: "Search NVD for CVEs by keyword. Returns matching CVEs with their descriptions and CVSS scores. Useful for finding CVEs related to a specific library, product, or vulnerability type when you don't have the exact CVE ID.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"keyword":{"type":"string","description":"Search keyword(s) to match against CVE descriptions (e.g. 'log4j remote code execution', 'jackson-databind')"},
-						"results_per_page":{"type":"integer","description":"Number of results to return (default: 5, max: 20)"}
-					},
-					"required":["keyword"]
-				}`),
-			},
-		})
+// pauseAtRoundLimit checkpoints a loop that ran out of rounds, posts a
+// progress summary, and tells the user how to resume.
+func (h *GeneralHandler) pauseAtRoundLimit(ctx context.Context, l *toolLoop, rounds int) {
+	if h.checkpoints == nil || l.auditTS == "" {
+		h.replyDefault(l.channelID, l.responseURL, l.auditTS, "The request required too many steps. Please try a simpler query.")
+		return
 	}
 
-	// Jira tools are only available when Jira is configured.
-	if h.jiraClient != nil {
-		tools = append(tools, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "create_jira_ticket",
-				Description: "Create a Jira ticket (issue). Use this when the user asks to create a ticket, task, story, or bug from the conversation content (e.g., a test plan, action item, or bug report). Populate the summary and description from the relevant content discussed in the conversation. IMPORTANT: Format the description using markdown — use # for headers, - for bullet lists, 1) for numbered lists, **bold** for emphasis, and `code` for inline code. Structure the ticket professionally with clear sections (e.g., ## Context, ## Scope, ## Acceptance Criteria). If the user asks to assign the ticket to a person, use the assignee field. If the user asks to assign to a team, use the team field. Both can be used at the same time.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"project":{"type":"string","description":"Jira project key (e.g. 'ENG', 'QA'). Optional — uses the configured default if omitted."},
-						"summary":{"type":"string","description":"Short one-line title for the ticket."},
-						"description":{"type":"string","description":"Detailed, well-structured description using markdown formatting. Use ## for section headers, - for bullet points, 1) for numbered steps, **bold** for key terms, and backticks for code references. Organize into clear sections like Context, Scope, Test Plan, Acceptance Criteria, References, etc."},
-						"issue_type":{"type":"string","description":"Issue type: 'Task', 'Bug', 'Story', 'Epic', etc. Default: 'Task'."},
-						"labels":{"type":"array","items":{"type":"string"},"description":"Optional labels to apply to the ticket (e.g. ['qa','automated-test'])."},
-						"assignee":{"type":"string","description":"Name of the person to assign the ticket to (e.g. 'Udi', 'John Smith'). The system will search for a matching Jira user."},
-						"team":{"type":"string","description":"Name of the team to assign the ticket to (e.g. 'Application', 'DevOps', 'asgard'). The system will search for a matching Jira team."}
-					},
-					"required":["summary","description"]
-				}`),
-			},
-		}, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "list_jira_projects",
-				Description: "List all Jira projects visible to the bot. Use this to discover available project keys before creating a ticket.",
-				Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
-			},
-		}, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "search_jira_issues",
-				Description: "Search for Jira issues using JQL (Jira Query Language). IMPORTANT: Jira Cloud does NOT reliably support searching by display name. Before searching by assignee, you MUST first call resolve_jira_user to get the user's Jira account ID, then use that account ID in JQL (e.g. assignee = 'accountId'). Common JQL examples: 'assignee = \"712020:abc-def\" AND status = \"In Progress\"', 'project = ENG AND status = \"To Do\"'. When searching for a specific user's tickets: 1) call get_slack_user_info to get their real name, 2) call resolve_jira_user with that name to get the Jira account ID, 3) use the account ID in the JQL query.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"jql":{"type":"string","description":"JQL query string (e.g. 'assignee = \"John Doe\" AND status = \"In Progress\" ORDER BY updated DESC')"},
-						"max_results":{"type":"integer","description":"Maximum number of results to return (default: 20, max: 50)"}
-					},
-					"required":["jql"]
-				}`),
-			},
-		}, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "get_jira_issue",
-				Description: "Get full details of a specific Jira issue by its key (e.g. 'ENG-123'). Returns summary, description, status, assignee, priority, labels, and more.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"issue_key":{"type":"string","description":"Jira issue key (e.g. 'ENG-123', 'PROJ-456')"}
-					},
-					"required":["issue_key"]
-				}`),
-			},
-		}, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "update_jira_issue",
-				Description: "Update a Jira issue's description or summary. Use this to rewrite, refine, or improve ticket descriptions. IMPORTANT: Format the new description using markdown — use # for headers, - for bullet lists, 1) for numbered lists, **bold** for emphasis. Structure it professionally with clear sections.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"issue_key":{"type":"string","description":"Jira issue key (e.g. 'ENG-123')"},
-						"summary":{"type":"string","description":"New summary/title for the ticket (optional — only set if you want to change it)"},
-						"description":{"type":"string","description":"New description for the ticket in markdown format. Structure with clear sections like ## Context, ## Requirements, ## Acceptance Criteria, etc."}
-					},
-					"required":["issue_key"]
-				}`),
-			},
-		})
+	summary := progressFromMessages(l.messages)
+	summaryMsgs := append(append([]github.ChatMessage{}, l.messages...), github.NewChatMessage("user",
+		"You have reached the step limit for this request. Do NOT call any tools. In a few short bullets, summarize what you have done so far (include any PR/ticket URLs) and what remains to finish the task."))
+	if resp, err := l.client.CompleteWithTools(ctx, summaryMsgs, l.tools); err == nil && len(resp.Choices) > 0 &&
+		len(resp.Choices[0].Message.ToolCalls) == 0 && strings.TrimSpace(resp.Choices[0].Message.Content) != "" {
+		summary = resp.Choices[0].Message.Content
 	}
 
-	// Slack user info tool is always available.
-	tools = append(tools, github.Tool{
-		Type: "function",
-		Function: github.ToolFunction{
-			Name:        "get_slack_user_info",
-			Description: "Get the real name and profile information of a Slack user by their user ID. Use this to resolve the current user's real name for Jira queries. The user_id is available from the conversation context (the person who sent the command).",
-			Parameters: json.RawMessage(`{
-				"type":"object",
-				"properties":{
-					"user_id":{"type":"string","description":"Slack user ID (e.g. 'U01ABC123'). Use the current user's ID from the command context."}
-				},
-				"required":["user_id"]
-			}`),
-		},
+	h.checkpoints.Save(l.channelID, l.auditTS, &Checkpoint{
+		UserID:    l.userID,
+		Task:      h.currentTask,
+		Messages:  l.messages,
+		CodeModel: h.codeModelsClient != nil && l.client == h.codeModelsClient,
+		Branches:  h.activeBranches,
+		Rounds:    l.priorRounds + rounds,
+		DryRun:    h.dryRun,
 	})
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] checkpointed conversation after %d rounds", l.userID, l.channelID, l.priorRounds+rounds)
 
-	// Jira user resolution tool — resolves a person's name/email to their Jira account ID.
-	if h.jiraClient != nil {
-		tools = append(tools, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "resolve_jira_user",
-				Description: "Search for a Jira user by name and/or email and return their account ID. IMPORTANT: Jira Cloud JQL does NOT reliably support searching by display name (e.g. assignee = 'Mike Joseph' may return zero results). You MUST call this tool first to get the user's Jira account ID, then use that account ID in JQL queries (e.g. assignee = 'accountId'). This is the ONLY reliable way to find issues by assignee in Jira Cloud. ALWAYS pass both name AND email (from get_slack_user_info) for best results — email-based search is the most reliable.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"name":{"type":"string","description":"The person's display name (e.g. 'Mike Joseph', 'John Smith')"},
-						"email":{"type":"string","description":"The person's email address (most reliable for Jira lookup). Get this from get_slack_user_info."}
-					},
-					"required":["name"]
-				}`),
-			},
-		}, github.Tool{
-			Type: "function",
-			Function: github.ToolFunction{
-				Name:        "resolve_jira_team",
-				Description: "Resolve a Jira team name to its UUID and JQL clause name. The Jira Teams integration field uses UUIDs, NOT display names, in JQL. You MUST call this tool first when searching for a team's tickets — it returns the JQL clause (e.g. 'Team[Team]') and team UUID. Then use the result in JQL like: '\"Team[Team]\" = \"<uuid>\"'. Example: resolve_jira_team({\"team_name\": \"DevOps\"}) → clause='Team[Team]', uuid='d6c2ac7c-...', then search with JQL '\"Team[Team]\" = \"d6c2ac7c-...\" AND status = \"In Progress\"'.",
-				Parameters: json.RawMessage(`{
-					"type":"object",
-					"properties":{
-						"team_name":{"type":"string","description":"The team name to resolve (e.g. 'DevOps', 'Platforms', 'Remediation')"}
-					},
-					"required":["team_name"]
-				}`),
-			},
-		})
-	}
-
-	return tools
+	h.replyDefault(l.channelID, l.responseURL, l.auditTS, fmt.Sprintf(
+		":pause_button: I reached the step limit (%d tool rounds) before finishing.\n\n*Progress so far:*\n%s\n\nReply `continue` in this thread within %d min to resume where I left off.",
+		rounds, summary, int(checkpointTTL.Minutes())))
 }
 
-func (h *GeneralHandler) executeTool(ctx context.Context, channelID, userID, auditTS, name, argsJSON string) string {
-	switch name {
-	case "list_org_repos":
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		repos, err := h.ghClient.ListOrgRepos(ctx, owner)
-		if err != nil {
-			return fmt.Sprintf("Error listing org repos: %v", err)
-		}
-		if len(repos) == 0 {
-			return fmt.Sprintf("No repositories found for organization %s.", owner)
-		}
-		log.Printf("[user=%s channel=%s] listed %d org repos for %s", userID, channelID, len(repos), owner)
-		return fmt.Sprintf("Organization: %s\nRepositories (%d):\n%s", owner, len(repos), strings.Join(repos, "\n"))
-
-	case "list_user_repos":
-		repos, err := h.ghClient.ListUserRepos(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error listing user repos: %v", err)
-		}
-		if len(repos) == 0 {
-			return "No repositories found for the authenticated user."
-		}
-		log.Printf("[user=%s channel=%s] listed %d user repos", userID, channelID, len(repos))
-		return fmt.Sprintf("Repositories (%d):\n%s", len(repos), strings.Join(repos, "\n"))
-
-	case "get_file_content":
-		var args struct {
-			Repo   string `json:"repo"`
-			Path   string `json:"path"`
-			Branch string `json:"branch"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		branch := args.Branch
-		if branch == "" {
-			branch, err = h.ghClient.GetDefaultBranch(ctx, owner, args.Repo)
-			if err != nil {
-				return fmt.Sprintf("Error getting default branch: %v", err)
-			}
-		}
-		content, _, err := h.ghClient.GetFileContent(ctx, owner, args.Repo, args.Path, branch)
-		if err != nil {
-			hint := ""
-			if strings.Contains(err.Error(), "404") {
-				hint = " This path may be a directory, or it may be nested under a provider subdirectory (e.g. aws/, azure/). Try list_directory on the parent path to discover the correct structure, then read the files you need."
-			}
-			return fmt.Sprintf("Error reading file: %v.%s", err, hint)
-		}
-		if len(content) > 8000 {
-			content = content[:8000] + "\n... (truncated — file is longer than shown, important content may follow)"
-		}
-		return content
-
-	case "get_repo_default_branch":
-		var args struct {
-			Repo string `json:"repo"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		branch, err := h.ghClient.GetDefaultBranch(ctx, owner, args.Repo)
-		if err != nil {
-			return fmt.Sprintf("Error: %v", err)
-		}
-		return fmt.Sprintf("Default branch for %s: %s", args.Repo, branch)
-
-	case "get_authenticated_user":
-		user, err := h.ghClient.GetAuthenticatedUser(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error: %v", err)
-		}
-		return fmt.Sprintf("Authenticated as: %s", user)
-
-	case "resolve_owner":
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error: %v", err)
-		}
-		return fmt.Sprintf("Resolved owner: %s", owner)
-
-	case "search_files":
-		var args struct {
-			Repo    string `json:"repo"`
-			Pattern string `json:"pattern"`
-			Branch  string `json:"branch"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		branch := args.Branch
-		if branch == "" {
-			branch, err = h.ghClient.GetDefaultBranch(ctx, owner, args.Repo)
-			if err != nil {
-				return fmt.Sprintf("Error getting default branch: %v", err)
-			}
-		}
-		matches, err := h.ghClient.SearchFiles(ctx, owner, args.Repo, branch, args.Pattern)
-		if err != nil {
-			return fmt.Sprintf("Error searching files: %v", err)
-		}
-		if len(matches) == 0 {
-			return fmt.Sprintf("No files matching '%s' found in %s.", args.Pattern, args.Repo)
-		}
-		log.Printf("[user=%s channel=%s] searched files in %s for '%s' (%d matches)", userID, channelID, args.Repo, args.Pattern, len(matches))
-		if len(matches) > 50 {
-			matches = matches[:50]
-			return fmt.Sprintf("Found %d+ matches (showing first 50):\n%s", len(matches), strings.Join(matches, "\n"))
-		}
-		return fmt.Sprintf("Found %d matches:\n%s", len(matches), strings.Join(matches, "\n"))
-
-	case "list_directory":
-		var args struct {
-			Repo   string `json:"repo"`
-			Path   string `json:"path"`
-			Branch string `json:"branch"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		branch := args.Branch
-		if branch == "" {
-			branch, err = h.ghClient.GetDefaultBranch(ctx, owner, args.Repo)
-			if err != nil {
-				return fmt.Sprintf("Error getting default branch: %v", err)
-			}
-		}
-		entries, err := h.ghClient.GetDirectoryContents(ctx, owner, args.Repo, args.Path, branch)
-		if err != nil {
-			return fmt.Sprintf("Error listing directory: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] listed directory %s/%s/%s (%d entries)", userID, channelID, args.Repo, branch, args.Path, len(entries))
-		return fmt.Sprintf("Contents of %s/%s:\n%s", args.Repo, args.Path, strings.Join(entries, "\n"))
-
-	case "fetch_channel_context":
-		context, err := h.contextProvider.GetChannelContext(channelID)
-		if err != nil {
-			return fmt.Sprintf("Error fetching channel context: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] fetched channel context via tool", userID, channelID)
-		return context
-
-	case "modify_file":
-		var args struct {
-			Repo        string `json:"repo"`
-			Path        string `json:"path"`
-			OldContent  string `json:"old_content"`
-			NewContent  string `json:"new_content"`
-			Description string `json:"description"`
-			Branch      string `json:"branch"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		baseBranch := args.Branch
-		if baseBranch == "" {
-			baseBranch, err = h.ghClient.GetDefaultBranch(ctx, owner, args.Repo)
-			if err != nil {
-				return fmt.Sprintf("Error getting default branch: %v", err)
-			}
-		}
-
-		// Reuse an existing branch for this repo if one was created earlier in this session.
-		repoKey := owner + "/" + args.Repo
-		active := h.activeBranches[repoKey]
-
-		// Determine which branch to read the file from.
-		// If we already have an active branch, read from it (it may contain prior commits).
-		readBranch := baseBranch
-		if active != nil {
-			readBranch = active.branchName
-		}
-
-		fullContent, fileSHA, err := h.ghClient.GetFileContent(ctx, owner, args.Repo, args.Path, readBranch)
-		if err != nil {
-			return fmt.Sprintf("Error reading current file: %v", err)
-		}
-		// Perform find-and-replace on the full file content.
-		if !strings.Contains(fullContent, args.OldContent) {
-			return "Error: old_content not found in the file. Make sure old_content is an exact substring of the current file (including whitespace and indentation). Re-read the file with get_file_content and try again."
-		}
-		occurrences := strings.Count(fullContent, args.OldContent)
-		if occurrences > 1 {
-			return fmt.Sprintf("Error: old_content matches %d locations in the file. Include more surrounding context lines to make it unique.", occurrences)
-		}
-		updatedContent := strings.Replace(fullContent, args.OldContent, args.NewContent, 1)
-
-		if active == nil {
-			// First modification for this repo — create a new branch and PR.
-			branchName := github.GenerateBranchName(h.agentID)
-			if err := h.ghClient.CreateBranch(ctx, owner, args.Repo, baseBranch, branchName); err != nil {
-				return fmt.Sprintf("Error creating branch: %v", err)
-			}
-			commitMsg := fmt.Sprintf("%s: %s", h.agentID, args.Description)
-			if err := h.ghClient.UpdateFile(ctx, owner, args.Repo, args.Path, branchName, commitMsg, []byte(updatedContent), fileSHA); err != nil {
-				return fmt.Sprintf("Error committing file: %v", err)
-			}
-			prTitle := fmt.Sprintf("%s: %s", h.agentID, args.Description)
-			prBody := fmt.Sprintf("Automated change requested via Slack by <@%s>.\n\nChange: %s", userID, args.Description)
-			prURL, err := h.ghClient.CreatePullRequest(ctx, owner, args.Repo, baseBranch, branchName, prTitle, prBody)
-			if err != nil {
-				return fmt.Sprintf("Changes committed to branch %s but PR creation failed: %v", branchName, err)
-			}
-			h.activeBranches[repoKey] = &activeBranchInfo{
-				branchName: branchName,
-				baseBranch: baseBranch,
-				prURL:      prURL,
-			}
-			log.Printf("[user=%s channel=%s] PR created via modify_file: %s", userID, channelID, prURL)
-			return fmt.Sprintf("Pull request created: %s", prURL)
-		}
-
-		// Subsequent modification — commit to the existing branch.
-		commitMsg := fmt.Sprintf("%s: %s", h.agentID, args.Description)
-		if err := h.ghClient.UpdateFile(ctx, owner, args.Repo, args.Path, active.branchName, commitMsg, []byte(updatedContent), fileSHA); err != nil {
-			return fmt.Sprintf("Error committing file to existing branch: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] additional commit to branch %s for PR: %s", userID, channelID, active.branchName, active.prURL)
-		return fmt.Sprintf("Changes committed to existing PR: %s", active.prURL)
-
-	case "get_pull_request":
-		var args struct {
-			Repo   string `json:"repo"`
-			Number int    `json:"number"`
-			URL    string `json:"url"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		// If a URL was provided, extract owner/repo/number from it.
-		if args.URL != "" {
-			prOwner, prRepo, prNum, parseErr := github.ParsePRURL(args.URL)
-			if parseErr != nil {
-				return fmt.Sprintf("Error parsing PR URL: %v", parseErr)
-			}
-			owner = prOwner
-			args.Repo = prRepo
-			args.Number = prNum
-		}
-		if args.Number == 0 {
-			return "Error: PR number or URL is required."
-		}
-		pr, err := h.ghClient.GetPullRequest(ctx, owner, args.Repo, args.Number)
-		if err != nil {
-			return fmt.Sprintf("Error getting PR: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] fetched PR #%d in %s/%s", userID, channelID, args.Number, owner, args.Repo)
-		return github.FormatPRSummary(pr)
-
-	case "list_pull_requests":
-		var args struct {
-			Repo  string `json:"repo"`
-			State string `json:"state"`
-			Limit int    `json:"limit"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		prs, err := h.ghClient.ListPullRequests(ctx, owner, args.Repo, args.State, args.Limit)
-		if err != nil {
-			return fmt.Sprintf("Error listing PRs: %v", err)
-		}
-		if len(prs) == 0 {
-			return fmt.Sprintf("No pull requests found in %s (state: %s).", args.Repo, args.State)
-		}
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "Pull Requests in %s (%d):\n", args.Repo, len(prs))
-		for _, pr := range prs {
-			fmt.Fprintf(&sb, "  • #%d %s (%s) by %s — %s\n", pr.Number, pr.Title, pr.State, pr.Author, pr.URL)
-		}
-		log.Printf("[user=%s channel=%s] listed %d PRs in %s", userID, channelID, len(prs), args.Repo)
-		return sb.String()
-
-	case "search_code":
-		var args struct {
-			Repo  string `json:"repo"`
-			Query string `json:"query"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, err := h.ghClient.ResolveOwner(ctx)
-		if err != nil {
-			return fmt.Sprintf("Error resolving owner: %v", err)
-		}
-		results, err := h.ghClient.SearchCode(ctx, owner, args.Repo, args.Query)
-		if err != nil {
-			return fmt.Sprintf("Error searching code: %v", err)
-		}
-		if len(results) == 0 {
-			return fmt.Sprintf("No code matches found for '%s' in %s. Try different search terms, broader patterns, or check if the repository name is correct.", args.Query, args.Repo)
-		}
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "Code search results for '%s' in %s (%d matches):\n", args.Query, args.Repo, len(results))
-		for _, r := range results {
-			fmt.Fprintf(&sb, "\n• %s\n  %s\n", r.File, r.URL)
-			for _, frag := range r.Fragments {
-				fmt.Fprintf(&sb, "  ```\n  %s\n  ```\n", frag)
-			}
-		}
-		log.Printf("[user=%s channel=%s] searched code in %s for '%s' (%d matches)", userID, channelID, args.Repo, args.Query, len(results))
-		return sb.String()
-
-	case "get_workflow_run":
-		var args struct {
-			URL string `json:"url"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, repo, runID, err := github.ParseWorkflowRunURL(args.URL)
-		if err != nil {
-			return fmt.Sprintf("Error parsing workflow run URL: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] fetching workflow run %s/%s/%d", userID, channelID, owner, repo, runID)
-		summary, err := h.ghClient.GetWorkflowRunSummary(ctx, owner, repo, runID)
-		if err != nil {
-			return fmt.Sprintf("Error fetching workflow run: %v", err)
-		}
-		result := github.FormatWorkflowRunSummary(summary)
-		log.Printf("[user=%s channel=%s] fetched workflow run %s/%s/%d (conclusion: %s)", userID, channelID, owner, repo, runID, summary.Conclusion)
-		return result
-
-	case "rerun_failed_jobs":
-		var args struct {
-			URL string `json:"url"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, repo, runID, err := github.ParseWorkflowRunURL(args.URL)
-		if err != nil {
-			return fmt.Sprintf("Error parsing workflow run URL: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] rerunning failed jobs for %s/%s/%d", userID, channelID, owner, repo, runID)
-		if err := h.ghClient.RerunFailedJobs(ctx, owner, repo, runID); err != nil {
-			return fmt.Sprintf("Error rerunning failed jobs: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] successfully triggered rerun of failed jobs for %s/%s/%d", userID, channelID, owner, repo, runID)
-		return fmt.Sprintf("Successfully triggered re-run of failed jobs for workflow run %d in %s/%s. The run is now in progress: %s", runID, owner, repo, args.URL)
-
-	case "rerun_workflow":
-		var args struct {
-			URL string `json:"url"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		owner, repo, runID, err := github.ParseWorkflowRunURL(args.URL)
-		if err != nil {
-			return fmt.Sprintf("Error parsing workflow run URL: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] rerunning entire workflow %s/%s/%d", userID, channelID, owner, repo, runID)
-		if err := h.ghClient.RerunWorkflow(ctx, owner, repo, runID); err != nil {
-			return fmt.Sprintf("Error rerunning workflow: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] successfully triggered full rerun of %s/%s/%d", userID, channelID, owner, repo, runID)
-		return fmt.Sprintf("Successfully triggered full re-run of workflow run %d in %s/%s. All jobs will run again: %s", runID, owner, repo, args.URL)
-
-	case "reply_in_thread":
-		var args struct {
-			ThreadTS string `json:"thread_ts"`
-			Text     string `json:"text"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		if err := h.slackClient.PostThreadReply(channelID, args.ThreadTS, args.Text); err != nil {
-			return fmt.Sprintf("Error posting thread reply: %v", err)
-		}
-		log.Printf("[user=%s channel=%s] posted thread reply to ts=%s", userID, channelID, args.ThreadTS)
-		return "Successfully posted reply in thread."
-
-	case "fetch_thread_context":
-		var args struct {
-			URL string `json:"url"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		threadChannelID, threadTS, err := ParseSlackThreadURL(args.URL)
-		if err != nil {
-			return fmt.Sprintf("Error parsing Slack thread URL: %v", err)
-		}
-		msgs, err := h.slackClient.FetchThreadReplies(threadChannelID, threadTS, 100)
-		if err != nil {
-			return fmt.Sprintf("Error fetching thread replies: %v", err)
-		}
-		if len(msgs) == 0 {
-			return fmt.Sprintf("No messages found in thread (channel=%s, thread_ts=%s).", threadChannelID, threadTS)
-		}
-		formatted := formatMessages(msgs)
-		log.Printf("[user=%s channel=%s] fetched thread context from %s (%d messages)", userID, channelID, args.URL, len(msgs))
-		return fmt.Sprintf("Thread context (channel_id=%s, thread_ts=%s):\n\n%s", threadChannelID, threadTS, formatted)
-
-	case "create_jira_ticket":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		var args struct {
-			Project     string   `json:"project"`
-			Summary     string   `json:"summary"`
-			Description string   `json:"description"`
-			IssueType   string   `json:"issue_type"`
-			Labels      []string `json:"labels"`
-			Assignee    string   `json:"assignee"`
-			Team        string   `json:"team"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		// Append agent stamp to the description.
-		stamp := fmt.Sprintf("\n\n---\nCreated by **%s** via Arbetern", h.agentID)
-		if h.appURL != "" {
-			stamp += fmt.Sprintf(" | %s/ui/", strings.TrimRight(h.appURL, "/"))
-		}
-		if h.currentChannelID != "" && h.currentAuditTS != "" {
-			if permalink, err := h.slackClient.GetPermalink(h.currentChannelID, h.currentAuditTS); err == nil && permalink != "" {
-				stamp += fmt.Sprintf(" | [Slack message](%s)", permalink)
-			}
-		}
-		args.Description += stamp
+// Resume continues a checkpointed conversation in its thread.
+func (h *GeneralHandler) Resume(channelID, userID, threadTS string, cp *Checkpoint) {
+	ctx := github.WithAttribution(logging.WithRequestID(context.Background(), h.requestID), github.Attribution{AgentID: h.agentID, UserID: userID, ChannelID: channelID})
+	h.currentChannelID = channelID
+	h.currentAuditTS = threadTS
+	h.currentTask = cp.Task
+	h.dryRun = h.dryRun || cp.DryRun
+	h.activeBranches = cp.Branches
+	if h.activeBranches == nil {
+		h.activeBranches = make(map[string]*activeBranchInfo)
+	}
 
-		// Resolve assignee name to Jira account ID.
-		var assigneeID string
-		if args.Assignee != "" {
-			project := args.Project
-			users, err := h.jiraClient.SearchAssignableUsers(args.Assignee, project)
-			if err != nil {
-				log.Printf("[user=%s channel=%s] Jira user search failed for %q: %v", userID, channelID, args.Assignee, err)
-			} else if len(users) > 0 {
-				best, isGood := jira.BestUserMatch(users, args.Assignee)
-				if isGood {
-					assigneeID = best.AccountID
-					log.Printf("[user=%s channel=%s] resolved assignee %q to user %s (%s)", userID, channelID, args.Assignee, best.DisplayName, assigneeID)
-				} else {
-					log.Printf("[user=%s channel=%s] user search for %q returned %d results but none matched well (top: %s)", userID, channelID, args.Assignee, len(users), users[0].DisplayName)
-				}
-			} else {
-				log.Printf("[user=%s channel=%s] no Jira user found for %q", userID, channelID, args.Assignee)
-			}
-		}
+	client := h.modelsClient
+	if cp.CodeModel && h.codeModelsClient != nil {
+		client = h.codeModelsClient
+	}
+	tools := h.buildTools()
+	baseSystemMsg := ""
+	if len(cp.Messages) > 0 && cp.Messages[0].Role == "system" {
+		baseSystemMsg = cp.Messages[0].Content
+	}
 
-		// Resolve team name independently.
-		var teamFieldID string
-		var teamID string
-		var teamDisplayName string
-		if args.Team != "" {
-			fid, tid, dname, err := h.jiraClient.ResolveTeam(args.Team)
-			if err != nil {
-				log.Printf("[user=%s channel=%s] team resolution failed for %q: %v", userID, channelID, args.Team, err)
-			} else {
-				teamFieldID = fid
-				teamID = tid
-				teamDisplayName = dname
-				log.Printf("[user=%s channel=%s] resolved %q to team %s (field: %s)", userID, channelID, args.Team, teamDisplayName, teamFieldID)
-			}
-		}
+	h.logger().Infof("[user=%s channel=%s thread=%s] resuming checkpointed conversation (%d rounds so far)", userID, channelID, threadTS, cp.Rounds)
+	h.runToolLoop(ctx, &toolLoop{
+		channelID:     channelID,
+		userID:        userID,
+		auditTS:       threadTS,
+		tools:         tools,
+		toolTokens:    estimateToolsTokens(tools),
+		client:        client,
+		baseSystemMsg: baseSystemMsg,
+		priorRounds:   cp.Rounds,
+		messages: append(cp.Messages, github.NewChatMessage("user",
+			"Continue the task from where you left off. Don't repeat steps that already succeeded.")),
+	})
+}
 
-		issue, err := h.jiraClient.CreateIssue(jira.CreateIssueInput{
-			Project:     args.Project,
-			Summary:     args.Summary,
-			Description: args.Description,
-			IssueType:   args.IssueType,
-			Labels:      args.Labels,
-			AssigneeID:  assigneeID,
+// recordChange stores successful write-type tool executions in the change
+// ledger and the activity store. Dry runs and calls staged for approval
+// change nothing and are skipped; an approved call is recorded when it runs.
+func (h *GeneralHandler) recordChange(channelID, userID, name, argsJSON, result, outcome string) {
+	if (h.ledger == nil && h.activity == nil) || outcome != AuditOK || !IsWriteTool(name) || strings.HasPrefix(result, "Error") {
+		return
+	}
+	artifact := artifactFromResult(result, artifactFallback(argsJSON))
+	if h.ledger != nil {
+		h.ledger.Record(ChangeRecord{
+			AgentID:   h.agentID,
+			UserID:    userID,
+			ChannelID: channelID,
+			Tool:      name,
+			ArgsHash:  hashArgs(argsJSON),
+			Artifact:  artifact,
 		})
-		if err != nil {
-			return fmt.Sprintf("Error creating Jira ticket: %v", err)
-		}
-
-		// Set team if resolved (update after creation since team is a custom field).
-		if teamFieldID != "" && teamID != "" {
-			if err := h.jiraClient.SetTeamField(issue.Key, teamFieldID, teamID); err != nil {
-				log.Printf("[user=%s channel=%s] failed to set team %s on %s: %v", userID, channelID, teamDisplayName, issue.Key, err)
-			} else {
-				log.Printf("[user=%s channel=%s] set team %s on %s", userID, channelID, teamDisplayName, issue.Key)
-			}
-		}
-
-		log.Printf("[user=%s channel=%s] created Jira ticket %s: %s", userID, channelID, issue.Key, issue.Browse)
-		return fmt.Sprintf("Jira ticket created: *%s* — %s\nSummary: %s", issue.Key, issue.Browse, args.Summary)
-
-	case "list_jira_projects":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		projects, err := h.jiraClient.ListProjects()
-		if err != nil {
-			return fmt.Sprintf("Error listing Jira projects: %v", err)
-		}
-		if len(projects) == 0 {
-			return "No Jira projects found."
-		}
-		log.Printf("[user=%s channel=%s] listed %d Jira projects", userID, channelID, len(projects))
-		return fmt.Sprintf("Jira projects (%d):\n%s", len(projects), strings.Join(projects, "\n"))
-
-	case "search_jira_issues":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		var args struct {
-			JQL        string `json:"jql"`
-			MaxResults int    `json:"max_results"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		issues, err := h.jiraClient.SearchIssuesJQL(args.JQL, args.MaxResults)
-		if err != nil {
-			return fmt.Sprintf("Error searching Jira issues: %v", err)
-		}
-		if len(issues) == 0 {
-			return fmt.Sprintf("No issues found for JQL: %s", args.JQL)
-		}
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "Found %d issues:\n\n", len(issues))
-		for _, i := range issues {
-			fmt.Fprintf(&sb, "• *%s* — %s\n  Status: %s | Type: %s | Priority: %s\n  Assignee: %s", i.Key, i.Summary, i.Status, i.IssueType, i.Priority, i.Assignee)
-			if i.Team != "" {
-				fmt.Fprintf(&sb, " | Team: %s", i.Team)
-			}
-			if i.Sprint != "" {
-				fmt.Fprintf(&sb, " | Sprint: %s", i.Sprint)
-			}
-			fmt.Fprintf(&sb, " | Updated: %s\n  URL: %s\n", i.Updated, i.Browse)
-			if i.Description != "" {
-				desc := i.Description
-				if len(desc) > 500 {
-					desc = desc[:500] + "... (truncated)"
-				}
-				fmt.Fprintf(&sb, "  Description: %s\n", desc)
-			}
-			sb.WriteString("\n")
-		}
-		log.Printf("[user=%s channel=%s] searched Jira issues with JQL, found %d", userID, channelID, len(issues))
-		return sb.String()
-
-	case "get_jira_issue":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		var args struct {
-			IssueKey string `json:"issue_key"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		issue, err := h.jiraClient.GetIssue(args.IssueKey)
-		if err != nil {
-			return fmt.Sprintf("Error getting Jira issue: %v", err)
-		}
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "*%s* — %s\n", issue.Key, issue.Summary)
-		fmt.Fprintf(&sb, "Status: %s | Type: %s | Priority: %s\n", issue.Status, issue.IssueType, issue.Priority)
-		fmt.Fprintf(&sb, "Assignee: %s | Reporter: %s\n", issue.Assignee, issue.Reporter)
-		if issue.Team != "" {
-			fmt.Fprintf(&sb, "Team: %s\n", issue.Team)
-		}
-		if issue.Sprint != "" {
-			fmt.Fprintf(&sb, "Sprint: %s\n", issue.Sprint)
-		}
-		fmt.Fprintf(&sb, "Updated: %s\n", issue.Updated)
-		if len(issue.Labels) > 0 {
-			fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(issue.Labels, ", "))
-		}
-		fmt.Fprintf(&sb, "URL: %s\n", issue.Browse)
-		if issue.Description != "" {
-			fmt.Fprintf(&sb, "\nDescription:\n%s\n", issue.Description)
-		} else {
-			fmt.Fprintf(&sb, "\nDescription: (empty)\n")
-		}
-		log.Printf("[user=%s channel=%s] fetched Jira issue %s", userID, channelID, args.IssueKey)
-		return sb.String()
-
-	case "update_jira_issue":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		var args struct {
-			IssueKey    string `json:"issue_key"`
-			Summary     string `json:"summary"`
-			Description string `json:"description"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		if args.Summary == "" && args.Description == "" {
-			return "Error: at least one of summary or description must be provided."
-		}
-		// Update summary if provided.
-		if args.Summary != "" {
-			if err := h.jiraClient.UpdateIssueFields(args.IssueKey, map[string]interface{}{"summary": args.Summary}); err != nil {
-				return fmt.Sprintf("Error updating summary: %v", err)
-			}
-		}
-		// Update description if provided (using ADF format).
-		if args.Description != "" {
-			if err := h.jiraClient.UpdateIssueDescription(args.IssueKey, args.Description); err != nil {
-				return fmt.Sprintf("Error updating description: %v", err)
-			}
-		}
-		updated := []string{}
-		if args.Summary != "" {
-			updated = append(updated, "summary")
-		}
-		if args.Description != "" {
-			updated = append(updated, "description")
-		}
-		log.Printf("[user=%s channel=%s] updated Jira issue %s (%s)", userID, channelID, args.IssueKey, strings.Join(updated, ", "))
-		return fmt.Sprintf("Successfully updated %s: %s", args.IssueKey, strings.Join(updated, " and "))
-
-	case "get_slack_user_info":
-		var args struct {
-			UserID string `json:"user_id"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		user, err := h.slackClient.GetUserInfo(args.UserID)
-		if err != nil {
-			return fmt.Sprintf("Error getting user info: %v", err)
-		}
-		return fmt.Sprintf("Slack User Info:\n  User ID: %s\n  Real Name: %s\n  Display Name: %s\n  Email: %s\n  Title: %s",
-			user.ID, user.RealName, user.Profile.DisplayName, user.Profile.Email, user.Profile.Title)
-
-	case "resolve_jira_team":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		var args struct {
-			TeamName string `json:"team_name"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		// First discover the JQL clause name for the Team field.
-		fields, err := h.jiraClient.FindTeamFields()
-		if err != nil {
-			return fmt.Sprintf("Error discovering Team field: %v", err)
-		}
-		jqlClause := fields[0].JQLName
-		// Then resolve the team name to its UUID.
-		_, teamID, displayName, err := h.jiraClient.ResolveTeam(args.TeamName)
-		if err != nil {
-			return fmt.Sprintf("Error resolving team %q: %v. Try a different team name spelling.", args.TeamName, err)
-		}
-		log.Printf("[user=%s channel=%s] resolved Jira team %q → %s (clause: %s)", userID, channelID, args.TeamName, teamID, jqlClause)
-		return fmt.Sprintf("Team resolved:\n  Display Name: %s\n  Team UUID: %s\n  JQL Clause: %s\n\nUse in JQL: \"%s\" = \"%s\"\nExample: \"%s\" = \"%s\" AND status = \"In Progress\" ORDER BY priority DESC", displayName, teamID, jqlClause, jqlClause, teamID, jqlClause, teamID)
-
-	case "resolve_jira_user":
-		if h.jiraClient == nil {
-			return "Error: Jira integration is not configured."
-		}
-		var args struct {
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-
-		// Multi-strategy search: email first (most reliable), then full name, then individual name parts.
-		type attempt struct {
-			label string
-			query string
-		}
-		var attempts []attempt
-		if args.Email != "" {
-			attempts = append(attempts, attempt{"email", args.Email})
-		}
-		if args.Name != "" {
-			attempts = append(attempts, attempt{"full name", args.Name})
-			// Also try individual name parts (first name, last name) since Jira's
-			// /user/search often matches prefixes, and "Mike Joseph" as a single
-			// query may fail while "Mike" succeeds.
-			parts := strings.Fields(args.Name)
-			if len(parts) > 1 {
-				for _, p := range parts {
-					attempts = append(attempts, attempt{"name part", p})
-				}
-			}
-		}
-
-		var users []jira.JiraUser
-		var matchLabel string
-		for _, a := range attempts {
-			result, err := h.jiraClient.SearchUsersGeneral(a.query)
-			if err != nil {
-				log.Printf("[user=%s channel=%s] Jira user search by %s (%q) failed: %v", userID, channelID, a.label, a.query, err)
-				continue
-			}
-			if len(result) > 0 {
-				users = result
-				matchLabel = a.label
-				log.Printf("[user=%s channel=%s] Jira user search by %s (%q) returned %d result(s)", userID, channelID, a.label, a.query, len(result))
-				break
-			}
-			log.Printf("[user=%s channel=%s] Jira user search by %s (%q) returned 0 results, trying next strategy", userID, channelID, a.label, a.query)
-		}
-
-		if len(users) == 0 {
-			// Final fallback: reverse-lookup via project issues. This works even when
-			// the service account lacks "Browse users and groups" global permission,
-			// because the issue search endpoint returns assignee accountIds.
-			log.Printf("[user=%s channel=%s] all /user/search strategies failed, trying issue-based reverse lookup for %q", userID, channelID, args.Name)
-			issueUsers, err := h.jiraClient.ResolveUserViaIssues(args.Name)
-			if err != nil {
-				log.Printf("[user=%s channel=%s] issue-based user lookup failed: %v", userID, channelID, err)
-			} else if len(issueUsers) > 0 {
-				users = issueUsers
-				matchLabel = "issue assignee reverse lookup"
-				log.Printf("[user=%s channel=%s] issue-based reverse lookup found %d match(es) for %q", userID, channelID, len(users), args.Name)
-			}
-		}
-
-		if len(users) == 0 {
-			return fmt.Sprintf("No Jira users found matching name=%q email=%q after trying all search strategies (user search + issue reverse lookup). Verify the user exists in Jira and has issues assigned in project.", args.Name, args.Email)
-		}
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "Found %d Jira user(s) (matched by %s):\n", len(users), matchLabel)
-		for i, u := range users {
-			if i >= 5 {
-				fmt.Fprintf(&sb, "  ... and %d more\n", len(users)-5)
-				break
-			}
-			fmt.Fprintf(&sb, "  • %s (accountId: %s, active: %v)\n", u.DisplayName, u.AccountID, u.Active)
-		}
-		fmt.Fprintf(&sb, "\nUse the accountId in JQL queries like: assignee = \"%s\"\n", users[0].AccountID)
-		log.Printf("[user=%s channel=%s] resolved Jira user %q -> %s (%s) via %s", userID, channelID, args.Name, users[0].DisplayName, users[0].AccountID, matchLabel)
-		return sb.String()
-
-	case "lookup_cve":
-		if h.nvdClient == nil {
-			return "Error: NVD integration is not configured."
-		}
-		var args struct {
-			CVEID string `json:"cve_id"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		args.CVEID = strings.TrimSpace(strings.ToUpper(args.CVEID))
-		if args.CVEID == "" {
-			return "Error: cve_id is required."
-		}
-		cve, err := h.nvdClient.LookupCVE(ctx, args.CVEID)
-		if err != nil {
-			return fmt.Sprintf("Error looking up %s: %v", args.CVEID, err)
-		}
-		log.Printf("[user=%s channel=%s] looked up CVE %s from NVD", userID, channelID, args.CVEID)
-		return nvd.FormatCVE(cve)
-
-	case "search_cve":
-		if h.nvdClient == nil {
-			return "Error: NVD integration is not configured."
-		}
-		var args struct {
-			Keyword        string `json:"keyword"`
-			ResultsPerPage int    `json:"results_per_page"`
-		}
-		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return fmt.Sprintf("Error parsing arguments: %v", err)
-		}
-		if args.Keyword == "" {
-			return "Error: keyword is required."
-		}
-		items, total, err := h.nvdClient.SearchCVE(ctx, args.Keyword, args.ResultsPerPage)
-		if err != nil {
-			return fmt.Sprintf("Error searching NVD: %v", err)
-		}
-		if len(items) == 0 {
-			return fmt.Sprintf("No CVEs found matching '%s'.", args.Keyword)
-		}
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "Found %d CVEs matching '%s' (showing %d):\n\n", total, args.Keyword, len(items))
-		for _, item := range items {
-			sb.WriteString(nvd.FormatCVE(&item))
-			sb.WriteString("\n---\n")
-		}
-		log.Printf("[user=%s channel=%s] searched NVD for '%s' (%d results)", userID, channelID, args.Keyword, total)
-		return sb.String()
+	}
+	if h.activity != nil {
+		h.activity.Record(ActivityEvent{
+			Kind:      ActivityChange,
+			AgentID:   h.agentID,
+			ChannelID: channelID,
+			UserID:    userID,
+			Tool:      name,
+			Artifact:  artifact,
+		})
+	}
+}
 
-	default:
-		return fmt.Sprintf("Unknown tool: %s", name)
+// tokenBudget returns how many estimated tokens the prompt may use: the
+// configured budget less estimateHeadroomPercent for estimation error.
+func (h *GeneralHandler) tokenBudget() int {
+	budget := defaultContextTokenBudget
+	if h.contextBudget > 0 {
+		budget = h.contextBudget
 	}
+	return budget - budget*estimateHeadroomPercent/100
+}
+
+func (h *GeneralHandler) systemPrompt() string {
+	return h.prompts.MustGet("security") + "\n\n" + h.prompts.MustGet("general")
 }
 
 func (h *GeneralHandler) fetchWorkflowLogs(ctx context.Context, text, userID, channelID string) string {
 	urls := github.ExtractWorkflowRunURLs(text)
-	if len(urls) == 0 {
+	if len(urls) == 0 || h.ghClient == nil {
 		return ""
 	}
 
@@ -1392,10 +512,10 @@ func (h *GeneralHandler) fetchWorkflowLogs(ctx context.Context, text, userID, ch
 			continue
 		}
 
-		log.Printf("[user=%s channel=%s] auto-fetching workflow run %s/%s/%d", userID, channelID, owner, repo, runID)
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] auto-fetching workflow run %s/%s/%d", userID, channelID, owner, repo, runID)
 		summary, err := h.ghClient.GetWorkflowRunSummary(ctx, owner, repo, runID)
 		if err != nil {
-			log.Printf("[user=%s channel=%s] failed to fetch workflow run summary: %v", userID, channelID, err)
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] failed to fetch workflow run summary: %v", userID, channelID, err)
 			continue
 		}
 
@@ -1406,13 +526,14 @@ func (h *GeneralHandler) fetchWorkflowLogs(ctx context.Context, text, userID, ch
 
 func (h *GeneralHandler) replyDefault(channelID, responseURL, auditTS, text string) {
 	if auditTS != "" {
+		h.sessions.Record(channelID, auditTS, TranscriptEntry{Kind: TranscriptBot, Text: redactText(h.redactor, h.agentID, "transcript", text)})
 		if err := h.slackClient.PostThreadReply(channelID, auditTS, text); err != nil {
-			log.Printf("[channel=%s] failed to post thread reply: %v", channelID, err)
+			h.logger().Errorf("[channel=%s] failed to post thread reply: %v", channelID, err)
 		}
 		return
 	}
-	if err := ovadslack.RespondToURL(responseURL, text, false); err != nil {
-		log.Printf("[channel=%s] failed to respond: %v", channelID, err)
+	if err := ovadslack.RespondToURL(responseURL, redactText(h.redactor, h.agentID, "output", text), false); err != nil {
+		h.logger().Errorf("[channel=%s] failed to respond: %v", channelID, err)
 	}
 }
 
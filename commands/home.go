@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/justmike1/ovad/prompts"
+	slacklib "github.com/slack-go/slack"
+)
+
+// AppHome renders the Slack App Home tab: the available agents and their
+// slash commands, the user's recent requests, their active thread sessions,
+// and their LLM usage for the current month.
+type AppHome struct {
+	Agents   []prompts.AgentConfig
+	Requests *RequestLog
+	Sessions *SessionStore
+	Usage    *UsageTracker // optional
+}
+
+// Blocks builds the Home tab blocks for userID.
+func (h *AppHome) Blocks(userID string) []slacklib.Block {
+	blocks := []slacklib.Block{
+		slacklib.NewHeaderBlock(plainText("ovad")),
+		section("*Agents*"),
+	}
+	for _, a := range h.Agents {
+		name := a.Name
+		if name == "" {
+			name = a.ID
+		}
+		blocks = append(blocks, section(fmt.Sprintf("`/%s` — %s", a.ID, name)))
+	}
+
+	blocks = append(blocks, slacklib.NewDividerBlock(), section("*Your recent requests*"))
+	recent := h.Requests.Recent(userID)
+	if len(recent) == 0 {
+		blocks = append(blocks, contextText("No requests yet. Try `/<agent> help` in any channel."))
+	}
+	for _, r := range recent {
+		blocks = append(blocks, section(fmt.Sprintf("`/%s` in <#%s> — %s\n> %s",
+			r.AgentID, r.ChannelID, r.At.UTC().Format("Jan 2 15:04 MST"), homeSnippet(r.Text))))
+	}
+
+	blocks = append(blocks, slacklib.NewDividerBlock(), section("*Active thread sessions*"))
+	sessions := h.Sessions.ForUser(userID)
+	if len(sessions) == 0 {
+		blocks = append(blocks, contextText("No active sessions."))
+	}
+	for _, s := range sessions {
+		blocks = append(blocks, section(fmt.Sprintf("`/%s` in <#%s> — started %s ago",
+			s.AgentID, s.ChannelID, time.Since(s.CreatedAt).Round(time.Second))))
+	}
+
+	if h.Usage != nil {
+		month := time.Now().UTC().Format("2006-01")
+		t, ok := h.Usage.Report(month).ByUser[userID]
+		if ok {
+			blocks = append(blocks, slacklib.NewDividerBlock(), contextText(fmt.Sprintf(
+				"LLM usage this month: %d requests, %d tokens, ~$%.2f",
+				t.Requests, t.PromptTokens+t.CompletionTokens, t.CostUSD)))
+		}
+	}
+	return blocks
+}
+
+func plainText(s string) *slacklib.TextBlockObject {
+	return slacklib.NewTextBlockObject(slacklib.PlainTextType, s, false, false)
+}
+
+func section(md string) *slacklib.SectionBlock {
+	return slacklib.NewSectionBlock(slacklib.NewTextBlockObject(slacklib.MarkdownType, md, false, false), nil, nil)
+}
+
+func contextText(md string) *slacklib.ContextBlock {
+	return slacklib.NewContextBlock("", slacklib.NewTextBlockObject(slacklib.MarkdownType, md, false, false))
+}
+
+// homeSnippet shortens request text so a long prompt doesn't dominate the tab.
+func homeSnippet(s string) string {
+	const max = 200
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "…"
+}
@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/fsutil"
+	"github.com/justmike1/ovad/logging"
+)
+
+// Identity links a Slack user to their GitHub login and Jira account.
+type Identity struct {
+	SlackUserID   string    `json:"slack_user_id"`
+	GitHubLogin   string    `json:"github_login,omitempty"`
+	JiraAccountID string    `json:"jira_account_id,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	UpdatedBy     string    `json:"updated_by,omitempty"` // Slack user ID, or "api"
+}
+
+// IdentityStore maps Slack users to their GitHub and Jira accounts. When
+// created with a path, the mapping is saved to that JSON file on every
+// change and loaded on startup. Safe for concurrent use.
+type IdentityStore struct {
+	mu     sync.RWMutex
+	path   string
+	byUser map[string]Identity
+}
+
+// NewIdentityStore creates a store, loading the mapping from path when it
+// is non-empty and the file exists.
+func NewIdentityStore(path string) (*IdentityStore, error) {
+	s := &IdentityStore{path: path, byUser: make(map[string]Identity)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity store: %w", err)
+	}
+	var ids []Identity
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to parse identity store %s: %w", path, err)
+	}
+	for _, id := range ids {
+		if id.SlackUserID != "" {
+			s.byUser[id.SlackUserID] = id
+		}
+	}
+	return s, nil
+}
+
+// Get returns the identity linked to a Slack user.
+func (s *IdentityStore) Get(slackUserID string) (Identity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.byUser[slackUserID]
+	return id, ok
+}
+
+// List returns all identities, ordered by Slack user ID.
+func (s *IdentityStore) List() []Identity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked()
+}
+
+// Link merges the non-empty fields of id into the stored identity of
+// id.SlackUserID and saves the store.
+func (s *IdentityStore) Link(id Identity) (Identity, error) {
+	if id.SlackUserID == "" {
+		return Identity{}, fmt.Errorf("slack_user_id is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.byUser[id.SlackUserID]
+	cur.SlackUserID = id.SlackUserID
+	if id.GitHubLogin != "" {
+		cur.GitHubLogin = id.GitHubLogin
+	}
+	if id.JiraAccountID != "" {
+		cur.JiraAccountID = id.JiraAccountID
+	}
+	cur.UpdatedAt = time.Now().UTC()
+	cur.UpdatedBy = id.UpdatedBy
+	s.byUser[id.SlackUserID] = cur
+	return cur, s.saveLocked()
+}
+
+// Unlink removes a Slack user's identity and saves the store.
+func (s *IdentityStore) Unlink(slackUserID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byUser[slackUserID]; !ok {
+		return false, nil
+	}
+	delete(s.byUser, slackUserID)
+	return true, s.saveLocked()
+}
+
+// ByGitHubLogin returns the identity linked to a GitHub login.
+func (s *IdentityStore) ByGitHubLogin(login string) (Identity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, id := range s.byUser {
+		if strings.EqualFold(id.GitHubLogin, login) {
+			return id, true
+		}
+	}
+	return Identity{}, false
+}
+
+func (s *IdentityStore) sortedLocked() []Identity {
+	out := make([]Identity, 0, len(s.byUser))
+	for _, id := range s.byUser {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SlackUserID < out[j].SlackUserID })
+	return out
+}
+
+// saveLocked writes the store to a temporary file and renames it over path,
+// so a crash never leaves a truncated mapping. Callers hold s.mu.
+func (s *IdentityStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.sortedLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode identity store: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save identity store: %w", err)
+	}
+	return nil
+}
+
+// identityTools let users link their own Slack account to GitHub and Jira.
+var identityTools = []*ToolDef{
+	{
+		Name:        "link_github_account",
+		Description: "Link the requesting Slack user to their GitHub login and/or Jira account, e.g. 'link my github account to octocat'. Linked accounts are used for reviews, assignments and mentions instead of name/email matching. Users can only link themselves; admins manage other users through the API.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"github_login":{"type":"string","description":"The requester's GitHub login"},
+				"jira_account_id":{"type":"string","description":"The requester's Jira account ID (from resolve_jira_user), optional"}
+			}
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).identitiesEnabled,
+		Run:       (*GeneralHandler).toolLinkGitHubAccount,
+	},
+}
+
+func (h *GeneralHandler) identitiesEnabled() bool {
+	return h.identities != nil
+}
+
+// linkedIdentity returns the stored identity of a Slack user, if any.
+func (h *GeneralHandler) linkedIdentity(slackUserID string) (Identity, bool) {
+	if h.identities == nil || slackUserID == "" {
+		return Identity{}, false
+	}
+	return h.identities.Get(strings.Trim(slackUserID, "<@>"))
+}
+
+func (h *GeneralHandler) toolLinkGitHubAccount(ctx context.Context, call ToolCall) string {
+	var args struct {
+		GitHubLogin   string `json:"github_login"`
+		JiraAccountID string `json:"jira_account_id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	args.GitHubLogin = strings.TrimPrefix(strings.TrimSpace(args.GitHubLogin), "@")
+	if args.GitHubLogin == "" && args.JiraAccountID == "" {
+		return "Error: pass github_login and/or jira_account_id."
+	}
+	if call.UserID == "" || call.UserID == ScheduledUserID {
+		return "Error: accounts can only be linked by a Slack user for themselves."
+	}
+	if args.GitHubLogin != "" {
+		if h.ghClient == nil {
+			return "Error: GitHub is not configured, so github_login cannot be linked."
+		}
+		user, err := h.ghClient.GetUser(ctx, args.GitHubLogin)
+		if err != nil {
+			return fmt.Sprintf("Error: GitHub user %q not found: %v", args.GitHubLogin, err)
+		}
+		args.GitHubLogin = user.Login
+	}
+	id, err := h.identities.Link(Identity{SlackUserID: call.UserID, GitHubLogin: args.GitHubLogin, JiraAccountID: args.JiraAccountID, UpdatedBy: call.UserID})
+	if err != nil {
+		return fmt.Sprintf("Error saving linked account: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] linked accounts github=%q jira=%q", call.UserID, call.ChannelID, id.GitHubLogin, id.JiraAccountID)
+	var parts []string
+	if id.GitHubLogin != "" {
+		parts = append(parts, "GitHub "+id.GitHubLogin)
+	}
+	if id.JiraAccountID != "" {
+		parts = append(parts, "Jira account "+id.JiraAccountID)
+	}
+	return fmt.Sprintf("Linked <@%s> to %s.", call.UserID, strings.Join(parts, " and "))
+}
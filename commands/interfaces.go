@@ -2,11 +2,15 @@ package commands
 
 import slacklib "github.com/slack-go/slack"
 
-type SlackClient interface {
+// ChatPlatform is the chat frontend an agent talks through. Slack is the
+// native one; other platforms (Microsoft Teams) adapt their messages and
+// users to the Slack shapes so the handlers stay platform-agnostic.
+type ChatPlatform interface {
 	FetchChannelHistory(channelID string, limit int) ([]slacklib.Message, error)
 	FetchThreadReplies(channelID, threadTS string, limit int) ([]slacklib.Message, error)
 	PostMessage(channelID, text string) (string, error)
 	PostThreadReply(channelID, threadTS, text string) error
+	UploadFile(channelID, threadTS, filename, title string, content []byte) error
 	GetPermalink(channelID, messageTS string) (string, error)
 	GetUserInfo(userID string) (*slacklib.User, error)
 }
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/jira"
+	"github.com/justmike1/ovad/logging"
+)
+
+// jqlAttempts is how many times build_jql asks the LLM for a query; every
+// attempt after the first includes the previous validation error.
+const jqlAttempts = 3
+
+const jqlSystemPrompt = `You convert a natural-language issue filter into a single Jira Cloud JQL query.
+Rules:
+- Use ONLY the statuses, issue types, priorities, components and fields listed in the metadata. Quote values that contain spaces.
+- People MUST be referenced by the account IDs given (e.g. assignee = "712020:abc"), never by display name.
+- Teams MUST be referenced by the clause and UUID given (e.g. "Team[Team]" = "uuid").
+- Restrict to the given project unless the filter clearly asks for others.
+- Use relative dates (e.g. updated >= -7d, startOfWeek()) for time ranges.
+- Add an ORDER BY only when the filter implies an order.
+Return ONLY the JQL, on one line, with no explanation and no code fences.`
+
+func (h *GeneralHandler) toolBuildJQL(ctx context.Context, call ToolCall) string {
+	if h.jiraClient == nil {
+		return "Error: Jira integration is not configured."
+	}
+	var args struct {
+		Filter  string   `json:"filter"`
+		Project string   `json:"project"`
+		People  []string `json:"people"`
+		Team    string   `json:"team"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Filter) == "" {
+		return "Error: filter is required."
+	}
+
+	meta, err := h.jiraClient.GetProjectMetadata(args.Project)
+	if err != nil {
+		return fmt.Sprintf("Error fetching Jira project metadata: %v", err)
+	}
+
+	var ctxb strings.Builder
+	fmt.Fprintf(&ctxb, "Filter: %s\n\nProject: %s\n", args.Filter, meta.Project)
+	fmt.Fprintf(&ctxb, "Issue types: %s\n", quoteList(meta.IssueTypes))
+	fmt.Fprintf(&ctxb, "Statuses: %s\n", quoteList(meta.Statuses))
+	fmt.Fprintf(&ctxb, "Priorities: %s\n", quoteList(meta.Priorities))
+	if len(meta.Components) > 0 {
+		fmt.Fprintf(&ctxb, "Components: %s\n", quoteList(meta.Components))
+	}
+	if fields, err := h.jiraClient.FieldClauses(); err != nil {
+		logging.Ctx(ctx).Errorf("[user=%s channel=%s] build_jql: field discovery failed: %v", call.UserID, call.ChannelID, err)
+	} else if custom := mentionedFields(fields, args.Filter); len(custom) > 0 {
+		ctxb.WriteString("Custom fields mentioned in the filter (name → JQL clause):\n")
+		for _, f := range custom {
+			fmt.Fprintf(&ctxb, "- %s → %s\n", f.Name, f.Clause)
+		}
+	}
+
+	var notes []string
+	for _, person := range args.People {
+		users, err := h.jiraClient.SearchUsersGeneral(person)
+		if err != nil || len(users) == 0 {
+			notes = append(notes, fmt.Sprintf("could not resolve %q to a Jira user", person))
+			continue
+		}
+		best, good := jira.BestUserMatch(users, person)
+		if !good {
+			notes = append(notes, fmt.Sprintf("%q matched %s only loosely", person, best.DisplayName))
+		}
+		fmt.Fprintf(&ctxb, "Person %q → %s, account ID %s\n", person, best.DisplayName, best.AccountID)
+	}
+	if args.Team != "" {
+		fields, ferr := h.jiraClient.FindTeamFields()
+		_, teamID, displayName, err := h.jiraClient.ResolveTeam(args.Team)
+		if ferr != nil || err != nil {
+			notes = append(notes, fmt.Sprintf("could not resolve team %q", args.Team))
+		} else {
+			fmt.Fprintf(&ctxb, "Team %q → %s, clause %q, UUID %s\n", args.Team, displayName, fields[0].JQLName, teamID)
+		}
+	}
+
+	prompt := ctxb.String()
+	var jql string
+	var lastErr error
+	for attempt := 1; attempt <= jqlAttempts; attempt++ {
+		out, err := h.modelsClient.Complete(ctx, jqlSystemPrompt, prompt)
+		if err != nil {
+			return fmt.Sprintf("Error generating JQL: %v", err)
+		}
+		jql = cleanJQL(out)
+		issues, err := h.jiraClient.SearchIssuesJQL(jql, 5)
+		if err == nil {
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] build_jql: %q → %s (attempt %d)", call.UserID, call.ChannelID, args.Filter, jql, attempt)
+			return formatBuiltJQL(jql, issues, notes)
+		}
+		lastErr = err
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] build_jql: attempt %d rejected by Jira: %v", call.UserID, call.ChannelID, attempt, err)
+		prompt = fmt.Sprintf("%s\nYour previous query was rejected by Jira.\nQuery: %s\nError: %v\nFix the query.", ctxb.String(), jql, err)
+	}
+	return fmt.Sprintf("Error: could not build valid JQL after %d attempts. Last query: %s\nJira error: %v", jqlAttempts, jql, lastErr)
+}
+
+// formatBuiltJQL reports a validated query with a sample of its matches.
+func formatBuiltJQL(jql string, sample []jira.IssueSummary, notes []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Validated JQL (dry-run succeeded):\n%s\n", jql)
+	if len(sample) == 0 {
+		sb.WriteString("\nThe query currently matches no issues.\n")
+	} else {
+		sb.WriteString("\nSample matches:\n")
+		for _, i := range sample {
+			fmt.Fprintf(&sb, "- %s [%s] %s\n", i.Key, i.Status, i.Summary)
+		}
+	}
+	for _, n := range notes {
+		fmt.Fprintf(&sb, "\nNote: %s.", n)
+	}
+	sb.WriteString("\nPass this JQL to search_jira_issues unchanged.")
+	return sb.String()
+}
+
+// mentionedFields returns the custom fields whose names appear in filter.
+func mentionedFields(fields []jira.FieldClause, filter string) []jira.FieldClause {
+	lower := strings.ToLower(filter)
+	var out []jira.FieldClause
+	for _, f := range fields {
+		if f.Custom && len(f.Name) > 2 && strings.Contains(lower, strings.ToLower(f.Name)) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// cleanJQL strips code fences, a "JQL:" prefix and line breaks from LLM output.
+func cleanJQL(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```jql")
+	s = strings.TrimPrefix(s, "```sql")
+	s = strings.Trim(s, "`")
+	s = strings.TrimSpace(s)
+	if len(s) > 4 && strings.EqualFold(s[:4], "jql:") {
+		s = s[4:]
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/justmike1/ovad/fsutil"
+	"github.com/justmike1/ovad/logging"
+)
+
+// jsonlFile is an append-only JSONL file of T records, as kept by the audit
+// log and the change ledger. name is used in errors ("audit log"), tag in
+// log lines ("audit").
+type jsonlFile[T any] struct {
+	path string
+	name string
+	tag  string
+}
+
+// load returns the records in the file for which keep reports true. When
+// any line is dropped, either unreadable or not kept, the file is compacted
+// to the kept records. A missing file loads as empty.
+func (f jsonlFile[T]) load(keep func(T) bool) ([]T, error) {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.name, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var kept []T
+	dropped := 0
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var rec T
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || !keep(rec) {
+			dropped++
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.name, err)
+	}
+	if dropped > 0 {
+		if err := f.rewrite(kept); err != nil {
+			return nil, err
+		}
+		logging.Infof("[%s] dropped %d entries older than the retention period from %s", f.tag, dropped, f.path)
+	}
+	return kept, nil
+}
+
+// rewrite atomically replaces the file with records.
+func (f jsonlFile[T]) rewrite(records []T) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("failed to compact %s: %w", f.name, err)
+		}
+	}
+	if err := fsutil.WriteFileAtomic(f.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to compact %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// append writes rec as one line. Failures are logged, not returned.
+func (f jsonlFile[T]) append(rec T) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		logging.Errorf("[%s] failed to open %s: %v", f.tag, f.path, err)
+		return
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logging.Errorf("[%s] failed to append to %s: %v", f.tag, f.path, err)
+	}
+}
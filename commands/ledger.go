@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+const (
+	// ledgerRetentionDays is how far back the ledger answers; older changes
+	// are dropped from memory and, on startup, from the file.
+	ledgerRetentionDays = 90
+	// maxLedgerEntries is a safety cap on the changes held in memory.
+	// Oldest entries are dropped first.
+	maxLedgerEntries = 200000
+)
+
+// ChangeRecord is a single write-type tool execution performed by the bot.
+type ChangeRecord struct {
+	Time      time.Time `json:"time"`
+	AgentID   string    `json:"agent_id"`
+	UserID    string    `json:"user_id"`
+	ChannelID string    `json:"channel_id"`
+	Tool      string    `json:"tool"`
+	ArgsHash  string    `json:"args_hash"`
+	Artifact  string    `json:"artifact,omitempty"` // URL or identifier of what was changed
+}
+
+// ChangeLedger records every write-type tool execution so users can review
+// what the bot has changed on their behalf over the last
+// ledgerRetentionDays. When created with a path, changes are appended to
+// that JSONL file and reloaded on startup. Safe for concurrent use.
+type ChangeLedger struct {
+	mu      sync.RWMutex
+	file    jsonlFile[ChangeRecord]
+	records []ChangeRecord
+}
+
+// NewChangeLedger creates a ledger, loading the changes of the last
+// ledgerRetentionDays from path when it is non-empty and the file exists.
+func NewChangeLedger(path string) (*ChangeLedger, error) {
+	l := &ChangeLedger{file: jsonlFile[ChangeRecord]{path: path, name: "change ledger", tag: "ledger"}}
+	if path == "" {
+		return l, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -ledgerRetentionDays)
+	kept, err := l.file.load(func(rec ChangeRecord) bool { return !rec.Time.Before(cutoff) })
+	if err != nil {
+		return nil, err
+	}
+	if len(kept) > maxLedgerEntries {
+		kept = kept[len(kept)-maxLedgerEntries:]
+	}
+	l.records = kept
+	return l, nil
+}
+
+// Len returns the number of changes held in memory.
+func (l *ChangeLedger) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.records)
+}
+
+// Record appends a change to the ledger. Write failures are logged, not
+// returned.
+func (l *ChangeLedger) Record(rec ChangeRecord) {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, rec)
+	cutoff := time.Now().AddDate(0, 0, -ledgerRetentionDays)
+	n := 0
+	for n < len(l.records) && l.records[n].Time.Before(cutoff) {
+		n++
+	}
+	l.records = l.records[max(n, len(l.records)-maxLedgerEntries):]
+
+	if l.file.path != "" {
+		l.file.append(rec)
+	}
+}
+
+// List returns changes newer than since, optionally filtered by user ID
+// (empty userID returns all users). Results are ordered newest first.
+func (l *ChangeLedger) List(userID string, since time.Time) []ChangeRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	out := []ChangeRecord{}
+	for _, r := range l.records {
+		if r.Time.Before(since) {
+			continue
+		}
+		if userID != "" && r.UserID != userID {
+			continue
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	return out
+}
+
+// hashArgs returns a short, stable fingerprint of a tool's JSON arguments.
+// The raw arguments are not stored — they may contain file contents.
+func hashArgs(argsJSON string) string {
+	sum := sha256.Sum256([]byte(argsJSON))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+var artifactURLRe = regexp.MustCompile(`https?://[^\s>|)]+`)
+
+// artifactFromResult extracts the URL of the changed artifact from a tool result
+// (e.g. the PR URL from modify_file or the ticket URL from create_jira_ticket).
+// Falls back to the given reference when the result contains no URL.
+func artifactFromResult(result, fallback string) string {
+	if u := artifactURLRe.FindString(result); u != "" {
+		return strings.TrimRight(u, ".,*")
+	}
+	return fallback
+}
+
+// artifactFallback derives a human-readable reference from a tool's arguments
+// for write tools whose result carries no URL (e.g. update_jira_issue).
+func artifactFallback(argsJSON string) string {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+		return ""
+	}
+	for _, key := range []string{"url", "issue_key", "thread_ts", "repo"} {
+		if v, ok := args[key].(string); ok && v != "" {
+			return key + "=" + v
+		}
+	}
+	return ""
+}
+
+// FormatChanges renders ledger records as a Slack-friendly list.
+func FormatChanges(records []ChangeRecord, days int) string {
+	if len(records) == 0 {
+		return fmt.Sprintf("No changes recorded in the last %d day(s).", days)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Changes made on your behalf in the last %d day(s) (%d):\n", days, len(records))
+	for _, r := range records {
+		fmt.Fprintf(&sb, "  • %s — %s (agent: %s, channel: <#%s>, args: %s)",
+			r.Time.Format("2006-01-02 15:04"), r.Tool, r.AgentID, r.ChannelID, r.ArgsHash)
+		if r.Artifact != "" {
+			fmt.Fprintf(&sb, " → %s", r.Artifact)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ledgerTools expose the change ledger to the user.
+var ledgerTools = []*ToolDef{
+	{
+		Name:        "list_my_changes",
+		Description: "List every change the bot has made on behalf of the current user (pull requests, workflow reruns, Jira tickets and updates, thread replies) within the last N days. Use this when the user asks what you did for them, what you changed, or wants an audit of recent actions.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"days":{"type":"integer","description":"How many days back to look (default: 7, max: 90; more is treated as 90)"}
+			},
+			"required":[]
+		}`),
+		Available: (*GeneralHandler).ledgerEnabled,
+		Run:       (*GeneralHandler).toolListMyChanges,
+	},
+}
+
+func (h *GeneralHandler) toolListMyChanges(ctx context.Context, call ToolCall) string {
+	if h.ledger == nil {
+		return "Error: change ledger is not enabled."
+	}
+	var args struct {
+		Days int `json:"days"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Days <= 0 {
+		args.Days = 7
+	}
+	args.Days = min(args.Days, ledgerRetentionDays)
+	records := h.ledger.List(call.UserID, time.Now().AddDate(0, 0, -args.Days))
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d ledger changes (last %d days)", call.UserID, call.ChannelID, len(records), args.Days)
+	return FormatChanges(records, args.Days)
+}
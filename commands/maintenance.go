@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// maxQueuedRequests bounds the requests kept for replay during maintenance.
+const maxQueuedRequests = 200
+
+// Maintenance puts every agent into maintenance mode, e.g. during planned
+// Jira or GitHub downtime. New requests get a deferral message instead of
+// failing halfway, and, when queueing is on, are replayed in arrival order
+// once maintenance ends. Safe for concurrent use.
+type Maintenance struct {
+	mu      sync.Mutex
+	enabled bool
+	message string
+	queue   bool
+	since   time.Time
+	pending []QueuedRequest
+}
+
+// QueuedRequest is a request deferred by maintenance mode.
+type QueuedRequest struct {
+	AgentID   string    `json:"agent"`
+	Kind      string    `json:"kind"` // command, thread_reply, scheduled, triggered, github_event, jira_event
+	ChannelID string    `json:"channel_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+	replay    func()
+}
+
+// MaintenanceStatus is the state of maintenance mode as served by the API.
+type MaintenanceStatus struct {
+	Enabled bool            `json:"enabled"`
+	Message string          `json:"message,omitempty"`
+	Queue   bool            `json:"queue"`
+	Since   *time.Time      `json:"since,omitempty"`
+	Queued  []QueuedRequest `json:"queued"`
+}
+
+// NewMaintenance creates a switch with maintenance mode off.
+func NewMaintenance() *Maintenance {
+	return &Maintenance{}
+}
+
+// Start turns maintenance mode on. message (optional) tells users why and
+// for how long; with queue, deferred requests are replayed when it ends.
+// Calling Start again updates the message and queueing.
+func (m *Maintenance) Start(message string, queue bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.enabled {
+		m.since = time.Now()
+	}
+	m.enabled, m.message, m.queue = true, message, queue
+}
+
+// End turns maintenance mode off and replays the queued requests one after
+// another in the background. Returns how many will be replayed.
+func (m *Maintenance) End() int {
+	m.mu.Lock()
+	pending := m.pending
+	m.enabled, m.message, m.queue, m.pending = false, "", false, nil
+	m.mu.Unlock()
+
+	if len(pending) > 0 {
+		go func() {
+			for _, req := range pending {
+				logging.Infof("[maintenance] replaying %s for agent %s (user=%s channel=%s, queued %s ago)",
+					req.Kind, req.AgentID, req.UserID, req.ChannelID, time.Since(req.QueuedAt).Round(time.Second))
+				req.replay()
+			}
+		}()
+	}
+	return len(pending)
+}
+
+// Status returns a snapshot of maintenance mode and the queued requests.
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := MaintenanceStatus{Enabled: m.enabled, Message: m.message, Queue: m.queue, Queued: append([]QueuedRequest{}, m.pending...)}
+	if m.enabled {
+		since := m.since
+		st.Since = &since
+	}
+	return st
+}
+
+// Defer holds back req while maintenance mode is on, queueing replay when
+// queueing is enabled and there is room. It returns the message to show
+// the user and whether the request was deferred; when it wasn't, the
+// caller handles the request normally.
+func (m *Maintenance) Defer(req QueuedRequest, replay func()) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.enabled {
+		return "", false
+	}
+
+	msg := ":construction: I'm down for planned maintenance right now."
+	if m.message != "" {
+		msg += " " + m.message
+	}
+	if m.queue && len(m.pending) < maxQueuedRequests {
+		req.QueuedAt = time.Now()
+		req.replay = replay
+		m.pending = append(m.pending, req)
+		logging.Infof("[maintenance] queued %s for agent %s (user=%s channel=%s, %d queued)", req.Kind, req.AgentID, req.UserID, req.ChannelID, len(m.pending))
+		return msg + " Your request is queued and will run automatically when maintenance ends.", true
+	}
+	logging.Infof("[maintenance] deferred %s for agent %s (user=%s channel=%s, not queued)", req.Kind, req.AgentID, req.UserID, req.ChannelID)
+	return msg + " Please try again once it's over.", true
+}
+
+// deferForMaintenance holds back a request while maintenance mode is on and
+// tells the user why through notify (nil for runs nobody is waiting on).
+// replay runs the request again once maintenance ends, if it was queued.
+func (r *Router) deferForMaintenance(kind, channelID, userID, text string, replay func(), notify func(msg string)) bool {
+	msg, deferred := r.maintenance.Defer(QueuedRequest{AgentID: r.agentID, Kind: kind, ChannelID: channelID, UserID: userID, Text: text}, replay)
+	if deferred && notify != nil {
+		notify(msg)
+	}
+	return deferred
+}
@@ -10,26 +10,49 @@ import (
 const (
 	maxConversationTurns = 10
 	conversationTTL      = 10 * time.Minute
+	// threadSummaryTTL is how long a thread's session recap is kept for
+	// later sessions in the same thread.
+	threadSummaryTTL   = 7 * 24 * time.Hour
+	maxThreadSummaries = 500
+	// Thread histories are shared by everyone in the thread and outlive a
+	// single user's conversation, since sessions can be extended for hours.
+	maxThreadTurns   = 20
+	threadHistoryTTL = 24 * time.Hour
 )
 
 type ConversationMemory struct {
-	mu    sync.Mutex
-	convs map[string]*conversation
+	mu        sync.Mutex
+	convs     map[string]*conversation
+	threads   map[string]*conversation // key: "channelID:threadTS"
+	summaries map[string]threadSummary // key: "channelID:threadTS"
+}
+
+type threadSummary struct {
+	text      string
+	createdAt time.Time
 }
 
 type conversation struct {
 	turns     []turn
 	updatedAt time.Time
+	// Thread histories only: summary replaces the turns folded into it, and
+	// dropped counts the turns removed from the front so far.
+	summary    string
+	dropped    int
+	compacting bool
 }
 
 type turn struct {
+	Speaker   string // user ID; set in thread histories only
 	User      string
 	Assistant string
 }
 
 func NewConversationMemory() *ConversationMemory {
 	return &ConversationMemory{
-		convs: make(map[string]*conversation),
+		convs:     make(map[string]*conversation),
+		threads:   make(map[string]*conversation),
+		summaries: make(map[string]threadSummary),
 	}
 }
 
@@ -94,3 +117,149 @@ func (cm *ConversationMemory) GetHistory(channelID, userID string) string {
 	}
 	return sb.String()
 }
+
+// SetThreadSummary keeps the recap of an ended session so a later session in
+// the same thread can pick up where it left off. The oldest recap is dropped
+// once maxThreadSummaries are kept.
+func (cm *ConversationMemory) SetThreadSummary(channelID, threadTS, text string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.summaries[sessionKey(channelID, threadTS)] = threadSummary{text: text, createdAt: time.Now()}
+	if len(cm.summaries) <= maxThreadSummaries {
+		return
+	}
+	var oldest string
+	for key, sum := range cm.summaries {
+		if oldest == "" || sum.createdAt.Before(cm.summaries[oldest].createdAt) {
+			oldest = key
+		}
+	}
+	delete(cm.summaries, oldest)
+}
+
+// ThreadSummary returns the recap of the thread's last ended session, or ""
+// when there is none or it is older than threadSummaryTTL.
+func (cm *ConversationMemory) ThreadSummary(channelID, threadTS string) string {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	sum, ok := cm.summaries[sessionKey(channelID, threadTS)]
+	if !ok || time.Since(sum.createdAt) > threadSummaryTTL {
+		return ""
+	}
+	return sum.text
+}
+
+// AddThreadMessage adds userID's message to the thread's shared history,
+// which every participant's requests in the thread see.
+func (cm *ConversationMemory) AddThreadMessage(channelID, threadTS, userID, text string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for key, conv := range cm.threads {
+		if time.Since(conv.updatedAt) > threadHistoryTTL {
+			delete(cm.threads, key)
+		}
+	}
+	key := sessionKey(channelID, threadTS)
+	conv, ok := cm.threads[key]
+	if !ok {
+		conv = &conversation{}
+		cm.threads[key] = conv
+	}
+	conv.turns = append(conv.turns, turn{Speaker: userID, User: text})
+	conv.updatedAt = time.Now()
+	if len(conv.turns) > maxThreadTurns {
+		conv.dropped += len(conv.turns) - maxThreadTurns
+		conv.turns = conv.turns[len(conv.turns)-maxThreadTurns:]
+	}
+}
+
+// SetThreadResponse records the answer to the thread's latest message.
+func (cm *ConversationMemory) SetThreadResponse(channelID, threadTS, text string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	conv, ok := cm.threads[sessionKey(channelID, threadTS)]
+	if !ok || len(conv.turns) == 0 {
+		return
+	}
+	conv.turns[len(conv.turns)-1].Assistant = text
+	conv.updatedAt = time.Now()
+}
+
+// GetThreadHistory renders the thread's shared history before its latest
+// message, naming who said what, or "" when there is none.
+func (cm *ConversationMemory) GetThreadHistory(channelID, threadTS string) string {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	conv, ok := cm.threads[sessionKey(channelID, threadTS)]
+	if !ok || time.Since(conv.updatedAt) > threadHistoryTTL || (len(conv.turns) <= 1 && conv.summary == "") {
+		return ""
+	}
+	var sb strings.Builder
+	if conv.summary != "" {
+		fmt.Fprintf(&sb, "Summary of earlier messages:\n%s\n\n", conv.summary)
+	}
+	for _, t := range conv.turns[:max(len(conv.turns)-1, 0)] {
+		fmt.Fprintf(&sb, "<@%s>: %s\n", t.Speaker, t.User)
+		if t.Assistant != "" {
+			fmt.Fprintf(&sb, "Assistant: %s\n", t.Assistant)
+		}
+	}
+	return sb.String()
+}
+
+// threadCompaction is a batch of a thread's oldest turns being folded into
+// its summary.
+type threadCompaction struct {
+	conv    *conversation
+	summary string // the summary so far
+	turns   []turn
+	start   int // absolute index of turns[0] in the thread
+}
+
+// BeginThreadCompaction returns the oldest turns of the thread's history
+// when it has more than maxTurns, keeping the newest half verbatim. Only one
+// compaction per thread runs at a time; the caller must end it with
+// FinishThreadCompaction.
+func (cm *ConversationMemory) BeginThreadCompaction(channelID, threadTS string, maxTurns int) (threadCompaction, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	conv, ok := cm.threads[sessionKey(channelID, threadTS)]
+	if !ok || conv.compacting || len(conv.turns) <= maxTurns {
+		return threadCompaction{}, false
+	}
+	n := len(conv.turns) - max(maxTurns/2, 1)
+	conv.compacting = true
+	return threadCompaction{
+		conv:    conv,
+		summary: conv.summary,
+		turns:   append([]turn(nil), conv.turns[:n]...),
+		start:   conv.dropped,
+	}, true
+}
+
+// FinishThreadCompaction replaces the turns of c with summary. An empty
+// summary abandons the compaction and keeps the turns.
+func (cm *ConversationMemory) FinishThreadCompaction(channelID, threadTS string, c threadCompaction, summary string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	conv := c.conv
+	conv.compacting = false
+	if cm.threads[sessionKey(channelID, threadTS)] != conv {
+		return // expired meanwhile
+	}
+	if summary == "" {
+		return
+	}
+	// Turns trimmed by the history limit meanwhile are already gone.
+	n := min(max(c.start+len(c.turns)-conv.dropped, 0), len(conv.turns))
+	conv.turns = conv.turns[n:]
+	conv.dropped += n
+	conv.summary = summary
+}
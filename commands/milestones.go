@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// milestoneTools list repository milestones and assign issues and PRs to them.
+var milestoneTools = []*ToolDef{
+	{
+		Name:        "list_milestones",
+		Description: "List the milestones of a GitHub repository with due dates and open/closed issue counts.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"state":{"type":"string","enum":["open","closed","all"],"description":"Milestone state (default: open)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolListMilestones,
+	},
+	{
+		Name:        "set_milestone",
+		Description: "Assign an issue or pull request to a milestone, e.g. 'add this to the 2.5 milestone' after opening a PR. The milestone is matched by title, ignoring case and a leading 'v'.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"number":{"type":"integer","description":"Issue or pull request number"},
+				"milestone":{"type":"string","description":"Milestone title (e.g. '2.5'), or '#<number>'"}
+			},
+			"required":["repo","number","milestone"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolSetMilestone,
+	},
+}
+
+func (h *GeneralHandler) toolListMilestones(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo  string `json:"repo"`
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	milestones, err := h.ghClient.ListMilestones(ctx, owner, args.Repo, args.State)
+	if err != nil {
+		return fmt.Sprintf("Error listing milestones: %v", err)
+	}
+	if len(milestones) == 0 {
+		if args.State == "" {
+			args.State = "open"
+		}
+		return fmt.Sprintf("%s/%s has no %s milestones.", owner, args.Repo, args.State)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d milestones in %s/%s", call.UserID, call.ChannelID, len(milestones), owner, args.Repo)
+	table := &Table{Name: "milestones", Columns: []string{"#", "Title", "State", "Due", "Open", "Closed", "URL"}, Inline: 6}
+	for _, m := range milestones {
+		due := ""
+		if !m.DueOn.IsZero() {
+			due = m.DueOn.UTC().Format("2006-01-02")
+		}
+		table.Rows = append(table.Rows, []string{strconv.Itoa(m.Number), m.Title, m.State, due, strconv.Itoa(m.OpenIssues), strconv.Itoa(m.ClosedIssues), m.URL})
+	}
+	return h.presentTable(call, fmt.Sprintf("Milestones in %s/%s (%d).", owner, args.Repo, len(milestones)), table)
+}
+
+func (h *GeneralHandler) toolSetMilestone(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo      string `json:"repo"`
+		Number    int    `json:"number"`
+		Milestone string `json:"milestone"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Number == 0 || strings.TrimSpace(args.Milestone) == "" {
+		return "Error: repo, number and milestone are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	milestones, err := h.ghClient.ListMilestones(ctx, owner, args.Repo, "open")
+	if err != nil {
+		return fmt.Sprintf("Error listing milestones: %v", err)
+	}
+	m, candidates := matchMilestone(milestones, args.Milestone)
+	if m == nil {
+		if len(candidates) > 1 {
+			return fmt.Sprintf("Error: %q matches several open milestones: %s. Pass the exact title.", args.Milestone, strings.Join(candidates, ", "))
+		}
+		var titles []string
+		for _, m := range milestones {
+			titles = append(titles, m.Title)
+		}
+		if len(titles) == 0 {
+			return fmt.Sprintf("Error: %s/%s has no open milestones.", owner, args.Repo)
+		}
+		return fmt.Sprintf("Error: no open milestone matches %q. Open milestones: %s.", args.Milestone, strings.Join(titles, ", "))
+	}
+	if err := h.ghClient.SetMilestone(ctx, owner, args.Repo, args.Number, m.Number); err != nil {
+		return fmt.Sprintf("Error setting milestone: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] set milestone %q on %s/%s#%d", call.UserID, call.ChannelID, m.Title, owner, args.Repo, args.Number)
+	return fmt.Sprintf("Added %s/%s#%d to milestone %q (%s).", owner, args.Repo, args.Number, m.Title, m.URL)
+}
+
+// matchMilestone finds the milestone named want: by number, exact title,
+// title without a leading "v", or a unique title containing want. When
+// several titles contain want it returns them as candidates.
+func matchMilestone(milestones []github.Milestone, want string) (*github.Milestone, []string) {
+	want = strings.TrimSpace(want)
+	norm := func(s string) string { return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "v") }
+	if n, err := strconv.Atoi(strings.TrimPrefix(want, "#")); err == nil && strings.HasPrefix(want, "#") {
+		for i := range milestones {
+			if milestones[i].Number == n {
+				return &milestones[i], nil
+			}
+		}
+	}
+	for i := range milestones {
+		if strings.EqualFold(milestones[i].Title, want) {
+			return &milestones[i], nil
+		}
+	}
+	for i := range milestones {
+		if norm(milestones[i].Title) == norm(want) {
+			return &milestones[i], nil
+		}
+	}
+	var found []int
+	for i := range milestones {
+		if strings.Contains(norm(milestones[i].Title), norm(want)) {
+			found = append(found, i)
+		}
+	}
+	if len(found) == 1 {
+		return &milestones[found[0]], nil
+	}
+	var titles []string
+	for _, i := range found {
+		titles = append(titles, milestones[i].Title)
+	}
+	return nil, titles
+}
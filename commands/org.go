@@ -0,0 +1,321 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// orgTools resolve GitHub teams, members and repository owners to people.
+var orgTools = []*ToolDef{
+	{
+		Name:        "list_github_teams",
+		Description: "List the GitHub organization's teams (slug, name, description, member count), optionally filtered by name.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"query":{"type":"string","description":"Only teams whose name or slug contains this text (optional)"}
+			}
+		}`),
+		Available: (*GeneralHandler).githubConfigured,
+		Run:       (*GeneralHandler).toolListGitHubTeams,
+	},
+	{
+		Name:        "get_team_members",
+		Description: "List the members of a GitHub team (login and name). Use it to pick reviewers from a team or to see who is on it.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"team":{"type":"string","description":"Team slug or name (e.g. 'platform' or '@org/platform')"}
+			},
+			"required":["team"]
+		}`),
+		Available: (*GeneralHandler).githubConfigured,
+		Run:       (*GeneralHandler).toolGetTeamMembers,
+	},
+	{
+		Name:        "get_repo_owners",
+		Description: "Answer 'who owns this repo / this file': the CODEOWNERS rules (or the rule for one path), the teams with admin, maintain or write access, and the most active committers of the last 90 days.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"File or directory to find the owner of (optional)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolGetRepoOwners,
+	},
+	{
+		Name:        "resolve_github_user",
+		Description: "Find a person's GitHub login from their Slack user ID, email, or name. Uses the account they linked with link_github_account when there is one; otherwise matches org members by public email or name, then commits authored with the email, then public profile emails. Use it before requesting reviews from or mentioning someone known from Slack.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"slack_user_id":{"type":"string","description":"Slack user ID (e.g. U012ABC); their email and real name are looked up"},
+				"email":{"type":"string","description":"Email address (optional when slack_user_id is given)"},
+				"name":{"type":"string","description":"Full name (optional)"}
+			}
+		}`),
+		Available: (*GeneralHandler).githubConfigured,
+		Run:       (*GeneralHandler).toolResolveGitHubUser,
+	},
+}
+
+func (h *GeneralHandler) toolListGitHubTeams(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	teams, err := h.ghClient.ListTeams(ctx, owner, args.Query)
+	if err != nil {
+		return fmt.Sprintf("Error listing teams: %v", err)
+	}
+	if len(teams) == 0 {
+		return fmt.Sprintf("No teams in %s match %q.", owner, args.Query)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d teams in %s", call.UserID, call.ChannelID, len(teams), owner)
+	table := &Table{Name: "teams", Columns: []string{"Slug", "Name", "Members", "Description", "URL"}, Inline: 4}
+	for _, t := range teams {
+		table.Rows = append(table.Rows, []string{t.Slug, t.Name, strconv.Itoa(t.Members), t.Description, t.URL})
+	}
+	return h.presentTable(call, fmt.Sprintf("Teams in %s (%d).", owner, len(teams)), table)
+}
+
+func (h *GeneralHandler) toolGetTeamMembers(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Team string `json:"team"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	slug, err := h.resolveTeamSlug(ctx, owner, args.Team)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	members, err := h.ghClient.TeamMembers(ctx, owner, slug)
+	if err != nil {
+		return fmt.Sprintf("Error listing team members: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d members of %s/%s", call.UserID, call.ChannelID, len(members), owner, slug)
+	if len(members) == 0 {
+		return fmt.Sprintf("Team @%s/%s has no members.", owner, slug)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Members of @%s/%s (%d):\n", owner, slug, len(members))
+	for _, m := range members {
+		if m.Name != "" {
+			fmt.Fprintf(&sb, "- %s (%s)\n", m.Login, m.Name)
+		} else {
+			fmt.Fprintf(&sb, "- %s\n", m.Login)
+		}
+	}
+	return sb.String()
+}
+
+// resolveTeamSlug turns a team reference ("platform", "@org/platform",
+// "Platform Team") into its slug.
+func (h *GeneralHandler) resolveTeamSlug(ctx context.Context, owner, team string) (string, error) {
+	team = strings.TrimSpace(strings.TrimPrefix(team, "@"))
+	if i := strings.LastIndex(team, "/"); i >= 0 {
+		team = team[i+1:]
+	}
+	if team == "" {
+		return "", fmt.Errorf("team is required")
+	}
+	teams, err := h.ghClient.ListTeams(ctx, owner, team)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range teams {
+		if strings.EqualFold(t.Slug, team) || strings.EqualFold(t.Name, team) {
+			return t.Slug, nil
+		}
+	}
+	if len(teams) == 1 {
+		return teams[0].Slug, nil
+	}
+	if len(teams) == 0 {
+		return "", fmt.Errorf("no team in %s matches %q", owner, team)
+	}
+	slugs := make([]string, len(teams))
+	for i, t := range teams {
+		slugs[i] = t.Slug
+	}
+	return "", fmt.Errorf("%q matches several teams: %s", team, strings.Join(slugs, ", "))
+}
+
+// ownerCommitWindow is how far back get_repo_owners looks for active committers.
+const ownerCommitWindow = 90 * 24 * time.Hour
+
+func (h *GeneralHandler) toolGetRepoOwners(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo string `json:"repo"`
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Owners of %s/%s", owner, args.Repo)
+	if args.Path != "" {
+		fmt.Fprintf(&sb, " (%s)", args.Path)
+	}
+	sb.WriteString(":\n")
+
+	rules, file, err := h.ghClient.GetCodeowners(ctx, owner, args.Repo, "")
+	switch {
+	case err != nil:
+		fmt.Fprintf(&sb, "\nCODEOWNERS: could not be read: %v\n", err)
+	case file == "":
+		sb.WriteString("\nCODEOWNERS: none.\n")
+	case args.Path != "":
+		if r := github.CodeownersFor(rules, args.Path); r != nil && len(r.Owners) > 0 {
+			fmt.Fprintf(&sb, "\nCODEOWNERS (%s): %s owned by %s (rule %q)\n", file, args.Path, strings.Join(r.Owners, " "), r.Pattern)
+		} else {
+			fmt.Fprintf(&sb, "\nCODEOWNERS (%s): no owner for %s.\n", file, args.Path)
+		}
+	default:
+		fmt.Fprintf(&sb, "\nCODEOWNERS (%s):\n", file)
+		for i, r := range rules {
+			if i == 40 {
+				fmt.Fprintf(&sb, "… %d more rules.\n", len(rules)-i)
+				break
+			}
+			fmt.Fprintf(&sb, "- %s → %s\n", r.Pattern, strings.Join(r.Owners, " "))
+		}
+	}
+
+	teams, err := h.ghClient.RepoTeams(ctx, owner, args.Repo)
+	if err != nil {
+		fmt.Fprintf(&sb, "\nTeams: could not be listed: %v\n", err)
+	} else {
+		var lines []string
+		for _, t := range teams {
+			if t.Permission == "admin" || t.Permission == "maintain" || t.Permission == "push" {
+				lines = append(lines, fmt.Sprintf("- @%s/%s (%s)", owner, t.Slug, t.Permission))
+			}
+		}
+		if len(lines) == 0 {
+			sb.WriteString("\nTeams with write access: none.\n")
+		} else {
+			fmt.Fprintf(&sb, "\nTeams with write access:\n%s\n", strings.Join(lines, "\n"))
+		}
+	}
+
+	filter := github.CommitFilter{Path: args.Path, Since: time.Now().Add(-ownerCommitWindow)}
+	commits, err := h.ghClient.ListCommits(ctx, owner, args.Repo, filter, 100)
+	if err != nil {
+		fmt.Fprintf(&sb, "\nRecent committers: could not be listed: %v\n", err)
+	} else {
+		counts := make(map[string]int)
+		for _, c := range commits {
+			if c.Author != "" && !strings.HasSuffix(c.Author, "[bot]") {
+				counts[c.Author]++
+			}
+		}
+		authors := make([]string, 0, len(counts))
+		for a := range counts {
+			authors = append(authors, a)
+		}
+		sort.Slice(authors, func(i, j int) bool {
+			if counts[authors[i]] != counts[authors[j]] {
+				return counts[authors[i]] > counts[authors[j]]
+			}
+			return authors[i] < authors[j]
+		})
+		if len(authors) == 0 {
+			sb.WriteString("\nRecent committers: none in the last 90 days.\n")
+		} else {
+			sb.WriteString("\nMost active committers (last 90 days):\n")
+			for _, a := range authors[:min(len(authors), 5)] {
+				fmt.Fprintf(&sb, "- %s (%d commits)\n", a, counts[a])
+			}
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] looked up owners of %s/%s path=%q", call.UserID, call.ChannelID, owner, args.Repo, args.Path)
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolResolveGitHubUser(ctx context.Context, call ToolCall) string {
+	var args struct {
+		SlackUserID string `json:"slack_user_id"`
+		Email       string `json:"email"`
+		Name        string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if id, ok := h.linkedIdentity(args.SlackUserID); ok && id.GitHubLogin != "" {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] resolve_github_user %s: linked account %s", call.UserID, call.ChannelID, id.SlackUserID, id.GitHubLogin)
+		return fmt.Sprintf("<@%s> is GitHub user %s (linked account).", id.SlackUserID, id.GitHubLogin)
+	}
+	if args.SlackUserID != "" {
+		user, err := h.slackClient.GetUserInfo(strings.Trim(args.SlackUserID, "<@>"))
+		if err != nil {
+			return fmt.Sprintf("Error getting Slack user info: %v", err)
+		}
+		if args.Email == "" {
+			args.Email = user.Profile.Email
+		}
+		if args.Name == "" {
+			args.Name = user.RealName
+		}
+	}
+	if args.Email == "" && args.Name == "" {
+		return "Error: pass slack_user_id, email, or name."
+	}
+	if args.Email == "" {
+		args.Email = h.directoryEmail(ctx, args.Name)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	matches, err := h.ghClient.FindUser(ctx, owner, args.Email, args.Name)
+	if err != nil {
+		return fmt.Sprintf("Error finding GitHub user: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] resolve_github_user email=%q name=%q: %d match(es)", call.UserID, call.ChannelID, args.Email, args.Name, len(matches))
+	who := strings.TrimSpace(args.Name + " <" + args.Email + ">")
+	if args.Email == "" {
+		who = args.Name
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("No GitHub account found for %s. Ask the user for their GitHub login.", who)
+	}
+	if len(matches) == 1 {
+		m := matches[0]
+		return fmt.Sprintf("%s is GitHub user %s (matched by %s). If that's wrong, they can fix it with link_github_account.", who, m.Login, m.Method)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Several GitHub accounts match %s; confirm which one with the user:\n", who)
+	for _, m := range matches {
+		fmt.Fprintf(&sb, "- %s (%s; matched by %s)\n", m.Login, m.Name, m.Method)
+	}
+	return sb.String()
+}
@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// toolPermissionHints describes, per tool, the integration permission it
+// depends on and how to grant it. Used to explain permission failures.
+var toolPermissionHints = map[string]string{
+	"list_org_repos":                 "GitHub: classic token needs `read:org`; fine-grained token needs organization \"Members: Read\".",
+	"get_file_content":               "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"get_repo_default_branch":        "GitHub: classic token needs `repo`; fine-grained token needs \"Metadata: Read\" on this repository.",
+	"search_files":                   "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"list_directory":                 "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"search_code":                    "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"get_pull_request":               "GitHub: classic token needs `repo`; fine-grained token needs \"Pull requests: Read\".",
+	"list_pull_requests":             "GitHub: classic token needs `repo`; fine-grained token needs \"Pull requests: Read\".",
+	"modify_file":                    "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read and write\" and \"Pull requests: Read and write\".",
+	"rewrite_file":                   "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read and write\" and \"Pull requests: Read and write\".",
+	"commit_files":                   "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read and write\" and \"Pull requests: Read and write\".",
+	"merge_pull_request":             "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read and write\", \"Pull requests: Read and write\" and \"Checks: Read\" (\"Administration: Read\" lets the bot read branch protection).",
+	"close_pull_request":             "GitHub: classic token needs `repo`; fine-grained token needs \"Pull requests: Read and write\".",
+	"update_pr_branch":               "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read and write\" and \"Pull requests: Read and write\".",
+	"resolve_project":                "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on the repositories searched.",
+	"list_commits":                   "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"git_blame":                      "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"get_commit":                     "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" (and \"Pull requests: Read\" for the commit's PRs) on this repository.",
+	"list_project_items":             "GitHub: classic token needs `read:project`; fine-grained token needs organization \"Projects: Read\".",
+	"add_to_project":                 "GitHub: classic token needs `project` and `repo`; fine-grained token needs organization \"Projects: Read and write\" and \"Issues: Read\" / \"Pull requests: Read\" on the item's repository.",
+	"compare_refs":                   "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"create_release":                 "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read and write\" on this repository.",
+	"create_repo_from_template":      "GitHub: classic token needs `repo` and `read:org`; fine-grained token needs \"Administration: Read and write\" and \"Contents: Read and write\" on all repositories and organization \"Members: Read\" (for teams).",
+	"list_milestones":                "GitHub: classic token needs `repo`; fine-grained token needs \"Issues: Read\" on this repository.",
+	"set_milestone":                  "GitHub: classic token needs `repo`; fine-grained token needs \"Issues: Read and write\" (and \"Pull requests: Read and write\" for PRs) on this repository.",
+	"list_github_teams":              "GitHub: classic token needs `read:org`; fine-grained token needs organization \"Members: Read\".",
+	"get_team_members":               "GitHub: classic token needs `read:org`; fine-grained token needs organization \"Members: Read\".",
+	"get_repo_owners":                "GitHub: classic token needs `repo` and `read:org`; fine-grained token needs \"Contents: Read\" and \"Administration: Read\" (for repository teams) on this repository.",
+	"resolve_github_user":            "GitHub: classic token needs `read:org`; fine-grained token needs organization \"Members: Read\". Slack: `users:read.email` to look up emails.",
+	"scan_dependencies":              "GitHub: classic token needs `repo`; fine-grained token needs \"Contents: Read\" on this repository.",
+	"get_check_runs":                 "GitHub: classic token needs `repo`; fine-grained token needs \"Checks: Read\" and \"Commit statuses: Read\" (and \"Pull requests: Read\" to look up a PR) on this repository.",
+	"get_workflow_run":               "GitHub: classic token needs `repo`; fine-grained token needs \"Actions: Read\" and \"Checks: Read\".",
+	"rerun_failed_jobs":              "GitHub: classic token needs `repo`; fine-grained token needs \"Actions: Read and write\".",
+	"rerun_workflow":                 "GitHub: classic token needs `repo`; fine-grained token needs \"Actions: Read and write\".",
+	"list_workflows":                 "GitHub: classic token needs `repo`; fine-grained token needs \"Actions: Read\" and \"Contents: Read\".",
+	"ci_status":                      "GitHub: classic token needs `repo`; fine-grained token needs \"Actions: Read\" and \"Metadata: Read\".",
+	"trigger_workflow":               "GitHub: classic token needs `repo` and `workflow`; fine-grained token needs \"Actions: Read and write\" and \"Contents: Read\".",
+	"reply_in_thread":                "Slack: the bot needs the `chat:write` scope and must be a member of the channel (invite it with /invite).",
+	"fetch_thread_context":           "Slack: the bot needs `channels:history` (public) or `groups:history` (private) and must be a member of the channel.",
+	"get_slack_user_info":            "Slack: the bot needs the `users:read` scope (and `users:read.email` for emails).",
+	"create_jira_ticket":             "Jira: the service account needs the CREATE_ISSUES project permission.",
+	"update_jira_issue":              "Jira: the service account needs the EDIT_ISSUES project permission.",
+	"transition_jira_issue":          "Jira: the service account needs the TRANSITION_ISSUES project permission.",
+	"comment_on_jira_issue":          "Jira: the service account needs the ADD_COMMENTS project permission.",
+	"search_jira_issues":             "Jira: the service account needs the BROWSE_PROJECTS project permission.",
+	"get_jira_issue":                 "Jira: the service account needs the BROWSE_PROJECTS project permission.",
+	"list_jira_projects":             "Jira: the service account needs the BROWSE_PROJECTS project permission.",
+	"create_ado_work_item":           "Azure DevOps: the PAT needs the \"Work Items: Read & write\" scope.",
+	"update_ado_work_item":           "Azure DevOps: the PAT needs the \"Work Items: Read & write\" scope.",
+	"search_ado_work_items":          "Azure DevOps: the PAT needs the \"Work Items: Read\" scope.",
+	"get_ado_work_item":              "Azure DevOps: the PAT needs the \"Work Items: Read\" scope.",
+	"list_ado_projects":              "Azure DevOps: the PAT needs the \"Project and Team: Read\" scope.",
+	"list_pagerduty_incidents":       "PagerDuty: the API key needs read access to incidents.",
+	"get_pagerduty_incident":         "PagerDuty: the API key needs read access to incidents.",
+	"acknowledge_pagerduty_incident": "PagerDuty: needs a full-access API key and PAGERDUTY_FROM_EMAIL set to a valid PagerDuty user.",
+	"resolve_pagerduty_incident":     "PagerDuty: needs a full-access API key and PAGERDUTY_FROM_EMAIL set to a valid PagerDuty user.",
+	"lookup_person":                  "Directory: Okta needs a read-only admin API token; Azure AD needs the Graph User.Read.All and GroupMember.Read.All application permissions.",
+	"query_metrics":                  "Metrics: Datadog needs an application key with the timeseries_query scope; Grafana needs a service account token with Viewer access to the data source.",
+	"list_vault_secrets":             "Vault: the token needs list on <mount>/metadata/*.",
+	"get_vault_secret_metadata":      "Vault: the token needs read on <mount>/metadata/*.",
+	"check_vault_lease":              "Vault: the token needs update on sys/leases/lookup, and list + sudo on sys/leases/lookup/* to check a prefix.",
+	"list_argocd_applications":       "Argo CD: the account needs 'get' on applications in argocd-rbac-cm.",
+	"sync_argocd_application":        "Argo CD: the account needs 'sync' on the application in argocd-rbac-cm.",
+	"list_cloud_run_revisions":       "GCP: the service account needs roles/run.viewer.",
+	"list_gke_workloads":             "GCP: the service account needs roles/container.viewer.",
+	"list_gcp_errors":                "GCP: the service account needs roles/errorreporting.viewer.",
+	"get_cloudflare_zone":            "Cloudflare: the API token needs Zone:Read and Zone Settings:Read on the zone.",
+	"list_cloudflare_waf_events":     "Cloudflare: the API token needs Analytics:Read on the zone.",
+	"purge_cloudflare_cache":         "Cloudflare: the API token needs Cache Purge:Purge on the zone.",
+	"list_terraform_runs":            "Terraform Cloud: the token needs read runs on the workspace.",
+	"get_terraform_plan":             "Terraform Cloud: the token needs read runs on the workspace; the JSON plan also needs the 'Download Sentinel mocks' permission or admin access.",
+	"get_image_vulnerabilities":      "ECR: the IAM role needs ecr:DescribeImageScanFindings (and inspector2:ListFindings for enhanced scanning). Harbor: the robot account needs read on the project's artifacts and scan reports.",
+	"query_cloud_costs":              "AWS: the IAM role needs ce:GetCostAndUsage and ce:GetDimensionValues (in the management account for costs across accounts). GCP: the service account needs roles/bigquery.jobUser on the GCP_PROJECT and roles/bigquery.dataViewer on the billing export dataset.",
+	"describe_ecs_service":           "AWS: the IAM role needs ecs:DescribeServices.",
+	"list_ecs_tasks":                 "AWS: the IAM role needs ecs:ListTasks and ecs:DescribeTasks.",
+	"query_cloudwatch_logs":          "AWS: the IAM role needs logs:StartQuery, logs:GetQueryResults and logs:StopQuery on the log groups.",
+	"list_cloudwatch_alarms":         "AWS: the IAM role needs cloudwatch:DescribeAlarms and cloudwatch:DescribeAlarmHistory.",
+	"get_lambda_errors":              "AWS: the IAM role needs lambda:GetFunctionConfiguration and cloudwatch:GetMetricStatistics, plus logs:StartQuery and logs:GetQueryResults for error lines.",
+	"search_notion":                  "Notion: share the page or database with the integration (page ••• menu → Connections).",
+	"read_notion_page":               "Notion: share the page with the integration and give it the \"Read content\" capability.",
+	"append_to_notion_page":          "Notion: share the page with the integration and give it the \"Insert content\" capability.",
+	"create_notion_database_entry":   "Notion: share the database with the integration and give it the \"Insert content\" capability.",
+	"publish_postmortem":             "Confluence: the Jira account needs \"Add page\" permission in CONFLUENCE_SPACE. Notion: share NOTION_POSTMORTEM_DATABASE with the integration and give it the \"Insert content\" capability.",
+	"resolve_jira_user":              "Jira: the service account needs the \"Browse users and groups\" global permission.",
+	"build_jql":                      "Jira: the service account needs the BROWSE_PROJECTS project permission.",
+}
+
+// permissionErrorMarkers are substrings that identify authorization failures
+// in tool error messages from GitHub, Slack, and Jira.
+var permissionErrorMarkers = []string{
+	" 401 ", " 403 ", "returned 401", "returned 403",
+	"Resource not accessible by", "Must have admin rights",
+	"missing_scope", "not_in_channel", "not_allowed_token_type",
+	"does not have permission", "You do not have permission",
+}
+
+// warnedPermissions remembers which tools already produced a Slack warning,
+// so each permission problem is surfaced once per process rather than on every call.
+var warnedPermissions sync.Map
+
+// isPermissionError reports whether a tool result looks like an authorization failure.
+func isPermissionError(result string) bool {
+	if !strings.HasPrefix(result, "Error") {
+		return false
+	}
+	for _, m := range permissionErrorMarkers {
+		if strings.Contains(result, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnPermissionFailure posts a one-time thread warning with remediation steps
+// the first time a tool fails because an integration lacks a permission.
+func (h *GeneralHandler) warnPermissionFailure(channelID, auditTS, name, result string) {
+	if auditTS == "" || !isPermissionError(result) {
+		return
+	}
+	hint, ok := toolPermissionHints[name]
+	if !ok {
+		return
+	}
+	if _, already := warnedPermissions.LoadOrStore(name, true); already {
+		return
+	}
+	msg := fmt.Sprintf(":warning: `%s` failed because of a missing permission. %s", name, hint)
+	if h.appURL != "" {
+		msg += fmt.Sprintf("\nSee the Integrations page for the full permission status: %s/ui/", strings.TrimRight(h.appURL, "/"))
+	}
+	if err := h.slackClient.PostThreadReply(channelID, auditTS, msg); err != nil {
+		h.logger().Errorf("[channel=%s] failed to post permission warning: %v", channelID, err)
+	}
+}
@@ -0,0 +1,334 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	slacklib "github.com/slack-go/slack"
+
+	"github.com/justmike1/ovad/confluence"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/ticketing"
+)
+
+// PostmortemTarget is where publish_postmortem files documents: a
+// Confluence space, a Notion database, or both.
+type PostmortemTarget struct {
+	Confluence       *confluence.Client
+	ConfluenceSpace  string // space key, e.g. "ENG"
+	ConfluenceParent string // parent page ID; empty = space root
+	NotionDatabase   string // database ID; needs the Notion client
+}
+
+// timelineMessageChars caps each message's text in an incident timeline.
+const timelineMessageChars = 300
+
+// postmortemTools turn an incident thread into a postmortem: a timeline from
+// the thread's message timestamps, then a document with Jira follow-ups.
+var postmortemTools = []*ToolDef{
+	{
+		Name:        "compile_incident_timeline",
+		Description: "Build an incident timeline from a Slack thread: every message with its UTC time, offset from the first message and author, plus the start, end, duration and participants. Defaults to the current thread. Use it first when asked for a postmortem, incident summary or timeline.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"url":{"type":"string","description":"Slack thread URL; omit for the current thread"}
+			}
+		}`),
+		Run: (*GeneralHandler).toolCompileIncidentTimeline,
+	},
+	{
+		Name:        "publish_postmortem",
+		Description: "Publish a postmortem document and open a follow-up ticket for each action item. Before calling: run compile_incident_timeline for the timeline, and establish the root cause from the evidence (get_workflow_run, logs, metrics, the thread itself) rather than guessing — say so in root_cause when it is not confirmed. Condense the timeline to the key events (detection, escalation, mitigation, resolution) with their times. The document links the Slack thread and the tickets, and each ticket links back to the document.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"title":{"type":"string","description":"Postmortem title, e.g. 'Checkout 5xx errors on 2024-05-01'"},
+				"summary":{"type":"string","description":"Two or three sentences: what happened and how it was resolved"},
+				"impact":{"type":"string","description":"Who and what was affected, for how long, and how badly"},
+				"timeline":{"type":"string","description":"Markdown list of key events, one per line: '- 14:02 UTC — alert fired for ...'"},
+				"root_cause":{"type":"string","description":"The root cause and the evidence for it"},
+				"resolution":{"type":"string","description":"How the incident was mitigated and resolved"},
+				"action_items":{"type":"array","items":{"type":"object","properties":{
+					"title":{"type":"string","description":"Follow-up ticket summary"},
+					"description":{"type":"string","description":"What to do and why"},
+					"assignee":{"type":"string","description":"Person's name, if the thread named an owner"}
+				},"required":["title"]}},
+				"destination":{"type":"string","enum":["confluence","notion"],"description":"Where to publish (default: Confluence when configured, else Notion)"},
+				"project":{"type":"string","description":"Jira project for the follow-up tickets (default: the configured project)"},
+				"thread_url":{"type":"string","description":"Slack thread URL of the incident; omit for the current thread"}
+			},
+			"required":["title","summary","impact","timeline","root_cause"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).postmortemConfigured,
+		Run:       (*GeneralHandler).toolPublishPostmortem,
+	},
+}
+
+func (h *GeneralHandler) postmortemConfigured() bool {
+	return h.postmortem.Confluence != nil || (h.notionClient != nil && h.postmortem.NotionDatabase != "")
+}
+
+// incidentThread returns the thread named by rawURL, or the thread of the
+// current request.
+func incidentThread(call ToolCall, rawURL string) (string, string, error) {
+	if rawURL != "" {
+		return ParseSlackThreadURL(rawURL)
+	}
+	if call.AuditTS == "" {
+		return "", "", fmt.Errorf("no current thread; pass the incident thread's URL")
+	}
+	return call.ChannelID, call.AuditTS, nil
+}
+
+func (h *GeneralHandler) toolCompileIncidentTimeline(ctx context.Context, call ToolCall) string {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	channelID, threadTS, err := incidentThread(call, args.URL)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	msgs, err := h.slackClient.FetchThreadReplies(channelID, threadTS, 1000)
+	if err != nil {
+		return fmt.Sprintf("Error fetching thread replies: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] compiled incident timeline for thread %s (%d messages)", call.UserID, call.ChannelID, threadTS, len(msgs))
+	return h.formatIncidentTimeline(channelID, threadTS, msgs)
+}
+
+// formatIncidentTimeline renders thread messages oldest first with UTC times
+// and offsets from the first message.
+func (h *GeneralHandler) formatIncidentTimeline(channelID, threadTS string, msgs []slacklib.Message) string {
+	names := make(map[string]string)
+	author := func(m slacklib.Message) string {
+		switch {
+		case m.User == "" && m.Username != "":
+			return m.Username + " (bot)"
+		case m.User == "":
+			return "bot"
+		}
+		if n, ok := names[m.User]; ok {
+			return n
+		}
+		n := m.User
+		if u, err := h.slackClient.GetUserInfo(m.User); err == nil {
+			n = u.RealName
+			if n == "" {
+				n = u.Name
+			}
+		}
+		if m.BotID != "" {
+			n += " (bot)"
+		}
+		names[m.User] = n
+		return n
+	}
+
+	var (
+		lines        []string
+		participants []string
+		seen         = make(map[string]bool)
+		start, end   time.Time
+	)
+	for _, m := range msgs {
+		text := strings.Join(strings.Fields(extractMessageContent(m)), " ")
+		t, err := tsToTime(m.Timestamp)
+		if text == "" || err != nil {
+			continue
+		}
+		t = t.UTC()
+		if start.IsZero() {
+			start = t
+		}
+		end = t
+		who := author(m)
+		if !seen[who] {
+			seen[who] = true
+			participants = append(participants, who)
+		}
+		if r := []rune(text); len(r) > timelineMessageChars {
+			text = string(r[:timelineMessageChars]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("- %s (+%s) %s: %s", t.Format("2006-01-02 15:04"), formatOffset(t.Sub(start)), who, text))
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("No messages found in thread (channel=%s, thread_ts=%s).", channelID, threadTS)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Incident thread (channel_id=%s, thread_ts=%s): %d messages\n", channelID, threadTS, len(lines))
+	fmt.Fprintf(&sb, "Start: %s UTC\nEnd (last message): %s UTC\nDuration: %s\n", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), formatOffset(end.Sub(start)))
+	fmt.Fprintf(&sb, "Participants: %s\n\nTimeline (UTC):\n%s", strings.Join(participants, ", "), strings.Join(lines, "\n"))
+	return sb.String()
+}
+
+// formatOffset renders d as "45m" or "2h05m".
+func formatOffset(d time.Duration) string {
+	m := int(d.Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", m/60, m%60)
+}
+
+func (h *GeneralHandler) toolPublishPostmortem(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Title       string `json:"title"`
+		Summary     string `json:"summary"`
+		Impact      string `json:"impact"`
+		Timeline    string `json:"timeline"`
+		RootCause   string `json:"root_cause"`
+		Resolution  string `json:"resolution"`
+		ActionItems []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Assignee    string `json:"assignee"`
+		} `json:"action_items"`
+		Destination string `json:"destination"`
+		Project     string `json:"project"`
+		ThreadURL   string `json:"thread_url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Title) == "" {
+		return "Error: title is required."
+	}
+	dest := args.Destination
+	if dest == "" {
+		dest = "notion"
+		if h.postmortem.Confluence != nil {
+			dest = "confluence"
+		}
+	}
+	switch {
+	case dest == "confluence" && h.postmortem.Confluence == nil:
+		return "Error: Confluence postmortems are not configured (set CONFLUENCE_SPACE)."
+	case dest == "notion" && (h.notionClient == nil || h.postmortem.NotionDatabase == ""):
+		return "Error: Notion postmortems are not configured (set NOTION_TOKEN and NOTION_POSTMORTEM_DATABASE)."
+	case dest != "confluence" && dest != "notion":
+		return fmt.Sprintf("Error: unknown destination %q; use confluence or notion.", dest)
+	}
+
+	threadLink := ""
+	if channelID, threadTS, err := incidentThread(call, args.ThreadURL); err == nil {
+		if permalink, err := h.slackClient.GetPermalink(channelID, threadTS); err == nil {
+			threadLink = permalink
+		}
+	}
+
+	// File the follow-ups first so the document can link them.
+	var (
+		tickets  []*ticketing.Ticket
+		items    []string
+		problems []string
+	)
+	for _, item := range args.ActionItems {
+		line := item.Title
+		if item.Assignee != "" {
+			line += " — " + item.Assignee
+		}
+		if h.tickets == nil {
+			items = append(items, "- "+line)
+			continue
+		}
+		desc := item.Description
+		if desc != "" {
+			desc += "\n\n"
+		}
+		desc += fmt.Sprintf("Follow-up from the postmortem %q.", args.Title)
+		if threadLink != "" {
+			desc += fmt.Sprintf(" [Incident thread](%s)", threadLink)
+		}
+		t, err := h.tickets.Create(ctx, ticketing.CreateInput{
+			Project:     args.Project,
+			Title:       item.Title,
+			Description: desc + h.ticketStamp(call.UserID),
+			Labels:      []string{"postmortem"},
+			Assignee:    item.Assignee,
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("could not create a ticket for %q: %v", item.Title, err))
+			items = append(items, "- "+line)
+			continue
+		}
+		tickets = append(tickets, t)
+		items = append(items, fmt.Sprintf("- [%s](%s) %s", t.Key, t.URL, line))
+	}
+
+	var doc strings.Builder
+	section := func(heading, body string) {
+		if body = strings.TrimSpace(body); body != "" {
+			fmt.Fprintf(&doc, "## %s\n%s\n\n", heading, body)
+		}
+	}
+	section("Summary", args.Summary)
+	section("Impact", args.Impact)
+	section("Timeline", args.Timeline)
+	section("Root cause", args.RootCause)
+	section("Resolution", args.Resolution)
+	section("Action items", strings.Join(items, "\n"))
+	doc.WriteString("---\n")
+	fmt.Fprintf(&doc, "Drafted by **%s** from the incident thread on %s.", h.agentID, time.Now().UTC().Format("2006-01-02"))
+	if threadLink != "" {
+		fmt.Fprintf(&doc, " [Slack thread](%s)", threadLink)
+	}
+
+	var docURL string
+	if dest == "confluence" {
+		page, err := h.postmortem.Confluence.CreatePage(ctx, h.postmortem.ConfluenceSpace, h.postmortem.ConfluenceParent, args.Title, doc.String())
+		if err != nil {
+			return postmortemFailure(fmt.Sprintf("Error publishing the postmortem to Confluence: %v", err), tickets)
+		}
+		docURL = page.URL
+	} else {
+		entry, err := h.notionClient.CreateDatabaseEntry(ctx, h.postmortem.NotionDatabase, args.Title, doc.String(), nil)
+		if err != nil {
+			return postmortemFailure(fmt.Sprintf("Error publishing the postmortem to Notion: %v", err), tickets)
+		}
+		docURL = entry.URL
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] published postmortem %q to %s: %s (%d follow-up tickets)", call.UserID, call.ChannelID, args.Title, dest, docURL, len(tickets))
+
+	for _, t := range tickets {
+		if err := h.tickets.Comment(ctx, t.Key, fmt.Sprintf("Postmortem: [%s](%s)", args.Title, docURL)); err != nil {
+			problems = append(problems, fmt.Sprintf("could not link %s to the postmortem: %v", t.Key, err))
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Postmortem published: %s\n", docURL)
+	if len(tickets) > 0 {
+		fmt.Fprintf(&sb, "Follow-up tickets (%d):\n", len(tickets))
+		for _, t := range tickets {
+			fmt.Fprintf(&sb, "- *%s* %s — %s\n", t.Key, t.Title, t.URL)
+		}
+	} else if len(args.ActionItems) > 0 && h.tickets == nil {
+		sb.WriteString("Action items are listed in the document; no ticket tracker is configured for follow-ups.\n")
+	}
+	for _, p := range problems {
+		fmt.Fprintf(&sb, "Warning: %s\n", p)
+	}
+	return sb.String()
+}
+
+// postmortemFailure reports a failed publish without hiding tickets that were
+// already created, so they are not filed twice on retry.
+func postmortemFailure(msg string, tickets []*ticketing.Ticket) string {
+	if len(tickets) == 0 {
+		return msg
+	}
+	var keys []string
+	for _, t := range tickets {
+		keys = append(keys, t.Key)
+	}
+	return fmt.Sprintf("%s\nFollow-up tickets were already created: %s. Do not create them again on retry; leave out action_items and mention these keys in the document instead.", msg, strings.Join(keys, ", "))
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// projectTools work with GitHub Projects (v2) boards, for teams that plan
+// in GitHub rather than Jira.
+var projectTools = []*ToolDef{
+	{
+		Name:        "list_project_items",
+		Description: "List the items (issues, pull requests, drafts) on a GitHub Projects board with their Status column. Without a project number, lists the organization's boards so you can pick one.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"project":{"type":"integer","description":"Project number, as in https://github.com/orgs/<org>/projects/<number> (optional — omit to list boards)"},
+				"status":{"type":"string","description":"Only items in this Status column, e.g. 'In Progress' (optional)"},
+				"limit":{"type":"integer","description":"Maximum number of items (default: 50, max: 500)"}
+			}
+		}`),
+		Available: (*GeneralHandler).githubConfigured,
+		Run:       (*GeneralHandler).toolListProjectItems,
+	},
+	{
+		Name:        "add_to_project",
+		Description: "Add an issue or pull request to a GitHub Projects board and optionally set its Status column. Also use this to move an item that is already on the board to another column — adding it again is a no-op. Use it after opening a PR or issue when the team plans in GitHub Projects.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"project":{"type":"integer","description":"Project number"},
+				"url":{"type":"string","description":"Issue or pull request URL (e.g. https://github.com/org/repo/pull/42)"},
+				"status":{"type":"string","description":"Status column to put the item in, e.g. 'Todo', 'In Progress', 'Done' (optional)"}
+			},
+			"required":["project","url"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolAddToProject,
+	},
+}
+
+func (h *GeneralHandler) toolListProjectItems(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Project int    `json:"project"`
+		Status  string `json:"status"`
+		Limit   int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+
+	if args.Project == 0 {
+		projects, err := h.ghClient.ListProjects(ctx, owner)
+		if err != nil {
+			return fmt.Sprintf("Error listing projects: %v", err)
+		}
+		if len(projects) == 0 {
+			return fmt.Sprintf("%s has no GitHub Projects boards.", owner)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "GitHub Projects boards for %s:\n", owner)
+		for _, p := range projects {
+			closed := ""
+			if p.Closed {
+				closed = " (closed)"
+			}
+			fmt.Fprintf(&sb, "- #%d %s%s — %s\n", p.Number, p.Title, closed, p.URL)
+		}
+		return sb.String()
+	}
+
+	if args.Limit <= 0 {
+		args.Limit = 50
+	}
+	args.Limit = min(args.Limit, 500)
+	project, err := h.ghClient.GetProject(ctx, owner, args.Project)
+	if err != nil {
+		return fmt.Sprintf("Error getting project: %v", err)
+	}
+	items, err := h.ghClient.ListProjectItems(ctx, project.ID, args.Limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing project items: %v", err)
+	}
+	// Boards span repositories; leave out items from those the agent may
+	// not read. Draft items belong to no repository.
+	var repos []string
+	for _, it := range items {
+		if it.Repo != "" {
+			repos = append(repos, it.Repo)
+		}
+	}
+	readable := make(map[string]bool)
+	for _, r := range h.filterReadableRepos(repos) {
+		readable[r] = true
+	}
+	table := &Table{Name: "project-items", Columns: []string{"Title", "Type", "Repository", "State", "Status", "URL"}, Inline: 5}
+	for _, it := range items {
+		if args.Status != "" && !strings.EqualFold(it.Status, args.Status) {
+			continue
+		}
+		if it.Repo != "" && !readable[it.Repo] {
+			continue
+		}
+		table.Rows = append(table.Rows, []string{it.Title, strings.ToLower(strings.ReplaceAll(it.Type, "_", " ")), it.Repo, it.State, it.Status, it.URL})
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d items on project %s#%d", call.UserID, call.ChannelID, len(table.Rows), owner, args.Project)
+	if len(table.Rows) == 0 {
+		if args.Status != "" {
+			return fmt.Sprintf("No items with Status %q on %q (%s).%s", args.Status, project.Title, project.URL, statusOptionsHint(project))
+		}
+		return fmt.Sprintf("%q (%s) has no items.", project.Title, project.URL)
+	}
+	summary := fmt.Sprintf("Items on %q (%s): %d.", project.Title, project.URL, len(table.Rows))
+	return h.presentTable(call, summary, table)
+}
+
+func (h *GeneralHandler) toolAddToProject(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Project int    `json:"project"`
+		URL     string `json:"url"`
+		Status  string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Project == 0 || args.URL == "" {
+		return "Error: project and url are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	project, err := h.ghClient.GetProject(ctx, owner, args.Project)
+	if err != nil {
+		return fmt.Sprintf("Error getting project: %v", err)
+	}
+	if project.Closed {
+		return fmt.Sprintf("Error: project %q is closed.", project.Title)
+	}
+
+	// Validate the column before touching the board, so a typo doesn't
+	// leave the item added but unplaced.
+	var fieldID, optionID, status string
+	if args.Status != "" {
+		field := project.Field("Status")
+		if field == nil || len(field.Options) == 0 {
+			return fmt.Sprintf("Error: project %q has no Status field.", project.Title)
+		}
+		opt := field.Option(args.Status)
+		if opt == nil {
+			return fmt.Sprintf("Error: unknown status %q.%s", args.Status, statusOptionsHint(project))
+		}
+		fieldID, optionID, status = field.ID, opt.ID, opt.Name
+	}
+
+	itemID, err := h.ghClient.AddProjectItem(ctx, project.ID, args.URL)
+	if err != nil {
+		return fmt.Sprintf("Error adding to project: %v", err)
+	}
+	if optionID != "" {
+		if err := h.ghClient.SetProjectItemOption(ctx, project.ID, itemID, fieldID, optionID); err != nil {
+			return fmt.Sprintf("Added %s to %q, but setting its status failed: %v", args.URL, project.Title, err)
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] added %s to project %s#%d status=%q", call.UserID, call.ChannelID, args.URL, owner, args.Project, status)
+	if status != "" {
+		return fmt.Sprintf("%s is on %q (%s) in %s.", args.URL, project.Title, project.URL, status)
+	}
+	return fmt.Sprintf("%s is on %q (%s).", args.URL, project.Title, project.URL)
+}
+
+// statusOptionsHint lists a board's Status columns for error messages.
+func statusOptionsHint(p *github.Project) string {
+	field := p.Field("Status")
+	if field == nil || len(field.Options) == 0 {
+		return ""
+	}
+	names := make([]string, len(field.Options))
+	for i, o := range field.Options {
+		names[i] = o.Name
+	}
+	return " Status columns: " + strings.Join(names, ", ") + "."
+}
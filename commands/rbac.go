@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/prompts"
+)
+
+// Tool categories for access rules. Entries that aren't one of these are
+// tool names or patterns.
+const (
+	CategoryRead      = "read"
+	CategoryWrite     = "write"
+	CategoryWriteCode = "write-code"
+	CategoryRerunCI   = "rerun-ci"
+	CategoryJiraWrite = "jira-write"
+)
+
+// categoryTools lists the tools in the named write categories.
+var categoryTools = map[string][]string{
+	CategoryWriteCode: {"modify_file", "rewrite_file", "commit_files", "merge_pull_request", "close_pull_request", "update_pr_branch", "create_release", "create_repo_from_template"},
+	CategoryRerunCI:   {"rerun_failed_jobs", "rerun_workflow", "trigger_workflow"},
+	CategoryJiraWrite: {"create_jira_ticket", "update_jira_issue", "transition_jira_issue", "comment_on_jira_issue"},
+}
+
+// groupMembersTTL is how long Slack user group memberships are cached.
+const groupMembersTTL = 10 * time.Minute
+
+// AccessControl decides which tools a user may run in a channel, from the
+// agent's access rules. Safe for concurrent use.
+type AccessControl struct {
+	defaults []string
+	rules    []prompts.AccessRule
+	// members resolves a Slack user group to its user IDs; nil disables
+	// group rules.
+	members func(groupID string) ([]string, error)
+
+	mu     sync.Mutex
+	groups map[string]cachedGroup
+}
+
+type cachedGroup struct {
+	users   []string
+	fetched time.Time
+}
+
+// NewAccessControl validates an agent's access config. It returns nil when
+// the config has no rules, leaving every tool open.
+func NewAccessControl(cfg prompts.AccessConfig, members func(groupID string) ([]string, error)) (*AccessControl, error) {
+	if len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+	defaults := cfg.Default
+	if len(defaults) == 0 {
+		defaults = []string{CategoryRead}
+	}
+	if err := validateCategories(defaults); err != nil {
+		return nil, fmt.Errorf("access default: %w", err)
+	}
+	for i, r := range cfg.Rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(r.Allow) == 0 {
+			return nil, fmt.Errorf("access rule %s: allow is required", name)
+		}
+		if err := validateCategories(r.Allow); err != nil {
+			return nil, fmt.Errorf("access rule %s: %w", name, err)
+		}
+	}
+	return &AccessControl{defaults: defaults, rules: cfg.Rules, members: members, groups: make(map[string]cachedGroup)}, nil
+}
+
+// validateCategories rejects entries that are neither a category, a known
+// tool nor a pattern, which are most likely typos.
+func validateCategories(entries []string) error {
+	for _, e := range entries {
+		switch {
+		case e == CategoryRead || e == CategoryWrite || categoryTools[e] != nil:
+		case strings.ContainsAny(e, "*?["):
+		case builtinTools.Lookup(e) != nil:
+		default:
+			return fmt.Errorf("unknown category or tool %q (categories: read, write, write-code, rerun-ci, jira-write)", e)
+		}
+	}
+	return nil
+}
+
+// Categories returns the categories and tool patterns userID may use in
+// channelID.
+func (a *AccessControl) Categories(userID, channelID string) []string {
+	out := slices.Clone(a.defaults)
+	for _, r := range a.rules {
+		if a.matches(r, userID, channelID) {
+			out = append(out, r.Allow...)
+		}
+	}
+	return out
+}
+
+// Allows reports whether userID may run def in channelID.
+func (a *AccessControl) Allows(def *ToolDef, userID, channelID string) bool {
+	for _, c := range a.Categories(userID, channelID) {
+		if inCategory(def, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func inCategory(def *ToolDef, category string) bool {
+	switch category {
+	case CategoryRead:
+		return def.Class == ToolRead
+	case CategoryWrite:
+		return def.Class == ToolWrite
+	}
+	if tools, ok := categoryTools[category]; ok {
+		return slices.Contains(tools, def.Name)
+	}
+	return prompts.ToolPolicy{Allow: []string{category}}.Allows(def.Name)
+}
+
+func (a *AccessControl) matches(r prompts.AccessRule, userID, channelID string) bool {
+	if len(r.Channels) > 0 && !slices.Contains(r.Channels, channelID) {
+		return false
+	}
+	if len(r.Users) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+	if slices.Contains(r.Users, userID) {
+		return true
+	}
+	for _, g := range r.Groups {
+		if slices.Contains(a.groupMembers(g), userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupMembers returns the cached members of a Slack user group, refreshing
+// them after groupMembersTTL. On a lookup failure the last known members
+// are kept.
+func (a *AccessControl) groupMembers(groupID string) []string {
+	if a.members == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cached, ok := a.groups[groupID]
+	if ok && time.Since(cached.fetched) < groupMembersTTL {
+		return cached.users
+	}
+	users, err := a.members(groupID)
+	if err != nil {
+		logging.Errorf("[access] failed to resolve user group %s: %v", groupID, err)
+		return cached.users
+	}
+	a.groups[groupID] = cachedGroup{users: users, fetched: time.Now()}
+	return users
+}
+
+// checkAccess enforces the agent's access rules before a tool runs. Returns
+// a non-empty error message when the call must be refused.
+func (h *GeneralHandler) checkAccess(userID, channelID string, def *ToolDef) string {
+	if h.access == nil || h.access.Allows(def, userID, channelID) {
+		return ""
+	}
+	h.logger().Infof("[user=%s channel=%s] blocked %s: not permitted by access rules of agent %s", userID, channelID, def.Name, h.agentID)
+	return fmt.Sprintf("Error: <@%s> is not permitted to use %s in this channel (allowed: %s). Do not retry or work around this with other tools; tell the user to ask an admin for access or to make the change themselves.",
+		userID, def.Name, strings.Join(h.access.Categories(userID, channelID), ", "))
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// ReadOnlySwitch disables every write tool, globally or for single agents,
+// e.g. during an incident or an audit. It starts from READ_ONLY and the
+// agents' read_only settings and can be flipped at runtime through
+// /api/read-only. Safe for concurrent use.
+type ReadOnlySwitch struct {
+	mu     sync.RWMutex
+	global bool
+	agents map[string]bool
+}
+
+// ReadOnlyStatus is the state of a ReadOnlySwitch as served by the API.
+type ReadOnlyStatus struct {
+	Global bool            `json:"global"`
+	Agents map[string]bool `json:"agents"`
+}
+
+// NewReadOnlySwitch creates a switch with the global flag set to global.
+func NewReadOnlySwitch(global bool) *ReadOnlySwitch {
+	return &ReadOnlySwitch{global: global, agents: make(map[string]bool)}
+}
+
+// Set turns read-only mode on or off for agentID, or globally when agentID
+// is empty. An agent is read-only when either flag is on.
+func (s *ReadOnlySwitch) Set(agentID string, on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if agentID == "" {
+		s.global = on
+		return
+	}
+	s.agents[agentID] = on
+}
+
+// Enabled reports whether write tools are disabled for agentID.
+func (s *ReadOnlySwitch) Enabled(agentID string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.global || s.agents[agentID]
+}
+
+// Status returns a snapshot of the global and per-agent flags.
+func (s *ReadOnlySwitch) Status() ReadOnlyStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return ReadOnlyStatus{Global: s.global, Agents: maps.Clone(s.agents)}
+}
+
+// checkReadOnly refuses write tools while the agent is in read-only mode.
+// Returns a non-empty error message when the call must be refused.
+func (h *GeneralHandler) checkReadOnly(userID, channelID string, def *ToolDef) string {
+	if def.Class != ToolWrite || !h.readOnly.Enabled(h.agentID) {
+		return ""
+	}
+	h.logger().Infof("[user=%s channel=%s] blocked %s: agent %s is in read-only mode", userID, channelID, def.Name, h.agentID)
+	return fmt.Sprintf("Error: agent %s is in read-only mode, so %s and every other tool that changes something is disabled. Do not retry or work around this; tell the user what they would need to change themselves.", h.agentID, def.Name)
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// recapInputTokens bounds the transcript sent to the model for a recap.
+const recapInputTokens = 12000
+
+// recapPrompt instructs the model how to recap an ended thread session.
+const recapPrompt = `You write the closing recap of a chat thread in which users worked with an engineering assistant bot. The thread has gone quiet.
+Reply in Slack mrkdwn with at most 8 short lines, in this shape:
+*Asked:* what the users wanted, in one line
+*Done:* what the assistant did or found, with the links (pull requests, tickets, workflow runs, documents) exactly as they appear in the transcript
+*Open:* outstanding items, unanswered questions or follow-ups, with links; "nothing" when all is resolved
+Never invent links, names or results that are not in the transcript.`
+
+// summarizeSession posts a recap of an expired session in its thread and
+// keeps it in memory for later sessions in the same thread.
+func (r *Router) summarizeSession(info SessionInfo) {
+	reqID := logging.NewRequestID()
+	lg := logging.Request(reqID)
+	ctx, cancel := context.WithTimeout(logging.WithRequestID(context.Background(), reqID), 2*time.Minute)
+	defer cancel()
+
+	recap, err := r.modelsClient.Complete(ctx, recapPrompt, keepHeadAndTail(formatTranscript(info.Transcript), recapInputTokens))
+	recap = strings.TrimSpace(recap)
+	if err != nil || recap == "" {
+		lg.Warnf("[agent=%s channel=%s thread=%s] session recap failed: %v", r.agentID, info.ChannelID, info.ThreadTS, err)
+		return
+	}
+	recap = redactText(r.redactor, r.agentID, "recap", recap)
+	r.memory.SetThreadSummary(info.ChannelID, info.ThreadTS, recap)
+
+	msg := fmt.Sprintf("*Session recap* — this thread went quiet, so the session ended. Mention me to pick it back up.\n%s", recap)
+	if err := r.slackClient.PostThreadReply(info.ChannelID, info.ThreadTS, msg); err != nil {
+		lg.Errorf("[agent=%s channel=%s thread=%s] failed to post session recap: %v", r.agentID, info.ChannelID, info.ThreadTS, err)
+		return
+	}
+	r.sessions.Record(info.ChannelID, info.ThreadTS, TranscriptEntry{Kind: TranscriptBot, Text: msg})
+	lg.Infof("[agent=%s channel=%s thread=%s] posted session recap (%d steps)", r.agentID, info.ChannelID, info.ThreadTS, len(info.Transcript))
+}
+
+// formatTranscript renders a session transcript as plain text for the model,
+// with tool results and long messages shortened.
+func formatTranscript(entries []TranscriptEntry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		at := e.Time.UTC().Format("15:04")
+		switch e.Kind {
+		case TranscriptUser:
+			fmt.Fprintf(&sb, "[%s] user <@%s>: %s\n", at, e.UserID, truncateRunes(e.Text, 1000))
+		case TranscriptTool:
+			fmt.Fprintf(&sb, "[%s] tool %s(%s) %s: %s\n", at, e.Tool, truncateRunes(e.Args, 300), e.Outcome, truncateRunes(e.Text, 500))
+		case TranscriptBot:
+			fmt.Fprintf(&sb, "[%s] assistant: %s\n", at, truncateRunes(e.Text, 1500))
+		}
+	}
+	return sb.String()
+}
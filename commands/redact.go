@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/redact"
+)
+
+// redactText masks the secrets in s, counting them under source (context,
+// history, request, workflow_logs, tool_result or output). A nil redactor
+// returns s unchanged.
+func redactText(r *redact.Redactor, agentID, source, s string) string {
+	out, n := r.Redact(s)
+	if n > 0 {
+		logging.Infof("[agent=%s] redacted %d secret(s) from %s", agentID, n, source)
+		metrics.Redactions.Add(float64(n), agentID, source)
+	}
+	return out
+}
+
+// redactingChat masks secrets in everything an agent posts, whichever
+// platform it posts to.
+type redactingChat struct {
+	ChatPlatform
+	redactor *redact.Redactor
+	agentID  string
+}
+
+func (c redactingChat) PostMessage(channelID, text string) (string, error) {
+	return c.ChatPlatform.PostMessage(channelID, redactText(c.redactor, c.agentID, "output", text))
+}
+
+func (c redactingChat) PostThreadReply(channelID, threadTS, text string) error {
+	return c.ChatPlatform.PostThreadReply(channelID, threadTS, redactText(c.redactor, c.agentID, "output", text))
+}
+
+func (c redactingChat) UploadFile(channelID, threadTS, filename, title string, content []byte) error {
+	return c.ChatPlatform.UploadFile(channelID, threadTS, filename, title, []byte(redactText(c.redactor, c.agentID, "output", string(content))))
+}
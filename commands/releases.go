@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// releaseTools compare refs and publish GitHub releases.
+var releaseTools = []*ToolDef{
+	{
+		Name:        "compare_refs",
+		Description: "Compare two branches, tags or commits in a GitHub repository: the commits in head that are not in base and the files changed. Use it for 'what changed between v1.3.0 and v1.4.0', 'what's on staging but not main', and as the input for drafting release notes.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"base":{"type":"string","description":"Older ref (e.g. 'v1.3.0' or 'main')"},
+				"head":{"type":"string","description":"Newer ref (e.g. 'v1.4.0' or a branch)"}
+			},
+			"required":["repo","base","head"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolCompareRefs,
+	},
+	{
+		Name:        "create_release",
+		Description: "Create a GitHub release for a tag, creating the tag from target if it doesn't exist. Write the release notes yourself from compare_refs output (group by features, fixes, and other changes; reference PR numbers), or set generate_notes to let GitHub generate them. Creates a draft unless draft is false; confirm with the user before publishing.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"tag":{"type":"string","description":"Tag name (e.g. 'v1.4.0')"},
+				"target":{"type":"string","description":"Branch or commit SHA to tag when the tag doesn't exist yet (optional, uses the default branch if empty)"},
+				"name":{"type":"string","description":"Release title (optional, defaults to the tag)"},
+				"body":{"type":"string","description":"Release notes in Markdown"},
+				"draft":{"type":"boolean","description":"Create the release as a draft (default: true)"},
+				"prerelease":{"type":"boolean","description":"Mark as a pre-release (default: false)"},
+				"generate_notes":{"type":"boolean","description":"Let GitHub generate notes from merged PRs and append them to body (default: false)"}
+			},
+			"required":["repo","tag"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolCreateRelease,
+	},
+}
+
+// compareListLimit caps the commits and files compare_refs prints.
+const compareListLimit = 150
+
+func (h *GeneralHandler) toolCompareRefs(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo string `json:"repo"`
+		Base string `json:"base"`
+		Head string `json:"head"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Base == "" || args.Head == "" {
+		return "Error: repo, base and head are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	cmp, err := h.ghClient.CompareRefs(ctx, owner, args.Repo, args.Base, args.Head)
+	if err != nil {
+		return fmt.Sprintf("Error comparing refs: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] compared %s/%s %s...%s: %s, %d commits, %d files", call.UserID, call.ChannelID, owner, args.Repo, args.Base, args.Head, cmp.Status, cmp.TotalCommits, len(cmp.Files))
+	return formatComparison(args.Base, args.Head, cmp)
+}
+
+func formatComparison(base, head string, cmp *github.Comparison) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s...%s: %s (%d ahead, %d behind) — %s\n", base, head, cmp.Status, cmp.AheadBy, cmp.BehindBy, cmp.URL)
+	if cmp.Status == "identical" {
+		return sb.String()
+	}
+
+	// Newest first reads better for release notes; GitHub returns oldest first.
+	fmt.Fprintf(&sb, "\nCommits in %s not in %s (%d):\n", head, base, cmp.TotalCommits)
+	shown := 0
+	for i := len(cmp.Commits) - 1; i >= 0 && shown < compareListLimit; i-- {
+		c := cmp.Commits[i]
+		fmt.Fprintf(&sb, "- %s %s (%s, %s)\n", c.SHA[:min(7, len(c.SHA))], c.Message, c.Author, c.Date.UTC().Format("2006-01-02"))
+		shown++
+	}
+	if cmp.TotalCommits > shown {
+		fmt.Fprintf(&sb, "… %d more commits not shown; see the compare URL.\n", cmp.TotalCommits-shown)
+	}
+
+	adds, dels := 0, 0
+	for _, f := range cmp.Files {
+		adds += f.Additions
+		dels += f.Deletions
+	}
+	fmt.Fprintf(&sb, "\nFiles changed (%d, +%d −%d):\n", len(cmp.Files), adds, dels)
+	for i, f := range cmp.Files {
+		if i == compareListLimit {
+			fmt.Fprintf(&sb, "… %d more files not shown.\n", len(cmp.Files)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "- %s (%s, +%d −%d)\n", f.Path, f.Status, f.Additions, f.Deletions)
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolCreateRelease(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo          string `json:"repo"`
+		Tag           string `json:"tag"`
+		Target        string `json:"target"`
+		Name          string `json:"name"`
+		Body          string `json:"body"`
+		Draft         *bool  `json:"draft"`
+		Prerelease    bool   `json:"prerelease"`
+		GenerateNotes bool   `json:"generate_notes"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Tag == "" {
+		return "Error: repo and tag are required."
+	}
+	if strings.TrimSpace(args.Body) == "" && !args.GenerateNotes {
+		return "Error: provide release notes in body (draft them from compare_refs) or set generate_notes."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	exists, err := h.ghClient.ReleaseExists(ctx, owner, args.Repo, args.Tag)
+	if err != nil {
+		return fmt.Sprintf("Error checking existing releases: %v", err)
+	}
+	if exists {
+		return fmt.Sprintf("Error: a release for %s already exists in %s/%s: https://github.com/%s/%s/releases/tag/%s", args.Tag, owner, args.Repo, owner, args.Repo, args.Tag)
+	}
+
+	opts := github.ReleaseOptions{
+		Tag:           args.Tag,
+		Target:        args.Target,
+		Name:          args.Name,
+		Body:          args.Body,
+		Draft:         args.Draft == nil || *args.Draft,
+		Prerelease:    args.Prerelease,
+		GenerateNotes: args.GenerateNotes,
+	}
+	if opts.Name == "" {
+		opts.Name = args.Tag
+	}
+	url, err := h.ghClient.CreateRelease(ctx, owner, args.Repo, opts)
+	if err != nil {
+		return fmt.Sprintf("Error creating release: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] created release %s in %s/%s draft=%t", call.UserID, call.ChannelID, args.Tag, owner, args.Repo, opts.Draft)
+	if opts.Draft {
+		return fmt.Sprintf("Created draft release %s: %s (not published yet; review and publish it on GitHub).", args.Tag, url)
+	}
+	return fmt.Sprintf("Published release %s: %s", args.Tag, url)
+}
@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// activityRetention is how long activity events are kept in memory.
+const activityRetention = 35 * 24 * time.Hour
+
+// Activity event kinds.
+const (
+	ActivityRequest   = "request"    // a user request (slash command or @mention)
+	ActivityChange    = "change"     // a successful write-type tool call
+	ActivityCIFailure = "ci_failure" // a failed workflow run from the GitHub webhook
+)
+
+// ActivityEvent is a single entry in the activity store.
+type ActivityEvent struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	AgentID   string    `json:"agent_id"`
+	ChannelID string    `json:"channel_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Text      string    `json:"text,omitempty"` // request text, or workflow and repo for CI failures
+	Tool      string    `json:"tool,omitempty"`
+	Artifact  string    `json:"artifact,omitempty"`
+}
+
+// ActivityStore keeps per-channel activity for the periodic activity
+// reports. When created with a path, events are appended to that JSONL file
+// and reloaded on startup. Safe for concurrent use.
+type ActivityStore struct {
+	mu     sync.RWMutex
+	path   string
+	events []ActivityEvent
+}
+
+// NewActivityStore creates a store, loading recent events from path when
+// it is non-empty and the file exists.
+func NewActivityStore(path string) (*ActivityStore, error) {
+	s := &ActivityStore{path: path}
+	if path == "" {
+		return s, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open activity store: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	cutoff := time.Now().Add(-activityRetention)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var ev ActivityEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || ev.Time.Before(cutoff) {
+			continue
+		}
+		s.events = append(s.events, ev)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read activity store: %w", err)
+	}
+	return s, nil
+}
+
+// Len returns the number of events held in memory.
+func (s *ActivityStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.events)
+}
+
+// Record stores an event. Write failures are logged, not returned.
+func (s *ActivityStore) Record(ev ActivityEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := time.Now().Add(-activityRetention)
+	i := 0
+	for i < len(s.events) && s.events[i].Time.Before(cutoff) {
+		i++
+	}
+	s.events = append(s.events[i:], ev)
+
+	if s.path == "" {
+		return
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		logging.Errorf("[activity] failed to open %s: %v", s.path, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logging.Errorf("[activity] failed to record event: %v", err)
+	}
+}
+
+// Events returns events newer than since in the given channels (all
+// channels when empty), oldest first.
+func (s *ActivityStore) Events(channels []string, since time.Time) []ActivityEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []ActivityEvent
+	for _, ev := range s.events {
+		if ev.Time.Before(since) {
+			continue
+		}
+		if len(channels) > 0 && !slices.Contains(channels, ev.ChannelID) {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+// reportTopQuestions is how many recurring questions a report lists.
+const reportTopQuestions = 5
+
+// PostActivityReport posts a summary of the last days of activity in
+// sources (default: channelID) to channelID.
+func (r *Router) PostActivityReport(name, channelID string, sources []string, days int) {
+	if r.activity == nil {
+		logging.Infof("[agent=%s channel=%s] activity report %q skipped: no activity store", r.agentID, channelID, name)
+		return
+	}
+	if len(sources) == 0 {
+		sources = []string{channelID}
+	}
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+	ctx := github.WithAttribution(context.Background(), github.Attribution{AgentID: r.agentID, UserID: ScheduledUserID, ChannelID: channelID})
+	report := r.buildActivityReport(ctx, r.activity.Events(sources, since))
+
+	var channels []string
+	for _, c := range sources {
+		channels = append(channels, fmt.Sprintf("<#%s>", c))
+	}
+	header := fmt.Sprintf(":bar_chart: *%s* — %s, %s to %s\n\n", name, strings.Join(channels, ", "), since.Format("Jan 2"), now.Format("Jan 2"))
+	if _, err := r.slackClient.PostMessage(channelID, header+report); err != nil {
+		logging.Errorf("[agent=%s channel=%s] failed to post activity report %q: %v", r.agentID, channelID, name, err)
+		return
+	}
+	logging.Infof("[agent=%s channel=%s] posted activity report %q", r.agentID, channelID, name)
+}
+
+// buildActivityReport renders bot PRs and merges, tickets opened and closed,
+// CI failures by workflow, and the top recurring questions.
+func (r *Router) buildActivityReport(ctx context.Context, events []ActivityEvent) string {
+	var (
+		prs, tickets, questions []string
+		ciTotal                 int
+		ciFailures              = make(map[string]int)
+	)
+	for _, ev := range events {
+		switch ev.Kind {
+		case ActivityRequest:
+			questions = append(questions, ev.Text)
+		case ActivityCIFailure:
+			ciTotal++
+			ciFailures[ev.Text]++
+		case ActivityChange:
+			switch {
+			case ev.Tool == "create_jira_ticket" && strings.Contains(ev.Artifact, "/browse/"):
+				if !slices.Contains(tickets, ev.Artifact) {
+					tickets = append(tickets, ev.Artifact)
+				}
+			case strings.Contains(ev.Artifact, "/pull/"):
+				if !slices.Contains(prs, ev.Artifact) {
+					prs = append(prs, ev.Artifact)
+				}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*Pull requests:* %d opened by the bot", len(prs))
+	if merged, ok := r.countMergedPRs(ctx, prs); ok {
+		fmt.Fprintf(&sb, ", %d merged", merged)
+	}
+	fmt.Fprintf(&sb, "\n*Jira tickets:* %d opened by the bot", len(tickets))
+	if closed, ok := r.countClosedTickets(tickets); ok {
+		fmt.Fprintf(&sb, ", %d closed", closed)
+	}
+
+	fmt.Fprintf(&sb, "\n*CI failures:* %d", ciTotal)
+	if len(ciFailures) > 0 {
+		type bucket struct {
+			name  string
+			count int
+		}
+		var buckets []bucket
+		for name, n := range ciFailures {
+			buckets = append(buckets, bucket{name, n})
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].count != buckets[j].count {
+				return buckets[i].count > buckets[j].count
+			}
+			return buckets[i].name < buckets[j].name
+		})
+		for _, b := range buckets {
+			fmt.Fprintf(&sb, "\n  • %s — %d", b.name, b.count)
+		}
+	}
+
+	fmt.Fprintf(&sb, "\n*Requests:* %d", len(questions))
+	if top := r.topQuestions(ctx, questions); top != "" {
+		sb.WriteString("\n*Top recurring questions:*\n" + top)
+	}
+	return sb.String()
+}
+
+// countMergedPRs returns how many of the PR URLs have been merged. ok is
+// false when GitHub isn't available.
+func (r *Router) countMergedPRs(ctx context.Context, prURLs []string) (merged int, ok bool) {
+	if r.ghClient == nil {
+		return 0, false
+	}
+	states, err := r.ghClient.MergedPullRequests(ctx, prURLs)
+	if err == nil {
+		for _, isMerged := range states {
+			if isMerged {
+				merged++
+			}
+		}
+		return merged, true
+	}
+	logging.Ctx(ctx).Warnf("[agent=%s] activity report: bulk PR lookup failed, checking one by one: %v", r.agentID, err)
+	for _, u := range prURLs {
+		owner, repo, number, err := github.ParsePRURL(u)
+		if err != nil {
+			continue
+		}
+		isMerged, err := r.ghClient.IsPullRequestMerged(ctx, owner, repo, number)
+		if err != nil {
+			logging.Ctx(ctx).Errorf("[agent=%s] activity report: failed to check %s: %v", r.agentID, u, err)
+			continue
+		}
+		if isMerged {
+			merged++
+		}
+	}
+	return merged, true
+}
+
+// countClosedTickets returns how many of the tickets (browse URLs) are in a
+// done status. ok is false when Jira isn't configured or the search fails.
+func (r *Router) countClosedTickets(browseURLs []string) (closed int, ok bool) {
+	if r.jiraClient == nil || len(browseURLs) == 0 {
+		return 0, r.jiraClient != nil
+	}
+	keys := make([]string, 0, len(browseURLs))
+	for _, u := range browseURLs {
+		keys = append(keys, u[strings.LastIndex(u, "/")+1:])
+	}
+	jql := fmt.Sprintf("key in (%s) AND statusCategory = Done", strings.Join(keys, ","))
+	issues, err := r.jiraClient.SearchIssuesJQL(jql, len(keys))
+	if err != nil {
+		logging.Errorf("[agent=%s] activity report: Jira search failed: %v", r.agentID, err)
+		return 0, false
+	}
+	return len(issues), true
+}
+
+// topQuestions groups similar requests with the LLM and lists the most
+// frequent ones. Falls back to exact-match counts if the LLM call fails.
+func (r *Router) topQuestions(ctx context.Context, questions []string) string {
+	if len(questions) < 2 {
+		return ""
+	}
+	var list strings.Builder
+	for _, q := range questions {
+		fmt.Fprintf(&list, "- %s\n", strings.Join(strings.Fields(q), " "))
+	}
+	system := fmt.Sprintf("You group user requests sent to an engineering assistant bot. Merge requests that ask essentially the same thing. "+
+		"Return only the %d most frequent groups that occur at least twice, as a Slack bullet list: "+
+		"\"• <short representative question> — <count>\". If nothing recurs, return exactly: none", reportTopQuestions)
+	out, err := r.modelsClient.Complete(ctx, system, list.String())
+	if err == nil {
+		out = strings.TrimSpace(out)
+		if strings.EqualFold(out, "none") {
+			return ""
+		}
+		return out
+	}
+	logging.Ctx(ctx).Warnf("[agent=%s] activity report: question grouping failed, using exact matches: %v", r.agentID, err)
+
+	counts := make(map[string]int)
+	for _, q := range questions {
+		counts[strings.ToLower(strings.Join(strings.Fields(q), " "))]++
+	}
+	var recurring []string
+	for q, n := range counts {
+		if n > 1 {
+			recurring = append(recurring, q)
+		}
+	}
+	sort.Slice(recurring, func(i, j int) bool {
+		if counts[recurring[i]] != counts[recurring[j]] {
+			return counts[recurring[i]] > counts[recurring[j]]
+		}
+		return recurring[i] < recurring[j]
+	})
+	if len(recurring) > reportTopQuestions {
+		recurring = recurring[:reportTopQuestions]
+	}
+	var sb strings.Builder
+	for _, q := range recurring {
+		fmt.Fprintf(&sb, "• %s — %d\n", q, counts[q])
+	}
+	return strings.TrimSpace(sb.String())
+}
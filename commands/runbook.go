@@ -0,0 +1,344 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/prompts"
+)
+
+// runbookPlaceholder matches a {{param}} reference in a step argument.
+var runbookPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// maxRunbookStepOutput caps how much of each step's result is reported.
+const maxRunbookStepOutput = 3000
+
+// Runbook is an operator-defined sequence of tool calls run by name. Steps
+// go through the same policy checks as the LLM's own tool calls, and the run
+// pauses before every step marked for approval until a human in the thread
+// approves it.
+type Runbook struct {
+	Name        string
+	Description string
+	params      []runbookParam
+	steps       []prompts.RunbookStep
+}
+
+type runbookParam struct {
+	prompts.RunbookParam
+	pattern *regexp.Regexp
+}
+
+// NewRunbooks validates and builds the runbooks from an agent's config.
+func NewRunbooks(cfgs []prompts.RunbookConfig) ([]*Runbook, error) {
+	var out []*Runbook
+	seen := make(map[string]bool)
+	for _, c := range cfgs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("runbook: name is required")
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("runbook %q: defined twice", c.Name)
+		}
+		seen[c.Name] = true
+		if len(c.Steps) == 0 {
+			return nil, fmt.Errorf("runbook %q: no steps", c.Name)
+		}
+
+		rb := &Runbook{Name: c.Name, Description: c.Description, steps: c.Steps}
+		declared := make(map[string]bool)
+		for _, p := range c.Params {
+			if p.Name == "" {
+				return nil, fmt.Errorf("runbook %q: parameter without a name", c.Name)
+			}
+			rp := runbookParam{RunbookParam: p}
+			if p.Pattern != "" {
+				re, err := regexp.Compile(`^(?:` + p.Pattern + `)$`)
+				if err != nil {
+					return nil, fmt.Errorf("runbook %q: parameter %s: invalid pattern: %w", c.Name, p.Name, err)
+				}
+				if p.Default != "" && !re.MatchString(p.Default) {
+					return nil, fmt.Errorf("runbook %q: parameter %s: default does not match its pattern", c.Name, p.Name)
+				}
+				rp.pattern = re
+			}
+			declared[p.Name] = true
+			rb.params = append(rb.params, rp)
+		}
+
+		for i, s := range c.Steps {
+			if s.Tool == "list_runbooks" || s.Tool == "run_runbook" {
+				return nil, fmt.Errorf("runbook %q step %d: runbooks cannot run other runbooks", c.Name, i+1)
+			}
+			if builtinTools.Lookup(s.Tool) == nil {
+				return nil, fmt.Errorf("runbook %q step %d: unknown tool %q", c.Name, i+1, s.Tool)
+			}
+			if gatedTools[s.Tool] && !s.Approval {
+				return nil, fmt.Errorf("runbook %q step %d: %s needs approval: true", c.Name, i+1, s.Tool)
+			}
+			for _, ref := range placeholders(s.Args) {
+				if !declared[ref] {
+					return nil, fmt.Errorf("runbook %q step %d: {{%s}} is not a declared parameter", c.Name, i+1, ref)
+				}
+			}
+		}
+		out = append(out, rb)
+	}
+	return out, nil
+}
+
+// resolve checks the caller's parameter values against the runbook's
+// declarations and fills in defaults.
+func (rb *Runbook) resolve(given map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(rb.params))
+	known := make(map[string]bool, len(rb.params))
+	for _, p := range rb.params {
+		known[p.Name] = true
+		v := strings.TrimSpace(given[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			return nil, fmt.Errorf("parameter %s is required", p.Name)
+		}
+		if v != "" && p.pattern != nil && !p.pattern.MatchString(v) {
+			return nil, fmt.Errorf("parameter %s=%q does not match %s", p.Name, v, p.Pattern)
+		}
+		values[p.Name] = v
+	}
+	for name := range given {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+	return values, nil
+}
+
+// stepArgs renders a step's arguments as JSON with the parameters filled in.
+func stepArgs(step prompts.RunbookStep, values map[string]string) (string, error) {
+	if step.Args == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(expandArgs(step.Args, values))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func expandArgs(v any, values map[string]string) any {
+	switch v := v.(type) {
+	case string:
+		return runbookPlaceholder.ReplaceAllStringFunc(v, func(m string) string {
+			return values[runbookPlaceholder.FindStringSubmatch(m)[1]]
+		})
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = expandArgs(e, values)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = expandArgs(e, values)
+		}
+		return out
+	}
+	return v
+}
+
+// placeholders returns the parameter names referenced in args.
+func placeholders(v any) []string {
+	var refs []string
+	switch v := v.(type) {
+	case string:
+		for _, m := range runbookPlaceholder.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, m[1])
+		}
+	case map[string]any:
+		for _, e := range v {
+			refs = append(refs, placeholders(e)...)
+		}
+	case []any:
+		for _, e := range v {
+			refs = append(refs, placeholders(e)...)
+		}
+	}
+	return refs
+}
+
+func stepLabel(step prompts.RunbookStep) string {
+	if step.Name != "" {
+		return step.Name + " (" + step.Tool + ")"
+	}
+	return step.Tool
+}
+
+// runbookTools let users list and run the agent's runbooks. They are
+// registered in init because run_runbook executes other builtin tools.
+var runbookTools = []*ToolDef{
+	{
+		Name:        "list_runbooks",
+		Description: "List the runbooks operators defined for this agent: their names, parameters and steps, and which steps need approval.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).runbooksConfigured,
+		Run:         (*GeneralHandler).toolListRunbooks,
+	},
+	{
+		Name:        "run_runbook",
+		Description: "Run an operator-defined runbook by name (e.g. \"run the rotate-api-key runbook for payments\"). Steps run in order exactly as defined, always from the first; do not substitute your own tool calls for them. The run pauses before steps that need approval and continues from there when someone replies \"approve\" in this thread: post what the paused step will do and do not call run_runbook again to resume it.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","description":"Runbook name from list_runbooks"},
+				"params":{"type":"object","additionalProperties":{"type":"string"},"description":"Parameter values by name"}
+			},
+			"required":["name"]
+		}`),
+		Available: (*GeneralHandler).runbooksConfigured,
+		Run:       (*GeneralHandler).toolRunRunbook,
+	},
+}
+
+func init() {
+	builtinTools.Register(runbookTools...)
+}
+
+func (h *GeneralHandler) runbooksConfigured() bool { return len(h.runbooks) > 0 }
+
+func (h *GeneralHandler) runbook(name string) *Runbook {
+	for _, rb := range h.runbooks {
+		if strings.EqualFold(rb.Name, name) {
+			return rb
+		}
+	}
+	return nil
+}
+
+func (h *GeneralHandler) toolListRunbooks(_ context.Context, _ ToolCall) string {
+	var sb strings.Builder
+	for _, rb := range h.runbooks {
+		fmt.Fprintf(&sb, "*%s*", rb.Name)
+		if rb.Description != "" {
+			fmt.Fprintf(&sb, " — %s", rb.Description)
+		}
+		sb.WriteString("\n")
+		for _, p := range rb.params {
+			fmt.Fprintf(&sb, "  param %s", p.Name)
+			switch {
+			case p.Required:
+				sb.WriteString(" (required)")
+			case p.Default != "":
+				fmt.Fprintf(&sb, " (default %q)", p.Default)
+			}
+			if p.Description != "" {
+				fmt.Fprintf(&sb, ": %s", p.Description)
+			}
+			sb.WriteString("\n")
+		}
+		for i, s := range rb.steps {
+			fmt.Fprintf(&sb, "  %d. %s", i+1, stepLabel(s))
+			if s.Approval {
+				sb.WriteString(" [needs approval]")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolRunRunbook(ctx context.Context, call ToolCall) string {
+	var args runbookRun
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	// A run resumes only where it paused, after a human approved that step;
+	// the model always starts at step 1.
+	approved := h.approvedRun("run_runbook", call)
+	if args.ResumeAt != 0 && !approved {
+		return "Error: a paused runbook resumes only when someone replies \"approve\" in the thread. Do not call run_runbook to resume it."
+	}
+	rb := h.runbook(args.Name)
+	if rb == nil {
+		return fmt.Sprintf("Error: no runbook named %q. Use list_runbooks to see the available runbooks.", args.Name)
+	}
+	values, err := rb.resolve(args.Params)
+	if err != nil {
+		return fmt.Sprintf("Error: runbook %s: %v.", rb.Name, err)
+	}
+	start := max(args.ResumeAt, 1)
+	if start > len(rb.steps) {
+		return fmt.Sprintf("Error: runbook %s has only %d steps.", rb.Name, len(rb.steps))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Runbook %s", rb.Name)
+	if start > 1 {
+		fmt.Fprintf(&sb, " (resumed at step %d)", start)
+	}
+	sb.WriteString(":\n")
+	for i := start - 1; i < len(rb.steps); i++ {
+		step := rb.steps[i]
+		n := i + 1
+		stepJSON, err := stepArgs(step, values)
+		if err != nil {
+			return fmt.Sprintf("Error: runbook %s step %d: %v", rb.Name, n, err)
+		}
+		if step.Approval {
+			if !(n == start && approved) {
+				logging.Ctx(ctx).Infof("[user=%s channel=%s] runbook %s paused before step %d/%d for approval", call.UserID, call.ChannelID, rb.Name, n, len(rb.steps))
+				return h.pauseRunbook(ctx, call, rb, values, n, sb.String(), stepJSON)
+			}
+			// The human approved this step, which covers the gated tool it calls.
+			h.approved = &PendingApproval{Tool: step.Tool, Args: stepJSON}
+		}
+
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] runbook %s step %d/%d: %s(%s)", call.UserID, call.ChannelID, rb.Name, n, len(rb.steps), step.Tool, stepJSON)
+		result, outcome := h.executeToolOutcome(ctx, call.ChannelID, call.UserID, call.AuditTS, step.Tool, stepJSON)
+		h.approved = nil
+		metrics.ToolCalls.Inc(h.agentID, step.Tool)
+		failed := strings.HasPrefix(result, "Error")
+		if failed {
+			metrics.ToolErrors.Inc(h.agentID, step.Tool)
+		}
+		h.recordChange(call.ChannelID, call.UserID, step.Tool, stepJSON, result, outcome)
+
+		if len(result) > maxRunbookStepOutput {
+			result = result[:maxRunbookStepOutput] + "\n… (truncated)"
+		}
+		fmt.Fprintf(&sb, "\nStep %d: %s\n%s\n", n, stepLabel(step), result)
+		if failed && !step.ContinueOnError {
+			fmt.Fprintf(&sb, "\nRunbook stopped: step %d failed. Report the failure to the user; do not run the remaining steps yourself.", n)
+			return sb.String()
+		}
+	}
+	sb.WriteString("\nRunbook completed.")
+	return sb.String()
+}
+
+// runbookRun is the run_runbook arguments. ResumeAt is never set by the
+// model: a paused run stages it for approval, and only the approved call
+// carries it.
+type runbookRun struct {
+	Name     string            `json:"name"`
+	Params   map[string]string `json:"params"`
+	ResumeAt int               `json:"resume_at,omitempty"`
+}
+
+// pauseRunbook stages the rest of the run, from step n, for a human to
+// approve in the thread. done is the report of the steps run so far.
+func (h *GeneralHandler) pauseRunbook(ctx context.Context, call ToolCall, rb *Runbook, values map[string]string, n int, done, stepJSON string) string {
+	resume, err := json.Marshal(runbookRun{Name: rb.Name, Params: values, ResumeAt: n})
+	if err != nil {
+		return fmt.Sprintf("Error: runbook %s: %v", rb.Name, err)
+	}
+	summary := fmt.Sprintf("Runbook %s, step %d of %d: %s with:\n%s", rb.Name, n, len(rb.steps), stepLabel(rb.steps[n-1]), stepJSON)
+	call.Args = string(resume)
+	return done + "\n" + h.requestApproval(ctx, call, "run_runbook", summary)
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// scaffoldTools create new repositories from template repositories.
+var scaffoldTools = []*ToolDef{
+	{
+		Name:        "create_repo_from_template",
+		Description: "Create a new GitHub repository from a template repository (e.g. 'spin up a new service repo payments-api from service-template'), set its visibility, give teams access, and commit initial customizations (service name in README, CODEOWNERS, CI config, ...) straight to its default branch in one commit. Read the template's files with get_file_content first to write the customizations. Confirm the name, template, visibility and teams with the user before creating.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Name of the new repository (without owner)"},
+				"template":{"type":"string","description":"Template repository name (without owner), or owner/name for a template outside the organization"},
+				"description":{"type":"string","description":"Repository description (optional)"},
+				"visibility":{"type":"string","enum":["private","internal","public"],"description":"Visibility (default: private)"},
+				"teams":{"type":"array","description":"Teams to give access (optional)","items":{
+					"type":"object",
+					"properties":{
+						"team":{"type":"string","description":"Team slug or name"},
+						"permission":{"type":"string","enum":["pull","triage","push","maintain","admin"],"description":"Permission (default: push)"}
+					},
+					"required":["team"]
+				}},
+				"files":{"type":"array","description":"Initial customizations, committed to the new repository's default branch (optional)","items":{
+					"type":"object",
+					"properties":{
+						"path":{"type":"string","description":"File path within the repository"},
+						"content":{"type":"string","description":"Complete new file content"},
+						"old_content":{"type":"string","description":"Exact text in the template's file to replace"},
+						"new_content":{"type":"string","description":"Replacement for old_content"},
+						"delete":{"type":"boolean","description":"Delete the file"}
+					},
+					"required":["path"]
+				}}
+			},
+			"required":["repo","template"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolCreateRepoFromTemplate,
+	},
+}
+
+// repoNameRe matches the names GitHub accepts for repositories.
+var repoNameRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+func (h *GeneralHandler) toolCreateRepoFromTemplate(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Template    string `json:"template"`
+		Description string `json:"description"`
+		Visibility  string `json:"visibility"`
+		Teams       []struct {
+			Team       string `json:"team"`
+			Permission string `json:"permission"`
+		} `json:"teams"`
+		Files []fileEdit `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if !repoNameRe.MatchString(args.Repo) || args.Repo == "." || args.Repo == ".." {
+		return fmt.Sprintf("Error: %q is not a valid repository name (letters, digits, '.', '-' and '_' only).", args.Repo)
+	}
+	if args.Template == "" {
+		return "Error: template is required."
+	}
+	switch args.Visibility {
+	case "":
+		args.Visibility = "private"
+	case "private", "internal", "public":
+	default:
+		return fmt.Sprintf("Error: visibility must be private, internal or public, not %q.", args.Visibility)
+	}
+
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	templateOwner, templateRepo := owner, args.Template
+	if o, r, ok := strings.Cut(args.Template, "/"); ok {
+		templateOwner, templateRepo = o, r
+	}
+
+	// Resolve teams and customizations against the template before creating
+	// anything, so a bad argument leaves no half-configured repository.
+	teamSlugs := make([]string, len(args.Teams))
+	for i, t := range args.Teams {
+		if teamSlugs[i], err = h.resolveTeamSlug(ctx, owner, t.Team); err != nil {
+			return fmt.Sprintf("Error resolving team %q: %v", t.Team, err)
+		}
+		if args.Teams[i].Permission == "" {
+			args.Teams[i].Permission = "push"
+		}
+	}
+	var changes []github.FileChange
+	if len(args.Files) > 0 {
+		var errMsg string
+		if changes, errMsg = h.resolveFileEdits(ctx, templateOwner, templateRepo, "", args.Files); errMsg != "" {
+			return errMsg
+		}
+	}
+
+	url, branch, err := h.ghClient.CreateRepoFromTemplate(ctx, templateOwner, templateRepo, owner, github.NewRepoOptions{
+		Name:        args.Repo,
+		Description: args.Description,
+		Visibility:  args.Visibility,
+	})
+	if err != nil {
+		if url != "" {
+			return fmt.Sprintf("Repository created at %s, but setup stopped: %v. No teams were added and no customizations committed; tell the user.", url, err)
+		}
+		return fmt.Sprintf("Error creating repository: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] created %s/%s (%s) from template %s/%s", call.UserID, call.ChannelID, owner, args.Repo, args.Visibility, templateOwner, templateRepo)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Created %s repository %s/%s from %s/%s: %s\n", args.Visibility, owner, args.Repo, templateOwner, templateRepo, url)
+	var failed bool
+	for i, t := range args.Teams {
+		if err := h.ghClient.AddTeamToRepo(ctx, owner, teamSlugs[i], args.Repo, t.Permission); err != nil {
+			fmt.Fprintf(&b, "- Error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(&b, "- Team %s: %s\n", teamSlugs[i], t.Permission)
+	}
+	if len(changes) > 0 {
+		message := fmt.Sprintf("%s: initial setup from %s", h.agentID, templateRepo)
+		if err := h.ghClient.CommitFiles(ctx, owner, args.Repo, branch, message, changes); err != nil {
+			fmt.Fprintf(&b, "- Error committing customizations: %v\n", err)
+			failed = true
+		} else {
+			fmt.Fprintf(&b, "- Committed %d customized files to %s\n", len(changes), branch)
+		}
+	}
+	if failed {
+		b.WriteString("Some setup steps failed; the repository exists. Tell the user which steps to finish by hand, or retry them with commit_files (which opens a PR).")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -1,9 +1,11 @@
 package commands
 
 import (
-	"log"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/justmike1/ovad/logging"
 )
 
 // DefaultSessionTTL is used when no custom TTL is provided.
@@ -15,21 +17,35 @@ const DefaultSessionTTL = 3 * time.Minute
 type ThreadSession struct {
 	ChannelID string
 	ThreadTS  string
-	UserID    string
+	UserID    string // user who started the session
 	AgentID   string
 	Router    *Router
 	CreatedAt time.Time
 	LastSeen  time.Time
 
-	mu    sync.Mutex
-	timer *time.Timer
+	mu        sync.Mutex
+	timer     *time.Timer
+	keepUntil time.Time // set by Extend; the session outlives its TTL until then
+	// participants are the users who posted in the thread during the
+	// session, in order of their first message, starting with UserID.
+	participants []string
+	transcript   []TranscriptEntry
+	endedAt      time.Time // zero while active
+	endReason    string
 }
 
 // SessionStore tracks active thread sessions. Safe for concurrent use.
 type SessionStore struct {
 	mu       sync.RWMutex
 	sessions map[string]*ThreadSession // key: "channelID:threadTS"
+	recent   []*ThreadSession          // ended sessions, oldest first
 	ttl      time.Duration
+	path     string     // state file set by Restore; "" = in memory only
+	saveMu   sync.Mutex // serializes writes of the state file
+
+	// summaryMinSteps is the transcript length from which an expired
+	// session gets a recap in its thread; 0 disables recaps.
+	summaryMinSteps int
 
 	// Observability counters
 	counterMu     sync.Mutex
@@ -53,6 +69,12 @@ func sessionKey(channelID, threadTS string) string {
 	return channelID + ":" + threadTS
 }
 
+// SetSummaryMinSteps makes sessions that expire with at least n transcript
+// steps post a recap in their thread. 0 disables recaps.
+func (s *SessionStore) SetSummaryMinSteps(n int) {
+	s.summaryMinSteps = n
+}
+
 // TTL returns the configured session time-to-live.
 func (s *SessionStore) TTL() time.Duration {
 	return s.ttl
@@ -64,23 +86,25 @@ func (s *SessionStore) Open(channelID, threadTS, userID, agentID string, router
 	key := sessionKey(channelID, threadTS)
 
 	s.mu.Lock()
+	defer s.save()
 	defer s.mu.Unlock()
 
 	if existing, ok := s.sessions[key]; ok {
 		existing.refresh(s.ttl)
-		log.Printf("[session] refreshed channel=%s thread=%s user=%s agent=%s ttl=%s",
+		logging.Infof("[session] refreshed channel=%s thread=%s user=%s agent=%s ttl=%s",
 			channelID, threadTS, userID, agentID, s.ttl)
 		return
 	}
 
 	sess := &ThreadSession{
-		ChannelID: channelID,
-		ThreadTS:  threadTS,
-		UserID:    userID,
-		AgentID:   agentID,
-		Router:    router,
-		CreatedAt: time.Now(),
-		LastSeen:  time.Now(),
+		ChannelID:    channelID,
+		ThreadTS:     threadTS,
+		UserID:       userID,
+		AgentID:      agentID,
+		Router:       router,
+		CreatedAt:    time.Now(),
+		LastSeen:     time.Now(),
+		participants: []string{userID},
 	}
 
 	sess.timer = time.AfterFunc(s.ttl, func() {
@@ -93,7 +117,7 @@ func (s *SessionStore) Open(channelID, threadTS, userID, agentID string, router
 	s.totalOpened++
 	s.counterMu.Unlock()
 
-	log.Printf("[session] opened channel=%s thread=%s user=%s agent=%s ttl=%s",
+	logging.Infof("[session] opened channel=%s thread=%s user=%s agent=%s ttl=%s",
 		channelID, threadTS, userID, agentID, s.ttl)
 }
 
@@ -111,6 +135,7 @@ func (s *SessionStore) Lookup(channelID, threadTS string) *ThreadSession {
 	}
 
 	sess.refresh(s.ttl)
+	s.save()
 	return sess
 }
 
@@ -125,19 +150,78 @@ func (s *SessionStore) Close(channelID, threadTS, reason string) {
 		sess.timer.Stop()
 		sess.mu.Unlock()
 		delete(s.sessions, key)
+		s.retireLocked(sess, reason)
 	}
 	s.mu.Unlock()
 
 	if ok {
+		s.save()
 		duration := time.Since(sess.CreatedAt).Round(time.Millisecond)
 		s.counterMu.Lock()
 		s.totalExplicit++
 		s.counterMu.Unlock()
-		log.Printf("[session] closed channel=%s thread=%s reason=%q duration=%s",
+		logging.Infof("[session] closed channel=%s thread=%s reason=%q duration=%s",
 			channelID, threadTS, reason, duration)
 	}
 }
 
+// Extend keeps a thread's session open for at least d, beyond its usual
+// TTL, and returns when it will expire if left idle. ok is false when the
+// thread has no session.
+func (s *SessionStore) Extend(channelID, threadTS string, d time.Duration) (until time.Time, ok bool) {
+	s.mu.RLock()
+	sess, ok := s.sessions[sessionKey(channelID, threadTS)]
+	s.mu.RUnlock()
+	if !ok {
+		return time.Time{}, false
+	}
+
+	until = time.Now().Add(d)
+	sess.mu.Lock()
+	sess.keepUntil = until
+	sess.mu.Unlock()
+	sess.refresh(s.ttl)
+	s.save()
+
+	logging.Infof("[session] extended channel=%s thread=%s until=%s", channelID, threadTS, until.Format(time.RFC3339))
+	return until, true
+}
+
+// CloseAll closes every session, or only agentID's when it is non-empty,
+// and returns how many were closed.
+func (s *SessionStore) CloseAll(agentID, reason string) int {
+	s.mu.RLock()
+	var matched []*ThreadSession
+	for _, sess := range s.sessions {
+		if agentID == "" || sess.AgentID == agentID {
+			matched = append(matched, sess)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sess := range matched {
+		s.Close(sess.ChannelID, sess.ThreadTS, reason)
+	}
+	return len(matched)
+}
+
+// ForUser returns the active sessions started by userID, most recently used first.
+func (s *SessionStore) ForUser(userID string) []*ThreadSession {
+	s.mu.RLock()
+	var out []*ThreadSession
+	for _, sess := range s.sessions {
+		if sess.UserID == userID {
+			out = append(out, sess)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].lastSeen().After(out[j].lastSeen())
+	})
+	return out
+}
+
 // ActiveCount returns the number of currently active sessions.
 func (s *SessionStore) ActiveCount() int {
 	s.mu.RLock()
@@ -164,10 +248,20 @@ func (s *SessionStore) expire(key string, sess *ThreadSession) {
 	s.mu.Lock()
 	// Only delete if the map entry still points to the same session object
 	// (guards against a race with Close or re-Open).
+	expired := false
 	if current, ok := s.sessions[key]; ok && current == sess {
 		delete(s.sessions, key)
+		s.retireLocked(sess, "expired")
+		expired = true
 	}
 	s.mu.Unlock()
+	s.save()
+
+	if expired && s.summaryMinSteps > 0 && sess.Router != nil {
+		if info := sess.info(true); len(info.Transcript) >= s.summaryMinSteps {
+			go sess.Router.summarizeSession(info)
+		}
+	}
 
 	duration := time.Since(sess.CreatedAt).Round(time.Millisecond)
 
@@ -175,14 +269,30 @@ func (s *SessionStore) expire(key string, sess *ThreadSession) {
 	s.totalExpired++
 	s.counterMu.Unlock()
 
-	log.Printf("[session] expired channel=%s thread=%s user=%s agent=%s duration=%s",
+	logging.Infof("[session] expired channel=%s thread=%s user=%s agent=%s duration=%s",
 		sess.ChannelID, sess.ThreadTS, sess.UserID, sess.AgentID, duration)
 }
 
-// refresh resets the session timer and updates LastSeen.
+// refresh resets the session timer and updates LastSeen. An extended
+// session keeps its timer until at least keepUntil.
 func (sess *ThreadSession) refresh(ttl time.Duration) {
 	sess.mu.Lock()
 	defer sess.mu.Unlock()
-	sess.timer.Reset(ttl)
+	sess.timer.Reset(max(ttl, time.Until(sess.keepUntil)))
 	sess.LastSeen = time.Now()
 }
+
+// Participants returns the users who posted in the thread during the
+// session, starting with the one who opened it.
+func (sess *ThreadSession) Participants() []string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return append([]string(nil), sess.participants...)
+}
+
+// lastSeen returns LastSeen under the session lock.
+func (sess *ThreadSession) lastSeen() time.Time {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.LastSeen
+}
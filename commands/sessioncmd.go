@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+const (
+	// defaultSessionExtension is how long "keep this open" keeps a session
+	// when no duration is given.
+	defaultSessionExtension = time.Hour
+	// maxSessionExtension bounds how long a thread can ask to stay open.
+	maxSessionExtension = 8 * time.Hour
+)
+
+// sessionExtendRe matches "keep this open for an hour", "leave the session
+// open", "extend the session by 30 minutes" and similar.
+var sessionExtendRe = regexp.MustCompile(`^(?:please |can you |could you )?(?:(?:keep|leave|hold) (?:this|it|the|this thread|the thread|this session|the session) open|extend (?:this |the )?session)(?: (?:for|by) (an?|one|\d+) ?(m|mins?|minutes?|h|hrs?|hours?))?(?: please)?$`)
+
+// parseSessionCommand recognizes a thread message that closes or extends
+// the session. It returns "close" or "extend" with the extension, or "".
+func parseSessionCommand(text string) (string, time.Duration) {
+	text = strings.Trim(strings.TrimSpace(strings.ToLower(text)), ".!?")
+	switch text {
+	case "done", "all done", "i'm done", "im done", "we're done", "were done", "we are done",
+		"close", "close session", "close this session", "close the session",
+		"end session", "end this session", "end the session":
+		return "close", 0
+	}
+	m := sessionExtendRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", 0
+	}
+	if m[1] == "" {
+		return "extend", defaultSessionExtension
+	}
+	n := 1
+	if v, err := strconv.Atoi(m[1]); err == nil {
+		n = v
+	}
+	unit := time.Minute
+	if strings.HasPrefix(m[2], "h") {
+		unit = time.Hour
+	}
+	return "extend", min(time.Duration(n)*unit, maxSessionExtension)
+}
+
+// handleSessionCommand closes or extends the thread's session when text asks
+// for it, confirms in the thread and reports whether it did.
+func (r *Router) handleSessionCommand(channelID, threadTS, userID, text string) bool {
+	if r.sessions == nil {
+		return false
+	}
+	action, d := parseSessionCommand(text)
+	var reply string
+	switch action {
+	case "close":
+		reply = fmt.Sprintf("Session closed. Mention me or use `/%s` to start a new one.", r.agentID)
+	case "extend":
+		until, ok := r.sessions.Extend(channelID, threadTS, d)
+		if !ok {
+			return false
+		}
+		reply = fmt.Sprintf("I'll keep this thread open until %s (%s), even when it's quiet. Say \"done\" to close it sooner.",
+			until.UTC().Format("15:04 UTC"), formatExtension(d))
+	default:
+		return false
+	}
+	logging.Infof("[agent=%s user=%s channel=%s thread=%s] session %s requested", r.agentID, userID, channelID, threadTS, action)
+	if err := r.slackClient.PostThreadReply(channelID, threadTS, reply); err != nil {
+		logging.Errorf("failed to confirm session %s in thread: %v", action, err)
+	}
+	r.sessions.Record(channelID, threadTS, TranscriptEntry{Kind: TranscriptBot, Text: reply})
+	if action == "close" {
+		r.sessions.Close(channelID, threadTS, "closed by "+userID)
+	}
+	return true
+}
+
+// formatExtension renders an extension like "1h" or "30m".
+func formatExtension(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
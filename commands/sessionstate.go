@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/justmike1/ovad/fsutil"
+	"github.com/justmike1/ovad/logging"
+)
+
+// persistedSession is the on-disk form of an active ThreadSession. The
+// router is not saved; it is looked up again by agent and platform.
+type persistedSession struct {
+	ChannelID    string    `json:"channel_id"`
+	ThreadTS     string    `json:"thread_ts"`
+	UserID       string    `json:"user_id"`
+	AgentID      string    `json:"agent_id"`
+	Platform     string    `json:"platform"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastSeen     time.Time `json:"last_seen"`
+	KeepUntil    time.Time `json:"keep_until"`
+	Participants []string  `json:"participants,omitempty"`
+}
+
+// sessionStateFile is the on-disk form of a SessionStore.
+type sessionStateFile struct {
+	Sessions []persistedSession `json:"sessions"`
+}
+
+// Restore loads the sessions saved in path that are still within their TTL
+// and from then on saves the active sessions to path on every change, so
+// thread follow-ups keep working across restarts. resolve returns the router
+// of an agent on a chat platform ("slack", "teams", "discord"), or nil when
+// it no longer exists. Returns how many sessions were restored; a missing
+// file restores none.
+func (s *SessionStore) Restore(path string, resolve func(agentID, platform string) *Router) (int, error) {
+	s.mu.Lock()
+	s.path = path
+	s.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read session state: %w", err)
+	}
+	var f sessionStateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return 0, fmt.Errorf("failed to parse session state %s: %w", path, err)
+	}
+
+	restored := 0
+	s.mu.Lock()
+	for _, p := range f.Sessions {
+		remaining := max(s.ttl-time.Since(p.LastSeen), time.Until(p.KeepUntil))
+		if remaining <= 0 {
+			continue
+		}
+		key := sessionKey(p.ChannelID, p.ThreadTS)
+		if _, ok := s.sessions[key]; ok {
+			continue
+		}
+		router := resolve(p.AgentID, p.Platform)
+		if router == nil {
+			logging.Warnf("[session] not restoring channel=%s thread=%s: agent %q on %s is gone", p.ChannelID, p.ThreadTS, p.AgentID, p.Platform)
+			continue
+		}
+		sess := &ThreadSession{
+			ChannelID:    p.ChannelID,
+			ThreadTS:     p.ThreadTS,
+			UserID:       p.UserID,
+			AgentID:      p.AgentID,
+			Router:       router,
+			CreatedAt:    p.CreatedAt,
+			LastSeen:     p.LastSeen,
+			keepUntil:    p.KeepUntil,
+			participants: p.Participants,
+		}
+		sess.timer = time.AfterFunc(remaining, func() {
+			s.expire(key, sess)
+		})
+		s.sessions[key] = sess
+		restored++
+		logging.Infof("[session] restored channel=%s thread=%s user=%s agent=%s remaining=%s",
+			p.ChannelID, p.ThreadTS, p.UserID, p.AgentID, remaining.Round(time.Second))
+	}
+	s.mu.Unlock()
+
+	s.save()
+	return restored, nil
+}
+
+// save writes the active sessions to a temporary file and renames it over
+// the state file, so a crash never leaves a truncated file. Failures are
+// logged; the in-memory sessions stay authoritative. No-op without Restore.
+func (s *SessionStore) save() {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	path := s.path
+	if path == "" {
+		s.mu.RUnlock()
+		return
+	}
+	f := sessionStateFile{Sessions: make([]persistedSession, 0, len(s.sessions))}
+	for _, sess := range s.sessions {
+		sess.mu.Lock()
+		f.Sessions = append(f.Sessions, persistedSession{
+			ChannelID:    sess.ChannelID,
+			ThreadTS:     sess.ThreadTS,
+			UserID:       sess.UserID,
+			AgentID:      sess.AgentID,
+			Platform:     sess.Router.Platform(),
+			CreatedAt:    sess.CreatedAt,
+			LastSeen:     sess.LastSeen,
+			KeepUntil:    sess.keepUntil,
+			Participants: append([]string(nil), sess.participants...),
+		})
+		sess.mu.Unlock()
+	}
+	s.mu.RUnlock()
+	sort.Slice(f.Sessions, func(i, j int) bool { return f.Sessions[i].LastSeen.Before(f.Sessions[j].LastSeen) })
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		logging.Warnf("[session] failed to encode session state: %v", err)
+		return
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		logging.Warnf("[session] failed to save session state: %v", err)
+	}
+}
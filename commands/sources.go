@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+)
+
+// maxFooterSources bounds how many sources are listed under an answer.
+const maxFooterSources = 8
+
+// resultURLRe matches the "URL: ..." line the record tools (PRs, tickets,
+// work items, incidents, pages) put in their results.
+var resultURLRe = regexp.MustCompile(`(?m)^URL: (\S+)`)
+
+// sourceArgs are the tool arguments that identify what was read.
+type sourceArgs struct {
+	Repo     string `json:"repo"`
+	Path     string `json:"path"`
+	Branch   string `json:"branch"`
+	Number   int    `json:"number"`
+	URL      string `json:"url"`
+	IssueKey string `json:"issue_key"`
+	CVEID    string `json:"cve_id"`
+	ID       int    `json:"id"`
+	Incident string `json:"incident"`
+	Page     string `json:"page"`
+	Base     string `json:"base"`
+	Head     string `json:"head"`
+}
+
+// sourceLabels turn a successful read tool call into the label shown in the
+// Sources footer. Tools not listed here (searches, listings, writes) don't
+// back a claim on their own and are left out.
+var sourceLabels = map[string]func(a sourceArgs, resultURL string) string{
+	"get_file_content": fileSource,
+	"git_blame":        fileSource,
+	"compare_refs": func(a sourceArgs, _ string) string {
+		return fmt.Sprintf("`%s` %s...%s", a.Repo, a.Base, a.Head)
+	},
+	"get_pull_request": func(a sourceArgs, u string) string {
+		if a.Number == 0 {
+			return linkOr(u, "PR")
+		}
+		return linkOr(u, fmt.Sprintf("%s#%d", a.Repo, a.Number))
+	},
+	"get_workflow_run": func(a sourceArgs, _ string) string {
+		return linkOr(a.URL, "workflow run")
+	},
+	"get_jira_issue": func(a sourceArgs, u string) string {
+		return linkOr(u, a.IssueKey)
+	},
+	"get_ado_work_item": func(a sourceArgs, u string) string {
+		return linkOr(u, fmt.Sprintf("ADO #%d", a.ID))
+	},
+	"get_pagerduty_incident": func(a sourceArgs, u string) string {
+		return linkOr(u, "incident "+a.Incident)
+	},
+	"read_notion_page": func(_ sourceArgs, u string) string {
+		return linkOr(u, "Notion page")
+	},
+	"lookup_cve": func(a sourceArgs, _ string) string {
+		return linkOr("https://nvd.nist.gov/vuln/detail/"+a.CVEID, a.CVEID)
+	},
+}
+
+func fileSource(a sourceArgs, _ string) string {
+	branch := a.Branch
+	if branch == "" {
+		branch = "default branch"
+	}
+	return fmt.Sprintf("`%s/%s` @ %s", a.Repo, strings.TrimPrefix(a.Path, "/"), branch)
+}
+
+// linkOr renders a Slack link to u labelled label, or just the label when
+// there is no URL.
+func linkOr(u, label string) string {
+	if u == "" {
+		return label
+	}
+	return fmt.Sprintf("<%s|%s>", u, label)
+}
+
+// sourcesFromMessages lists what an answer was built from — files read,
+// pull requests, tickets, workflow runs and pages fetched by tool calls
+// that succeeded — in the order they were first used.
+func sourcesFromMessages(messages []github.ChatMessage) []string {
+	results := make(map[string]string)
+	for _, m := range messages {
+		if m.Role == "tool" {
+			results[m.ToolCallID] = m.Content
+		}
+	}
+	var sources []string
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		for _, tc := range m.ToolCalls {
+			label, ok := sourceLabels[tc.Function.Name]
+			result, done := results[tc.ID]
+			if !ok || !done || strings.HasPrefix(result, "Error") {
+				continue
+			}
+			var args sourceArgs
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				continue
+			}
+			var u string
+			if match := resultURLRe.FindStringSubmatch(result); match != nil {
+				u = match[1]
+			}
+			if s := label(args, u); s != "" && !seen[s] {
+				seen[s] = true
+				sources = append(sources, s)
+			}
+		}
+	}
+	return sources
+}
+
+// sourcesFooter renders sources as a compact footer appended to an answer.
+func sourcesFooter(sources []string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	shown := sources[:min(len(sources), maxFooterSources)]
+	footer := "\n\n_Sources:_ " + strings.Join(shown, " · ")
+	if extra := len(sources) - len(shown); extra > 0 {
+		footer += fmt.Sprintf(" · _+%d more_", extra)
+	}
+	return footer
+}
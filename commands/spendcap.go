@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// SpendLimits are the daily budgets per user and per channel. A zero limit
+// is unlimited.
+type SpendLimits struct {
+	UserTokens     int `json:"user_tokens"`
+	UserActions    int `json:"user_actions"`
+	ChannelTokens  int `json:"channel_tokens"`
+	ChannelActions int `json:"channel_actions"`
+}
+
+// Any reports whether at least one limit is set.
+func (l SpendLimits) Any() bool {
+	return l.UserTokens > 0 || l.UserActions > 0 || l.ChannelTokens > 0 || l.ChannelActions > 0
+}
+
+// DailySpend is what a user or channel used today: LLM tokens (prompt and
+// completion) and tool calls.
+type DailySpend struct {
+	Tokens  int `json:"tokens"`
+	Actions int `json:"actions"`
+}
+
+// SpendCaps enforces daily token and tool-call budgets per user and per
+// channel, so one user's runaway automation can't burn the whole LLM budget.
+// Counters reset at midnight UTC; an admin can exempt a user or channel for
+// the rest of the day. When created with a path, every count and exemption
+// is appended to that JSONL file and today's are replayed on startup, so a
+// restart doesn't reset the caps. It implements github.UsageRecorder. A nil
+// *SpendCaps enforces nothing. Safe for concurrent use.
+type SpendCaps struct {
+	mu       sync.Mutex
+	limits   SpendLimits
+	file     jsonlFile[spendEvent]
+	day      string // YYYY-MM-DD (UTC) the counters are for
+	users    map[string]*DailySpend
+	channels map[string]*DailySpend
+	exempt   map[string]bool // "user:<id>" or "channel:<id>", for day only
+}
+
+// SpendCapStatus is the state of the caps as served by the API.
+type SpendCapStatus struct {
+	Day      string                `json:"day"`
+	ResetsAt time.Time             `json:"resets_at"`
+	Limits   SpendLimits           `json:"limits"`
+	Users    map[string]DailySpend `json:"users"`
+	Channels map[string]DailySpend `json:"channels"`
+	Exempt   []string              `json:"exempt"`
+}
+
+// spendEvent is one line of the spend cap file: tokens or tool calls used,
+// or an exemption lifted or restored.
+type spendEvent struct {
+	Time      time.Time `json:"time"`
+	UserID    string    `json:"user_id,omitempty"`
+	ChannelID string    `json:"channel_id,omitempty"`
+	Tokens    int       `json:"tokens,omitempty"`
+	Actions   int       `json:"actions,omitempty"`
+	Exempt    string    `json:"exempt,omitempty"`  // "user:<id>" or "channel:<id>"
+	Restore   bool      `json:"restore,omitempty"` // Exempt was removed
+}
+
+// NewSpendCaps creates caps with limits, or returns nil when none is set.
+// When path is non-empty and the file exists, today's counters and
+// exemptions are loaded from it.
+func NewSpendCaps(limits SpendLimits, path string) (*SpendCaps, error) {
+	if !limits.Any() {
+		return nil, nil
+	}
+	c := &SpendCaps{limits: limits, file: jsonlFile[spendEvent]{path: path, name: "spend cap file", tag: "spendcap"}}
+	c.resetLocked(today())
+	if path == "" {
+		return c, nil
+	}
+	events, err := c.file.load(func(e spendEvent) bool { return e.Time.UTC().Format("2006-01-02") == c.day })
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		c.applyLocked(e)
+	}
+	return c, nil
+}
+
+// applyLocked adds e to the counters or exemptions. The caller holds c.mu.
+func (c *SpendCaps) applyLocked(e spendEvent) {
+	if e.Exempt != "" {
+		if e.Restore {
+			delete(c.exempt, e.Exempt)
+		} else {
+			c.exempt[e.Exempt] = true
+		}
+		return
+	}
+	if e.UserID != "" {
+		u := spendFor(c.users, e.UserID)
+		u.Tokens += e.Tokens
+		u.Actions += e.Actions
+	}
+	if e.ChannelID != "" {
+		ch := spendFor(c.channels, e.ChannelID)
+		ch.Tokens += e.Tokens
+		ch.Actions += e.Actions
+	}
+}
+
+// recordLocked applies e and appends it to the file. The caller holds c.mu.
+func (c *SpendCaps) recordLocked(e spendEvent) {
+	e.Time = time.Now().UTC()
+	c.applyLocked(e)
+	if c.file.path != "" {
+		c.file.append(e)
+	}
+}
+
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+// rolloverLocked starts new counters when the UTC day changed, and empties
+// the file of the previous day's. The caller holds c.mu.
+func (c *SpendCaps) rolloverLocked() {
+	if d := today(); d != c.day {
+		c.resetLocked(d)
+		if c.file.path != "" {
+			if err := c.file.rewrite(nil); err != nil {
+				logging.Warnf("[spendcap] %v", err)
+			}
+		}
+	}
+}
+
+func (c *SpendCaps) resetLocked(day string) {
+	c.day = day
+	c.users = make(map[string]*DailySpend)
+	c.channels = make(map[string]*DailySpend)
+	c.exempt = make(map[string]bool)
+}
+
+func spendFor(m map[string]*DailySpend, key string) *DailySpend {
+	s, ok := m[key]
+	if !ok {
+		s = &DailySpend{}
+		m[key] = s
+	}
+	return s
+}
+
+// RecordUsage implements github.UsageRecorder.
+func (c *SpendCaps) RecordUsage(a github.Attribution, _ string, u github.Usage) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rolloverLocked()
+	if a.UserID == "" && a.ChannelID == "" {
+		return
+	}
+	c.recordLocked(spendEvent{UserID: a.UserID, ChannelID: a.ChannelID, Tokens: u.PromptTokens + u.CompletionTokens})
+}
+
+// RecordAction counts one tool call for userID and channelID.
+func (c *SpendCaps) RecordAction(userID, channelID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rolloverLocked()
+	if userID == "" && channelID == "" {
+		return
+	}
+	c.recordLocked(spendEvent{UserID: userID, ChannelID: channelID, Actions: 1})
+}
+
+// Check returns a message for the user when userID or channelID has used up
+// a daily budget, or "" when the request may go ahead.
+func (c *SpendCaps) Check(userID, channelID string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rolloverLocked()
+
+	var what string
+	if u := c.users[userID]; u != nil && !c.exempt["user:"+userID] {
+		switch {
+		case c.limits.UserTokens > 0 && u.Tokens >= c.limits.UserTokens:
+			what = fmt.Sprintf("your daily budget of %d tokens", c.limits.UserTokens)
+		case c.limits.UserActions > 0 && u.Actions >= c.limits.UserActions:
+			what = fmt.Sprintf("your daily budget of %d tool calls", c.limits.UserActions)
+		}
+	}
+	if ch := c.channels[channelID]; what == "" && ch != nil && !c.exempt["channel:"+channelID] {
+		switch {
+		case c.limits.ChannelTokens > 0 && ch.Tokens >= c.limits.ChannelTokens:
+			what = fmt.Sprintf("this channel's daily budget of %d tokens", c.limits.ChannelTokens)
+		case c.limits.ChannelActions > 0 && ch.Actions >= c.limits.ChannelActions:
+			what = fmt.Sprintf("this channel's daily budget of %d tool calls", c.limits.ChannelActions)
+		}
+	}
+	if what == "" {
+		return ""
+	}
+	resume := time.Until(nextMidnightUTC()).Round(time.Minute)
+	return fmt.Sprintf(":money_with_wings: Budget exceeded: %s is used up. It resumes at midnight UTC (in %s); an admin can lift it for today if this is urgent.", what, resume)
+}
+
+// Exempt lifts the caps of a "user" or "channel" for the rest of the UTC
+// day, or restores them when on is false.
+func (c *SpendCaps) Exempt(kind, id string, on bool) error {
+	if kind != "user" && kind != "channel" {
+		return fmt.Errorf("unknown kind %q: must be user or channel", kind)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rolloverLocked()
+	c.recordLocked(spendEvent{Exempt: kind + ":" + id, Restore: !on})
+	return nil
+}
+
+// Status returns today's limits, counters and exemptions.
+func (c *SpendCaps) Status() SpendCapStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rolloverLocked()
+	st := SpendCapStatus{
+		Day:      c.day,
+		ResetsAt: nextMidnightUTC(),
+		Limits:   c.limits,
+		Users:    make(map[string]DailySpend, len(c.users)),
+		Channels: make(map[string]DailySpend, len(c.channels)),
+		Exempt:   []string{},
+	}
+	for k, v := range c.users {
+		st.Users[k] = *v
+	}
+	for k, v := range c.channels {
+		st.Channels[k] = *v
+	}
+	for k := range c.exempt {
+		st.Exempt = append(st.Exempt, k)
+	}
+	sort.Strings(st.Exempt)
+	return st
+}
+
+func nextMidnightUTC() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// overSpendCap tells the user, through notify, that their daily budget is
+// used up and reports whether the request must be dropped.
+func (r *Router) overSpendCap(kind, channelID, userID string, notify func(msg string)) bool {
+	msg := r.spendCaps.Check(userID, channelID)
+	if msg == "" {
+		return false
+	}
+	logging.Infof("[agent=%s user=%s channel=%s] dropped %s: daily budget exceeded", r.agentID, userID, channelID, kind)
+	if notify != nil {
+		notify(msg)
+	}
+	return true
+}
+
+// checkSpendCap refuses tool calls once the user or channel has used up a
+// daily budget, so a long tool loop stops mid-run.
+func (h *GeneralHandler) checkSpendCap(userID, channelID string) string {
+	msg := h.spendCaps.Check(userID, channelID)
+	if msg == "" {
+		return ""
+	}
+	h.logger().Infof("[user=%s channel=%s] blocked tool call: daily budget exceeded", userID, channelID)
+	return "Error: " + msg + " Stop calling tools and tell the user."
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/jira"
+	"github.com/justmike1/ovad/logging"
+)
+
+// botTicketMarker appears in the description stamp create_jira_ticket adds,
+// and identifies tickets the bot created.
+const botTicketMarker = "via Arbetern"
+
+// jiraKeyRe matches Jira issue keys ("ENG-123") in PR titles, bodies and
+// branch names. Branch names are upper-cased before matching.
+var jiraKeyRe = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b`)
+
+// IssueSync mirrors state between bot-created Jira tickets and the GitHub
+// pull requests that reference them: PR activity is commented on the ticket,
+// and ticket status changes are commented on the PR. Links are learned from
+// pull_request webhook deliveries and kept in memory. Safe for concurrent use.
+type IssueSync struct {
+	ghClient   *github.Client
+	jiraClient *jira.Client
+
+	mu    sync.Mutex
+	links map[string][]string // Jira key → PR URLs
+}
+
+// NewIssueSync creates a sync between the given GitHub and Jira clients.
+func NewIssueSync(ghClient *github.Client, jiraClient *jira.Client) *IssueSync {
+	return &IssueSync{ghClient: ghClient, jiraClient: jiraClient, links: make(map[string][]string)}
+}
+
+// HandlePullRequest links a pull_request event to the bot-created tickets
+// it references and comments PR opens, merges and closes on them.
+func (s *IssueSync) HandlePullRequest(ev *github.WebhookEvent) {
+	var note string
+	switch {
+	case ev.Action == "opened" || ev.Action == "reopened":
+		note = fmt.Sprintf("Pull request %s was %s by %s: %s", ev.URL, ev.Action, ev.Sender, ev.Title)
+	case ev.Action == "closed" && ev.Merged:
+		note = fmt.Sprintf("Pull request %s was merged by %s.", ev.URL, ev.Sender)
+	case ev.Action == "closed":
+		note = fmt.Sprintf("Pull request %s was closed without merging by %s.", ev.URL, ev.Sender)
+	case ev.Action == "edited":
+		// Only links newly referenced tickets.
+	default:
+		return
+	}
+
+	for _, key := range referencedKeys(ev.Title, ev.Body, strings.ToUpper(ev.Branch)) {
+		isNew, ok := s.link(key, ev.URL)
+		if !ok {
+			continue
+		}
+		text := note
+		if text == "" {
+			if !isNew {
+				continue
+			}
+			text = fmt.Sprintf("Pull request %s now references this ticket: %s", ev.URL, ev.Title)
+		}
+		if err := s.jiraClient.AddComment(key, text); err != nil {
+			logging.Errorf("[sync] failed to comment on %s for %s: %v", key, ev.URL, err)
+			continue
+		}
+		logging.Infof("[sync] %s %s → %s", ev.URL, ev.Action, key)
+	}
+}
+
+// HandleJiraIssue comments a linked ticket's status change on its pull requests.
+func (s *IssueSync) HandleJiraIssue(ev *jira.WebhookEvent) {
+	if !ev.StatusChanged() {
+		return
+	}
+	s.mu.Lock()
+	prs := slices.Clone(s.links[ev.IssueKey])
+	s.mu.Unlock()
+
+	for _, u := range prs {
+		owner, repo, number, err := github.ParsePRURL(u)
+		if err != nil {
+			continue
+		}
+		body := fmt.Sprintf("Jira [%s](%s/browse/%s) moved from **%s** to **%s** by %s.",
+			ev.IssueKey, strings.TrimRight(s.jiraClient.SiteURL(), "/"), ev.IssueKey, ev.FromStatus, ev.ToStatus, ev.Actor)
+		if err := s.ghClient.CommentOnPullRequest(context.Background(), owner, repo, number, body); err != nil {
+			logging.Errorf("[sync] failed to comment on %s for %s: %v", u, ev.IssueKey, err)
+			continue
+		}
+		logging.Infof("[sync] %s status %q → %s", ev.IssueKey, ev.ToStatus, u)
+	}
+}
+
+// link records that prURL references key when key is a bot-created ticket.
+// isNew reports whether the link was added by this call.
+func (s *IssueSync) link(key, prURL string) (isNew, ok bool) {
+	s.mu.Lock()
+	linked := slices.Contains(s.links[key], prURL)
+	s.mu.Unlock()
+	if linked {
+		return false, true
+	}
+
+	issue, err := s.jiraClient.GetIssue(key)
+	if err != nil || !strings.Contains(issue.Description, botTicketMarker) {
+		return false, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.Contains(s.links[key], prURL) {
+		return false, true
+	}
+	s.links[key] = append(s.links[key], prURL)
+	return true, true
+}
+
+// referencedKeys returns the distinct Jira keys found in texts.
+func referencedKeys(texts ...string) []string {
+	var keys []string
+	for _, t := range texts {
+		for _, k := range jiraKeyRe.FindAllString(t, -1) {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tableInlineRows is the most rows shown inline. Larger results are attached
+// to the thread as a CSV file, with only the first rows shown.
+const tableInlineRows = 15
+
+// tableCellWidth truncates inline cells so tables stay readable on mobile.
+const tableCellWidth = 40
+
+// Table is tabular tool output. The first Inline columns are rendered in
+// Slack; the remaining ones (URLs, descriptions, ...) only go to the CSV.
+// Inline 0 renders every column.
+type Table struct {
+	Name    string // CSV file name stem, e.g. "jira-issues"
+	Columns []string
+	Rows    [][]string
+	Inline  int
+}
+
+// Render returns up to maxRows rows as an aligned, monospaced Slack table.
+func (t *Table) Render(maxRows int) string {
+	cols := len(t.Columns)
+	if t.Inline > 0 && t.Inline < cols {
+		cols = t.Inline
+	}
+	rows := t.Rows
+	if maxRows > 0 && len(rows) > maxRows {
+		rows = rows[:maxRows]
+	}
+
+	cell := func(row []string, i int) string {
+		if i >= len(row) {
+			return ""
+		}
+		v := strings.Join(strings.Fields(row[i]), " ")
+		if utf8.RuneCountInString(v) > tableCellWidth {
+			v = string([]rune(v)[:tableCellWidth-1]) + "…"
+		}
+		return v
+	}
+	widths := make([]int, cols)
+	for i := 0; i < cols; i++ {
+		widths[i] = utf8.RuneCountInString(t.Columns[i])
+		for _, r := range rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell(r, i)))
+		}
+	}
+
+	var sb strings.Builder
+	line := func(values func(i int) string) {
+		for i := 0; i < cols; i++ {
+			v := values(i)
+			sb.WriteString(v)
+			if i < cols-1 {
+				sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)+2))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+	line(func(i int) string { return t.Columns[i] })
+	line(func(i int) string { return strings.Repeat("-", widths[i]) })
+	for _, r := range rows {
+		line(func(i int) string { return cell(r, i) })
+	}
+	sb.WriteString("```")
+	return sb.String()
+}
+
+// CSV returns all rows and columns as CSV.
+func (t *Table) CSV() []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(t.Columns)
+	_ = w.WriteAll(t.Rows)
+	return buf.Bytes()
+}
+
+// presentTable formats tabular tool output for the LLM to relay. Small
+// tables are returned whole; large ones are uploaded to the request's thread
+// as CSV and only the first tableInlineRows rows are returned.
+func (h *GeneralHandler) presentTable(call ToolCall, summary string, t *Table) string {
+	const relay = "Show the table below to the user as-is, inside its code block, rather than rewriting it as a list."
+	if len(t.Rows) <= tableInlineRows {
+		return fmt.Sprintf("%s\n%s\n%s", summary, relay, t.Render(0))
+	}
+
+	filename := fmt.Sprintf("%s-%s.csv", t.Name, time.Now().UTC().Format("20060102-150405"))
+	if err := h.slackClient.UploadFile(call.ChannelID, call.AuditTS, filename, summary, t.CSV()); err != nil {
+		h.logger().Errorf("[user=%s channel=%s] failed to upload %s: %v", call.UserID, call.ChannelID, filename, err)
+		return fmt.Sprintf("%s\n%s\n%s", summary, relay, t.Render(0))
+	}
+	h.logger().Infof("[user=%s channel=%s] attached %d-row table as %s", call.UserID, call.ChannelID, len(t.Rows), filename)
+	return fmt.Sprintf("%s\nThe full results (%d rows, all columns) were attached to the thread as %s — tell the user. %s Only the first %d rows are shown:\n%s",
+		summary, len(t.Rows), filename, relay, tableInlineRows, t.Render(tableInlineRows))
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// threadCompactPrompt instructs the model how to fold older thread turns into
+// the thread's running summary.
+const threadCompactPrompt = `You maintain the running summary of a long chat thread between users and an engineering assistant bot, so the bot can keep following the thread after older messages are dropped.
+You get the current summary (possibly empty) and the next older messages. Reply with the updated summary only, in at most 15 short lines of plain text:
+- who asked for what (keep the <@USERID> mentions)
+- what the assistant did or found, with the links, IDs, names and numbers exactly as they appear
+- decisions made and anything still open
+Drop greetings, repetition and detail that no longer matters. Never invent facts, links or names.`
+
+// compactThreadHistory folds the older half of the thread's history into its
+// summary once the history is longer than the configured limit, so long
+// threads keep their gist within the context window.
+func (r *Router) compactThreadHistory(reqID, channelID, threadTS string) {
+	if r.threadMaxTurns <= 0 {
+		return
+	}
+	c, ok := r.memory.BeginThreadCompaction(channelID, threadTS, r.threadMaxTurns)
+	if !ok {
+		return
+	}
+	lg := logging.Request(reqID)
+	ctx, cancel := context.WithTimeout(logging.WithRequestID(context.Background(), reqID), 2*time.Minute)
+	defer cancel()
+
+	var in strings.Builder
+	fmt.Fprintf(&in, "Current summary:\n%s\n\nOlder messages:\n", c.summary)
+	for _, t := range c.turns {
+		fmt.Fprintf(&in, "<@%s>: %s\n", t.Speaker, truncateRunes(t.User, 1000))
+		if t.Assistant != "" {
+			fmt.Fprintf(&in, "Assistant: %s\n", truncateRunes(t.Assistant, 1500))
+		}
+	}
+	input := redactText(r.redactor, r.agentID, "history", keepHeadAndTail(in.String(), recapInputTokens))
+
+	summary, err := r.modelsClient.Complete(ctx, threadCompactPrompt, input)
+	summary = strings.TrimSpace(summary)
+	if err != nil || summary == "" {
+		lg.Warnf("[agent=%s channel=%s thread=%s] thread history summary failed, keeping the raw turns: %v", r.agentID, channelID, threadTS, err)
+		r.memory.FinishThreadCompaction(channelID, threadTS, c, "")
+		return
+	}
+	r.memory.FinishThreadCompaction(channelID, threadTS, c, summary)
+	lg.Infof("[agent=%s channel=%s thread=%s] summarized %d older thread turns", r.agentID, channelID, threadTS, len(c.turns))
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+const (
+	// defaultContextTokenBudget is used when no budget is configured. It
+	// leaves headroom below the 128k window of the smallest supported models.
+	defaultContextTokenBudget = 100000
+
+	// messageOverheadTokens approximates per-message framing (role, separators).
+	messageOverheadTokens = 4
+
+	// minTrimmedTokens is the smallest size a section or tool result is cut to.
+	minTrimmedTokens = 200
+
+	// estimateHeadroomPercent is the share of the budget left unused to
+	// absorb the error of estimateTokens.
+	estimateHeadroomPercent = 10
+)
+
+// estimateTokens estimates the BPE token count of s as produced by
+// cl100k/o200k-style tokenizers: runs of letters average ~4 characters per
+// token, digits ~3, and each punctuation or non-ASCII symbol is roughly one
+// token. It is a guess from character counts, not a tokenizer: budgets keep
+// estimateHeadroomPercent free for its error, and the tool loop rescales it
+// with the prompt size the provider reports (see toolLoop.calibrate).
+func estimateTokens(s string) int {
+	tokens := 0
+	letters, digits := 0, 0
+	flush := func() {
+		tokens += (letters + 3) / 4
+		tokens += (digits + 2) / 3
+		letters, digits = 0, 0
+	}
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLetter(r):
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		case unicode.IsDigit(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// estimateMessagesTokens returns the estimated prompt size of a conversation.
+func estimateMessagesTokens(messages []github.ChatMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += messageOverheadTokens + estimateTokens(m.Content)
+		for _, tc := range m.ToolCalls {
+			total += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// estimateToolsTokens returns the estimated size of the tool definitions.
+func estimateToolsTokens(tools []github.Tool) int {
+	total := 0
+	for _, t := range tools {
+		total += estimateTokens(t.Function.Name) + estimateTokens(t.Function.Description) + estimateTokens(string(t.Function.Parameters))
+	}
+	return total
+}
+
+// keepTail trims s to roughly maxTokens by dropping whole lines from the
+// start (the oldest messages or earliest log lines), keeping the most recent.
+func keepTail(s string, maxTokens int) string {
+	if estimateTokens(s) <= maxTokens {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	kept, used := 0, 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		t := estimateTokens(lines[i]) + 1
+		if used+t > maxTokens {
+			break
+		}
+		used += t
+		kept++
+	}
+	dropped := len(lines) - kept
+	return fmt.Sprintf("[... %d earlier line(s) truncated to fit the context window ...]\n%s",
+		dropped, strings.Join(lines[dropped:], "\n"))
+}
+
+// keepHeadAndTail trims s to roughly maxTokens, keeping its beginning and end
+// (where file headers, error summaries, and final results usually are).
+func keepHeadAndTail(s string, maxTokens int) string {
+	total := estimateTokens(s)
+	if total <= maxTokens || total == 0 {
+		return s
+	}
+	// Tokens map roughly linearly to bytes; cut by byte ratio on rune boundaries.
+	keep := len(s) * maxTokens / total / 2
+	head := s[:keep]
+	for !utf8.ValidString(head) && len(head) > 0 {
+		head = head[:len(head)-1]
+	}
+	tail := s[len(s)-keep:]
+	for !utf8.ValidString(tail) && len(tail) > 0 {
+		tail = tail[1:]
+	}
+	return fmt.Sprintf("%s\n[... about %d tokens (estimated) truncated to fit the context window ...]\n%s", head, total-maxTokens, tail)
+}
+
+// fitSections trims the given context sections (oldest lines first, largest
+// section first) until their combined size fits within budget tokens.
+func fitSections(budget int, sections ...*string) {
+	for {
+		total, largest, largestSize := 0, -1, 0
+		for i, s := range sections {
+			n := estimateTokens(*s)
+			total += n
+			if n > largestSize && n > minTrimmedTokens {
+				largest, largestSize = i, n
+			}
+		}
+		if total <= budget || largest < 0 {
+			return
+		}
+		target := largestSize - (total - budget)
+		if target < minTrimmedTokens {
+			target = minTrimmedTokens
+		}
+		*sections[largest] = keepTail(*sections[largest], target)
+		if estimateTokens(*sections[largest]) >= largestSize {
+			return // no progress possible (e.g. a single huge line)
+		}
+	}
+}
+
+// trimMessagesToBudget shrinks the largest tool results in messages until the
+// conversation (plus toolTokens for tool definitions) fits within budget.
+// The system prompt, user text, and tool-call structure are never removed.
+func trimMessagesToBudget(messages []github.ChatMessage, toolTokens, budget int) {
+	for {
+		total := estimateMessagesTokens(messages) + toolTokens
+		if total <= budget {
+			return
+		}
+		largest, largestSize := -1, minTrimmedTokens
+		for i, m := range messages {
+			if m.Role != "tool" {
+				continue
+			}
+			if n := estimateTokens(m.Content); n > largestSize {
+				largest, largestSize = i, n
+			}
+		}
+		if largest < 0 {
+			logging.Infof("[tokens] conversation is an estimated %d tokens (budget %d) and nothing is left to trim", total, budget)
+			return
+		}
+		target := largestSize - (total - budget)
+		if target < minTrimmedTokens {
+			target = minTrimmedTokens
+		}
+		messages[largest].Content = keepHeadAndTail(messages[largest].Content, target)
+		logging.Infof("[tokens] trimmed tool result from an estimated %d to %d tokens (budget %d)", largestSize, target, budget)
+	}
+}
@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// ToolClass is the permission class of a tool.
+type ToolClass int
+
+const (
+	// ToolRead tools only read from external systems.
+	ToolRead ToolClass = iota
+	// ToolWrite tools change state in an external system (GitHub, Jira,
+	// Slack). Successful calls are recorded in the ChangeLedger.
+	ToolWrite
+)
+
+// ToolCall is a single tool invocation requested by the LLM.
+type ToolCall struct {
+	ChannelID string
+	UserID    string
+	AuditTS   string // thread of the request; "" outside Slack
+	Args      string // raw JSON arguments
+}
+
+// ToolFunc executes a tool call and returns the result text for the LLM.
+// Failures are reported as text starting with "Error".
+type ToolFunc func(h *GeneralHandler, ctx context.Context, call ToolCall) string
+
+// ToolDef is a self-describing tool: the schema shown to the LLM, the
+// executor, and the metadata used to enforce agent policy.
+type ToolDef struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+	Class       ToolClass
+	// RepoAccess is "read" or "write" for tools that target a single
+	// repository, checked against the agent's repo policy before Run.
+	RepoAccess string
+	// Available reports whether the tool can be offered, typically because
+	// its integration is configured. nil means always available.
+	Available func(h *GeneralHandler) bool
+	Run       ToolFunc
+	// Preview describes what a write tool would do without doing it, for
+	// dry-run mode. nil shows the call's arguments.
+	Preview ToolFunc
+}
+
+// ToolRegistry is an ordered set of tools. Safe for concurrent reads once
+// populated.
+type ToolRegistry struct {
+	defs   []*ToolDef
+	byName map[string]*ToolDef
+}
+
+// NewToolRegistry creates a registry holding defs.
+func NewToolRegistry(defs ...*ToolDef) *ToolRegistry {
+	r := &ToolRegistry{byName: make(map[string]*ToolDef)}
+	r.Register(defs...)
+	return r
+}
+
+// Register adds tools to the registry. Registering a name twice panics,
+// since it is always a programming error.
+func (r *ToolRegistry) Register(defs ...*ToolDef) {
+	for _, d := range defs {
+		if _, dup := r.byName[d.Name]; dup {
+			panic(fmt.Sprintf("tool %q registered twice", d.Name))
+		}
+		r.defs = append(r.defs, d)
+		r.byName[d.Name] = d
+	}
+}
+
+// Lookup returns the named tool, or nil.
+func (r *ToolRegistry) Lookup(name string) *ToolDef {
+	return r.byName[name]
+}
+
+// Subset returns a new registry with only the named tools, in registry order.
+func (r *ToolRegistry) Subset(names ...string) *ToolRegistry {
+	keep := make(map[string]bool, len(names))
+	for _, n := range names {
+		keep[n] = true
+	}
+	sub := NewToolRegistry()
+	for _, d := range r.defs {
+		if keep[d.Name] {
+			sub.Register(d)
+		}
+	}
+	return sub
+}
+
+// Definitions returns the LLM tool schemas available to h: the tool's
+// integration must be configured, the agent's tool policy must allow it and,
+// in read-only mode, it must not be a write tool.
+func (r *ToolRegistry) Definitions(h *GeneralHandler) []github.Tool {
+	var tools []github.Tool
+	readOnly := h.readOnly.Enabled(h.agentID)
+	for _, d := range r.defs {
+		if d.Available != nil && !d.Available(h) {
+			continue
+		}
+		if !h.toolPolicy.Allows(d.Name) {
+			continue
+		}
+		if d.Class == ToolWrite && readOnly {
+			continue
+		}
+		tools = append(tools, github.Tool{
+			Type: "function",
+			Function: github.ToolFunction{
+				Name:        d.Name,
+				Description: d.Description,
+				Parameters:  d.Parameters,
+			},
+		})
+	}
+	return tools
+}
+
+// builtinTools holds every tool shipped with arbetern.
+var builtinTools = NewToolRegistry(allBuiltinTools()...)
+
+func allBuiltinTools() []*ToolDef {
+	var defs []*ToolDef
+	defs = append(defs, githubTools...)
+	defs = append(defs, workspaceTools...)
+	defs = append(defs, projectTools...)
+	defs = append(defs, releaseTools...)
+	defs = append(defs, milestoneTools...)
+	defs = append(defs, orgTools...)
+	defs = append(defs, scaffoldTools...)
+	defs = append(defs, ciStatusTools...)
+	defs = append(defs, identityTools...)
+	defs = append(defs, slackTools...)
+	defs = append(defs, jiraTools...)
+	defs = append(defs, adoTools...)
+	defs = append(defs, notionTools...)
+	defs = append(defs, postmortemTools...)
+	defs = append(defs, pagerDutyTools...)
+	defs = append(defs, onCallTools...)
+	defs = append(defs, directoryTools...)
+	defs = append(defs, metricsTools...)
+	defs = append(defs, vaultTools...)
+	defs = append(defs, awsTools...)
+	defs = append(defs, gcpTools...)
+	defs = append(defs, argoCDTools...)
+	defs = append(defs, cloudflareTools...)
+	defs = append(defs, terraformTools...)
+	defs = append(defs, imageScanTools...)
+	defs = append(defs, costTools...)
+	defs = append(defs, nvdTools...)
+	defs = append(defs, cveWatchTools...)
+	defs = append(defs, depsTools...)
+	defs = append(defs, ledgerTools...)
+	defs = append(defs, compressTools...)
+	defs = append(defs, freezeTools...)
+	defs = append(defs, faqTools...)
+	return defs
+}
+
+// IsWriteTool returns true when the named tool changes state in an external system.
+func IsWriteTool(name string) bool {
+	d := builtinTools.Lookup(name)
+	return d != nil && d.Class == ToolWrite
+}
+
+func (h *GeneralHandler) githubConfigured() bool { return h.ghClient != nil }
+func (h *GeneralHandler) scmConfigured() bool    { return h.scm != nil }
+func (h *GeneralHandler) jiraConfigured() bool   { return h.jiraClient != nil }
+
+func (h *GeneralHandler) ticketingConfigured() bool { return h.tickets != nil }
+func (h *GeneralHandler) nvdConfigured() bool       { return h.nvdClient != nil }
+func (h *GeneralHandler) ledgerEnabled() bool       { return h.ledger != nil }
+func (h *GeneralHandler) compressionEnabled() bool {
+	return h.summarizer != nil && h.compressThreshold > 0
+}
+
+// registry returns the tools this handler may offer.
+func (h *GeneralHandler) registry() *ToolRegistry {
+	if h.tools != nil {
+		return h.tools
+	}
+	return builtinTools
+}
+
+// metricToolName returns name for tool metrics when it is a registered tool
+// and "unknown" otherwise, so made-up tool names can't grow the label set.
+func (h *GeneralHandler) metricToolName(name string) string {
+	if h.registry().Lookup(name) == nil {
+		return "unknown"
+	}
+	return name
+}
+
+func (h *GeneralHandler) buildTools() []github.Tool {
+	return h.registry().Definitions(h)
+}
+
+func (h *GeneralHandler) executeTool(ctx context.Context, channelID, userID, auditTS, name, argsJSON string) string {
+	result, _ := h.executeToolOutcome(ctx, channelID, userID, auditTS, name, argsJSON)
+	return result
+}
+
+// executeToolOutcome is executeTool that also returns the audit outcome, for
+// callers that treat refused calls differently from failed ones.
+func (h *GeneralHandler) executeToolOutcome(ctx context.Context, channelID, userID, auditTS, name, argsJSON string) (string, string) {
+	start := time.Now()
+	def, result, outcome := h.runTool(ctx, channelID, userID, auditTS, name, argsJSON)
+	h.recordAudit(channelID, userID, def, name, argsJSON, result, outcome, time.Since(start))
+	result = redactText(h.redactor, h.agentID, "tool_result", result)
+	shown := outcome
+	if shown == AuditOK && strings.HasPrefix(result, "Error") {
+		shown = AuditError
+	}
+	h.sessions.Record(channelID, auditTS, TranscriptEntry{
+		Kind:    TranscriptTool,
+		UserID:  userID,
+		Tool:    name,
+		Args:    redactText(h.redactor, h.agentID, "transcript", argsJSON),
+		Outcome: shown,
+		Text:    result,
+	})
+	return result, outcome
+}
+
+// runTool runs a tool call after the policy, read-only, access, freeze and
+// spend cap checks, and returns the tool (nil when unknown), its result and the audit
+// outcome. In dry-run mode write tools return a preview instead of running;
+// gated tools that only staged their action for approval are AuditPending.
+func (h *GeneralHandler) runTool(ctx context.Context, channelID, userID, auditTS, name, argsJSON string) (*ToolDef, string, string) {
+	// The model only sees allowed tools, but guard against hallucinated calls.
+	if !h.toolPolicy.Allows(name) {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] blocked tool %s (not allowed for agent %s)", userID, channelID, name, h.agentID)
+		return nil, fmt.Sprintf("Error: tool %s is not permitted for agent %s.", name, h.agentID), AuditDenied
+	}
+	def := h.registry().Lookup(name)
+	if def == nil || (def.Available != nil && !def.Available(h)) {
+		return nil, fmt.Sprintf("Unknown tool: %s", name), AuditError
+	}
+	if msg := h.checkReadOnly(userID, channelID, def); msg != "" {
+		return def, msg, AuditDenied
+	}
+	if msg := h.checkAccess(userID, channelID, def); msg != "" {
+		return def, msg, AuditDenied
+	}
+	if msg := h.checkRepoAccess(ctx, userID, channelID, def, argsJSON); msg != "" {
+		return def, msg, AuditDenied
+	}
+	if msg := h.checkProtectedPaths(ctx, userID, channelID, def, argsJSON); msg != "" {
+		return def, msg, AuditDenied
+	}
+	if msg := h.checkSubmodulePaths(ctx, userID, channelID, def, argsJSON); msg != "" {
+		return def, msg, AuditDenied
+	}
+	if msg := h.checkFreeze(ctx, userID, channelID, def, argsJSON); msg != "" {
+		return def, msg, AuditDenied
+	}
+	if msg := h.checkSpendCap(userID, channelID); msg != "" {
+		return def, msg, AuditDenied
+	}
+	call := ToolCall{ChannelID: channelID, UserID: userID, AuditTS: auditTS, Args: argsJSON}
+	if def.Class == ToolWrite && h.dryRun {
+		return def, h.previewTool(ctx, def, call), AuditDryRun
+	}
+	h.spendCaps.RecordAction(userID, channelID)
+	h.staged = false
+	result := def.Run(h, ctx, call)
+	if h.staged {
+		h.staged = false
+		return def, result, AuditPending
+	}
+	return def, result, AuditOK
+}
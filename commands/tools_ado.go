@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/justmike1/ovad/ado"
+	"github.com/justmike1/ovad/logging"
+)
+
+// adoTools create, search, read, and edit Azure DevOps Boards work items,
+// mirroring the Jira tools for teams that track work in ADO but keep code on
+// GitHub. Offered only when Azure DevOps is configured; agents pick between
+// the two with their tool policy (e.g. deny "*_ado_*" or "*jira*").
+var adoTools = []*ToolDef{
+	{
+		Name:        "create_ado_work_item",
+		Description: "Create an Azure DevOps Boards work item (task, bug, user story, ...). Use this when the user asks to create a ticket or work item and the team tracks work in Azure DevOps. Populate the title and description from the relevant content discussed in the conversation. IMPORTANT: Format the description using markdown — use ## for headers, - for bullet lists, 1. for numbered lists, **bold** for emphasis, and `code` for inline code. Structure it professionally with clear sections (e.g., ## Context, ## Scope, ## Acceptance Criteria).",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"project":{"type":"string","description":"Azure DevOps project name. Optional — uses the configured default if omitted."},
+				"title":{"type":"string","description":"Short one-line title for the work item."},
+				"description":{"type":"string","description":"Detailed, well-structured description in markdown. For bugs it is stored as the repro steps."},
+				"type":{"type":"string","description":"Work item type: 'Task', 'Bug', 'User Story', 'Product Backlog Item', 'Feature', 'Epic', etc. Default: 'Task'."},
+				"tags":{"type":"array","items":{"type":"string"},"description":"Optional tags to apply."},
+				"assigned_to":{"type":"string","description":"Email (preferred) or display name of the person to assign the work item to. Get the email with get_slack_user_info."},
+				"area_path":{"type":"string","description":"Area path, e.g. 'Project\\Team' (optional)"},
+				"iteration":{"type":"string","description":"Iteration path, e.g. 'Project\\Sprint 12' (optional)"},
+				"priority":{"type":"integer","description":"Priority 1 (highest) to 4 (optional)"}
+			},
+			"required":["title","description"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).adoConfigured,
+		Run:       (*GeneralHandler).toolCreateADOWorkItem,
+	},
+	{
+		Name:        "list_ado_projects",
+		Description: "List all Azure DevOps projects visible to the bot. Use this to discover project names before creating a work item.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).adoConfigured,
+		Run:         (*GeneralHandler).toolListADOProjects,
+	},
+	{
+		Name:        "search_ado_work_items",
+		Description: "Search Azure DevOps work items with WIQL (Work Item Query Language). Always select [System.Id]; the tool fetches the details. Examples: \"SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.AssignedTo] = 'jane@example.com' AND [System.State] = 'Active' ORDER BY [System.ChangedDate] DESC\", \"SELECT [System.Id] FROM WorkItems WHERE [System.Tags] CONTAINS 'security' AND [System.WorkItemType] = 'Bug'\". Prefer emails over display names for [System.AssignedTo].",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"wiql":{"type":"string","description":"WIQL query"},
+				"project":{"type":"string","description":"Project the query runs in, used for @project (default: the configured project)"},
+				"max_results":{"type":"integer","description":"Maximum number of results to return (default: 20, max: 50)"}
+			},
+			"required":["wiql"]
+		}`),
+		Available: (*GeneralHandler).adoConfigured,
+		Run:       (*GeneralHandler).toolSearchADOWorkItems,
+	},
+	{
+		Name:        "get_ado_work_item",
+		Description: "Get full details of an Azure DevOps work item by its ID (e.g. 1234). Returns title, description, state, assignee, area, iteration, tags, and more.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"id":{"type":"integer","description":"Work item ID"}
+			},
+			"required":["id"]
+		}`),
+		Available: (*GeneralHandler).adoConfigured,
+		Run:       (*GeneralHandler).toolGetADOWorkItem,
+	},
+	{
+		Name:        "update_ado_work_item",
+		Description: "Update an Azure DevOps work item's title, description, state, assignee or tags. Use get_ado_work_item first when rewriting the description. Format the description using markdown.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"id":{"type":"integer","description":"Work item ID"},
+				"title":{"type":"string","description":"New title (optional)"},
+				"description":{"type":"string","description":"New description in markdown (optional)"},
+				"state":{"type":"string","description":"New state, e.g. 'Active', 'Resolved', 'Closed' (optional; valid states depend on the process)"},
+				"assigned_to":{"type":"string","description":"Email or display name of the new assignee (optional)"},
+				"tags":{"type":"array","items":{"type":"string"},"description":"Replaces the work item's tags (optional)"}
+			},
+			"required":["id"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).adoConfigured,
+		Run:       (*GeneralHandler).toolUpdateADOWorkItem,
+	},
+}
+
+func (h *GeneralHandler) adoConfigured() bool { return h.adoClient != nil }
+
+func (h *GeneralHandler) toolCreateADOWorkItem(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Project     string   `json:"project"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Type        string   `json:"type"`
+		Tags        []string `json:"tags"`
+		AssignedTo  string   `json:"assigned_to"`
+		AreaPath    string   `json:"area_path"`
+		Iteration   string   `json:"iteration"`
+		Priority    int      `json:"priority"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Title) == "" {
+		return "Error: title is required."
+	}
+	item, err := h.adoClient.CreateWorkItem(ctx, ado.CreateWorkItemInput{
+		Project:     args.Project,
+		Type:        args.Type,
+		Title:       args.Title,
+		Description: args.Description + h.ticketStamp(call.UserID),
+		AssignedTo:  args.AssignedTo,
+		AreaPath:    args.AreaPath,
+		Iteration:   args.Iteration,
+		Tags:        args.Tags,
+		Priority:    args.Priority,
+	})
+	if err != nil {
+		return fmt.Sprintf("Error creating Azure DevOps work item: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] created ADO work item %d: %s", call.UserID, call.ChannelID, item.ID, item.URL)
+	result := fmt.Sprintf("Azure DevOps %s created: *#%d* — %s\nTitle: %s", item.Type, item.ID, item.URL, item.Title)
+	if args.AssignedTo != "" {
+		result += "\nAssigned to: " + item.AssignedTo
+	}
+	return result
+}
+
+func (h *GeneralHandler) toolListADOProjects(ctx context.Context, call ToolCall) string {
+	projects, err := h.adoClient.ListProjects(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error listing Azure DevOps projects: %v", err)
+	}
+	if len(projects) == 0 {
+		return "No Azure DevOps projects found."
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d ADO projects", call.UserID, call.ChannelID, len(projects))
+	return fmt.Sprintf("Azure DevOps projects (%d):\n%s", len(projects), strings.Join(projects, "\n"))
+}
+
+func (h *GeneralHandler) toolSearchADOWorkItems(ctx context.Context, call ToolCall) string {
+	var args struct {
+		WIQL       string `json:"wiql"`
+		Project    string `json:"project"`
+		MaxResults int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.MaxResults <= 0 {
+		args.MaxResults = 20
+	}
+	args.MaxResults = min(args.MaxResults, 50)
+	items, err := h.adoClient.QueryWorkItems(ctx, args.Project, args.WIQL, args.MaxResults)
+	if err != nil {
+		return fmt.Sprintf("Error searching Azure DevOps work items: %v", err)
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf("No work items found for WIQL: %s", args.WIQL)
+	}
+	table := &Table{
+		Name:    "ado-work-items",
+		Columns: []string{"ID", "Title", "State", "Type", "Priority", "Assigned To", "Changed", "Iteration", "URL", "Description"},
+		Inline:  7,
+	}
+	for _, w := range items {
+		changed := ""
+		if !w.Changed.IsZero() {
+			changed = w.Changed.Format("2006-01-02")
+		}
+		priority := ""
+		if w.Priority > 0 {
+			priority = strconv.Itoa(w.Priority)
+		}
+		table.Rows = append(table.Rows, []string{strconv.Itoa(w.ID), w.Title, w.State, w.Type, priority, w.AssignedTo, changed, w.Iteration, w.URL, w.Description})
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] searched ADO work items with WIQL, found %d", call.UserID, call.ChannelID, len(items))
+	return h.presentTable(call, fmt.Sprintf("Found %d work items.", len(items)), table)
+}
+
+func (h *GeneralHandler) toolGetADOWorkItem(ctx context.Context, call ToolCall) string {
+	var args struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	w, err := h.adoClient.GetWorkItem(ctx, args.ID)
+	if err != nil {
+		return fmt.Sprintf("Error getting Azure DevOps work item: %v", err)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*#%d* — %s\n", w.ID, w.Title)
+	fmt.Fprintf(&sb, "State: %s | Type: %s | Priority: %d\n", w.State, w.Type, w.Priority)
+	fmt.Fprintf(&sb, "Assigned To: %s\n", w.AssignedTo)
+	fmt.Fprintf(&sb, "Project: %s | Area: %s | Iteration: %s\n", w.Project, w.AreaPath, w.Iteration)
+	fmt.Fprintf(&sb, "Changed: %s\n", w.Changed.Format("2006-01-02 15:04"))
+	if len(w.Tags) > 0 {
+		fmt.Fprintf(&sb, "Tags: %s\n", strings.Join(w.Tags, ", "))
+	}
+	fmt.Fprintf(&sb, "URL: %s\n", w.URL)
+	if w.Description != "" {
+		fmt.Fprintf(&sb, "\nDescription:\n%s\n", w.Description)
+	} else {
+		fmt.Fprintf(&sb, "\nDescription: (empty)\n")
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched ADO work item %d", call.UserID, call.ChannelID, args.ID)
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolUpdateADOWorkItem(ctx context.Context, call ToolCall) string {
+	var args struct {
+		ID          int       `json:"id"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		State       string    `json:"state"`
+		AssignedTo  string    `json:"assigned_to"`
+		Tags        *[]string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	fields := make(map[string]any)
+	var updated []string
+	if args.Title != "" {
+		fields["System.Title"] = args.Title
+		updated = append(updated, "title")
+	}
+	if args.Description != "" {
+		fields["System.Description"] = ado.MarkdownToHTML(args.Description)
+		updated = append(updated, "description")
+	}
+	if args.State != "" {
+		fields["System.State"] = args.State
+		updated = append(updated, "state")
+	}
+	if args.AssignedTo != "" {
+		fields["System.AssignedTo"] = args.AssignedTo
+		updated = append(updated, "assignee")
+	}
+	if args.Tags != nil {
+		fields["System.Tags"] = strings.Join(*args.Tags, "; ")
+		updated = append(updated, "tags")
+	}
+	if len(fields) == 0 {
+		return "Error: at least one of title, description, state, assigned_to or tags must be provided."
+	}
+	w, err := h.adoClient.UpdateWorkItem(ctx, args.ID, fields)
+	if err != nil {
+		return fmt.Sprintf("Error updating Azure DevOps work item: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] updated ADO work item %d (%s)", call.UserID, call.ChannelID, args.ID, strings.Join(updated, ", "))
+	return fmt.Sprintf("Successfully updated #%d (%s): %s — state %s, assigned to %s", w.ID, w.URL, strings.Join(updated, ", "), w.State, w.AssignedTo)
+}
@@ -0,0 +1,282 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/argocd"
+	"github.com/justmike1/ovad/logging"
+)
+
+// maxArgoDiffLines caps the changed fields shown per resource.
+const maxArgoDiffLines = 15
+
+// argoCDTools inspect Argo CD applications — sync and health status, what
+// differs from Git — and trigger a sync once the user approves it. Offered
+// only when Argo CD is configured.
+var argoCDTools = []*ToolDef{
+	{
+		Name:        "list_argocd_applications",
+		Description: "List Argo CD applications with their sync status (Synced/OutOfSync), health (Healthy/Progressing/Degraded/Missing) and target revision. Use it for 'what's out of sync?' or to find an application's exact name.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"search":{"type":"string","description":"Only applications whose name contains this"},
+				"project":{"type":"string","description":"Only applications in this Argo CD project"},
+				"problems_only":{"type":"boolean","description":"Only applications that are not Synced and Healthy"}
+			}
+		}`),
+		Available: (*GeneralHandler).argoCDConfigured,
+		Run:       (*GeneralHandler).toolListArgoCDApplications,
+	},
+	{
+		Name:        "get_argocd_application",
+		Description: "Get an Argo CD application's sync and health status, source (repo, path, revision), conditions (e.g. ComparisonError), the last sync operation and every resource that is out of sync or unhealthy. Use it for 'why is payments-api OutOfSync / Degraded?', then diff_argocd_application to see what differs.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","description":"Application name"}
+			},
+			"required":["name"]
+		}`),
+		Available: (*GeneralHandler).argoCDConfigured,
+		Run:       (*GeneralHandler).toolGetArgoCDApplication,
+	},
+	{
+		Name:        "diff_argocd_application",
+		Description: "Show what differs between an Argo CD application's live state and Git: per resource, the changed fields (live → desired), and resources that would be created or pruned. Use it to explain an OutOfSync status (manual kubectl edits, HPA-managed replicas, a pending image bump) before suggesting a sync.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","description":"Application name"}
+			},
+			"required":["name"]
+		}`),
+		Available: (*GeneralHandler).argoCDConfigured,
+		Run:       (*GeneralHandler).toolDiffArgoCDApplication,
+	},
+	{
+		Name:        "sync_argocd_application",
+		Description: "Sync an Argo CD application to its target revision in Git. Requires a human's approval: the call only previews what will change and holds the sync until someone replies \"approve\" in this thread, which runs it. Post the preview to the user and do not call it again for the same sync. Set prune only if the user asked for deleting resources that are no longer in Git.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","description":"Application name"},
+				"prune":{"type":"boolean","description":"Delete live resources that are no longer in Git (default: false)"}
+			},
+			"required":["name"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).argoCDConfigured,
+		Run:       (*GeneralHandler).toolSyncArgoCDApplication,
+	},
+}
+
+func (h *GeneralHandler) argoCDConfigured() bool { return h.argoCD != nil }
+
+func (h *GeneralHandler) toolListArgoCDApplications(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Search       string `json:"search"`
+		Project      string `json:"project"`
+		ProblemsOnly bool   `json:"problems_only"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	apps, err := h.argoCD.ListApplications(ctx, args.Project, args.Search)
+	if err != nil {
+		return fmt.Sprintf("Error listing Argo CD applications: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d Argo CD applications", call.UserID, call.ChannelID, len(apps))
+	table := &Table{
+		Name:    "argocd-applications",
+		Columns: []string{"Application", "Sync", "Health", "Revision", "Project", "Namespace", "Auto-sync", "Repo", "Path"},
+		Inline:  6,
+	}
+	for _, a := range apps {
+		if args.ProblemsOnly && a.SyncStatus == "Synced" && a.Health == "Healthy" {
+			continue
+		}
+		table.Rows = append(table.Rows, []string{
+			a.Name, a.SyncStatus, a.Health, a.TargetRevision, a.Project, a.Namespace, fmt.Sprint(a.AutoSync), a.RepoURL, a.Path,
+		})
+	}
+	if len(table.Rows) == 0 {
+		if args.ProblemsOnly {
+			return fmt.Sprintf("All %d matching Argo CD applications are Synced and Healthy.", len(apps))
+		}
+		return "No matching Argo CD applications."
+	}
+	return h.presentTable(call, fmt.Sprintf("%d Argo CD application(s).", len(table.Rows)), table)
+}
+
+func (h *GeneralHandler) toolGetArgoCDApplication(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	app, err := h.argoCD.GetApplication(ctx, args.Name)
+	if err != nil {
+		return fmt.Sprintf("Error getting Argo CD application: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched Argo CD application %s", call.UserID, call.ChannelID, args.Name)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* — %s, %s", app.Name, app.SyncStatus, app.Health)
+	if app.HealthMessage != "" {
+		fmt.Fprintf(&sb, " (%s)", app.HealthMessage)
+	}
+	fmt.Fprintf(&sb, "\nSource: %s %s @ %s", app.RepoURL, app.Path, app.TargetRevision)
+	if app.SyncRevision != "" {
+		fmt.Fprintf(&sb, " (resolved %s)", shortSHA(app.SyncRevision))
+	}
+	fmt.Fprintf(&sb, "\nDestination: %s / %s | Project: %s | Auto-sync: %t\nURL: %s\n", app.Cluster, app.Namespace, app.Project, app.AutoSync, h.argoCD.AppURL(app.Name))
+	if len(app.Conditions) > 0 {
+		sb.WriteString("\nConditions:\n")
+		for _, c := range app.Conditions {
+			fmt.Fprintf(&sb, "- %s\n", c)
+		}
+	}
+	if op := app.Operation; op != nil {
+		fmt.Fprintf(&sb, "\nLast sync: %s", op.Phase)
+		if op.Revision != "" {
+			fmt.Fprintf(&sb, " at %s", shortSHA(op.Revision))
+		}
+		if !op.FinishedAt.IsZero() {
+			fmt.Fprintf(&sb, ", finished %s (%s ago)", op.FinishedAt.UTC().Format("2006-01-02 15:04 MST"), time.Since(op.FinishedAt).Round(time.Minute))
+		} else if !op.StartedAt.IsZero() {
+			fmt.Fprintf(&sb, ", started %s", op.StartedAt.UTC().Format("2006-01-02 15:04 MST"))
+		}
+		if op.Message != "" {
+			fmt.Fprintf(&sb, " — %s", op.Message)
+		}
+		sb.WriteString("\n")
+		for _, f := range op.FailedSync {
+			fmt.Fprintf(&sb, "- %s\n", f)
+		}
+	}
+
+	var problems []string
+	for _, r := range app.Resources {
+		if r.SyncStatus == "Synced" && (r.Health == "" || r.Health == "Healthy") {
+			continue
+		}
+		line := fmt.Sprintf("- %s %s/%s: %s", r.Kind, r.Namespace, r.Name, r.SyncStatus)
+		if r.Health != "" {
+			line += ", " + r.Health
+		}
+		if r.HealthMessage != "" {
+			line += " (" + r.HealthMessage + ")"
+		}
+		problems = append(problems, line)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(&sb, "\n%d of %d resources out of sync or unhealthy:\n%s\n", len(problems), len(app.Resources), strings.Join(problems, "\n"))
+	} else {
+		fmt.Fprintf(&sb, "\nAll %d resources are synced and healthy.\n", len(app.Resources))
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolDiffArgoCDApplication(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	diffs, err := h.argoCD.Diff(ctx, args.Name)
+	if err != nil {
+		return fmt.Sprintf("Error diffing Argo CD application: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] diffed Argo CD application %s: %d resources", call.UserID, call.ChannelID, args.Name, len(diffs))
+	if len(diffs) == 0 {
+		return fmt.Sprintf("%s: live state matches Git.", args.Name)
+	}
+	return fmt.Sprintf("%s: %d resource(s) differ from Git.\n%s", args.Name, len(diffs), formatArgoDiffs(diffs))
+}
+
+func (h *GeneralHandler) toolSyncArgoCDApplication(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Name  string `json:"name"`
+		Prune bool   `json:"prune"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Name) == "" {
+		return "Error: name is required."
+	}
+
+	// Only a human's reply in the thread runs the sync; see RunApproved.
+	if !h.approvedRun("sync_argocd_application", call) {
+		diffs, err := h.argoCD.Diff(ctx, args.Name)
+		if err != nil {
+			return fmt.Sprintf("Error previewing Argo CD sync: %v", err)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Sync of Argo CD application %s", args.Name)
+		if args.Prune {
+			sb.WriteString(" with prune (deletes live resources that are not in Git)")
+		}
+		sb.WriteString(":\n")
+		if len(diffs) == 0 {
+			sb.WriteString("Live state already matches Git; a sync would only re-apply the manifests.\n")
+		} else {
+			sb.WriteString(formatArgoDiffs(diffs))
+		}
+		return h.requestApproval(ctx, call, "sync_argocd_application", sb.String())
+	}
+
+	app, err := h.argoCD.Sync(ctx, args.Name, args.Prune)
+	if err != nil {
+		return fmt.Sprintf("Error syncing Argo CD application: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] synced Argo CD application %s (prune=%t)", call.UserID, call.ChannelID, args.Name, args.Prune)
+	phase := "started"
+	if app.Operation != nil && app.Operation.Phase != "" {
+		phase = strings.ToLower(app.Operation.Phase)
+	}
+	return fmt.Sprintf("Sync of %s %s (target %s). Follow it at %s", app.Name, phase, app.TargetRevision, h.argoCD.AppURL(app.Name))
+}
+
+// formatArgoDiffs renders resource diffs as a bullet list with the changed
+// fields of each resource.
+func formatArgoDiffs(diffs []argocd.ResourceDiff) string {
+	var sb strings.Builder
+	for _, d := range diffs {
+		ref := d.Kind + " " + d.Name
+		if d.Namespace != "" {
+			ref = d.Kind + " " + d.Namespace + "/" + d.Name
+		}
+		switch {
+		case d.Created:
+			fmt.Fprintf(&sb, "• %s — will be created\n", ref)
+		case d.Deleted:
+			fmt.Fprintf(&sb, "• %s — not in Git (pruned only with prune)\n", ref)
+		default:
+			fmt.Fprintf(&sb, "• %s — %d field(s) differ (live → Git):\n", ref, len(d.Changes))
+			for _, c := range d.Changes[:min(len(d.Changes), maxArgoDiffLines)] {
+				fmt.Fprintf(&sb, "    %s\n", c)
+			}
+			if len(d.Changes) > maxArgoDiffLines {
+				fmt.Fprintf(&sb, "    … %d more\n", len(d.Changes)-maxArgoDiffLines)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// shortSHA abbreviates a 40-character commit SHA; other revisions (tags,
+// chart versions) are returned unchanged.
+func shortSHA(rev string) string {
+	if len(rev) == 40 && strings.Trim(rev, "0123456789abcdef") == "" {
+		return rev[:7]
+	}
+	return rev
+}
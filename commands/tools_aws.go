@@ -0,0 +1,426 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/aws"
+	"github.com/justmike1/ovad/logging"
+)
+
+// awsTools describe AWS resource state — ECS services and tasks, CloudWatch
+// alarms and logs, Lambda error rates — for infra debugging threads. They
+// call read-only APIs with the pod's IAM role. Offered only when the AWS
+// tools are enabled.
+var awsTools = []*ToolDef{
+	{
+		Name:        "describe_ecs_service",
+		Description: "Describe an AWS ECS service: desired/running/pending task counts, task definition, deployments with rollout state, and recent service events (failed placements, unhealthy targets, steady state). Use it when a service is down, a deploy is stuck, or tasks keep restarting.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"cluster":{"type":"string","description":"ECS cluster name or ARN"},
+				"service":{"type":"string","description":"ECS service name or ARN"},
+				"max_events":{"type":"integer","description":"Number of recent service events to include (default: 10, max: 50)"}
+			},
+			"required":["cluster","service"]
+		}`),
+		Available: (*GeneralHandler).awsConfigured,
+		Run:       (*GeneralHandler).toolDescribeECSService,
+	},
+	{
+		Name:        "list_ecs_tasks",
+		Description: "List the running or recently stopped tasks of an AWS ECS service with their health and, for stopped tasks, the stop code, stopped reason and each container's exit code and reason (e.g. OutOfMemoryError, failed health checks, image pull errors). Use it when tasks keep restarting or a deploy can't start tasks. ECS keeps stopped tasks for about an hour.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"cluster":{"type":"string","description":"ECS cluster name or ARN"},
+				"service":{"type":"string","description":"ECS service name (default: all tasks in the cluster)"},
+				"status":{"type":"string","enum":["RUNNING","STOPPED"],"description":"Task status to list (default: STOPPED)"},
+				"max_results":{"type":"integer","description":"Maximum number of tasks (default: 20, max: 100)"}
+			},
+			"required":["cluster"]
+		}`),
+		Available: (*GeneralHandler).awsConfigured,
+		Run:       (*GeneralHandler).toolListECSTasks,
+	},
+	{
+		Name:        "list_cloudwatch_alarms",
+		Description: "List AWS CloudWatch metric alarms — by default those currently in ALARM — most recently changed first, with the reason; optionally include recent state changes. Use it for 'is anything alarming in AWS?' or to check the alarms of a service.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"state":{"type":"string","enum":["ALARM","OK","INSUFFICIENT_DATA","ANY"],"description":"Alarm state to list (default: ALARM)"},
+				"name_prefix":{"type":"string","description":"Only alarms whose name starts with this prefix"},
+				"history_hours":{"type":"integer","description":"Also list state changes of the last N hours (max: 336)"},
+				"max_results":{"type":"integer","description":"Maximum number of alarms (default: 25, max: 100)"}
+			}
+		}`),
+		Available: (*GeneralHandler).awsConfigured,
+		Run:       (*GeneralHandler).toolListCloudWatchAlarms,
+	},
+	{
+		Name:        "query_cloudwatch_logs",
+		Description: "Run a CloudWatch Logs Insights query over one or more log groups and return the matching rows. Write the query in Logs Insights syntax, e.g. 'fields @timestamp, @message | filter @message like /ERROR/ | sort @timestamp desc' or 'filter level = \"error\" | stats count(*) by bin(5m)'. Lambda functions log to /aws/lambda/<function>. Keep the time range and result count small; queries are billed by data scanned.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"log_groups":{"type":"array","items":{"type":"string"},"description":"Log group names (max 10)"},
+				"query":{"type":"string","description":"Logs Insights query"},
+				"minutes":{"type":"integer","description":"How far back to look (default: 60, max: 10080)"},
+				"max_results":{"type":"integer","description":"Maximum number of rows (default: 50, max: 200)"}
+			},
+			"required":["log_groups","query"]
+		}`),
+		Available: (*GeneralHandler).awsConfigured,
+		Run:       (*GeneralHandler).toolQueryCloudWatchLogs,
+	},
+	{
+		Name:        "get_lambda_errors",
+		Description: "Get an AWS Lambda function's state and its invocations, errors, error rate, throttles and duration over a window from CloudWatch, with errors per interval and, when there were errors, the most recent error lines from its logs. Use it for 'what errors did the checkout lambda throw in the last hour?' or to see whether errors started at a deploy.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"function":{"type":"string","description":"Lambda function name or ARN"},
+				"hours":{"type":"integer","description":"How many hours to look back (default: 1, max: 336)"}
+			},
+			"required":["function"]
+		}`),
+		Available: (*GeneralHandler).awsConfigured,
+		Run:       (*GeneralHandler).toolGetLambdaErrors,
+	},
+}
+
+func (h *GeneralHandler) awsConfigured() bool { return h.awsClient != nil }
+
+func (h *GeneralHandler) toolDescribeECSService(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Cluster   string `json:"cluster"`
+		Service   string `json:"service"`
+		MaxEvents int    `json:"max_events"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Cluster == "" || args.Service == "" {
+		return "Error: cluster and service are required."
+	}
+	maxEvents := 10
+	if args.MaxEvents > 0 {
+		maxEvents = min(args.MaxEvents, 50)
+	}
+	svc, err := h.awsClient.DescribeService(ctx, args.Cluster, args.Service)
+	if err != nil {
+		return fmt.Sprintf("Error describing ECS service: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] described ECS service %s/%s", call.UserID, call.ChannelID, args.Cluster, args.Service)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* (cluster %s, %s) — %s\n", svc.Name, svc.Cluster, h.awsClient.Region(), svc.Status)
+	fmt.Fprintf(&sb, "Tasks: %d running / %d desired / %d pending", svc.RunningCount, svc.DesiredCount, svc.PendingCount)
+	if svc.LaunchType != "" {
+		fmt.Fprintf(&sb, " | Launch type: %s", svc.LaunchType)
+	}
+	fmt.Fprintf(&sb, "\nTask definition: %s\n", svc.TaskDefinition)
+	if len(svc.Deployments) > 0 {
+		sb.WriteString("\nDeployments:\n")
+		for _, d := range svc.Deployments {
+			fmt.Fprintf(&sb, "- %s %s: %d/%d running, %d pending", d.Status, d.TaskDefinition, d.RunningCount, d.DesiredCount, d.PendingCount)
+			if d.FailedTasks > 0 {
+				fmt.Fprintf(&sb, ", %d failed tasks", d.FailedTasks)
+			}
+			if d.RolloutState != "" {
+				fmt.Fprintf(&sb, " — rollout %s", d.RolloutState)
+				if d.RolloutReason != "" {
+					fmt.Fprintf(&sb, " (%s)", d.RolloutReason)
+				}
+			}
+			fmt.Fprintf(&sb, ", started %s\n", d.CreatedAt.UTC().Format("2006-01-02 15:04 MST"))
+		}
+	}
+	if len(svc.Events) > 0 {
+		sb.WriteString("\nRecent events:\n")
+		for _, e := range svc.Events[:min(len(svc.Events), maxEvents)] {
+			fmt.Fprintf(&sb, "- %s %s\n", e.CreatedAt.UTC().Format("01-02 15:04"), e.Message)
+		}
+	}
+	return sb.String()
+}
+
+// maxLogFieldLen caps each value shown from a log query row.
+const maxLogFieldLen = 500
+
+// logsQueryWait is how long query_cloudwatch_logs waits for a query.
+const logsQueryWait = 25 * time.Second
+
+func (h *GeneralHandler) toolListECSTasks(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Cluster    string `json:"cluster"`
+		Service    string `json:"service"`
+		Status     string `json:"status"`
+		MaxResults int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Cluster == "" {
+		return "Error: cluster is required."
+	}
+	status := strings.ToUpper(args.Status)
+	if status == "" {
+		status = "STOPPED"
+	}
+	if status != "RUNNING" && status != "STOPPED" {
+		return "Error: status must be RUNNING or STOPPED."
+	}
+	limit := 20
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 100)
+	}
+	tasks, err := h.awsClient.ListTasks(ctx, args.Cluster, args.Service, status, limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing ECS tasks: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d %s ECS tasks in %s/%s", call.UserID, call.ChannelID, len(tasks), status, args.Cluster, args.Service)
+	scope := "cluster " + args.Cluster
+	if args.Service != "" {
+		scope = "service " + args.Service + " (cluster " + args.Cluster + ")"
+	}
+	if len(tasks) == 0 {
+		if status == "STOPPED" {
+			return fmt.Sprintf("No stopped tasks in %s within the last hour or so.", scope)
+		}
+		return fmt.Sprintf("No running tasks in %s.", scope)
+	}
+
+	table := &Table{
+		Name:    "ecs-tasks",
+		Columns: []string{"Task", "Task Definition", "Status", "Health", "Started", "Stopped", "Stop Reason", "Containers"},
+		Inline:  7,
+	}
+	for _, t := range tasks {
+		reason := t.StoppedReason
+		if t.StopCode != "" {
+			reason = strings.TrimSuffix(t.StopCode+": "+reason, ": ")
+		}
+		var containers []string
+		for _, ct := range t.Containers {
+			s := ct.Name + " " + ct.LastStatus
+			if ct.ExitCode != nil {
+				s += fmt.Sprintf(" (exit %d)", *ct.ExitCode)
+			}
+			if ct.Reason != "" {
+				s += " — " + ct.Reason
+			}
+			containers = append(containers, s)
+		}
+		started, stopped := "", ""
+		if !t.StartedAt.IsZero() {
+			started = t.StartedAt.UTC().Format("01-02 15:04:05")
+		}
+		if !t.StoppedAt.IsZero() {
+			stopped = t.StoppedAt.UTC().Format("01-02 15:04:05")
+		}
+		table.Rows = append(table.Rows, []string{
+			t.ID, t.TaskDefinition, t.LastStatus, t.HealthStatus, started, stopped, reason, strings.Join(containers, "; "),
+		})
+	}
+	return h.presentTable(call, fmt.Sprintf("%d %s task(s) in %s.", len(tasks), strings.ToLower(status), scope), table)
+}
+
+func (h *GeneralHandler) toolQueryCloudWatchLogs(ctx context.Context, call ToolCall) string {
+	var args struct {
+		LogGroups  []string `json:"log_groups"`
+		Query      string   `json:"query"`
+		Minutes    int      `json:"minutes"`
+		MaxResults int      `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if len(args.LogGroups) == 0 || strings.TrimSpace(args.Query) == "" {
+		return "Error: log_groups and query are required."
+	}
+	if len(args.LogGroups) > 10 {
+		return "Error: at most 10 log groups per query."
+	}
+	minutes := 60
+	if args.Minutes > 0 {
+		minutes = min(args.Minutes, 10080)
+	}
+	limit := 50
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 200)
+	}
+	end := time.Now()
+	res, err := h.awsClient.QueryLogs(ctx, args.LogGroups, args.Query, end.Add(-time.Duration(minutes)*time.Minute), end, limit, logsQueryWait)
+	if err != nil {
+		return fmt.Sprintf("Error querying CloudWatch Logs: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] ran Logs Insights query over %s (%dm): %d rows, %s", call.UserID, call.ChannelID, strings.Join(args.LogGroups, ","), minutes, len(res.Rows), res.Status)
+
+	summary := fmt.Sprintf("%d row(s) from %s over the last %d minutes (%.0f of %.0f records matched).", len(res.Rows), strings.Join(args.LogGroups, ", "), minutes, res.Matched, res.Scanned)
+	switch res.Status {
+	case "Complete":
+	case "Running":
+		summary += fmt.Sprintf(" The query did not finish within %s and was stopped; results are partial — narrow the time range or log groups.", logsQueryWait)
+	default:
+		summary += " Query status: " + res.Status + "."
+	}
+	if len(res.Rows) == 0 {
+		return summary
+	}
+	table := &Table{Name: "cloudwatch-logs", Columns: res.Fields, Inline: len(res.Fields)}
+	for _, r := range res.Rows {
+		row := make([]string, len(res.Fields))
+		for i, f := range res.Fields {
+			v := strings.TrimSpace(r[f])
+			if len(v) > maxLogFieldLen {
+				v = v[:maxLogFieldLen] + "…"
+			}
+			row[i] = v
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return h.presentTable(call, summary, table)
+}
+
+func (h *GeneralHandler) toolListCloudWatchAlarms(ctx context.Context, call ToolCall) string {
+	var args struct {
+		State        string `json:"state"`
+		NamePrefix   string `json:"name_prefix"`
+		HistoryHours int    `json:"history_hours"`
+		MaxResults   int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	state := strings.ToUpper(args.State)
+	switch state {
+	case "":
+		state = "ALARM"
+	case "ANY":
+		state = ""
+	}
+	limit := 25
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 100)
+	}
+	alarms, err := h.awsClient.DescribeAlarms(ctx, state, args.NamePrefix, limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing CloudWatch alarms: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d CloudWatch alarms (state=%q)", call.UserID, call.ChannelID, len(alarms), state)
+
+	var history string
+	if args.HistoryHours > 0 {
+		since := time.Now().Add(-time.Duration(min(args.HistoryHours, 336)) * time.Hour)
+		changes, err := h.awsClient.AlarmStateChanges(ctx, "", since, 50)
+		if err != nil {
+			history = fmt.Sprintf("\nState changes unavailable: %v\n", err)
+		} else if len(changes) > 0 {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "\nState changes in the last %dh:\n", min(args.HistoryHours, 336))
+			for _, c := range changes {
+				if args.NamePrefix != "" && !strings.HasPrefix(c.Alarm, args.NamePrefix) {
+					continue
+				}
+				fmt.Fprintf(&sb, "- %s %s\n", c.Timestamp.UTC().Format("01-02 15:04"), c.Summary)
+			}
+			history = sb.String()
+		}
+	}
+
+	if len(alarms) == 0 {
+		if state == "" {
+			return "No CloudWatch alarms match." + history
+		}
+		return fmt.Sprintf("No CloudWatch alarms in state %s (%s).%s", state, h.awsClient.Region(), history)
+	}
+	table := &Table{
+		Name:    "cloudwatch-alarms",
+		Columns: []string{"Alarm", "State", "Since", "Metric", "Reason"},
+		Inline:  5,
+	}
+	for _, a := range alarms {
+		table.Rows = append(table.Rows, []string{
+			a.Name, a.State, a.UpdatedAt.UTC().Format("2006-01-02 15:04"), a.Namespace + "/" + a.Metric, a.Reason,
+		})
+	}
+	return h.presentTable(call, fmt.Sprintf("%d CloudWatch alarm(s) in %s.", len(alarms), h.awsClient.Region()), table) + history
+}
+
+func (h *GeneralHandler) toolGetLambdaErrors(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Function string `json:"function"`
+		Hours    int    `json:"hours"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Function) == "" {
+		return "Error: function is required."
+	}
+	hours := 1
+	if args.Hours > 0 {
+		hours = min(args.Hours, 336)
+	}
+	fn, err := h.awsClient.GetFunction(ctx, args.Function)
+	if err != nil {
+		return fmt.Sprintf("Error getting Lambda function: %v", err)
+	}
+	// CloudWatch allows at most 1440 datapoints; aim for about 12 intervals.
+	window := time.Duration(hours) * time.Hour
+	period := max(time.Minute, (window / 12).Round(time.Minute))
+	end := time.Now()
+	m, err := h.awsClient.FunctionMetrics(ctx, fn.Name, end.Add(-window), end, period)
+	if err != nil {
+		return fmt.Sprintf("Error getting Lambda metrics: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched Lambda metrics of %s (%dh)", call.UserID, call.ChannelID, fn.Name, hours)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* (%s, %s) — state %s", fn.Name, fn.Runtime, h.awsClient.Region(), fn.State)
+	if fn.StateReason != "" {
+		fmt.Fprintf(&sb, " (%s)", fn.StateReason)
+	}
+	fmt.Fprintf(&sb, "\nLast modified: %s | Last update: %s", fn.LastModified.UTC().Format("2006-01-02 15:04 MST"), fn.LastUpdateStatus)
+	if fn.LastUpdateReason != "" {
+		fmt.Fprintf(&sb, " (%s)", fn.LastUpdateReason)
+	}
+	fmt.Fprintf(&sb, "\nMemory: %d MB | Timeout: %ds\n\n", fn.MemoryMB, fn.TimeoutSeconds)
+
+	fmt.Fprintf(&sb, "Last %dh: %.0f invocations, %.0f errors", hours, m.Invocations, m.Errors)
+	if m.Invocations > 0 {
+		fmt.Fprintf(&sb, " (%.2f%% error rate)", 100*m.Errors/m.Invocations)
+	}
+	fmt.Fprintf(&sb, ", %.0f throttles\n", m.Throttles)
+	if m.AvgDuration > 0 {
+		fmt.Fprintf(&sb, "Duration: avg %s, max %s (timeout %ds)\n", m.AvgDuration.Round(time.Millisecond), m.MaxDuration.Round(time.Millisecond), fn.TimeoutSeconds)
+	}
+	if m.Errors > 0 {
+		fmt.Fprintf(&sb, "\nErrors per %s:\n", period)
+		for _, p := range m.ErrorsByPeriod {
+			fmt.Fprintf(&sb, "- %s %.0f\n", p.Timestamp.UTC().Format("01-02 15:04"), p.Sum)
+		}
+		// Show what the errors were, not just how many.
+		res, err := h.awsClient.QueryLogs(ctx, []string{fn.LogGroup}, aws.LambdaErrorQuery, end.Add(-window), end, 15, logsQueryWait)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&sb, "\nError logs unavailable: %v\n", err)
+		case len(res.Rows) > 0:
+			fmt.Fprintf(&sb, "\nRecent error lines from %s:\n", fn.LogGroup)
+			for _, r := range res.Rows {
+				msg := strings.TrimSpace(r["@message"])
+				if len(msg) > maxLogFieldLen {
+					msg = msg[:maxLogFieldLen] + "…"
+				}
+				fmt.Fprintf(&sb, "- %s %s\n", r["@timestamp"], msg)
+			}
+		}
+	}
+	return sb.String()
+}
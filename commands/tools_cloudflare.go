@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/cloudflare"
+	"github.com/justmike1/ovad/logging"
+)
+
+// cloudflareTools check a zone's status and security settings, show recent
+// WAF events and purge the cache once the user approves it — for "site is
+// slow / blocked" reports. Offered only when Cloudflare is configured.
+var cloudflareTools = []*ToolDef{
+	{
+		Name:        "get_cloudflare_zone",
+		Description: "Get a Cloudflare zone's status and the settings that affect visitors: active/paused, security level (e.g. I'm Under Attack mode), development mode (cache bypassed), Always Online and SSL mode. Use it first for 'the site is down / slow / showing a challenge page'.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"zone":{"type":"string","description":"Zone name (e.g. 'example.com') or zone ID"}
+			},
+			"required":["zone"]
+		}`),
+		Available: (*GeneralHandler).cloudflareConfigured,
+		Run:       (*GeneralHandler).toolGetCloudflareZone,
+	},
+	{
+		Name:        "list_cloudflare_waf_events",
+		Description: "List recent Cloudflare firewall events (WAF managed rules, custom rules, rate limiting, bot and security-level challenges) for a zone, with a breakdown by action, rule and client country. Use it for 'customers are getting blocked / 403 / challenged' — filter by host or path, or search by the user's IP or Ray ID.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"zone":{"type":"string","description":"Zone name or ID"},
+				"minutes":{"type":"integer","description":"How far back to look (default: 60, max: 1440)"},
+				"host":{"type":"string","description":"Only requests to this hostname"},
+				"path":{"type":"string","description":"Only requests whose path starts with this"},
+				"search":{"type":"string","description":"Only events whose client IP, Ray ID or rule ID contains this"},
+				"max_results":{"type":"integer","description":"Maximum number of events to fetch (default: 200, max: 1000)"}
+			},
+			"required":["zone"]
+		}`),
+		Available: (*GeneralHandler).cloudflareConfigured,
+		Run:       (*GeneralHandler).toolListCloudflareWAFEvents,
+	},
+	{
+		Name:        "purge_cloudflare_cache",
+		Description: "Purge cached content from a Cloudflare zone: specific URLs, URL prefixes, hostnames, or everything. Requires a human's approval: the call only previews the purge and holds it until someone replies \"approve\" in this thread, which runs it. Post the preview to the user and do not call it again for the same purge. Prefer purging specific URLs or prefixes; purge everything only when the user insists, since it sends all traffic to the origin.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"zone":{"type":"string","description":"Zone name or ID"},
+				"files":{"type":"array","items":{"type":"string"},"description":"Full URLs to purge (max 30)"},
+				"prefixes":{"type":"array","items":{"type":"string"},"description":"URL prefixes without scheme, e.g. 'www.example.com/assets/' (max 30)"},
+				"hosts":{"type":"array","items":{"type":"string"},"description":"Hostnames whose whole cache to purge (max 30)"},
+				"everything":{"type":"boolean","description":"Purge the entire zone cache"}
+			},
+			"required":["zone"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).cloudflareConfigured,
+		Run:       (*GeneralHandler).toolPurgeCloudflareCache,
+	},
+}
+
+// maxCloudflarePurgeItems is Cloudflare's per-request limit for files,
+// prefixes and hosts.
+const maxCloudflarePurgeItems = 30
+
+func (h *GeneralHandler) cloudflareConfigured() bool { return h.cloudflare != nil }
+
+func (h *GeneralHandler) toolGetCloudflareZone(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Zone string `json:"zone"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	z, err := h.cloudflare.GetZone(ctx, args.Zone)
+	if err != nil {
+		return fmt.Sprintf("Error getting Cloudflare zone: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched Cloudflare zone %s", call.UserID, call.ChannelID, z.Name)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* — %s (%s plan)\n", z.Name, z.Status, z.Plan)
+	if z.Paused {
+		sb.WriteString("⚠️ Paused: Cloudflare is bypassed; traffic goes straight to the origin (no cache, no WAF).\n")
+	}
+	if z.Status != "active" {
+		fmt.Fprintf(&sb, "⚠️ Zone is not active; nameservers must be %s.\n", strings.Join(z.NameServers, ", "))
+	}
+	if z.SecurityLevel != "" {
+		fmt.Fprintf(&sb, "Security level: %s", z.SecurityLevel)
+		if z.SecurityLevel == "under_attack" {
+			sb.WriteString(" — I'm Under Attack mode: every visitor gets a challenge page")
+		}
+		sb.WriteString("\n")
+		fmt.Fprintf(&sb, "Development mode: %s | Always Online: %s | SSL: %s\n", onOff(z.DevelopmentMode), onOff(z.AlwaysOnline), z.SSLMode)
+		if z.DevelopmentMode {
+			sb.WriteString("Development mode bypasses the cache, which can make the site slow.\n")
+		}
+	} else {
+		sb.WriteString("Settings unavailable (the token needs Zone Settings:Read).\n")
+	}
+	fmt.Fprintf(&sb, "Zone ID: %s\n", z.ID)
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolListCloudflareWAFEvents(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Zone       string `json:"zone"`
+		Minutes    int    `json:"minutes"`
+		Host       string `json:"host"`
+		Path       string `json:"path"`
+		Search     string `json:"search"`
+		MaxResults int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	minutes := 60
+	if args.Minutes > 0 {
+		minutes = min(args.Minutes, 1440)
+	}
+	limit := 200
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 1000)
+	}
+	z, err := h.cloudflare.GetZone(ctx, args.Zone)
+	if err != nil {
+		return fmt.Sprintf("Error getting Cloudflare zone: %v", err)
+	}
+	events, err := h.cloudflare.FirewallEvents(ctx, z.ID, time.Now().Add(-time.Duration(minutes)*time.Minute), args.Host, args.Path, limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing Cloudflare firewall events: %v", err)
+	}
+	if args.Search != "" {
+		var filtered []cloudflare.FirewallEvent
+		for _, e := range events {
+			if strings.Contains(e.ClientIP, args.Search) || strings.Contains(e.RayID, args.Search) || strings.Contains(e.RuleID, args.Search) {
+				filtered = append(filtered, e)
+			}
+		}
+		events = filtered
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d Cloudflare firewall events for %s", call.UserID, call.ChannelID, len(events), z.Name)
+	if len(events) == 0 {
+		return fmt.Sprintf("No firewall events for %s in the last %d minutes.", z.Name, minutes)
+	}
+
+	summary := fmt.Sprintf("%d firewall event(s) for %s in the last %d minutes", len(events), z.Name, minutes)
+	if len(events) == limit {
+		summary += " (limit reached; narrow by host, path or time)"
+	}
+	summary += ".\nBy action: " + topCounts(events, func(e cloudflare.FirewallEvent) string { return e.Action }, 5)
+	summary += "\nBy source/rule: " + topCounts(events, func(e cloudflare.FirewallEvent) string {
+		if e.Description != "" {
+			return e.Source + " — " + e.Description
+		}
+		return e.Source + " " + e.RuleID
+	}, 5)
+	summary += "\nBy country: " + topCounts(events, func(e cloudflare.FirewallEvent) string { return e.Country }, 5)
+
+	table := &Table{
+		Name:    "cloudflare-waf-events",
+		Columns: []string{"Time", "Action", "Source", "Rule", "Client IP", "Country", "Request", "Ray ID", "User Agent"},
+		Inline:  7,
+	}
+	for _, e := range events {
+		table.Rows = append(table.Rows, []string{
+			e.Time.UTC().Format("15:04:05"), e.Action, e.Source, strings.TrimSpace(e.RuleID + " " + e.Description),
+			e.ClientIP, e.Country, e.Method + " " + e.Host + e.Path, e.RayID, e.UserAgent,
+		})
+	}
+	return h.presentTable(call, summary, table)
+}
+
+func (h *GeneralHandler) toolPurgeCloudflareCache(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Zone       string   `json:"zone"`
+		Files      []string `json:"files"`
+		Prefixes   []string `json:"prefixes"`
+		Hosts      []string `json:"hosts"`
+		Everything bool     `json:"everything"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	req := cloudflare.PurgeRequest{Files: args.Files, Prefixes: args.Prefixes, Hosts: args.Hosts, Everything: args.Everything}
+	var what string
+	set := 0
+	for _, sel := range []struct {
+		name  string
+		items []string
+	}{{"URL", args.Files}, {"prefix", args.Prefixes}, {"host", args.Hosts}} {
+		if len(sel.items) == 0 {
+			continue
+		}
+		set++
+		if len(sel.items) > maxCloudflarePurgeItems {
+			return fmt.Sprintf("Error: at most %d %ss can be purged per call.", maxCloudflarePurgeItems, sel.name)
+		}
+		what = fmt.Sprintf("%d %s(s): %s", len(sel.items), sel.name, strings.Join(sel.items, ", "))
+	}
+	if args.Everything {
+		set++
+		what = "EVERYTHING (the whole zone cache; all traffic hits the origin until the cache warms up again)"
+	}
+	if set != 1 {
+		return "Error: set exactly one of files, prefixes, hosts or everything."
+	}
+
+	z, err := h.cloudflare.GetZone(ctx, args.Zone)
+	if err != nil {
+		return fmt.Sprintf("Error getting Cloudflare zone: %v", err)
+	}
+	// Only a human's reply in the thread runs the purge; see RunApproved.
+	if !h.approvedRun("purge_cloudflare_cache", call) {
+		return h.requestApproval(ctx, call, "purge_cloudflare_cache", fmt.Sprintf("Purge from Cloudflare zone %s: %s", z.Name, what))
+	}
+	if err := h.cloudflare.PurgeCache(ctx, z.ID, req); err != nil {
+		return fmt.Sprintf("Error purging Cloudflare cache: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] purged Cloudflare cache of %s: %s", call.UserID, call.ChannelID, z.Name, what)
+	return fmt.Sprintf("Purged from %s: %s", z.Name, what)
+}
+
+// topCounts returns the n most frequent keys of events as "key (count), ...".
+func topCounts(events []cloudflare.FirewallEvent, key func(cloudflare.FirewallEvent) string, n int) string {
+	counts := map[string]int{}
+	for _, e := range events {
+		counts[key(e)]++
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, 0, n)
+	for _, k := range keys[:min(len(keys), n)] {
+		if k == "" {
+			k = "(none)"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
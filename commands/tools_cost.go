@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/cost"
+	"github.com/justmike1/ovad/logging"
+)
+
+// maxCostGroups caps the services or accounts listed per cost report.
+const maxCostGroups = 15
+
+// costTools answer coarse cloud cost questions from AWS Cost Explorer or the
+// GCP billing export. Results are cached until the end of the day. Offered
+// when at least one cost source is configured.
+var costTools = []*ToolDef{
+	{
+		Name:        "query_cloud_costs",
+		Description: "Get cloud costs for a period from AWS Cost Explorer or the GCP billing export: the total, the largest services or accounts/projects with their share, and the daily totals. Use it for coarse questions like 'what did the staging account cost last week?' or 'which service drove last month's bill?'. Amounts are unblended (AWS) or net of credits (GCP); recent days may still change. Results are cached for the rest of the day.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"provider":{"type":"string","enum":["aws","gcp"],"description":"Cloud provider (default: the only configured one)"},
+				"period":{"type":"string","enum":["yesterday","last_7_days","last_week","month_to_date","last_month","last_30_days"],"description":"Named period in UTC (default: last_7_days); ignored when start is set"},
+				"start":{"type":"string","description":"First day, YYYY-MM-DD"},
+				"end":{"type":"string","description":"Last day to include, YYYY-MM-DD (default: yesterday)"},
+				"scope":{"type":"string","description":"AWS account ID or name (e.g. 'staging'), or GCP project ID (default: everything)"},
+				"group_by":{"type":"string","enum":["service","account","none"],"description":"Break the total down by service (default) or by AWS account / GCP project"}
+			}
+		}`),
+		Available: (*GeneralHandler).costConfigured,
+		Run:       (*GeneralHandler).toolQueryCloudCosts,
+	},
+}
+
+func (h *GeneralHandler) costConfigured() bool { return len(h.costSources) > 0 }
+
+func (h *GeneralHandler) toolQueryCloudCosts(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Provider string `json:"provider"`
+		Period   string `json:"period"`
+		Start    string `json:"start"`
+		End      string `json:"end"`
+		Scope    string `json:"scope"`
+		GroupBy  string `json:"group_by"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+
+	var src cost.Source
+	var names []string
+	for _, s := range h.costSources {
+		names = append(names, s.Name())
+		if s.Name() == args.Provider || (args.Provider == "" && len(h.costSources) == 1) {
+			src = s
+		}
+	}
+	if src == nil {
+		if args.Provider == "" {
+			return fmt.Sprintf("Error: provider is required (configured: %s).", strings.Join(names, ", "))
+		}
+		return fmt.Sprintf("Error: %s costs are not configured (configured: %s).", args.Provider, strings.Join(names, ", "))
+	}
+
+	q := cost.Query{Scope: strings.TrimSpace(args.Scope), GroupBy: args.GroupBy}
+	switch q.GroupBy {
+	case "":
+		q.GroupBy = "service"
+	case "none":
+		q.GroupBy = ""
+	}
+	if args.Start != "" {
+		start, err := time.Parse(time.DateOnly, args.Start)
+		if err != nil {
+			return "Error: start must be YYYY-MM-DD."
+		}
+		end := time.Now().UTC().Truncate(24 * time.Hour)
+		if args.End != "" {
+			last, err := time.Parse(time.DateOnly, args.End)
+			if err != nil {
+				return "Error: end must be YYYY-MM-DD."
+			}
+			end = last.AddDate(0, 0, 1)
+		}
+		if !end.After(start) {
+			return "Error: end must not be before start."
+		}
+		q.Start, q.End = start, end
+	} else {
+		var err error
+		if q.Start, q.End, err = cost.Period(args.Period, time.Now().UTC()); err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+	}
+	if q.End.Sub(q.Start) > 366*24*time.Hour {
+		return "Error: the period can be at most a year."
+	}
+
+	report, cached, err := h.costCache.Costs(ctx, src, q)
+	if err != nil {
+		return fmt.Sprintf("Error querying %s costs: %v", strings.ToUpper(src.Name()), err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] queried %s costs %s–%s (scope=%q, cached=%t)", call.UserID, call.ChannelID, src.Name(), q.Start.Format(time.DateOnly), q.End.Format(time.DateOnly), q.Scope, cached)
+
+	var sb strings.Builder
+	scope := "all accounts"
+	if src.Name() == "gcp" {
+		scope = "all projects"
+	}
+	if report.Scope != "" {
+		scope = report.Scope
+	}
+	fmt.Fprintf(&sb, "%s costs for %s, %s to %s: *%s %s*", strings.ToUpper(src.Name()), scope,
+		q.Start.Format(time.DateOnly), q.End.AddDate(0, 0, -1).Format(time.DateOnly), formatCost(report.Total), report.Currency)
+	if report.Estimated {
+		sb.WriteString(" (estimated; recent days are not final)")
+	}
+	sb.WriteString("\n")
+	if cached {
+		fmt.Fprintf(&sb, "(cached from %s UTC)\n", report.FetchedAt.UTC().Format("15:04"))
+	}
+
+	if len(report.Groups) > 0 {
+		label := "service"
+		if q.GroupBy == "account" {
+			label = "account"
+			if src.Name() == "gcp" {
+				label = "project"
+			}
+		}
+		fmt.Fprintf(&sb, "\nBy %s:\n", label)
+		var rest float64
+		for i, g := range report.Groups {
+			if i >= maxCostGroups {
+				rest += g.Amount
+				continue
+			}
+			share := 0.0
+			if report.Total != 0 {
+				share = 100 * g.Amount / report.Total
+			}
+			fmt.Fprintf(&sb, "- %s: %s (%.1f%%)\n", g.Key, formatCost(g.Amount), share)
+		}
+		if rest != 0 {
+			fmt.Fprintf(&sb, "- %d others: %s\n", len(report.Groups)-maxCostGroups, formatCost(rest))
+		}
+	}
+	if len(report.Daily) > 1 && len(report.Daily) <= 31 {
+		sb.WriteString("\nDaily:\n")
+		for _, d := range report.Daily {
+			fmt.Fprintf(&sb, "- %s %s: %s\n", d.Date.Format(time.DateOnly), d.Date.Weekday().String()[:3], formatCost(d.Amount))
+		}
+	}
+	return sb.String()
+}
+
+// formatCost renders an amount with two decimals and thousands separators,
+// e.g. 12345.678 → "12,345.68".
+func formatCost(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	var sb strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	return sign + sb.String() + "." + frac
+}
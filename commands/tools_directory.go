@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/directory"
+	"github.com/justmike1/ovad/logging"
+)
+
+// directoryTools look people up in the company directory (Okta or Azure AD):
+// title, department, manager chain and groups. Offered only when a directory
+// is configured.
+var directoryTools = []*ToolDef{
+	{
+		Name:        "lookup_person",
+		Description: "Look a person up in the company directory by email or name: title, department, team, location, manager chain and groups. Use it for escalations ('page the service owner's manager' — resolve the owner, then their manager), to find who someone reports to, or to get a reliable email before resolve_jira_user / resolve_github_user. Prefer an email (from get_slack_user_info) over a name.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"query":{"type":"string","description":"Email (preferred) or full or partial name"},
+				"manager_levels":{"type":"integer","description":"How many levels of the management chain to return (default: 1, max: 5; 0 = none)"},
+				"include_groups":{"type":"boolean","description":"Include the directory groups the person belongs to (default: true)"}
+			},
+			"required":["query"]
+		}`),
+		Available: (*GeneralHandler).directoryConfigured,
+		Run:       (*GeneralHandler).toolLookupPerson,
+	},
+}
+
+func (h *GeneralHandler) directoryConfigured() bool { return h.directory != nil }
+
+func (h *GeneralHandler) toolLookupPerson(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Query         string `json:"query"`
+		ManagerLevels *int   `json:"manager_levels"`
+		IncludeGroups *bool  `json:"include_groups"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return "Error: query is required."
+	}
+	levels := 1
+	if args.ManagerLevels != nil {
+		levels = max(0, min(*args.ManagerLevels, 5))
+	}
+
+	people, err := h.directory.Find(ctx, args.Query)
+	if err != nil {
+		return fmt.Sprintf("Error searching %s: %v", h.directory.Name(), err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] looked up %q in %s: %d match(es)", call.UserID, call.ChannelID, args.Query, h.directory.Name(), len(people))
+	switch len(people) {
+	case 0:
+		return fmt.Sprintf("No active %s user matches %q.", h.directory.Name(), args.Query)
+	case 1:
+	default:
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d %s users match %q — ask which one is meant, or search by email:\n", len(people), h.directory.Name(), args.Query)
+		for _, p := range people {
+			fmt.Fprintf(&sb, "• %s <%s>%s\n", p.Name, p.Email, personRole(&p))
+		}
+		return sb.String()
+	}
+
+	p := &people[0]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* <%s>%s\n", p.Name, p.Email, personRole(p))
+	if p.Team != "" {
+		fmt.Fprintf(&sb, "Team: %s\n", p.Team)
+	}
+	if p.Location != "" {
+		fmt.Fprintf(&sb, "Location: %s\n", p.Location)
+	}
+
+	if levels > 0 {
+		chain := []string{}
+		for cur := p; len(chain) < levels; {
+			m, err := h.directory.Manager(ctx, cur)
+			if err != nil {
+				chain = append(chain, fmt.Sprintf("(lookup failed: %v)", err))
+				break
+			}
+			if m == nil {
+				break
+			}
+			chain = append(chain, fmt.Sprintf("%s <%s>%s", m.Name, m.Email, personRole(m)))
+			cur = m
+		}
+		if len(chain) == 0 {
+			sb.WriteString("Manager: (none set in the directory)\n")
+		} else {
+			sb.WriteString("Management chain:\n")
+			for i, c := range chain {
+				fmt.Fprintf(&sb, "%d. %s\n", i+1, c)
+			}
+		}
+	}
+
+	if args.IncludeGroups == nil || *args.IncludeGroups {
+		if groups, err := h.directory.Groups(ctx, p); err != nil {
+			fmt.Fprintf(&sb, "Groups: (lookup failed: %v)\n", err)
+		} else if len(groups) > 0 {
+			fmt.Fprintf(&sb, "Groups (%d): %s\n", len(groups), strings.Join(groups, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// personRole formats " — title, department" for a directory person.
+func personRole(p *directory.Person) string {
+	var parts []string
+	for _, s := range []string{p.Title, p.Department} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " — " + strings.Join(parts, ", ")
+}
+
+// directoryEmail returns the email of the single active directory user
+// matching name, for lookups that work better by email.
+func (h *GeneralHandler) directoryEmail(ctx context.Context, name string) string {
+	if h.directory == nil || strings.TrimSpace(name) == "" {
+		return ""
+	}
+	people, err := h.directory.Find(ctx, name)
+	if err != nil || len(people) != 1 {
+		return ""
+	}
+	return people[0].Email
+}
@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// gcpTools describe Google Cloud resource state — Cloud Run revisions, GKE
+// workloads and Error Reporting groups — for services hosted on GCP. They
+// call read-only APIs with the pod's Google identity. Offered only when a
+// GCP project is configured.
+var gcpTools = []*ToolDef{
+	{
+		Name:        "list_cloud_run_revisions",
+		Description: "Get a Cloud Run service's status and its recent revisions: which revision serves how much traffic, image, creation time and whether each revision is ready (with the failure reason). Use it when a Cloud Run service is failing, a deploy didn't take effect, or to find the revision a regression shipped in.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"service":{"type":"string","description":"Cloud Run service name"},
+				"region":{"type":"string","description":"Region, e.g. 'europe-west1' (default: the configured region)"},
+				"max_revisions":{"type":"integer","description":"Number of recent revisions (default: 10, max: 50)"}
+			},
+			"required":["service"]
+		}`),
+		Available: (*GeneralHandler).gcpConfigured,
+		Run:       (*GeneralHandler).toolListCloudRunRevisions,
+	},
+	{
+		Name:        "list_gke_workloads",
+		Description: "List the Deployments, StatefulSets and DaemonSets of a GKE cluster with ready/desired replicas, images and failing conditions (e.g. ProgressDeadlineExceeded). Use it when pods aren't coming up, a rollout is stuck, or to check which image a workload runs.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"cluster":{"type":"string","description":"GKE cluster name"},
+				"location":{"type":"string","description":"Cluster region or zone (default: the configured region)"},
+				"namespace":{"type":"string","description":"Only this namespace (default: all)"},
+				"search":{"type":"string","description":"Only workloads whose name contains this"},
+				"problems_only":{"type":"boolean","description":"Only workloads that are not fully ready and up to date"}
+			},
+			"required":["cluster"]
+		}`),
+		Available: (*GeneralHandler).gcpConfigured,
+		Run:       (*GeneralHandler).toolListGKEWorkloads,
+	},
+	{
+		Name:        "list_gcp_errors",
+		Description: "List recent error groups from Google Cloud Error Reporting, most recently seen first: message (top of the stack trace), service and version, count, affected users, first and last seen. Use it for 'what errors is <service> throwing?' or to check whether an error started with a new version.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"service":{"type":"string","description":"Only errors of this service (as reported in the service context, e.g. the Cloud Run service name)"},
+				"hours":{"type":"integer","description":"How far back to look; rounded up to 1, 6, 24, 168 or 720 hours (default: 24)"},
+				"max_results":{"type":"integer","description":"Maximum number of error groups (default: 15, max: 50)"}
+			}
+		}`),
+		Available: (*GeneralHandler).gcpConfigured,
+		Run:       (*GeneralHandler).toolListGCPErrors,
+	},
+}
+
+func (h *GeneralHandler) gcpConfigured() bool { return h.gcpClient != nil }
+
+func (h *GeneralHandler) toolListCloudRunRevisions(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Service      string `json:"service"`
+		Region       string `json:"region"`
+		MaxRevisions int    `json:"max_revisions"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Service) == "" {
+		return "Error: service is required."
+	}
+	limit := 10
+	if args.MaxRevisions > 0 {
+		limit = min(args.MaxRevisions, 50)
+	}
+	svc, err := h.gcpClient.GetRunService(ctx, args.Region, args.Service)
+	if err != nil {
+		return fmt.Sprintf("Error getting Cloud Run service: %v", err)
+	}
+	revs, err := h.gcpClient.ListRunRevisions(ctx, args.Region, args.Service, limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing Cloud Run revisions: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d Cloud Run revisions of %s", call.UserID, call.ChannelID, len(revs), args.Service)
+
+	summary := fmt.Sprintf("*%s* (%s) — %s", svc.Name, svc.URL, strings.TrimPrefix(svc.Ready, "CONDITION_"))
+	if svc.ReadyMessage != "" {
+		summary += " (" + svc.ReadyMessage + ")"
+	}
+	summary += fmt.Sprintf("\nLatest ready revision: %s", svc.LatestReady)
+	if svc.LatestCreated != svc.LatestReady {
+		summary += fmt.Sprintf(" — latest created %s is NOT ready", svc.LatestCreated)
+	}
+	summary += fmt.Sprintf("\nLast deployed %s by %s", svc.UpdateTime.UTC().Format("2006-01-02 15:04 MST"), svc.LastModifier)
+
+	table := &Table{
+		Name:    "cloud-run-revisions",
+		Columns: []string{"Revision", "Traffic", "Ready", "Created", "Image", "Instances", "Message"},
+		Inline:  5,
+	}
+	for _, r := range revs {
+		traffic := ""
+		if p, ok := svc.TrafficPercent[r.Name]; ok {
+			traffic = fmt.Sprintf("%d%%", p)
+		}
+		table.Rows = append(table.Rows, []string{
+			r.Name, traffic, fmt.Sprint(r.Ready), r.CreateTime.UTC().Format("2006-01-02 15:04"), r.Image,
+			fmt.Sprintf("%d–%d", r.MinInstances, r.MaxInstances), r.Message,
+		})
+	}
+	return h.presentTable(call, summary, table)
+}
+
+func (h *GeneralHandler) toolListGKEWorkloads(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Cluster      string `json:"cluster"`
+		Location     string `json:"location"`
+		Namespace    string `json:"namespace"`
+		Search       string `json:"search"`
+		ProblemsOnly bool   `json:"problems_only"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Cluster) == "" {
+		return "Error: cluster is required."
+	}
+	workloads, err := h.gcpClient.ListWorkloads(ctx, args.Location, args.Cluster, args.Namespace, args.Search)
+	if err != nil {
+		return fmt.Sprintf("Error listing GKE workloads: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d GKE workloads in %s", call.UserID, call.ChannelID, len(workloads), args.Cluster)
+
+	table := &Table{
+		Name:    "gke-workloads",
+		Columns: []string{"Namespace", "Workload", "Kind", "Ready", "Up-to-date", "Problems", "Images"},
+		Inline:  6,
+	}
+	for _, w := range workloads {
+		healthy := w.Ready >= w.Desired && w.Updated >= w.Desired && w.Generated && len(w.Problems) == 0
+		if args.ProblemsOnly && healthy {
+			continue
+		}
+		problems := strings.Join(w.Problems, "; ")
+		if !w.Generated {
+			problems = strings.TrimPrefix(problems+"; rollout not yet observed", "; ")
+		}
+		table.Rows = append(table.Rows, []string{
+			w.Namespace, w.Name, w.Kind, fmt.Sprintf("%d/%d", w.Ready, w.Desired), fmt.Sprintf("%d/%d", w.Updated, w.Desired),
+			problems, strings.Join(w.Images, ", "),
+		})
+	}
+	if len(table.Rows) == 0 {
+		if args.ProblemsOnly {
+			return fmt.Sprintf("All %d matching workloads in %s are ready and up to date.", len(workloads), args.Cluster)
+		}
+		return fmt.Sprintf("No matching workloads in %s.", args.Cluster)
+	}
+	return h.presentTable(call, fmt.Sprintf("%d workload(s) in GKE cluster %s.", len(table.Rows), args.Cluster), table)
+}
+
+func (h *GeneralHandler) toolListGCPErrors(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Service    string `json:"service"`
+		Hours      int    `json:"hours"`
+		MaxResults int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	hours := 24
+	if args.Hours > 0 {
+		hours = args.Hours
+	}
+	limit := 15
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 50)
+	}
+	groups, err := h.gcpClient.ListErrorGroups(ctx, args.Service, hours, limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing Error Reporting groups: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d GCP error groups (service=%q)", call.UserID, call.ChannelID, len(groups), args.Service)
+	if len(groups) == 0 {
+		return fmt.Sprintf("No errors reported in project %s in the last %dh.", h.gcpClient.Project(), hours)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].LastSeen.After(groups[j].LastSeen) })
+
+	table := &Table{
+		Name:    "gcp-errors",
+		Columns: []string{"Error", "Service", "Version", "Count", "Users", "First Seen", "Last Seen", "Status", "URL"},
+		Inline:  7,
+	}
+	for _, g := range groups {
+		msg, _, _ := strings.Cut(g.Message, "\n")
+		if len(msg) > 160 {
+			msg = msg[:160] + "…"
+		}
+		table.Rows = append(table.Rows, []string{
+			msg, g.Service, g.Version, fmt.Sprint(g.Count), fmt.Sprint(g.AffectedUsers),
+			g.FirstSeen.UTC().Format("2006-01-02 15:04"), formatAgo(g.LastSeen), g.Resolution, h.gcpClient.ErrorGroupURL(g.ID),
+		})
+	}
+	return h.presentTable(call, fmt.Sprintf("%d error group(s) in project %s.", len(groups), h.gcpClient.Project()), table)
+}
+
+// formatAgo renders t as "5m ago", "3h ago" or a date for older times.
+func formatAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return t.UTC().Format("2006-01-02 15:04")
+	}
+}
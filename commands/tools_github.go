@@ -0,0 +1,1635 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/scm"
+)
+
+// githubTools read and change repositories, pull requests, and CI runs. The
+// core file, pull request and pipeline tools go through the configured SCM
+// provider (GitHub or GitLab); the rest need GitHub.
+var githubTools = []*ToolDef{
+	{
+		Name:        "list_org_repos",
+		Description: "List all repositories in the organization (GitLab: group) that the bot has access to. On GitHub, most recently pushed first, with language, open PR count, last push date, and description.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).scmConfigured,
+		Run:         (*GeneralHandler).toolListOrgRepos,
+	},
+	{
+		Name:        "list_user_repos",
+		Description: "List all repositories accessible by the authenticated GitHub user.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).githubConfigured,
+		Run:         (*GeneralHandler).toolListUserRepos,
+	},
+	{
+		Name:        "get_file_content",
+		Description: "Read the content of a file from a repository.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"File path within the repository"},
+				"branch":{"type":"string","description":"Branch name (optional, uses default branch if empty)"}
+			},
+			"required":["repo","path"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolGetFileContent,
+	},
+	{
+		Name:        "get_repo_default_branch",
+		Description: "Get the default branch name of a repository.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolGetRepoDefaultBranch,
+	},
+	{
+		Name:        "get_authenticated_user",
+		Description: "Get the GitHub username of the authenticated bot user.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).githubConfigured,
+		Run:         (*GeneralHandler).toolGetAuthenticatedUser,
+	},
+	{
+		Name:        "resolve_owner",
+		Description: "Resolve the organization, group or user that owns repositories.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).scmConfigured,
+		Run:         (*GeneralHandler).toolResolveOwner,
+	},
+	{
+		Name:        "search_files",
+		Description: "Search for files in a repository by name or path pattern. Returns all file paths containing the search term. Use this FIRST when looking for a specific file — it is much faster than navigating directories one by one.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"pattern":{"type":"string","description":"Search term to match against file paths (case-insensitive, e.g. 'services.yaml' or 'amplify/main.tf')"},
+				"branch":{"type":"string","description":"Branch name (optional, uses default branch if empty)"}
+			},
+			"required":["repo","pattern"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolSearchFiles,
+	},
+	{
+		Name:        "list_directory",
+		Description: "List the files and subdirectories at a path in a repository. Use this when get_file_content fails because a path is a directory, or when you need to discover what files exist under a path.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"Directory path within the repository"},
+				"branch":{"type":"string","description":"Branch name (optional, uses default branch if empty)"}
+			},
+			"required":["repo","path"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolListDirectory,
+	},
+	{
+		Name:        "modify_file",
+		Description: "Modify a file in a repository using a safe find-and-replace approach. Provide the exact text to find (old_content) and the replacement text (new_content). The tool reads the FULL file, performs the replacement, then creates a branch, commits, and opens a PR. Multiple modify_file calls for the SAME repository are automatically grouped into a SINGLE pull request — so when implementing a change that touches several files, just call modify_file for each file and all changes will land in one PR. IMPORTANT: old_content must be an exact substring of the current file — include enough surrounding lines (3-5) will ensure a unique match. Only the matched section is replaced; the rest of the file is preserved.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"File path within the repository"},
+				"old_content":{"type":"string","description":"The exact text in the current file to find and replace. Include 3-5 surrounding context lines to ensure a unique match."},
+				"new_content":{"type":"string","description":"The replacement text that will replace old_content."},
+				"description":{"type":"string","description":"Short description of what was changed (used as commit message and PR title)"},
+				"branch":{"type":"string","description":"Base branch name (optional, uses default branch if empty)"}
+			},
+			"required":["repo","path","old_content","new_content","description"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolModifyFile,
+		Preview:    (*GeneralHandler).previewModifyFile,
+	},
+	{
+		Name:        "rewrite_file",
+		Description: "Replace the ENTIRE content of an existing file and commit it (grouped into the same PR as other changes to this repo in this request). Only use this when most of the file changes (e.g. reformatting, regenerating, or restructuring it); for targeted edits use modify_file. Always read the file with get_file_content first and provide the complete new content — anything omitted is deleted.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"File path within the repository"},
+				"content":{"type":"string","description":"The complete new file content"},
+				"description":{"type":"string","description":"Short description of the change (used in the commit message and PR title)"},
+				"branch":{"type":"string","description":"Base branch name (optional, uses default branch if empty)"}
+			},
+			"required":["repo","path","content","description"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolRewriteFile,
+		Preview:    (*GeneralHandler).previewRewriteFile,
+	},
+	{
+		Name:        "commit_files",
+		Description: "Change several files in a repository in ONE commit (created atomically). Prefer this over repeated modify_file/rewrite_file calls when a change spans multiple files. Each file either gives the complete new content, an exact old_content/new_content replacement (like modify_file), or delete: true. New files need content. Read files with get_file_content first. The commit joins the same PR as other changes to this repo in this request.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"files":{"type":"array","description":"Files to change","items":{
+					"type":"object",
+					"properties":{
+						"path":{"type":"string","description":"File path within the repository"},
+						"content":{"type":"string","description":"Complete new file content (new files or full rewrites)"},
+						"old_content":{"type":"string","description":"Exact text in the current file to replace"},
+						"new_content":{"type":"string","description":"Replacement for old_content"},
+						"delete":{"type":"boolean","description":"Delete the file"}
+					},
+					"required":["path"]
+				}},
+				"description":{"type":"string","description":"Short description of the change (used in the commit message and PR title)"},
+				"branch":{"type":"string","description":"Base branch name (optional, uses default branch if empty)"}
+			},
+			"required":["repo","files","description"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolCommitFiles,
+	},
+	{
+		Name:        "get_pull_request",
+		Description: "Get details, changed files, and diff of a pull request (GitLab: merge request) by number or URL. Use this to analyze what a PR changed, understand code patterns introduced or removed, and find old/new usage patterns.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"number":{"type":"integer","description":"Pull request number (e.g., 123)"},
+				"url":{"type":"string","description":"Full GitHub PR URL (alternative to repo+number). If provided, repo and number are extracted from it."}
+			},
+			"required":[]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolGetPullRequest,
+	},
+	{
+		Name:        "list_pull_requests",
+		Description: "List recent pull requests in a repository with their review decision and combined check status. Useful for finding relevant PRs by title, discovering recent changes, or identifying the PR that introduced a particular change.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"state":{"type":"string","description":"Filter by state: 'open', 'closed', or 'all' (default: 'all')"},
+				"limit":{"type":"integer","description":"Maximum number of PRs to return (default: 10, max: 30)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolListPullRequests,
+	},
+	{
+		Name:        "merge_pull_request",
+		Description: "Merge an open GitHub pull request. Before merging, the tool checks the PR against the base branch's protection rules — required approving reviews, requested changes, failing or pending status checks, merge conflicts, and up-to-date requirements — and refuses with the list of blockers if any apply. Only call this when the user explicitly asks to merge a specific PR.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"number":{"type":"integer","description":"Pull request number"},
+				"method":{"type":"string","enum":["merge","squash","rebase"],"description":"Merge method (default: 'squash')"},
+				"commit_title":{"type":"string","description":"Title of the merge or squash commit (optional, GitHub's default if empty)"}
+			},
+			"required":["repo","number"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolMergePullRequest,
+	},
+	{
+		Name:        "close_pull_request",
+		Description: "Close a GitHub pull request without merging it, optionally leaving a comment explaining why. Only call this when the user explicitly asks to close a specific PR.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"number":{"type":"integer","description":"Pull request number"},
+				"comment":{"type":"string","description":"Optional comment posted on the PR before closing it"}
+			},
+			"required":["repo","number"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolClosePullRequest,
+	},
+	{
+		Name:        "update_pr_branch",
+		Description: "Bring a GitHub pull request's branch up to date by merging the latest base branch into it (the 'Update branch' button). Use this when a merge is blocked because the branch is behind its base. It cannot resolve merge conflicts.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"number":{"type":"integer","description":"Pull request number"}
+			},
+			"required":["repo","number"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolUpdatePRBranch,
+	},
+	{
+		Name:        "search_code",
+		Description: "Search for code content within a GitHub repository. Unlike search_files (which matches file names/paths), this searches inside file contents. Use this to find usages of functions, classes, patterns, imports, or any code string across the entire repository. Returns matching files with code fragments showing the context around each match.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"query":{"type":"string","description":"Code search query, without repo:, org: or user: qualifiers. Can include the code pattern to find (e.g., 'db.session', 'SessionLocal()', 'def create_session'). Supports GitHub code search qualifiers like 'language:python', 'path:src/', 'extension:py'."}
+			},
+			"required":["repo","query"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolSearchCode,
+	},
+	{
+		Name:        "list_commits",
+		Description: "List commits in a GitHub repository, newest first, optionally only those touching a file or directory, by an author, or within a time range. Use this to answer questions like 'what changed in this file in the last week' or 'what did alice merge yesterday' with real history. For 'when did this config last change and by whom', pass path with limit 1, then get_commit for the change itself.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"Only commits touching this file or directory (optional)"},
+				"author":{"type":"string","description":"GitHub login or email of the commit author (optional)"},
+				"since":{"type":"string","description":"Only commits after this time: a date (2024-05-01), an RFC 3339 timestamp, or a relative age like '7d', '2w', '36h' (optional)"},
+				"until":{"type":"string","description":"Only commits before this time, same formats as since (optional)"},
+				"branch":{"type":"string","description":"Branch, tag or SHA to list history from (optional, uses the default branch if empty)"},
+				"limit":{"type":"integer","description":"Maximum number of commits (default: 20, max: 100)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolListCommits,
+	},
+	{
+		Name:        "git_blame",
+		Description: "Show who last changed each line of a file (git blame), with the commit, author, date, and commit message for each run of lines. Use this for 'who last touched this function' — read the file first to find the function's line range, then blame that range.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"path":{"type":"string","description":"File path within the repository"},
+				"start_line":{"type":"integer","description":"First line to show (optional, default 1)"},
+				"end_line":{"type":"integer","description":"Last line to show (optional, default: start_line + 199)"},
+				"branch":{"type":"string","description":"Branch, tag or SHA (optional, uses the default branch if empty)"}
+			},
+			"required":["repo","path"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolGitBlame,
+	},
+	{
+		Name:        "get_commit",
+		Description: "Show one commit in a GitHub repository: full message, author, date, the pull requests it belongs to, and the files it changed with their diffs. Use it after list_commits or git_blame to see exactly what a change did, without fetching the whole pull request. Pass path to see only that file's diff.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"sha":{"type":"string","description":"Commit SHA (full or abbreviated), or a branch or tag for its latest commit"},
+				"path":{"type":"string","description":"Only show the diff of this file (optional)"}
+			},
+			"required":["repo","sha"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolGetCommit,
+	},
+	{
+		Name:        "get_workflow_run",
+		Description: "Fetch details and logs for a GitHub Actions workflow run or GitLab pipeline. Use this PROACTIVELY whenever you see a failed CI/CD notification, a GitHub Actions or GitLab pipeline URL, or the user mentions a build/deploy/pipeline failure. Returns the run status, jobs, steps, annotations, and actual log output for any failed jobs so you can diagnose the root cause.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"url":{"type":"string","description":"Full GitHub Actions workflow run URL (e.g., 'https://github.com/org/repo/actions/runs/12345') or GitLab pipeline URL (e.g., 'https://gitlab.com/group/project/-/pipelines/12345'). Extract this from channel context messages — look for 'View Workflow Run' button URLs or similar links."}
+			},
+			"required":["url"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).scmConfigured,
+		Run:        (*GeneralHandler).toolGetWorkflowRun,
+	},
+	{
+		Name:        "get_check_runs",
+		Description: "List every check on a commit or pull request: GitHub check runs from any app (GitHub Actions, Buildkite, CircleCI, ...) and commit statuses reported by external CI. Use this when a PR is blocked by a check that get_workflow_run cannot open, or to see which gates passed. Returns each check's result, reporting app, output summary, annotations of failing check runs, and a details link to the external CI page. Use get_workflow_run on a GitHub Actions run URL for its job logs.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"ref":{"type":"string","description":"Commit SHA, branch or tag to inspect"},
+				"number":{"type":"integer","description":"Pull request number; its head commit is inspected. Alternative to ref."},
+				"failing_only":{"type":"boolean","description":"Only list failing and pending checks (default: false)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolGetCheckRuns,
+	},
+	{
+		Name:        "rerun_failed_jobs",
+		Description: "Re-run only the failed jobs (and their dependent jobs) in a GitHub Actions workflow run. This is equivalent to clicking 'Re-run failed jobs' in the GitHub Actions UI. Use this when the user asks to retry, rerun, or re-trigger a failed workflow. Only works on completed runs that have at least one failed job.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"url":{"type":"string","description":"Full GitHub Actions workflow run URL (e.g., 'https://github.com/org/repo/actions/runs/12345')."}
+			},
+			"required":["url"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolRerunFailedJobs,
+	},
+	{
+		Name:        "rerun_workflow",
+		Description: "Re-run an entire GitHub Actions workflow run (all jobs, not just failed ones). This is equivalent to clicking 'Re-run all jobs' in the GitHub Actions UI. Use this when the user wants to completely re-trigger a workflow from scratch.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"url":{"type":"string","description":"Full GitHub Actions workflow run URL (e.g., 'https://github.com/org/repo/actions/runs/12345')."}
+			},
+			"required":["url"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolRerunWorkflow,
+	},
+	{
+		Name:        "list_workflows",
+		Description: "List the GitHub Actions workflows in a repository, which of them can be triggered manually (workflow_dispatch), and their inputs with types, allowed options, defaults, and whether they are required. Call this before trigger_workflow to find the right workflow file and inputs.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"ref":{"type":"string","description":"Branch or tag to read the workflow files from (optional, uses the default branch if empty)"}
+			},
+			"required":["repo"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolListWorkflows,
+	},
+	{
+		Name:        "trigger_workflow",
+		Description: "Trigger a GitHub Actions workflow that has a workflow_dispatch trigger, e.g. 'run the deploy workflow on main with environment=staging'. Inputs are validated against the workflow file before dispatching. Call list_workflows first if you don't know the workflow file or its inputs, and confirm the ref and inputs with the user before triggering deploys.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"repo":{"type":"string","description":"Repository name (without owner)"},
+				"workflow":{"type":"string","description":"Workflow file name (e.g. 'deploy.yml') or workflow name as shown by list_workflows"},
+				"ref":{"type":"string","description":"Branch or tag to run the workflow on (optional, uses the default branch if empty)"},
+				"inputs":{"type":"object","additionalProperties":{"type":"string"},"description":"workflow_dispatch inputs as name → value (e.g. {\"environment\":\"staging\"})"}
+			},
+			"required":["repo","workflow"]
+		}`),
+		Class:      ToolWrite,
+		RepoAccess: "write",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolTriggerWorkflow,
+	},
+}
+
+func (h *GeneralHandler) toolListOrgRepos(ctx context.Context, call ToolCall) string {
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	if h.ghClient == nil {
+		return h.listRepoNames(ctx, call, owner)
+	}
+	details, err := h.ghClient.ListOrgRepoDetails(ctx, owner)
+	if err != nil {
+		logging.Ctx(ctx).Warnf("[user=%s channel=%s] GraphQL repo listing failed, falling back to REST: %v", call.UserID, call.ChannelID, err)
+		return h.listRepoNames(ctx, call, owner)
+	}
+
+	table := &Table{Name: "repositories", Columns: []string{"Repository", "Language", "Open PRs", "Last push", "Description", "Default branch", "Visibility"}, Inline: 5}
+	for _, r := range details {
+		if h.repoPolicy.Restricted() && !h.repoPolicy.AllowsRead(r.FullName) {
+			continue
+		}
+		name := r.FullName
+		if r.Archived {
+			name += " (archived)"
+		}
+		visibility := "public"
+		if r.Private {
+			visibility = "private"
+		}
+		table.Rows = append(table.Rows, []string{name, r.Language, strconv.Itoa(r.OpenPRs), r.PushedAt.Format("2006-01-02"), r.Description, r.DefaultBranch, visibility})
+	}
+	if len(table.Rows) == 0 {
+		return fmt.Sprintf("No repositories found for organization %s.", owner)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d org repos for %s", call.UserID, call.ChannelID, len(table.Rows), owner)
+	return h.presentTable(call, fmt.Sprintf("Organization: %s — %d repositories, most recently pushed first.", owner, len(table.Rows)), table)
+}
+
+// listRepoNames lists owner's repositories by name only, for hosts without
+// a details query.
+func (h *GeneralHandler) listRepoNames(ctx context.Context, call ToolCall, owner string) string {
+	repos, err := h.scm.ListRepos(ctx, owner)
+	if err != nil {
+		return fmt.Sprintf("Error listing org repos: %v", err)
+	}
+	repos = h.filterReadableRepos(repos)
+	if len(repos) == 0 {
+		return fmt.Sprintf("No repositories found for organization %s.", owner)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d org repos for %s", call.UserID, call.ChannelID, len(repos), owner)
+	return fmt.Sprintf("Organization: %s\nRepositories (%d):\n%s", owner, len(repos), strings.Join(repos, "\n"))
+}
+
+func (h *GeneralHandler) toolListUserRepos(ctx context.Context, call ToolCall) string {
+	repos, err := h.ghClient.ListUserRepos(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error listing user repos: %v", err)
+	}
+	repos = h.filterReadableRepos(repos)
+	if len(repos) == 0 {
+		return "No repositories found for the authenticated user."
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d user repos", call.UserID, call.ChannelID, len(repos))
+	return fmt.Sprintf("Repositories (%d):\n%s", len(repos), strings.Join(repos, "\n"))
+}
+
+func (h *GeneralHandler) toolGetFileContent(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo   string `json:"repo"`
+		Path   string `json:"path"`
+		Branch string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	branch := args.Branch
+	if branch == "" {
+		branch, err = h.scm.DefaultBranch(ctx, owner, args.Repo)
+		if err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+	content, err := h.scm.FileContent(ctx, owner, args.Repo, args.Path, branch)
+	if err != nil {
+		hint := ""
+		if strings.Contains(err.Error(), "404") {
+			hint = " This path may be a directory, or it may be nested under a provider subdirectory (e.g. aws/, azure/). Try list_directory on the parent path to discover the correct structure, then read the files you need."
+		}
+		return fmt.Sprintf("Error reading file: %v.%s", err, hint)
+	}
+	if len(content) > 8000 {
+		content = content[:8000] + "\n... (truncated — file is longer than shown, important content may follow)"
+	}
+	return content
+}
+
+func (h *GeneralHandler) toolGetRepoDefaultBranch(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo string `json:"repo"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	branch, err := h.scm.DefaultBranch(ctx, owner, args.Repo)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Default branch for %s: %s", args.Repo, branch)
+}
+
+func (h *GeneralHandler) toolGetAuthenticatedUser(ctx context.Context, call ToolCall) string {
+	user, err := h.ghClient.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Authenticated as: %s", user)
+}
+
+func (h *GeneralHandler) toolResolveOwner(ctx context.Context, call ToolCall) string {
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Resolved owner: %s", owner)
+}
+
+func (h *GeneralHandler) toolSearchFiles(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo    string `json:"repo"`
+		Pattern string `json:"pattern"`
+		Branch  string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	branch := args.Branch
+	if branch == "" {
+		branch, err = h.scm.DefaultBranch(ctx, owner, args.Repo)
+		if err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+	matches, err := h.scm.SearchFiles(ctx, owner, args.Repo, branch, args.Pattern)
+	if err != nil {
+		return fmt.Sprintf("Error searching files: %v", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("No files matching '%s' found in %s.", args.Pattern, args.Repo)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] searched files in %s for '%s' (%d matches)", call.UserID, call.ChannelID, args.Repo, args.Pattern, len(matches))
+	if len(matches) > 50 {
+		matches = matches[:50]
+		return fmt.Sprintf("Found %d+ matches (showing first 50):\n%s", len(matches), strings.Join(matches, "\n"))
+	}
+	return fmt.Sprintf("Found %d matches:\n%s", len(matches), strings.Join(matches, "\n"))
+}
+
+func (h *GeneralHandler) toolListDirectory(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo   string `json:"repo"`
+		Path   string `json:"path"`
+		Branch string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	branch := args.Branch
+	if branch == "" {
+		branch, err = h.scm.DefaultBranch(ctx, owner, args.Repo)
+		if err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+	entries, err := h.scm.ListDirectory(ctx, owner, args.Repo, args.Path, branch)
+	if err != nil {
+		return fmt.Sprintf("Error listing directory: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed directory %s/%s/%s (%d entries)", call.UserID, call.ChannelID, args.Repo, branch, args.Path, len(entries))
+	return fmt.Sprintf("Contents of %s/%s:\n%s", args.Repo, args.Path, strings.Join(entries, "\n"))
+}
+
+func (h *GeneralHandler) toolModifyFile(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Path        string `json:"path"`
+		OldContent  string `json:"old_content"`
+		NewContent  string `json:"new_content"`
+		Description string `json:"description"`
+		Branch      string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	baseBranch := args.Branch
+	if baseBranch == "" {
+		baseBranch, err = h.scm.DefaultBranch(ctx, owner, args.Repo)
+		if err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+
+	// Reuse an existing branch for this repo if one was created earlier in this session.
+	repoKey := owner + "/" + args.Repo
+	active := h.activeBranches[repoKey]
+
+	// Determine which branch to read the file from.
+	// If we already have an active branch, read from it (it may contain prior commits).
+	readBranch := baseBranch
+	if active != nil {
+		readBranch = active.branchName
+	}
+
+	fullContent, err := h.scm.FileContent(ctx, owner, args.Repo, args.Path, readBranch)
+	if err != nil {
+		return fmt.Sprintf("Error reading current file: %v", err)
+	}
+	// Perform find-and-replace on the full file content.
+	if !strings.Contains(fullContent, args.OldContent) {
+		return "Error: old_content not found in the file. Make sure old_content is an exact substring of the current file (including whitespace and indentation). Re-read the file with get_file_content and try again."
+	}
+	occurrences := strings.Count(fullContent, args.OldContent)
+	if occurrences > 1 {
+		return fmt.Sprintf("Error: old_content matches %d locations in the file. Include more surrounding context lines to make it unique.", occurrences)
+	}
+	updatedContent := strings.Replace(fullContent, args.OldContent, args.NewContent, 1)
+
+	return h.commitGroupedChange(ctx, call, "modify_file", owner, args.Repo, baseBranch, args.Description,
+		h.fileCommit(ctx, owner, args.Repo, args.Path, updatedContent))
+}
+
+func (h *GeneralHandler) toolRewriteFile(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Path        string `json:"path"`
+		Content     string `json:"content"`
+		Description string `json:"description"`
+		Branch      string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Content) == "" {
+		return "Error: content is empty. Provide the complete new file content."
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	baseBranch := args.Branch
+	if baseBranch == "" {
+		baseBranch, err = h.scm.DefaultBranch(ctx, owner, args.Repo)
+		if err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+
+	readBranch := baseBranch
+	if active := h.activeBranches[owner+"/"+args.Repo]; active != nil {
+		readBranch = active.branchName
+	}
+	if _, err := h.scm.FileContent(ctx, owner, args.Repo, args.Path, readBranch); err != nil {
+		return fmt.Sprintf("Error reading current file: %v", err)
+	}
+
+	return h.commitGroupedChange(ctx, call, "rewrite_file", owner, args.Repo, baseBranch, args.Description,
+		h.fileCommit(ctx, owner, args.Repo, args.Path, args.Content))
+}
+
+func (h *GeneralHandler) toolCommitFiles(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string     `json:"repo"`
+		Files       []fileEdit `json:"files"`
+		Description string     `json:"description"`
+		Branch      string     `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if len(args.Files) == 0 {
+		return "Error: files is empty."
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	baseBranch := args.Branch
+	if baseBranch == "" {
+		baseBranch, err = h.scm.DefaultBranch(ctx, owner, args.Repo)
+		if err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+	readBranch := baseBranch
+	if active := h.activeBranches[owner+"/"+args.Repo]; active != nil {
+		readBranch = active.branchName
+	}
+
+	// Resolve every file before creating anything, so a bad edit leaves no
+	// branch or partial commit behind.
+	changes, errMsg := h.resolveFileEdits(ctx, owner, args.Repo, readBranch, args.Files)
+	if errMsg != "" {
+		return errMsg
+	}
+
+	return h.commitGroupedChange(ctx, call, "commit_files", owner, args.Repo, baseBranch, args.Description, func(branch, message string) error {
+		return h.scm.CommitFiles(ctx, owner, args.Repo, branch, message, changes)
+	})
+}
+
+// fileEdit is one file of a multi-file change: complete content, an exact
+// old_content/new_content replacement, or a deletion.
+type fileEdit struct {
+	Path       string  `json:"path"`
+	Content    *string `json:"content"`
+	OldContent string  `json:"old_content"`
+	NewContent string  `json:"new_content"`
+	Delete     bool    `json:"delete"`
+}
+
+// resolveFileEdits turns edits into the file changes to commit, reading the
+// files they replace or delete from ref. It returns an error message for the
+// model when an edit doesn't apply.
+func (h *GeneralHandler) resolveFileEdits(ctx context.Context, owner, repo, ref string, edits []fileEdit) ([]github.FileChange, string) {
+	changes := make([]github.FileChange, 0, len(edits))
+	seen := make(map[string]bool, len(edits))
+	for _, f := range edits {
+		if f.Path == "" {
+			return nil, "Error: every file needs a path."
+		}
+		if seen[f.Path] {
+			return nil, fmt.Sprintf("Error: %s is listed more than once; combine its edits into one entry.", f.Path)
+		}
+		seen[f.Path] = true
+		switch {
+		case f.Delete:
+			if _, err := h.scm.FileContent(ctx, owner, repo, f.Path, ref); err != nil {
+				return nil, fmt.Sprintf("Error: cannot delete %s: %v", f.Path, err)
+			}
+			changes = append(changes, github.FileChange{Path: f.Path, Delete: true})
+		case f.Content != nil:
+			changes = append(changes, github.FileChange{Path: f.Path, Content: []byte(*f.Content)})
+		case f.OldContent != "":
+			current, err := h.scm.FileContent(ctx, owner, repo, f.Path, ref)
+			if err != nil {
+				return nil, fmt.Sprintf("Error reading %s: %v", f.Path, err)
+			}
+			switch n := strings.Count(current, f.OldContent); n {
+			case 0:
+				return nil, fmt.Sprintf("Error: old_content not found in %s. Re-read the file with get_file_content and try again.", f.Path)
+			case 1:
+			default:
+				return nil, fmt.Sprintf("Error: old_content matches %d locations in %s. Include more surrounding context lines to make it unique.", n, f.Path)
+			}
+			changes = append(changes, github.FileChange{Path: f.Path, Content: []byte(strings.Replace(current, f.OldContent, f.NewContent, 1))})
+		default:
+			return nil, fmt.Sprintf("Error: %s needs content, old_content/new_content, or delete.", f.Path)
+		}
+	}
+	return changes, ""
+}
+
+// commitGroupedChange commits a change via commit(branch, message). The first
+// change to a repo in this request creates a branch and PR; later changes
+// are committed to the same branch, so they land in a single PR.
+func (h *GeneralHandler) commitGroupedChange(ctx context.Context, call ToolCall, tool, owner, repo, baseBranch, description string, commit func(branch, message string) error) string {
+	repoKey := owner + "/" + repo
+	active := h.activeBranches[repoKey]
+	commitMsg := fmt.Sprintf("%s: %s", h.agentID, description)
+
+	if active == nil {
+		// First modification for this repo — create a new branch and PR.
+		branchName := github.GenerateBranchName(h.agentID)
+		if err := h.scm.CreateBranch(ctx, owner, repo, baseBranch, branchName); err != nil {
+			return fmt.Sprintf("Error creating branch: %v", err)
+		}
+		if err := commit(branchName, commitMsg); err != nil {
+			return fmt.Sprintf("Error committing changes: %v", err)
+		}
+		prTitle := fmt.Sprintf("%s: %s", h.agentID, description)
+		prBody := fmt.Sprintf("Automated change requested via Slack by %s.\n\nChange: %s", h.displayName(call.UserID), description)
+		prURL, err := h.scm.CreateChangeRequest(ctx, owner, repo, baseBranch, branchName, prTitle, prBody)
+		if err != nil {
+			return fmt.Sprintf("Changes committed to branch %s but PR creation failed: %v", branchName, err)
+		}
+		h.activeBranches[repoKey] = &activeBranchInfo{
+			branchName: branchName,
+			baseBranch: baseBranch,
+			prURL:      prURL,
+		}
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] PR created via %s: %s", call.UserID, call.ChannelID, tool, prURL)
+		return fmt.Sprintf("Pull request created: %s", prURL)
+	}
+
+	// Subsequent modification — commit to the existing branch.
+	if err := commit(active.branchName, commitMsg); err != nil {
+		return fmt.Sprintf("Error committing changes to existing branch: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] additional commit to branch %s for PR: %s", call.UserID, call.ChannelID, active.branchName, active.prURL)
+	return fmt.Sprintf("Changes committed to existing PR: %s", active.prURL)
+}
+
+// fileCommit returns a commit func that writes content to path.
+func (h *GeneralHandler) fileCommit(ctx context.Context, owner, repo, path, content string) func(branch, message string) error {
+	return func(branch, message string) error {
+		return h.scm.CommitFiles(ctx, owner, repo, branch, message, []scm.FileChange{{Path: path, Content: []byte(content)}})
+	}
+}
+
+func (h *GeneralHandler) toolGetPullRequest(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo   string `json:"repo"`
+		Number int    `json:"number"`
+		URL    string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	// If a URL was provided, extract owner/repo/number from it.
+	if args.URL != "" {
+		prOwner, prRepo, prNum, parseErr := h.scm.ParseChangeRequestURL(args.URL)
+		if parseErr != nil {
+			return fmt.Sprintf("Error parsing PR URL: %v", parseErr)
+		}
+		owner = prOwner
+		args.Repo = prRepo
+		args.Number = prNum
+	}
+	if args.Number == 0 {
+		return "Error: PR number or URL is required."
+	}
+	pr, err := h.scm.GetChangeRequest(ctx, owner, args.Repo, args.Number)
+	if err != nil {
+		return fmt.Sprintf("Error getting PR: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched PR #%d in %s/%s", call.UserID, call.ChannelID, args.Number, owner, args.Repo)
+	return github.FormatPRSummary(pr)
+}
+
+func (h *GeneralHandler) toolListPullRequests(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo  string `json:"repo"`
+		State string `json:"state"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.scm.Owner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	prs, err := h.scm.ListChangeRequests(ctx, owner, args.Repo, args.State, args.Limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing PRs: %v", err)
+	}
+	if len(prs) == 0 {
+		return fmt.Sprintf("No pull requests found in %s (state: %s).", args.Repo, args.State)
+	}
+	table := &Table{Name: "pull-requests", Columns: []string{"#", "Title", "State", "Author", "Review", "Checks", "URL"}, Inline: 6}
+	for _, pr := range prs {
+		state := pr.State
+		if pr.Draft {
+			state = "draft"
+		}
+		table.Rows = append(table.Rows, []string{strconv.Itoa(pr.Number), pr.Title, state, pr.Author, pr.ReviewDecision, pr.Checks, pr.URL})
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d PRs in %s", call.UserID, call.ChannelID, len(prs), args.Repo)
+	link := strings.TrimSuffix(prs[0].URL, strconv.Itoa(prs[0].Number)) + "<number>"
+	summary := fmt.Sprintf("Pull Requests in %s (%d). Links: %s.", args.Repo, len(prs), link)
+	return h.presentTable(call, summary, table)
+}
+
+// searchScopeQualifier matches code search qualifiers that pick which
+// repositories are searched.
+var searchScopeQualifier = regexp.MustCompile(`(?i)(?:^|\s)-?(?:repo|org|user):`)
+
+func (h *GeneralHandler) toolSearchCode(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo  string `json:"repo"`
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" {
+		return "Error: repo is required."
+	}
+	// The repository is scoped by the repo argument alone, which the
+	// allowlist checked; qualifiers in the query would widen the search.
+	if q := searchScopeQualifier.FindString(args.Query); q != "" {
+		return fmt.Sprintf("Error: the query must not contain %q; pass the repository in the repo argument instead.", strings.TrimSpace(q))
+	}
+	owner, repo, ok := strings.Cut(args.Repo, "/")
+	if !ok {
+		var err error
+		if owner, err = h.ghClient.ResolveOwner(ctx); err != nil {
+			return fmt.Sprintf("Error resolving owner: %v", err)
+		}
+		repo = args.Repo
+	}
+	results, err := h.ghClient.SearchCode(ctx, owner, repo, args.Query)
+	if err != nil {
+		return fmt.Sprintf("Error searching code: %v", err)
+	}
+	if h.repoPolicy.Restricted() {
+		kept := results[:0]
+		for _, r := range results {
+			if h.repoPolicy.AllowsRead(r.Repo) {
+				kept = append(kept, r)
+			}
+		}
+		results = kept
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No code matches found for '%s' in %s. Try different search terms, broader patterns, or check if the repository name is correct.", args.Query, args.Repo)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Code search results for '%s' in %s (%d matches):\n", args.Query, args.Repo, len(results))
+	for _, r := range results {
+		fmt.Fprintf(&sb, "\n• %s\n  %s\n", r.File, r.URL)
+		for _, frag := range r.Fragments {
+			fmt.Fprintf(&sb, "  ```\n  %s\n  ```\n", frag)
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] searched code in %s for '%s' (%d matches)", call.UserID, call.ChannelID, args.Repo, args.Query, len(results))
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolGetWorkflowRun(ctx context.Context, call ToolCall) string {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, repo, runID, err := h.scm.ParsePipelineURL(args.URL)
+	if err != nil {
+		return fmt.Sprintf("Error parsing workflow run URL: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetching workflow run %s/%s/%d", call.UserID, call.ChannelID, owner, repo, runID)
+	summary, err := h.scm.GetPipeline(ctx, owner, repo, runID)
+	if err != nil {
+		return fmt.Sprintf("Error fetching workflow run: %v", err)
+	}
+	result := github.FormatWorkflowRunSummary(summary)
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched workflow run %s/%s/%d (conclusion: %s)", call.UserID, call.ChannelID, owner, repo, runID, summary.Conclusion)
+	return result
+}
+
+func (h *GeneralHandler) toolGetCheckRuns(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Ref         string `json:"ref"`
+		Number      int    `json:"number"`
+		FailingOnly bool   `json:"failing_only"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || (args.Ref == "") == (args.Number == 0) {
+		return "Error: repo and exactly one of ref or number are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	ref, target := args.Ref, args.Ref
+	if args.Number != 0 {
+		if ref, err = h.ghClient.PullRequestHeadSHA(ctx, owner, args.Repo, args.Number); err != nil {
+			return fmt.Sprintf("Error getting pull request: %v", err)
+		}
+		target = fmt.Sprintf("PR #%d", args.Number)
+	}
+	checks, err := h.ghClient.ListCommitChecks(ctx, owner, args.Repo, ref)
+	if err != nil {
+		return fmt.Sprintf("Error listing checks: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d checks on %s/%s@%s", call.UserID, call.ChannelID, len(checks.Checks), owner, args.Repo, ref)
+	return formatCommitChecks(fmt.Sprintf("%s/%s %s", owner, args.Repo, target), checks, args.FailingOnly)
+}
+
+// formatCommitChecks renders a commit's checks for the model, failing first,
+// then pending, then passed.
+func formatCommitChecks(target string, c *github.CommitChecks, failingOnly bool) string {
+	if len(c.Checks) == 0 {
+		return fmt.Sprintf("No checks or commit statuses reported on %s (commit %s).", target, c.SHA)
+	}
+	var failing, pending, passed []github.Check
+	for _, ch := range c.Checks {
+		switch {
+		case ch.Status != "completed":
+			pending = append(pending, ch)
+		case github.CheckFailed(ch):
+			failing = append(failing, ch)
+		default:
+			passed = append(passed, ch)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Checks on %s (commit %s): %d failing, %d pending, %d passed.\n", target, c.SHA[:min(7, len(c.SHA))], len(failing), len(pending), len(passed))
+	writeChecks := func(heading string, checks []github.Check, detailed bool) {
+		if len(checks) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s:\n", heading)
+		for _, ch := range checks {
+			source := ch.App
+			if ch.Kind == "status" {
+				source = "commit status"
+			}
+			result := ch.Conclusion
+			if ch.Status != "completed" {
+				result = strings.ReplaceAll(ch.Status, "_", " ")
+			}
+			fmt.Fprintf(&sb, "- %s [%s] %s", ch.Name, source, result)
+			if ch.URL != "" {
+				fmt.Fprintf(&sb, " — %s", ch.URL)
+			}
+			sb.WriteString("\n")
+			if !detailed {
+				continue
+			}
+			if ch.Title != "" {
+				fmt.Fprintf(&sb, "  Title: %s\n", ch.Title)
+			}
+			if summary := strings.TrimSpace(ch.Summary); summary != "" {
+				fmt.Fprintf(&sb, "  Summary: %s\n", strings.ReplaceAll(truncateRunes(summary, 800), "\n", "\n  "))
+			}
+			for _, ann := range ch.Annotations {
+				msg := ann.Message
+				if ann.Title != "" {
+					msg = ann.Title + ": " + msg
+				}
+				fmt.Fprintf(&sb, "  [%s] %s\n", strings.ToUpper(ann.Level), msg)
+			}
+		}
+	}
+	writeChecks("Failing", failing, true)
+	writeChecks("Pending", pending, false)
+	if !failingOnly {
+		writeChecks("Passed", passed, false)
+	}
+	sb.WriteString("\nExternal CI checks link to their own system; for GitHub Actions checks, call get_workflow_run on the run URL for job logs.\n")
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolRerunFailedJobs(ctx context.Context, call ToolCall) string {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, repo, runID, err := github.ParseWorkflowRunURL(args.URL)
+	if err != nil {
+		return fmt.Sprintf("Error parsing workflow run URL: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] rerunning failed jobs for %s/%s/%d", call.UserID, call.ChannelID, owner, repo, runID)
+	if err := h.ghClient.RerunFailedJobs(ctx, owner, repo, runID); err != nil {
+		return fmt.Sprintf("Error rerunning failed jobs: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] successfully triggered rerun of failed jobs for %s/%s/%d", call.UserID, call.ChannelID, owner, repo, runID)
+	return fmt.Sprintf("Successfully triggered re-run of failed jobs for workflow run %d in %s/%s. The run is now in progress: %s", runID, owner, repo, args.URL)
+}
+
+func (h *GeneralHandler) toolRerunWorkflow(ctx context.Context, call ToolCall) string {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, repo, runID, err := github.ParseWorkflowRunURL(args.URL)
+	if err != nil {
+		return fmt.Sprintf("Error parsing workflow run URL: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] rerunning entire workflow %s/%s/%d", call.UserID, call.ChannelID, owner, repo, runID)
+	if err := h.ghClient.RerunWorkflow(ctx, owner, repo, runID); err != nil {
+		return fmt.Sprintf("Error rerunning workflow: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] successfully triggered full rerun of %s/%s/%d", call.UserID, call.ChannelID, owner, repo, runID)
+	return fmt.Sprintf("Successfully triggered full re-run of workflow run %d in %s/%s. All jobs will run again: %s", runID, owner, repo, args.URL)
+}
+
+func (h *GeneralHandler) toolMergePullRequest(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo        string `json:"repo"`
+		Number      int    `json:"number"`
+		Method      string `json:"method"`
+		CommitTitle string `json:"commit_title"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Number == 0 {
+		return "Error: repo and number are required."
+	}
+	if args.Method == "" {
+		args.Method = "squash"
+	}
+	if args.Method != "merge" && args.Method != "squash" && args.Method != "rebase" {
+		return fmt.Sprintf("Error: invalid merge method %q (use merge, squash or rebase).", args.Method)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	status, err := h.ghClient.GetMergeStatus(ctx, owner, args.Repo, args.Number)
+	if err != nil {
+		return fmt.Sprintf("Error checking PR: %v", err)
+	}
+	if blockers := status.Blockers(args.Method); len(blockers) > 0 {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] refused to merge %s/%s#%d: %s", call.UserID, call.ChannelID, owner, args.Repo, args.Number, strings.Join(blockers, "; "))
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Error: PR #%d (%s) cannot be merged because:\n", args.Number, status.URL)
+		for _, b := range blockers {
+			fmt.Fprintf(&sb, "- %s\n", b)
+		}
+		sb.WriteString("Explain these blockers to the user. Do not bypass them.")
+		return sb.String()
+	}
+
+	sha, err := h.ghClient.MergePullRequest(ctx, owner, args.Repo, args.Number, args.Method, args.CommitTitle, status.HeadSHA)
+	if err != nil {
+		return fmt.Sprintf("Error merging PR: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] merged %s/%s#%d (%s) as %s", call.UserID, call.ChannelID, owner, args.Repo, args.Number, args.Method, sha)
+	result := fmt.Sprintf("Merged PR #%d \"%s\" into %s (%s, commit %s): %s", args.Number, status.Title, status.Base, args.Method, sha[:min(7, len(sha))], status.URL)
+	if status.ProtectionUnknown {
+		result += "\nNote: the bot cannot read branch protection for this repository, so only GitHub's own merge checks were applied."
+	}
+	return result
+}
+
+func (h *GeneralHandler) toolClosePullRequest(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo    string `json:"repo"`
+		Number  int    `json:"number"`
+		Comment string `json:"comment"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Number == 0 {
+		return "Error: repo and number are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	pr, err := h.ghClient.GetMergeStatus(ctx, owner, args.Repo, args.Number)
+	if err != nil {
+		return fmt.Sprintf("Error checking PR: %v", err)
+	}
+	if pr.Merged {
+		return fmt.Sprintf("Error: PR #%d is already merged and cannot be closed: %s", args.Number, pr.URL)
+	}
+	if pr.State != "open" {
+		return fmt.Sprintf("PR #%d is already closed: %s", args.Number, pr.URL)
+	}
+	if args.Comment != "" {
+		if err := h.ghClient.CommentOnPullRequest(ctx, owner, args.Repo, args.Number, args.Comment); err != nil {
+			return fmt.Sprintf("Error commenting on PR: %v", err)
+		}
+	}
+	if err := h.ghClient.ClosePullRequest(ctx, owner, args.Repo, args.Number); err != nil {
+		return fmt.Sprintf("Error closing PR: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] closed %s/%s#%d", call.UserID, call.ChannelID, owner, args.Repo, args.Number)
+	return fmt.Sprintf("Closed PR #%d \"%s\" without merging: %s", args.Number, pr.Title, pr.URL)
+}
+
+func (h *GeneralHandler) toolUpdatePRBranch(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo   string `json:"repo"`
+		Number int    `json:"number"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Number == 0 {
+		return "Error: repo and number are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	pr, err := h.ghClient.GetMergeStatus(ctx, owner, args.Repo, args.Number)
+	if err != nil {
+		return fmt.Sprintf("Error checking PR: %v", err)
+	}
+	switch {
+	case pr.State != "open":
+		return fmt.Sprintf("Error: PR #%d is not open: %s", args.Number, pr.URL)
+	case pr.MergeableState == "dirty":
+		return fmt.Sprintf("Error: PR #%d has merge conflicts with %s. Updating the branch cannot resolve them; the author must resolve the conflicts: %s", args.Number, pr.Base, pr.URL)
+	}
+	if err := h.ghClient.UpdatePullRequestBranch(ctx, owner, args.Repo, args.Number, pr.HeadSHA); err != nil {
+		return fmt.Sprintf("Error updating PR branch: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] updated branch of %s/%s#%d from %s", call.UserID, call.ChannelID, owner, args.Repo, args.Number, pr.Base)
+	return fmt.Sprintf("Started merging %s into %s for PR #%d. GitHub applies the update in the background and CI will re-run on the new commit: %s", pr.Base, pr.Head, args.Number, pr.URL)
+}
+
+func (h *GeneralHandler) toolListWorkflows(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo string `json:"repo"`
+		Ref  string `json:"ref"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	workflows, err := h.ghClient.ListWorkflows(ctx, owner, args.Repo, args.Ref)
+	if err != nil {
+		return fmt.Sprintf("Error listing workflows: %v", err)
+	}
+	if len(workflows) == 0 {
+		return fmt.Sprintf("No GitHub Actions workflows found in %s/%s.", owner, args.Repo)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d workflows in %s/%s", call.UserID, call.ChannelID, len(workflows), owner, args.Repo)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Workflows in %s/%s (%d):\n", owner, args.Repo, len(workflows))
+	for _, w := range workflows {
+		fmt.Fprintf(&sb, "\n• %s (%s)", w.Name, w.File)
+		if w.State != "active" {
+			fmt.Fprintf(&sb, " — %s", w.State)
+		}
+		switch {
+		case w.ParseError != "":
+			fmt.Fprintf(&sb, "\n  could not read workflow file: %s", w.ParseError)
+		case !w.Dispatch:
+			sb.WriteString("\n  not manually triggerable (no workflow_dispatch)")
+		case len(w.Inputs) == 0:
+			sb.WriteString("\n  manually triggerable, no inputs")
+		default:
+			sb.WriteString("\n  manually triggerable, inputs:")
+			for _, in := range w.Inputs {
+				fmt.Fprintf(&sb, "\n    - %s (%s", in.Name, in.Type)
+				if in.Required {
+					sb.WriteString(", required")
+				}
+				if in.Default != "" {
+					fmt.Fprintf(&sb, ", default %q", in.Default)
+				}
+				if len(in.Options) > 0 {
+					fmt.Fprintf(&sb, ", one of: %s", strings.Join(in.Options, ", "))
+				}
+				sb.WriteString(")")
+				if in.Description != "" {
+					fmt.Fprintf(&sb, ": %s", in.Description)
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolTriggerWorkflow(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo     string            `json:"repo"`
+		Workflow string            `json:"workflow"`
+		Ref      string            `json:"ref"`
+		Inputs   map[string]string `json:"inputs"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.Workflow == "" {
+		return "Error: repo and workflow are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	if args.Ref == "" {
+		if args.Ref, err = h.ghClient.GetDefaultBranch(ctx, owner, args.Repo); err != nil {
+			return fmt.Sprintf("Error getting default branch: %v", err)
+		}
+	}
+	workflows, err := h.ghClient.ListWorkflows(ctx, owner, args.Repo, args.Ref)
+	if err != nil {
+		return fmt.Sprintf("Error listing workflows: %v", err)
+	}
+	var wf *github.Workflow
+	for i := range workflows {
+		w := &workflows[i]
+		if strings.EqualFold(w.File, args.Workflow) || strings.EqualFold(w.Path, args.Workflow) || strings.EqualFold(w.Name, args.Workflow) {
+			wf = w
+			break
+		}
+	}
+	if wf == nil {
+		return fmt.Sprintf("Error: no workflow %q in %s/%s. Call list_workflows to see the available workflows.", args.Workflow, owner, args.Repo)
+	}
+	if msg := validateDispatch(wf, args.Ref, args.Inputs); msg != "" {
+		return msg
+	}
+
+	inputs := make(map[string]any, len(args.Inputs))
+	for k, v := range args.Inputs {
+		inputs[k] = v
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] dispatching %s in %s/%s on %s with %v", call.UserID, call.ChannelID, wf.File, owner, args.Repo, args.Ref, args.Inputs)
+	runURL, err := h.ghClient.DispatchWorkflow(ctx, owner, args.Repo, wf.File, args.Ref, inputs)
+	if err != nil {
+		return fmt.Sprintf("Error triggering workflow: %v", err)
+	}
+	if runURL == "" {
+		runURL = fmt.Sprintf("https://github.com/%s/%s/actions/workflows/%s", owner, args.Repo, wf.File)
+		return fmt.Sprintf("Triggered workflow %s (%s) on %s. The run has not appeared yet; it will show up at %s", wf.Name, wf.File, args.Ref, runURL)
+	}
+	return fmt.Sprintf("Triggered workflow %s (%s) on %s. Run: %s", wf.Name, wf.File, args.Ref, runURL)
+}
+
+// validateDispatch checks inputs against a workflow's workflow_dispatch
+// definition. Returns a non-empty error message when the dispatch would fail.
+func validateDispatch(wf *github.Workflow, ref string, inputs map[string]string) string {
+	switch {
+	case wf.ParseError != "":
+		return fmt.Sprintf("Error: could not read workflow %s on %s: %s", wf.File, ref, wf.ParseError)
+	case !wf.Dispatch:
+		return fmt.Sprintf("Error: workflow %s has no workflow_dispatch trigger on %s, so it cannot be run manually.", wf.File, ref)
+	case wf.State != "active":
+		return fmt.Sprintf("Error: workflow %s is %s.", wf.File, wf.State)
+	}
+
+	var problems []string
+	known := make(map[string]bool)
+	for _, in := range wf.Inputs {
+		known[in.Name] = true
+		v, ok := inputs[in.Name]
+		if !ok {
+			if in.Required && in.Default == "" {
+				problems = append(problems, fmt.Sprintf("missing required input %q", in.Name))
+			}
+			continue
+		}
+		switch {
+		case in.Type == "choice" && len(in.Options) > 0 && !slices.Contains(in.Options, v):
+			problems = append(problems, fmt.Sprintf("input %q must be one of %s, got %q", in.Name, strings.Join(in.Options, ", "), v))
+		case in.Type == "boolean" && v != "true" && v != "false":
+			problems = append(problems, fmt.Sprintf("input %q must be true or false, got %q", in.Name, v))
+		case in.Type == "number":
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				problems = append(problems, fmt.Sprintf("input %q must be a number, got %q", in.Name, v))
+			}
+		}
+	}
+	for name := range inputs {
+		if !known[name] {
+			problems = append(problems, fmt.Sprintf("workflow %s has no input %q", wf.File, name))
+		}
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	sort.Strings(problems)
+	return fmt.Sprintf("Error: invalid inputs for %s: %s. Call list_workflows to see its inputs.", wf.File, strings.Join(problems, "; "))
+}
+
+func (h *GeneralHandler) toolListCommits(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo   string `json:"repo"`
+		Path   string `json:"path"`
+		Author string `json:"author"`
+		Since  string `json:"since"`
+		Until  string `json:"until"`
+		Branch string `json:"branch"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	filter := github.CommitFilter{Ref: args.Branch, Path: args.Path, Author: args.Author}
+	var err error
+	if filter.Since, err = parseHistoryTime(args.Since); err != nil {
+		return fmt.Sprintf("Error: invalid since: %v", err)
+	}
+	if filter.Until, err = parseHistoryTime(args.Until); err != nil {
+		return fmt.Sprintf("Error: invalid until: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	commits, err := h.ghClient.ListCommits(ctx, owner, args.Repo, filter, args.Limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing commits: %v", err)
+	}
+
+	var scope []string
+	if args.Path != "" {
+		scope = append(scope, "touching "+args.Path)
+	}
+	if args.Author != "" {
+		scope = append(scope, "by "+args.Author)
+	}
+	if !filter.Since.IsZero() {
+		scope = append(scope, "since "+filter.Since.Format("2006-01-02 15:04"))
+	}
+	if !filter.Until.IsZero() {
+		scope = append(scope, "until "+filter.Until.Format("2006-01-02 15:04"))
+	}
+	desc := strings.Join(scope, ", ")
+	if len(commits) == 0 {
+		return fmt.Sprintf("No commits found in %s/%s %s.", owner, args.Repo, desc)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d commits in %s/%s %s", call.UserID, call.ChannelID, len(commits), owner, args.Repo, desc)
+	table := &Table{Name: "commits", Columns: []string{"SHA", "Date", "Author", "Message", "URL"}, Inline: 4}
+	for _, c := range commits {
+		table.Rows = append(table.Rows, []string{c.SHA[:min(7, len(c.SHA))], c.Date.UTC().Format("2006-01-02 15:04"), c.Author, c.Message, c.URL})
+	}
+	summary := fmt.Sprintf("Commits in %s/%s %s (%d, newest first). Links: https://github.com/%s/%s/commit/<sha>.", owner, args.Repo, desc, len(commits), owner, args.Repo)
+	return h.presentTable(call, summary, table)
+}
+
+func (h *GeneralHandler) toolGitBlame(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo      string `json:"repo"`
+		Path      string `json:"path"`
+		StartLine int    `json:"start_line"`
+		EndLine   int    `json:"end_line"`
+		Branch    string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	if args.StartLine <= 0 {
+		args.StartLine = 1
+	}
+	if args.EndLine < args.StartLine {
+		args.EndLine = args.StartLine + 199
+	}
+	ranges, err := h.ghClient.Blame(ctx, owner, args.Repo, args.Branch, args.Path)
+	if err != nil {
+		return fmt.Sprintf("Error running blame: %v", err)
+	}
+	content, _, err := h.ghClient.GetFileContent(ctx, owner, args.Repo, args.Path, args.Branch)
+	if err != nil {
+		return fmt.Sprintf("Error reading file: %v", err)
+	}
+	lines := strings.Split(content, "\n")
+	args.EndLine = min(args.EndLine, len(lines))
+	if args.StartLine > args.EndLine {
+		return fmt.Sprintf("Error: %s has only %d lines.", args.Path, len(lines))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Blame for %s/%s:%s lines %d-%d:\n", owner, args.Repo, args.Path, args.StartLine, args.EndLine)
+	for _, r := range ranges {
+		from, to := max(r.StartLine, args.StartLine), min(r.EndLine, args.EndLine)
+		if from > to {
+			continue
+		}
+		fmt.Fprintf(&sb, "\nLines %d-%d — %s by %s on %s: %s (%s)\n", from, to, r.Commit.SHA[:min(7, len(r.Commit.SHA))], r.Commit.Author, r.Commit.Date.UTC().Format("2006-01-02"), r.Commit.Message, r.Commit.URL)
+		for n := from; n <= to; n++ {
+			fmt.Fprintf(&sb, "%5d | %s\n", n, lines[n-1])
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] blamed %s/%s:%s lines %d-%d", call.UserID, call.ChannelID, owner, args.Repo, args.Path, args.StartLine, args.EndLine)
+	return sb.String()
+}
+
+const (
+	// commitPatchLines caps the diff lines get_commit shows per file.
+	commitPatchLines = 80
+	// commitTotalPatchLines caps the diff lines get_commit shows in total;
+	// files past it are listed without their diff.
+	commitTotalPatchLines = 600
+)
+
+func (h *GeneralHandler) toolGetCommit(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Repo string `json:"repo"`
+		SHA  string `json:"sha"`
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Repo == "" || args.SHA == "" {
+		return "Error: repo and sha are required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+	c, err := h.ghClient.GetCommit(ctx, owner, args.Repo, args.SHA)
+	if err != nil {
+		return fmt.Sprintf("Error getting commit: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] read commit %s/%s@%s (%d files)", call.UserID, call.ChannelID, owner, args.Repo, c.SHA, len(c.Files))
+	return formatCommit(c, args.Path)
+}
+
+// formatCommit renders a commit for the model. With path, only that file's
+// diff is shown, in full up to commitTotalPatchLines.
+func formatCommit(c *github.Commit, path string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Commit %s by %s on %s — %s\n", c.SHA, c.Author, c.Date.UTC().Format("2006-01-02 15:04 UTC"), c.URL)
+	if c.Committer != "" {
+		fmt.Fprintf(&sb, "Committed by %s\n", c.Committer)
+	}
+	if len(c.PullRequests) > 0 {
+		fmt.Fprintf(&sb, "Pull requests: %s\n", strings.Join(c.PullRequests, ", "))
+	}
+	fmt.Fprintf(&sb, "\n%s\n\nFiles changed (%d, +%d −%d):\n", strings.TrimSpace(c.FullMessage), len(c.Files), c.Additions, c.Deletions)
+	for _, f := range c.Files {
+		name := f.Path
+		if f.PreviousPath != "" && f.PreviousPath != f.Path {
+			name = f.PreviousPath + " → " + f.Path
+		}
+		fmt.Fprintf(&sb, "- %s (%s, +%d −%d)\n", name, f.Status, f.Additions, f.Deletions)
+	}
+
+	budget := commitTotalPatchLines
+	found := path == ""
+	for _, f := range c.Files {
+		if path != "" && f.Path != path && f.PreviousPath != path {
+			continue
+		}
+		found = true
+		if f.Patch == "" {
+			fmt.Fprintf(&sb, "\n%s: no diff available (binary or too large).\n", f.Path)
+			continue
+		}
+		if budget <= 0 {
+			sb.WriteString("\nDiffs of the remaining files are not shown; call get_commit with path for one of them.\n")
+			break
+		}
+		perFile := commitPatchLines
+		if path != "" {
+			perFile = budget
+		}
+		lines := strings.Split(f.Patch, "\n")
+		show := min(len(lines), perFile, budget)
+		fmt.Fprintf(&sb, "\n--- %s\n%s\n", f.Path, strings.Join(lines[:show], "\n"))
+		if show < len(lines) {
+			fmt.Fprintf(&sb, "… %d more diff lines; call get_commit with path=%q to see more.\n", len(lines)-show, f.Path)
+		}
+		budget -= show
+	}
+	if !found {
+		fmt.Fprintf(&sb, "\n%s was not changed by this commit.\n", path)
+	}
+	return sb.String()
+}
+
+// parseHistoryTime parses a date, an RFC 3339 timestamp, or a relative age
+// ("7d", "2w", "36h") into a time. Empty means no bound.
+func parseHistoryTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n >= 0 {
+		switch s[len(s)-1] {
+		case 'd':
+			return time.Now().AddDate(0, 0, -n), nil
+		case 'w':
+			return time.Now().AddDate(0, 0, -7*n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02), RFC 3339 timestamp, or age like 7d, 2w, 36h", s)
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/justmike1/ovad/imagescan"
+	"github.com/justmike1/ovad/logging"
+)
+
+// imageScanTools look up the vulnerability scan of a container image in the
+// registry that scanned it (ECR, or Harbor's Trivy scanner). Offered when at
+// least one scanner is configured.
+var imageScanTools = []*ToolDef{
+	{
+		Name:        "get_image_vulnerabilities",
+		Description: "Get the vulnerability scan results of a container image tag or digest from its registry (Amazon ECR basic/enhanced scanning, or Harbor's Trivy scanner): scan status and time, findings per severity, and the findings at or above a severity with package, installed and fixed version. Use it in deploy and release discussions ('is v1.4.2 safe to ship?', 'does prod run an image with critical CVEs?').",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"image":{"type":"string","description":"Image reference, e.g. '123456789012.dkr.ecr.us-east-1.amazonaws.com/checkout:v1.4.2', 'harbor.example.com/payments/api:1.2' or 'checkout@sha256:...'"},
+				"min_severity":{"type":"string","enum":["CRITICAL","HIGH","MEDIUM","LOW"],"description":"Only list findings at or above this severity (default: HIGH); counts always cover all severities"},
+				"fixable_only":{"type":"boolean","description":"Only list findings with a fixed version available"},
+				"max_results":{"type":"integer","description":"Maximum number of findings to list (default: 30, max: 200)"}
+			},
+			"required":["image"]
+		}`),
+		Available: (*GeneralHandler).imageScanConfigured,
+		Run:       (*GeneralHandler).toolGetImageVulnerabilities,
+	},
+}
+
+func (h *GeneralHandler) imageScanConfigured() bool { return len(h.imageScanners) > 0 }
+
+func (h *GeneralHandler) toolGetImageVulnerabilities(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Image       string `json:"image"`
+		MinSeverity string `json:"min_severity"`
+		FixableOnly bool   `json:"fixable_only"`
+		MaxResults  int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	img, err := imagescan.ParseImage(args.Image)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	minRank := imagescan.SeverityRank("HIGH")
+	if args.MinSeverity != "" {
+		minRank = imagescan.SeverityRank(args.MinSeverity)
+	}
+	limit := 30
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 200)
+	}
+
+	var scanner imagescan.Scanner
+	for _, s := range h.imageScanners {
+		if s.Handles(img) {
+			scanner = s
+			break
+		}
+	}
+	if scanner == nil {
+		names := make([]string, 0, len(h.imageScanners))
+		for _, s := range h.imageScanners {
+			names = append(names, s.Name())
+		}
+		return fmt.Sprintf("Error: no configured scanner covers registry %q (configured: %s).", img.Registry, strings.Join(names, ", "))
+	}
+	report, err := scanner.Scan(ctx, img)
+	if err != nil {
+		return fmt.Sprintf("Error getting scan results from %s: %v", scanner.Name(), err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched %s scan of %s: %s, %d findings", call.UserID, call.ChannelID, scanner.Name(), img, report.Status, len(report.Findings))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* — %s scan: %s", img, report.Scanner, report.Status)
+	if report.Message != "" {
+		fmt.Fprintf(&sb, " (%s)", report.Message)
+	}
+	if report.Digest != "" {
+		fmt.Fprintf(&sb, "\nDigest: %s", report.Digest)
+	}
+	if !report.ScannedAt.IsZero() {
+		fmt.Fprintf(&sb, "\nScanned: %s", formatAgo(report.ScannedAt))
+		if !report.DBUpdatedAt.IsZero() {
+			fmt.Fprintf(&sb, " (vulnerability data from %s)", report.DBUpdatedAt.UTC().Format("2006-01-02"))
+		}
+	}
+	var counts []string
+	for _, sev := range imagescan.Severities {
+		if n := report.Counts[sev]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, strings.ToLower(sev)))
+		}
+	}
+	if len(counts) == 0 && len(report.Findings) == 0 {
+		if len(report.Counts) == 0 && report.ScannedAt.IsZero() {
+			return sb.String() + "\nNo scan results available."
+		}
+		return sb.String() + "\nNo vulnerabilities found."
+	}
+	fmt.Fprintf(&sb, "\nFindings: %s", strings.Join(counts, ", "))
+
+	var shown []imagescan.Finding
+	for _, f := range report.Findings {
+		if imagescan.SeverityRank(f.Severity) > minRank || (args.FixableOnly && f.FixedIn == "") {
+			continue
+		}
+		shown = append(shown, f)
+	}
+	sort.SliceStable(shown, func(i, j int) bool {
+		return imagescan.SeverityRank(shown[i].Severity) < imagescan.SeverityRank(shown[j].Severity)
+	})
+	if len(shown) == 0 {
+		fmt.Fprintf(&sb, "\nNo findings at or above %s", imagescan.Severities[minRank])
+		if args.FixableOnly {
+			sb.WriteString(" with a fix available")
+		}
+		return sb.String() + "."
+	}
+	total := len(shown)
+	shown = shown[:min(total, limit)]
+	fmt.Fprintf(&sb, "\nListing %d of %d finding(s) at or above %s.", len(shown), total, imagescan.Severities[minRank])
+
+	table := &Table{
+		Name:    "image-vulnerabilities",
+		Columns: []string{"ID", "Severity", "Package", "Installed", "Fixed In", "Title", "URL"},
+		Inline:  5,
+	}
+	for _, f := range shown {
+		table.Rows = append(table.Rows, []string{f.ID, f.Severity, f.Package, f.Version, f.FixedIn, f.Title, f.URL})
+	}
+	return h.presentTable(call, sb.String(), table)
+}
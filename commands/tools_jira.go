@@ -0,0 +1,523 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/jira"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/ticketing"
+)
+
+// jiraTools search, read, and edit Jira issues. The create, search, get,
+// update, transition and comment tools go through the ticketing provider;
+// the resolve and JQL tools are Jira-only. Offered only when Jira is
+// configured.
+var jiraTools = []*ToolDef{
+	{
+		Name:        "create_jira_ticket",
+		Description: "Create a Jira ticket (issue). Use this when the user asks to create a ticket, task, story, or bug from the conversation content (e.g., a test plan, action item, or bug report). Populate the summary and description from the relevant content discussed in the conversation. IMPORTANT: Format the description using markdown — use # for headers, - for bullet lists, 1) for numbered lists, **bold** for emphasis, and `code` for inline code. Structure the ticket professionally with clear sections (e.g., ## Context, ## Scope, ## Acceptance Criteria). If the user asks to assign the ticket to a person, use the assignee field. If the user asks to assign to a team, use the team field. Both can be used at the same time. When the ticket is about code, pass repo (and path) so an assignee can be suggested from code ownership if none is given.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"project":{"type":"string","description":"Jira project key (e.g. 'ENG', 'QA'). Optional — uses the configured default if omitted."},
+				"summary":{"type":"string","description":"Short one-line title for the ticket."},
+				"description":{"type":"string","description":"Detailed, well-structured description using markdown formatting. Use ## for section headers, - for bullet points, 1) for numbered steps, **bold** for key terms, and backticks for code references. Organize into clear sections like Context, Scope, Test Plan, Acceptance Criteria, References, etc."},
+				"issue_type":{"type":"string","description":"Issue type: 'Task', 'Bug', 'Story', 'Epic', etc. Default: 'Task'."},
+				"labels":{"type":"array","items":{"type":"string"},"description":"Optional labels to apply to the ticket (e.g. ['qa','automated-test'])."},
+				"assignee":{"type":"string","description":"Name of the person to assign the ticket to (e.g. 'Udi', 'John Smith'). The system will search for a matching Jira user."},
+				"team":{"type":"string","description":"Name of the team to assign the ticket to (e.g. 'Application', 'DevOps', 'asgard'). The system will search for a matching Jira team."},
+				"components":{"type":"array","items":{"type":"string"},"description":"Jira components to set (optional). A component lead is suggested as assignee when none is given."},
+				"repo":{"type":"string","description":"GitHub repository the ticket is about (without owner), optional. Used to suggest an assignee from CODEOWNERS and recent committers."},
+				"path":{"type":"string","description":"File or directory in repo the ticket is about (optional)"}
+			},
+			"required":["summary","description"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).ticketingConfigured,
+		Run:       (*GeneralHandler).toolCreateJiraTicket,
+		Preview:   (*GeneralHandler).previewCreateTicket,
+	},
+	{
+		Name:        "list_jira_projects",
+		Description: "List all Jira projects visible to the bot. Use this to discover available project keys before creating a ticket.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Available:   (*GeneralHandler).jiraConfigured,
+		Run:         (*GeneralHandler).toolListJiraProjects,
+	},
+	{
+		Name:        "search_jira_issues",
+		Description: "Search for Jira issues using JQL (Jira Query Language). IMPORTANT: Jira Cloud does NOT reliably support searching by display name. Before searching by assignee, you MUST first call resolve_jira_user to get the user's Jira account ID, then use that account ID in JQL (e.g. assignee = 'accountId'). Common JQL examples: 'assignee = \"712020:abc-def\" AND status = \"In Progress\"', 'project = ENG AND status = \"To Do\"'. When searching for a specific user's tickets: 1) call get_slack_user_info to get their real name, 2) call resolve_jira_user with that name to get the Jira account ID, 3) use the account ID in the JQL query. For anything beyond a simple query, call build_jql first to get validated JQL.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"jql":{"type":"string","description":"JQL query string (e.g. 'assignee = \"John Doe\" AND status = \"In Progress\" ORDER BY updated DESC')"},
+				"max_results":{"type":"integer","description":"Maximum number of results to return (default: 20, max: 50)"}
+			},
+			"required":["jql"]
+		}`),
+		Available: (*GeneralHandler).ticketingConfigured,
+		Run:       (*GeneralHandler).toolSearchJiraIssues,
+	},
+	{
+		Name:        "get_jira_issue",
+		Description: "Get full details of a specific Jira issue by its key (e.g. 'ENG-123'). Returns summary, description, status, assignee, priority, labels, and more.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"issue_key":{"type":"string","description":"Jira issue key (e.g. 'ENG-123', 'PROJ-456')"}
+			},
+			"required":["issue_key"]
+		}`),
+		Available: (*GeneralHandler).ticketingConfigured,
+		Run:       (*GeneralHandler).toolGetJiraIssue,
+	},
+	{
+		Name:        "update_jira_issue",
+		Description: "Update a Jira issue's description, summary or assignee. Use this to rewrite, refine, or improve ticket descriptions. IMPORTANT: Format the new description using markdown — use # for headers, - for bullet lists, 1) for numbered lists, **bold** for emphasis. Structure it professionally with clear sections.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"issue_key":{"type":"string","description":"Jira issue key (e.g. 'ENG-123')"},
+				"summary":{"type":"string","description":"New summary/title for the ticket (optional — only set if you want to change it)"},
+				"description":{"type":"string","description":"New description for the ticket in markdown format. Structure with clear sections like ## Context, ## Requirements, ## Acceptance Criteria, etc."},
+				"assignee_account_id":{"type":"string","description":"Jira account ID to assign the ticket to (from resolve_jira_user or a suggested assignee)"}
+			},
+			"required":["issue_key"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).ticketingConfigured,
+		Run:       (*GeneralHandler).toolUpdateJiraIssue,
+	},
+	{
+		Name:        "transition_jira_issue",
+		Description: "Move a Jira issue to another status (e.g. 'In Progress', 'In Review', 'Done') through its workflow. Pass the target status or the transition name; if it is not reachable from the current status, the error lists the transitions that are.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"issue_key":{"type":"string","description":"Jira issue key (e.g. 'ENG-123')"},
+				"status":{"type":"string","description":"Target status or transition name (e.g. 'Done')"}
+			},
+			"required":["issue_key","status"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).ticketingConfigured,
+		Run:       (*GeneralHandler).toolTransitionJiraIssue,
+	},
+	{
+		Name:        "comment_on_jira_issue",
+		Description: "Add a comment to a Jira issue, e.g. a status update, a link to a PR, or a summary of a Slack discussion. Format the comment using markdown.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"issue_key":{"type":"string","description":"Jira issue key (e.g. 'ENG-123')"},
+				"comment":{"type":"string","description":"Comment text in markdown"}
+			},
+			"required":["issue_key","comment"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).ticketingConfigured,
+		Run:       (*GeneralHandler).toolCommentOnJiraIssue,
+	},
+	{
+		Name:        "resolve_jira_user",
+		Description: "Search for a Jira user by name and/or email and return their account ID. IMPORTANT: Jira Cloud JQL does NOT reliably support searching by display name (e.g. assignee = 'Mike Joseph' may return zero results). You MUST call this tool first to get the user's Jira account ID, then use that account ID in JQL queries (e.g. assignee = 'accountId'). This is the ONLY reliable way to find issues by assignee in Jira Cloud. ALWAYS pass both name AND email (from get_slack_user_info) for best results — email-based search is the most reliable.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","description":"The person's display name (e.g. 'Mike Joseph', 'John Smith')"},
+				"email":{"type":"string","description":"The person's email address (most reliable for Jira lookup). Get this from get_slack_user_info."},
+				"slack_user_id":{"type":"string","description":"The person's Slack user ID, if known. A Jira account they linked with link_github_account is returned directly."}
+			}
+		}`),
+		Available: (*GeneralHandler).jiraConfigured,
+		Run:       (*GeneralHandler).toolResolveJiraUser,
+	},
+	{
+		Name:        "resolve_jira_team",
+		Description: "Resolve a Jira team name to its UUID and JQL clause name. The Jira Teams integration field uses UUIDs, NOT display names, in JQL. You MUST call this tool first when searching for a team's tickets — it returns the JQL clause (e.g. 'Team[Team]') and team UUID. Then use the result in JQL like: '\"Team[Team]\" = \"<uuid>\"'. Example: resolve_jira_team({\"team_name\": \"DevOps\"}) → clause='Team[Team]', uuid='d6c2ac7c-...', then search with JQL '\"Team[Team]\" = \"d6c2ac7c-...\" AND status = \"In Progress\"'.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"team_name":{"type":"string","description":"The team name to resolve (e.g. 'DevOps', 'Platforms', 'Remediation')"}
+			},
+			"required":["team_name"]
+		}`),
+		Available: (*GeneralHandler).jiraConfigured,
+		Run:       (*GeneralHandler).toolResolveJiraTeam,
+	},
+	{
+		Name:        "build_jql",
+		Description: "Convert a natural-language filter into valid JQL for search_jira_issues. Use this for any non-trivial search (people, teams, statuses, dates, custom fields) instead of writing JQL by hand. It resolves people to account IDs and teams to UUIDs, uses the project's real status, issue type and field names, and validates the query with a dry-run search before returning it.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"filter":{"type":"string","description":"What to search for in plain language (e.g. 'open bugs assigned to Dana updated in the last week, highest priority first')."},
+				"project":{"type":"string","description":"Jira project key (e.g. 'ENG'). Optional — uses the configured default if omitted."},
+				"people":{"type":"array","items":{"type":"string"},"description":"Names or emails of people mentioned in the filter, resolved to Jira account IDs."},
+				"team":{"type":"string","description":"Team mentioned in the filter, resolved to its Jira team UUID."}
+			},
+			"required":["filter"]
+		}`),
+		Available: (*GeneralHandler).jiraConfigured,
+		Run:       (*GeneralHandler).toolBuildJQL,
+	},
+}
+
+func (h *GeneralHandler) toolCreateJiraTicket(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Project     string   `json:"project"`
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		IssueType   string   `json:"issue_type"`
+		Labels      []string `json:"labels"`
+		Assignee    string   `json:"assignee"`
+		Team        string   `json:"team"`
+		Components  []string `json:"components"`
+		Repo        string   `json:"repo"`
+		Path        string   `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	ticket, err := h.tickets.Create(ctx, ticketing.CreateInput{
+		Project:     args.Project,
+		Title:       args.Summary,
+		Description: args.Description + h.ticketStamp(call.UserID),
+		Type:        args.IssueType,
+		Labels:      args.Labels,
+		Assignee:    args.Assignee,
+		Team:        args.Team,
+		Components:  args.Components,
+	})
+	if err != nil {
+		return fmt.Sprintf("Error creating %s ticket: %v", h.tickets.Name(), err)
+	}
+
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] created %s ticket %s: %s", call.UserID, call.ChannelID, h.tickets.Name(), ticket.Key, ticket.URL)
+	result := fmt.Sprintf("%s ticket created: *%s* — %s\nSummary: %s", h.tickets.Name(), ticket.Key, ticket.URL, args.Summary)
+	if args.Team != "" && ticket.Team == "" {
+		result += fmt.Sprintf("\nTeam %q could not be resolved; the ticket has no team.", args.Team)
+	}
+	if args.Assignee == "" && h.jiraClient != nil {
+		project, _, _ := strings.Cut(ticket.Key, "-")
+		if suggestions := h.suggestAssignees(ctx, project, args.Components, args.Repo, args.Path); len(suggestions) > 0 {
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] suggested %d assignee(s) for %s, top: %s (%s)", call.UserID, call.ChannelID, len(suggestions), ticket.Key, suggestions[0].DisplayName, suggestions[0].Reason)
+			result += formatAssigneeSuggestions(ticket.Key, suggestions)
+		}
+	}
+	return result
+}
+
+// ticketStamp is the markdown footer appended to the description of tickets
+// the bot creates: the agent, the user who asked for it, the UI and the
+// Slack message.
+func (h *GeneralHandler) ticketStamp(userID string) string {
+	stamp := fmt.Sprintf("\n\n---\nCreated by **%s** %s", h.agentID, botTicketMarker)
+	if userID != "" {
+		stamp += " | requested by " + h.displayName(userID)
+	}
+	if h.appURL != "" {
+		stamp += fmt.Sprintf(" | %s/ui/", strings.TrimRight(h.appURL, "/"))
+	}
+	if h.currentChannelID != "" && h.currentAuditTS != "" {
+		if permalink, err := h.slackClient.GetPermalink(h.currentChannelID, h.currentAuditTS); err == nil && permalink != "" {
+			stamp += fmt.Sprintf(" | [Slack message](%s)", permalink)
+		}
+	}
+	return stamp
+}
+
+func (h *GeneralHandler) toolListJiraProjects(ctx context.Context, call ToolCall) string {
+	if h.jiraClient == nil {
+		return "Error: Jira integration is not configured."
+	}
+	projects, err := h.jiraClient.ListProjects()
+	if err != nil {
+		return fmt.Sprintf("Error listing Jira projects: %v", err)
+	}
+	if len(projects) == 0 {
+		return "No Jira projects found."
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d Jira projects", call.UserID, call.ChannelID, len(projects))
+	return fmt.Sprintf("Jira projects (%d):\n%s", len(projects), strings.Join(projects, "\n"))
+}
+
+func (h *GeneralHandler) toolSearchJiraIssues(ctx context.Context, call ToolCall) string {
+	var args struct {
+		JQL        string `json:"jql"`
+		MaxResults int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	tickets, err := h.tickets.Search(ctx, args.JQL, args.MaxResults)
+	if err != nil {
+		return fmt.Sprintf("Error searching %s issues: %v", h.tickets.Name(), err)
+	}
+	if len(tickets) == 0 {
+		return fmt.Sprintf("No issues found for query: %s", args.JQL)
+	}
+	table := &Table{
+		Name:    "jira-issues",
+		Columns: []string{"Key", "Summary", "Status", "Type", "Priority", "Assignee", "Updated", "Team", "Sprint", "URL", "Description"},
+		Inline:  7,
+	}
+	var details strings.Builder
+	for _, t := range tickets {
+		updated := t.Updated
+		if len(updated) > 10 {
+			updated = updated[:10]
+		}
+		table.Rows = append(table.Rows, []string{t.Key, t.Title, t.Status, t.Type, t.Priority, t.Assignee, updated, t.Team, t.Sprint, t.URL, t.Description})
+		fmt.Fprintf(&details, "• %s — %s", t.Key, t.URL)
+		if t.Team != "" {
+			fmt.Fprintf(&details, " | Team: %s", t.Team)
+		}
+		if t.Sprint != "" {
+			fmt.Fprintf(&details, " | Sprint: %s", t.Sprint)
+		}
+		if t.Description != "" {
+			desc := t.Description
+			if len(desc) > 300 {
+				desc = desc[:300] + "... (truncated)"
+			}
+			fmt.Fprintf(&details, "\n  Description: %s", desc)
+		}
+		details.WriteString("\n")
+	}
+	result := h.presentTable(call, fmt.Sprintf("Found %d issues.", len(tickets)), table)
+	if len(tickets) <= tableInlineRows {
+		result += "\n\nLinks and details (for your reference; link keys when useful):\n" + details.String()
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] searched %s issues, found %d", call.UserID, call.ChannelID, h.tickets.Name(), len(tickets))
+	return result
+}
+
+func (h *GeneralHandler) toolGetJiraIssue(ctx context.Context, call ToolCall) string {
+	var args struct {
+		IssueKey string `json:"issue_key"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	t, err := h.tickets.Get(ctx, args.IssueKey)
+	if err != nil {
+		return fmt.Sprintf("Error getting %s issue: %v", h.tickets.Name(), err)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* — %s\n", t.Key, t.Title)
+	fmt.Fprintf(&sb, "Status: %s | Type: %s | Priority: %s\n", t.Status, t.Type, t.Priority)
+	fmt.Fprintf(&sb, "Assignee: %s | Reporter: %s\n", t.Assignee, t.Reporter)
+	if t.Team != "" {
+		fmt.Fprintf(&sb, "Team: %s\n", t.Team)
+	}
+	if t.Sprint != "" {
+		fmt.Fprintf(&sb, "Sprint: %s\n", t.Sprint)
+	}
+	fmt.Fprintf(&sb, "Updated: %s\n", t.Updated)
+	if len(t.Labels) > 0 {
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(t.Labels, ", "))
+	}
+	fmt.Fprintf(&sb, "URL: %s\n", t.URL)
+	if t.Description != "" {
+		fmt.Fprintf(&sb, "\nDescription:\n%s\n", t.Description)
+	} else {
+		fmt.Fprintf(&sb, "\nDescription: (empty)\n")
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched %s issue %s", call.UserID, call.ChannelID, h.tickets.Name(), args.IssueKey)
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolUpdateJiraIssue(ctx context.Context, call ToolCall) string {
+	var args struct {
+		IssueKey    string `json:"issue_key"`
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		AssigneeID  string `json:"assignee_account_id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Summary == "" && args.Description == "" && args.AssigneeID == "" {
+		return "Error: at least one of summary, description or assignee_account_id must be provided."
+	}
+	err := h.tickets.Update(ctx, args.IssueKey, ticketing.UpdateInput{
+		Title:       args.Summary,
+		Description: args.Description,
+		AssigneeID:  args.AssigneeID,
+	})
+	if err != nil {
+		return fmt.Sprintf("Error updating %s: %v", args.IssueKey, err)
+	}
+	updated := []string{}
+	if args.Summary != "" {
+		updated = append(updated, "summary")
+	}
+	if args.Description != "" {
+		updated = append(updated, "description")
+	}
+	if args.AssigneeID != "" {
+		updated = append(updated, "assignee")
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] updated %s issue %s (%s)", call.UserID, call.ChannelID, h.tickets.Name(), args.IssueKey, strings.Join(updated, ", "))
+	return fmt.Sprintf("Successfully updated %s: %s", args.IssueKey, strings.Join(updated, ", "))
+}
+
+func (h *GeneralHandler) toolTransitionJiraIssue(ctx context.Context, call ToolCall) string {
+	var args struct {
+		IssueKey string `json:"issue_key"`
+		Status   string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Status) == "" {
+		return "Error: status is required."
+	}
+	status, err := h.tickets.Transition(ctx, args.IssueKey, args.Status)
+	if err != nil {
+		return fmt.Sprintf("Error transitioning %s: %v", args.IssueKey, err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] moved %s issue %s to %s", call.UserID, call.ChannelID, h.tickets.Name(), args.IssueKey, status)
+	return fmt.Sprintf("Moved %s to %s.", args.IssueKey, status)
+}
+
+func (h *GeneralHandler) toolCommentOnJiraIssue(ctx context.Context, call ToolCall) string {
+	var args struct {
+		IssueKey string `json:"issue_key"`
+		Comment  string `json:"comment"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Comment) == "" {
+		return "Error: comment is required."
+	}
+	if err := h.tickets.Comment(ctx, args.IssueKey, args.Comment); err != nil {
+		return fmt.Sprintf("Error commenting on %s: %v", args.IssueKey, err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] commented on %s issue %s", call.UserID, call.ChannelID, h.tickets.Name(), args.IssueKey)
+	return fmt.Sprintf("Comment added to %s.", args.IssueKey)
+}
+
+func (h *GeneralHandler) toolResolveJiraUser(ctx context.Context, call ToolCall) string {
+	if h.jiraClient == nil {
+		return "Error: Jira integration is not configured."
+	}
+	var args struct {
+		Name        string `json:"name"`
+		Email       string `json:"email"`
+		SlackUserID string `json:"slack_user_id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if id, ok := h.linkedIdentity(args.SlackUserID); ok && id.JiraAccountID != "" {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] resolved Jira user for %s via linked account -> %s", call.UserID, call.ChannelID, id.SlackUserID, id.JiraAccountID)
+		return fmt.Sprintf("Found Jira user (linked account of <@%s>):\n  • accountId: %s\n\nUse the accountId in JQL queries like: assignee = \"%s\"\n", id.SlackUserID, id.JiraAccountID, id.JiraAccountID)
+	}
+	if args.Name == "" && args.Email == "" {
+		return "Error: pass name and/or email (get them with get_slack_user_info)."
+	}
+	if args.Email == "" {
+		args.Email = h.directoryEmail(ctx, args.Name)
+	}
+
+	// Multi-strategy search: email first (most reliable), then full name, then individual name parts.
+	type attempt struct {
+		label string
+		query string
+	}
+	var attempts []attempt
+	if args.Email != "" {
+		attempts = append(attempts, attempt{"email", args.Email})
+	}
+	if args.Name != "" {
+		attempts = append(attempts, attempt{"full name", args.Name})
+		// Also try individual name parts (first name, last name) since Jira's
+		// /user/search often matches prefixes, and "Mike Joseph" as a single
+		// query may fail while "Mike" succeeds.
+		parts := strings.Fields(args.Name)
+		if len(parts) > 1 {
+			for _, p := range parts {
+				attempts = append(attempts, attempt{"name part", p})
+			}
+		}
+	}
+
+	var users []jira.JiraUser
+	var matchLabel string
+	for _, a := range attempts {
+		result, err := h.jiraClient.SearchUsersGeneral(a.query)
+		if err != nil {
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] Jira user search by %s (%q) failed: %v", call.UserID, call.ChannelID, a.label, a.query, err)
+			continue
+		}
+		if len(result) > 0 {
+			users = result
+			matchLabel = a.label
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] Jira user search by %s (%q) returned %d result(s)", call.UserID, call.ChannelID, a.label, a.query, len(result))
+			break
+		}
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] Jira user search by %s (%q) returned 0 results, trying next strategy", call.UserID, call.ChannelID, a.label, a.query)
+	}
+
+	if len(users) == 0 {
+		// Final fallback: reverse-lookup via project issues. This works even when
+		// the service account lacks "Browse users and groups" global permission,
+		// because the issue search endpoint returns assignee accountIds.
+		logging.Ctx(ctx).Warnf("[user=%s channel=%s] all /user/search strategies failed, trying issue-based reverse lookup for %q", call.UserID, call.ChannelID, args.Name)
+		issueUsers, err := h.jiraClient.ResolveUserViaIssues(args.Name)
+		if err != nil {
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] issue-based user lookup failed: %v", call.UserID, call.ChannelID, err)
+		} else if len(issueUsers) > 0 {
+			users = issueUsers
+			matchLabel = "issue assignee reverse lookup"
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] issue-based reverse lookup found %d match(es) for %q", call.UserID, call.ChannelID, len(users), args.Name)
+		}
+	}
+
+	if len(users) == 0 {
+		return fmt.Sprintf("No Jira users found matching name=%q email=%q after trying all search strategies (user search + issue reverse lookup). Verify the user exists in Jira and has issues assigned in project.", args.Name, args.Email)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d Jira user(s) (matched by %s):\n", len(users), matchLabel)
+	for i, u := range users {
+		if i >= 5 {
+			fmt.Fprintf(&sb, "  ... and %d more\n", len(users)-5)
+			break
+		}
+		fmt.Fprintf(&sb, "  • %s (accountId: %s, active: %v)\n", u.DisplayName, u.AccountID, u.Active)
+	}
+	fmt.Fprintf(&sb, "\nUse the accountId in JQL queries like: assignee = \"%s\"\n", users[0].AccountID)
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] resolved Jira user %q -> %s (%s) via %s", call.UserID, call.ChannelID, args.Name, users[0].DisplayName, users[0].AccountID, matchLabel)
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolResolveJiraTeam(ctx context.Context, call ToolCall) string {
+	if h.jiraClient == nil {
+		return "Error: Jira integration is not configured."
+	}
+	var args struct {
+		TeamName string `json:"team_name"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	// First discover the JQL clause name for the Team field.
+	fields, err := h.jiraClient.FindTeamFields()
+	if err != nil {
+		return fmt.Sprintf("Error discovering Team field: %v", err)
+	}
+	jqlClause := fields[0].JQLName
+	// Then resolve the team name to its UUID.
+	_, teamID, displayName, err := h.jiraClient.ResolveTeam(args.TeamName)
+	if err != nil {
+		return fmt.Sprintf("Error resolving team %q: %v. Try a different team name spelling.", args.TeamName, err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] resolved Jira team %q → %s (clause: %s)", call.UserID, call.ChannelID, args.TeamName, teamID, jqlClause)
+	return fmt.Sprintf("Team resolved:\n  Display Name: %s\n  Team UUID: %s\n  JQL Clause: %s\n\nUse in JQL: \"%s\" = \"%s\"\nExample: \"%s\" = \"%s\" AND status = \"In Progress\" ORDER BY priority DESC", displayName, teamID, jqlClause, jqlClause, teamID, jqlClause, teamID)
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/observability"
+)
+
+const (
+	// maxMetricsRange is the longest window query_metrics accepts.
+	maxMetricsRange = 30 * 24 * time.Hour
+	// maxMetricsSeries caps the series summarized per query.
+	maxMetricsSeries = 20
+)
+
+// metricsTools query the team's monitoring backend (Datadog or a
+// Prometheus-compatible API) and return numeric summaries of the series.
+// Offered only when a metrics backend is configured.
+var metricsTools = []*ToolDef{
+	{
+		Name:        "query_metrics",
+		Description: "Query time-series metrics from the monitoring system and get per-series summaries (last, average, p95, max and when it peaked, trend, sparkline). Use it for 'is <service> degraded?', latency or error-rate questions, and to confirm whether a deploy or incident changed a metric. Set compare_previous to compare with the preceding window of the same length. Quote the key numbers in your reply. The query language depends on the backend named in the result: Datadog metric queries (e.g. 'avg:trace.http.request.duration{service:api,env:prod}', 'sum:trace.http.request.errors{service:api}.as_count()') or PromQL (e.g. 'histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{job=\"api\"}[5m])))', 'sum(rate(http_requests_total{job=\"api\",code=~\"5..\"}[5m])) / sum(rate(http_requests_total{job=\"api\"}[5m]))').",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"query":{"type":"string","description":"Datadog metric query or PromQL expression, depending on the configured backend"},
+				"range":{"type":"string","description":"How far back to look, e.g. '30m', '6h', '7d' (default: 1h, max: 30d)"},
+				"end":{"type":"string","description":"End of the window as RFC 3339 (default: now), e.g. to look at the time of an incident"},
+				"step":{"type":"string","description":"Sample resolution for PromQL, e.g. '1m' (default: range/120)"},
+				"compare_previous":{"type":"boolean","description":"Also query the preceding window of the same length and report the change in average"}
+			},
+			"required":["query"]
+		}`),
+		Available: (*GeneralHandler).metricsConfigured,
+		Run:       (*GeneralHandler).toolQueryMetrics,
+	},
+}
+
+func (h *GeneralHandler) metricsConfigured() bool { return h.metricsBackend != nil }
+
+func (h *GeneralHandler) toolQueryMetrics(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Query           string `json:"query"`
+		Range           string `json:"range"`
+		End             string `json:"end"`
+		Step            string `json:"step"`
+		ComparePrevious bool   `json:"compare_previous"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return "Error: query is required."
+	}
+	window := time.Hour
+	if args.Range != "" {
+		d, err := parseLookback(args.Range)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		window = d
+	}
+	if window > maxMetricsRange {
+		return fmt.Sprintf("Error: range %s is longer than the maximum of 30d.", args.Range)
+	}
+	end := time.Now()
+	if args.End != "" {
+		t, err := time.Parse(time.RFC3339, args.End)
+		if err != nil {
+			return fmt.Sprintf("Error: invalid end %q: must be RFC 3339 (e.g. 2024-05-01T14:00:00Z)", args.End)
+		}
+		end = t
+	}
+	step := window / 120
+	if args.Step != "" {
+		d, err := parseLookback(args.Step)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		step = d
+	}
+	start := end.Add(-window)
+
+	series, err := h.metricsBackend.Query(ctx, args.Query, start, end, step)
+	if err != nil {
+		return fmt.Sprintf("Error querying %s: %v", h.metricsBackend.Name(), err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] queried %s metrics (%s): %d series", call.UserID, call.ChannelID, h.metricsBackend.Name(), window, len(series))
+	if len(series) == 0 {
+		return fmt.Sprintf("%s returned no data for %s over the last %s (ending %s). Check the metric name and tags/labels.", h.metricsBackend.Name(), args.Query, window, end.UTC().Format(time.RFC3339))
+	}
+
+	var previous map[string]observability.Summary
+	if args.ComparePrevious {
+		prev, err := h.metricsBackend.Query(ctx, args.Query, start.Add(-window), start, step)
+		if err != nil {
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] previous-window metrics query failed: %v", call.UserID, call.ChannelID, err)
+		} else {
+			previous = make(map[string]observability.Summary, len(prev))
+			for _, s := range prev {
+				previous[s.Name] = s.Summarize()
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%s: %d series for `%s`, %s → %s (%s).", h.metricsBackend.Name(), len(series), args.Query,
+		start.UTC().Format("2006-01-02 15:04"), end.UTC().Format("2006-01-02 15:04 MST"), window)
+	if len(series) > maxMetricsSeries {
+		summary += fmt.Sprintf(" Showing the first %d; aggregate the query (e.g. sum by / top) to narrow it.", maxMetricsSeries)
+		series = series[:maxMetricsSeries]
+	}
+	columns := []string{"Series", "Last", "Avg", "P95", "Max", "Max At", "Trend", "Sparkline"}
+	if previous != nil {
+		columns = append(columns, "Prev Avg", "vs Prev")
+	}
+	table := &Table{Name: "metrics", Columns: columns, Inline: 8}
+	if previous != nil {
+		table.Inline = 10
+	}
+	for _, s := range series {
+		st := s.Summarize()
+		if st.Count == 0 {
+			table.Rows = append(table.Rows, append([]string{s.Name, "no data"}, make([]string, len(columns)-2)...))
+			continue
+		}
+		row := []string{
+			s.Name,
+			observability.FormatValue(st.Last),
+			observability.FormatValue(st.Avg),
+			observability.FormatValue(st.P95),
+			observability.FormatValue(st.Max),
+			st.MaxAt.UTC().Format("01-02 15:04"),
+			formatChange(st.Change),
+			s.Sparkline(24),
+		}
+		if previous != nil {
+			if p, ok := previous[s.Name]; ok && p.Count > 0 {
+				change := math.NaN()
+				if p.Avg != 0 {
+					change = (st.Avg - p.Avg) / math.Abs(p.Avg)
+				}
+				row = append(row, observability.FormatValue(p.Avg), formatChange(change))
+			} else {
+				row = append(row, "", "new")
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return h.presentTable(call, summary, table)
+}
+
+// formatChange renders a relative change as "+12%", "-3%" or "flat".
+func formatChange(c float64) string {
+	switch {
+	case math.IsNaN(c):
+		return ""
+	case math.Abs(c) < 0.05:
+		return "flat"
+	default:
+		return fmt.Sprintf("%+.0f%%", c*100)
+	}
+}
+
+// parseLookback parses a duration that may also use d (days) and w (weeks),
+// e.g. "90m", "6h", "7d", "2w".
+func parseLookback(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid duration %q: must be like 30m, 6h or 7d", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be like 30m, 6h or 7d", s)
+	}
+	return d, nil
+}
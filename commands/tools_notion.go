@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/notion"
+)
+
+// notionTools search, read and write the team's Notion workspace so that
+// decisions and summaries reached in Slack can be filed where the team keeps
+// its docs. The integration only sees pages shared with it. Offered only
+// when NOTION_TOKEN is set.
+var notionTools = []*ToolDef{
+	{
+		Name:        "search_notion",
+		Description: "Search the Notion workspace for pages and databases by title. Use it to find where a decision log, meeting notes, runbook or team page lives before reading or appending to it. Returns IDs and URLs to pass to the other Notion tools. Only pages shared with the bot's integration are visible.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"query":{"type":"string","description":"Text to match against page and database titles (empty lists recently edited ones)"},
+				"type":{"type":"string","enum":["page","database"],"description":"Only return pages or only databases (default: both)"},
+				"max_results":{"type":"integer","description":"Maximum number of results (default: 10, max: 50)"}
+			}
+		}`),
+		Available: (*GeneralHandler).notionConfigured,
+		Run:       (*GeneralHandler).toolSearchNotion,
+	},
+	{
+		Name:        "read_notion_page",
+		Description: "Read a Notion page's title and text content (top-level blocks rendered as markdown). Use it to answer from team docs or to check a page's structure before appending to it.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"page":{"type":"string","description":"Page ID or Notion URL"}
+			},
+			"required":["page"]
+		}`),
+		Available: (*GeneralHandler).notionConfigured,
+		Run:       (*GeneralHandler).toolReadNotionPage,
+	},
+	{
+		Name:        "append_to_notion_page",
+		Description: "Append content to the end of an existing Notion page, e.g. add a decision to a decision log or a summary to meeting notes. Write the content in markdown — # headings, - bullets, 1. numbered items, - [ ] to-dos, > quotes, ``` code blocks and --- dividers become Notion blocks. Start with a dated heading when adding to a running log.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"page":{"type":"string","description":"Page ID or Notion URL"},
+				"content":{"type":"string","description":"Markdown content to append"}
+			},
+			"required":["page","content"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).notionConfigured,
+		Run:       (*GeneralHandler).toolAppendToNotionPage,
+	},
+	{
+		Name:        "create_notion_database_entry",
+		Description: "Add an entry (a new page) to a Notion database, e.g. a decision record, postmortem or meeting summary. The title fills the database's title column; content becomes the page body (markdown, as for append_to_notion_page). Other columns can be set by name in properties — supported column types: text, select, status, multi-select (comma-separated), date (YYYY-MM-DD), URL, number and checkbox.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"database":{"type":"string","description":"Database ID or Notion URL (find it with search_notion type=database)"},
+				"title":{"type":"string","description":"Entry title"},
+				"content":{"type":"string","description":"Markdown page body"},
+				"properties":{"type":"object","additionalProperties":{"type":"string"},"description":"Column name → value, e.g. {\"Status\":\"Accepted\",\"Date\":\"2024-05-01\"}"}
+			},
+			"required":["database","title","content"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).notionConfigured,
+		Run:       (*GeneralHandler).toolCreateNotionDatabaseEntry,
+	},
+}
+
+// notionPageChars caps the page text returned by read_notion_page.
+const notionPageChars = 20000
+
+func (h *GeneralHandler) notionConfigured() bool { return h.notionClient != nil }
+
+func (h *GeneralHandler) toolSearchNotion(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Query      string `json:"query"`
+		Type       string `json:"type"`
+		MaxResults int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	results, err := h.notionClient.Search(ctx, args.Query, args.Type, args.MaxResults)
+	if err != nil {
+		return fmt.Sprintf("Error searching Notion: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] searched Notion for %q: %d result(s)", call.UserID, call.ChannelID, args.Query, len(results))
+	if len(results) == 0 {
+		return fmt.Sprintf("No Notion pages or databases match %q. The page may not be shared with the integration.", args.Query)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Notion results for %q (%d):\n", args.Query, len(results))
+	for _, r := range results {
+		fmt.Fprintf(&sb, "- [%s] %s — id %s, edited %s\n  %s\n", r.Object, r.Title, r.ID, r.LastEdited.Format("2006-01-02"), r.URL)
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolReadNotionPage(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Page string `json:"page"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	id, err := notion.ParseID(args.Page)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	page, err := h.notionClient.GetPage(ctx, id, notionPageChars)
+	if err != nil {
+		return fmt.Sprintf("Error reading Notion page: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] read Notion page %s", call.UserID, call.ChannelID, id)
+	if strings.TrimSpace(page.Content) == "" {
+		page.Content = "(empty page)"
+	}
+	return fmt.Sprintf("Notion page: %s\nURL: %s\n\n%s", page.Title, page.URL, page.Content)
+}
+
+func (h *GeneralHandler) toolAppendToNotionPage(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Page    string `json:"page"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Content) == "" {
+		return "Error: content is required."
+	}
+	id, err := notion.ParseID(args.Page)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	if err := h.notionClient.AppendMarkdown(ctx, id, args.Content+h.ticketStamp(call.UserID)); err != nil {
+		return fmt.Sprintf("Error appending to Notion page: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] appended %d chars to Notion page %s", call.UserID, call.ChannelID, len(args.Content), id)
+	return fmt.Sprintf("Appended to Notion page %s (https://www.notion.so/%s).", id, strings.ReplaceAll(id, "-", ""))
+}
+
+func (h *GeneralHandler) toolCreateNotionDatabaseEntry(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Database   string            `json:"database"`
+		Title      string            `json:"title"`
+		Content    string            `json:"content"`
+		Properties map[string]string `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Title) == "" {
+		return "Error: title is required."
+	}
+	id, err := notion.ParseID(args.Database)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	entry, err := h.notionClient.CreateDatabaseEntry(ctx, id, args.Title, args.Content+h.ticketStamp(call.UserID), args.Properties)
+	if err != nil {
+		return fmt.Sprintf("Error creating Notion database entry: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] created Notion entry %s in database %s", call.UserID, call.ChannelID, entry.ID, id)
+	return fmt.Sprintf("Notion entry created: *%s* — %s", entry.Title, entry.URL)
+}
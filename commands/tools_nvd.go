@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/nvd"
+)
+
+// nvdTools look up vulnerabilities in the NVD. Offered only when the NVD client is configured.
+var nvdTools = []*ToolDef{
+	{
+		Name:        "lookup_cve",
+		Description: "Look up a specific CVE by its ID from the NVD (National Vulnerability Database). Returns full details: description, CVSS scores, affected products (CPEs), weaknesses (CWEs), and references. ALWAYS call this tool FIRST when the user mentions a CVE ID (e.g. CVE-2025-13836) to get authoritative data before searching code.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"cve_id":{"type":"string","description":"The CVE identifier (e.g. 'CVE-2025-13836')"}
+			},
+			"required":["cve_id"]
+		}`),
+		Available: (*GeneralHandler).nvdConfigured,
+		Run:       (*GeneralHandler).toolLookupCVE,
+	},
+	{
+		Name:        "search_cve",
+		Description: "Search NVD for CVEs by keyword. Returns matching CVEs with their descriptions and CVSS scores. Useful for finding CVEs related to a specific library, product, or vulnerability type when you don't have the exact CVE ID.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"keyword":{"type":"string","description":"Search keyword(s) to match against CVE descriptions (e.g. 'log4j remote code execution', 'jackson-databind')"},
+				"results_per_page":{"type":"integer","description":"Number of results to return (default: 5, max: 20)"}
+			},
+			"required":["keyword"]
+		}`),
+		Available: (*GeneralHandler).nvdConfigured,
+		Run:       (*GeneralHandler).toolSearchCVE,
+	},
+}
+
+func (h *GeneralHandler) toolLookupCVE(ctx context.Context, call ToolCall) string {
+	if h.nvdClient == nil {
+		return "Error: NVD integration is not configured."
+	}
+	var args struct {
+		CVEID string `json:"cve_id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	args.CVEID = strings.TrimSpace(strings.ToUpper(args.CVEID))
+	if args.CVEID == "" {
+		return "Error: cve_id is required."
+	}
+	cve, err := h.nvdClient.LookupCVE(ctx, args.CVEID)
+	if err != nil {
+		return fmt.Sprintf("Error looking up %s: %v", args.CVEID, err)
+	}
+	items := []nvd.CVEItem{*cve}
+	if err := h.nvdClient.Enrich(ctx, items); err != nil {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] EPSS/KEV enrichment of %s incomplete: %v", call.UserID, call.ChannelID, args.CVEID, err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] looked up CVE %s from NVD", call.UserID, call.ChannelID, args.CVEID)
+	return nvd.FormatCVE(&items[0])
+}
+
+func (h *GeneralHandler) toolSearchCVE(ctx context.Context, call ToolCall) string {
+	if h.nvdClient == nil {
+		return "Error: NVD integration is not configured."
+	}
+	var args struct {
+		Keyword        string `json:"keyword"`
+		ResultsPerPage int    `json:"results_per_page"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if args.Keyword == "" {
+		return "Error: keyword is required."
+	}
+	items, total, err := h.nvdClient.SearchCVE(ctx, args.Keyword, args.ResultsPerPage)
+	if err != nil {
+		return fmt.Sprintf("Error searching NVD: %v", err)
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf("No CVEs found matching '%s'.", args.Keyword)
+	}
+	if err := h.nvdClient.Enrich(ctx, items); err != nil {
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] EPSS/KEV enrichment incomplete: %v", call.UserID, call.ChannelID, err)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d CVEs matching '%s' (showing %d):\n\n", total, args.Keyword, len(items))
+	for _, item := range items {
+		sb.WriteString(nvd.FormatCVE(&item))
+		sb.WriteString("\n---\n")
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] searched NVD for '%s' (%d results)", call.UserID, call.ChannelID, args.Keyword, total)
+	return sb.String()
+}
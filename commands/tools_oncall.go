@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// onCallTools resolve "the on-call for X" to a person, so requests can page,
+// mention or assign them. Offered when Opsgenie or PagerDuty is configured.
+var onCallTools = []*ToolDef{
+	{
+		Name:        "whos_on_call",
+		Description: "Look up who is on call right now in Opsgenie or PagerDuty, with their email (and Jira account when Jira is configured). Use it whenever a request refers to 'the on-call' or 'whoever is on call' for a team or service — e.g. before assigning a Jira ticket to them or mentioning them about a failure. Level 1 is the first responder; escalate to higher levels only when asked.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"schedule":{"type":"string","description":"Team, schedule or escalation policy name (partial match), e.g. 'platform'. Omit to list every schedule."}
+			}
+		}`),
+		Available: (*GeneralHandler).onCallConfigured,
+		Run:       (*GeneralHandler).toolWhosOnCall,
+	},
+}
+
+func (h *GeneralHandler) onCallConfigured() bool { return h.onCall != nil }
+
+func (h *GeneralHandler) toolWhosOnCall(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Schedule string `json:"schedule"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	shifts, err := h.onCall.OnCall(ctx, args.Schedule)
+	if err != nil {
+		return fmt.Sprintf("Error looking up on-call in %s: %v", h.onCall.Name(), err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] looked up on-call %q in %s: %d shift(s)", call.UserID, call.ChannelID, args.Schedule, h.onCall.Name(), len(shifts))
+	if len(shifts) == 0 {
+		if args.Schedule == "" {
+			return fmt.Sprintf("Nobody is on call in %s right now.", h.onCall.Name())
+		}
+		return fmt.Sprintf("No %s schedule or escalation policy matching %q has anyone on call right now. Call whos_on_call without a schedule to see all of them.", h.onCall.Name(), args.Schedule)
+	}
+	sort.SliceStable(shifts, func(i, j int) bool {
+		if shifts[i].Schedule != shifts[j].Schedule {
+			return shifts[i].Schedule < shifts[j].Schedule
+		}
+		return shifts[i].Level < shifts[j].Level
+	})
+
+	jiraIDs := make(map[string]string)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "On call now (%s):\n", h.onCall.Name())
+	for _, s := range shifts {
+		fmt.Fprintf(&sb, "- %s", s.Schedule)
+		if s.Team != "" && s.Team != s.Schedule {
+			fmt.Fprintf(&sb, " (%s)", s.Team)
+		}
+		if s.Level > 0 {
+			fmt.Fprintf(&sb, ", level %d", s.Level)
+		}
+		fmt.Fprintf(&sb, ": %s", s.Name)
+		if s.Email != "" && s.Email != s.Name {
+			fmt.Fprintf(&sb, " <%s>", s.Email)
+		}
+		if !s.Until.IsZero() {
+			fmt.Fprintf(&sb, ", until %s", s.Until.UTC().Format(time.RFC3339))
+		}
+		if h.jiraClient != nil && s.Email != "" {
+			id, ok := jiraIDs[s.Email]
+			if !ok {
+				if users, err := h.jiraClient.SearchUsersGeneral(s.Email); err == nil && len(users) == 1 {
+					id = users[0].AccountID
+				}
+				jiraIDs[s.Email] = id
+			}
+			if id != "" {
+				fmt.Fprintf(&sb, ", Jira account %s", id)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
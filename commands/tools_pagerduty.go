@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/pagerduty"
+)
+
+// pagerDutyTools list, inspect, acknowledge and resolve PagerDuty incidents
+// so on-call engineers can triage from the Slack thread, together with the
+// workflow run and log tools. Offered only when PagerDuty is configured.
+var pagerDutyTools = []*ToolDef{
+	{
+		Name:        "list_pagerduty_incidents",
+		Description: "List PagerDuty incidents — by default the open ones (triggered and acknowledged), newest first. Use it for 'what's firing?', 'is anything open for <service>?' or at the start of on-call triage.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"statuses":{"type":"array","items":{"type":"string","enum":["triggered","acknowledged","resolved"]},"description":"Statuses to include (default: triggered and acknowledged)"},
+				"urgency":{"type":"string","enum":["high","low"],"description":"Only incidents of this urgency"},
+				"service":{"type":"string","description":"Service name (partial match) or ID"},
+				"since_hours":{"type":"integer","description":"Only incidents created in the last N hours"},
+				"max_results":{"type":"integer","description":"Maximum number of incidents (default: 25, max: 100)"}
+			}
+		}`),
+		Available: (*GeneralHandler).pagerDutyConfigured,
+		Run:       (*GeneralHandler).toolListPagerDutyIncidents,
+	},
+	{
+		Name:        "get_pagerduty_incident",
+		Description: "Get a PagerDuty incident's details, timeline (triggers, escalations, acknowledgements, notifications) and notes. Use it to understand who was paged, what happened so far and what responders wrote before digging into workflow runs or logs.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"incident":{"type":"string","description":"Incident ID (e.g. 'Q1ABCDEF2GHIJ') or incident URL"}
+			},
+			"required":["incident"]
+		}`),
+		Available: (*GeneralHandler).pagerDutyConfigured,
+		Run:       (*GeneralHandler).toolGetPagerDutyIncident,
+	},
+	{
+		Name:        "acknowledge_pagerduty_incident",
+		Description: "Acknowledge a triggered PagerDuty incident, stopping further escalation. Only do this when the user asks to ack it. An optional note is added to the incident timeline.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"incident":{"type":"string","description":"Incident ID or URL"},
+				"note":{"type":"string","description":"Note to add, e.g. who is looking at it"}
+			},
+			"required":["incident"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).pagerDutyConfigured,
+		Run:       (*GeneralHandler).toolAcknowledgePagerDutyIncident,
+	},
+	{
+		Name:        "resolve_pagerduty_incident",
+		Description: "Resolve a PagerDuty incident. Only do this when the user confirms the issue is fixed. Pass a note with the resolution (cause and fix, PR or workflow run link) so it lands on the incident timeline.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"incident":{"type":"string","description":"Incident ID or URL"},
+				"note":{"type":"string","description":"Resolution note"}
+			},
+			"required":["incident"]
+		}`),
+		Class:     ToolWrite,
+		Available: (*GeneralHandler).pagerDutyConfigured,
+		Run:       (*GeneralHandler).toolResolvePagerDutyIncident,
+	},
+}
+
+func (h *GeneralHandler) pagerDutyConfigured() bool { return h.pagerDuty != nil }
+
+func (h *GeneralHandler) toolListPagerDutyIncidents(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Statuses   []string `json:"statuses"`
+		Urgency    string   `json:"urgency"`
+		Service    string   `json:"service"`
+		SinceHours int      `json:"since_hours"`
+		MaxResults int      `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	opts := pagerduty.ListOptions{Statuses: args.Statuses, Urgency: args.Urgency, Service: args.Service, Limit: args.MaxResults}
+	if args.SinceHours > 0 {
+		opts.Since = time.Now().Add(-time.Duration(args.SinceHours) * time.Hour)
+	}
+	incidents, err := h.pagerDuty.ListIncidents(ctx, opts)
+	if err != nil {
+		return fmt.Sprintf("Error listing PagerDuty incidents: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d PagerDuty incidents", call.UserID, call.ChannelID, len(incidents))
+	if len(incidents) == 0 {
+		return "No matching PagerDuty incidents."
+	}
+	table := &Table{
+		Name:    "pagerduty-incidents",
+		Columns: []string{"#", "Status", "Urgency", "Title", "Service", "Assignees", "Created", "Priority", "ID", "URL"},
+		Inline:  7,
+	}
+	for _, in := range incidents {
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprint(in.Number), in.Status, in.Urgency, in.Title, in.Service, strings.Join(in.Assignees, ", "),
+			in.CreatedAt.Format("2006-01-02 15:04"), in.Priority, in.ID, in.URL,
+		})
+	}
+	return h.presentTable(call, fmt.Sprintf("Found %d PagerDuty incident(s).", len(incidents)), table)
+}
+
+func (h *GeneralHandler) toolGetPagerDutyIncident(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Incident string `json:"incident"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	id := pagerduty.ParseIncidentID(args.Incident)
+	in, err := h.pagerDuty.GetIncident(ctx, id)
+	if err != nil {
+		return fmt.Sprintf("Error getting PagerDuty incident: %v", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*#%d* %s\n", in.Number, in.Title)
+	fmt.Fprintf(&sb, "Status: %s | Urgency: %s", in.Status, in.Urgency)
+	if in.Priority != "" {
+		fmt.Fprintf(&sb, " | Priority: %s", in.Priority)
+	}
+	fmt.Fprintf(&sb, "\nService: %s | Escalation policy: %s\n", in.Service, in.EscalationPolicy)
+	if len(in.Assignees) > 0 {
+		fmt.Fprintf(&sb, "Assigned to: %s\n", strings.Join(in.Assignees, ", "))
+	}
+	fmt.Fprintf(&sb, "Created: %s (%s ago)\nURL: %s\n", in.CreatedAt.Format(time.RFC3339), time.Since(in.CreatedAt).Round(time.Minute), in.URL)
+	if in.Description != "" {
+		fmt.Fprintf(&sb, "\nDescription:\n%s\n", in.Description)
+	}
+
+	// The timeline and notes are best effort; the incident itself is enough
+	// to act on.
+	if entries, err := h.pagerDuty.Timeline(ctx, id); err != nil {
+		fmt.Fprintf(&sb, "\nTimeline unavailable: %v\n", err)
+	} else if len(entries) > 0 {
+		sb.WriteString("\nTimeline:\n")
+		for _, e := range entries {
+			fmt.Fprintf(&sb, "- %s %s", e.CreatedAt.Format("15:04:05"), e.Summary)
+			if e.Agent != "" && !strings.Contains(e.Summary, e.Agent) {
+				fmt.Fprintf(&sb, " (%s)", e.Agent)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if notes, err := h.pagerDuty.Notes(ctx, id); err == nil && len(notes) > 0 {
+		sb.WriteString("\nNotes:\n")
+		for _, n := range notes {
+			fmt.Fprintf(&sb, "- %s %s: %s\n", n.CreatedAt.Format("2006-01-02 15:04"), n.User, n.Content)
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched PagerDuty incident %s", call.UserID, call.ChannelID, id)
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolAcknowledgePagerDutyIncident(ctx context.Context, call ToolCall) string {
+	return h.setPagerDutyStatus(ctx, call, "acknowledged")
+}
+
+func (h *GeneralHandler) toolResolvePagerDutyIncident(ctx context.Context, call ToolCall) string {
+	return h.setPagerDutyStatus(ctx, call, "resolved")
+}
+
+// setPagerDutyStatus adds the optional note, then moves the incident to
+// status. The note goes first so it is on the timeline even if the status
+// change fails (e.g. the incident was already resolved).
+func (h *GeneralHandler) setPagerDutyStatus(ctx context.Context, call ToolCall, status string) string {
+	var args struct {
+		Incident string `json:"incident"`
+		Note     string `json:"note"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	id := pagerduty.ParseIncidentID(args.Incident)
+	if strings.TrimSpace(args.Note) != "" {
+		note := fmt.Sprintf("%s\n\n(via %s, requested by Slack user %s)", args.Note, h.agentID, call.UserID)
+		if err := h.pagerDuty.AddNote(ctx, id, note); err != nil {
+			return fmt.Sprintf("Error adding note to PagerDuty incident: %v", err)
+		}
+	}
+	in, err := h.pagerDuty.SetStatus(ctx, id, status)
+	if err != nil {
+		return fmt.Sprintf("Error updating PagerDuty incident: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] set PagerDuty incident %s to %s", call.UserID, call.ChannelID, id, status)
+	return fmt.Sprintf("PagerDuty incident *#%d* %s is now %s — %s", in.Number, in.Title, in.Status, in.URL)
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// slackTools read Slack context and reply in threads.
+var slackTools = []*ToolDef{
+	{
+		Name:        "fetch_channel_context",
+		Description: "Fetch recent messages from the current Slack channel for additional context about the ongoing conversation.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Run:         (*GeneralHandler).toolFetchChannelContext,
+	},
+	{
+		Name:        "reply_in_thread",
+		Description: "Post a message as a threaded reply to a specific Slack message. Use this when the user asks you to reply inside someone's thread or respond to a particular message. You need the thread_ts of the target message from the channel context. IMPORTANT: Messages marked [BOT] are this bot's own messages — never reply to those. Always use the thread_ts of the HUMAN user's message (e.g. the person mentioned by name like 'Shahar', 'John', etc.).",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"thread_ts":{"type":"string","description":"The thread_ts timestamp of the target human user's message to reply to. MUST be from a non-[BOT] message. Get this from the channel context."},
+				"text":{"type":"string","description":"The message text to post as a threaded reply. Supports Slack markdown formatting."}
+			},
+			"required":["thread_ts","text"]
+		}`),
+		Class: ToolWrite,
+		Run:   (*GeneralHandler).toolReplyInThread,
+	},
+	{
+		Name:        "fetch_thread_context",
+		Description: "Fetch the full conversation from a Slack thread URL. Use this FIRST whenever the user provides a Slack thread/message link (https://...slack.com/archives/...) to read the thread's content before acting on it (e.g., creating a Jira ticket, summarizing, replying). Returns all messages in the thread. The response also includes the channel_id and thread_ts so you can reply_in_thread afterwards.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"url":{"type":"string","description":"Slack thread or message URL (e.g. 'https://yourorg.slack.com/archives/C01BS13KFL7/p1771847194296799')"}
+			},
+			"required":["url"]
+		}`),
+		Run: (*GeneralHandler).toolFetchThreadContext,
+	},
+	{
+		Name:        "get_slack_user_info",
+		Description: "Get the real name and profile information of a Slack user by their user ID. Use this to resolve the current user's real name for Jira queries. The user_id is available from the conversation context (the person who sent the command).",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"user_id":{"type":"string","description":"Slack user ID (e.g. 'U01ABC123'). Use the current user's ID from the command context."}
+			},
+			"required":["user_id"]
+		}`),
+		Run: (*GeneralHandler).toolGetSlackUserInfo,
+	},
+}
+
+func (h *GeneralHandler) toolFetchChannelContext(ctx context.Context, call ToolCall) string {
+	context, err := h.contextProvider.GetChannelContext(call.ChannelID)
+	if err != nil {
+		return fmt.Sprintf("Error fetching channel context: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched channel context via tool", call.UserID, call.ChannelID)
+	return context
+}
+
+func (h *GeneralHandler) toolReplyInThread(ctx context.Context, call ToolCall) string {
+	var args struct {
+		ThreadTS string `json:"thread_ts"`
+		Text     string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if err := h.slackClient.PostThreadReply(call.ChannelID, args.ThreadTS, args.Text); err != nil {
+		return fmt.Sprintf("Error posting thread reply: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] posted thread reply to ts=%s", call.UserID, call.ChannelID, args.ThreadTS)
+	return "Successfully posted reply in thread."
+}
+
+func (h *GeneralHandler) toolFetchThreadContext(ctx context.Context, call ToolCall) string {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	threadChannelID, threadTS, err := ParseSlackThreadURL(args.URL)
+	if err != nil {
+		return fmt.Sprintf("Error parsing Slack thread URL: %v", err)
+	}
+	msgs, err := h.slackClient.FetchThreadReplies(threadChannelID, threadTS, 100)
+	if err != nil {
+		return fmt.Sprintf("Error fetching thread replies: %v", err)
+	}
+	if len(msgs) == 0 {
+		return fmt.Sprintf("No messages found in thread (channel=%s, thread_ts=%s).", threadChannelID, threadTS)
+	}
+	formatted := formatMessages(msgs)
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched thread context from %s (%d messages)", call.UserID, call.ChannelID, args.URL, len(msgs))
+	return fmt.Sprintf("Thread context (channel_id=%s, thread_ts=%s):\n\n%s", threadChannelID, threadTS, formatted)
+}
+
+func (h *GeneralHandler) toolGetSlackUserInfo(ctx context.Context, call ToolCall) string {
+	var args struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	user, err := h.slackClient.GetUserInfo(args.UserID)
+	if err != nil {
+		return fmt.Sprintf("Error getting user info: %v", err)
+	}
+	return fmt.Sprintf("Slack User Info:\n  User ID: %s\n  Real Name: %s\n  Display Name: %s\n  Email: %s\n  Title: %s",
+		user.ID, user.RealName, user.Profile.DisplayName, user.Profile.Email, user.Profile.Title)
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/terraform"
+)
+
+const (
+	// maxTerraformPlanResources caps the resources listed per plan.
+	maxTerraformPlanResources = 60
+	// maxTerraformFieldLines caps the changed attributes shown per resource.
+	maxTerraformFieldLines = 10
+)
+
+// terraformTools list Terraform Cloud / Enterprise runs and turn a plan into
+// a per-resource change list the model can summarize, with destructive
+// changes first. Read-only; offered only when Terraform Cloud is configured.
+var terraformTools = []*ToolDef{
+	{
+		Name:        "list_terraform_runs",
+		Description: "List a Terraform Cloud/Enterprise workspace's recent runs: status (e.g. planned and waiting for approval, applied, errored), trigger message and source, and the plan's add/change/destroy counts. Use it for 'what's pending on prod?' or to find a run ID.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"workspace":{"type":"string","description":"Workspace name"},
+				"organization":{"type":"string","description":"Organization (default: the configured organization)"},
+				"max_results":{"type":"integer","description":"Number of recent runs (default: 10, max: 50)"}
+			},
+			"required":["workspace"]
+		}`),
+		Available: (*GeneralHandler).terraformConfigured,
+		Run:       (*GeneralHandler).toolListTerraformRuns,
+	},
+	{
+		Name:        "get_terraform_plan",
+		Description: "Get the resource-level changes of a Terraform Cloud/Enterprise plan: every resource to create, update, replace or destroy, with the changed attributes (before → after) and which attributes force a replacement. Destructive changes (destroy and replace) are listed first. Defaults to the run waiting for approval, else the latest run with a finished plan. Use it for 'summarize the pending plan for prod and flag destructive changes'.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"workspace":{"type":"string","description":"Workspace name"},
+				"organization":{"type":"string","description":"Organization (default: the configured organization)"},
+				"run_id":{"type":"string","description":"A specific run, e.g. 'run-AbC123' (default: the pending run)"},
+				"destructive_only":{"type":"boolean","description":"Only list destroyed and replaced resources"}
+			},
+			"required":["workspace"]
+		}`),
+		Available: (*GeneralHandler).terraformConfigured,
+		Run:       (*GeneralHandler).toolGetTerraformPlan,
+	},
+}
+
+func (h *GeneralHandler) terraformConfigured() bool { return h.terraform != nil }
+
+func (h *GeneralHandler) toolListTerraformRuns(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Workspace    string `json:"workspace"`
+		Organization string `json:"organization"`
+		MaxResults   int    `json:"max_results"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	limit := 10
+	if args.MaxResults > 0 {
+		limit = min(args.MaxResults, 50)
+	}
+	ws, err := h.terraform.GetWorkspace(ctx, args.Organization, args.Workspace)
+	if err != nil {
+		return fmt.Sprintf("Error getting Terraform workspace: %v", err)
+	}
+	runs, err := h.terraform.ListRuns(ctx, ws.ID, limit)
+	if err != nil {
+		return fmt.Sprintf("Error listing Terraform runs: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed %d Terraform runs of %s/%s", call.UserID, call.ChannelID, len(runs), ws.Organization, ws.Name)
+	if len(runs) == 0 {
+		return fmt.Sprintf("No runs in workspace %s.", ws.Name)
+	}
+
+	summary := fmt.Sprintf("Workspace *%s* (Terraform %s, %s execution", ws.Name, ws.TerraformVersion, ws.ExecutionMode)
+	if ws.AutoApply {
+		summary += ", auto-apply"
+	}
+	summary += ")"
+	if ws.Locked {
+		summary += " — locked"
+	}
+	table := &Table{
+		Name:    "terraform-runs",
+		Columns: []string{"Run", "Status", "Created", "Plan (+/~/-)", "Message", "Source", "URL"},
+		Inline:  5,
+	}
+	for _, r := range runs {
+		status := r.Status
+		if r.Confirmable {
+			status += " (awaiting approval)"
+		}
+		if r.IsDestroy {
+			status += " [destroy run]"
+		}
+		counts := ""
+		if r.PlanStatus == "finished" {
+			counts = fmt.Sprintf("+%d ~%d -%d", r.Additions, r.Changes, r.Destructions)
+		}
+		msg, _, _ := strings.Cut(r.Message, "\n")
+		table.Rows = append(table.Rows, []string{
+			r.ID, status, r.CreatedAt.UTC().Format("2006-01-02 15:04"), counts, msg, r.Source, h.terraform.RunURL(ws, r.ID),
+		})
+	}
+	return h.presentTable(call, summary, table)
+}
+
+func (h *GeneralHandler) toolGetTerraformPlan(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Workspace       string `json:"workspace"`
+		Organization    string `json:"organization"`
+		RunID           string `json:"run_id"`
+		DestructiveOnly bool   `json:"destructive_only"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	ws, err := h.terraform.GetWorkspace(ctx, args.Organization, args.Workspace)
+	if err != nil {
+		return fmt.Sprintf("Error getting Terraform workspace: %v", err)
+	}
+
+	var run *terraform.Run
+	if args.RunID != "" {
+		if run, err = h.terraform.GetRun(ctx, args.RunID); err != nil {
+			return fmt.Sprintf("Error getting Terraform run: %v", err)
+		}
+	} else {
+		runs, err := h.terraform.ListRuns(ctx, ws.ID, 20)
+		if err != nil {
+			return fmt.Sprintf("Error listing Terraform runs: %v", err)
+		}
+		for i := range runs {
+			if runs[i].Confirmable {
+				run = &runs[i]
+				break
+			}
+		}
+		if run == nil {
+			for i := range runs {
+				if runs[i].PlanStatus == "finished" {
+					run = &runs[i]
+					break
+				}
+			}
+		}
+		if run == nil {
+			return fmt.Sprintf("No run with a finished plan among the last %d runs of %s.", len(runs), ws.Name)
+		}
+	}
+	if run.PlanStatus != "finished" {
+		return fmt.Sprintf("The plan of run %s is %q, not finished; there are no changes to show yet.", run.ID, run.PlanStatus)
+	}
+
+	changes, outputs, err := h.terraform.PlanChanges(ctx, run.PlanID)
+	if err != nil {
+		return fmt.Sprintf("Error getting Terraform plan: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] fetched Terraform plan of %s (%d resource changes)", call.UserID, call.ChannelID, run.ID, len(changes))
+
+	var destructive, other []terraform.ResourceChange
+	for _, c := range changes {
+		if c.Destructive() {
+			destructive = append(destructive, c)
+		} else if !args.DestructiveOnly && c.Action != "read" {
+			other = append(other, c)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Run %s on %s — %s", run.ID, ws.Name, run.Status)
+	if run.Confirmable {
+		sb.WriteString(" (awaiting approval)")
+	}
+	fmt.Fprintf(&sb, "\nPlan: %d to add, %d to change, %d to destroy", run.Additions, run.Changes, run.Destructions)
+	if run.Imports > 0 {
+		fmt.Fprintf(&sb, ", %d to import", run.Imports)
+	}
+	if run.Message != "" {
+		fmt.Fprintf(&sb, "\nMessage: %s", run.Message)
+	}
+	fmt.Fprintf(&sb, "\n%s\n", h.terraform.RunURL(ws, run.ID))
+	if run.IsDestroy {
+		sb.WriteString("\n⚠️ This is a destroy run: it destroys every resource in the workspace.\n")
+	}
+
+	shown := 0
+	if len(destructive) > 0 {
+		fmt.Fprintf(&sb, "\n⚠️ Destructive changes (%d):\n", len(destructive))
+		shown += writeTerraformChanges(&sb, destructive, maxTerraformPlanResources)
+	} else {
+		sb.WriteString("\nNo resources are destroyed or replaced.\n")
+	}
+	if len(other) > 0 {
+		fmt.Fprintf(&sb, "\nOther changes (%d):\n", len(other))
+		shown += writeTerraformChanges(&sb, other, maxTerraformPlanResources-shown)
+	}
+	if total := len(destructive) + len(other); shown < total {
+		fmt.Fprintf(&sb, "\n… %d more resource(s) not shown; see the run in Terraform Cloud.\n", total-shown)
+	}
+	if len(outputs) > 0 && !args.DestructiveOnly {
+		fmt.Fprintf(&sb, "\nOutputs changed: %s\n", strings.Join(outputs, ", "))
+	}
+	return sb.String()
+}
+
+// writeTerraformChanges writes up to limit resource changes to sb and
+// returns how many it wrote.
+func writeTerraformChanges(sb *strings.Builder, changes []terraform.ResourceChange, limit int) int {
+	n := min(len(changes), max(limit, 0))
+	for _, c := range changes[:n] {
+		fmt.Fprintf(sb, "• %s — %s", c.Address, c.Action)
+		if c.Reason != "" {
+			fmt.Fprintf(sb, " (%s)", c.Reason)
+		}
+		if len(c.ForcesReplacement) > 0 {
+			fmt.Fprintf(sb, "; forced by %s", strings.Join(c.ForcesReplacement, ", "))
+		}
+		sb.WriteString("\n")
+		for _, f := range c.Fields[:min(len(c.Fields), maxTerraformFieldLines)] {
+			fmt.Fprintf(sb, "    %s\n", f)
+		}
+		if len(c.Fields) > maxTerraformFieldLines {
+			fmt.Fprintf(sb, "    … %d more\n", len(c.Fields)-maxTerraformFieldLines)
+		}
+	}
+	return n
+}
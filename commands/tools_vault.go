@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// vaultTools answer questions about Vault secrets — what exists, when it was
+// rotated, when a lease expires — from metadata only. Secret values are never
+// read, so the LLM cannot leak them. Offered only when Vault is configured.
+var vaultTools = []*ToolDef{
+	{
+		Name:        "list_vault_secrets",
+		Description: "List secret paths in a Vault KV v2 mount. Entries ending in '/' are folders; list them to go deeper. Never returns secret values.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"path":{"type":"string","description":"Folder to list, e.g. 'apps/payments/' (default: mount root)"},
+				"mount":{"type":"string","description":"KV v2 mount (default: the configured mount)"}
+			}
+		}`),
+		Available: (*GeneralHandler).vaultConfigured,
+		Run:       (*GeneralHandler).toolListVaultSecrets,
+	},
+	{
+		Name:        "get_vault_secret_metadata",
+		Description: "Get the metadata of a Vault KV v2 secret: current version, when it was created and last updated (i.e. rotated), version history, deletion settings and custom metadata (e.g. owner, rotation period). Never returns secret values. Use it for 'when was the API key last rotated?'.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"path":{"type":"string","description":"Secret path, e.g. 'apps/payments/stripe'"},
+				"mount":{"type":"string","description":"KV v2 mount (default: the configured mount)"}
+			},
+			"required":["path"]
+		}`),
+		Available: (*GeneralHandler).vaultConfigured,
+		Run:       (*GeneralHandler).toolGetVaultSecretMetadata,
+	},
+	{
+		Name:        "check_vault_lease",
+		Description: "Check the TTL and expiry of Vault leases for dynamic secrets (database credentials, cloud credentials). Pass a lease ID, or a prefix ending in '/' (e.g. 'database/creds/app-readonly/') to check every lease under it, soonest expiry first. Use it for 'is the DB credential expiring?'.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"lease":{"type":"string","description":"Lease ID, or a lease prefix ending in '/'"}
+			},
+			"required":["lease"]
+		}`),
+		Available: (*GeneralHandler).vaultConfigured,
+		Run:       (*GeneralHandler).toolCheckVaultLease,
+	},
+}
+
+// maxVaultLeases caps the leases looked up for a prefix.
+const maxVaultLeases = 50
+
+func (h *GeneralHandler) vaultConfigured() bool { return h.vaultClient != nil }
+
+func (h *GeneralHandler) toolListVaultSecrets(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Path  string `json:"path"`
+		Mount string `json:"mount"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	keys, err := h.vaultClient.List(ctx, args.Mount, args.Path)
+	if err != nil {
+		return fmt.Sprintf("Error listing Vault secrets: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed Vault path %q: %d entries", call.UserID, call.ChannelID, args.Path, len(keys))
+	if len(keys) == 0 {
+		return fmt.Sprintf("No secrets under %q.", args.Path)
+	}
+	return fmt.Sprintf("%d entries under %q:\n%s", len(keys), args.Path, strings.Join(keys, "\n"))
+}
+
+func (h *GeneralHandler) toolGetVaultSecretMetadata(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Path  string `json:"path"`
+		Mount string `json:"mount"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return "Error: path is required."
+	}
+	md, err := h.vaultClient.Metadata(ctx, args.Mount, args.Path)
+	if err != nil {
+		return fmt.Sprintf("Error reading Vault secret metadata: %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] read Vault metadata of %q", call.UserID, call.ChannelID, md.Path)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* — version %d\n", md.Path, md.CurrentVersion)
+	fmt.Fprintf(&sb, "Created: %s\n", md.CreatedTime.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Last updated: %s (%s ago)\n", md.UpdatedTime.Format(time.RFC3339), time.Since(md.UpdatedTime).Round(time.Hour))
+	if md.MaxVersions > 0 {
+		fmt.Fprintf(&sb, "Max versions kept: %d\n", md.MaxVersions)
+	}
+	if md.DeleteAfter != "" && md.DeleteAfter != "0s" {
+		fmt.Fprintf(&sb, "Versions deleted after: %s\n", md.DeleteAfter)
+	}
+	if len(md.CustomMetadata) > 0 {
+		keys := make([]string, 0, len(md.CustomMetadata))
+		for k := range md.CustomMetadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("Custom metadata:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "- %s: %s\n", k, md.CustomMetadata[k])
+		}
+	}
+	if len(md.Versions) > 0 {
+		sb.WriteString("Versions:\n")
+		for _, v := range md.Versions[:min(len(md.Versions), 10)] {
+			fmt.Fprintf(&sb, "- v%d %s", v.Number, v.CreatedTime.Format("2006-01-02 15:04"))
+			switch {
+			case v.Destroyed:
+				sb.WriteString(" (destroyed)")
+			case !v.DeletionTime.IsZero():
+				fmt.Fprintf(&sb, " (deleted %s)", v.DeletionTime.Format("2006-01-02 15:04"))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func (h *GeneralHandler) toolCheckVaultLease(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Lease string `json:"lease"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	lease := strings.TrimSpace(args.Lease)
+	if lease == "" {
+		return "Error: lease is required."
+	}
+
+	if !strings.HasSuffix(lease, "/") {
+		l, err := h.vaultClient.LookupLease(ctx, lease)
+		if err != nil {
+			return fmt.Sprintf("Error looking up Vault lease: %v", err)
+		}
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] looked up Vault lease %s", call.UserID, call.ChannelID, lease)
+		renewable := "not renewable"
+		if l.Renewable {
+			renewable = "renewable"
+		}
+		return fmt.Sprintf("Lease %s\nIssued: %s\nExpires: %s (TTL %s, %s)\n", l.ID,
+			l.IssueTime.Format(time.RFC3339), l.ExpireTime.Format(time.RFC3339), l.TTL, renewable)
+	}
+
+	ids, err := h.vaultClient.ListLeases(ctx, lease)
+	if err != nil {
+		return fmt.Sprintf("Error listing Vault leases (listing needs sudo on sys/leases/lookup): %v", err)
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] listed Vault leases under %s: %d", call.UserID, call.ChannelID, lease, len(ids))
+	if len(ids) == 0 {
+		return fmt.Sprintf("No leases under %s.", lease)
+	}
+	summary := fmt.Sprintf("%d lease(s) under %s.", len(ids), lease)
+	if len(ids) > maxVaultLeases {
+		summary += fmt.Sprintf(" Checked the first %d.", maxVaultLeases)
+		ids = ids[:maxVaultLeases]
+	}
+	type row struct {
+		id     string
+		expire time.Time
+		ttl    time.Duration
+		renew  bool
+		err    error
+	}
+	rows := make([]row, 0, len(ids))
+	for _, id := range ids {
+		if strings.HasSuffix(id, "/") {
+			continue // a deeper prefix; list it separately
+		}
+		l, err := h.vaultClient.LookupLease(ctx, lease+id)
+		if err != nil {
+			rows = append(rows, row{id: id, err: err})
+			continue
+		}
+		rows = append(rows, row{id: id, expire: l.ExpireTime, ttl: l.TTL, renew: l.Renewable})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].ttl < rows[j].ttl })
+
+	table := &Table{Name: "vault-leases", Columns: []string{"Lease", "Expires", "TTL", "Renewable"}, Inline: 4}
+	for _, r := range rows {
+		if r.err != nil {
+			table.Rows = append(table.Rows, []string{r.id, "lookup failed: " + r.err.Error(), "", ""})
+			continue
+		}
+		table.Rows = append(table.Rows, []string{r.id, r.expire.Format("2006-01-02 15:04 MST"), r.ttl.String(), fmt.Sprint(r.renew)})
+	}
+	return h.presentTable(call, summary, table)
+}
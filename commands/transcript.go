@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxTranscriptEntries bounds the entries kept per session; the oldest
+	// are dropped first.
+	maxTranscriptEntries = 200
+	// maxTranscriptRunes bounds the text kept per entry.
+	maxTranscriptRunes = 4000
+	// maxRecentSessions is how many ended sessions are kept for the session
+	// browser.
+	maxRecentSessions = 50
+)
+
+// Transcript entry kinds.
+const (
+	TranscriptUser = "user" // a message from the user
+	TranscriptTool = "tool" // a tool call and its result
+	TranscriptBot  = "bot"  // a reply posted by the agent
+)
+
+// TranscriptEntry is one step of a thread session, shown in the session
+// browser to explain why an agent did something.
+type TranscriptEntry struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	UserID  string    `json:"user_id,omitempty"`
+	Tool    string    `json:"tool,omitempty"`
+	Args    string    `json:"args,omitempty"`
+	Outcome string    `json:"outcome,omitempty"` // audit outcome of a tool call
+	Text    string    `json:"text"`              // message, reply or tool result
+}
+
+// SessionInfo describes an active or recently ended thread session.
+type SessionInfo struct {
+	ChannelID    string            `json:"channel_id"`
+	ThreadTS     string            `json:"thread_ts"`
+	UserID       string            `json:"user_id"`
+	AgentID      string            `json:"agent_id"`
+	Participants []string          `json:"participants,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	LastSeen     time.Time         `json:"last_seen"`
+	Active       bool              `json:"active"`
+	EndedAt      *time.Time        `json:"ended_at,omitempty"`
+	EndReason    string            `json:"end_reason,omitempty"`
+	Entries      int               `json:"entries"`
+	Transcript   []TranscriptEntry `json:"transcript,omitempty"`
+}
+
+// Record appends e to the transcript of the thread's session, or of the
+// most recent ended one, so a long run that outlives its session is still
+// captured. Threads without a session are ignored. Safe on a nil store.
+func (s *SessionStore) Record(channelID, threadTS string, e TranscriptEntry) {
+	if s == nil || threadTS == "" {
+		return
+	}
+	key := sessionKey(channelID, threadTS)
+	s.mu.RLock()
+	sess := s.sessions[key]
+	for i := len(s.recent) - 1; sess == nil && i >= 0; i-- {
+		if sessionKey(s.recent[i].ChannelID, s.recent[i].ThreadTS) == key {
+			sess = s.recent[i]
+		}
+	}
+	s.mu.RUnlock()
+	if sess == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Text = truncateRunes(e.Text, maxTranscriptRunes)
+	e.Args = truncateRunes(e.Args, maxTranscriptRunes)
+	sess.mu.Lock()
+	if e.Kind == TranscriptUser && e.UserID != "" && !slices.Contains(sess.participants, e.UserID) {
+		sess.participants = append(sess.participants, e.UserID)
+	}
+	sess.transcript = append(sess.transcript, e)
+	if n := len(sess.transcript) - maxTranscriptEntries; n > 0 {
+		sess.transcript = append(sess.transcript[:0], sess.transcript[n:]...)
+	}
+	sess.mu.Unlock()
+}
+
+// List returns the active sessions, most recently used first, followed by
+// the recently ended ones, newest first. Transcripts are left out.
+func (s *SessionStore) List() []SessionInfo {
+	s.mu.RLock()
+	active := make([]*ThreadSession, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		active = append(active, sess)
+	}
+	recent := append([]*ThreadSession(nil), s.recent...)
+	s.mu.RUnlock()
+
+	out := make([]SessionInfo, 0, len(active)+len(recent))
+	for _, sess := range active {
+		out = append(out, sess.info(false))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeen.After(out[j].LastSeen) })
+	for i := len(recent) - 1; i >= 0; i-- {
+		out = append(out, recent[i].info(false))
+	}
+	return out
+}
+
+// Get returns the thread's active session, or its most recent ended one,
+// with the transcript.
+func (s *SessionStore) Get(channelID, threadTS string) (SessionInfo, bool) {
+	key := sessionKey(channelID, threadTS)
+	s.mu.RLock()
+	sess := s.sessions[key]
+	for i := len(s.recent) - 1; sess == nil && i >= 0; i-- {
+		if sessionKey(s.recent[i].ChannelID, s.recent[i].ThreadTS) == key {
+			sess = s.recent[i]
+		}
+	}
+	s.mu.RUnlock()
+	if sess == nil {
+		return SessionInfo{}, false
+	}
+	return sess.info(true), true
+}
+
+// retireLocked keeps an ended session for the session browser. The caller
+// holds s.mu.
+func (s *SessionStore) retireLocked(sess *ThreadSession, reason string) {
+	sess.mu.Lock()
+	sess.endedAt = time.Now()
+	sess.endReason = reason
+	sess.mu.Unlock()
+	s.recent = append(s.recent, sess)
+	if n := len(s.recent) - maxRecentSessions; n > 0 {
+		s.recent = append(s.recent[:0], s.recent[n:]...)
+	}
+}
+
+// info snapshots the session, with its transcript when withTranscript.
+func (sess *ThreadSession) info(withTranscript bool) SessionInfo {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	info := SessionInfo{
+		ChannelID:    sess.ChannelID,
+		ThreadTS:     sess.ThreadTS,
+		UserID:       sess.UserID,
+		AgentID:      sess.AgentID,
+		Participants: append([]string(nil), sess.participants...),
+		CreatedAt:    sess.CreatedAt,
+		LastSeen:     sess.LastSeen,
+		Active:       sess.endedAt.IsZero(),
+		EndReason:    sess.endReason,
+		Entries:      len(sess.transcript),
+	}
+	if !info.Active {
+		ended := sess.endedAt
+		info.EndedAt = &ended
+	}
+	if withTranscript {
+		info.Transcript = append([]TranscriptEntry{}, sess.transcript...)
+	}
+	return info
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut.
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return strings.TrimRight(string(r[:n]), " \n") + "…"
+	}
+	return s
+}
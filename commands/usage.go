@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/github"
+)
+
+// ModelPrice is the USD cost per one million prompt/completion tokens.
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// defaultModelPrices covers common models; override or extend with LLM_PRICES.
+// Keys match the model/deployment name, with any "provider/" prefix removed.
+var defaultModelPrices = map[string]ModelPrice{
+	"gpt-4o":            {Prompt: 2.50, Completion: 10.00},
+	"gpt-4o-mini":       {Prompt: 0.15, Completion: 0.60},
+	"gpt-4.1":           {Prompt: 2.00, Completion: 8.00},
+	"gpt-4.1-mini":      {Prompt: 0.40, Completion: 1.60},
+	"gpt-5":             {Prompt: 1.25, Completion: 10.00},
+	"gpt-5-mini":        {Prompt: 0.25, Completion: 2.00},
+	"claude-sonnet-4-5": {Prompt: 3.00, Completion: 15.00},
+	"claude-haiku-4-5":  {Prompt: 1.00, Completion: 5.00},
+}
+
+// ParseModelPrices parses "model=prompt:completion,..." (USD per 1M tokens)
+// and merges it over the built-in price table.
+func ParseModelPrices(spec string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(defaultModelPrices))
+	for k, v := range defaultModelPrices {
+		prices[k] = v
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, rates, ok := strings.Cut(entry, "=")
+		in, out, ok2 := strings.Cut(rates, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid price entry %q: expected model=prompt:completion", entry)
+		}
+		p, err1 := strconv.ParseFloat(in, 64)
+		c, err2 := strconv.ParseFloat(out, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid price entry %q: prices must be numbers", entry)
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{Prompt: p, Completion: c}
+	}
+	return prices, nil
+}
+
+// UsageTotals aggregates token usage and estimated cost.
+type UsageTotals struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+func (t *UsageTotals) add(u github.Usage, cost float64) {
+	t.Requests++
+	t.PromptTokens += u.PromptTokens
+	t.CompletionTokens += u.CompletionTokens
+	t.CostUSD += cost
+}
+
+// UsageReport is the usage for one calendar month, broken down by dimension.
+type UsageReport struct {
+	Month     string                  `json:"month"` // YYYY-MM (UTC)
+	Total     UsageTotals             `json:"total"`
+	ByUser    map[string]*UsageTotals `json:"by_user"`
+	ByChannel map[string]*UsageTotals `json:"by_channel"`
+	ByAgent   map[string]*UsageTotals `json:"by_agent"`
+	ByModel   map[string]*UsageTotals `json:"by_model"`
+}
+
+func newUsageReport(month string) *UsageReport {
+	return &UsageReport{
+		Month:     month,
+		ByUser:    make(map[string]*UsageTotals),
+		ByChannel: make(map[string]*UsageTotals),
+		ByAgent:   make(map[string]*UsageTotals),
+		ByModel:   make(map[string]*UsageTotals),
+	}
+}
+
+// UsageTracker aggregates LLM token usage and cost per month, user, channel,
+// agent, and model. It implements github.UsageRecorder. Safe for concurrent use.
+type UsageTracker struct {
+	mu     sync.RWMutex
+	prices map[string]ModelPrice
+	months map[string]*UsageReport
+}
+
+// NewUsageTracker creates an empty tracker using the given price table.
+func NewUsageTracker(prices map[string]ModelPrice) *UsageTracker {
+	return &UsageTracker{prices: prices, months: make(map[string]*UsageReport)}
+}
+
+// RecordUsage implements github.UsageRecorder.
+func (t *UsageTracker) RecordUsage(a github.Attribution, model string, u github.Usage) {
+	cost := t.cost(model, u)
+	month := time.Now().UTC().Format("2006-01")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r, ok := t.months[month]
+	if !ok {
+		r = newUsageReport(month)
+		t.months[month] = r
+	}
+	r.Total.add(u, cost)
+	addUsage(r.ByUser, orUnknown(a.UserID), u, cost)
+	addUsage(r.ByChannel, orUnknown(a.ChannelID), u, cost)
+	addUsage(r.ByAgent, orUnknown(a.AgentID), u, cost)
+	addUsage(r.ByModel, model, u, cost)
+}
+
+func addUsage(m map[string]*UsageTotals, key string, u github.Usage, cost float64) {
+	t, ok := m[key]
+	if !ok {
+		t = &UsageTotals{}
+		m[key] = t
+	}
+	t.add(u, cost)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// cost returns the estimated USD cost of u for model (0 for unpriced models).
+func (t *UsageTracker) cost(model string, u github.Usage) float64 {
+	p, ok := t.prices[model]
+	if !ok {
+		if i := strings.LastIndex(model, "/"); i >= 0 {
+			p, ok = t.prices[model[i+1:]]
+		}
+	}
+	if !ok {
+		return 0
+	}
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
+
+// Report returns a copy of the usage for month (YYYY-MM), or an empty report.
+func (t *UsageTracker) Report(month string) UsageReport {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	r, ok := t.months[month]
+	if !ok {
+		return *newUsageReport(month)
+	}
+	cp := *newUsageReport(month)
+	cp.Total = r.Total
+	copyTotals(cp.ByUser, r.ByUser)
+	copyTotals(cp.ByChannel, r.ByChannel)
+	copyTotals(cp.ByAgent, r.ByAgent)
+	copyTotals(cp.ByModel, r.ByModel)
+	return cp
+}
+
+func copyTotals(dst, src map[string]*UsageTotals) {
+	for k, v := range src {
+		totals := *v
+		dst[k] = &totals
+	}
+}
+
+// FormatUsageReport renders a month's usage as a Slack message.
+func FormatUsageReport(r UsageReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ":bar_chart: *LLM usage for %s*\n", r.Month)
+	fmt.Fprintf(&sb, "Total: %d requests, %d prompt + %d completion tokens, *$%.2f*\n",
+		r.Total.Requests, r.Total.PromptTokens, r.Total.CompletionTokens, r.Total.CostUSD)
+	// Slack mentions don't render in code blocks, so user and channel
+	// sections stay lists; the rest are aligned tables.
+	section := func(title string, m map[string]*UsageTotals, format func(string) string) {
+		if len(m) == 0 {
+			return
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return m[keys[i]].CostUSD > m[keys[j]].CostUSD })
+		if len(keys) > 10 {
+			keys = keys[:10]
+		}
+		fmt.Fprintf(&sb, "\n*%s*\n", title)
+		if format == nil {
+			t := &Table{Columns: []string{"Name", "Cost", "Requests", "Tokens"}}
+			for _, k := range keys {
+				u := m[k]
+				t.Rows = append(t.Rows, []string{k, fmt.Sprintf("$%.2f", u.CostUSD), strconv.Itoa(u.Requests), strconv.Itoa(u.PromptTokens + u.CompletionTokens)})
+			}
+			sb.WriteString(t.Render(0) + "\n")
+			return
+		}
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "  • %s — $%.2f (%d requests)\n", format(k), m[k].CostUSD, m[k].Requests)
+		}
+	}
+	section("By agent", r.ByAgent, nil)
+	section("By model", r.ByModel, nil)
+	section("Top users", r.ByUser, func(k string) string {
+		if k == "unknown" {
+			return k
+		}
+		return "<@" + k + ">"
+	})
+	section("Top channels", r.ByChannel, func(k string) string {
+		if k == "unknown" {
+			return k
+		}
+		return "<#" + k + ">"
+	})
+	return sb.String()
+}
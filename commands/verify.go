@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+)
+
+// maxVerifiedReferences bounds the existence checks made for one answer.
+const maxVerifiedReferences = 20
+
+var (
+	// referenceRe matches, leftmost first, a Slack link (<url|label>), a
+	// bare URL, or a Jira-style issue key.
+	referenceRe = regexp.MustCompile(`<(https?://[^>|\s]+)(?:\|([^>]*))?>|https?://[^\s<>|)\]]+|\b[A-Z][A-Z0-9_]+-\d+\b`)
+	// jiraBrowseRe extracts the issue key from a Jira browse link.
+	jiraBrowseRe = regexp.MustCompile(`/browse/([A-Z][A-Z0-9_]+-\d+)`)
+)
+
+// verifyReferences checks that the GitHub links and Jira issue keys in an
+// answer exist before it is posted. References that already appeared in
+// the conversation (the request, context or a tool result) are trusted;
+// the rest are looked up, and the ones that don't exist are struck through
+// with a note so nobody chases a made-up PR or ticket. References that
+// can't be checked (API errors, projects the bot can't see) are left as
+// they are.
+func (h *GeneralHandler) verifyReferences(ctx context.Context, userID, channelID, content string, messages []github.ChatMessage) string {
+	var seen strings.Builder
+	for _, m := range messages {
+		seen.WriteString(m.Content)
+		for _, tc := range m.ToolCalls {
+			seen.WriteString(tc.Function.Arguments)
+		}
+	}
+	known := seen.String()
+	projects := h.jiraProjectKeys()
+
+	// fabricated holds the verdict per reference: true when it doesn't exist.
+	fabricated := make(map[string]bool)
+	checks := 0
+	check := func(ref, kind string, exists func() (bool, error)) bool {
+		if v, ok := fabricated[ref]; ok {
+			return v
+		}
+		if strings.Contains(known, ref) || checks == maxVerifiedReferences {
+			return false
+		}
+		checks++
+		ok, err := exists()
+		if err != nil {
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] could not verify %s: %v", userID, channelID, ref, err)
+		}
+		fabricated[ref] = err == nil && !ok
+		if fabricated[ref] {
+			logging.Ctx(ctx).Infof("[user=%s channel=%s] answer references nonexistent %s %s", userID, channelID, kind, ref)
+			metrics.FabricatedReferences.Inc(h.agentID, kind)
+		}
+		return fabricated[ref]
+	}
+	issueMissing := func(key string) bool {
+		project, _, _ := strings.Cut(key, "-")
+		if h.jiraClient == nil || !projects[project] {
+			return false
+		}
+		return check(key, "jira", func() (bool, error) { return h.jiraClient.IssueExists(key) })
+	}
+	urlMissing := func(u string) bool {
+		u = strings.TrimRight(u, ".,*_")
+		if m := jiraBrowseRe.FindStringSubmatch(u); m != nil {
+			return issueMissing(m[1])
+		}
+		if h.ghClient == nil || !strings.HasPrefix(u, "https://github.com/") {
+			return false
+		}
+		return check(u, "github", func() (bool, error) { return h.ghClient.ReferenceExists(ctx, u) })
+	}
+
+	struck := false
+	out := referenceRe.ReplaceAllStringFunc(content, func(s string) string {
+		switch {
+		case strings.HasPrefix(s, "<"):
+			m := referenceRe.FindStringSubmatch(s)
+			if !urlMissing(m[1]) {
+				return s
+			}
+			label := m[2]
+			if label == "" {
+				label = m[1]
+			}
+			struck = true
+			return "~" + label + "~"
+		case strings.HasPrefix(s, "http"):
+			if !urlMissing(s) {
+				return s
+			}
+		default:
+			if !issueMissing(s) {
+				return s
+			}
+		}
+		struck = true
+		return "~" + s + "~"
+	})
+	if !struck {
+		return content
+	}
+	return out + "\n\n:warning: _The struck-through references above don't exist and may have been made up; please don't rely on them._"
+}
+
+// jiraProjectKeys returns the keys of the Jira projects the bot can see, so
+// that strings like "UTF-8" aren't mistaken for issue keys.
+func (h *GeneralHandler) jiraProjectKeys() map[string]bool {
+	keys := make(map[string]bool)
+	if h.jiraClient == nil {
+		return keys
+	}
+	projects, err := h.jiraClient.ListProjects()
+	if err != nil {
+		return keys
+	}
+	for _, p := range projects {
+		key, _, _ := strings.Cut(p, " ")
+		keys[key] = true
+	}
+	return keys
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// workspaceTools map service names to the repository and directory that hold them.
+var workspaceTools = []*ToolDef{
+	{
+		Name:        "resolve_project",
+		Description: "Find where a service or package lives: its repository and subdirectory. Matches repository names and, in monorepos, workspace members from go.work, pnpm-workspace.yaml, lerna.json, and package.json workspaces, plus git submodules. Call this BEFORE reading or editing code for a named service, so you edit the right copy — code inside a submodule must be changed in the submodule's own repository.",
+		Parameters: json.RawMessage(`{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","description":"Service, package, or project name (e.g. 'billing-api', 'web')"},
+				"repo":{"type":"string","description":"Monorepo to search (without owner). Optional — if omitted, repository names are matched and the best-matching repositories are scanned."},
+				"branch":{"type":"string","description":"Branch to read manifests from (optional, uses the default branch if empty)"}
+			},
+			"required":["name"]
+		}`),
+		RepoAccess: "read",
+		Available:  (*GeneralHandler).githubConfigured,
+		Run:        (*GeneralHandler).toolResolveProject,
+	},
+}
+
+// resolveScanRepos is how many matching repositories resolve_project scans
+// for workspace members when no repo is given.
+const resolveScanRepos = 3
+
+// projectMatch is a resolve_project candidate.
+type projectMatch struct {
+	repo    string // owner/name
+	dir     string // "" for the repository root
+	source  string
+	sub     *github.Submodule
+	score   int
+	display string
+}
+
+func (h *GeneralHandler) toolResolveProject(ctx context.Context, call ToolCall) string {
+	var args struct {
+		Name   string `json:"name"`
+		Repo   string `json:"repo"`
+		Branch string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(call.Args), &args); err != nil {
+		return fmt.Sprintf("Error parsing arguments: %v", err)
+	}
+	want := normalizeProjectName(args.Name)
+	if want == "" {
+		return "Error: name is required."
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error resolving owner: %v", err)
+	}
+
+	var matches []projectMatch
+	scan := []string{args.Repo}
+	if args.Repo == "" {
+		repos, err := h.ghClient.ListOrgRepos(ctx, owner)
+		if err != nil {
+			return fmt.Sprintf("Error listing repositories: %v. Pass the monorepo name in repo.", err)
+		}
+		scan = nil
+		for _, full := range h.filterReadableRepos(repos) {
+			name := full[strings.LastIndex(full, "/")+1:]
+			if score := projectScore(want, name, ""); score > 0 {
+				matches = append(matches, projectMatch{repo: full, source: "repository name", score: score + 1})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+		for _, m := range matches[:min(len(matches), resolveScanRepos)] {
+			scan = append(scan, m.repo[strings.LastIndex(m.repo, "/")+1:])
+		}
+	}
+
+	for _, repo := range scan {
+		projects, err := h.ghClient.DetectWorkspace(ctx, owner, repo, args.Branch)
+		if err != nil {
+			logging.Ctx(ctx).Errorf("[user=%s channel=%s] resolve_project: workspace scan of %s/%s failed: %v", call.UserID, call.ChannelID, owner, repo, err)
+			continue
+		}
+		for _, p := range projects {
+			if score := projectScore(want, p.Name, p.Dir); score > 0 {
+				matches = append(matches, projectMatch{repo: owner + "/" + repo, dir: p.Dir, source: p.Source, sub: p.Submodule, score: score})
+			}
+		}
+	}
+	logging.Ctx(ctx).Infof("[user=%s channel=%s] resolve_project %q: %d candidate(s)", call.UserID, call.ChannelID, args.Name, len(matches))
+	if len(matches) == 0 {
+		if args.Repo == "" {
+			return fmt.Sprintf("No repository or workspace project matching %q was found. If it lives in a monorepo, call resolve_project again with that repo.", args.Name)
+		}
+		return fmt.Sprintf("No workspace project or submodule matching %q was found in %s/%s. Try search_files or list_directory to locate it.", args.Name, owner, args.Repo)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Locations for %q (best match first):\n", args.Name)
+	for _, m := range matches[:min(len(matches), 5)] {
+		switch {
+		case m.sub != nil && m.sub.Repo != "":
+			fmt.Fprintf(&sb, "- %s: %s is a git submodule of %s. Edit it in repository %s (root), not in %s.\n", m.dir, m.dir, m.repo, m.sub.Repo, m.repo)
+		case m.sub != nil:
+			fmt.Fprintf(&sb, "- %s: %s is a git submodule of %s pointing to %s, outside GitHub; it cannot be edited here.\n", m.dir, m.dir, m.repo, m.sub.URL)
+		case m.dir == "":
+			fmt.Fprintf(&sb, "- repository %s (root, matched by %s)\n", m.repo, m.source)
+		default:
+			fmt.Fprintf(&sb, "- repository %s, directory %s/ (from %s)\n", m.repo, m.dir, m.source)
+		}
+	}
+	sb.WriteString("Use the repository and directory above for reads and edits; prefix file paths with the directory.")
+	return sb.String()
+}
+
+// projectScore rates how well a project name or directory matches want
+// (already normalized): 3 exact name, 2 name contains, 1 directory contains.
+func projectScore(want, name, dir string) int {
+	n := normalizeProjectName(name)
+	switch {
+	case n == want:
+		return 3
+	case n != "" && (strings.Contains(n, want) || strings.Contains(want, n)):
+		return 2
+	case dir != "" && strings.Contains(normalizeProjectName(dir), want):
+		return 1
+	}
+	return 0
+}
+
+// normalizeProjectName lower-cases s and drops punctuation, so "billing-api",
+// "Billing API" and "@org/billing_api" compare equal.
+func normalizeProjectName(s string) string {
+	if i := strings.LastIndex(s, "/"); i >= 0 && strings.HasPrefix(s, "@") {
+		s = s[i+1:]
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// checkSubmodulePaths refuses repository writes to paths inside a git
+// submodule, which would edit a pinned copy instead of the real source.
+// Returns a non-empty error message when the call must be refused.
+func (h *GeneralHandler) checkSubmodulePaths(ctx context.Context, userID, channelID string, def *ToolDef, argsJSON string) string {
+	if def.Class != ToolWrite || def.RepoAccess != "write" || h.ghClient == nil {
+		return ""
+	}
+	paths := toolPaths(argsJSON)
+	if len(paths) == 0 {
+		return ""
+	}
+	var args struct {
+		Repo   string `json:"repo"`
+		Branch string `json:"branch"`
+	}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil || args.Repo == "" {
+		return ""
+	}
+	owner, err := h.ghClient.ResolveOwner(ctx)
+	if err != nil {
+		return ""
+	}
+	subs, err := h.ghClient.GetSubmodules(ctx, owner, args.Repo, args.Branch)
+	if err != nil || len(subs) == 0 {
+		return ""
+	}
+	for _, p := range paths {
+		sub := github.SubmoduleFor(subs, p)
+		if sub == nil {
+			continue
+		}
+		logging.Ctx(ctx).Infof("[user=%s channel=%s] blocked %s on %s in %s/%s: inside submodule %s", userID, channelID, def.Name, p, owner, args.Repo, sub.Path)
+		if sub.Repo != "" {
+			return fmt.Sprintf("Error: %s is inside the git submodule %s, which points to repository %s. Make the change in %s (path relative to its root) instead of %s.", p, sub.Path, sub.Repo, sub.Repo, args.Repo)
+		}
+		return fmt.Sprintf("Error: %s is inside the git submodule %s (%s), which cannot be edited from %s.", p, sub.Path, sub.URL, args.Repo)
+	}
+	return ""
+}
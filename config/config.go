@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,36 +12,188 @@ const (
 	defaultPort             = "8080"
 	defaultModel            = "openai/gpt-4o"
 	defaultAzureModel       = "gpt-4o"
+	defaultOpenAIModel      = "gpt-4o"
+	defaultAnthropicModel   = "claude-sonnet-4-5"
+	defaultOllamaModel      = "llama3.1"
 	defaultThreadSessionTTL = 3 * time.Minute
+	defaultJiraMetadataTTL  = time.Hour
+	defaultCVEWatchInterval = time.Hour
+	defaultFAQMinRepeats    = 3
+	defaultAuditRetention   = 90
 	defaultMaxToolRounds    = 50
+	defaultGitLabURL        = "https://gitlab.com"
+	defaultAgentsGitBranch  = "main"
+	defaultAgentsGitPath    = "agents"
+	defaultAgentsGitSync    = 5 * time.Minute
 )
 
 type Config struct {
-	SlackBotToken      string
-	SlackSigningSecret string
-	GitHubToken        string
-	GeneralModel       string // Default model/deployment for general queries.
-	CodeModel          string // Separate model/deployment for code-generation tasks (PRs, modify_file).
-	AzureEndpoint      string
-	AzureAPIKey        string
-	Port               string
-	UIAllowedCIDRs     string
-	JiraURL            string
-	JiraEmail          string
-	JiraAPIToken       string
-	JiraProject        string
-	JiraClientID       string
-	JiraClientSecret   string
-	AppURL             string
-	SlackAppToken      string
-	ThreadSessionTTL   time.Duration
-	MaxToolRounds      int
-	NVDAPIKey          string
+	SlackBotToken         string
+	SlackSigningSecret    string
+	AgentSigningSecrets   map[string]string // SLACK_SIGNING_SECRET_<AGENT> suffix → signing secret of that agent's Slack app.
+	GitHubToken           string
+	SCMProvider           string // "github" (default), "gitlab" or "bitbucket"; backs the repository, pull request and pipeline tools.
+	GitLabURL             string
+	GitLabToken           string
+	GitLabGroup           string // Group whose projects the agent works on, e.g. "acme".
+	BitbucketWorkspace    string // Workspace whose repositories the agent works on.
+	BitbucketUsername     string // Set to use basic auth with an API token or app password; empty for access tokens.
+	BitbucketToken        string
+	GeneralModel          string // Default model/deployment for general queries.
+	CodeModel             string // Separate model/deployment for code-generation tasks (PRs, modify_file).
+	AzureEndpoint         string
+	AzureAPIKey           string
+	AzureAuthMode         string // "api-key" (default) or "entra" for Entra ID token auth.
+	LLMProviderName       string // Explicit LLM provider: github, azure, openai, anthropic.
+	OpenAIAPIKey          string
+	OpenAIBaseURL         string
+	AnthropicAPIKey       string
+	AnthropicBaseURL      string
+	OllamaEndpoint        string
+	OllamaAPIKey          string
+	Port                  string
+	UIAllowedCIDRs        string
+	TrustedProxyCIDRs     string // Comma-separated proxies whose ClientIPHeader is honored.
+	ClientIPHeader        string // Header carrying the client address (default: X-Forwarded-For).
+	TLSCertFile           string // Serve HTTPS with this certificate; requires TLSKeyFile.
+	TLSKeyFile            string
+	TLSClientCAFile       string // Require client certificates signed by this CA (mTLS).
+	LogLevel              string // debug, info (default), warn or error.
+	LogFormat             string // text (default) or json.
+	JiraURL               string
+	JiraEmail             string
+	JiraAPIToken          string
+	JiraProject           string
+	JiraClientID          string
+	JiraClientSecret      string
+	ADOOrgURL             string // Azure DevOps organization URL; with ADOPAT enables the work item tools.
+	ADOPAT                string
+	ADOProject            string
+	NotionToken           string // Notion internal integration token; enables the Notion tools.
+	NotionPostmortemDB    string // Notion database that publish_postmortem adds postmortems to.
+	ConfluenceSpace       string // Confluence space key for postmortems; uses the Jira site and API token.
+	ConfluenceParentPage  string // Page ID postmortems are created under; empty = space root.
+	PagerDutyToken        string // PagerDuty REST API key; enables the incident tools.
+	PagerDutyFromEmail    string // PagerDuty user email that acknowledgements and resolutions are attributed to.
+	OpsgenieAPIKey        string // Opsgenie API key; on-call lookups use Opsgenie instead of PagerDuty schedules.
+	OpsgenieAPIURL        string // Opsgenie API base URL; empty = US instance.
+	DirectoryProvider     string // "okta" or "azuread"; enables lookup_person.
+	OktaOrgURL            string
+	OktaAPIToken          string
+	MetricsProvider       string // "datadog" or "prometheus"; enables query_metrics.
+	DatadogSite           string
+	DatadogAPIKey         string
+	DatadogAppKey         string
+	PrometheusURL         string // Prometheus-compatible API base URL (or a Grafana data source proxy URL).
+	PrometheusToken       string // Optional bearer token, e.g. a Grafana service account token.
+	VaultAddr             string
+	VaultToken            string // Read-only token; needs no access to secret data.
+	VaultNamespace        string
+	VaultKVMount          string
+	ArgoCDURL             string // Argo CD server URL; with ArgoCDToken enables the Argo CD tools.
+	ArgoCDToken           string
+	AWSTools              bool // Enables the read-only AWS tools (ECS, CloudWatch, Lambda).
+	AWSRegion             string
+	AWSToolsRoleARN       string // Optional role the AWS tools assume, e.g. a dedicated read-only role.
+	GCPProject            string // Enables the read-only GCP tools (Cloud Run, GKE, Error Reporting).
+	GCPRegion             string // Default region for Cloud Run and GKE lookups.
+	AWSCostExplorer       bool   // Enables AWS costs in query_cloud_costs (Cost Explorer bills per request).
+	GCPBillingTable       string // BigQuery billing export table; enables GCP costs in query_cloud_costs.
+	CloudflareAPIToken    string // Enables the Cloudflare zone, WAF event and cache purge tools.
+	TerraformToken        string // Enables the Terraform Cloud / Enterprise run and plan tools.
+	TerraformAddress      string // Terraform Enterprise URL; empty means Terraform Cloud.
+	TerraformOrganization string // Default organization for workspace lookups.
+	HarborURL             string // Enables image scan lookups in Harbor (Trivy scanner).
+	HarborUsername        string // Robot account, e.g. "robot$ovad".
+	HarborPassword        string
+	AppURL                string
+	SlackAppToken         string
+	TeamsAppID            string // Microsoft App ID of the Teams bot; enables the /teams/messages endpoint.
+	TeamsAppPassword      string
+	TeamsTenantID         string // Tenant of a single-tenant bot; also restricts which tenant may message it.
+	TeamsServiceURL       string // Bot Framework service URL for conversations not yet seen; empty = global Teams endpoint.
+	DiscordBotToken       string // Enables the Discord gateway listener.
+	DiscordGuildID        string // Server to register slash commands in (immediate); empty = global commands.
+	ThreadSessionTTL      time.Duration
+	ThreadSessionFile     string // JSON file persisting active thread sessions across restarts; empty = in memory.
+	ThreadSummaryMinSteps int    // Expired sessions with at least this many transcript steps get a recap; 0 = disabled.
+	ThreadHistoryMaxTurns int    // Thread histories longer than this fold older turns into a summary; 0 = disabled.
+	MaxToolRounds         int
+	LLMMaxRetries         int // Retries for transient LLM failures (429/5xx); -1 = client default.
+	ReasoningEffort       string
+	BenchCorpusFile       string            // JSONL file where general requests are recorded for /api/bench.
+	ActivityFile          string            // JSONL file persisting activity for reports; empty = in memory.
+	AuditLogFile          string            // JSONL file persisting the tool invocation audit log; empty = in memory.
+	ChangeLedgerFile      string            // JSONL file persisting the change ledger; empty = in memory.
+	AuditRetentionDays    int               // Days of audit entries kept in AuditLogFile; 0 = forever.
+	IdentityFile          string            // JSON file persisting Slack → GitHub/Jira account links; empty = in memory.
+	EmbeddingModel        string            // Embedding model for the FAQ loop; empty = disabled.
+	FAQFile               string            // JSON file persisting curated FAQ answers; empty = in memory.
+	FAQMinRepeats         int               // Times a question must recur before saving an answer is suggested.
+	ContextTokenBudget    int               // Approximate prompt token budget; 0 = default.
+	CompressThreshold     int               // Tool results above this many tokens are summarized; 0 = disabled.
+	WorkflowLogBudget     int               // Characters of failed job logs in a workflow run summary; 0 = default.
+	WorkflowLogPatterns   []string          // Extra regexes marking error lines in failed job logs.
+	SummarizerModel       string            // Cheap model used for tool-result compression (default: GeneralModel).
+	LLMPrices             string            // "model=prompt:completion,..." USD per 1M tokens, merged over built-in prices.
+	UsageReportChannel    string            // Slack channel ID that receives a monthly LLM cost summary.
+	DailyUserTokens       int               // LLM tokens per user per UTC day; 0 = unlimited.
+	DailyUserActions      int               // Tool calls per user per UTC day; 0 = unlimited.
+	DailyChannelTokens    int               // LLM tokens per channel per UTC day; 0 = unlimited.
+	DailyChannelActions   int               // Tool calls per channel per UTC day; 0 = unlimited.
+	SpendCapFile          string            // JSONL file persisting today's spend cap counters; empty = in memory.
+	DefaultAgent          string            // Agent that handles @mentions (default: first discovered agent).
+	ChannelAgents         map[string]string // Slack channel ID → agent ID, from CHANNEL_AGENTS.
+	TriggerToken          string            // Bearer token for /api/agents/{id}/trigger; empty disables it.
+	GitHubWebhookSecret   string            // Secret for /github/webhook signatures; empty disables it.
+	JiraWebhookSecret     string            // Secret for /jira/webhook signatures; empty disables it.
+	JiraGitHubSync        bool              // Mirror state between bot-created Jira tickets and linked PRs.
+	ReadOnly              bool              // Disable every write tool for all agents at startup.
+	DryRun                bool              // Write tools return a preview instead of running, for all agents.
+	SecretRedaction       bool              // Mask secrets in LLM inputs and chat output (default: true).
+	RedactPatterns        []string          // Extra regexes whose matches (or last group) are masked.
+	RedactEntropy         bool              // Also mask high-entropy tokens no pattern matches (default: true).
+	JiraMetadataTTL       time.Duration     // How long Jira project/field metadata is cached; 0 = no caching.
+	NVDAPIKey             string
+	CVEWatchFile          string        // JSON file persisting per-channel CVE watchlists; empty = in memory.
+	CVEWatchInterval      time.Duration // How often NVD is polled for watched CVEs.
+	PromptHistoryFile     string        // JSON file persisting prompt versions; empty = in memory.
+	AgentsGitURL          string        // Repository the agents directory is synced from; empty = use the local directory.
+	AgentsGitProvider     string        // "github" or "gitlab"; empty = detected from the URL.
+	AgentsGitBranch       string        // Branch synced (default: main).
+	AgentsGitToken        string        // Token with read access to the repository.
+	AgentsGitPath         string        // Directory within the repository holding the agents (default: agents).
+	AgentsGitInterval     time.Duration // How often the branch is polled; 0 = only on webhook or API trigger.
 }
 
 // UseAzure returns true when Azure OpenAI credentials are configured.
 func (c *Config) UseAzure() bool {
-	return c.AzureEndpoint != "" && c.AzureAPIKey != ""
+	return c.AzureEndpoint != "" && (c.AzureAPIKey != "" || c.AzureUseEntraID())
+}
+
+// AzureUseEntraID returns true when Azure OpenAI should authenticate with
+// Entra ID (Azure AD) tokens instead of a static API key. Credentials are
+// resolved from the standard AZURE_TENANT_ID / AZURE_CLIENT_ID /
+// AZURE_CLIENT_SECRET / AZURE_FEDERATED_TOKEN_FILE variables, falling back
+// to managed identity.
+func (c *Config) AzureUseEntraID() bool {
+	return strings.EqualFold(c.AzureAuthMode, "entra")
+}
+
+// LLMProvider returns the LLM backend to use. LLM_PROVIDER selects it
+// explicitly; otherwise a local Ollama server is used when OLLAMA_ENDPOINT is
+// set, then Azure OpenAI when configured, falling back to GitHub Models.
+func (c *Config) LLMProvider() string {
+	if c.LLMProviderName != "" {
+		return strings.ToLower(c.LLMProviderName)
+	}
+	if c.OllamaEndpoint != "" {
+		return "ollama"
+	}
+	if c.UseAzure() {
+		return "azure"
+	}
+	return "github"
 }
 
 // JiraConfigured returns true when Jira credentials are present.
@@ -57,26 +210,140 @@ func (c *Config) JiraUseOAuth() bool {
 	return c.JiraClientID != "" && c.JiraClientSecret != ""
 }
 
+// ADOConfigured returns true when Azure DevOps credentials are present.
+func (c *Config) ADOConfigured() bool {
+	return c.ADOOrgURL != "" && c.ADOPAT != ""
+}
+
+// signingSecretPrefix starts the env vars holding per-agent Slack signing
+// secrets, e.g. SLACK_SIGNING_SECRET_AGENT_Q for agent "agent-q".
+const signingSecretPrefix = "SLACK_SIGNING_SECRET_"
+
+// AgentSecretKey is the env var suffix for agentID: upper case, with "-"
+// replaced by "_".
+func AgentSecretKey(agentID string) string {
+	return strings.ToUpper(strings.ReplaceAll(agentID, "-", "_"))
+}
+
+// SigningSecretFor returns the Slack signing secret that requests to
+// agentID's webhook must be signed with: its own secret when one is set,
+// else SLACK_SIGNING_SECRET.
+func (c *Config) SigningSecretFor(agentID string) string {
+	if s := c.AgentSigningSecrets[AgentSecretKey(agentID)]; s != "" {
+		return s
+	}
+	return c.SlackSigningSecret
+}
+
 func Load() (*Config, error) {
 	cfg := &Config{
-		SlackBotToken:      os.Getenv("SLACK_BOT_TOKEN"),
-		SlackSigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
-		GitHubToken:        os.Getenv("GITHUB_TOKEN"),
-		GeneralModel:       os.Getenv("GENERAL_MODEL"),
-		CodeModel:          os.Getenv("CODE_MODEL"),
-		AzureEndpoint:      os.Getenv("AZURE_OPEN_AI_ENDPOINT"),
-		AzureAPIKey:        os.Getenv("AZURE_API_KEY"),
-		Port:               os.Getenv("PORT"),
-		UIAllowedCIDRs:     os.Getenv("UI_ALLOWED_CIDRS"),
-		JiraURL:            os.Getenv("JIRA_URL"),
-		JiraEmail:          os.Getenv("JIRA_EMAIL"),
-		JiraAPIToken:       os.Getenv("JIRA_API_TOKEN"),
-		JiraProject:        os.Getenv("JIRA_PROJECT"),
-		JiraClientID:       os.Getenv("JIRA_CLIENT_ID"),
-		JiraClientSecret:   os.Getenv("JIRA_CLIENT_SECRET"),
-		AppURL:             os.Getenv("APP_URL"),
-		SlackAppToken:      os.Getenv("SLACK_APP_TOKEN"),
-		NVDAPIKey:          os.Getenv("NVD_API_KEY"),
+		SlackBotToken:         os.Getenv("SLACK_BOT_TOKEN"),
+		SlackSigningSecret:    os.Getenv("SLACK_SIGNING_SECRET"),
+		GitHubToken:           os.Getenv("GITHUB_TOKEN"),
+		SCMProvider:           strings.ToLower(os.Getenv("SCM_PROVIDER")),
+		GitLabURL:             os.Getenv("GITLAB_URL"),
+		GitLabToken:           os.Getenv("GITLAB_TOKEN"),
+		GitLabGroup:           os.Getenv("GITLAB_GROUP"),
+		BitbucketWorkspace:    os.Getenv("BITBUCKET_WORKSPACE"),
+		BitbucketUsername:     os.Getenv("BITBUCKET_USERNAME"),
+		BitbucketToken:        os.Getenv("BITBUCKET_TOKEN"),
+		GeneralModel:          os.Getenv("GENERAL_MODEL"),
+		CodeModel:             os.Getenv("CODE_MODEL"),
+		AzureEndpoint:         os.Getenv("AZURE_OPEN_AI_ENDPOINT"),
+		AzureAPIKey:           os.Getenv("AZURE_API_KEY"),
+		AzureAuthMode:         os.Getenv("AZURE_AUTH_MODE"),
+		LLMProviderName:       os.Getenv("LLM_PROVIDER"),
+		OpenAIAPIKey:          os.Getenv("OPENAI_API_KEY"),
+		OpenAIBaseURL:         os.Getenv("OPENAI_BASE_URL"),
+		AnthropicAPIKey:       os.Getenv("ANTHROPIC_API_KEY"),
+		AnthropicBaseURL:      os.Getenv("ANTHROPIC_BASE_URL"),
+		OllamaEndpoint:        os.Getenv("OLLAMA_ENDPOINT"),
+		OllamaAPIKey:          os.Getenv("OLLAMA_API_KEY"),
+		ReasoningEffort:       os.Getenv("REASONING_EFFORT"),
+		BenchCorpusFile:       os.Getenv("BENCH_CORPUS_FILE"),
+		ActivityFile:          os.Getenv("ACTIVITY_FILE"),
+		AuditLogFile:          os.Getenv("AUDIT_LOG_FILE"),
+		ChangeLedgerFile:      os.Getenv("CHANGE_LEDGER_FILE"),
+		SpendCapFile:          os.Getenv("SPEND_CAP_FILE"),
+		IdentityFile:          os.Getenv("IDENTITY_FILE"),
+		EmbeddingModel:        os.Getenv("EMBEDDING_MODEL"),
+		FAQFile:               os.Getenv("FAQ_FILE"),
+		SummarizerModel:       os.Getenv("SUMMARIZER_MODEL"),
+		LLMPrices:             os.Getenv("LLM_PRICES"),
+		UsageReportChannel:    os.Getenv("USAGE_REPORT_CHANNEL"),
+		DefaultAgent:          os.Getenv("DEFAULT_AGENT"),
+		TriggerToken:          os.Getenv("TRIGGER_TOKEN"),
+		GitHubWebhookSecret:   os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		JiraWebhookSecret:     os.Getenv("JIRA_WEBHOOK_SECRET"),
+		Port:                  os.Getenv("PORT"),
+		UIAllowedCIDRs:        os.Getenv("UI_ALLOWED_CIDRS"),
+		TrustedProxyCIDRs:     os.Getenv("TRUSTED_PROXY_CIDRS"),
+		ClientIPHeader:        os.Getenv("CLIENT_IP_HEADER"),
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+		TLSClientCAFile:       os.Getenv("TLS_CLIENT_CA_FILE"),
+		LogLevel:              os.Getenv("LOG_LEVEL"),
+		LogFormat:             os.Getenv("LOG_FORMAT"),
+		JiraURL:               os.Getenv("JIRA_URL"),
+		JiraEmail:             os.Getenv("JIRA_EMAIL"),
+		JiraAPIToken:          os.Getenv("JIRA_API_TOKEN"),
+		JiraProject:           os.Getenv("JIRA_PROJECT"),
+		JiraClientID:          os.Getenv("JIRA_CLIENT_ID"),
+		JiraClientSecret:      os.Getenv("JIRA_CLIENT_SECRET"),
+		ADOOrgURL:             os.Getenv("ADO_ORG_URL"),
+		ADOPAT:                os.Getenv("ADO_PAT"),
+		ADOProject:            os.Getenv("ADO_PROJECT"),
+		NotionToken:           os.Getenv("NOTION_TOKEN"),
+		NotionPostmortemDB:    os.Getenv("NOTION_POSTMORTEM_DATABASE"),
+		ConfluenceSpace:       os.Getenv("CONFLUENCE_SPACE"),
+		ConfluenceParentPage:  os.Getenv("CONFLUENCE_PARENT_PAGE_ID"),
+		PagerDutyToken:        os.Getenv("PAGERDUTY_API_TOKEN"),
+		PagerDutyFromEmail:    os.Getenv("PAGERDUTY_FROM_EMAIL"),
+		OpsgenieAPIKey:        os.Getenv("OPSGENIE_API_KEY"),
+		OpsgenieAPIURL:        os.Getenv("OPSGENIE_API_URL"),
+		DirectoryProvider:     strings.ToLower(os.Getenv("DIRECTORY_PROVIDER")),
+		OktaOrgURL:            os.Getenv("OKTA_ORG_URL"),
+		OktaAPIToken:          os.Getenv("OKTA_API_TOKEN"),
+		MetricsProvider:       strings.ToLower(os.Getenv("METRICS_PROVIDER")),
+		DatadogSite:           os.Getenv("DATADOG_SITE"),
+		DatadogAPIKey:         os.Getenv("DATADOG_API_KEY"),
+		DatadogAppKey:         os.Getenv("DATADOG_APP_KEY"),
+		PrometheusURL:         os.Getenv("PROMETHEUS_URL"),
+		PrometheusToken:       os.Getenv("PROMETHEUS_TOKEN"),
+		VaultAddr:             os.Getenv("VAULT_ADDR"),
+		VaultToken:            os.Getenv("VAULT_TOKEN"),
+		VaultNamespace:        os.Getenv("VAULT_NAMESPACE"),
+		VaultKVMount:          os.Getenv("VAULT_KV_MOUNT"),
+		ArgoCDURL:             os.Getenv("ARGOCD_URL"),
+		ArgoCDToken:           os.Getenv("ARGOCD_TOKEN"),
+		AWSRegion:             os.Getenv("AWS_REGION"),
+		AWSToolsRoleARN:       os.Getenv("AWS_TOOLS_ROLE_ARN"),
+		GCPProject:            os.Getenv("GCP_PROJECT"),
+		GCPRegion:             os.Getenv("GCP_REGION"),
+		GCPBillingTable:       os.Getenv("GCP_BILLING_TABLE"),
+		CloudflareAPIToken:    os.Getenv("CLOUDFLARE_API_TOKEN"),
+		TerraformToken:        os.Getenv("TFE_TOKEN"),
+		TerraformAddress:      os.Getenv("TFE_ADDRESS"),
+		TerraformOrganization: os.Getenv("TFE_ORGANIZATION"),
+		HarborURL:             os.Getenv("HARBOR_URL"),
+		HarborUsername:        os.Getenv("HARBOR_USERNAME"),
+		HarborPassword:        os.Getenv("HARBOR_PASSWORD"),
+		AppURL:                os.Getenv("APP_URL"),
+		SlackAppToken:         os.Getenv("SLACK_APP_TOKEN"),
+		TeamsAppID:            os.Getenv("TEAMS_APP_ID"),
+		TeamsAppPassword:      os.Getenv("TEAMS_APP_PASSWORD"),
+		TeamsTenantID:         os.Getenv("TEAMS_TENANT_ID"),
+		TeamsServiceURL:       os.Getenv("TEAMS_SERVICE_URL"),
+		DiscordBotToken:       os.Getenv("DISCORD_BOT_TOKEN"),
+		DiscordGuildID:        os.Getenv("DISCORD_GUILD_ID"),
+		NVDAPIKey:             os.Getenv("NVD_API_KEY"),
+		CVEWatchFile:          os.Getenv("CVE_WATCH_FILE"),
+		PromptHistoryFile:     os.Getenv("PROMPT_HISTORY_FILE"),
+		ThreadSessionFile:     os.Getenv("THREAD_SESSION_FILE"),
+		AgentsGitURL:          os.Getenv("AGENTS_GIT_URL"),
+		AgentsGitProvider:     os.Getenv("AGENTS_GIT_PROVIDER"),
+		AgentsGitBranch:       os.Getenv("AGENTS_GIT_BRANCH"),
+		AgentsGitToken:        os.Getenv("AGENTS_GIT_TOKEN"),
 	}
 
 	if cfg.SlackBotToken == "" {
@@ -85,22 +352,121 @@ func Load() (*Config, error) {
 	if cfg.SlackSigningSecret == "" {
 		return nil, fmt.Errorf("SLACK_SIGNING_SECRET is required")
 	}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if suffix, ok := strings.CutPrefix(name, signingSecretPrefix); ok && suffix != "" && value != "" {
+			if cfg.AgentSigningSecrets == nil {
+				cfg.AgentSigningSecrets = make(map[string]string)
+			}
+			cfg.AgentSigningSecrets[suffix] = value
+		}
+	}
+
+	// The selected LLM provider must have credentials.
+	switch cfg.LLMProvider() {
+	case "github":
+		if cfg.GitHubToken == "" {
+			return nil, fmt.Errorf("GITHUB_TOKEN is required (or set AZURE_OPEN_AI_ENDPOINT with AZURE_API_KEY or AZURE_AUTH_MODE=entra, or LLM_PROVIDER)")
+		}
+	case "azure":
+		if !cfg.UseAzure() {
+			return nil, fmt.Errorf("LLM_PROVIDER=azure requires AZURE_OPEN_AI_ENDPOINT with AZURE_API_KEY or AZURE_AUTH_MODE=entra")
+		}
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("LLM_PROVIDER=openai requires OPENAI_API_KEY")
+		}
+	case "anthropic":
+		if cfg.AnthropicAPIKey == "" {
+			return nil, fmt.Errorf("LLM_PROVIDER=anthropic requires ANTHROPIC_API_KEY")
+		}
+	case "ollama":
+		if cfg.OllamaEndpoint == "" {
+			return nil, fmt.Errorf("LLM_PROVIDER=ollama requires OLLAMA_ENDPOINT")
+		}
+	default:
+		return nil, fmt.Errorf("invalid LLM_PROVIDER %q: must be github, azure, openai, anthropic, or ollama", cfg.LLMProviderName)
+	}
+
+	if cfg.TeamsAppID != "" && cfg.TeamsAppPassword == "" {
+		return nil, fmt.Errorf("TEAMS_APP_ID requires TEAMS_APP_PASSWORD")
+	}
+	if cfg.ConfluenceSpace != "" && (cfg.JiraURL == "" || cfg.JiraEmail == "" || cfg.JiraAPIToken == "") {
+		return nil, fmt.Errorf("CONFLUENCE_SPACE requires JIRA_URL, JIRA_EMAIL and JIRA_API_TOKEN (Confluence uses the same Atlassian site and token)")
+	}
+	if cfg.NotionPostmortemDB != "" && cfg.NotionToken == "" {
+		return nil, fmt.Errorf("NOTION_POSTMORTEM_DATABASE requires NOTION_TOKEN")
+	}
+
+	switch cfg.SCMProvider {
+	case "":
+		cfg.SCMProvider = "github"
+	case "github":
+	case "gitlab":
+		if cfg.GitLabToken == "" || cfg.GitLabGroup == "" {
+			return nil, fmt.Errorf("SCM_PROVIDER=gitlab requires GITLAB_TOKEN and GITLAB_GROUP")
+		}
+		if cfg.GitLabURL == "" {
+			cfg.GitLabURL = defaultGitLabURL
+		}
+	case "bitbucket":
+		if cfg.BitbucketToken == "" || cfg.BitbucketWorkspace == "" {
+			return nil, fmt.Errorf("SCM_PROVIDER=bitbucket requires BITBUCKET_TOKEN and BITBUCKET_WORKSPACE")
+		}
+	default:
+		return nil, fmt.Errorf("invalid SCM_PROVIDER %q: must be github, gitlab or bitbucket", cfg.SCMProvider)
+	}
 
-	// Either GitHub token or Azure credentials are required for LLM access.
-	if cfg.GitHubToken == "" && !cfg.UseAzure() {
-		return nil, fmt.Errorf("GITHUB_TOKEN is required (or set AZURE_OPEN_AI_ENDPOINT and AZURE_API_KEY)")
+	switch cfg.DirectoryProvider {
+	case "", "azuread":
+	case "okta":
+		if cfg.OktaOrgURL == "" || cfg.OktaAPIToken == "" {
+			return nil, fmt.Errorf("DIRECTORY_PROVIDER=okta requires OKTA_ORG_URL and OKTA_API_TOKEN")
+		}
+	default:
+		return nil, fmt.Errorf("invalid DIRECTORY_PROVIDER %q: must be okta or azuread", cfg.DirectoryProvider)
+	}
+
+	switch cfg.MetricsProvider {
+	case "":
+	case "datadog":
+		if cfg.DatadogAPIKey == "" || cfg.DatadogAppKey == "" {
+			return nil, fmt.Errorf("METRICS_PROVIDER=datadog requires DATADOG_API_KEY and DATADOG_APP_KEY")
+		}
+	case "prometheus":
+		if cfg.PrometheusURL == "" {
+			return nil, fmt.Errorf("METRICS_PROVIDER=prometheus requires PROMETHEUS_URL")
+		}
+	default:
+		return nil, fmt.Errorf("invalid METRICS_PROVIDER %q: must be datadog or prometheus", cfg.MetricsProvider)
 	}
 
 	if cfg.GeneralModel == "" {
-		if cfg.UseAzure() {
+		switch cfg.LLMProvider() {
+		case "azure":
 			cfg.GeneralModel = defaultAzureModel
-		} else {
+		case "openai":
+			cfg.GeneralModel = defaultOpenAIModel
+		case "anthropic":
+			cfg.GeneralModel = defaultAnthropicModel
+		case "ollama":
+			cfg.GeneralModel = defaultOllamaModel
+		default:
 			cfg.GeneralModel = defaultModel
 		}
 	}
 	if cfg.Port == "" {
 		cfg.Port = defaultPort
 	}
+	if cfg.ClientIPHeader == "" {
+		cfg.ClientIPHeader = "X-Forwarded-For"
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
 
 	// CODE_MODEL defaults to the general model when not explicitly set.
 	if cfg.CodeModel == "" {
@@ -117,6 +483,231 @@ func Load() (*Config, error) {
 		cfg.MaxToolRounds = defaultMaxToolRounds
 	}
 
+	switch strings.ToLower(cfg.ReasoningEffort) {
+	case "", "minimal", "low", "medium", "high":
+	default:
+		return nil, fmt.Errorf("invalid REASONING_EFFORT %q: must be minimal, low, medium, or high", cfg.ReasoningEffort)
+	}
+
+	if bStr := os.Getenv("CONTEXT_TOKEN_BUDGET"); bStr != "" {
+		if n, err := strconv.Atoi(bStr); err == nil && n > 0 {
+			cfg.ContextTokenBudget = n
+		} else {
+			return nil, fmt.Errorf("invalid CONTEXT_TOKEN_BUDGET %q: must be a positive integer", bStr)
+		}
+	}
+
+	if cStr := os.Getenv("TOOL_RESULT_COMPRESSION_THRESHOLD"); cStr != "" {
+		if n, err := strconv.Atoi(cStr); err == nil && n >= 0 {
+			cfg.CompressThreshold = n
+		} else {
+			return nil, fmt.Errorf("invalid TOOL_RESULT_COMPRESSION_THRESHOLD %q: must be a non-negative integer", cStr)
+		}
+	}
+	for _, limit := range []struct {
+		env string
+		dst *int
+	}{
+		{"USER_DAILY_TOKEN_LIMIT", &cfg.DailyUserTokens},
+		{"USER_DAILY_ACTION_LIMIT", &cfg.DailyUserActions},
+		{"CHANNEL_DAILY_TOKEN_LIMIT", &cfg.DailyChannelTokens},
+		{"CHANNEL_DAILY_ACTION_LIMIT", &cfg.DailyChannelActions},
+	} {
+		if v := os.Getenv(limit.env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %q: must be a non-negative integer", limit.env, v)
+			}
+			*limit.dst = n
+		}
+	}
+	if sStr := os.Getenv("THREAD_SUMMARY_MIN_STEPS"); sStr != "" {
+		if n, err := strconv.Atoi(sStr); err == nil && n >= 0 {
+			cfg.ThreadSummaryMinSteps = n
+		} else {
+			return nil, fmt.Errorf("invalid THREAD_SUMMARY_MIN_STEPS %q: must be a non-negative integer", sStr)
+		}
+	}
+	if tStr := os.Getenv("THREAD_HISTORY_MAX_TURNS"); tStr != "" {
+		if n, err := strconv.Atoi(tStr); err == nil && n >= 0 {
+			cfg.ThreadHistoryMaxTurns = n
+		} else {
+			return nil, fmt.Errorf("invalid THREAD_HISTORY_MAX_TURNS %q: must be a non-negative integer", tStr)
+		}
+	}
+	if cfg.EmbeddingModel != "" && cfg.LLMProvider() == "anthropic" {
+		return nil, fmt.Errorf("EMBEDDING_MODEL is not supported with LLM_PROVIDER=anthropic (no embeddings API)")
+	}
+	cfg.FAQMinRepeats = defaultFAQMinRepeats
+	if rStr := os.Getenv("FAQ_MIN_REPEATS"); rStr != "" {
+		if n, err := strconv.Atoi(rStr); err == nil && n > 1 {
+			cfg.FAQMinRepeats = n
+		} else {
+			return nil, fmt.Errorf("invalid FAQ_MIN_REPEATS %q: must be an integer of at least 2", rStr)
+		}
+	}
+	cfg.AuditRetentionDays = defaultAuditRetention
+	if dStr := os.Getenv("AUDIT_RETENTION_DAYS"); dStr != "" {
+		if n, err := strconv.Atoi(dStr); err == nil && n >= 0 {
+			cfg.AuditRetentionDays = n
+		} else {
+			return nil, fmt.Errorf("invalid AUDIT_RETENTION_DAYS %q: must be a non-negative integer", dStr)
+		}
+	}
+	if bStr := os.Getenv("WORKFLOW_LOG_BUDGET"); bStr != "" {
+		if n, err := strconv.Atoi(bStr); err == nil && n > 0 {
+			cfg.WorkflowLogBudget = n
+		} else {
+			return nil, fmt.Errorf("invalid WORKFLOW_LOG_BUDGET %q: must be a positive integer", bStr)
+		}
+	}
+	if pStr := os.Getenv("WORKFLOW_LOG_ERROR_PATTERNS"); pStr != "" {
+		for _, p := range strings.Split(pStr, ";") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.WorkflowLogPatterns = append(cfg.WorkflowLogPatterns, p)
+			}
+		}
+	}
+	if caStr := os.Getenv("CHANNEL_AGENTS"); caStr != "" {
+		cfg.ChannelAgents = make(map[string]string)
+		for _, pair := range strings.Split(caStr, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			channel, agent, ok := strings.Cut(pair, "=")
+			channel, agent = strings.TrimSpace(channel), strings.TrimSpace(agent)
+			if !ok || channel == "" || agent == "" {
+				return nil, fmt.Errorf("invalid CHANNEL_AGENTS entry %q: expected CHANNEL_ID=agent", pair)
+			}
+			cfg.ChannelAgents[channel] = agent
+		}
+	}
+
+	if cfg.SummarizerModel == "" {
+		cfg.SummarizerModel = cfg.GeneralModel
+	}
+
+	cfg.LLMMaxRetries = -1
+	if rStr := os.Getenv("LLM_MAX_RETRIES"); rStr != "" {
+		if n, err := strconv.Atoi(rStr); err == nil && n >= 0 {
+			cfg.LLMMaxRetries = n
+		} else {
+			return nil, fmt.Errorf("invalid LLM_MAX_RETRIES %q: must be a non-negative integer", rStr)
+		}
+	}
+
+	if sStr := os.Getenv("JIRA_GITHUB_SYNC"); sStr != "" {
+		b, err := strconv.ParseBool(sStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JIRA_GITHUB_SYNC %q: must be true or false", sStr)
+		}
+		cfg.JiraGitHubSync = b
+	}
+
+	if sStr := os.Getenv("READ_ONLY"); sStr != "" {
+		b, err := strconv.ParseBool(sStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid READ_ONLY %q: must be true or false", sStr)
+		}
+		cfg.ReadOnly = b
+	}
+
+	if sStr := os.Getenv("DRY_RUN"); sStr != "" {
+		b, err := strconv.ParseBool(sStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DRY_RUN %q: must be true or false", sStr)
+		}
+		cfg.DryRun = b
+	}
+
+	cfg.SecretRedaction, cfg.RedactEntropy = true, true
+	for _, b := range []struct {
+		env string
+		dst *bool
+	}{{"SECRET_REDACTION", &cfg.SecretRedaction}, {"REDACT_ENTROPY", &cfg.RedactEntropy}} {
+		if sStr := os.Getenv(b.env); sStr != "" {
+			v, err := strconv.ParseBool(sStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: must be true or false", b.env, sStr)
+			}
+			*b.dst = v
+		}
+	}
+	if pStr := os.Getenv("REDACT_PATTERNS"); pStr != "" {
+		for _, p := range strings.Split(pStr, ";") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.RedactPatterns = append(cfg.RedactPatterns, p)
+			}
+		}
+	}
+
+	if sStr := os.Getenv("AWS_TOOLS"); sStr != "" {
+		b, err := strconv.ParseBool(sStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AWS_TOOLS %q: must be true or false", sStr)
+		}
+		cfg.AWSTools = b
+	}
+	if cfg.AWSRegion == "" {
+		cfg.AWSRegion = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.AWSTools && cfg.AWSRegion == "" {
+		return nil, fmt.Errorf("AWS_TOOLS=true requires AWS_REGION")
+	}
+	if sStr := os.Getenv("AWS_COST_EXPLORER"); sStr != "" {
+		b, err := strconv.ParseBool(sStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AWS_COST_EXPLORER %q: must be true or false", sStr)
+		}
+		cfg.AWSCostExplorer = b
+	}
+	if cfg.AWSCostExplorer && !cfg.AWSTools {
+		return nil, fmt.Errorf("AWS_COST_EXPLORER=true requires AWS_TOOLS=true")
+	}
+	if cfg.GCPBillingTable != "" && cfg.GCPProject == "" {
+		return nil, fmt.Errorf("GCP_BILLING_TABLE requires GCP_PROJECT")
+	}
+
+	cfg.JiraMetadataTTL = defaultJiraMetadataTTL
+	if ttlStr := os.Getenv("JIRA_METADATA_TTL"); ttlStr != "" {
+		d, err := time.ParseDuration(ttlStr)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid JIRA_METADATA_TTL %q: must be a Go duration (e.g. 30m, 1h); 0 disables caching", ttlStr)
+		}
+		cfg.JiraMetadataTTL = d
+	}
+
+	cfg.CVEWatchInterval = defaultCVEWatchInterval
+	if iStr := os.Getenv("CVE_WATCH_INTERVAL"); iStr != "" {
+		d, err := time.ParseDuration(iStr)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("invalid CVE_WATCH_INTERVAL %q: must be a Go duration of at least 1m (e.g. 30m, 1h)", iStr)
+		}
+		cfg.CVEWatchInterval = d
+	}
+
+	if cfg.AgentsGitBranch == "" {
+		cfg.AgentsGitBranch = defaultAgentsGitBranch
+	}
+	cfg.AgentsGitPath = defaultAgentsGitPath
+	if p, ok := os.LookupEnv("AGENTS_GIT_PATH"); ok {
+		cfg.AgentsGitPath = p // "" or "/" = the repository root
+	}
+	switch cfg.AgentsGitProvider {
+	case "", "github", "gitlab":
+	default:
+		return nil, fmt.Errorf("invalid AGENTS_GIT_PROVIDER %q: must be github or gitlab", cfg.AgentsGitProvider)
+	}
+	cfg.AgentsGitInterval = defaultAgentsGitSync
+	if iStr := os.Getenv("AGENTS_GIT_INTERVAL"); iStr != "" {
+		d, err := time.ParseDuration(iStr)
+		if err != nil || (d != 0 && d < 30*time.Second) {
+			return nil, fmt.Errorf("invalid AGENTS_GIT_INTERVAL %q: must be 0 or a Go duration of at least 30s (e.g. 5m)", iStr)
+		}
+		cfg.AgentsGitInterval = d
+	}
+
 	if ttlStr := os.Getenv("THREAD_SESSION_TTL"); ttlStr != "" {
 		if d, err := time.ParseDuration(ttlStr); err == nil && d > 0 {
 			cfg.ThreadSessionTTL = d
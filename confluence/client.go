@@ -0,0 +1,234 @@
+package confluence
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// Client provides access to the Confluence Cloud REST API (v2). Confluence
+// shares the Atlassian site and API token with Jira.
+type Client struct {
+	siteURL    string // e.g. "https://yourorg.atlassian.net"
+	email      string
+	apiToken   string
+	httpClient *http.Client
+}
+
+// NewClient creates a Confluence client using Basic Auth (email + API token).
+func NewClient(siteURL, email, apiToken string) *Client {
+	return &Client{
+		siteURL:  strings.TrimRight(siteURL, "/"),
+		email:    email,
+		apiToken: apiToken,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "confluence"},
+		},
+	}
+}
+
+// Page is a created Confluence page.
+type Page struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// CreatePage creates a page in the space with key spaceKey, under parentID
+// when set. The body is markdown, converted with MarkdownToStorage.
+func (c *Client) CreatePage(ctx context.Context, spaceKey, parentID, title, markdown string) (*Page, error) {
+	var spaces struct {
+		Results []struct {
+			ID string `json:"id"`
+		} `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/spaces?keys="+url.QueryEscape(spaceKey), nil, &spaces); err != nil {
+		return nil, fmt.Errorf("look up space %s: %w", spaceKey, err)
+	}
+	if len(spaces.Results) == 0 {
+		return nil, fmt.Errorf("space %s not found or not visible to the API token", spaceKey)
+	}
+
+	body := map[string]any{
+		"spaceId": spaces.Results[0].ID,
+		"status":  "current",
+		"title":   title,
+		"body":    map[string]string{"representation": "storage", "value": MarkdownToStorage(markdown)},
+	}
+	if parentID != "" {
+		body["parentId"] = parentID
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Links struct {
+			Base  string `json:"base"`
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/pages", body, &created); err != nil {
+		return nil, fmt.Errorf("create page: %w", err)
+	}
+	base := created.Links.Base
+	if base == "" {
+		base = c.siteURL + "/wiki"
+	}
+	return &Page{ID: created.ID, Title: created.Title, URL: base + created.Links.WebUI}, nil
+}
+
+// --------------------------------------------------------------------------
+// Markdown conversion
+// --------------------------------------------------------------------------
+
+// inlineMarkup matches markdown links, **bold** and `code` spans in
+// HTML-escaped text.
+var inlineMarkup = regexp.MustCompile("\\[([^\\]]+)\\]\\((https?://[^)\\s]+)\\)|\\*\\*([^*]+)\\*\\*|`([^`]+)`")
+
+// inline escapes a line of markdown for the storage format, keeping links,
+// bold and inline code; other markup stays as written.
+func inline(text string) string {
+	return inlineMarkup.ReplaceAllStringFunc(html.EscapeString(text), func(m string) string {
+		g := inlineMarkup.FindStringSubmatch(m)
+		switch {
+		case g[1] != "":
+			return fmt.Sprintf(`<a href="%s">%s</a>`, g[2], g[1])
+		case g[3] != "":
+			return "<strong>" + g[3] + "</strong>"
+		default:
+			return "<code>" + g[4] + "</code>"
+		}
+	})
+}
+
+// MarkdownToStorage converts the markdown the model writes to Confluence
+// storage format: headings, bulleted and numbered lists, to-dos (as
+// bullets), fenced code, quotes, dividers and paragraphs, with links, bold
+// and inline code.
+func MarkdownToStorage(md string) string {
+	var sb strings.Builder
+	list := "" // "ul" or "ol" while inside a list
+	closeList := func() {
+		if list != "" {
+			sb.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	item := func(kind, text string) {
+		if list != kind {
+			closeList()
+			sb.WriteString("<" + kind + ">")
+			list = kind
+		}
+		sb.WriteString("<li>" + inline(text) + "</li>")
+	}
+
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			closeList()
+		case strings.HasPrefix(line, "```"):
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			// "]]>" cannot appear inside CDATA; split it across two sections.
+			body := strings.ReplaceAll(strings.Join(code, "\n"), "]]>", "]]]]><![CDATA[>")
+			sb.WriteString(`<ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[` + body + `]]></ac:plain-text-body></ac:structured-macro>`)
+		case line == "---" || line == "***":
+			closeList()
+			sb.WriteString("<hr/>")
+		case strings.HasPrefix(line, "### "):
+			closeList()
+			sb.WriteString("<h3>" + inline(line[4:]) + "</h3>")
+		case strings.HasPrefix(line, "## "):
+			closeList()
+			sb.WriteString("<h2>" + inline(line[3:]) + "</h2>")
+		case strings.HasPrefix(line, "# "):
+			closeList()
+			sb.WriteString("<h1>" + inline(line[2:]) + "</h1>")
+		case strings.HasPrefix(line, "- [ ] ") || strings.HasPrefix(line, "- [x] "):
+			item("ul", line[6:])
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			item("ul", line[2:])
+		case numberedItem.MatchString(line):
+			item("ol", numberedItem.ReplaceAllString(line, ""))
+		case strings.HasPrefix(line, "> "):
+			closeList()
+			sb.WriteString("<blockquote><p>" + inline(line[2:]) + "</p></blockquote>")
+		default:
+			closeList()
+			sb.WriteString("<p>" + inline(line) + "</p>")
+		}
+	}
+	closeList()
+	return sb.String()
+}
+
+var numberedItem = regexp.MustCompile(`^\d+[.)]\s+`)
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.siteURL+"/wiki/api/v2"+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.SetBasicAuth(c.email, c.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []struct {
+				Title string `json:"title"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && len(apiErr.Errors) > 0 && apiErr.Errors[0].Title != "" {
+			return fmt.Errorf("confluence API error (HTTP %d): %s", resp.StatusCode, apiErr.Errors[0].Title)
+		}
+		return fmt.Errorf("confluence API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
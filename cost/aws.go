@@ -0,0 +1,89 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justmike1/ovad/aws"
+)
+
+// accountIDPattern matches a 12-digit AWS account ID.
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// AWS reads unblended costs from AWS Cost Explorer. For costs across
+// accounts, the credentials must belong to the organization's management
+// (or a delegated billing) account.
+type AWS struct {
+	client *aws.Client
+}
+
+// NewAWS creates a Cost Explorer source.
+func NewAWS(client *aws.Client) *AWS {
+	return &AWS{client: client}
+}
+
+func (a *AWS) Name() string { return "aws" }
+
+func (a *AWS) Costs(ctx context.Context, q Query) (*Report, error) {
+	var accountID, scope string
+	if q.Scope != "" {
+		acct, err := a.resolveAccount(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		accountID, scope = acct.ID, acct.ID
+		if acct.Name != "" {
+			scope = acct.Name + " (" + acct.ID + ")"
+		}
+	}
+	groupBy := ""
+	switch q.GroupBy {
+	case "service":
+		groupBy = "SERVICE"
+	case "account":
+		groupBy = "LINKED_ACCOUNT"
+	}
+	costs, err := a.client.CostAndUsage(ctx, q.Start, q.End, groupBy, accountID)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]row, 0, len(costs))
+	for _, c := range costs {
+		rows = append(rows, row{day: c.Day, key: c.Key, amount: c.Amount, currency: c.Unit, estimated: c.Estimated})
+	}
+	return newReport(a.Name(), scope, rows), nil
+}
+
+// resolveAccount turns an account ID or (part of) an account name into a
+// linked account.
+func (a *AWS) resolveAccount(ctx context.Context, q Query) (aws.Account, error) {
+	if accountIDPattern.MatchString(q.Scope) {
+		return aws.Account{ID: q.Scope}, nil
+	}
+	accounts, err := a.client.LinkedAccounts(ctx, q.Start, q.End)
+	if err != nil {
+		return aws.Account{}, fmt.Errorf("look up account %q: %w", q.Scope, err)
+	}
+	var matches []aws.Account
+	for _, acct := range accounts {
+		if strings.EqualFold(acct.Name, q.Scope) {
+			return acct, nil
+		}
+		if strings.Contains(strings.ToLower(acct.Name), strings.ToLower(q.Scope)) {
+			matches = append(matches, acct)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return aws.Account{}, fmt.Errorf("no account named %q had costs in this period", q.Scope)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, fmt.Sprintf("%s (%s)", m.Name, m.ID))
+	}
+	return aws.Account{}, fmt.Errorf("%q matches several accounts: %s", q.Scope, strings.Join(names, ", "))
+}
@@ -0,0 +1,179 @@
+// Package cost answers coarse cloud cost questions ("what did the staging
+// account cost last week?") from AWS Cost Explorer and the GCP Cloud Billing
+// export, caching results for the rest of the day.
+package cost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a cloud billing API.
+type Source interface {
+	// Name is the provider key, "aws" or "gcp".
+	Name() string
+	// Costs returns daily costs for q.
+	Costs(ctx context.Context, q Query) (*Report, error)
+}
+
+// Query selects the costs to report.
+type Query struct {
+	Start, End time.Time // whole UTC days; End is exclusive
+	// Scope limits the costs to one AWS account (ID or name) or GCP
+	// project; empty means everything the credentials can see.
+	Scope string
+	// GroupBy is "service", "account" (AWS linked account / GCP project)
+	// or "" for totals only.
+	GroupBy string
+}
+
+// Report is the result of a cost query.
+type Report struct {
+	Provider  string
+	Scope     string // resolved scope, e.g. "staging (123456789012)"
+	Currency  string
+	Total     float64
+	Groups    []Group // by amount, largest first
+	Daily     []Day   // oldest first
+	Estimated bool    // some days are not final yet
+	FetchedAt time.Time
+}
+
+// Group is the cost of one service or account over the whole period.
+type Group struct {
+	Key    string
+	Amount float64
+}
+
+// Day is the total cost of one day.
+type Day struct {
+	Date   time.Time
+	Amount float64
+}
+
+// row is one provider cost row; sources convert theirs before building a
+// report.
+type row struct {
+	day       time.Time
+	key       string
+	amount    float64
+	currency  string
+	estimated bool
+}
+
+// newReport aggregates rows into totals, per-group and per-day amounts.
+func newReport(provider, scope string, rows []row) *Report {
+	r := &Report{Provider: provider, Scope: scope, FetchedAt: time.Now()}
+	groups := map[string]float64{}
+	days := map[time.Time]float64{}
+	for _, rw := range rows {
+		if r.Currency == "" {
+			r.Currency = rw.currency
+		}
+		r.Total += rw.amount
+		if rw.key != "" {
+			groups[rw.key] += rw.amount
+		}
+		days[rw.day] += rw.amount
+		r.Estimated = r.Estimated || rw.estimated
+	}
+	for k, v := range groups {
+		r.Groups = append(r.Groups, Group{Key: k, Amount: v})
+	}
+	sort.Slice(r.Groups, func(i, j int) bool { return r.Groups[i].Amount > r.Groups[j].Amount })
+	for d, v := range days {
+		r.Daily = append(r.Daily, Day{Date: d, Amount: v})
+	}
+	sort.Slice(r.Daily, func(i, j int) bool { return r.Daily[i].Date.Before(r.Daily[j].Date) })
+	return r
+}
+
+// --------------------------------------------------------------------------
+// Periods
+// --------------------------------------------------------------------------
+
+// Periods lists the named periods Period accepts.
+var Periods = []string{"yesterday", "last_7_days", "last_week", "month_to_date", "last_month", "last_30_days"}
+
+// Period returns the [start, end) UTC days of a named period relative to
+// now. Weeks start on Monday.
+func Period(name string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch name {
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "last_7_days", "":
+		return today.AddDate(0, 0, -7), today, nil
+	case "last_week":
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, -7), monday, nil
+	case "month_to_date":
+		first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if first.Equal(today) {
+			// Nothing has been billed yet on the 1st; include today.
+			return first, today.AddDate(0, 0, 1), nil
+		}
+		return first, today, nil
+	case "last_month":
+		first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first.AddDate(0, -1, 0), first, nil
+	case "last_30_days":
+		return today.AddDate(0, 0, -30), today, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown period %q (valid: %s)", name, strings.Join(Periods, ", "))
+}
+
+// --------------------------------------------------------------------------
+// Cache
+// --------------------------------------------------------------------------
+
+// Cache keeps reports until the end of the UTC day they were fetched on.
+// Billing data changes a few times a day at most, and Cost Explorer charges
+// per request.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*Report
+}
+
+// NewCache creates an empty cache.
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]*Report)}
+}
+
+// Costs returns the cached report for q, or queries src and caches the
+// result. cached reports whether the report came from the cache.
+func (c *Cache) Costs(ctx context.Context, src Source, q Query) (report *Report, cached bool, err error) {
+	key := fmt.Sprintf("%s|%s|%s|%s|%s", src.Name(), q.Start.Format(time.DateOnly), q.End.Format(time.DateOnly), strings.ToLower(q.Scope), q.GroupBy)
+	now := time.Now().UTC()
+
+	c.mu.Lock()
+	for k, r := range c.entries {
+		if !sameDay(r.FetchedAt.UTC(), now) {
+			delete(c.entries, k)
+		}
+	}
+	r, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return r, true, nil
+	}
+
+	r, err = src.Costs(ctx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	c.entries[key] = r
+	c.mu.Unlock()
+	return r, false, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,37 @@
+package cost
+
+import (
+	"context"
+
+	"github.com/justmike1/ovad/gcp"
+)
+
+// GCP reads net costs (after credits) from the Cloud Billing export in
+// BigQuery.
+type GCP struct {
+	client *gcp.Client
+	table  string // "project.dataset.gcp_billing_export_v1_XXXXXX"
+}
+
+// NewGCP creates a billing export source for table.
+func NewGCP(client *gcp.Client, table string) *GCP {
+	return &GCP{client: client, table: table}
+}
+
+func (g *GCP) Name() string { return "gcp" }
+
+func (g *GCP) Costs(ctx context.Context, q Query) (*Report, error) {
+	groupBy := q.GroupBy
+	if groupBy == "account" {
+		groupBy = "project"
+	}
+	costs, err := g.client.BillingCosts(ctx, g.table, q.Start, q.End, groupBy, q.Scope)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]row, 0, len(costs))
+	for _, c := range costs {
+		rows = append(rows, row{day: c.Day, key: c.Key, amount: c.Amount, currency: c.Currency})
+	}
+	return newReport(g.Name(), q.Scope, rows), nil
+}
@@ -0,0 +1,133 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+)
+
+const (
+	graphURL   = "https://graph.microsoft.com/v1.0"
+	graphScope = "https://graph.microsoft.com/.default"
+	userSelect = "id,displayName,mail,userPrincipalName,jobTitle,department,officeLocation,accountEnabled"
+)
+
+// AzureAD is the Provider backed by Microsoft Graph (Entra ID). The app
+// needs the User.Read.All and GroupMember.Read.All application permissions.
+type AzureAD struct {
+	cred       *github.AzureCredential
+	httpClient *http.Client
+}
+
+// NewAzureAD creates an Entra ID directory authenticating with cred (the
+// standard AZURE_* credential chain).
+func NewAzureAD(cred *github.AzureCredential) *AzureAD {
+	return &AzureAD{cred: cred.WithScope(graphScope), httpClient: newHTTPClient("azuread")}
+}
+
+func (a *AzureAD) Name() string { return "Azure AD" }
+
+type graphUser struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	Mail              string `json:"mail"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	JobTitle          string `json:"jobTitle"`
+	Department        string `json:"department"`
+	OfficeLocation    string `json:"officeLocation"`
+	AccountEnabled    *bool  `json:"accountEnabled"`
+}
+
+func (u graphUser) person() Person {
+	email := u.Mail
+	if email == "" {
+		email = u.UserPrincipalName
+	}
+	return Person{ID: u.ID, Name: u.DisplayName, Email: email, Title: u.JobTitle, Department: u.Department, Location: u.OfficeLocation}
+}
+
+// Find looks up an email as a user principal name or mail address, and a
+// name by display, given or family name prefix.
+func (a *AzureAD) Find(ctx context.Context, query string) ([]Person, error) {
+	query = strings.TrimSpace(query)
+	if strings.Contains(query, "@") {
+		var u graphUser
+		err := getJSON(ctx, a.httpClient, graphURL+"/users/"+url.PathEscape(query)+"?$select="+userSelect, a.authorize, &u)
+		if err == nil {
+			return []Person{u.person()}, nil
+		}
+		if !errors.Is(err, errNotFound) {
+			return nil, err
+		}
+		return a.filter(ctx, "mail eq "+odataQuote(query))
+	}
+	q := odataQuote(query)
+	return a.filter(ctx, "startswith(displayName,"+q+") or startswith(givenName,"+q+") or startswith(surname,"+q+")")
+}
+
+func (a *AzureAD) Manager(ctx context.Context, p *Person) (*Person, error) {
+	var u graphUser
+	err := getJSON(ctx, a.httpClient, graphURL+"/users/"+url.PathEscape(p.ID)+"/manager?$select="+userSelect, a.authorize, &u)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := u.person()
+	return &m, nil
+}
+
+func (a *AzureAD) Groups(ctx context.Context, p *Person) ([]string, error) {
+	var resp struct {
+		Value []struct {
+			Type        string `json:"@odata.type"`
+			DisplayName string `json:"displayName"`
+		} `json:"value"`
+	}
+	if err := getJSON(ctx, a.httpClient, graphURL+"/users/"+url.PathEscape(p.ID)+"/memberOf?$select=displayName&$top=100", a.authorize, &resp); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, g := range resp.Value {
+		if g.Type == "#microsoft.graph.group" {
+			names = append(names, g.DisplayName)
+		}
+	}
+	return names, nil
+}
+
+func (a *AzureAD) filter(ctx context.Context, filter string) ([]Person, error) {
+	var resp struct {
+		Value []graphUser `json:"value"`
+	}
+	u := graphURL + "/users?" + url.Values{"$filter": {filter}, "$select": {userSelect}, "$top": {"20"}}.Encode()
+	if err := getJSON(ctx, a.httpClient, u, a.authorize, &resp); err != nil {
+		return nil, err
+	}
+	var out []Person
+	for _, u := range resp.Value {
+		if u.AccountEnabled == nil || *u.AccountEnabled {
+			out = append(out, u.person())
+		}
+	}
+	return out, nil
+}
+
+func (a *AzureAD) authorize(req *http.Request) error {
+	token, err := a.cred.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// odataQuote quotes a string literal for an OData filter.
+func odataQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
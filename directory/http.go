@@ -0,0 +1,60 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// errNotFound is returned by getJSON on HTTP 404.
+var errNotFound = errors.New("not found")
+
+func newHTTPClient(integration string) *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &metrics.Transport{Integration: integration},
+	}
+}
+
+// getJSON performs a GET, authorizing the request with authorize, and
+// decodes the response into out.
+func getJSON(ctx context.Context, hc *http.Client, u string, authorize func(*http.Request) error, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := authorize(req); err != nil {
+		return fmt.Errorf("auth request: %w", err)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body) > 300 {
+			body = body[:300]
+		}
+		return fmt.Errorf("directory API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Okta is the Provider backed by the Okta Users API. It needs a read-only
+// admin API token.
+type Okta struct {
+	orgURL     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewOkta creates an Okta directory for an org URL such as
+// https://acme.okta.com.
+func NewOkta(orgURL, token string) *Okta {
+	return &Okta{orgURL: strings.TrimRight(orgURL, "/"), token: token, httpClient: newHTTPClient("okta")}
+}
+
+func (o *Okta) Name() string { return "Okta" }
+
+type oktaUser struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Profile struct {
+		Login       string `json:"login"`
+		FirstName   string `json:"firstName"`
+		LastName    string `json:"lastName"`
+		DisplayName string `json:"displayName"`
+		Email       string `json:"email"`
+		Title       string `json:"title"`
+		Department  string `json:"department"`
+		Division    string `json:"division"`
+		City        string `json:"city"`
+		ManagerID   string `json:"managerId"`
+		Manager     string `json:"manager"`
+	} `json:"profile"`
+}
+
+func (u oktaUser) person() Person {
+	p := u.Profile
+	name := p.DisplayName
+	if name == "" {
+		name = strings.TrimSpace(p.FirstName + " " + p.LastName)
+	}
+	ref := p.ManagerID
+	if ref == "" {
+		ref = p.Manager
+	}
+	return Person{ID: u.ID, Name: name, Email: p.Email, Title: p.Title, Department: p.Department, Team: p.Division, Location: p.City, managerRef: ref}
+}
+
+// Find searches by email, or by first/last/display name prefix.
+func (o *Okta) Find(ctx context.Context, query string) ([]Person, error) {
+	query = strings.TrimSpace(query)
+	var expr string
+	if strings.Contains(query, "@") {
+		expr = fmt.Sprintf(`profile.email eq %s or profile.login eq %s`, oktaQuote(query), oktaQuote(query))
+	} else if parts := strings.Fields(query); len(parts) > 1 {
+		expr = fmt.Sprintf(`(profile.firstName sw %s and profile.lastName sw %s) or profile.displayName sw %s`,
+			oktaQuote(parts[0]), oktaQuote(parts[len(parts)-1]), oktaQuote(query))
+	} else {
+		expr = fmt.Sprintf(`profile.firstName sw %s or profile.lastName sw %s or profile.displayName sw %s`,
+			oktaQuote(query), oktaQuote(query), oktaQuote(query))
+	}
+	users, err := o.search(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+	var out []Person
+	for _, u := range users {
+		if u.Status == "ACTIVE" || u.Status == "PASSWORD_EXPIRED" || u.Status == "LOCKED_OUT" || u.Status == "RECOVERY" {
+			out = append(out, u.person())
+		}
+	}
+	return out, nil
+}
+
+// Manager resolves profile.managerId, which orgs fill with the manager's
+// Okta ID, login or employee number, falling back to the profile.manager
+// display name.
+func (o *Okta) Manager(ctx context.Context, p *Person) (*Person, error) {
+	if p.managerRef == "" {
+		return nil, nil
+	}
+	var u oktaUser
+	err := getJSON(ctx, o.httpClient, o.orgURL+"/api/v1/users/"+url.PathEscape(p.managerRef), o.authorize, &u)
+	if err == nil {
+		m := u.person()
+		return &m, nil
+	}
+	if !errors.Is(err, errNotFound) {
+		return nil, err
+	}
+	users, err := o.search(ctx, fmt.Sprintf(`profile.employeeNumber eq %s or profile.displayName eq %s`, oktaQuote(p.managerRef), oktaQuote(p.managerRef)))
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("manager %q not found in Okta", p.managerRef)
+	}
+	m := users[0].person()
+	return &m, nil
+}
+
+func (o *Okta) Groups(ctx context.Context, p *Person) ([]string, error) {
+	var groups []struct {
+		Type    string `json:"type"`
+		Profile struct {
+			Name string `json:"name"`
+		} `json:"profile"`
+	}
+	if err := getJSON(ctx, o.httpClient, o.orgURL+"/api/v1/users/"+url.PathEscape(p.ID)+"/groups", o.authorize, &groups); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, g := range groups {
+		// Skip the built-in "Everyone" group.
+		if g.Type != "BUILT_IN" {
+			names = append(names, g.Profile.Name)
+		}
+	}
+	return names, nil
+}
+
+func (o *Okta) search(ctx context.Context, expr string) ([]oktaUser, error) {
+	var users []oktaUser
+	u := o.orgURL + "/api/v1/users?" + url.Values{"search": {expr}, "limit": {"20"}}.Encode()
+	if err := getJSON(ctx, o.httpClient, u, o.authorize, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (o *Okta) authorize(req *http.Request) error {
+	req.Header.Set("Authorization", "SSWS "+o.token)
+	return nil
+}
+
+// oktaQuote quotes a value for an Okta search expression.
+func oktaQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
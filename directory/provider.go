@@ -0,0 +1,29 @@
+// Package directory looks people up in the company directory (Okta or
+// Azure AD / Entra ID): their title, department, manager and groups.
+package directory
+
+import "context"
+
+// Provider is a company directory backend.
+type Provider interface {
+	// Name is the directory's display name, e.g. "Okta".
+	Name() string
+	// Find returns the active people matching an email or a name.
+	Find(ctx context.Context, query string) ([]Person, error)
+	// Manager returns a person's manager, or nil when none is set.
+	Manager(ctx context.Context, p *Person) (*Person, error)
+	// Groups returns the names of the groups a person belongs to.
+	Groups(ctx context.Context, p *Person) ([]string, error)
+}
+
+// Person is a directory user.
+type Person struct {
+	ID         string
+	Name       string
+	Email      string
+	Title      string
+	Department string
+	Team       string // division or team, when the directory has one
+	Location   string
+	managerRef string // backend-specific manager reference
+}
@@ -0,0 +1,580 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/slack"
+	slacklib "github.com/slack-go/slack"
+)
+
+const (
+	apiURL = "https://discord.com/api/v10"
+
+	// DMThread is the thread key used for direct messages, which have no
+	// threads: the whole DM is one conversation.
+	DMThread = "dm"
+
+	maxMessageLen = 2000 // Discord's limit per message
+	maxPageSize   = 100
+	threadNameLen = 90
+
+	// discordEpoch is the first millisecond of 2015, where snowflakes start.
+	discordEpoch = 1420070400000
+)
+
+// Discord channel types used here.
+const (
+	channelDM            = 1
+	channelGroupDM       = 3
+	channelNewsThread    = 10
+	channelPublicThread  = 11
+	channelPrivateThread = 12
+)
+
+// Client is a Discord bot REST client that implements commands.ChatPlatform.
+// Threads are started from the message they answer, so a thread's ID is its
+// root message's ID and thread keys work like Slack's thread timestamps.
+type Client struct {
+	token      string
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	channels map[string]*Channel // channel ID → channel metadata
+}
+
+// NewClient creates a Discord client authenticated with a bot token.
+func NewClient(token string) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "discord"},
+		},
+		channels: make(map[string]*Channel),
+	}
+}
+
+// User is a Discord user.
+type User struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+	Bot        bool   `json:"bot"`
+}
+
+// DisplayName returns the user's display name, falling back to the username.
+func (u User) DisplayName() string {
+	if u.GlobalName != "" {
+		return u.GlobalName
+	}
+	return u.Username
+}
+
+// Message is a Discord message.
+type Message struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+	Author    User   `json:"author"`
+	Content   string `json:"content"`
+	Mentions  []User `json:"mentions"`
+}
+
+// Channel is a Discord channel or thread.
+type Channel struct {
+	ID       string `json:"id"`
+	Type     int    `json:"type"`
+	GuildID  string `json:"guild_id"`
+	ParentID string `json:"parent_id"`
+}
+
+// IsThread reports whether the channel is a thread.
+func (ch *Channel) IsThread() bool {
+	return ch.Type == channelNewsThread || ch.Type == channelPublicThread || ch.Type == channelPrivateThread
+}
+
+// IsDM reports whether the channel is a direct or group DM.
+func (ch *Channel) IsDM() bool {
+	return ch.Type == channelDM || ch.Type == channelGroupDM
+}
+
+// GetChannel returns a channel's metadata, cached after the first lookup.
+func (c *Client) GetChannel(ctx context.Context, channelID string) (*Channel, error) {
+	c.mu.Lock()
+	ch, ok := c.channels[channelID]
+	c.mu.Unlock()
+	if ok {
+		return ch, nil
+	}
+	ch = &Channel{}
+	if err := c.do(ctx, http.MethodGet, "/channels/"+channelID, nil, ch); err != nil {
+		return nil, err
+	}
+	c.rememberChannel(ch)
+	return ch, nil
+}
+
+func (c *Client) rememberChannel(ch *Channel) {
+	c.mu.Lock()
+	c.channels[ch.ID] = ch
+	c.mu.Unlock()
+}
+
+// Conversation maps a Discord channel to the channel and thread keys the
+// sessions use: a thread maps to its parent channel and itself, a DM to
+// DMThread, and any other channel to the message that starts the thread.
+func (c *Client) Conversation(ctx context.Context, channelID, messageID string) (string, string, error) {
+	ch, err := c.GetChannel(ctx, channelID)
+	if err != nil {
+		return "", "", err
+	}
+	switch {
+	case ch.IsThread():
+		return ch.ParentID, ToTS(ch.ID), nil
+	case ch.IsDM():
+		return ch.ID, DMThread, nil
+	}
+	return ch.ID, ToTS(messageID), nil
+}
+
+// FetchChannelHistory returns the latest messages in channelID, newest first.
+func (c *Client) FetchChannelHistory(channelID string, limit int) ([]slacklib.Message, error) {
+	msgs, err := c.messages(context.Background(), channelID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channel history: %w", err)
+	}
+	out := make([]slacklib.Message, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, toSlack(m))
+	}
+	return out, nil
+}
+
+// FetchThreadReplies returns the thread's root message followed by its
+// replies, oldest first like Slack's conversations.replies.
+func (c *Client) FetchThreadReplies(channelID, threadTS string, limit int) ([]slacklib.Message, error) {
+	ctx := context.Background()
+	if threadTS == DMThread {
+		msgs, err := c.FetchChannelHistory(channelID, limit)
+		if err != nil {
+			return nil, err
+		}
+		for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+			msgs[i], msgs[j] = msgs[j], msgs[i]
+		}
+		return msgs, nil
+	}
+
+	threadID := FromTS(threadTS)
+	var out []slacklib.Message
+	var root Message
+	if err := c.do(ctx, http.MethodGet, "/channels/"+channelID+"/messages/"+threadID, nil, &root); err == nil {
+		out = append(out, toSlack(root))
+	}
+	replies, err := c.messages(ctx, threadID, limit)
+	if err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("failed to fetch thread replies: %w", err)
+	}
+	for i := len(replies) - 1; i >= 0 && len(out) < limit; i-- {
+		m := toSlack(replies[i])
+		m.ThreadTimestamp = threadTS
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// messages lists up to limit of the latest messages in a channel, newest
+// first, paging backwards.
+func (c *Client) messages(ctx context.Context, channelID string, limit int) ([]Message, error) {
+	var all []Message
+	before := ""
+	for len(all) < limit {
+		q := url.Values{"limit": {strconv.Itoa(min(limit-len(all), maxPageSize))}}
+		if before != "" {
+			q.Set("before", before)
+		}
+		var page []Message
+		if err := c.do(ctx, http.MethodGet, "/channels/"+channelID+"/messages?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < maxPageSize {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+	return all, nil
+}
+
+// PostMessage posts text to a channel and returns the first message's
+// timestamp, which replies use as the thread key.
+func (c *Client) PostMessage(channelID, text string) (string, error) {
+	id, err := c.send(context.Background(), channelID, text)
+	if err != nil {
+		return "", fmt.Errorf("failed to post message: %w", err)
+	}
+	return ToTS(id), nil
+}
+
+// PostThreadReply replies in the thread started from the message threadTS,
+// starting the thread on the first reply. In DMs it posts to the DM.
+func (c *Client) PostThreadReply(channelID, threadTS, text string) error {
+	target, err := c.threadTarget(context.Background(), channelID, threadTS, text)
+	if err == nil {
+		_, err = c.send(context.Background(), target, text)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post thread reply: %w", err)
+	}
+	return nil
+}
+
+// UploadFile attaches content as a file, in threadTS's thread when set.
+func (c *Client) UploadFile(channelID, threadTS, filename, title string, content []byte) error {
+	ctx := context.Background()
+	target := channelID
+	if threadTS != "" {
+		t, err := c.threadTarget(ctx, channelID, threadTS, title)
+		if err != nil {
+			return fmt.Errorf("failed to upload file: %w", err)
+		}
+		target = t
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	payload, _ := json.Marshal(map[string]any{
+		"content":     truncate(title, maxMessageLen),
+		"attachments": []map[string]any{{"id": 0, "filename": filename}},
+	})
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="payload_json"`)
+	h.Set("Content-Type", "application/json")
+	pw, err := mw.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	_, _ = pw.Write(payload)
+	fw, err := mw.CreateFormFile("files[0]", filename)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	_, _ = fw.Write(content)
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/channels/"+target+"/messages", &body)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if _, err := c.roundTrip(req); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
+// GetPermalink returns the link to a message (or the root of a thread).
+func (c *Client) GetPermalink(channelID, messageTS string) (string, error) {
+	if messageTS == "" || messageTS == DMThread {
+		return "", fmt.Errorf("no message to link to in %s", channelID)
+	}
+	ch, err := c.GetChannel(context.Background(), channelID)
+	if err != nil {
+		return "", err
+	}
+	guild := ch.GuildID
+	if guild == "" {
+		guild = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guild, channelID, FromTS(messageTS)), nil
+}
+
+// GetUserInfo looks up a Discord user. Discord does not expose email or
+// title to bots; those stay empty.
+func (c *Client) GetUserInfo(userID string) (*slacklib.User, error) {
+	var u User
+	if err := c.do(context.Background(), http.MethodGet, "/users/"+userID, nil, &u); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	su := &slacklib.User{ID: u.ID, Name: u.Username, RealName: u.DisplayName(), IsBot: u.Bot}
+	su.Profile.RealName = u.DisplayName()
+	su.Profile.DisplayName = u.DisplayName()
+	return su, nil
+}
+
+// threadTarget returns the channel a thread reply goes to: the DM itself,
+// or the thread started from message threadTS, created if needed.
+func (c *Client) threadTarget(ctx context.Context, channelID, threadTS, name string) (string, error) {
+	if threadTS == DMThread {
+		return channelID, nil
+	}
+	if ch, err := c.GetChannel(ctx, channelID); err == nil && ch.IsDM() {
+		return channelID, nil // DMs have no threads
+	}
+	threadID := FromTS(threadTS)
+	c.mu.Lock()
+	_, known := c.channels[threadID]
+	c.mu.Unlock()
+	if known {
+		return threadID, nil
+	}
+
+	// Name the thread after the request it answers when it can be read.
+	var root Message
+	if err := c.do(ctx, http.MethodGet, "/channels/"+channelID+"/messages/"+threadID, nil, &root); err == nil && root.Content != "" {
+		name = mentionTag.ReplaceAllString(root.Content, "")
+	}
+	body := map[string]any{"name": threadName(name), "auto_archive_duration": 1440}
+	ch := &Channel{}
+	err := c.do(ctx, http.MethodPost, "/channels/"+channelID+"/messages/"+threadID+"/threads", body, ch)
+	if err != nil {
+		// The thread may already exist (started by a user or an earlier run).
+		if existing, getErr := c.GetChannel(ctx, threadID); getErr == nil && existing.IsThread() {
+			return threadID, nil
+		}
+		return "", fmt.Errorf("start thread: %w", err)
+	}
+	c.rememberChannel(ch)
+	return ch.ID, nil
+}
+
+// threadName derives a thread title from the first line of text.
+func threadName(text string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	name = strings.Trim(name, "*_`> ")
+	if name == "" {
+		name = "ovad"
+	}
+	return truncate(name, threadNameLen)
+}
+
+// send posts text to a channel, split into messages of at most 2000
+// characters, and returns the ID of the first.
+func (c *Client) send(ctx context.Context, channelID, text string) (string, error) {
+	first := ""
+	for _, chunk := range Split(ToMarkdown(text), maxMessageLen) {
+		var m Message
+		body := map[string]any{
+			"content":          chunk,
+			"allowed_mentions": map[string]any{"parse": []string{"users"}},
+		}
+		if err := c.do(ctx, http.MethodPost, "/channels/"+channelID+"/messages", body, &m); err != nil {
+			return first, err
+		}
+		if first == "" {
+			first = m.ID
+		}
+	}
+	return first, nil
+}
+
+// ToMarkdown converts the agents' Slack mrkdwn for Discord. User mentions
+// use the same <@id> syntax and are kept.
+func ToMarkdown(text string) string {
+	return slack.ToMarkdown(text, func(id string) string { return "<@" + id + ">" })
+}
+
+// Split breaks text into chunks of at most limit bytes, preferring line
+// breaks, and closes and reopens code fences that span a split.
+func Split(text string, limit int) []string {
+	var chunks []string
+	inFence := false
+	for len(text) > 0 {
+		prefix := ""
+		if inFence {
+			prefix = "```\n"
+		}
+		room := limit - len(prefix) - len("\n```")
+		if len(prefix)+len(text) <= limit {
+			chunks = append(chunks, prefix+text)
+			break
+		}
+		cut := strings.LastIndex(text[:room], "\n")
+		if cut <= 0 {
+			cut = room
+			for cut > 0 && !isRuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunk := text[:cut]
+		if strings.Count(chunk, "```")%2 == 1 {
+			inFence = !inFence
+		}
+		if inFence {
+			chunk += "\n```"
+		}
+		chunks = append(chunks, prefix+chunk)
+		text = strings.TrimPrefix(text[cut:], "\n")
+	}
+	return chunks
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !isRuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func toSlack(m Message) slacklib.Message {
+	out := slacklib.Message{}
+	out.User = m.Author.ID
+	out.Username = m.Author.DisplayName()
+	if m.Author.Bot {
+		out.BotID = m.Author.ID
+	}
+	out.Text = m.Content
+	out.Timestamp = ToTS(m.ID)
+	return out
+}
+
+// ToTS converts a snowflake ID to a Slack-style "seconds.fraction"
+// timestamp: the creation time in seconds, then the milliseconds and the
+// snowflake's low 22 bits, so it sorts by time and converts back with FromTS.
+// Other IDs are returned unchanged.
+func ToTS(id string) string {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return id
+	}
+	ms := n>>22 + discordEpoch
+	return fmt.Sprintf("%d.%03d%07d", ms/1000, ms%1000, n&0x3FFFFF)
+}
+
+// FromTS reverses ToTS.
+func FromTS(ts string) string {
+	sec, frac, ok := strings.Cut(ts, ".")
+	if !ok || len(frac) != 10 {
+		return ts
+	}
+	s, err1 := strconv.ParseUint(sec, 10, 64)
+	ms, err2 := strconv.ParseUint(frac[:3], 10, 64)
+	low, err3 := strconv.ParseUint(frac[3:], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return ts
+	}
+	return strconv.FormatUint((s*1000+ms-discordEpoch)<<22|low, 10)
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+// apiError is an error response from the Discord API.
+type apiError struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("discord API error (HTTP %d): %s", e.Status, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.Status == http.StatusNotFound
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, apiURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bot "+c.token)
+	req.Header.Set("User-Agent", "DiscordBot (https://github.com/justmike1/arbetern, 1.0)")
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	respBody, err := c.roundTrip(req)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// roundTrip sends a request, waiting out one rate limit response.
+func (c *Client) roundTrip(req *http.Request) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("send request: %w", err)
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			var limited struct {
+				RetryAfter float64 `json:"retry_after"`
+			}
+			_ = json.Unmarshal(respBody, &limited)
+			if wait := time.Duration(limited.RetryAfter * float64(time.Second)); wait > 0 && wait <= 10*time.Second {
+				time.Sleep(wait)
+				if req.GetBody != nil {
+					if req.Body, err = req.GetBody(); err != nil {
+						return nil, fmt.Errorf("create request: %w", err)
+					}
+				}
+				continue
+			}
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			ae := &apiError{Status: resp.StatusCode, Message: string(respBody)}
+			var body struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(respBody, &body) == nil && body.Message != "" {
+				ae.Code, ae.Message = body.Code, body.Message
+			}
+			return nil, ae
+		}
+		return respBody, nil
+	}
+}
@@ -0,0 +1,417 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+const (
+	gatewayURL = "wss://gateway.discord.gg"
+
+	intentGuilds         = 1 << 0
+	intentGuildMessages  = 1 << 9
+	intentDirectMessages = 1 << 12
+	intentMessageContent = 1 << 15 // privileged: enable it on the bot's page in the developer portal
+
+	maxBackoff = time.Minute
+)
+
+// Gateway opcodes.
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opResume         = 6
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+	opHeartbeatAck   = 11
+)
+
+var (
+	mentionTag  = regexp.MustCompile(`<@!?\d+>`)
+	commandName = regexp.MustCompile(`^[-_a-z0-9]{1,32}$`)
+)
+
+// MessageHandler receives a user message. channelID and threadTS are the
+// session keys from Client.Conversation; mentioned reports whether the bot
+// was @mentioned (always true in DMs). text has the bot mention removed.
+type MessageHandler func(channelID, threadTS, userID, text string, mentioned bool)
+
+// CommandHandler receives a slash command. command is the command name
+// (an agent ID) and threadTS is the thread started from the command's echo.
+type CommandHandler func(command, channelID, threadTS, userID, text string)
+
+// Gateway connects to the Discord gateway (outbound WebSocket), registers a
+// slash command per agent and dispatches messages and commands. Like Slack
+// Socket Mode, it needs no inbound URL.
+type Gateway struct {
+	client    *Client
+	guildID   string
+	commands  []string
+	onMessage MessageHandler
+	onCommand CommandHandler
+	intents   int
+
+	botUserID atomic.Value // string
+	appID     atomic.Value // string
+
+	writeMu   sync.Mutex
+	seq       atomic.Int64
+	sessionID string
+	resumeURL string
+}
+
+// NewGateway creates a gateway listener for client's bot. commands are the
+// slash commands to register (one per agent), in guildID when set — guild
+// commands appear immediately, global ones can take a while.
+func NewGateway(client *Client, guildID string, commands []string, onMessage MessageHandler, onCommand CommandHandler) *Gateway {
+	g := &Gateway{
+		client:    client,
+		guildID:   guildID,
+		onMessage: onMessage,
+		onCommand: onCommand,
+		intents:   intentGuilds | intentGuildMessages | intentDirectMessages | intentMessageContent,
+	}
+	for _, c := range commands {
+		if !commandName.MatchString(c) {
+			logging.Infof("[discord] skipping slash command %q: names must be 1-32 lowercase letters, digits, - or _", c)
+			continue
+		}
+		g.commands = append(g.commands, c)
+	}
+	g.botUserID.Store("")
+	g.appID.Store("")
+	return g
+}
+
+// errFatal marks gateway close codes that reconnecting cannot fix.
+var errFatal = errors.New("fatal gateway error")
+
+// Start connects and keeps reconnecting (resuming the session when
+// possible) until a fatal error such as an invalid token. It blocks.
+func (g *Gateway) Start() {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := g.run()
+		if errors.Is(err, errFatal) {
+			logging.Infof("[discord] gateway stopped: %v", err)
+			return
+		}
+		if time.Since(started) > 5*time.Minute {
+			backoff = time.Second
+		}
+		logging.Infof("[discord] gateway disconnected (%v); reconnecting in %s", err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+type payload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d,omitempty"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+func (g *Gateway) run() error {
+	endpoint := gatewayURL
+	if g.sessionID != "" && g.resumeURL != "" {
+		endpoint = g.resumeURL
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(strings.TrimRight(endpoint, "/")+"/?v=10&encoding=json", nil)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	var hello payload
+	if err := conn.ReadJSON(&hello); err != nil {
+		return fmt.Errorf("read hello: %w", err)
+	}
+	var hb struct {
+		Interval int64 `json:"heartbeat_interval"`
+	}
+	if hello.Op != opHello || json.Unmarshal(hello.D, &hb) != nil || hb.Interval <= 0 {
+		return fmt.Errorf("unexpected first payload (op %d)", hello.Op)
+	}
+
+	if g.sessionID != "" {
+		err = g.send(conn, opResume, map[string]any{"token": g.client.token, "session_id": g.sessionID, "seq": g.seq.Load()})
+	} else {
+		err = g.send(conn, opIdentify, map[string]any{
+			"token":      g.client.token,
+			"intents":    g.intents,
+			"properties": map[string]string{"os": runtime.GOOS, "browser": "arbetern", "device": "arbetern"},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	var acked atomic.Bool
+	acked.Store(true)
+	go func() {
+		ticker := time.NewTicker(time.Duration(hb.Interval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !acked.Swap(false) {
+					logging.Infof("[discord] no heartbeat ack; reconnecting")
+					_ = conn.Close()
+					return
+				}
+				_ = g.heartbeat(conn)
+			}
+		}
+	}()
+
+	for {
+		var p payload
+		if err := conn.ReadJSON(&p); err != nil {
+			return g.closeError(err)
+		}
+		switch p.Op {
+		case opDispatch:
+			if p.S != nil {
+				g.seq.Store(*p.S)
+			}
+			g.dispatch(p.T, p.D)
+		case opHeartbeat:
+			_ = g.heartbeat(conn)
+		case opHeartbeatAck:
+			acked.Store(true)
+		case opReconnect:
+			return errors.New("reconnect requested")
+		case opInvalidSession:
+			var resumable bool
+			_ = json.Unmarshal(p.D, &resumable)
+			if !resumable {
+				g.sessionID = ""
+			}
+			time.Sleep(2 * time.Second)
+			return errors.New("invalid session")
+		}
+	}
+}
+
+// closeError interprets the gateway's close code, dropping the session or
+// the message content intent where that lets the next connection succeed.
+func (g *Gateway) closeError(err error) error {
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) {
+		return err
+	}
+	switch ce.Code {
+	case 4004:
+		return fmt.Errorf("%w: authentication failed (check DISCORD_BOT_TOKEN)", errFatal)
+	case 4010, 4011, 4012, 4013:
+		return fmt.Errorf("%w: %d %s", errFatal, ce.Code, ce.Text)
+	case 4014:
+		if g.intents&intentMessageContent == 0 {
+			return fmt.Errorf("%w: disallowed intents", errFatal)
+		}
+		logging.Infof("[discord] Message Content intent not enabled for the bot; thread follow-ups will need an @mention")
+		g.intents &^= intentMessageContent
+		g.sessionID = ""
+	case 4007, 4009:
+		g.sessionID = ""
+	}
+	return err
+}
+
+func (g *Gateway) send(conn *websocket.Conn, op int, d any) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	if err := conn.WriteJSON(payload{Op: op, D: data}); err != nil {
+		return fmt.Errorf("write payload: %w", err)
+	}
+	return nil
+}
+
+func (g *Gateway) heartbeat(conn *websocket.Conn) error {
+	var seq any
+	if s := g.seq.Load(); s > 0 {
+		seq = s
+	}
+	return g.send(conn, opHeartbeat, seq)
+}
+
+func (g *Gateway) dispatch(event string, d json.RawMessage) {
+	switch event {
+	case "READY":
+		var ready struct {
+			SessionID string `json:"session_id"`
+			ResumeURL string `json:"resume_gateway_url"`
+			User      User   `json:"user"`
+			App       struct {
+				ID string `json:"id"`
+			} `json:"application"`
+		}
+		if err := json.Unmarshal(d, &ready); err != nil {
+			logging.Errorf("[discord] failed to parse READY: %v", err)
+			return
+		}
+		g.sessionID, g.resumeURL = ready.SessionID, ready.ResumeURL
+		g.botUserID.Store(ready.User.ID)
+		g.appID.Store(ready.App.ID)
+		logging.Infof("[discord] connected as %s (%s)", ready.User.Username, ready.User.ID)
+		go g.registerCommands(ready.App.ID)
+	case "RESUMED":
+		logging.Infof("[discord] session resumed")
+	case "CHANNEL_CREATE", "THREAD_CREATE", "THREAD_UPDATE":
+		var ch Channel
+		if json.Unmarshal(d, &ch) == nil && ch.ID != "" {
+			g.client.rememberChannel(&ch)
+		}
+	case "MESSAGE_CREATE":
+		var m Message
+		if err := json.Unmarshal(d, &m); err != nil || m.Author.Bot {
+			return
+		}
+		go g.handleMessage(m)
+	case "INTERACTION_CREATE":
+		var in interaction
+		if err := json.Unmarshal(d, &in); err != nil || in.Type != interactionCommand {
+			return
+		}
+		go g.handleCommand(in)
+	}
+}
+
+func (g *Gateway) handleMessage(m Message) {
+	botID := g.botUserID.Load().(string)
+	mentioned := false
+	for _, u := range m.Mentions {
+		if u.ID == botID {
+			mentioned = true
+		}
+	}
+	text := strings.NewReplacer("<@"+botID+">", "", "<@!"+botID+">", "").Replace(m.Content)
+	text = strings.TrimSpace(text)
+	if text == "" && !mentioned {
+		return
+	}
+
+	channelID, threadTS, err := g.client.Conversation(context.Background(), m.ChannelID, m.ID)
+	if err != nil {
+		logging.Errorf("[discord] failed to resolve channel %s: %v", m.ChannelID, err)
+		return
+	}
+	g.onMessage(channelID, threadTS, m.Author.ID, text, mentioned || threadTS == DMThread)
+}
+
+// --------------------------------------------------------------------------
+// Slash commands
+// --------------------------------------------------------------------------
+
+const interactionCommand = 2
+
+type interaction struct {
+	ID        string `json:"id"`
+	Token     string `json:"token"`
+	Type      int    `json:"type"`
+	ChannelID string `json:"channel_id"`
+	Member    *struct {
+		User User `json:"user"`
+	} `json:"member"`
+	User *User `json:"user"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+// registerCommands overwrites the bot's slash commands with one per agent.
+func (g *Gateway) registerCommands(appID string) {
+	if len(g.commands) == 0 || appID == "" {
+		return
+	}
+	defs := make([]map[string]any, 0, len(g.commands))
+	for _, name := range g.commands {
+		defs = append(defs, map[string]any{
+			"name":        name,
+			"type":        1,
+			"description": "Ask the " + name + " agent",
+			"options": []map[string]any{{
+				"type": 3, "name": "request", "description": "What you want the agent to do", "required": false,
+			}},
+		})
+	}
+	path := "/applications/" + appID + "/commands"
+	if g.guildID != "" {
+		path = "/applications/" + appID + "/guilds/" + g.guildID + "/commands"
+	}
+	if err := g.client.do(context.Background(), http.MethodPut, path, defs, nil); err != nil {
+		logging.Errorf("[discord] failed to register slash commands: %v", err)
+		return
+	}
+	logging.Infof("[discord] registered slash commands: %v", g.commands)
+}
+
+// handleCommand acknowledges a slash command by echoing it to the channel,
+// then hands it on with the echo as the root of the thread the agent answers in.
+func (g *Gateway) handleCommand(in interaction) {
+	ctx := context.Background()
+	user := in.User
+	if in.Member != nil {
+		user = &in.Member.User
+	}
+	if user == nil {
+		return
+	}
+	text := ""
+	for _, o := range in.Data.Options {
+		if o.Name == "request" {
+			text, _ = o.Value.(string)
+		}
+	}
+
+	echo := fmt.Sprintf("<@%s> `/%s` %s", user.ID, in.Data.Name, text)
+	resp := map[string]any{
+		"type": 4, // CHANNEL_MESSAGE_WITH_SOURCE
+		"data": map[string]any{"content": truncate(echo, maxMessageLen), "allowed_mentions": map[string]any{"parse": []string{}}},
+	}
+	if err := g.client.do(ctx, http.MethodPost, "/interactions/"+in.ID+"/"+in.Token+"/callback", resp, nil); err != nil {
+		logging.Errorf("[discord] failed to acknowledge /%s: %v", in.Data.Name, err)
+		return
+	}
+	var original Message
+	if err := g.client.do(ctx, http.MethodGet, "/webhooks/"+g.appID.Load().(string)+"/"+in.Token+"/messages/@original", nil, &original); err != nil {
+		logging.Errorf("[discord] failed to read /%s response: %v", in.Data.Name, err)
+		return
+	}
+
+	channelID, threadTS, err := g.client.Conversation(ctx, in.ChannelID, original.ID)
+	if err != nil {
+		logging.Errorf("[discord] failed to resolve channel %s: %v", in.ChannelID, err)
+		return
+	}
+	g.onCommand(in.Data.Name, channelID, threadTS, user.ID, text)
+}
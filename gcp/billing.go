@@ -0,0 +1,115 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const bigQueryAPI = "https://bigquery.googleapis.com/bigquery/v2"
+
+// billingTablePattern matches a fully qualified BigQuery table,
+// "project.dataset.table".
+var billingTablePattern = regexp.MustCompile(`^[A-Za-z0-9_:-]+(\.[A-Za-z0-9_.-]+){2}$`)
+
+// maxBillingPolls caps how often BillingCosts waits for a running query.
+const maxBillingPolls = 3
+
+// CostRow is the net cost (after credits) of one group on one day.
+type CostRow struct {
+	Day      time.Time
+	Key      string // service description or project ID; empty when not grouped
+	Amount   float64
+	Currency string
+}
+
+// BillingCosts queries the Cloud Billing export table for daily net costs
+// between start and end (end exclusive), grouped by groupBy ("service",
+// "project" or "" for totals) and optionally limited to one project. The
+// query runs as a BigQuery job in the client's project.
+func (c *Client) BillingCosts(ctx context.Context, table string, start, end time.Time, groupBy, project string) ([]CostRow, error) {
+	if !billingTablePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid billing export table %q: expected project.dataset.table", table)
+	}
+	key := "''"
+	switch groupBy {
+	case "service":
+		key = "service.description"
+	case "project":
+		key = "IFNULL(project.id, '(no project)')"
+	case "":
+	default:
+		return nil, fmt.Errorf("invalid grouping %q", groupBy)
+	}
+	query := fmt.Sprintf("SELECT FORMAT_DATE('%%F', DATE(usage_start_time)) AS day, %s AS key, currency,"+
+		" SUM(cost) + SUM(IFNULL((SELECT SUM(cr.amount) FROM UNNEST(credits) cr), 0)) AS amount"+
+		" FROM `%s` WHERE usage_start_time >= @start AND usage_start_time < @end", key, table)
+	params := []map[string]any{timestampParam("start", start), timestampParam("end", end)}
+	if project != "" {
+		query += " AND project.id = @project"
+		params = append(params, map[string]any{
+			"name":           "project",
+			"parameterType":  map[string]string{"type": "STRING"},
+			"parameterValue": map[string]string{"value": project},
+		})
+	}
+	query += " GROUP BY day, key, currency ORDER BY day"
+
+	var resp queryResponse
+	body := map[string]any{
+		"query":           query,
+		"useLegacySql":    false,
+		"parameterMode":   "NAMED",
+		"queryParameters": params,
+		"timeoutMs":       20000,
+	}
+	if err := c.post(ctx, fmt.Sprintf("%s/projects/%s/queries", bigQueryAPI, url.PathEscape(c.project)), body, &resp); err != nil {
+		return nil, err
+	}
+	for i := 0; !resp.JobComplete && i < maxBillingPolls; i++ {
+		q := url.Values{"timeoutMs": {"20000"}, "location": {resp.JobReference.Location}}
+		u := fmt.Sprintf("%s/projects/%s/queries/%s?%s", bigQueryAPI, url.PathEscape(c.project), url.PathEscape(resp.JobReference.JobID), q.Encode())
+		resp = queryResponse{JobReference: resp.JobReference}
+		if err := c.get(ctx, u, &resp); err != nil {
+			return nil, err
+		}
+	}
+	if !resp.JobComplete {
+		return nil, fmt.Errorf("billing query %s did not finish in time", resp.JobReference.JobID)
+	}
+
+	rows := make([]CostRow, 0, len(resp.Rows))
+	for _, r := range resp.Rows {
+		if len(r.F) < 4 {
+			continue
+		}
+		day, _ := time.Parse("2006-01-02", r.F[0].V)
+		amount, _ := strconv.ParseFloat(r.F[3].V, 64)
+		rows = append(rows, CostRow{Day: day, Key: r.F[1].V, Currency: r.F[2].V, Amount: amount})
+	}
+	return rows, nil
+}
+
+type queryResponse struct {
+	JobComplete  bool `json:"jobComplete"`
+	JobReference struct {
+		JobID    string `json:"jobId"`
+		Location string `json:"location"`
+	} `json:"jobReference"`
+	Rows []struct {
+		F []struct {
+			V string `json:"v"`
+		} `json:"f"`
+	} `json:"rows"`
+}
+
+func timestampParam(name string, t time.Time) map[string]any {
+	return map[string]any{
+		"name":           name,
+		"parameterType":  map[string]string{"type": "TIMESTAMP"},
+		"parameterValue": map[string]string{"value": t.UTC().Format("2006-01-02 15:04:05") + " UTC"},
+	}
+}
@@ -0,0 +1,117 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// Client calls a small set of read-only Google Cloud APIs (Cloud Run, GKE,
+// Error Reporting, BigQuery for the billing export) for one project.
+type Client struct {
+	project    string
+	region     string
+	ts         oauth2.TokenSource
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	clusters map[string]*gkeCluster // "location/name" → Kubernetes API connection
+}
+
+// NewClient creates a GCP client for project. region is the default
+// location for Cloud Run lookups.
+func NewClient(project, region string, ts oauth2.TokenSource) *Client {
+	return &Client{
+		project:  project,
+		region:   region,
+		ts:       ts,
+		clusters: make(map[string]*gkeCluster),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &oauth2.Transport{
+				Source: ts,
+				Base:   &metrics.Transport{Integration: "gcp"},
+			},
+		},
+	}
+}
+
+// Project returns the client's project ID.
+func (c *Client) Project() string { return c.project }
+
+// location returns loc, or the default region.
+func (c *Client) location(loc string) (string, error) {
+	if loc != "" {
+		return loc, nil
+	}
+	if c.region == "" {
+		return "", fmt.Errorf("no region given and GCP_REGION is not set")
+	}
+	return c.region, nil
+}
+
+func (c *Client) get(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	return doJSON(c.httpClient, req, out)
+}
+
+func (c *Client) post(ctx context.Context, url string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doJSON(c.httpClient, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+			Message string `json:"message"` // Kubernetes Status
+		}
+		if json.Unmarshal(body, &apiErr) == nil {
+			if apiErr.Error.Message != "" {
+				return fmt.Errorf("GCP API error (HTTP %d %s): %s", resp.StatusCode, apiErr.Error.Status, apiErr.Error.Message)
+			}
+			if apiErr.Message != "" {
+				return fmt.Errorf("kubernetes API error (HTTP %d): %s", resp.StatusCode, apiErr.Message)
+			}
+		}
+		return fmt.Errorf("GCP API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	metadataTokenURL   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	defaultTokenURL    = "https://oauth2.googleapis.com/token"
+)
+
+// NewTokenSource returns a token source for the cloud-platform scope,
+// mirroring Application Default Credentials:
+//  1. Service account key — GOOGLE_APPLICATION_CREDENTIALS pointing to a JSON key file
+//  2. Metadata server — GKE Workload Identity, Cloud Run, GCE
+//
+// Tokens are cached until shortly before they expire.
+func NewTokenSource() (oauth2.TokenSource, string, error) {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		var key struct {
+			Type         string `json:"type"`
+			ClientEmail  string `json:"client_email"`
+			PrivateKey   string `json:"private_key"`
+			PrivateKeyID string `json:"private_key_id"`
+			TokenURI     string `json:"token_uri"`
+		}
+		if err := json.Unmarshal(data, &key); err != nil {
+			return nil, "", fmt.Errorf("failed to parse GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		if key.Type != "service_account" {
+			return nil, "", fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: unsupported credential type %q (only service_account keys are supported)", key.Type)
+		}
+		if key.TokenURI == "" {
+			key.TokenURI = defaultTokenURL
+		}
+		cfg := &jwt.Config{
+			Email:        key.ClientEmail,
+			PrivateKey:   []byte(key.PrivateKey),
+			PrivateKeyID: key.PrivateKeyID,
+			Scopes:       []string{cloudPlatformScope},
+			TokenURL:     key.TokenURI,
+		}
+		return cfg.TokenSource(context.Background()), "service account key (" + key.ClientEmail + ")", nil
+	}
+	ts := &metadataTokenSource{httpClient: &http.Client{Timeout: 10 * time.Second}}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, ts, 5*time.Minute), "metadata server", nil
+}
+
+// metadataTokenSource fetches the attached service account's token from the
+// GCE metadata server.
+type metadataTokenSource struct {
+	httpClient *http.Client
+}
+
+func (s *metadataTokenSource) Token() (*oauth2.Token, error) {
+	req, err := http.NewRequest(http.MethodGet, metadataTokenURL+"?scopes="+cloudPlatformScope, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("metadata server unavailable (no GCP credentials found): %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata server returned %d: %s", resp.StatusCode, string(body))
+	}
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata token response: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: tr.AccessToken,
+		TokenType:   tr.TokenType,
+		Expiry:      time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second),
+	}, nil
+}
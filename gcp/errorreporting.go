@@ -0,0 +1,97 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const errorReportingAPI = "https://clouderrorreporting.googleapis.com/v1beta1"
+
+// ErrorGroup is a group of similar errors from Error Reporting.
+type ErrorGroup struct {
+	ID            string
+	Message       string // representative error message (first lines of the stack trace)
+	Service       string
+	Version       string
+	Count         int64
+	AffectedUsers int64
+	FirstSeen     time.Time
+	LastSeen      time.Time
+	Resolution    string // OPEN, ACKNOWLEDGED, RESOLVED or MUTED
+}
+
+// errorPeriods maps look-back hours to the periods Error Reporting supports.
+var errorPeriods = []struct {
+	hours  int
+	period string
+}{
+	{1, "PERIOD_1_HOUR"}, {6, "PERIOD_6_HOURS"}, {24, "PERIOD_1_DAY"}, {24 * 7, "PERIOD_1_WEEK"}, {24 * 30, "PERIOD_30_DAYS"},
+}
+
+// ListErrorGroups returns error groups seen in the last hours (rounded up
+// to 1h, 6h, 1d, 1w or 30d), most recently seen first, optionally for one
+// service.
+func (c *Client) ListErrorGroups(ctx context.Context, service string, hours, limit int) ([]ErrorGroup, error) {
+	period := errorPeriods[len(errorPeriods)-1].period
+	for _, p := range errorPeriods {
+		if hours <= p.hours {
+			period = p.period
+			break
+		}
+	}
+	q := url.Values{
+		"timeRange.period": {period},
+		"order":            {"LAST_SEEN_DESC"},
+		"pageSize":         {fmt.Sprint(max(limit, 1))},
+	}
+	if service != "" {
+		q.Set("serviceFilter.service", service)
+	}
+	var resp struct {
+		ErrorGroupStats []struct {
+			Group struct {
+				GroupID          string `json:"groupId"`
+				ResolutionStatus string `json:"resolutionStatus"`
+			} `json:"group"`
+			Count              string    `json:"count"`
+			AffectedUsersCount string    `json:"affectedUsersCount"`
+			FirstSeenTime      time.Time `json:"firstSeenTime"`
+			LastSeenTime       time.Time `json:"lastSeenTime"`
+			Representative     struct {
+				Message        string `json:"message"`
+				ServiceContext struct {
+					Service string `json:"service"`
+					Version string `json:"version"`
+				} `json:"serviceContext"`
+			} `json:"representative"`
+		} `json:"errorGroupStats"`
+	}
+	u := fmt.Sprintf("%s/projects/%s/groupStats?%s", errorReportingAPI, url.PathEscape(c.project), q.Encode())
+	if err := c.get(ctx, u, &resp); err != nil {
+		return nil, err
+	}
+	var out []ErrorGroup
+	for _, g := range resp.ErrorGroupStats {
+		eg := ErrorGroup{
+			ID:         g.Group.GroupID,
+			Message:    g.Representative.Message,
+			Service:    g.Representative.ServiceContext.Service,
+			Version:    g.Representative.ServiceContext.Version,
+			FirstSeen:  g.FirstSeenTime,
+			LastSeen:   g.LastSeenTime,
+			Resolution: g.Group.ResolutionStatus,
+		}
+		// int64 fields are JSON strings in Google APIs.
+		_, _ = fmt.Sscan(g.Count, &eg.Count)
+		_, _ = fmt.Sscan(g.AffectedUsersCount, &eg.AffectedUsers)
+		out = append(out, eg)
+	}
+	return out, nil
+}
+
+// ErrorGroupURL returns the Cloud Console URL of an error group.
+func (c *Client) ErrorGroupURL(id string) string {
+	return fmt.Sprintf("https://console.cloud.google.com/errors/detail/%s?project=%s", url.PathEscape(id), url.QueryEscape(c.project))
+}
@@ -0,0 +1,207 @@
+package gcp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+const containerAPI = "https://container.googleapis.com/v1"
+
+// Workload is a Kubernetes Deployment, StatefulSet or DaemonSet on GKE.
+type Workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Desired   int
+	Ready     int
+	Updated   int
+	Available int
+	Images    []string
+	Problems  []string // failing conditions, e.g. "Progressing: ProgressDeadlineExceeded"
+	Generated bool     // the controller has observed the latest spec
+}
+
+// gkeCluster is the connection info of a cluster's Kubernetes API.
+type gkeCluster struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// ListWorkloads returns the Deployments, StatefulSets and DaemonSets of a
+// GKE cluster, optionally in one namespace and filtered by name substring.
+// It uses the Kubernetes API with the caller's Google identity, so the
+// identity needs Kubernetes Engine Viewer (or equivalent RBAC).
+func (c *Client) ListWorkloads(ctx context.Context, location, cluster, namespace, search string) ([]Workload, error) {
+	loc, err := c.location(location)
+	if err != nil {
+		return nil, err
+	}
+	kc, err := c.cluster(ctx, loc, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Workload
+	for _, kind := range []struct{ name, resource string }{
+		{"Deployment", "deployments"}, {"StatefulSet", "statefulsets"}, {"DaemonSet", "daemonsets"},
+	} {
+		p := "/apis/apps/v1/" + kind.resource
+		if namespace != "" {
+			p = "/apis/apps/v1/namespaces/" + url.PathEscape(namespace) + "/" + kind.resource
+		}
+		var list struct {
+			Items []k8sWorkload `json:"items"`
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, kc.endpoint+p+"?limit=500", nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		if err := doJSON(kc.httpClient, req, &list); err != nil {
+			return nil, fmt.Errorf("list %s: %w", kind.resource, err)
+		}
+		for _, it := range list.Items {
+			if search != "" && !strings.Contains(strings.ToLower(it.Metadata.Name), strings.ToLower(search)) {
+				continue
+			}
+			out = append(out, it.toWorkload(kind.name))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// cluster returns (and caches) the API endpoint and a client trusting the
+// cluster CA.
+func (c *Client) cluster(ctx context.Context, location, name string) (*gkeCluster, error) {
+	key := location + "/" + name
+	c.mu.Lock()
+	kc := c.clusters[key]
+	c.mu.Unlock()
+	if kc != nil {
+		return kc, nil
+	}
+
+	var cl struct {
+		Endpoint   string `json:"endpoint"`
+		MasterAuth struct {
+			ClusterCACertificate string `json:"clusterCaCertificate"`
+		} `json:"masterAuth"`
+	}
+	u := fmt.Sprintf("%s/projects/%s/locations/%s/clusters/%s", containerAPI, url.PathEscape(c.project), url.PathEscape(location), url.PathEscape(name))
+	if err := c.get(ctx, u, &cl); err != nil {
+		return nil, err
+	}
+	caPEM, err := base64.StdEncoding.DecodeString(cl.MasterAuth.ClusterCACertificate)
+	if err != nil {
+		return nil, fmt.Errorf("decode cluster CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("cluster %s has no valid CA certificate", name)
+	}
+	kc = &gkeCluster{
+		endpoint: "https://" + cl.Endpoint,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &oauth2.Transport{
+				Source: c.ts,
+				Base: &metrics.Transport{
+					Integration: "gke",
+					Base:        &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+				},
+			},
+		},
+	}
+	c.mu.Lock()
+	c.clusters[key] = kc
+	c.mu.Unlock()
+	return kc, nil
+}
+
+type k8sWorkload struct {
+	Metadata struct {
+		Name       string `json:"name"`
+		Namespace  string `json:"namespace"`
+		Generation int64  `json:"generation"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Template struct {
+			Spec struct {
+				Containers []struct {
+					Image string `json:"image"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		ObservedGeneration int64 `json:"observedGeneration"`
+		ReadyReplicas      int   `json:"readyReplicas"`
+		UpdatedReplicas    int   `json:"updatedReplicas"`
+		AvailableReplicas  int   `json:"availableReplicas"`
+		// DaemonSet counts
+		DesiredNumberScheduled int `json:"desiredNumberScheduled"`
+		NumberReady            int `json:"numberReady"`
+		UpdatedNumberScheduled int `json:"updatedNumberScheduled"`
+		NumberAvailable        int `json:"numberAvailable"`
+		Conditions             []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+func (w k8sWorkload) toWorkload(kind string) Workload {
+	out := Workload{
+		Kind:      kind,
+		Namespace: w.Metadata.Namespace,
+		Name:      w.Metadata.Name,
+		Generated: w.Status.ObservedGeneration >= w.Metadata.Generation,
+	}
+	st := w.Status
+	if kind == "DaemonSet" {
+		out.Desired, out.Ready, out.Updated, out.Available = st.DesiredNumberScheduled, st.NumberReady, st.UpdatedNumberScheduled, st.NumberAvailable
+	} else {
+		out.Desired = 1
+		if w.Spec.Replicas != nil {
+			out.Desired = *w.Spec.Replicas
+		}
+		out.Ready, out.Updated, out.Available = st.ReadyReplicas, st.UpdatedReplicas, st.AvailableReplicas
+		if kind == "StatefulSet" && out.Available == 0 {
+			out.Available = st.ReadyReplicas
+		}
+	}
+	for _, ct := range w.Spec.Template.Spec.Containers {
+		out.Images = append(out.Images, ct.Image)
+	}
+	for _, cond := range st.Conditions {
+		failing := cond.Status == "False" || (cond.Type == "ReplicaFailure" && cond.Status == "True")
+		if failing {
+			msg := cond.Type + ": " + cond.Reason
+			if cond.Message != "" {
+				msg += " — " + cond.Message
+			}
+			out.Problems = append(out.Problems, msg)
+		}
+	}
+	return out
+}
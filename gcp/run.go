@@ -0,0 +1,132 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+)
+
+const cloudRunAPI = "https://run.googleapis.com/v2"
+
+// RunService is a Cloud Run service and where its traffic goes.
+type RunService struct {
+	Name           string
+	URL            string
+	LatestReady    string
+	LatestCreated  string
+	Ready          string // condition state, e.g. CONDITION_SUCCEEDED
+	ReadyMessage   string
+	TrafficPercent map[string]int // revision → percent
+	LastModifier   string
+	UpdateTime     time.Time
+}
+
+// RunRevision is a Cloud Run revision.
+type RunRevision struct {
+	Name         string
+	CreateTime   time.Time
+	Image        string
+	Ready        bool
+	Message      string // reason the revision is not ready, if any
+	MinInstances int
+	MaxInstances int
+}
+
+// GetRunService returns a Cloud Run service in location (default: the
+// client's region).
+func (c *Client) GetRunService(ctx context.Context, location, service string) (*RunService, error) {
+	loc, err := c.location(location)
+	if err != nil {
+		return nil, err
+	}
+	var s struct {
+		Name              string    `json:"name"`
+		URI               string    `json:"uri"`
+		LatestReady       string    `json:"latestReadyRevision"`
+		LatestCreated     string    `json:"latestCreatedRevision"`
+		LastModifier      string    `json:"lastModifier"`
+		UpdateTime        time.Time `json:"updateTime"`
+		TerminalCondition condition `json:"terminalCondition"`
+		TrafficStatuses   []struct {
+			Type     string `json:"type"`
+			Revision string `json:"revision"`
+			Percent  int    `json:"percent"`
+		} `json:"trafficStatuses"`
+	}
+	u := fmt.Sprintf("%s/projects/%s/locations/%s/services/%s", cloudRunAPI, url.PathEscape(c.project), url.PathEscape(loc), url.PathEscape(service))
+	if err := c.get(ctx, u, &s); err != nil {
+		return nil, err
+	}
+	out := &RunService{
+		Name:           path.Base(s.Name),
+		URL:            s.URI,
+		LatestReady:    path.Base(s.LatestReady),
+		LatestCreated:  path.Base(s.LatestCreated),
+		Ready:          s.TerminalCondition.State,
+		ReadyMessage:   s.TerminalCondition.Message,
+		TrafficPercent: map[string]int{},
+		LastModifier:   s.LastModifier,
+		UpdateTime:     s.UpdateTime,
+	}
+	for _, t := range s.TrafficStatuses {
+		rev := path.Base(t.Revision)
+		if t.Type == "TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST" || rev == "." || rev == "" {
+			rev = out.LatestReady
+		}
+		out.TrafficPercent[rev] += t.Percent
+	}
+	return out, nil
+}
+
+// ListRunRevisions returns the revisions of a Cloud Run service, newest
+// first.
+func (c *Client) ListRunRevisions(ctx context.Context, location, service string, limit int) ([]RunRevision, error) {
+	loc, err := c.location(location)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Revisions []struct {
+			Name       string    `json:"name"`
+			CreateTime time.Time `json:"createTime"`
+			Containers []struct {
+				Image string `json:"image"`
+			} `json:"containers"`
+			Scaling struct {
+				Min int `json:"minInstanceCount"`
+				Max int `json:"maxInstanceCount"`
+			} `json:"scaling"`
+			Conditions []condition `json:"conditions"`
+		} `json:"revisions"`
+	}
+	u := fmt.Sprintf("%s/projects/%s/locations/%s/services/%s/revisions?pageSize=%d", cloudRunAPI,
+		url.PathEscape(c.project), url.PathEscape(loc), url.PathEscape(service), max(limit, 1))
+	if err := c.get(ctx, u, &resp); err != nil {
+		return nil, err
+	}
+	var out []RunRevision
+	for _, r := range resp.Revisions {
+		rev := RunRevision{Name: path.Base(r.Name), CreateTime: r.CreateTime, Ready: true, MinInstances: r.Scaling.Min, MaxInstances: r.Scaling.Max}
+		if len(r.Containers) > 0 {
+			rev.Image = r.Containers[0].Image
+		}
+		for _, cond := range r.Conditions {
+			if cond.Type == "Ready" && cond.State != "CONDITION_SUCCEEDED" {
+				rev.Ready = false
+				rev.Message = cond.Message
+			}
+		}
+		out = append(out, rev)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreateTime.After(out[j].CreateTime) })
+	return out, nil
+}
+
+type condition struct {
+	Type    string `json:"type"`
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,246 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicAPIURL  = "https://api.anthropic.com/v1/messages"
+	anthropicVersion = "2023-06-01"
+
+	// anthropicMaxTokens is the output token cap sent with every request;
+	// the Messages API requires max_tokens to be set explicitly.
+	anthropicMaxTokens = 8192
+)
+
+// ---------------------------------------------------------------------------
+// Anthropic Messages API support
+// ---------------------------------------------------------------------------
+
+// anthropicRequest is the request body for the Anthropic Messages API.
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens int                `json:"max_tokens"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a content block: text, tool_use (assistant) or tool_result (user).
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// chatToolsToAnthropicTools converts Chat Completions tool definitions to the
+// Anthropic format (name/description/input_schema at the top level).
+func chatToolsToAnthropicTools(tools []Tool) []anthropicTool {
+	if len(tools) == 0 {
+		return nil
+	}
+	out := make([]anthropicTool, len(tools))
+	for i, t := range tools {
+		schema := t.Function.Parameters
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		out[i] = anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		}
+	}
+	return out
+}
+
+// chatMessagesToAnthropic converts Chat Completions messages to the Anthropic
+// format. System messages become the top-level system prompt, assistant tool
+// calls become tool_use blocks, and tool results become tool_result blocks in
+// a user turn. Consecutive messages with the same role are merged because the
+// Messages API requires user/assistant turns to alternate.
+func chatMessagesToAnthropic(messages []ChatMessage) (string, []anthropicMessage) {
+	var systemParts []string
+	var out []anthropicMessage
+
+	appendBlocks := func(role string, blocks ...anthropicBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+			return
+		}
+		out = append(out, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
+		case "assistant":
+			var blocks []anthropicBlock
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage(`{}`)
+				}
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: input,
+				})
+			}
+			appendBlocks("assistant", blocks...)
+		case "tool":
+			appendBlocks("user", anthropicBlock{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   msg.Content,
+			})
+		default:
+			if msg.Content != "" {
+				appendBlocks("user", anthropicBlock{Type: "text", Text: msg.Content})
+			}
+		}
+	}
+
+	return strings.Join(systemParts, "\n\n"), out
+}
+
+// anthropicToChatResponse converts an Anthropic response into the ChatResponse
+// format used by the rest of the codebase.
+func anthropicToChatResponse(ar *anthropicResponse) *ChatResponse {
+	cr := &ChatResponse{}
+
+	var choice struct {
+		Message struct {
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	}
+
+	var textParts []string
+	for _, block := range ar.Content {
+		switch block.Type {
+		case "text":
+			textParts = append(textParts, block.Text)
+		case "tool_use":
+			tc := ToolCall{ID: block.ID, Type: "function"}
+			tc.Function.Name = block.Name
+			tc.Function.Arguments = string(block.Input)
+			if tc.Function.Arguments == "" {
+				tc.Function.Arguments = "{}"
+			}
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, tc)
+		}
+	}
+
+	choice.Message.Content = strings.Join(textParts, "")
+	switch {
+	case len(choice.Message.ToolCalls) > 0:
+		choice.FinishReason = "tool_calls"
+	case ar.StopReason == "max_tokens":
+		choice.FinishReason = "length"
+	default:
+		choice.FinishReason = "stop"
+	}
+
+	cr.Choices = append(cr.Choices, choice)
+	if ar.Usage != nil {
+		cr.Usage = &Usage{PromptTokens: ar.Usage.InputTokens, CompletionTokens: ar.Usage.OutputTokens}
+	}
+	return cr
+}
+
+// doAnthropic calls the Anthropic Messages API.
+func (m *ModelsClient) doAnthropic(ctx context.Context, messages []ChatMessage, tools []Tool) (*ChatResponse, error) {
+	system, msgs := chatMessagesToAnthropic(messages)
+
+	reqBody := anthropicRequest{
+		Model:     m.model,
+		System:    system,
+		Messages:  msgs,
+		Tools:     chatToolsToAnthropicTools(tools),
+		MaxTokens: anthropicMaxTokens,
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anthropic request: %w", err)
+	}
+
+	apiURL := anthropicAPIURL
+	if m.baseURL != "" {
+		apiURL = m.baseURL + "/v1/messages"
+	}
+
+	status, body, err := m.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create anthropic request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", m.token)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anthropic API request failed: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("anthropic API returned %d: %s", status, string(body))
+	}
+
+	var ar anthropicResponse
+	if err := json.Unmarshal(body, &ar); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal anthropic response: %w", err)
+	}
+
+	if ar.Error != nil {
+		return nil, fmt.Errorf("anthropic API error: %s", ar.Error.Message)
+	}
+
+	return anthropicToChatResponse(&ar), nil
+}
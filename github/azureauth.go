@@ -0,0 +1,203 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cognitiveServicesScope is the Entra ID scope for Azure OpenAI data-plane calls.
+	cognitiveServicesScope    = "https://cognitiveservices.azure.com/.default"
+	cognitiveServicesResource = "https://cognitiveservices.azure.com"
+
+	entraTokenURLTemplate = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	imdsTokenURL          = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	// tokenRefreshSkew refreshes tokens this long before they expire.
+	tokenRefreshSkew = 5 * time.Minute
+)
+
+// AzureCredential obtains Entra ID (Azure AD) access tokens for Azure OpenAI
+// (or another resource, see WithScope), mirroring the DefaultAzureCredential
+// chain:
+//  1. Client secret — AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET
+//  2. Workload identity — AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_FEDERATED_TOKEN_FILE (AKS)
+//  3. Managed identity — instance metadata service (optionally AZURE_CLIENT_ID for a user-assigned identity)
+//
+// Tokens are cached and refreshed shortly before expiry. Safe for concurrent use.
+type AzureCredential struct {
+	tenantID           string
+	clientID           string
+	clientSecret       string
+	federatedTokenFile string
+	scope              string // Entra ID scope; "" = Azure OpenAI
+	httpClient         *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewAzureCredentialFromEnv builds an AzureCredential from the standard
+// AZURE_* environment variables used by the Azure SDKs.
+func NewAzureCredentialFromEnv() *AzureCredential {
+	return &AzureCredential{
+		tenantID:           os.Getenv("AZURE_TENANT_ID"),
+		clientID:           os.Getenv("AZURE_CLIENT_ID"),
+		clientSecret:       os.Getenv("AZURE_CLIENT_SECRET"),
+		federatedTokenFile: os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		httpClient:         &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithScope returns a credential using the same identity for another
+// resource, e.g. "https://graph.microsoft.com/.default" for Microsoft Graph.
+func (c *AzureCredential) WithScope(scope string) *AzureCredential {
+	return &AzureCredential{
+		tenantID:           c.tenantID,
+		clientID:           c.clientID,
+		clientSecret:       c.clientSecret,
+		federatedTokenFile: c.federatedTokenFile,
+		scope:              scope,
+		httpClient:         c.httpClient,
+	}
+}
+
+// scopeAndResource returns the v2 scope and the IMDS resource of the
+// credential's target.
+func (c *AzureCredential) scopeAndResource() (string, string) {
+	if c.scope == "" {
+		return cognitiveServicesScope, cognitiveServicesResource
+	}
+	return c.scope, strings.TrimSuffix(c.scope, "/.default")
+}
+
+// Method returns a human-readable name for the credential source in use.
+func (c *AzureCredential) Method() string {
+	switch {
+	case c.tenantID != "" && c.clientID != "" && c.clientSecret != "":
+		return "Entra ID (client secret)"
+	case c.tenantID != "" && c.clientID != "" && c.federatedTokenFile != "":
+		return "Entra ID (workload identity)"
+	default:
+		return "Entra ID (managed identity)"
+	}
+}
+
+// Token returns a valid access token, fetching a new one when the cached token
+// is missing or about to expire.
+func (c *AzureCredential) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenRefreshSkew).Before(c.expiry) {
+		return c.token, nil
+	}
+
+	var (
+		token     string
+		expiresIn time.Duration
+		err       error
+	)
+	scope, resource := c.scopeAndResource()
+	switch {
+	case c.tenantID != "" && c.clientID != "" && c.clientSecret != "":
+		token, expiresIn, err = c.fetchEntraToken(ctx, url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {c.clientID},
+			"client_secret": {c.clientSecret},
+			"scope":         {scope},
+		})
+	case c.tenantID != "" && c.clientID != "" && c.federatedTokenFile != "":
+		assertion, readErr := os.ReadFile(c.federatedTokenFile)
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read federated token file: %w", readErr)
+		}
+		token, expiresIn, err = c.fetchEntraToken(ctx, url.Values{
+			"grant_type":            {"client_credentials"},
+			"client_id":             {c.clientID},
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+			"scope":                 {scope},
+		})
+	default:
+		token, expiresIn, err = c.fetchManagedIdentityToken(ctx, resource)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.expiry = time.Now().Add(expiresIn)
+	return c.token, nil
+}
+
+// fetchEntraToken exchanges credentials for a token at the Entra ID v2 token endpoint.
+func (c *AzureCredential) fetchEntraToken(ctx context.Context, form url.Values) (string, time.Duration, error) {
+	tokenURL := fmt.Sprintf(entraTokenURLTemplate, url.PathEscape(c.tenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create Entra ID token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.doTokenRequest(req)
+}
+
+// fetchManagedIdentityToken requests a token from the Azure instance metadata service.
+func (c *AzureCredential) fetchManagedIdentityToken(ctx context.Context, resource string) (string, time.Duration, error) {
+	params := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {resource},
+	}
+	if c.clientID != "" {
+		params.Set("client_id", c.clientID) // user-assigned identity
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsTokenURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create managed identity token request: %w", err)
+	}
+	req.Header.Set("Metadata", "true")
+	return c.doTokenRequest(req)
+}
+
+func (c *AzureCredential) doTokenRequest(req *http.Request) (string, time.Duration, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("Entra ID token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read Entra ID token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("Entra ID token endpoint returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	// expires_in is a number from the v2 endpoint but a string from IMDS.
+	var tr struct {
+		AccessToken string          `json:"access_token"`
+		ExpiresIn   json.RawMessage `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", 0, fmt.Errorf("failed to parse Entra ID token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, fmt.Errorf("Entra ID token response contained no access_token")
+	}
+	secs, err := strconv.Atoi(strings.Trim(string(tr.ExpiresIn), `"`))
+	if err != nil || secs <= 0 {
+		secs = 3600
+	}
+	return tr.AccessToken, time.Duration(secs) * time.Second, nil
+}
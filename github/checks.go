@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// maxCheckAnnotations bounds the annotations fetched per failing check run.
+const maxCheckAnnotations = 10
+
+// Check is a check run or legacy commit status reported on a commit, from
+// GitHub Actions or an external CI such as Buildkite or CircleCI.
+type Check struct {
+	Name        string
+	Kind        string // "check_run" or "status"
+	App         string // app that reported a check run, e.g. "GitHub Actions", "Buildkite"
+	Status      string // "queued", "in_progress" or "completed"
+	Conclusion  string // "success", "failure", "cancelled", ... once completed; statuses use "success", "failure" or "error"
+	Title       string // check run output title
+	Summary     string // check run output summary, or the status description
+	URL         string // details page on the reporting system
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Annotations []WorkflowAnnotation // failing check runs only
+}
+
+// CommitChecks holds every check run and commit status on a commit.
+type CommitChecks struct {
+	SHA    string
+	Checks []Check
+}
+
+// PullRequestHeadSHA returns the SHA of a pull request's head commit.
+func (c *Client) PullRequestHeadSHA(ctx context.Context, owner, repo string, number int) (string, error) {
+	pr, _, err := c.api.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return "", fmt.Errorf("failed to get PR #%d: %w", number, err)
+	}
+	return pr.GetHead().GetSHA(), nil
+}
+
+// ListCommitChecks returns the latest check runs and commit statuses on ref
+// (a SHA, branch or tag), sorted by name. Failing check runs carry up to
+// maxCheckAnnotations annotations.
+func (c *Client) ListCommitChecks(ctx context.Context, owner, repo, ref string) (*CommitChecks, error) {
+	out := &CommitChecks{}
+	opts := &gh.ListCheckRunsOptions{Filter: gh.String("latest"), ListOptions: gh.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := c.api.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list check runs on %s: %w", ref, err)
+		}
+		for _, run := range runs.CheckRuns {
+			if out.SHA == "" {
+				out.SHA = run.GetHeadSHA()
+			}
+			ch := Check{
+				Name:        run.GetName(),
+				Kind:        "check_run",
+				App:         run.GetApp().GetName(),
+				Status:      run.GetStatus(),
+				Conclusion:  run.GetConclusion(),
+				Title:       run.GetOutput().GetTitle(),
+				Summary:     run.GetOutput().GetSummary(),
+				URL:         run.GetDetailsURL(),
+				StartedAt:   run.GetStartedAt().Time,
+				CompletedAt: run.GetCompletedAt().Time,
+			}
+			if ch.URL == "" {
+				ch.URL = run.GetHTMLURL()
+			}
+			if CheckFailed(ch) && run.GetOutput().GetAnnotationsCount() > 0 {
+				annotations, _, err := c.api.Checks.ListCheckRunAnnotations(ctx, owner, repo, run.GetID(), &gh.ListOptions{PerPage: maxCheckAnnotations})
+				if err == nil {
+					for _, ann := range annotations {
+						ch.Annotations = append(ch.Annotations, WorkflowAnnotation{
+							JobName: ch.Name,
+							Level:   ann.GetAnnotationLevel(),
+							Message: ann.GetMessage(),
+							Title:   ann.GetTitle(),
+						})
+					}
+				}
+			}
+			out.Checks = append(out.Checks, ch)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	combined, _, err := c.api.Repositories.GetCombinedStatus(ctx, owner, repo, ref, &gh.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit statuses on %s: %w", ref, err)
+	}
+	if sha := combined.GetSHA(); sha != "" {
+		out.SHA = sha
+	}
+	for _, st := range combined.Statuses {
+		ch := Check{
+			Name:      st.GetContext(),
+			Kind:      "status",
+			Status:    "completed",
+			Summary:   st.GetDescription(),
+			URL:       st.GetTargetURL(),
+			StartedAt: st.GetCreatedAt().Time,
+		}
+		if st.GetState() == "pending" {
+			ch.Status = "in_progress"
+		} else {
+			ch.Conclusion = st.GetState()
+			ch.CompletedAt = st.GetUpdatedAt().Time
+		}
+		out.Checks = append(out.Checks, ch)
+	}
+	sort.SliceStable(out.Checks, func(i, j int) bool { return out.Checks[i].Name < out.Checks[j].Name })
+	return out, nil
+}
+
+// CheckFailed reports whether a completed check did not pass. Neutral and
+// skipped check runs count as passed, as they do for merge gating.
+func CheckFailed(ch Check) bool {
+	if ch.Status != "completed" {
+		return false
+	}
+	switch ch.Conclusion {
+	case "success", "neutral", "skipped":
+		return false
+	}
+	return true
+}
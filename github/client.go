@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -12,17 +11,33 @@ import (
 	"time"
 
 	gh "github.com/google/go-github/v60/github"
+	"github.com/justmike1/ovad/metrics"
 	"golang.org/x/oauth2"
 )
 
 type Client struct {
-	api *gh.Client
+	api         *gh.Client
+	fineGrained bool
+
+	logs LogScanner // failed job log extraction; see SetJobLogOptions
 }
 
 func NewClient(token string) *Client {
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 	httpClient := oauth2.NewClient(context.Background(), ts)
-	return &Client{api: gh.NewClient(httpClient)}
+	httpClient.Transport = &metrics.Transport{Integration: "github", Base: httpClient.Transport}
+	return &Client{api: gh.NewClient(httpClient), fineGrained: IsFineGrainedToken(token)}
+}
+
+// IsFineGrainedToken returns true for fine-grained personal access tokens,
+// which carry repository permissions instead of classic OAuth scopes.
+func IsFineGrainedToken(token string) bool {
+	return strings.HasPrefix(token, "github_pat_")
+}
+
+// IsFineGrained returns true when the client authenticates with a fine-grained PAT.
+func (c *Client) IsFineGrained() bool {
+	return c.fineGrained
 }
 
 func (c *Client) GetAuthenticatedUser(ctx context.Context) (string, error) {
@@ -54,6 +69,79 @@ func (c *Client) GetGrantedScopes(ctx context.Context) ([]string, error) {
 	return scopes, nil
 }
 
+// PermissionProbe is the outcome of probing a single fine-grained permission.
+type PermissionProbe struct {
+	Granted  bool
+	Accepted string // X-Accepted-GitHub-Permissions header from a denied request, e.g. "actions=read"
+}
+
+// ProbeFineGrainedPermissions checks which read permissions a token has by
+// calling one cheap endpoint per API area against a repository the token can
+// see. Fine-grained tokens have no X-OAuth-Scopes header, so this is the only
+// way to learn what they can do. Write permissions can't be probed without
+// side effects and are not included. Areas whose probe fails for reasons other
+// than 403/404 are omitted (unknown).
+func (c *Client) ProbeFineGrainedPermissions(ctx context.Context) (map[string]PermissionProbe, error) {
+	results := make(map[string]PermissionProbe)
+
+	repos, resp, err := c.api.Repositories.ListByAuthenticatedUser(ctx, &gh.RepositoryListByAuthenticatedUserOptions{
+		ListOptions: gh.ListOptions{PerPage: 1},
+	})
+	if p, ok := probeResult(resp, err); ok {
+		results["metadata:read"] = p
+	}
+	if err != nil {
+		return results, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	if len(repos) == 0 {
+		return results, nil // no repository selected for the token — nothing else to probe
+	}
+	owner, repo := repos[0].GetOwner().GetLogin(), repos[0].GetName()
+	branch := repos[0].GetDefaultBranch()
+
+	_, _, resp, err = c.api.Repositories.GetContents(ctx, owner, repo, "", &gh.RepositoryContentGetOptions{Ref: branch})
+	if p, ok := probeResult(resp, err); ok {
+		results["contents:read"] = p
+	}
+	_, resp, err = c.api.PullRequests.List(ctx, owner, repo, &gh.PullRequestListOptions{ListOptions: gh.ListOptions{PerPage: 1}})
+	if p, ok := probeResult(resp, err); ok {
+		results["pull_requests:read"] = p
+	}
+	_, resp, err = c.api.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, &gh.ListWorkflowRunsOptions{ListOptions: gh.ListOptions{PerPage: 1}})
+	if p, ok := probeResult(resp, err); ok {
+		results["actions:read"] = p
+	}
+	if branch != "" {
+		_, resp, err = c.api.Checks.ListCheckRunsForRef(ctx, owner, repo, branch, &gh.ListCheckRunsOptions{ListOptions: gh.ListOptions{PerPage: 1}})
+		if p, ok := probeResult(resp, err); ok {
+			results["checks:read"] = p
+		}
+	}
+	if repos[0].GetOwner().GetType() == "Organization" {
+		_, resp, err = c.api.Organizations.ListMembers(ctx, owner, &gh.ListMembersOptions{ListOptions: gh.ListOptions{PerPage: 1}})
+		if p, ok := probeResult(resp, err); ok {
+			results["members:read"] = p
+		}
+	}
+	return results, nil
+}
+
+// probeResult interprets a probe response: success means granted, 403/404
+// means denied, anything else is inconclusive (ok=false).
+func probeResult(resp *gh.Response, err error) (PermissionProbe, bool) {
+	if err == nil {
+		return PermissionProbe{Granted: true}, true
+	}
+	if resp == nil {
+		return PermissionProbe{}, false
+	}
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound:
+		return PermissionProbe{Accepted: resp.Header.Get("X-Accepted-GitHub-Permissions")}, true
+	}
+	return PermissionProbe{}, false
+}
+
 func (c *Client) ResolveOwner(ctx context.Context) (string, error) {
 	user, _, err := c.api.Users.Get(ctx, "")
 	if err != nil {
@@ -74,6 +162,12 @@ func (c *Client) GetFileContent(ctx context.Context, owner, repo, path, branch s
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get file %s: %w", path, err)
 	}
+	if file == nil {
+		return "", "", fmt.Errorf("path %s is a directory, not a file", path)
+	}
+	if file.GetType() == "submodule" {
+		return "", "", fmt.Errorf("path %s is a git submodule (%s); read it from that repository instead", path, file.GetSubmoduleGitURL())
+	}
 
 	content, err := base64.StdEncoding.DecodeString(*file.Content)
 	if err != nil {
@@ -124,6 +218,61 @@ func (c *Client) UpdateFile(ctx context.Context, owner, repo, path, branch, mess
 	return nil
 }
 
+// FileChange is one file in a multi-file commit. Delete removes the file.
+type FileChange struct {
+	Path    string
+	Content []byte
+	Delete  bool
+}
+
+// CommitFiles lands all changes on branch as a single commit using the Git
+// data API (blobs, tree, commit, ref update). The ref update is not forced,
+// so it fails instead of overwriting commits pushed to branch meanwhile.
+func (c *Client) CommitFiles(ctx context.Context, owner, repo, branch, message string, files []FileChange) error {
+	ref, _, err := c.api.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	if err != nil {
+		return fmt.Errorf("failed to get ref for %s: %w", branch, err)
+	}
+	parent, _, err := c.api.Git.GetCommit(ctx, owner, repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return fmt.Errorf("failed to get head commit of %s: %w", branch, err)
+	}
+
+	entries := make([]*gh.TreeEntry, 0, len(files))
+	for _, f := range files {
+		entry := &gh.TreeEntry{Path: gh.String(f.Path), Mode: gh.String("100644"), Type: gh.String("blob")}
+		if !f.Delete {
+			blob, _, err := c.api.Git.CreateBlob(ctx, owner, repo, &gh.Blob{
+				Content:  gh.String(base64.StdEncoding.EncodeToString(f.Content)),
+				Encoding: gh.String("base64"),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create blob for %s: %w", f.Path, err)
+			}
+			entry.SHA = blob.SHA
+		}
+		entries = append(entries, entry)
+	}
+
+	tree, _, err := c.api.Git.CreateTree(ctx, owner, repo, parent.GetTree().GetSHA(), entries)
+	if err != nil {
+		return fmt.Errorf("failed to create tree: %w", err)
+	}
+	commit, _, err := c.api.Git.CreateCommit(ctx, owner, repo, &gh.Commit{
+		Message: gh.String(message),
+		Tree:    tree,
+		Parents: []*gh.Commit{{SHA: parent.SHA}},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create commit: %w", err)
+	}
+	ref.Object.SHA = commit.SHA
+	if _, _, err := c.api.Git.UpdateRef(ctx, owner, repo, ref, false); err != nil {
+		return fmt.Errorf("failed to update %s (it may have moved; retry): %w", branch, err)
+	}
+	return nil
+}
+
 func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, baseBranch, headBranch, title, body string) (string, error) {
 	pr := &gh.NewPullRequest{
 		Title: gh.String(title),
@@ -191,8 +340,11 @@ func (c *Client) GetDirectoryContents(ctx context.Context, owner, repo, path, br
 	var entries []string
 	for _, entry := range dir {
 		name := entry.GetPath()
-		if entry.GetType() == "dir" {
+		switch entry.GetType() {
+		case "dir":
 			name += "/"
+		case "submodule":
+			name += " (submodule)"
 		}
 		entries = append(entries, name)
 	}
@@ -274,6 +426,11 @@ type PRSummary struct {
 	Body      string
 	Diff      string
 	FileNames []string
+
+	// Set by ListPullRequestDetails only.
+	Draft          bool
+	ReviewDecision string // "approved", "changes requested", "review required", or "" when no review is required
+	Checks         string // combined check state: "success", "failure", "pending", ... or "" when none ran
 }
 
 // GetPullRequest fetches a PR's details and diff.
@@ -460,7 +617,13 @@ type WorkflowJobSummary struct {
 	Status     string
 	Conclusion string
 	Steps      []WorkflowStepSummary
-	LogContent string // Populated for failed jobs only.
+
+	// Populated for failed jobs only, within the summary's log budget.
+	ErrorLines []string // log lines matching an error pattern, as "L<n>: text"
+	ErrorTotal int      // matching lines before trimming to the budget
+	LogTail    string   // last lines of the log
+	LogLines   int
+	LogError   string // why the log could not be fetched, or was skipped
 }
 
 type WorkflowStepSummary struct {
@@ -495,6 +658,14 @@ func (c *Client) GetWorkflowRunSummary(ctx context.Context, owner, repo string,
 		return summary, fmt.Errorf("failed to list jobs for run %d: %w", runID, err)
 	}
 
+	failed := 0
+	for _, job := range jobs.Jobs {
+		if job.GetConclusion() == "failure" {
+			failed++
+		}
+	}
+	perJob, withLogs := c.logs.Budget(failed)
+
 	for _, job := range jobs.Jobs {
 		js := WorkflowJobSummary{
 			Name:       job.GetName(),
@@ -509,12 +680,16 @@ func (c *Client) GetWorkflowRunSummary(ctx context.Context, owner, repo string,
 			})
 		}
 
-		// Fetch actual log output for failed jobs.
+		// Fetch the full log of failed jobs and keep its error lines and tail.
 		if job.GetConclusion() == "failure" {
-			logContent, logErr := c.getJobLogs(ctx, owner, repo, job.GetID())
-			if logErr == nil {
-				js.LogContent = logContent
+			if withLogs == 0 {
+				js.LogError = "skipped: log budget used by other failed jobs"
+			} else if jl, logErr := c.getJobLog(ctx, owner, repo, job.GetID(), perJob); logErr != nil {
+				js.LogError = logErr.Error()
+			} else {
+				js.ErrorLines, js.ErrorTotal, js.LogTail, js.LogLines = jl.ErrorLines, jl.ErrorTotal, jl.Tail, jl.Lines
 			}
+			withLogs = max(withLogs-1, 0)
 		}
 
 		summary.Jobs = append(summary.Jobs, js)
@@ -555,34 +730,6 @@ func parseCheckRunID(checkRunURL string) int64 {
 	return id
 }
 
-const maxJobLogSize = 16000
-
-// getJobLogs downloads the plain-text log for a specific job run.
-func (c *Client) getJobLogs(ctx context.Context, owner, repo string, jobID int64) (string, error) {
-	logURL, _, err := c.api.Actions.GetWorkflowJobLogs(ctx, owner, repo, jobID, 2)
-	if err != nil {
-		return "", fmt.Errorf("failed to get log URL for job %d: %w", jobID, err)
-	}
-
-	resp, err := http.Get(logURL.String())
-	if err != nil {
-		return "", fmt.Errorf("failed to download job logs: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJobLogSize+1))
-	if err != nil {
-		return "", fmt.Errorf("failed to read job logs: %w", err)
-	}
-
-	content := string(body)
-	if len(content) > maxJobLogSize {
-		// Keep the tail — the error is usually at the end.
-		content = "... (log truncated, showing last portion) ...\n" + content[len(content)-maxJobLogSize:]
-	}
-	return content, nil
-}
-
 func FormatWorkflowRunSummary(s *WorkflowRunSummary) string {
 	var sb strings.Builder
 	fmt.Fprintf(&sb, "Workflow Run: %s (ID: %d)\n", s.Name, s.RunID)
@@ -621,14 +768,41 @@ func FormatWorkflowRunSummary(s *WorkflowRunSummary) string {
 	}
 
 	for _, job := range s.Jobs {
-		if job.LogContent != "" {
-			fmt.Fprintf(&sb, "\n--- Logs for failed job '%s' ---\n%s\n", job.Name, job.LogContent)
+		if job.LogError != "" {
+			fmt.Fprintf(&sb, "\n--- Logs for failed job '%s' unavailable (%s) ---\n", job.Name, job.LogError)
+			continue
+		}
+		if len(job.ErrorLines) > 0 {
+			fmt.Fprintf(&sb, "\n--- Error lines in failed job '%s' (%d of %d matches, %d log lines) ---\n", job.Name, len(job.ErrorLines), job.ErrorTotal, job.LogLines)
+			sb.WriteString(strings.Join(job.ErrorLines, "\n"))
+			sb.WriteString("\n")
+		}
+		if job.LogTail != "" {
+			fmt.Fprintf(&sb, "\n--- End of log for failed job '%s' ---\n%s\n", job.Name, job.LogTail)
 		}
 	}
 
 	return sb.String()
 }
 
+// IsPullRequestMerged reports whether a pull request has been merged.
+func (c *Client) IsPullRequestMerged(ctx context.Context, owner, repo string, number int) (bool, error) {
+	merged, _, err := c.api.PullRequests.IsMerged(ctx, owner, repo, number)
+	if err != nil {
+		return false, fmt.Errorf("failed to check PR #%d: %w", number, err)
+	}
+	return merged, nil
+}
+
+// CommentOnPullRequest posts a comment on a pull request's conversation.
+func (c *Client) CommentOnPullRequest(ctx context.Context, owner, repo string, number int, body string) error {
+	_, _, err := c.api.Issues.CreateComment(ctx, owner, repo, number, &gh.IssueComment{Body: gh.String(body)})
+	if err != nil {
+		return fmt.Errorf("failed to comment on PR #%d: %w", number, err)
+	}
+	return nil
+}
+
 // RerunFailedJobs re-runs only the failed jobs (and their dependents) in a workflow run.
 func (c *Client) RerunFailedJobs(ctx context.Context, owner, repo string, runID int64) error {
 	_, err := c.api.Actions.RerunFailedJobsByID(ctx, owner, repo, runID)
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ContentFilterError is returned when Azure OpenAI's content filter (or
+// Prompt Shields jailbreak detection) blocks a prompt or a completion.
+type ContentFilterError struct {
+	// Categories lists the filter categories that triggered, e.g. "jailbreak", "hate".
+	Categories []string
+	// Message is the raw error message returned by the API, if any.
+	Message string
+}
+
+func (e *ContentFilterError) Error() string {
+	if len(e.Categories) > 0 {
+		return fmt.Sprintf("request blocked by content policy (%s)", strings.Join(e.Categories, ", "))
+	}
+	return "request blocked by content policy"
+}
+
+// IsContentFiltered reports whether err (or any error it wraps) is a ContentFilterError.
+func IsContentFiltered(err error) bool {
+	var cfe *ContentFilterError
+	return errors.As(err, &cfe)
+}
+
+// contentFilterError inspects an Azure error body and returns a
+// ContentFilterError when it describes a content-filter block, or nil.
+//
+// Chat Completions and the Responses API both report blocked prompts as
+// HTTP 400 with error.code "content_filter"; the offending categories are in
+// error.innererror.content_filter_result.
+func contentFilterError(body []byte) *ContentFilterError {
+	var env struct {
+		Error struct {
+			Code       string `json:"code"`
+			Message    string `json:"message"`
+			InnerError struct {
+				Code                string                     `json:"code"`
+				ContentFilterResult map[string]json.RawMessage `json:"content_filter_result"`
+			} `json:"innererror"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil
+	}
+	if env.Error.Code != "content_filter" && env.Error.InnerError.Code != "ResponsibleAIPolicyViolation" {
+		return nil
+	}
+	return &ContentFilterError{
+		Categories: filteredCategories(env.Error.InnerError.ContentFilterResult),
+		Message:    env.Error.Message,
+	}
+}
+
+// filteredCategories returns the sorted names of categories marked
+// filtered (or detected, for jailbreak/indirect_attack shields).
+func filteredCategories(results map[string]json.RawMessage) []string {
+	var out []string
+	for name, raw := range results {
+		var r struct {
+			Filtered bool `json:"filtered"`
+			Detected bool `json:"detected"`
+		}
+		if json.Unmarshal(raw, &r) == nil && (r.Filtered || r.Detected) {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,91 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const modelsEmbeddingsURL = "https://models.github.ai/inference/embeddings"
+
+// Embed returns an embedding vector per input, in order, from the client's
+// model (an embedding model such as text-embedding-3-small). Anthropic has
+// no embeddings API.
+func (m *ModelsClient) Embed(ctx context.Context, inputs []string) (vectors [][]float64, err error) {
+	defer m.observe(time.Now(), &err)
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	var apiURL string
+	body := map[string]any{"model": m.model, "input": inputs}
+	switch {
+	case m.useAzure():
+		apiURL = fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s", m.azureEndpoint, m.model, azureAPIVersion)
+		delete(body, "model")
+	case m.provider == ProviderOpenAI:
+		apiURL = m.baseURL + "/embeddings"
+	case m.provider == ProviderOllama:
+		apiURL = m.baseURL + "/api/embed"
+	case m.provider == ProviderAnthropic:
+		return nil, fmt.Errorf("embeddings are not supported with the anthropic provider")
+	default:
+		apiURL = modelsEmbeddingsURL
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embeddings request: %w", err)
+	}
+	status, respBody, err := m.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embeddings request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if m.useAzure() {
+			if err := m.setAzureAuth(ctx, req); err != nil {
+				return nil, err
+			}
+		} else if m.token != "" {
+			req.Header.Set("Authorization", "Bearer "+m.token)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("embeddings API returned %d: %s", status, string(respBody))
+	}
+
+	// OpenAI-compatible APIs return {"data":[{"index","embedding"}]};
+	// Ollama returns {"embeddings":[[...]]}.
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal embeddings response: %w", err)
+	}
+	if resp.Embeddings != nil {
+		vectors = resp.Embeddings
+	} else {
+		vectors = make([][]float64, len(inputs))
+		for _, d := range resp.Data {
+			if d.Index >= 0 && d.Index < len(vectors) {
+				vectors[d.Index] = d.Embedding
+			}
+		}
+	}
+	if len(vectors) != len(inputs) {
+		return nil, fmt.Errorf("embeddings API returned %d vectors for %d inputs", len(vectors), len(inputs))
+	}
+	return vectors, nil
+}
@@ -0,0 +1,283 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphQLURL is the GitHub GraphQL endpoint. GraphQL is used where REST
+// would need one request per item (repo metadata, PR reviews and checks).
+const graphQLURL = "https://api.github.com/graphql"
+
+// graphQL runs query with vars and decodes the response's data into out.
+func (c *Client) graphQL(ctx context.Context, query string, vars map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return fmt.Errorf("failed to encode GraphQL request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, graphQLURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create GraphQL request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.api.Client().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send GraphQL request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read GraphQL response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GraphQL API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		msgs := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("GraphQL error: %s", strings.Join(msgs, "; "))
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("failed to decode GraphQL data: %w", err)
+	}
+	return nil
+}
+
+// RepoInfo is a repository with the metadata shown in repo listings.
+type RepoInfo struct {
+	FullName      string
+	Description   string
+	Language      string
+	DefaultBranch string
+	Stars         int
+	OpenPRs       int
+	Private       bool
+	Archived      bool
+	PushedAt      time.Time
+}
+
+const orgReposQuery = `query($org: String!, $cursor: String) {
+  organization(login: $org) {
+    repositories(first: 100, after: $cursor, orderBy: {field: PUSHED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        nameWithOwner description isPrivate isArchived pushedAt stargazerCount
+        primaryLanguage { name }
+        defaultBranchRef { name }
+        pullRequests(states: OPEN) { totalCount }
+      }
+    }
+  }
+}`
+
+// ListOrgRepoDetails lists an organization's repositories with their
+// metadata, most recently pushed first, in one GraphQL request per 100 repos.
+func (c *Client) ListOrgRepoDetails(ctx context.Context, org string) ([]RepoInfo, error) {
+	var repos []RepoInfo
+	vars := map[string]any{"org": org, "cursor": nil}
+	for {
+		var data struct {
+			Organization *struct {
+				Repositories struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						NameWithOwner   string    `json:"nameWithOwner"`
+						Description     string    `json:"description"`
+						IsPrivate       bool      `json:"isPrivate"`
+						IsArchived      bool      `json:"isArchived"`
+						PushedAt        time.Time `json:"pushedAt"`
+						StargazerCount  int       `json:"stargazerCount"`
+						PrimaryLanguage *struct {
+							Name string `json:"name"`
+						} `json:"primaryLanguage"`
+						DefaultBranchRef *struct {
+							Name string `json:"name"`
+						} `json:"defaultBranchRef"`
+						PullRequests struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"pullRequests"`
+					} `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"organization"`
+		}
+		if err := c.graphQL(ctx, orgReposQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("failed to list repositories for org %s: %w", org, err)
+		}
+		if data.Organization == nil {
+			return nil, fmt.Errorf("failed to list repositories: %s is not an organization", org)
+		}
+		for _, n := range data.Organization.Repositories.Nodes {
+			r := RepoInfo{
+				FullName:    n.NameWithOwner,
+				Description: n.Description,
+				Stars:       n.StargazerCount,
+				OpenPRs:     n.PullRequests.TotalCount,
+				Private:     n.IsPrivate,
+				Archived:    n.IsArchived,
+				PushedAt:    n.PushedAt,
+			}
+			if n.PrimaryLanguage != nil {
+				r.Language = n.PrimaryLanguage.Name
+			}
+			if n.DefaultBranchRef != nil {
+				r.DefaultBranch = n.DefaultBranchRef.Name
+			}
+			repos = append(repos, r)
+		}
+		page := data.Organization.Repositories.PageInfo
+		if !page.HasNextPage {
+			break
+		}
+		vars["cursor"] = page.EndCursor
+	}
+	return repos, nil
+}
+
+const pullRequestsQuery = `query($owner: String!, $repo: String!, $states: [PullRequestState!], $limit: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequests(first: $limit, states: $states, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      nodes {
+        number title state url isDraft reviewDecision
+        author { login }
+        commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+      }
+    }
+  }
+}`
+
+// ListPullRequestDetails returns recent PRs for a repo like ListPullRequests,
+// plus each PR's review decision and combined check state, in one request.
+func (c *Client) ListPullRequestDetails(ctx context.Context, owner, repo, state string, limit int) ([]PRSummary, error) {
+	if limit <= 0 || limit > 30 {
+		limit = 10
+	}
+	var states any // nil: all states
+	switch state {
+	case "open":
+		states = []string{"OPEN"}
+	case "closed":
+		states = []string{"CLOSED", "MERGED"}
+	}
+
+	var data struct {
+		Repository *struct {
+			PullRequests struct {
+				Nodes []struct {
+					Number         int    `json:"number"`
+					Title          string `json:"title"`
+					State          string `json:"state"`
+					URL            string `json:"url"`
+					IsDraft        bool   `json:"isDraft"`
+					ReviewDecision string `json:"reviewDecision"`
+					Author         *struct {
+						Login string `json:"login"`
+					} `json:"author"`
+					Commits struct {
+						Nodes []struct {
+							Commit struct {
+								StatusCheckRollup *struct {
+									State string `json:"state"`
+								} `json:"statusCheckRollup"`
+							} `json:"commit"`
+						} `json:"nodes"`
+					} `json:"commits"`
+				} `json:"nodes"`
+			} `json:"pullRequests"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"owner": owner, "repo": repo, "states": states, "limit": limit}
+	if err := c.graphQL(ctx, pullRequestsQuery, vars, &data); err != nil {
+		return nil, fmt.Errorf("failed to list PRs: %w", err)
+	}
+	if data.Repository == nil {
+		return nil, fmt.Errorf("failed to list PRs: repository %s/%s not found", owner, repo)
+	}
+
+	var summaries []PRSummary
+	for _, n := range data.Repository.PullRequests.Nodes {
+		s := PRSummary{
+			Number:         n.Number,
+			Title:          n.Title,
+			State:          strings.ToLower(n.State),
+			URL:            n.URL,
+			Draft:          n.IsDraft,
+			ReviewDecision: strings.ToLower(strings.ReplaceAll(n.ReviewDecision, "_", " ")),
+		}
+		if n.Author != nil {
+			s.Author = n.Author.Login
+		}
+		if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+			s.Checks = strings.ToLower(n.Commits.Nodes[0].Commit.StatusCheckRollup.State)
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+// mergedBatchSize caps the PRs looked up per GraphQL request.
+const mergedBatchSize = 50
+
+// MergedPullRequests reports which of the given PR URLs have been merged,
+// using one aliased GraphQL query per batch instead of a request per PR.
+// URLs that can't be parsed or found are left out of the result.
+func (c *Client) MergedPullRequests(ctx context.Context, prURLs []string) (map[string]bool, error) {
+	merged := make(map[string]bool, len(prURLs))
+	for start := 0; start < len(prURLs); start += mergedBatchSize {
+		batch := prURLs[start:min(start+mergedBatchSize, len(prURLs))]
+		var q strings.Builder
+		q.WriteString("query {")
+		aliases := make(map[string]string)
+		for i, u := range batch {
+			owner, repo, number, err := ParsePRURL(u)
+			if err != nil {
+				continue
+			}
+			alias := fmt.Sprintf("pr%d", i)
+			aliases[alias] = u
+			fmt.Fprintf(&q, " %s: repository(owner: %s, name: %s) { pullRequest(number: %d) { merged } }",
+				alias, strconv.Quote(owner), strconv.Quote(repo), number)
+		}
+		q.WriteString(" }")
+		if len(aliases) == 0 {
+			continue
+		}
+
+		var data map[string]*struct {
+			PullRequest *struct {
+				Merged bool `json:"merged"`
+			} `json:"pullRequest"`
+		}
+		if err := c.graphQL(ctx, q.String(), nil, &data); err != nil {
+			return nil, fmt.Errorf("failed to check PRs: %w", err)
+		}
+		for alias, u := range aliases {
+			if r := data[alias]; r != nil && r.PullRequest != nil {
+				merged[u] = r.PullRequest.Merged
+			}
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,214 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// CommitInfo is a commit as shown in history listings.
+type CommitInfo struct {
+	SHA     string
+	Author  string // GitHub login, or the git author name when not linked
+	Date    time.Time
+	Message string // first line
+	URL     string
+}
+
+// CommitFilter narrows ListCommits. Zero values are ignored.
+type CommitFilter struct {
+	Ref    string // branch, tag or SHA; default branch when empty
+	Path   string // only commits touching this file or directory
+	Author string // GitHub login or email
+	Since  time.Time
+	Until  time.Time
+}
+
+// ListCommits returns up to limit commits matching f, newest first.
+func (c *Client) ListCommits(ctx context.Context, owner, repo string, f CommitFilter, limit int) ([]CommitInfo, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	opts := &gh.CommitsListOptions{
+		SHA:         f.Ref,
+		Path:        f.Path,
+		Author:      f.Author,
+		Since:       f.Since,
+		Until:       f.Until,
+		ListOptions: gh.ListOptions{PerPage: limit},
+	}
+	commits, _, err := c.api.Repositories.ListCommits(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	out := make([]CommitInfo, 0, len(commits))
+	for _, rc := range commits {
+		author := rc.GetAuthor().GetLogin()
+		if author == "" {
+			author = rc.GetCommit().GetAuthor().GetName()
+		}
+		msg, _, _ := strings.Cut(rc.GetCommit().GetMessage(), "\n")
+		out = append(out, CommitInfo{
+			SHA:     rc.GetSHA(),
+			Author:  author,
+			Date:    rc.GetCommit().GetAuthor().GetDate().Time,
+			Message: msg,
+			URL:     rc.GetHTMLURL(),
+		})
+	}
+	return out, nil
+}
+
+// BlameRange is a run of consecutive lines last changed by the same commit.
+type BlameRange struct {
+	StartLine int
+	EndLine   int
+	Commit    CommitInfo
+}
+
+const blameQuery = `query($owner: String!, $repo: String!, $ref: String!, $path: String!) {
+  repository(owner: $owner, name: $repo) {
+    object(expression: $ref) {
+      ... on Commit {
+        blame(path: $path) {
+          ranges {
+            startingLine endingLine
+            commit { oid messageHeadline committedDate url author { name user { login } } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// Blame returns who last changed each line of a file on ref (default
+// branch when empty).
+func (c *Client) Blame(ctx context.Context, owner, repo, ref, path string) ([]BlameRange, error) {
+	if ref == "" {
+		var err error
+		if ref, err = c.GetDefaultBranch(ctx, owner, repo); err != nil {
+			return nil, err
+		}
+	}
+	var data struct {
+		Repository *struct {
+			Object *struct {
+				Blame *struct {
+					Ranges []struct {
+						StartingLine int `json:"startingLine"`
+						EndingLine   int `json:"endingLine"`
+						Commit       struct {
+							OID             string    `json:"oid"`
+							MessageHeadline string    `json:"messageHeadline"`
+							CommittedDate   time.Time `json:"committedDate"`
+							URL             string    `json:"url"`
+							Author          struct {
+								Name string `json:"name"`
+								User *struct {
+									Login string `json:"login"`
+								} `json:"user"`
+							} `json:"author"`
+						} `json:"commit"`
+					} `json:"ranges"`
+				} `json:"blame"`
+			} `json:"object"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"owner": owner, "repo": repo, "ref": ref, "path": path}
+	if err := c.graphQL(ctx, blameQuery, vars, &data); err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+	if data.Repository == nil || data.Repository.Object == nil || data.Repository.Object.Blame == nil {
+		return nil, fmt.Errorf("failed to blame %s: ref %q or file not found", path, ref)
+	}
+
+	var out []BlameRange
+	for _, r := range data.Repository.Object.Blame.Ranges {
+		author := r.Commit.Author.Name
+		if r.Commit.Author.User != nil && r.Commit.Author.User.Login != "" {
+			author = r.Commit.Author.User.Login
+		}
+		out = append(out, BlameRange{
+			StartLine: r.StartingLine,
+			EndLine:   r.EndingLine,
+			Commit: CommitInfo{
+				SHA:     r.Commit.OID,
+				Author:  author,
+				Date:    r.Commit.CommittedDate,
+				Message: r.Commit.MessageHeadline,
+				URL:     r.Commit.URL,
+			},
+		})
+	}
+	return out, nil
+}
+
+// Commit is a single commit with the files it changed.
+type Commit struct {
+	CommitInfo
+	FullMessage  string
+	Committer    string // git committer name, when it differs from the author
+	Additions    int
+	Deletions    int
+	Files        []CommitFile // GitHub returns at most 300
+	PullRequests []string     // URLs of the pull requests that contain the commit
+}
+
+// CommitFile is a file changed by a commit.
+type CommitFile struct {
+	ChangedFile
+	PreviousPath string // set for renames
+	Patch        string // unified diff; empty for binary or very large files
+}
+
+// GetCommit returns the commit ref (a SHA, branch or tag) with its files
+// and the pull requests it belongs to.
+func (c *Client) GetCommit(ctx context.Context, owner, repo, ref string) (*Commit, error) {
+	rc, _, err := c.api.Repositories.GetCommit(ctx, owner, repo, ref, &gh.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", ref, err)
+	}
+	author := rc.GetAuthor().GetLogin()
+	if author == "" {
+		author = rc.GetCommit().GetAuthor().GetName()
+	}
+	msg := rc.GetCommit().GetMessage()
+	headline, _, _ := strings.Cut(msg, "\n")
+	out := &Commit{
+		CommitInfo: CommitInfo{
+			SHA:     rc.GetSHA(),
+			Author:  author,
+			Date:    rc.GetCommit().GetAuthor().GetDate().Time,
+			Message: headline,
+			URL:     rc.GetHTMLURL(),
+		},
+		FullMessage: msg,
+		Additions:   rc.GetStats().GetAdditions(),
+		Deletions:   rc.GetStats().GetDeletions(),
+	}
+	if name := rc.GetCommit().GetCommitter().GetName(); name != rc.GetCommit().GetAuthor().GetName() {
+		out.Committer = name
+	}
+	for _, f := range rc.Files {
+		out.Files = append(out.Files, CommitFile{
+			ChangedFile: ChangedFile{
+				Path:      f.GetFilename(),
+				Status:    f.GetStatus(),
+				Additions: f.GetAdditions(),
+				Deletions: f.GetDeletions(),
+			},
+			PreviousPath: f.GetPreviousFilename(),
+			Patch:        f.GetPatch(),
+		})
+	}
+	// Best effort: the commit is still useful without its pull requests.
+	if prs, _, err := c.api.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, out.SHA, nil); err == nil {
+		for _, pr := range prs {
+			out.PullRequests = append(out.PullRequests, pr.GetHTMLURL())
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultJobLogBudget is the number of log characters a workflow run
+// summary includes across all failed jobs.
+const DefaultJobLogBudget = 16000
+
+const (
+	maxJobLogDownload = 64 << 20 // stop reading logs larger than this
+	minJobLogBudget   = 2000     // smallest share of the budget a failed job gets
+	jobLogTailLines   = 300
+)
+
+// defaultLogErrorPatterns match lines that usually explain a failed job.
+var defaultLogErrorPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`##\[error\]`),
+	regexp.MustCompile(`(?i)\b(error|fatal|failed|failure)\b[:\]]`),
+	regexp.MustCompile(`(?i)\bexception\b`),
+	regexp.MustCompile(`^panic: `),
+	regexp.MustCompile(`^Traceback \(most recent call last\)`),
+	regexp.MustCompile(`^(--- )?FAIL\b`),
+	regexp.MustCompile(`(?i)exit (code|status) [1-9]`),
+	regexp.MustCompile(`(?i)\bnpm ERR!`),
+}
+
+// logTimestampRe matches the timestamp GitHub prefixes to every log line.
+var logTimestampRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z `)
+
+// LogScanner keeps the lines of CI job logs that usually explain a failure:
+// those matching an error pattern, plus the tail. The zero value uses the
+// built-in patterns and DefaultJobLogBudget.
+type LogScanner struct {
+	patterns []*regexp.Regexp
+	budget   int
+}
+
+// NewLogScanner returns a scanner with extra error patterns added to the
+// built-in ones and a total log budget (characters) per run summary.
+// budget <= 0 keeps the default.
+func NewLogScanner(extraPatterns []string, budget int) (*LogScanner, error) {
+	patterns := append([]*regexp.Regexp(nil), defaultLogErrorPatterns...)
+	for _, p := range extraPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log error pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return &LogScanner{patterns: patterns, budget: max(budget, 0)}, nil
+}
+
+// SetJobLogOptions adds extra error patterns to the built-in ones and sets
+// the total log budget (characters) of a workflow run summary. budget <= 0
+// keeps the default.
+func (c *Client) SetJobLogOptions(extraPatterns []string, budget int) error {
+	s, err := NewLogScanner(extraPatterns, budget)
+	if err != nil {
+		return err
+	}
+	c.logs = *s
+	return nil
+}
+
+// Budget returns the log budget for each of failed jobs, and how many of
+// them get logs at all.
+func (s *LogScanner) Budget(failed int) (perJob, jobs int) {
+	budget := s.budget
+	if budget <= 0 {
+		budget = DefaultJobLogBudget
+	}
+	jobs = min(failed, max(1, budget/minJobLogBudget))
+	if jobs == 0 {
+		return 0, 0
+	}
+	return budget / jobs, jobs
+}
+
+// JobLog is what a run summary keeps from a failed job's log.
+type JobLog struct {
+	ErrorLines []string // "L<n>: text" for lines matching an error pattern
+	ErrorTotal int      // all matching lines, including ones dropped for size
+	Tail       string
+	Lines      int
+}
+
+// getJobLog downloads the full plain-text log of a job and scans it.
+func (c *Client) getJobLog(ctx context.Context, owner, repo string, jobID int64, budget int) (*JobLog, error) {
+	logURL, _, err := c.api.Actions.GetWorkflowJobLogs(ctx, owner, repo, jobID, 2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log URL for job %d: %w", jobID, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download job logs: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download job logs: HTTP %d", resp.StatusCode)
+	}
+	return c.logs.Scan(resp.Body, budget)
+}
+
+// Scan reads a plain-text job log and keeps the error-matching lines and
+// the tail, within budget characters. About a third of the budget goes to
+// error lines; the rest to the tail.
+func (s *LogScanner) Scan(r io.Reader, budget int) (*JobLog, error) {
+	patterns := s.patterns
+	if patterns == nil {
+		patterns = defaultLogErrorPatterns
+	}
+	errBudget := budget / 3
+	errUsed := 0
+	out := &JobLog{}
+	tail := make([]string, 0, jobLogTailLines)
+
+	sc := bufio.NewScanner(io.LimitReader(r, maxJobLogDownload))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		out.Lines++
+		line := logTimestampRe.ReplaceAllString(strings.TrimRight(sc.Text(), "\r"), "")
+		if len(tail) == jobLogTailLines {
+			tail = tail[1:]
+		}
+		tail = append(tail, line)
+
+		for _, re := range patterns {
+			if !re.MatchString(line) {
+				continue
+			}
+			out.ErrorTotal++
+			entry := fmt.Sprintf("L%d: %s", out.Lines, truncateLine(line, 300))
+			if errUsed+len(entry)+1 <= errBudget {
+				out.ErrorLines = append(out.ErrorLines, entry)
+				errUsed += len(entry) + 1
+			}
+			break
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job logs: %w", err)
+	}
+
+	// Fill the rest of the budget with the last lines, newest kept.
+	remaining := budget - errUsed
+	start := len(tail)
+	for used := 0; start > 0; start-- {
+		n := len(tail[start-1]) + 1
+		if used+n > remaining {
+			break
+		}
+		used += n
+	}
+	out.Tail = strings.Join(tail[start:], "\n")
+	return out, nil
+}
+
+func truncateLine(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
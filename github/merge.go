@@ -0,0 +1,285 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// MergeStatus describes whether a pull request can be merged, combining the
+// PR's mergeable state with the base branch's protection rules, reviews,
+// and the checks on the head commit.
+type MergeStatus struct {
+	Number         int
+	Title          string
+	URL            string
+	State          string // "open" or "closed"
+	Draft          bool
+	Merged         bool
+	Base           string
+	Head           string
+	HeadSHA        string
+	MergeableState string // clean, blocked, behind, dirty, unstable, unknown, draft, has_hooks
+
+	// Branch protection on Base. ProtectionUnknown is set when the token
+	// cannot read the protection settings.
+	Protected         bool
+	ProtectionUnknown bool
+	RequiredApprovals int
+	RequiredChecks    []string
+	RequireUpToDate   bool
+	LinearHistory     bool
+
+	Approvals        []string // reviewers whose latest review approves
+	ChangesRequested []string // reviewers whose latest review requests changes
+	FailingChecks    []string
+	PendingChecks    []string
+	PassedChecks     []string
+}
+
+// Blockers returns human-readable reasons the pull request cannot be merged
+// with method ("merge", "squash" or "rebase"). Empty means it looks mergeable.
+func (s *MergeStatus) Blockers(method string) []string {
+	switch {
+	case s.Merged:
+		return []string{"it is already merged"}
+	case s.State != "open":
+		return []string{"it is closed"}
+	}
+
+	var out []string
+	if s.Draft {
+		out = append(out, "it is a draft; mark it ready for review first")
+	}
+	if s.MergeableState == "dirty" {
+		out = append(out, fmt.Sprintf("it has merge conflicts with %s that must be resolved in the branch", s.Base))
+	}
+	if len(s.ChangesRequested) > 0 {
+		out = append(out, fmt.Sprintf("changes were requested by %s", strings.Join(s.ChangesRequested, ", ")))
+	}
+	if s.RequiredApprovals > len(s.Approvals) {
+		out = append(out, fmt.Sprintf("%s requires %d approving review(s), it has %d", s.Base, s.RequiredApprovals, len(s.Approvals)))
+	}
+	if len(s.FailingChecks) > 0 {
+		out = append(out, fmt.Sprintf("checks are failing: %s", strings.Join(s.FailingChecks, ", ")))
+	}
+	var pendingRequired, missing []string
+	for _, c := range s.RequiredChecks {
+		switch {
+		case slices.Contains(s.PendingChecks, c):
+			pendingRequired = append(pendingRequired, c)
+		case !slices.Contains(s.PassedChecks, c) && !slices.Contains(s.FailingChecks, c):
+			missing = append(missing, c)
+		}
+	}
+	if len(pendingRequired) > 0 {
+		out = append(out, fmt.Sprintf("required checks are still running: %s", strings.Join(pendingRequired, ", ")))
+	}
+	if len(missing) > 0 {
+		out = append(out, fmt.Sprintf("required checks have not reported: %s", strings.Join(missing, ", ")))
+	}
+	if s.MergeableState == "behind" && s.RequireUpToDate {
+		out = append(out, fmt.Sprintf("the branch is behind %s and protection requires it to be up to date; update the branch first", s.Base))
+	}
+	if s.LinearHistory && method == "merge" {
+		out = append(out, fmt.Sprintf("%s requires linear history; use the squash or rebase method", s.Base))
+	}
+	if len(out) == 0 && s.MergeableState == "blocked" {
+		out = append(out, "GitHub reports the merge as blocked by a rule the bot cannot inspect (e.g. code owner review, unresolved conversations, or a repository ruleset)")
+	}
+	return out
+}
+
+// GetMergeStatus gathers the information needed to decide whether a pull
+// request can be merged.
+func (c *Client) GetMergeStatus(ctx context.Context, owner, repo string, number int) (*MergeStatus, error) {
+	pr, _, err := c.api.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, err)
+	}
+	// GitHub computes mergeability in the background; the first read after a
+	// push often reports "unknown".
+	if pr.GetState() == "open" && pr.GetMergeableState() == "unknown" {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+		if again, _, err := c.api.PullRequests.Get(ctx, owner, repo, number); err == nil {
+			pr = again
+		}
+	}
+
+	s := &MergeStatus{
+		Number:         number,
+		Title:          pr.GetTitle(),
+		URL:            pr.GetHTMLURL(),
+		State:          pr.GetState(),
+		Draft:          pr.GetDraft(),
+		Merged:         pr.GetMerged(),
+		Base:           pr.GetBase().GetRef(),
+		Head:           pr.GetHead().GetRef(),
+		HeadSHA:        pr.GetHead().GetSHA(),
+		MergeableState: pr.GetMergeableState(),
+	}
+	if s.State != "open" {
+		return s, nil
+	}
+
+	protection, _, err := c.api.Repositories.GetBranchProtection(ctx, owner, repo, s.Base)
+	switch {
+	case errors.Is(err, gh.ErrBranchNotProtected):
+	case err != nil:
+		s.ProtectionUnknown = true
+	default:
+		s.Protected = true
+		if r := protection.GetRequiredPullRequestReviews(); r != nil {
+			s.RequiredApprovals = r.RequiredApprovingReviewCount
+		}
+		if rc := protection.GetRequiredStatusChecks(); rc != nil {
+			s.RequireUpToDate = rc.Strict
+			if rc.Checks != nil {
+				for _, chk := range *rc.Checks {
+					s.RequiredChecks = append(s.RequiredChecks, chk.Context)
+				}
+			} else if rc.Contexts != nil {
+				s.RequiredChecks = append(s.RequiredChecks, *rc.Contexts...)
+			}
+		}
+		s.LinearHistory = protection.GetRequireLinearHistory().Enabled
+	}
+
+	if err := c.collectReviews(ctx, owner, repo, number, s); err != nil {
+		return nil, err
+	}
+	if err := c.collectChecks(ctx, owner, repo, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// collectReviews records each reviewer's latest approving or
+// changes-requested review.
+func (c *Client) collectReviews(ctx context.Context, owner, repo string, number int, s *MergeStatus) error {
+	latest := make(map[string]string)
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := c.api.PullRequests.ListReviews(ctx, owner, repo, number, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list reviews for PR #%d: %w", number, err)
+		}
+		for _, r := range reviews {
+			switch state := r.GetState(); state {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+				latest[r.GetUser().GetLogin()] = state
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	for user, state := range latest {
+		switch state {
+		case "APPROVED":
+			s.Approvals = append(s.Approvals, user)
+		case "CHANGES_REQUESTED":
+			s.ChangesRequested = append(s.ChangesRequested, user)
+		}
+	}
+	sort.Strings(s.Approvals)
+	sort.Strings(s.ChangesRequested)
+	return nil
+}
+
+// collectChecks sorts the check runs and commit statuses on the head commit
+// into passed, failing and pending.
+func (c *Client) collectChecks(ctx context.Context, owner, repo string, s *MergeStatus) error {
+	opts := &gh.ListCheckRunsOptions{ListOptions: gh.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := c.api.Checks.ListCheckRunsForRef(ctx, owner, repo, s.HeadSHA, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list check runs: %w", err)
+		}
+		for _, run := range runs.CheckRuns {
+			name := run.GetName()
+			switch {
+			case run.GetStatus() != "completed":
+				s.PendingChecks = append(s.PendingChecks, name)
+			case slices.Contains([]string{"success", "neutral", "skipped"}, run.GetConclusion()):
+				s.PassedChecks = append(s.PassedChecks, name)
+			default:
+				s.FailingChecks = append(s.FailingChecks, fmt.Sprintf("%s (%s)", name, run.GetConclusion()))
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	combined, _, err := c.api.Repositories.GetCombinedStatus(ctx, owner, repo, s.HeadSHA, &gh.ListOptions{PerPage: 100})
+	if err != nil {
+		return fmt.Errorf("failed to get commit statuses: %w", err)
+	}
+	for _, st := range combined.Statuses {
+		name := st.GetContext()
+		switch st.GetState() {
+		case "success":
+			s.PassedChecks = append(s.PassedChecks, name)
+		case "pending":
+			s.PendingChecks = append(s.PendingChecks, name)
+		default:
+			s.FailingChecks = append(s.FailingChecks, fmt.Sprintf("%s (%s)", name, st.GetState()))
+		}
+	}
+	return nil
+}
+
+// MergePullRequest merges a pull request with method ("merge", "squash" or
+// "rebase"). headSHA, when set, makes GitHub refuse the merge if the branch
+// moved since it was checked. Returns the merge commit SHA.
+func (c *Client) MergePullRequest(ctx context.Context, owner, repo string, number int, method, commitTitle, headSHA string) (string, error) {
+	result, _, err := c.api.PullRequests.Merge(ctx, owner, repo, number, "", &gh.PullRequestOptions{
+		CommitTitle: commitTitle,
+		MergeMethod: method,
+		SHA:         headSHA,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to merge PR #%d: %w", number, err)
+	}
+	if !result.GetMerged() {
+		return "", fmt.Errorf("failed to merge PR #%d: %s", number, result.GetMessage())
+	}
+	return result.GetSHA(), nil
+}
+
+// ClosePullRequest closes a pull request without merging it.
+func (c *Client) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	_, _, err := c.api.PullRequests.Edit(ctx, owner, repo, number, &gh.PullRequest{State: gh.String("closed")})
+	if err != nil {
+		return fmt.Errorf("failed to close PR #%d: %w", number, err)
+	}
+	return nil
+}
+
+// UpdatePullRequestBranch merges the base branch into the pull request's
+// branch. GitHub performs the update in the background.
+func (c *Client) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, headSHA string) error {
+	opts := &gh.PullRequestBranchUpdateOptions{}
+	if headSHA != "" {
+		opts.ExpectedHeadSHA = gh.String(headSHA)
+	}
+	_, _, err := c.api.PullRequests.UpdateBranch(ctx, owner, repo, number, opts)
+	var accepted *gh.AcceptedError
+	if err != nil && !errors.As(err, &accepted) {
+		return fmt.Errorf("failed to update branch of PR #%d: %w", number, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// Milestone is a repository milestone.
+type Milestone struct {
+	Number       int
+	Title        string
+	State        string
+	Description  string
+	DueOn        time.Time // zero when no due date
+	OpenIssues   int
+	ClosedIssues int
+	URL          string
+}
+
+// ListMilestones returns a repository's milestones in state ("open",
+// "closed" or "all"; default "open"), soonest due first.
+func (c *Client) ListMilestones(ctx context.Context, owner, repo, state string) ([]Milestone, error) {
+	if state == "" {
+		state = "open"
+	}
+	opts := &gh.MilestoneListOptions{
+		State:       state,
+		Sort:        "due_on",
+		Direction:   "asc",
+		ListOptions: gh.ListOptions{PerPage: 100},
+	}
+	var out []Milestone
+	for {
+		page, resp, err := c.api.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list milestones: %w", err)
+		}
+		for _, m := range page {
+			out = append(out, Milestone{
+				Number:       m.GetNumber(),
+				Title:        m.GetTitle(),
+				State:        m.GetState(),
+				Description:  m.GetDescription(),
+				DueOn:        m.GetDueOn().Time,
+				OpenIssues:   m.GetOpenIssues(),
+				ClosedIssues: m.GetClosedIssues(),
+				URL:          m.GetHTMLURL(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return out, nil
+}
+
+// SetMilestone assigns an issue or pull request to a milestone.
+func (c *Client) SetMilestone(ctx context.Context, owner, repo string, number, milestone int) error {
+	req := &gh.IssueRequest{Milestone: gh.Int(milestone)}
+	if _, _, err := c.api.Issues.Edit(ctx, owner, repo, number, req); err != nil {
+		return fmt.Errorf("failed to set milestone on #%d: %w", number, err)
+	}
+	return nil
+}
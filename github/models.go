@@ -5,14 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
 )
 
 const modelsAPIURL = "https://models.github.ai/inference/chat/completions"
 
+// openAIBaseURL is the default base URL for the native OpenAI API.
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// LLM providers supported by ModelsClient.
+const (
+	ProviderGitHub    = "github"
+	ProviderAzure     = "azure"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
+)
+
 // azureAPIVersion is the Azure OpenAI REST API version to use for chat completions.
 const azureAPIVersion = "2024-10-21"
 
@@ -20,13 +34,25 @@ const azureAPIVersion = "2024-10-21"
 const azureResponsesAPIVersion = "2025-04-01-preview"
 
 type ModelsClient struct {
-	token      string
+	token      string // GitHub token, OpenAI API key, or Anthropic API key
 	model      string
 	httpClient *http.Client
 
+	// provider selects the request path for non-Azure clients (github, openai, anthropic, ollama).
+	provider string
+	baseURL  string // API base URL for openai/anthropic/ollama
+
+	reasoningEffort string // Responses API reasoning effort; empty = model default
+
+	usageRecorder UsageRecorder // receives token usage per request; nil = not tracked
+
+	maxRetries int             // retries for transient failures (429, 5xx)
+	breaker    *circuitBreaker // shared across requests; opens on persistent failure
+
 	// Azure OpenAI fields (empty when using GitHub Models).
-	azureEndpoint string
-	azureAPIKey   string
+	azureEndpoint   string
+	azureAPIKey     string
+	azureCredential *AzureCredential // Entra ID auth; used instead of azureAPIKey when set
 }
 
 type chatRequest struct {
@@ -73,6 +99,7 @@ type ChatResponse struct {
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 func NewModelsClient(token, model string) *ModelsClient {
@@ -80,6 +107,42 @@ func NewModelsClient(token, model string) *ModelsClient {
 		token:      token,
 		model:      model,
 		httpClient: &http.Client{},
+		maxRetries: defaultLLMMaxRetries,
+		breaker:    &circuitBreaker{},
+		provider:   ProviderGitHub,
+	}
+}
+
+// NewOpenAIModelsClient creates a ModelsClient backed by the OpenAI Chat
+// Completions API. baseURL may be empty to use api.openai.com, or point at an
+// OpenAI-compatible gateway.
+func NewOpenAIModelsClient(apiKey, baseURL, model string) *ModelsClient {
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+	return &ModelsClient{
+		token:      apiKey,
+		model:      model,
+		httpClient: &http.Client{},
+		maxRetries: defaultLLMMaxRetries,
+		breaker:    &circuitBreaker{},
+		provider:   ProviderOpenAI,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// NewAnthropicModelsClient creates a ModelsClient backed by the Anthropic
+// Messages API. Tool calls are translated to and from tool_use blocks so
+// callers keep using the Chat Completions types. baseURL may be empty.
+func NewAnthropicModelsClient(apiKey, baseURL, model string) *ModelsClient {
+	return &ModelsClient{
+		token:      apiKey,
+		model:      model,
+		httpClient: &http.Client{},
+		maxRetries: defaultLLMMaxRetries,
+		breaker:    &circuitBreaker{},
+		provider:   ProviderAnthropic,
+		baseURL:    strings.TrimRight(baseURL, "/"),
 	}
 }
 
@@ -90,14 +153,82 @@ func NewAzureModelsClient(endpoint, apiKey, deployment string) *ModelsClient {
 	return &ModelsClient{
 		model:         deployment,
 		httpClient:    &http.Client{},
+		maxRetries:    defaultLLMMaxRetries,
+		breaker:       &circuitBreaker{},
 		azureEndpoint: endpoint,
 		azureAPIKey:   apiKey,
 	}
 }
 
+// NewAzureModelsClientWithCredential creates a ModelsClient backed by Azure OpenAI
+// that authenticates with Entra ID (Azure AD) tokens instead of a static API key.
+// The credential may be shared between clients so tokens are cached once.
+func NewAzureModelsClientWithCredential(endpoint string, cred *AzureCredential, deployment string) *ModelsClient {
+	endpoint = strings.TrimRight(endpoint, "/")
+	return &ModelsClient{
+		model:           deployment,
+		httpClient:      &http.Client{},
+		maxRetries:      defaultLLMMaxRetries,
+		breaker:         &circuitBreaker{},
+		azureEndpoint:   endpoint,
+		azureCredential: cred,
+	}
+}
+
 // useAzure returns true when the client is configured for Azure OpenAI.
 func (m *ModelsClient) useAzure() bool {
-	return m.azureEndpoint != "" && m.azureAPIKey != ""
+	return m.azureEndpoint != "" && (m.azureAPIKey != "" || m.azureCredential != nil)
+}
+
+// setAzureAuth sets the Azure authentication header: a bearer token from the
+// Entra ID credential when configured, otherwise the static api-key header.
+func (m *ModelsClient) setAzureAuth(ctx context.Context, req *http.Request) error {
+	if m.azureCredential == nil {
+		req.Header.Set("api-key", m.azureAPIKey)
+		return nil
+	}
+	token, err := m.azureCredential.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire Entra ID token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// AzureAuthMode returns a human-readable description of how the client
+// authenticates to Azure OpenAI, or empty for non-Azure clients.
+func (m *ModelsClient) AzureAuthMode() string {
+	switch {
+	case !m.useAzure():
+		return ""
+	case m.azureCredential != nil:
+		return m.azureCredential.Method()
+	default:
+		return "API Key"
+	}
+}
+
+// NewOllamaModelsClient creates a ModelsClient backed by an Ollama server's
+// /api/chat endpoint, so no prompts or code leave the organization's network.
+// token is optional and sent as a bearer token for servers behind an auth proxy.
+func NewOllamaModelsClient(endpoint, token, model string) *ModelsClient {
+	return &ModelsClient{
+		token:      token,
+		model:      model,
+		httpClient: &http.Client{},
+		maxRetries: defaultLLMMaxRetries,
+		breaker:    &circuitBreaker{},
+		provider:   ProviderOllama,
+		baseURL:    strings.TrimRight(endpoint, "/"),
+	}
+}
+
+// Provider returns the LLM provider backing this client.
+func (m *ModelsClient) Provider() string {
+	if m.useAzure() {
+		return ProviderAzure
+	}
+	return m.provider
 }
 
 // Model returns the model/deployment name this client is using.
@@ -105,38 +236,54 @@ func (m *ModelsClient) Model() string {
 	return m.model
 }
 
-func (m *ModelsClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+func (m *ModelsClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (content string, err error) {
+	defer m.observe(time.Now(), &err)
+
 	messages := []ChatMessage{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userPrompt},
 	}
 
-	if m.isResponsesModel() {
-		resp, err := m.doResponses(ctx, messages, nil)
-		if err != nil {
-			return "", err
-		}
-		if len(resp.Choices) == 0 {
-			return "", fmt.Errorf("responses API returned no output")
-		}
-		return resp.Choices[0].Message.Content, nil
-	}
-
-	resp, err := m.doChat(ctx, messages, nil)
+	resp, err := m.send(ctx, messages, nil)
 	if err != nil {
 		return "", err
 	}
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("GitHub Models returned no choices")
+		return "", fmt.Errorf("%s returned no choices", m.Provider())
 	}
 	return resp.Choices[0].Message.Content, nil
 }
 
-func (m *ModelsClient) CompleteWithTools(ctx context.Context, messages []ChatMessage, tools []Tool) (*ChatResponse, error) {
-	if m.isResponsesModel() {
-		return m.doResponses(ctx, messages, tools)
+func (m *ModelsClient) CompleteWithTools(ctx context.Context, messages []ChatMessage, tools []Tool) (resp *ChatResponse, err error) {
+	defer m.observe(time.Now(), &err)
+
+	return m.send(ctx, messages, tools)
+}
+
+// send dispatches a request to the provider-specific request path.
+func (m *ModelsClient) send(ctx context.Context, messages []ChatMessage, tools []Tool) (resp *ChatResponse, err error) {
+	switch {
+	case m.isResponsesModel():
+		resp, err = m.doResponses(ctx, messages, tools)
+	case m.provider == ProviderAnthropic:
+		resp, err = m.doAnthropic(ctx, messages, tools)
+	case m.provider == ProviderOllama:
+		resp, err = m.doOllama(ctx, messages, tools)
+	default:
+		resp, err = m.doChat(ctx, messages, tools)
+	}
+	if err == nil {
+		m.recordUsage(ctx, resp)
+	}
+	return resp, err
+}
+
+// observe records request latency and errors for the metrics endpoint.
+func (m *ModelsClient) observe(start time.Time, err *error) {
+	metrics.LLMLatency.ObserveSince(start, m.model)
+	if *err != nil {
+		metrics.LLMErrors.Inc(m.model)
 	}
-	return m.doChat(ctx, messages, tools)
 }
 
 func (m *ModelsClient) doChat(ctx context.Context, messages []ChatMessage, tools []Tool) (*ChatResponse, error) {
@@ -152,38 +299,40 @@ func (m *ModelsClient) doChat(ctx context.Context, messages []ChatMessage, tools
 	}
 
 	var apiURL string
-	if m.useAzure() {
+	switch {
+	case m.useAzure():
 		apiURL = fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
 			m.azureEndpoint, m.model, azureAPIVersion)
-	} else {
+	case m.provider == ProviderOpenAI:
+		apiURL = m.baseURL + "/chat/completions"
+	default:
 		apiURL = modelsAPIURL
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if m.useAzure() {
-		req.Header.Set("api-key", m.azureAPIKey)
-	} else {
-		req.Header.Set("Authorization", "Bearer "+m.token)
-	}
-
-	resp, err := m.httpClient.Do(req)
+	status, body, err := m.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if m.useAzure() {
+			if err := m.setAzureAuth(ctx, req); err != nil {
+				return nil, err
+			}
+		} else {
+			req.Header.Set("Authorization", "Bearer "+m.token)
+		}
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("LLM API request failed: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("LLM API returned %d: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		if cfe := contentFilterError(body); cfe != nil {
+			return nil, cfe
+		}
+		return nil, fmt.Errorf("LLM API returned %d: %s", status, string(body))
 	}
 
 	var chatResp ChatResponse
@@ -195,6 +344,11 @@ func (m *ModelsClient) doChat(ctx context.Context, messages []ChatMessage, tools
 		return nil, fmt.Errorf("LLM API error: %s", chatResp.Error.Message)
 	}
 
+	// A filtered completion comes back as 200 with finish_reason "content_filter".
+	if len(chatResp.Choices) > 0 && chatResp.Choices[0].FinishReason == "content_filter" {
+		return nil, &ContentFilterError{Message: "completion was filtered"}
+	}
+
 	return &chatResp, nil
 }
 
@@ -211,10 +365,18 @@ func (m *ModelsClient) isResponsesModel() bool {
 
 // responsesRequest is the request body for the Azure Responses API.
 type responsesRequest struct {
-	Input        []responsesInputItem `json:"input"`
-	Instructions string               `json:"instructions,omitempty"`
-	Model        string               `json:"model"`
-	Tools        []responsesTool      `json:"tools,omitempty"`
+	Input             []responsesInputItem `json:"input"`
+	Instructions      string               `json:"instructions,omitempty"`
+	Model             string               `json:"model"`
+	Tools             []responsesTool      `json:"tools,omitempty"`
+	ParallelToolCalls *bool                `json:"parallel_tool_calls,omitempty"`
+	Reasoning         *responsesReasoning  `json:"reasoning,omitempty"`
+}
+
+// responsesReasoning configures reasoning models (o-series, gpt-5.x, codex).
+type responsesReasoning struct {
+	Effort  string `json:"effort,omitempty"`  // "minimal", "low", "medium", "high"
+	Summary string `json:"summary,omitempty"` // "auto" returns a reasoning summary
 }
 
 // responsesTool is the tool definition format for the Azure Responses API.
@@ -268,16 +430,25 @@ type responsesInputItem struct {
 // responsesResponse is the response body from the Azure Responses API.
 type responsesResponse struct {
 	ID     string                `json:"id"`
+	Status string                `json:"status"` // "completed", "incomplete", "failed"
 	Output []responsesOutputItem `json:"output"`
 	Error  *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details,omitempty"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 type responsesOutputItem struct {
-	Type    string                   `json:"type"` // "message" or "function_call"
+	Type    string                   `json:"type"` // "message", "function_call", or "reasoning"
 	Role    string                   `json:"role,omitempty"`
 	Content []responsesOutputContent `json:"content,omitempty"` // for type "message"
+	Summary []responsesOutputContent `json:"summary,omitempty"` // for type "reasoning"
 
 	// For type "function_call"
 	ID        string `json:"id,omitempty"`
@@ -287,7 +458,7 @@ type responsesOutputItem struct {
 }
 
 type responsesOutputContent struct {
-	Type string `json:"type"` // "output_text"
+	Type string `json:"type"` // "output_text", "summary_text"
 	Text string `json:"text"`
 }
 
@@ -310,23 +481,24 @@ func chatMessagesToResponsesInput(msgs []ChatMessage) (instructions string, item
 				Content: m.Content,
 			})
 		case "assistant":
-			if len(m.ToolCalls) > 0 {
-				// Each tool call becomes a separate function_call input item.
-				for _, tc := range m.ToolCalls {
-					items = append(items, responsesInputItem{
-						Type:      "function_call",
-						CallID:    tc.ID,
-						Name:      tc.Function.Name,
-						Arguments: tc.Function.Arguments,
-					})
-				}
-			} else {
+			// Text the model emitted alongside tool calls is kept so the
+			// next turn sees it; each (possibly parallel) tool call becomes
+			// a separate function_call input item.
+			if m.Content != "" || len(m.ToolCalls) == 0 {
 				items = append(items, responsesInputItem{
 					Type:    "message",
 					Role:    "assistant",
 					Content: m.Content,
 				})
 			}
+			for _, tc := range m.ToolCalls {
+				items = append(items, responsesInputItem{
+					Type:      "function_call",
+					CallID:    tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				})
+			}
 		case "tool":
 			items = append(items, responsesInputItem{
 				Type:   "function_call_output",
@@ -367,8 +539,12 @@ func responsesOutputToChatResponse(rr *responsesResponse) *ChatResponse {
 				}
 			}
 		case "function_call":
+			callID := item.CallID
+			if callID == "" {
+				callID = item.ID
+			}
 			choice.Message.ToolCalls = append(choice.Message.ToolCalls, ToolCall{
-				ID:   item.CallID,
+				ID:   callID,
 				Type: "function",
 				Function: struct {
 					Name      string `json:"name"`
@@ -382,16 +558,48 @@ func responsesOutputToChatResponse(rr *responsesResponse) *ChatResponse {
 	}
 
 	choice.Message.Content = strings.Join(textParts, "")
-	if len(choice.Message.ToolCalls) > 0 {
+	switch {
+	case len(choice.Message.ToolCalls) > 0:
 		choice.FinishReason = "tool_calls"
-	} else {
+	case rr.Status == "incomplete":
+		// Output was cut short (e.g. max_output_tokens); content-filter
+		// truncation is surfaced as a ContentFilterError before this point.
+		choice.FinishReason = "length"
+	default:
 		choice.FinishReason = "stop"
 	}
 
 	cr.Choices = append(cr.Choices, choice)
+	if rr.Usage != nil {
+		cr.Usage = &Usage{PromptTokens: rr.Usage.InputTokens, CompletionTokens: rr.Usage.OutputTokens}
+	}
 	return cr
 }
 
+// reasoningSummary returns the concatenated reasoning summary text from a
+// Responses API output, or empty when the model produced none.
+func reasoningSummary(rr *responsesResponse) string {
+	var parts []string
+	for _, item := range rr.Output {
+		if item.Type != "reasoning" {
+			continue
+		}
+		for _, c := range item.Summary {
+			if c.Text != "" {
+				parts = append(parts, c.Text)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// SetReasoningEffort sets the reasoning effort ("minimal", "low", "medium",
+// "high") sent to reasoning models via the Responses API. Empty uses the
+// model's default. A reasoning summary is requested and logged alongside.
+func (m *ModelsClient) SetReasoningEffort(effort string) {
+	m.reasoningEffort = strings.ToLower(effort)
+}
+
 // doResponses calls the Azure Responses API (/responses) for codex models.
 func (m *ModelsClient) doResponses(ctx context.Context, messages []ChatMessage, tools []Tool) (*ChatResponse, error) {
 	instructions, items := chatMessagesToResponsesInput(messages)
@@ -402,6 +610,13 @@ func (m *ModelsClient) doResponses(ctx context.Context, messages []ChatMessage,
 		Model:        m.model,
 		Tools:        chatToolsToResponsesTools(tools),
 	}
+	if len(tools) > 0 {
+		parallel := true
+		reqBody.ParallelToolCalls = &parallel
+	}
+	if m.reasoningEffort != "" {
+		reqBody.Reasoning = &responsesReasoning{Effort: m.reasoningEffort, Summary: "auto"}
+	}
 
 	payload, err := json.Marshal(reqBody)
 	if err != nil {
@@ -409,32 +624,32 @@ func (m *ModelsClient) doResponses(ctx context.Context, messages []ChatMessage,
 	}
 
 	if len(tools) > 0 {
-		log.Printf("[responses] sending %d tools, first tool: name=%q type=%q", len(reqBody.Tools), reqBody.Tools[0].Name, reqBody.Tools[0].Type)
+		logging.Ctx(ctx).Infof("[responses] sending %d tools, first tool: name=%q type=%q", len(reqBody.Tools), reqBody.Tools[0].Name, reqBody.Tools[0].Type)
 	}
 
 	apiURL := fmt.Sprintf("%s/openai/responses?api-version=%s",
 		m.azureEndpoint, azureResponsesAPIVersion)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create responses request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", m.azureAPIKey)
-
-	resp, err := m.httpClient.Do(req)
+	status, body, err := m.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create responses request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if err := m.setAzureAuth(ctx, req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("responses API request failed: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read responses body: %w", err)
-	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("responses API returned %d: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		if cfe := contentFilterError(body); cfe != nil {
+			return nil, cfe
+		}
+		return nil, fmt.Errorf("responses API returned %d: %s", status, string(body))
 	}
 
 	var rr responsesResponse
@@ -446,6 +661,17 @@ func (m *ModelsClient) doResponses(ctx context.Context, messages []ChatMessage,
 		return nil, fmt.Errorf("responses API error: %s", rr.Error.Message)
 	}
 
+	// A filtered output comes back as 200 with status "incomplete".
+	if rr.IncompleteDetails != nil && rr.IncompleteDetails.Reason == "content_filter" {
+		return nil, &ContentFilterError{Message: "response output was filtered"}
+	}
+	if rr.Status == "incomplete" && rr.IncompleteDetails != nil {
+		logging.Ctx(ctx).Infof("[responses] response %s incomplete: %s", rr.ID, rr.IncompleteDetails.Reason)
+	}
+	if summary := reasoningSummary(&rr); summary != "" {
+		logging.Ctx(ctx).Infof("[responses] reasoning summary (%s): %s", m.model, summary)
+	}
+
 	return responsesOutputToChatResponse(&rr), nil
 }
 
@@ -477,7 +703,7 @@ func (m *ModelsClient) ValidateModel(ctx context.Context) error {
 }
 
 // ListModels queries the Azure OpenAI /openai/models endpoint and returns
-// the model IDs accessible with the configured API key. Returns nil for
+// the model IDs accessible with the configured credentials. Returns nil for
 // non-Azure clients.
 func (m *ModelsClient) ListModels(ctx context.Context) ([]string, error) {
 	if !m.useAzure() {
@@ -489,7 +715,9 @@ func (m *ModelsClient) ListModels(ctx context.Context) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("build models request: %w", err)
 	}
-	req.Header.Set("api-key", m.azureAPIKey)
+	if err := m.setAzureAuth(ctx, req); err != nil {
+		return nil, err
+	}
 
 	resp, err := m.httpClient.Do(req)
 	if err != nil {
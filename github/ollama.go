@@ -0,0 +1,155 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ---------------------------------------------------------------------------
+// Ollama /api/chat support (local / on-prem models)
+// ---------------------------------------------------------------------------
+
+// ollamaRequest is the request body for Ollama's /api/chat endpoint.
+// Tool definitions use the same shape as Chat Completions.
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []Tool          `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// ollamaToolCall differs from Chat Completions: there is no call ID and
+// arguments are a JSON object rather than a string.
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	Error           string        `json:"error,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+// chatMessagesToOllama converts Chat Completions messages to Ollama's format.
+// Ollama matches tool results to calls by name, so tool_call_id is resolved
+// to the originating function name.
+func chatMessagesToOllama(messages []ChatMessage) []ollamaMessage {
+	toolNames := make(map[string]string)
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, msg := range messages {
+		om := ollamaMessage{Role: msg.Role, Content: msg.Content}
+		for _, tc := range msg.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+			var otc ollamaToolCall
+			otc.Function.Name = tc.Function.Name
+			otc.Function.Arguments = json.RawMessage(tc.Function.Arguments)
+			if !json.Valid(otc.Function.Arguments) {
+				otc.Function.Arguments = json.RawMessage(`{}`)
+			}
+			om.ToolCalls = append(om.ToolCalls, otc)
+		}
+		if msg.Role == "tool" {
+			om.ToolName = toolNames[msg.ToolCallID]
+		}
+		out = append(out, om)
+	}
+	return out
+}
+
+// ollamaToChatResponse converts an Ollama response into the ChatResponse
+// format used by the rest of the codebase, synthesizing tool call IDs.
+func ollamaToChatResponse(or *ollamaResponse) *ChatResponse {
+	cr := &ChatResponse{}
+
+	var choice struct {
+		Message struct {
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	}
+
+	choice.Message.Content = or.Message.Content
+	for i, otc := range or.Message.ToolCalls {
+		tc := ToolCall{ID: fmt.Sprintf("call_%d_%s", i, otc.Function.Name), Type: "function"}
+		tc.Function.Name = otc.Function.Name
+		tc.Function.Arguments = string(otc.Function.Arguments)
+		if tc.Function.Arguments == "" || tc.Function.Arguments == "null" {
+			tc.Function.Arguments = "{}"
+		}
+		choice.Message.ToolCalls = append(choice.Message.ToolCalls, tc)
+	}
+
+	switch {
+	case len(choice.Message.ToolCalls) > 0:
+		choice.FinishReason = "tool_calls"
+	case or.DoneReason == "length":
+		choice.FinishReason = "length"
+	default:
+		choice.FinishReason = "stop"
+	}
+
+	cr.Choices = append(cr.Choices, choice)
+	cr.Usage = &Usage{PromptTokens: or.PromptEvalCount, CompletionTokens: or.EvalCount}
+	return cr
+}
+
+// doOllama calls Ollama's /api/chat endpoint (non-streaming).
+func (m *ModelsClient) doOllama(ctx context.Context, messages []ChatMessage, tools []Tool) (*ChatResponse, error) {
+	reqBody := ollamaRequest{
+		Model:    m.model,
+		Messages: chatMessagesToOllama(messages),
+		Tools:    tools,
+		Stream:   false,
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ollama request: %w", err)
+	}
+
+	status, body, err := m.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/api/chat", bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ollama request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if m.token != "" {
+			req.Header.Set("Authorization", "Bearer "+m.token)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ollama request failed: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("ollama returned %d: %s", status, string(body))
+	}
+
+	var or ollamaResponse
+	if err := json.Unmarshal(body, &or); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ollama response: %w", err)
+	}
+
+	if or.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", or.Error)
+	}
+
+	return ollamaToChatResponse(&or), nil
+}
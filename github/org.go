@@ -0,0 +1,353 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// Team is an organization team.
+type Team struct {
+	Slug        string
+	Name        string
+	Description string
+	URL         string
+	Members     int
+	Permission  string // repository permission, set by RepoTeams only
+}
+
+// Member is an organization member or team member.
+type Member struct {
+	Login string
+	Name  string
+	Email string // public profile email, often empty
+}
+
+const orgTeamsQuery = `query($org: String!, $query: String, $cursor: String) {
+  organization(login: $org) {
+    teams(first: 100, after: $cursor, query: $query) {
+      pageInfo { hasNextPage endCursor }
+      nodes { slug name description url members { totalCount } }
+    }
+  }
+}`
+
+// ListTeams returns the organization's teams whose name or slug contains
+// query (all teams when empty).
+func (c *Client) ListTeams(ctx context.Context, org, query string) ([]Team, error) {
+	var teams []Team
+	vars := map[string]any{"org": org, "query": query, "cursor": nil}
+	for {
+		var data struct {
+			Organization *struct {
+				Teams struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Slug        string `json:"slug"`
+						Name        string `json:"name"`
+						Description string `json:"description"`
+						URL         string `json:"url"`
+						Members     struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"members"`
+					} `json:"nodes"`
+				} `json:"teams"`
+			} `json:"organization"`
+		}
+		if err := c.graphQL(ctx, orgTeamsQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("failed to list teams for org %s: %w", org, err)
+		}
+		if data.Organization == nil {
+			return nil, fmt.Errorf("failed to list teams: %s is not an organization", org)
+		}
+		for _, n := range data.Organization.Teams.Nodes {
+			teams = append(teams, Team{Slug: n.Slug, Name: n.Name, Description: n.Description, URL: n.URL, Members: n.Members.TotalCount})
+		}
+		page := data.Organization.Teams.PageInfo
+		if !page.HasNextPage {
+			break
+		}
+		vars["cursor"] = page.EndCursor
+	}
+	return teams, nil
+}
+
+const teamMembersQuery = `query($org: String!, $slug: String!, $cursor: String) {
+  organization(login: $org) {
+    team(slug: $slug) {
+      members(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { login name email }
+      }
+    }
+  }
+}`
+
+// TeamMembers returns the members of a team, including child teams.
+func (c *Client) TeamMembers(ctx context.Context, org, slug string) ([]Member, error) {
+	var members []Member
+	vars := map[string]any{"org": org, "slug": slug, "cursor": nil}
+	for {
+		var data struct {
+			Organization *struct {
+				Team *struct {
+					Members memberPage `json:"members"`
+				} `json:"team"`
+			} `json:"organization"`
+		}
+		if err := c.graphQL(ctx, teamMembersQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("failed to list members of team %s: %w", slug, err)
+		}
+		if data.Organization == nil || data.Organization.Team == nil {
+			return nil, fmt.Errorf("team %s not found in %s", slug, org)
+		}
+		page := data.Organization.Team.Members
+		members = append(members, page.members()...)
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		vars["cursor"] = page.PageInfo.EndCursor
+	}
+	return members, nil
+}
+
+const orgMembersQuery = `query($org: String!, $cursor: String) {
+  organization(login: $org) {
+    membersWithRole(first: 100, after: $cursor) {
+      pageInfo { hasNextPage endCursor }
+      nodes { login name email }
+    }
+  }
+}`
+
+// OrgMembers returns all members of the organization.
+func (c *Client) OrgMembers(ctx context.Context, org string) ([]Member, error) {
+	var members []Member
+	vars := map[string]any{"org": org, "cursor": nil}
+	for {
+		var data struct {
+			Organization *struct {
+				MembersWithRole memberPage `json:"membersWithRole"`
+			} `json:"organization"`
+		}
+		if err := c.graphQL(ctx, orgMembersQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("failed to list members of %s: %w", org, err)
+		}
+		if data.Organization == nil {
+			return nil, fmt.Errorf("failed to list members: %s is not an organization", org)
+		}
+		page := data.Organization.MembersWithRole
+		members = append(members, page.members()...)
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		vars["cursor"] = page.PageInfo.EndCursor
+	}
+	return members, nil
+}
+
+// memberPage is one page of a GraphQL user connection.
+type memberPage struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []struct {
+		Login string `json:"login"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"nodes"`
+}
+
+func (p memberPage) members() []Member {
+	out := make([]Member, len(p.Nodes))
+	for i, n := range p.Nodes {
+		out[i] = Member{Login: n.Login, Name: n.Name, Email: n.Email}
+	}
+	return out
+}
+
+// RepoTeams returns the teams with access to a repository and their
+// permission, strongest first.
+func (c *Client) RepoTeams(ctx context.Context, owner, repo string) ([]Team, error) {
+	teams, _, err := c.api.Repositories.ListTeams(ctx, owner, repo, &gh.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams of %s/%s: %w", owner, repo, err)
+	}
+	rank := map[string]int{"admin": 0, "maintain": 1, "push": 2, "triage": 3, "pull": 4}
+	out := make([]Team, 0, len(teams))
+	for _, t := range teams {
+		out = append(out, Team{Slug: t.GetSlug(), Name: t.GetName(), Description: t.GetDescription(), URL: t.GetHTMLURL(), Permission: t.GetPermission()})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return rank[out[i].Permission] < rank[out[j].Permission] })
+	return out, nil
+}
+
+// codeownersPaths are where GitHub looks for a CODEOWNERS file, in order.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeownersRule is one line of a CODEOWNERS file.
+type CodeownersRule struct {
+	Pattern string
+	Owners  []string // "@user", "@org/team" or emails
+}
+
+// GetCodeowners returns the repository's CODEOWNERS rules and the file they
+// came from, or nil when there is no CODEOWNERS file.
+func (c *Client) GetCodeowners(ctx context.Context, owner, repo, ref string) ([]CodeownersRule, string, error) {
+	for _, p := range codeownersPaths {
+		content, found, err := c.optionalFile(ctx, owner, repo, p, ref)
+		if err != nil {
+			return nil, "", err
+		}
+		if found {
+			return ParseCodeowners(content), p, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// ParseCodeowners parses the contents of a CODEOWNERS file.
+func ParseCodeowners(content string) []CodeownersRule {
+	var rules []CodeownersRule
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, CodeownersRule{Pattern: fields[0], Owners: fields[1:]})
+	}
+	return rules
+}
+
+// CodeownersFor returns the rule that owns file — the last matching one,
+// as GitHub applies them — or nil. Patterns follow gitignore rules, except
+// that "**" is only understood as a trailing "/**".
+func CodeownersFor(rules []CodeownersRule, file string) *CodeownersRule {
+	file = strings.TrimPrefix(path.Clean("/"+file), "/")
+	for i := len(rules) - 1; i >= 0; i-- {
+		if codeownersMatch(rules[i].Pattern, file) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func codeownersMatch(pattern, file string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	p := strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/**")
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	if p == "*" || p == "**" || p == "" {
+		return true
+	}
+	if !anchored && !strings.Contains(p, "/") {
+		// Unanchored name: matches any path component.
+		parts := strings.Split(file, "/")
+		if dirOnly {
+			parts = parts[:len(parts)-1]
+		}
+		for _, part := range parts {
+			if ok, _ := path.Match(p, part); ok {
+				return true
+			}
+		}
+		return false
+	}
+	parts := strings.Split(file, "/")
+	for n := 1; n <= len(parts); n++ {
+		prefix := strings.Join(parts[:n], "/")
+		if ok, _ := path.Match(p, prefix); ok && (n < len(parts) || !dirOnly) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUser returns a GitHub user's canonical login, name and public email,
+// or an error when the user doesn't exist.
+func (c *Client) GetUser(ctx context.Context, login string) (*Member, error) {
+	user, _, err := c.api.Users.Get(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", login, err)
+	}
+	return &Member{Login: user.GetLogin(), Name: user.GetName(), Email: user.GetEmail()}, nil
+}
+
+// UserMatch is a GitHub account found for a person, and how it was found.
+type UserMatch struct {
+	Login  string
+	Name   string
+	Method string // "org member email", "org member name", "commit email", "public email"
+}
+
+// FindUser looks up the GitHub account of a person by email and/or name:
+// first among org members (public email, then exact name), then by commits
+// authored with the email in the org, then by public profile email.
+func (c *Client) FindUser(ctx context.Context, org, email, name string) ([]UserMatch, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	name = strings.TrimSpace(name)
+	if email == "" && name == "" {
+		return nil, fmt.Errorf("email or name is required")
+	}
+
+	// Member listing fails for user accounts and tokens without org read
+	// access; the searches below still work then.
+	members, membersErr := c.OrgMembers(ctx, org)
+	var byName []UserMatch
+	for _, m := range members {
+		if email != "" && strings.EqualFold(m.Email, email) {
+			return []UserMatch{{Login: m.Login, Name: m.Name, Method: "org member email"}}, nil
+		}
+		if name != "" && strings.EqualFold(m.Name, name) {
+			byName = append(byName, UserMatch{Login: m.Login, Name: m.Name, Method: "org member name"})
+		}
+	}
+	if email != "" {
+		if match := c.userByCommitEmail(ctx, org, email); match != nil {
+			return []UserMatch{*match}, nil
+		}
+	}
+	if len(byName) > 0 {
+		return byName, nil
+	}
+	if email == "" {
+		return nil, membersErr
+	}
+
+	res, _, err := c.api.Search.Users(ctx, email+" in:email", &gh.SearchOptions{ListOptions: gh.ListOptions{PerPage: 5}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	var out []UserMatch
+	for _, u := range res.Users {
+		out = append(out, UserMatch{Login: u.GetLogin(), Name: u.GetName(), Method: "public email"})
+	}
+	return out, nil
+}
+
+// userByCommitEmail finds the account that authored a commit in org with
+// email. Works for private emails, as long as the person has committed
+// with it.
+func (c *Client) userByCommitEmail(ctx context.Context, org, email string) *UserMatch {
+	res, _, err := c.api.Search.Commits(ctx, fmt.Sprintf("author-email:%s org:%s", email, org), &gh.SearchOptions{ListOptions: gh.ListOptions{PerPage: 5}})
+	if err != nil {
+		return nil
+	}
+	for _, cr := range res.Commits {
+		if login := cr.GetAuthor().GetLogin(); login != "" {
+			return &UserMatch{Login: login, Name: cr.GetCommit().GetAuthor().GetName(), Method: "commit email"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Project is a GitHub Projects (v2) board.
+type Project struct {
+	ID     string
+	Number int
+	Title  string
+	URL    string
+	Closed bool
+	Fields []ProjectField
+}
+
+// ProjectField is a board field. Options is set for single-select fields
+// such as Status.
+type ProjectField struct {
+	ID      string
+	Name    string
+	Options []ProjectFieldOption
+}
+
+// ProjectFieldOption is one value of a single-select field.
+type ProjectFieldOption struct {
+	ID   string
+	Name string
+}
+
+// ProjectItem is an issue, pull request, or draft on a board.
+type ProjectItem struct {
+	ID     string
+	Type   string // "ISSUE", "PULL_REQUEST", "DRAFT_ISSUE", "REDACTED"
+	Title  string
+	URL    string
+	Repo   string
+	State  string
+	Status string // value of the board's Status field
+}
+
+// Field returns the field with the given name (case-insensitive), or nil.
+func (p *Project) Field(name string) *ProjectField {
+	for i := range p.Fields {
+		if strings.EqualFold(p.Fields[i].Name, name) {
+			return &p.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Option returns the option with the given name (case-insensitive), or nil.
+func (f *ProjectField) Option(name string) *ProjectFieldOption {
+	for i := range f.Options {
+		if strings.EqualFold(f.Options[i].Name, name) {
+			return &f.Options[i]
+		}
+	}
+	return nil
+}
+
+const listProjectsQuery = `query($owner: String!) {
+  repositoryOwner(login: $owner) {
+    ... on ProjectV2Owner {
+      projectsV2(first: 50, orderBy: {field: UPDATED_AT, direction: DESC}) {
+        nodes { id number title url closed }
+      }
+    }
+  }
+}`
+
+// ListProjects returns the owner's project boards, most recently updated first.
+func (c *Client) ListProjects(ctx context.Context, owner string) ([]Project, error) {
+	var data struct {
+		RepositoryOwner *struct {
+			ProjectsV2 struct {
+				Nodes []struct {
+					ID     string `json:"id"`
+					Number int    `json:"number"`
+					Title  string `json:"title"`
+					URL    string `json:"url"`
+					Closed bool   `json:"closed"`
+				} `json:"nodes"`
+			} `json:"projectsV2"`
+		} `json:"repositoryOwner"`
+	}
+	if err := c.graphQL(ctx, listProjectsQuery, map[string]any{"owner": owner}, &data); err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	if data.RepositoryOwner == nil {
+		return nil, fmt.Errorf("failed to list projects: owner %s not found", owner)
+	}
+	var out []Project
+	for _, n := range data.RepositoryOwner.ProjectsV2.Nodes {
+		out = append(out, Project{ID: n.ID, Number: n.Number, Title: n.Title, URL: n.URL, Closed: n.Closed})
+	}
+	return out, nil
+}
+
+const getProjectQuery = `query($owner: String!, $number: Int!) {
+  repositoryOwner(login: $owner) {
+    ... on ProjectV2Owner {
+      projectV2(number: $number) {
+        id number title url closed
+        fields(first: 50) {
+          nodes {
+            ... on ProjectV2FieldCommon { id name }
+            ... on ProjectV2SingleSelectField { options { id name } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// GetProject returns a board with its fields.
+func (c *Client) GetProject(ctx context.Context, owner string, number int) (*Project, error) {
+	var data struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				ID     string `json:"id"`
+				Number int    `json:"number"`
+				Title  string `json:"title"`
+				URL    string `json:"url"`
+				Closed bool   `json:"closed"`
+				Fields struct {
+					Nodes []struct {
+						ID      string `json:"id"`
+						Name    string `json:"name"`
+						Options []struct {
+							ID   string `json:"id"`
+							Name string `json:"name"`
+						} `json:"options"`
+					} `json:"nodes"`
+				} `json:"fields"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	vars := map[string]any{"owner": owner, "number": number}
+	if err := c.graphQL(ctx, getProjectQuery, vars, &data); err != nil {
+		return nil, fmt.Errorf("failed to get project %d: %w", number, err)
+	}
+	if data.RepositoryOwner == nil || data.RepositoryOwner.ProjectV2 == nil {
+		return nil, fmt.Errorf("project %d not found for %s", number, owner)
+	}
+	p := data.RepositoryOwner.ProjectV2
+	project := &Project{ID: p.ID, Number: p.Number, Title: p.Title, URL: p.URL, Closed: p.Closed}
+	for _, f := range p.Fields.Nodes {
+		field := ProjectField{ID: f.ID, Name: f.Name}
+		for _, o := range f.Options {
+			field.Options = append(field.Options, ProjectFieldOption{ID: o.ID, Name: o.Name})
+		}
+		project.Fields = append(project.Fields, field)
+	}
+	return project, nil
+}
+
+const projectItemsQuery = `query($id: ID!, $cursor: String) {
+  node(id: $id) {
+    ... on ProjectV2 {
+      items(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id type
+          status: fieldValueByName(name: "Status") { ... on ProjectV2ItemFieldSingleSelectValue { name } }
+          content {
+            ... on Issue { title url state repository { nameWithOwner } }
+            ... on PullRequest { title url state repository { nameWithOwner } }
+            ... on DraftIssue { title }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// ListProjectItems returns up to limit items on a board.
+func (c *Client) ListProjectItems(ctx context.Context, projectID string, limit int) ([]ProjectItem, error) {
+	var items []ProjectItem
+	vars := map[string]any{"id": projectID, "cursor": nil}
+	for len(items) < limit {
+		var data struct {
+			Node *struct {
+				Items struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						ID     string `json:"id"`
+						Type   string `json:"type"`
+						Status *struct {
+							Name string `json:"name"`
+						} `json:"status"`
+						Content *struct {
+							Title      string `json:"title"`
+							URL        string `json:"url"`
+							State      string `json:"state"`
+							Repository *struct {
+								NameWithOwner string `json:"nameWithOwner"`
+							} `json:"repository"`
+						} `json:"content"`
+					} `json:"nodes"`
+				} `json:"items"`
+			} `json:"node"`
+		}
+		if err := c.graphQL(ctx, projectItemsQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("failed to list project items: %w", err)
+		}
+		if data.Node == nil {
+			return nil, fmt.Errorf("failed to list project items: project not found")
+		}
+		for _, n := range data.Node.Items.Nodes {
+			item := ProjectItem{ID: n.ID, Type: n.Type}
+			if n.Status != nil {
+				item.Status = n.Status.Name
+			}
+			if n.Content != nil {
+				item.Title, item.URL, item.State = n.Content.Title, n.Content.URL, strings.ToLower(n.Content.State)
+				if n.Content.Repository != nil {
+					item.Repo = n.Content.Repository.NameWithOwner
+				}
+			}
+			items = append(items, item)
+		}
+		page := data.Node.Items.PageInfo
+		if !page.HasNextPage {
+			break
+		}
+		vars["cursor"] = page.EndCursor
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// issueOrPRURLRe matches GitHub issue and pull request URLs.
+var issueOrPRURLRe = regexp.MustCompile(`https://github\.com/([^/]+)/([^/]+)/(issues|pull)/(\d+)`)
+
+// ParseIssueOrPRURL extracts owner and repo from a GitHub issue or pull
+// request URL.
+func ParseIssueOrPRURL(rawURL string) (owner, repo string, err error) {
+	m := issueOrPRURLRe.FindStringSubmatch(rawURL)
+	if m == nil {
+		return "", "", fmt.Errorf("not a GitHub issue or pull request URL: %s", rawURL)
+	}
+	return m[1], m[2], nil
+}
+
+// contentNodeID returns the GraphQL node ID of an issue or pull request URL.
+func (c *Client) contentNodeID(ctx context.Context, rawURL string) (string, error) {
+	m := issueOrPRURLRe.FindStringSubmatch(rawURL)
+	if m == nil {
+		return "", fmt.Errorf("not a GitHub issue or pull request URL: %s", rawURL)
+	}
+	number, _ := strconv.Atoi(m[4])
+	if m[3] == "pull" {
+		pr, _, err := c.api.PullRequests.Get(ctx, m[1], m[2], number)
+		if err != nil {
+			return "", fmt.Errorf("failed to get PR #%d: %w", number, err)
+		}
+		return pr.GetNodeID(), nil
+	}
+	issue, _, err := c.api.Issues.Get(ctx, m[1], m[2], number)
+	if err != nil {
+		return "", fmt.Errorf("failed to get issue #%d: %w", number, err)
+	}
+	return issue.GetNodeID(), nil
+}
+
+const addProjectItemMutation = `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}`
+
+// AddProjectItem adds an issue or pull request (by URL) to a board and
+// returns the item ID. Adding an item that is already on the board returns
+// the existing item.
+func (c *Client) AddProjectItem(ctx context.Context, projectID, contentURL string) (string, error) {
+	contentID, err := c.contentNodeID(ctx, contentURL)
+	if err != nil {
+		return "", err
+	}
+	var data struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	vars := map[string]any{"project": projectID, "content": contentID}
+	if err := c.graphQL(ctx, addProjectItemMutation, vars, &data); err != nil {
+		return "", fmt.Errorf("failed to add %s to project: %w", contentURL, err)
+	}
+	return data.AddProjectV2ItemByID.Item.ID, nil
+}
+
+const setProjectFieldMutation = `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) {
+    projectV2Item { id }
+  }
+}`
+
+// SetProjectItemOption sets a single-select field (e.g. Status) on a board item.
+func (c *Client) SetProjectItemOption(ctx context.Context, projectID, itemID, fieldID, optionID string) error {
+	var data struct{}
+	vars := map[string]any{"project": projectID, "item": itemID, "field": fieldID, "option": optionID}
+	if err := c.graphQL(ctx, setProjectFieldMutation, vars, &data); err != nil {
+		return fmt.Errorf("failed to update project item: %w", err)
+	}
+	return nil
+}
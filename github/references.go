@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// referenceURLPattern splits a github.com link into owner, repo, the kind
+// of artifact and the rest of the path.
+var referenceURLPattern = regexp.MustCompile(`^https://github\.com/([\w.-]+)/([\w.-]+?)(?:\.git)?(?:/(pull|issues|commit|blob|tree|actions/runs)/([^?#]+))?/?(?:[?#].*)?$`)
+
+// ReferenceExists reports whether a github.com link points to an existing
+// repository, pull request, issue, commit, file, directory or workflow run.
+// Links it can't check (other hosts, or pages such as /settings) are
+// reported as existing.
+func (c *Client) ReferenceExists(ctx context.Context, rawURL string) (bool, error) {
+	m := referenceURLPattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return true, nil
+	}
+	owner, repo, kind, rest := m[1], m[2], m[3], strings.TrimSuffix(m[4], "/")
+
+	var err error
+	switch kind {
+	case "":
+		_, _, err = c.api.Repositories.Get(ctx, owner, repo)
+	case "pull", "issues":
+		// Pull requests are issues too, so one lookup covers both.
+		n, convErr := strconv.Atoi(strings.SplitN(rest, "/", 2)[0])
+		if convErr != nil {
+			return true, nil
+		}
+		_, _, err = c.api.Issues.Get(ctx, owner, repo, n)
+	case "commit":
+		_, _, err = c.api.Repositories.GetCommitSHA1(ctx, owner, repo, rest, "")
+	case "blob", "tree":
+		// The ref is taken to be the first segment. A miss only counts when
+		// that ref exists: otherwise it may be a ref containing a slash
+		// (e.g. feature/x), which can't be told apart from the path.
+		ref, path, _ := strings.Cut(rest, "/")
+		if path, err = url.PathUnescape(path); err != nil {
+			return true, nil
+		}
+		_, _, _, err = c.api.Repositories.GetContents(ctx, owner, repo, path, &gh.RepositoryContentGetOptions{Ref: ref})
+		if isNotFound(err) {
+			if _, _, refErr := c.api.Repositories.GetCommitSHA1(ctx, owner, repo, ref, ""); refErr != nil {
+				return true, nil
+			}
+		}
+	case "actions/runs":
+		id, convErr := strconv.ParseInt(strings.SplitN(rest, "/", 2)[0], 10, 64)
+		if convErr != nil {
+			return true, nil
+		}
+		_, _, err = c.api.Actions.GetWorkflowRunByID(ctx, owner, repo, id)
+	}
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", rawURL, err)
+	}
+	return true, nil
+}
+
+func isNotFound(err error) bool {
+	var ghErr *gh.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
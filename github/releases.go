@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// Comparison is the difference between two refs.
+type Comparison struct {
+	Status       string // "ahead", "behind", "diverged", "identical"
+	AheadBy      int
+	BehindBy     int
+	TotalCommits int
+	Commits      []CommitInfo // oldest first; GitHub returns at most 250
+	Files        []ChangedFile
+	URL          string
+}
+
+// ChangedFile is a file changed between two refs.
+type ChangedFile struct {
+	Path      string
+	Status    string // "added", "modified", "removed", "renamed", ...
+	Additions int
+	Deletions int
+}
+
+// CompareRefs compares head against base (branches, tags or SHAs).
+func (c *Client) CompareRefs(ctx context.Context, owner, repo, base, head string) (*Comparison, error) {
+	cmp, _, err := c.api.Repositories.CompareCommits(ctx, owner, repo, base, head, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %w", base, head, err)
+	}
+	out := &Comparison{
+		Status:       cmp.GetStatus(),
+		AheadBy:      cmp.GetAheadBy(),
+		BehindBy:     cmp.GetBehindBy(),
+		TotalCommits: cmp.GetTotalCommits(),
+		URL:          cmp.GetHTMLURL(),
+	}
+	for _, rc := range cmp.Commits {
+		author := rc.GetAuthor().GetLogin()
+		if author == "" {
+			author = rc.GetCommit().GetAuthor().GetName()
+		}
+		msg, _, _ := strings.Cut(rc.GetCommit().GetMessage(), "\n")
+		out.Commits = append(out.Commits, CommitInfo{
+			SHA:     rc.GetSHA(),
+			Author:  author,
+			Date:    rc.GetCommit().GetAuthor().GetDate().Time,
+			Message: msg,
+			URL:     rc.GetHTMLURL(),
+		})
+	}
+	for _, f := range cmp.Files {
+		out.Files = append(out.Files, ChangedFile{
+			Path:      f.GetFilename(),
+			Status:    f.GetStatus(),
+			Additions: f.GetAdditions(),
+			Deletions: f.GetDeletions(),
+		})
+	}
+	return out, nil
+}
+
+// ReleaseOptions describes a release to create.
+type ReleaseOptions struct {
+	Tag           string
+	Target        string // branch or SHA to tag when Tag doesn't exist yet
+	Name          string
+	Body          string
+	Draft         bool
+	Prerelease    bool
+	GenerateNotes bool // let GitHub append its generated notes to Body
+}
+
+// ReleaseExists reports whether a release already exists for tag.
+func (c *Client) ReleaseExists(ctx context.Context, owner, repo, tag string) (bool, error) {
+	_, _, err := c.api.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	var ghErr *gh.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get release %s: %w", tag, err)
+	}
+	return true, nil
+}
+
+// CreateRelease creates a GitHub release and returns its URL.
+func (c *Client) CreateRelease(ctx context.Context, owner, repo string, opts ReleaseOptions) (string, error) {
+	rel := &gh.RepositoryRelease{
+		TagName:              gh.String(opts.Tag),
+		Name:                 gh.String(opts.Name),
+		Body:                 gh.String(opts.Body),
+		Draft:                gh.Bool(opts.Draft),
+		Prerelease:           gh.Bool(opts.Prerelease),
+		GenerateReleaseNotes: gh.Bool(opts.GenerateNotes),
+	}
+	if opts.Target != "" {
+		rel.TargetCommitish = gh.String(opts.Target)
+	}
+	created, _, err := c.api.Repositories.CreateRelease(ctx, owner, repo, rel)
+	if err != nil {
+		return "", fmt.Errorf("failed to create release %s: %w", opts.Tag, err)
+	}
+	return created.GetHTMLURL(), nil
+}
@@ -0,0 +1,215 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+const (
+	defaultLLMMaxRetries = 3
+	retryBaseDelay       = 1 * time.Second
+	retryMaxDelay        = 30 * time.Second
+
+	// breakerThreshold is the number of consecutive failed requests (after
+	// retries) that opens the circuit; breakerCooldown is how long it stays
+	// open before a single trial request is let through (half-open). The
+	// trial's outcome closes or re-opens it.
+	breakerThreshold = 5
+	breakerCooldown  = 60 * time.Second
+)
+
+// ErrLLMUnavailable is returned without contacting the backend while the
+// circuit breaker is open because recent requests kept failing.
+var ErrLLMUnavailable = errors.New("LLM backend is temporarily unavailable")
+
+// IsLLMUnavailable reports whether err indicates the circuit breaker is open.
+func IsLLMUnavailable(err error) bool {
+	return errors.Is(err, ErrLLMUnavailable)
+}
+
+// circuitBreaker tracks consecutive transient failures for one backend.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openUntil time.Time
+	probing   bool // a trial request is in flight while half-open
+}
+
+// allow returns false while the circuit is open. Once the cooldown has
+// passed it lets exactly one trial request through, reported by probe; the
+// caller must call endProbe when that request is done.
+func (b *circuitBreaker) allow() (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case !b.open:
+		return true, false
+	case time.Now().Before(b.openUntil) || b.probing:
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// endProbe ends a trial request whose outcome was neither a success nor a
+// transient failure (e.g. the caller gave up), so the next request probes.
+func (b *circuitBreaker) endProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.open = false
+	b.probing = false
+}
+
+// failure records a failed request and reports whether it opened the circuit.
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= breakerThreshold {
+		b.open = true
+		b.openUntil = time.Now().Add(breakerCooldown)
+		b.failures = breakerThreshold - 1 // one more failure after cooldown re-opens
+		return true
+	}
+	return false
+}
+
+// SetMaxRetries sets how many times a transient failure (429, 5xx, network
+// error) is retried before giving up. Zero disables retries.
+func (m *ModelsClient) SetMaxRetries(n int) {
+	if n < 0 {
+		n = 0
+	}
+	m.maxRetries = n
+}
+
+// isRetryableStatus returns true for HTTP statuses worth retrying.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		529: // Anthropic "overloaded"
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the server-requested delay from Azure's retry-after-ms or
+// the standard Retry-After header (seconds or HTTP date). Returns 0 if absent.
+func retryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.Atoi(h.Get("retry-after-ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// backoff returns the exponential delay (with jitter) before retry attempt n (0-based).
+func backoff(n int) time.Duration {
+	d := retryBaseDelay << n
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// doRequest sends the request built by newReq, retrying transient failures
+// with exponential backoff and honoring Retry-After; a Retry-After longer than
+// retryMaxDelay ends the retries instead. newReq is called once per
+// attempt so the body can be re-read. It returns the final status code and
+// body; non-2xx statuses are not errors here so callers can inspect the body.
+func (m *ModelsClient) doRequest(ctx context.Context, newReq func() (*http.Request, error)) (int, []byte, error) {
+	ok, probe := m.breaker.allow()
+	if !ok {
+		return 0, nil, ErrLLMUnavailable
+	}
+	if probe {
+		defer m.breaker.endProbe()
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var (
+			status int
+			body   []byte
+			wait   time.Duration
+		)
+		resp, err := m.httpClient.Do(req)
+		if err == nil {
+			status = resp.StatusCode
+			wait = retryAfter(resp.Header)
+			body, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				err = fmt.Errorf("failed to read response body: %w", err)
+			}
+		}
+
+		transient := (err != nil && ctx.Err() == nil) || isRetryableStatus(status)
+		if !transient {
+			if err == nil {
+				m.breaker.success()
+			}
+			return status, body, err
+		}
+
+		// Don't undercut a server that asks for a longer pause than we are
+		// willing to wait; give up instead.
+		tooLong := wait > retryMaxDelay
+		if attempt >= m.maxRetries || tooLong {
+			if tooLong {
+				logging.Ctx(ctx).Warnf("[llm] %s asked to retry after %s, longer than %s; giving up", m.model, wait.Round(time.Second), retryMaxDelay)
+			}
+			if m.breaker.failure() {
+				logging.Ctx(ctx).Infof("[llm] circuit breaker opened for %s after repeated failures; pausing requests for %s", m.model, breakerCooldown)
+			}
+			return status, body, err
+		}
+
+		if wait <= 0 {
+			wait = backoff(attempt)
+		}
+		if err != nil {
+			logging.Ctx(ctx).Warnf("[llm] request to %s failed (%v), retrying in %s (attempt %d/%d)", m.model, err, wait.Round(time.Millisecond), attempt+1, m.maxRetries)
+		} else {
+			logging.Ctx(ctx).Warnf("[llm] %s returned %d, retrying in %s (attempt %d/%d)", m.model, status, wait.Round(time.Millisecond), attempt+1, m.maxRetries)
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// NewRepoOptions describes a repository to generate from a template.
+type NewRepoOptions struct {
+	Name        string
+	Description string
+	Visibility  string // "private", "internal" or "public"
+}
+
+// CreateRepoFromTemplate generates owner/opts.Name from the template
+// repository templateOwner/templateRepo and returns the new repository's URL
+// and default branch. GitHub copies the template's files in the background,
+// so it waits (up to about 30s) until the default branch exists.
+func (c *Client) CreateRepoFromTemplate(ctx context.Context, templateOwner, templateRepo, owner string, opts NewRepoOptions) (url, defaultBranch string, err error) {
+	tmpl, _, err := c.api.Repositories.Get(ctx, templateOwner, templateRepo)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get template %s/%s: %w", templateOwner, templateRepo, err)
+	}
+	if !tmpl.GetIsTemplate() {
+		return "", "", fmt.Errorf("%s/%s is not a template repository (enable \"Template repository\" in its settings)", templateOwner, templateRepo)
+	}
+
+	repo, _, err := c.api.Repositories.CreateFromTemplate(ctx, templateOwner, templateRepo, &gh.TemplateRepoRequest{
+		Name:        gh.String(opts.Name),
+		Owner:       gh.String(owner),
+		Description: gh.String(opts.Description),
+		Private:     gh.Bool(opts.Visibility != "public"),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create %s/%s from template: %w", owner, opts.Name, err)
+	}
+	// The generate endpoint only knows private and public.
+	if opts.Visibility == "internal" {
+		if _, _, err := c.api.Repositories.Edit(ctx, owner, opts.Name, &gh.Repository{Visibility: gh.String("internal")}); err != nil {
+			return repo.GetHTMLURL(), "", fmt.Errorf("created %s/%s but failed to make it internal: %w", owner, opts.Name, err)
+		}
+	}
+
+	defaultBranch = repo.GetDefaultBranch()
+	if defaultBranch == "" {
+		defaultBranch = tmpl.GetDefaultBranch()
+	}
+	for range 15 {
+		_, _, err = c.api.Repositories.GetBranch(ctx, owner, opts.Name, defaultBranch, 0)
+		var ghErr *gh.ErrorResponse
+		if err == nil || !errors.As(err, &ghErr) || ghErr.Response == nil || ghErr.Response.StatusCode != http.StatusNotFound {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return repo.GetHTMLURL(), "", ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	if err != nil {
+		return repo.GetHTMLURL(), "", fmt.Errorf("created %s/%s but its %s branch is not ready yet: %w", owner, opts.Name, defaultBranch, err)
+	}
+	return repo.GetHTMLURL(), defaultBranch, nil
+}
+
+// AddTeamToRepo grants the organization team slug permission ("pull",
+// "triage", "push", "maintain" or "admin") on org/repo.
+func (c *Client) AddTeamToRepo(ctx context.Context, org, slug, repo, permission string) error {
+	_, err := c.api.Teams.AddTeamRepoBySlug(ctx, org, slug, org, repo, &gh.TeamAddTeamRepoOptions{Permission: permission})
+	if err != nil {
+		return fmt.Errorf("failed to give team %s %s access to %s: %w", slug, permission, repo, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package github
+
+import "context"
+
+// Usage is the token usage reported by the LLM backend for one request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Attribution identifies who an LLM request was made for, so usage can be
+// aggregated per agent, Slack user, and channel.
+type Attribution struct {
+	AgentID   string
+	UserID    string
+	ChannelID string
+}
+
+// UsageRecorder receives token usage after every successful LLM request.
+type UsageRecorder interface {
+	RecordUsage(a Attribution, model string, u Usage)
+}
+
+// UsageRecorders passes usage on to each of several recorders.
+type UsageRecorders []UsageRecorder
+
+// RecordUsage implements UsageRecorder.
+func (rs UsageRecorders) RecordUsage(a Attribution, model string, u Usage) {
+	for _, r := range rs {
+		r.RecordUsage(a, model, u)
+	}
+}
+
+type attributionKey struct{}
+
+// WithAttribution returns a context carrying a, used to attribute LLM usage.
+func WithAttribution(ctx context.Context, a Attribution) context.Context {
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// AttributionFrom returns the attribution stored in ctx, if any.
+func AttributionFrom(ctx context.Context) Attribution {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	return a
+}
+
+// SetUsageRecorder registers r to receive token usage for every request.
+func (m *ModelsClient) SetUsageRecorder(r UsageRecorder) {
+	m.usageRecorder = r
+}
+
+// recordUsage forwards resp's usage (when reported) to the usage recorder.
+func (m *ModelsClient) recordUsage(ctx context.Context, resp *ChatResponse) {
+	if m.usageRecorder == nil || resp == nil || resp.Usage == nil {
+		return
+	}
+	m.usageRecorder.RecordUsage(AttributionFrom(ctx), m.model, *resp.Usage)
+}
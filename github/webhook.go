@@ -0,0 +1,95 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	gh "github.com/google/go-github/v60/github"
+)
+
+// WebhookEvent is the subset of a GitHub webhook delivery the bot reacts to.
+type WebhookEvent struct {
+	Type       string // X-GitHub-Event: "workflow_run", "pull_request", "ping", ...
+	DeliveryID string // X-GitHub-Delivery
+	Action     string
+	Repo       string // owner/name
+	Title      string // workflow name or pull request title
+	URL        string // workflow run or pull request HTML URL
+	Branch     string // head branch, or the branch pushed to
+	Conclusion string // workflow run conclusion
+	Number     int    // pull request number
+	Actor      string // login that triggered the run or opened the PR
+	Sender     string // login that performed the action
+	Body       string // pull request description
+	Draft      bool
+	Merged     bool
+}
+
+// WorkflowFailed reports whether the event is a completed, failed workflow run.
+func (e *WebhookEvent) WorkflowFailed() bool {
+	return e.Type == "workflow_run" && e.Action == "completed" && e.Conclusion == "failure"
+}
+
+// PullRequestOpened reports whether the event is a newly opened pull request.
+func (e *WebhookEvent) PullRequestOpened() bool {
+	return e.Type == "pull_request" && e.Action == "opened"
+}
+
+// BranchPushed reports whether the event is a push to a branch (not a tag).
+func (e *WebhookEvent) BranchPushed() bool {
+	return e.Type == "push" && e.Branch != ""
+}
+
+// ParseWebhook verifies the X-Hub-Signature-256 of r against secret and
+// decodes the delivery. Event types other than workflow_run, pull_request
+// and push are returned with only Type and DeliveryID set.
+func ParseWebhook(r *http.Request, secret string) (*WebhookEvent, error) {
+	payload, err := gh.ValidatePayload(r, []byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook signature: %w", err)
+	}
+	ev := &WebhookEvent{Type: gh.WebHookType(r), DeliveryID: gh.DeliveryID(r)}
+	if ev.Type != "workflow_run" && ev.Type != "pull_request" && ev.Type != "push" {
+		return ev, nil
+	}
+	parsed, err := gh.ParseWebHook(ev.Type, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s payload: %w", ev.Type, err)
+	}
+
+	switch e := parsed.(type) {
+	case *gh.WorkflowRunEvent:
+		run := e.GetWorkflowRun()
+		ev.Action = e.GetAction()
+		ev.Repo = e.GetRepo().GetFullName()
+		ev.Title = run.GetName()
+		ev.URL = run.GetHTMLURL()
+		ev.Branch = run.GetHeadBranch()
+		ev.Conclusion = run.GetConclusion()
+		ev.Actor = run.GetActor().GetLogin()
+	case *gh.PullRequestEvent:
+		pr := e.GetPullRequest()
+		ev.Action = e.GetAction()
+		ev.Repo = e.GetRepo().GetFullName()
+		ev.Title = pr.GetTitle()
+		ev.URL = pr.GetHTMLURL()
+		ev.Branch = pr.GetHead().GetRef()
+		ev.Number = e.GetNumber()
+		ev.Actor = pr.GetUser().GetLogin()
+		ev.Sender = e.GetSender().GetLogin()
+		ev.Body = pr.GetBody()
+		ev.Draft = pr.GetDraft()
+		ev.Merged = pr.GetMerged()
+	case *gh.PushEvent:
+		ev.Repo = e.GetRepo().GetFullName()
+		ev.Branch, _ = strings.CutPrefix(e.GetRef(), "refs/heads/")
+		if ev.Branch == e.GetRef() {
+			ev.Branch = "" // tag push
+		}
+		ev.Title = e.GetHeadCommit().GetMessage()
+		ev.URL = e.GetCompare()
+		ev.Sender = e.GetSender().GetLogin()
+	}
+	return ev, nil
+}
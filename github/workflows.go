@@ -0,0 +1,225 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	gh "github.com/google/go-github/v60/github"
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a GitHub Actions workflow and, when it can be triggered
+// manually, its workflow_dispatch inputs.
+type Workflow struct {
+	ID         int64
+	Name       string
+	File       string // file name under .github/workflows, e.g. "deploy.yml"
+	Path       string
+	State      string // "active", "disabled_manually", ...
+	Dispatch   bool   // has a workflow_dispatch trigger
+	Inputs     []WorkflowInput
+	ParseError string // set when the workflow file could not be read or parsed
+}
+
+// WorkflowInput is a single workflow_dispatch input.
+type WorkflowInput struct {
+	Name        string
+	Description string
+	Type        string // string, choice, boolean, number, environment
+	Required    bool
+	Default     string
+	Options     []string // for type choice
+}
+
+// ListWorkflows returns a repository's workflows, reading each workflow file
+// on ref (default branch when empty) to find its workflow_dispatch inputs.
+func (c *Client) ListWorkflows(ctx context.Context, owner, repo, ref string) ([]Workflow, error) {
+	var out []Workflow
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := c.api.Actions.ListWorkflows(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workflows: %w", err)
+		}
+		for _, w := range page.Workflows {
+			out = append(out, Workflow{
+				ID:    w.GetID(),
+				Name:  w.GetName(),
+				File:  path.Base(w.GetPath()),
+				Path:  w.GetPath(),
+				State: w.GetState(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	for i := range out {
+		w := &out[i]
+		content, _, err := c.GetFileContent(ctx, owner, repo, w.Path, ref)
+		if err != nil {
+			w.ParseError = err.Error()
+			continue
+		}
+		w.Dispatch, w.Inputs, err = ParseDispatchInputs([]byte(content))
+		if err != nil {
+			w.ParseError = err.Error()
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// WorkflowRunStatus is the latest run of a workflow on a branch.
+type WorkflowRunStatus struct {
+	Workflow   string // workflow name
+	Status     string // "completed", "in_progress", "queued", ...
+	Conclusion string // "success", "failure", "cancelled", ... once completed
+	Event      string // event that triggered the run, e.g. "push"
+	SHA        string
+	Actor      string
+	URL        string
+	UpdatedAt  time.Time
+}
+
+// LatestWorkflowRuns returns the latest run of each workflow among the 100
+// most recent runs on branch, sorted by workflow name. Workflows that
+// haven't run on branch recently are left out.
+func (c *Client) LatestWorkflowRuns(ctx context.Context, owner, repo, branch string) ([]WorkflowRunStatus, error) {
+	runs, _, err := c.api.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, &gh.ListWorkflowRunsOptions{
+		Branch:      branch,
+		ListOptions: gh.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow runs of %s/%s on %s: %w", owner, repo, branch, err)
+	}
+	var out []WorkflowRunStatus
+	seen := make(map[int64]bool)
+	for _, r := range runs.WorkflowRuns { // newest first
+		if seen[r.GetWorkflowID()] {
+			continue
+		}
+		seen[r.GetWorkflowID()] = true
+		out = append(out, WorkflowRunStatus{
+			Workflow:   r.GetName(),
+			Status:     r.GetStatus(),
+			Conclusion: r.GetConclusion(),
+			Event:      r.GetEvent(),
+			SHA:        r.GetHeadSHA(),
+			Actor:      r.GetActor().GetLogin(),
+			URL:        r.GetHTMLURL(),
+			UpdatedAt:  r.GetUpdatedAt().Time,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Workflow < out[j].Workflow })
+	return out, nil
+}
+
+// ParseDispatchInputs reports whether a workflow file has a
+// workflow_dispatch trigger and returns its inputs, sorted by name.
+func ParseDispatchInputs(content []byte) (bool, []WorkflowInput, error) {
+	var doc struct {
+		On yaml.Node `yaml:"on"`
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return false, nil, fmt.Errorf("invalid workflow YAML: %w", err)
+	}
+
+	switch doc.On.Kind {
+	case yaml.ScalarNode: // on: workflow_dispatch
+		return doc.On.Value == "workflow_dispatch", nil, nil
+	case yaml.SequenceNode: // on: [push, workflow_dispatch]
+		for _, n := range doc.On.Content {
+			if n.Value == "workflow_dispatch" {
+				return true, nil, nil
+			}
+		}
+		return false, nil, nil
+	case yaml.MappingNode:
+	default:
+		return false, nil, nil
+	}
+
+	var triggers map[string]*struct {
+		Inputs map[string]struct {
+			Description string   `yaml:"description"`
+			Type        string   `yaml:"type"`
+			Required    bool     `yaml:"required"`
+			Default     any      `yaml:"default"`
+			Options     []string `yaml:"options"`
+		} `yaml:"inputs"`
+	}
+	if err := doc.On.Decode(&triggers); err != nil {
+		return false, nil, fmt.Errorf("invalid workflow triggers: %w", err)
+	}
+	dispatch, ok := triggers["workflow_dispatch"]
+	if !ok {
+		return false, nil, nil
+	}
+	if dispatch == nil {
+		return true, nil, nil
+	}
+	var inputs []WorkflowInput
+	for name, in := range dispatch.Inputs {
+		typ := in.Type
+		if typ == "" {
+			typ = "string"
+		}
+		def := ""
+		if in.Default != nil {
+			def = fmt.Sprint(in.Default)
+		}
+		inputs = append(inputs, WorkflowInput{
+			Name:        name,
+			Description: in.Description,
+			Type:        typ,
+			Required:    in.Required,
+			Default:     def,
+			Options:     in.Options,
+		})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+	return true, inputs, nil
+}
+
+// DispatchWorkflow triggers a workflow_dispatch run of the workflow file on
+// ref, then briefly polls for the run it created. runURL is empty when the
+// run hasn't appeared yet.
+func (c *Client) DispatchWorkflow(ctx context.Context, owner, repo, file, ref string, inputs map[string]any) (runURL string, err error) {
+	started := time.Now().Add(-5 * time.Second)
+	_, err = c.api.Actions.CreateWorkflowDispatchEventByFileName(ctx, owner, repo, file, gh.CreateWorkflowDispatchEventRequest{
+		Ref:    ref,
+		Inputs: inputs,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to dispatch workflow %s: %w", file, err)
+	}
+
+	// The dispatch API doesn't return the run; it shows up a few seconds later.
+	for range 5 {
+		select {
+		case <-ctx.Done():
+			return "", nil
+		case <-time.After(2 * time.Second):
+		}
+		runs, _, err := c.api.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, file, &gh.ListWorkflowRunsOptions{
+			Event:       "workflow_dispatch",
+			Branch:      ref,
+			ListOptions: gh.ListOptions{PerPage: 5},
+		})
+		if err != nil {
+			return "", nil
+		}
+		for _, r := range runs.WorkflowRuns {
+			if r.GetCreatedAt().After(started) {
+				return r.GetHTMLURL(), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,244 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v60/github"
+	"gopkg.in/yaml.v3"
+)
+
+// Submodule is a git submodule declared in .gitmodules.
+type Submodule struct {
+	Path string
+	URL  string
+	Repo string // "owner/name" when URL points at github.com, else ""
+}
+
+// WorkspaceProject is a project inside a repository: a workspace member
+// (go.work, pnpm, lerna, npm/yarn workspaces) or a submodule.
+type WorkspaceProject struct {
+	Name      string // directory base name
+	Dir       string // path from the repository root
+	Source    string // "go.work", "pnpm-workspace.yaml", "lerna.json", "package.json", ".gitmodules"
+	Submodule *Submodule
+}
+
+var submoduleRepoRe = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(\.git)?/?$`)
+
+// optionalFile returns a file's content, or found=false when it doesn't exist.
+func (c *Client) optionalFile(ctx context.Context, owner, repo, filePath, ref string) (content string, found bool, err error) {
+	content, _, err = c.GetFileContent(ctx, owner, repo, filePath, ref)
+	var ghErr *gh.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// GetOptionalFile returns a file's content on ref (default branch when
+// empty), or found=false when it doesn't exist.
+func (c *Client) GetOptionalFile(ctx context.Context, owner, repo, filePath, ref string) (string, bool, error) {
+	return c.optionalFile(ctx, owner, repo, filePath, ref)
+}
+
+// GetSubmodules returns the submodules declared in the repository's
+// .gitmodules on ref (default branch when empty).
+func (c *Client) GetSubmodules(ctx context.Context, owner, repo, ref string) ([]Submodule, error) {
+	content, found, err := c.optionalFile(ctx, owner, repo, ".gitmodules", ref)
+	if err != nil || !found {
+		return nil, err
+	}
+	return ParseGitmodules(content), nil
+}
+
+// ParseGitmodules parses the contents of a .gitmodules file.
+func ParseGitmodules(content string) []Submodule {
+	var subs []Submodule
+	var cur *Submodule
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[submodule") {
+			subs = append(subs, Submodule{})
+			cur = &subs[len(subs)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || cur == nil {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "path":
+			cur.Path = strings.Trim(strings.TrimSpace(value), "/")
+		case "url":
+			cur.URL = strings.TrimSpace(value)
+			if m := submoduleRepoRe.FindStringSubmatch(cur.URL); m != nil {
+				cur.Repo = m[1] + "/" + m[2]
+			}
+		}
+	}
+	return subs
+}
+
+// SubmoduleFor returns the submodule containing filePath, or nil.
+func SubmoduleFor(subs []Submodule, filePath string) *Submodule {
+	filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	for i := range subs {
+		p := subs[i].Path
+		if p != "" && (filePath == p || strings.HasPrefix(filePath, p+"/")) {
+			return &subs[i]
+		}
+	}
+	return nil
+}
+
+// DetectWorkspace finds the projects in a repository from its workspace
+// manifests and submodules on ref (default branch when empty).
+func (c *Client) DetectWorkspace(ctx context.Context, owner, repo, ref string) ([]WorkspaceProject, error) {
+	var projects []WorkspaceProject
+	seen := make(map[string]bool)
+	add := func(dir, source string, sub *Submodule) {
+		dir = strings.Trim(path.Clean(dir), "/")
+		if dir == "" || dir == "." || seen[dir] {
+			return
+		}
+		seen[dir] = true
+		projects = append(projects, WorkspaceProject{Name: path.Base(dir), Dir: dir, Source: source, Submodule: sub})
+	}
+
+	subs, err := c.GetSubmodules(ctx, owner, repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .gitmodules: %w", err)
+	}
+	for i := range subs {
+		add(subs[i].Path, ".gitmodules", &subs[i])
+	}
+
+	manifests := []struct {
+		file  string
+		parse func(string) []string
+	}{
+		{"go.work", parseGoWork},
+		{"pnpm-workspace.yaml", parsePnpmWorkspace},
+		{"lerna.json", parseLernaPackages},
+		{"package.json", parsePackageWorkspaces},
+	}
+	for _, m := range manifests {
+		content, found, err := c.optionalFile(ctx, owner, repo, m.file, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", m.file, err)
+		}
+		if !found {
+			continue
+		}
+		for _, pattern := range m.parse(content) {
+			for _, dir := range c.expandWorkspaceGlob(ctx, owner, repo, ref, pattern) {
+				add(dir, m.file, nil)
+			}
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Dir < projects[j].Dir })
+	return projects, nil
+}
+
+// expandWorkspaceGlob resolves a workspace pattern like "packages/*" or
+// "services/api" to directories. Only a trailing "*" or "**" is expanded.
+func (c *Client) expandWorkspaceGlob(ctx context.Context, owner, repo, ref, pattern string) []string {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+	if pattern == "" || strings.HasPrefix(pattern, "!") {
+		return nil
+	}
+	parent, last := path.Split(strings.TrimSuffix(pattern, "/"))
+	if !strings.Contains(last, "*") {
+		return []string{pattern}
+	}
+	if strings.Contains(parent, "*") {
+		return nil
+	}
+	entries, err := c.GetDirectoryContents(ctx, owner, repo, strings.TrimSuffix(parent, "/"), ref)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !strings.HasSuffix(e, "/") {
+			continue
+		}
+		dir := strings.TrimSuffix(e, "/")
+		if ok, _ := path.Match(last, path.Base(dir)); ok || strings.HasPrefix(last, "**") {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// parseGoWork returns the directories in a go.work file's use directives.
+func parseGoWork(content string) []string {
+	var dirs []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			dirs = append(dirs, strings.Trim(line, `"`))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "use ")), `"`))
+		}
+	}
+	return dirs
+}
+
+func parsePnpmWorkspace(content string) []string {
+	var ws struct {
+		Packages []string `yaml:"packages"`
+	}
+	_ = yaml.Unmarshal([]byte(content), &ws)
+	return ws.Packages
+}
+
+func parseLernaPackages(content string) []string {
+	var lerna struct {
+		Packages []string `json:"packages"`
+	}
+	_ = json.Unmarshal([]byte(content), &lerna)
+	return lerna.Packages
+}
+
+// parsePackageWorkspaces reads npm/yarn "workspaces", either a list or
+// {"packages": [...]}.
+func parsePackageWorkspaces(content string) []string {
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil || len(pkg.Workspaces) == 0 {
+		return nil
+	}
+	var list []string
+	if json.Unmarshal(pkg.Workspaces, &list) == nil {
+		return list
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	_ = json.Unmarshal(pkg.Workspaces, &obj)
+	return obj.Packages
+}
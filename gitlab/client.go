@@ -0,0 +1,460 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// maxPages caps how many pages of 100 a listing follows.
+const maxPages = 50
+
+// Client provides access to the GitLab REST API (v4) of gitlab.com or a
+// self-managed instance, authenticated with a personal, group or project
+// access token.
+type Client struct {
+	baseURL    string // e.g. "https://gitlab.com"
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a GitLab client for the instance at baseURL.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "gitlab"},
+		},
+	}
+}
+
+// BaseURL returns the instance URL, without a trailing slash.
+func (c *Client) BaseURL() string { return c.baseURL }
+
+// Project is a GitLab project (repository).
+type Project struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"` // e.g. "platform/infra/terraform"
+	DefaultBranch     string `json:"default_branch"`
+	WebURL            string `json:"web_url"`
+	Archived          bool   `json:"archived"`
+}
+
+// TreeEntry is a file or directory in a repository tree.
+type TreeEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"` // "blob", "tree" or "commit" (submodule)
+}
+
+// CommitAction is one file change in a commit: "create", "update" or
+// "delete".
+type CommitAction struct {
+	Action   string
+	FilePath string
+	Content  []byte
+}
+
+// MergeRequest is a GitLab merge request.
+type MergeRequest struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	State  string `json:"state"` // opened, closed, merged or locked
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	WebURL              string `json:"web_url"`
+	Description         string `json:"description"`
+	Draft               bool   `json:"draft"`
+	DetailedMergeStatus string `json:"detailed_merge_status"` // e.g. "mergeable", "not_approved", "ci_still_running"
+	HeadPipeline        *struct {
+		Status string `json:"status"`
+	} `json:"head_pipeline"` // set when fetching a single merge request
+}
+
+// Diff is the change to one file in a merge request.
+type Diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// Pipeline is a CI/CD pipeline run.
+type Pipeline struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"` // created, pending, running, success, failed, canceled, skipped, manual, ...
+	Ref    string `json:"ref"`
+	Name   string `json:"name"`
+	WebURL string `json:"web_url"`
+}
+
+// Job is one job of a pipeline.
+type Job struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Stage         string `json:"stage"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"` // e.g. "script_failure", "runner_system_failure"
+	AllowFailure  bool   `json:"allow_failure"`
+	WebURL        string `json:"web_url"`
+}
+
+// GetProject returns a project by its full path, e.g. "group/sub/project".
+func (c *Client) GetProject(ctx context.Context, project string) (*Project, error) {
+	var p Project
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project), nil, &p); err != nil {
+		return nil, fmt.Errorf("get project %s: %w", project, err)
+	}
+	return &p, nil
+}
+
+// ListGroupProjects returns the unarchived projects of a group and its
+// subgroups, most recently active first.
+func (c *Client) ListGroupProjects(ctx context.Context, group string) ([]Project, error) {
+	q := url.Values{
+		"include_subgroups": {"true"},
+		"archived":          {"false"},
+		"order_by":          {"last_activity_at"},
+		"sort":              {"desc"},
+	}
+	var all []Project
+	err := c.paginate(ctx, "/groups/"+url.PathEscape(group)+"/projects", q, func(raw json.RawMessage) error {
+		var page []Project
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list projects of group %s: %w", group, err)
+	}
+	return all, nil
+}
+
+// GetFile returns the raw content of a file at ref.
+func (c *Client) GetFile(ctx context.Context, project, path, ref string) (string, error) {
+	q := url.Values{"ref": {ref}}
+	data, err := c.raw(ctx, projectPath(project)+"/repository/files/"+url.PathEscape(strings.TrimPrefix(path, "/"))+"/raw?"+q.Encode())
+	if err != nil {
+		return "", fmt.Errorf("get file %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// FileExists reports whether path is a file at ref.
+func (c *Client) FileExists(ctx context.Context, project, path, ref string) (bool, error) {
+	q := url.Values{"ref": {ref}}
+	req, err := c.newRequest(ctx, http.MethodHead, projectPath(project)+"/repository/files/"+url.PathEscape(strings.TrimPrefix(path, "/"))+"?"+q.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("send request: %w", err)
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, fmt.Errorf("gitlab API error (HTTP %d) checking %s", resp.StatusCode, path)
+	}
+	return true, nil
+}
+
+// ListTree returns the entries under path at ref; recursive includes the
+// whole subtree.
+func (c *Client) ListTree(ctx context.Context, project, path, ref string, recursive bool) ([]TreeEntry, error) {
+	q := url.Values{"ref": {ref}}
+	if path = strings.Trim(path, "/"); path != "" {
+		q.Set("path", path)
+	}
+	if recursive {
+		q.Set("recursive", "true")
+	}
+	var all []TreeEntry
+	err := c.paginate(ctx, projectPath(project)+"/repository/tree", q, func(raw json.RawMessage) error {
+		var page []TreeEntry
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list tree %s: %w", path, err)
+	}
+	return all, nil
+}
+
+// CreateBranch creates branch from ref.
+func (c *Client) CreateBranch(ctx context.Context, project, branch, ref string) error {
+	q := url.Values{"branch": {branch}, "ref": {ref}}
+	if _, err := c.do(ctx, http.MethodPost, projectPath(project)+"/repository/branches?"+q.Encode(), nil, nil); err != nil {
+		return fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// CreateCommit lands all actions on branch as a single commit.
+func (c *Client) CreateCommit(ctx context.Context, project, branch, message string, actions []CommitAction) error {
+	type action struct {
+		Action   string `json:"action"`
+		FilePath string `json:"file_path"`
+		Content  string `json:"content,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+	body := struct {
+		Branch        string   `json:"branch"`
+		CommitMessage string   `json:"commit_message"`
+		Actions       []action `json:"actions"`
+	}{Branch: branch, CommitMessage: message}
+	for _, a := range actions {
+		act := action{Action: a.Action, FilePath: a.FilePath}
+		if a.Action != "delete" {
+			act.Content, act.Encoding = base64.StdEncoding.EncodeToString(a.Content), "base64"
+		}
+		body.Actions = append(body.Actions, act)
+	}
+	if _, err := c.do(ctx, http.MethodPost, projectPath(project)+"/repository/commits", body, nil); err != nil {
+		return fmt.Errorf("commit to %s: %w", branch, err)
+	}
+	return nil
+}
+
+// CreateMergeRequest opens a merge request from source into target. The
+// source branch is removed when the merge request is merged.
+func (c *Client) CreateMergeRequest(ctx context.Context, project, source, target, title, description string) (*MergeRequest, error) {
+	body := map[string]any{
+		"source_branch":        source,
+		"target_branch":        target,
+		"title":                title,
+		"description":          description,
+		"remove_source_branch": true,
+	}
+	var mr MergeRequest
+	if _, err := c.do(ctx, http.MethodPost, projectPath(project)+"/merge_requests", body, &mr); err != nil {
+		return nil, fmt.Errorf("create merge request: %w", err)
+	}
+	return &mr, nil
+}
+
+// GetMergeRequest returns a merge request by its project-level number (IID).
+func (c *Client) GetMergeRequest(ctx context.Context, project string, iid int) (*MergeRequest, error) {
+	var mr MergeRequest
+	if _, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/merge_requests/%d", projectPath(project), iid), nil, &mr); err != nil {
+		return nil, fmt.Errorf("get merge request !%d: %w", iid, err)
+	}
+	return &mr, nil
+}
+
+// MergeRequestDiffs returns the file changes of a merge request.
+func (c *Client) MergeRequestDiffs(ctx context.Context, project string, iid int) ([]Diff, error) {
+	var all []Diff
+	err := c.paginate(ctx, fmt.Sprintf("%s/merge_requests/%d/diffs", projectPath(project), iid), nil, func(raw json.RawMessage) error {
+		var page []Diff
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get diffs of merge request !%d: %w", iid, err)
+	}
+	return all, nil
+}
+
+// ListMergeRequests returns up to limit merge requests in state ("opened",
+// "closed", "merged" or "all"), most recently updated first.
+func (c *Client) ListMergeRequests(ctx context.Context, project, state string, limit int) ([]MergeRequest, error) {
+	q := url.Values{
+		"state":    {state},
+		"order_by": {"updated_at"},
+		"sort":     {"desc"},
+		"per_page": {strconv.Itoa(limit)},
+	}
+	var mrs []MergeRequest
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/merge_requests?"+q.Encode(), nil, &mrs); err != nil {
+		return nil, fmt.Errorf("list merge requests: %w", err)
+	}
+	return mrs, nil
+}
+
+// GetPipeline returns a pipeline by ID.
+func (c *Client) GetPipeline(ctx context.Context, project string, id int64) (*Pipeline, error) {
+	var p Pipeline
+	if _, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/pipelines/%d", projectPath(project), id), nil, &p); err != nil {
+		return nil, fmt.Errorf("get pipeline %d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// PipelineJobs returns the latest attempt of every job in a pipeline.
+func (c *Client) PipelineJobs(ctx context.Context, project string, pipelineID int64) ([]Job, error) {
+	var all []Job
+	err := c.paginate(ctx, fmt.Sprintf("%s/pipelines/%d/jobs", projectPath(project), pipelineID), nil, func(raw json.RawMessage) error {
+		var page []Job
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		all = append(all, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list jobs of pipeline %d: %w", pipelineID, err)
+	}
+	return all, nil
+}
+
+// JobTrace opens the plain-text log of a job. The caller closes it.
+func (c *Client) JobTrace(ctx context.Context, project string, jobID int64) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("%s/jobs/%d/trace", projectPath(project), jobID), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Traces can be large; don't let the client timeout cut the download.
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("gitlab API error (HTTP %d) downloading job %d log", resp.StatusCode, jobID)
+	}
+	return resp.Body, nil
+}
+
+// projectPath returns the API path of a project, addressed by its
+// URL-encoded full path.
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+// ----- HTTP transport -----
+
+// paginate follows X-Next-Page for up to maxPages pages of 100, passing
+// each page's JSON array to fn.
+func (c *Client) paginate(ctx context.Context, path string, q url.Values, fn func(json.RawMessage) error) error {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("per_page", "100")
+	for page := 1; page <= maxPages; page++ {
+		q.Set("page", strconv.Itoa(page))
+		var raw json.RawMessage
+		header, err := c.do(ctx, http.MethodGet, path+"?"+q.Encode(), nil, &raw)
+		if err != nil {
+			return err
+		}
+		if err := fn(raw); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+		if header.Get("X-Next-Page") == "" {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/api/v4"+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+func (c *Client) raw(ctx context.Context, path string) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
+
+// apiError turns an error response into an error. GitLab reports errors
+// as {"message": ...} or {"error": ...}.
+func apiError(status int, body []byte) error {
+	var e struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		switch {
+		case e.Message != nil:
+			return fmt.Errorf("gitlab API error (HTTP %d): %v", status, e.Message)
+		case e.Error != "":
+			return fmt.Errorf("gitlab API error (HTTP %d): %s", status, e.Error)
+		}
+	}
+	return fmt.Errorf("gitlab API error (HTTP %d): %s", status, string(body))
+}
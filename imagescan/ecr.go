@@ -0,0 +1,58 @@
+package imagescan
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/justmike1/ovad/aws"
+)
+
+// ecrHost matches an ECR registry host and captures the account and region.
+var ecrHost = regexp.MustCompile(`^(\d{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// ECR reads basic or enhanced (Amazon Inspector) scan findings from Amazon
+// ECR, using the AWS tools' client and region.
+type ECR struct {
+	client *aws.Client
+}
+
+// NewECR creates an ECR scanner.
+func NewECR(client *aws.Client) *ECR {
+	return &ECR{client: client}
+}
+
+func (e *ECR) Name() string { return "Amazon ECR" }
+
+// Handles accepts ECR registry hosts and references without a registry,
+// which are looked up in the caller's account.
+func (e *ECR) Handles(img Image) bool {
+	return img.Registry == "" || ecrHost.MatchString(img.Registry)
+}
+
+func (e *ECR) Scan(ctx context.Context, img Image) (*Report, error) {
+	var registryID string
+	if m := ecrHost.FindStringSubmatch(img.Registry); m != nil {
+		if m[2] != e.client.Region() {
+			return nil, fmt.Errorf("image is in %s, but the AWS tools are configured for %s", m[2], e.client.Region())
+		}
+		registryID = m[1]
+	}
+	scan, err := e.client.ImageScanFindings(ctx, registryID, img.Repository, img.Ref())
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Scanner:     e.Name(),
+		Digest:      scan.Digest,
+		Status:      scan.Status,
+		Message:     scan.StatusMessage,
+		ScannedAt:   scan.CompletedAt,
+		DBUpdatedAt: scan.DBUpdatedAt,
+		Counts:      scan.SeverityCounts,
+	}
+	for _, f := range scan.Findings {
+		r.Findings = append(r.Findings, Finding(f))
+	}
+	return r, nil
+}
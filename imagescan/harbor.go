@@ -0,0 +1,166 @@
+package imagescan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// harborReportMIME is the vulnerability report format Harbor's Trivy
+// adapter produces.
+const harborReportMIME = "application/vnd.security.vulnerability.report; version=1.1"
+
+// Harbor reads the Trivy scan results Harbor stores for each artifact.
+type Harbor struct {
+	baseURL    string
+	host       string
+	username   string // robot account, e.g. "robot$ovad"
+	password   string
+	httpClient *http.Client
+}
+
+// NewHarbor creates a Harbor scanner for baseURL (e.g.
+// "https://harbor.example.com"). username and password may be empty for
+// public projects.
+func NewHarbor(baseURL, username, password string) (*Harbor, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Harbor URL %q", baseURL)
+	}
+	return &Harbor{
+		baseURL:  u.String(),
+		host:     u.Host,
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "harbor"},
+		},
+	}, nil
+}
+
+func (h *Harbor) Name() string { return "Harbor" }
+
+// Handles accepts images on the Harbor host and references without a
+// registry ("project/repository:tag").
+func (h *Harbor) Handles(img Image) bool {
+	return img.Registry == "" || strings.EqualFold(img.Registry, h.host)
+}
+
+func (h *Harbor) Scan(ctx context.Context, img Image) (*Report, error) {
+	project, repo, ok := strings.Cut(img.Repository, "/")
+	if !ok {
+		return nil, fmt.Errorf("images in Harbor are <project>/<repository>, got %q", img.Repository)
+	}
+	// Repository names containing "/" must be escaped twice.
+	path := fmt.Sprintf("/api/v2.0/projects/%s/repositories/%s/artifacts/%s",
+		url.PathEscape(project), url.PathEscape(url.PathEscape(repo)), url.PathEscape(img.Ref()))
+
+	var artifact struct {
+		Digest       string `json:"digest"`
+		ScanOverview map[string]struct {
+			ScanStatus string    `json:"scan_status"`
+			EndTime    time.Time `json:"end_time"`
+			Scanner    struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"scanner"`
+		} `json:"scan_overview"`
+	}
+	if err := h.get(ctx, path+"?with_scan_overview=true", &artifact); err != nil {
+		return nil, err
+	}
+	r := &Report{Scanner: h.Name(), Digest: artifact.Digest, Counts: map[string]int{}}
+	overview, scanned := artifact.ScanOverview[harborReportMIME]
+	if !scanned {
+		r.Status = "NotScanned"
+		r.Message = "the artifact has not been scanned; enable scan on push or scan it in Harbor"
+		return r, nil
+	}
+	r.Status = overview.ScanStatus
+	r.ScannedAt = overview.EndTime
+	if overview.Scanner.Name != "" {
+		r.Scanner = fmt.Sprintf("Harbor (%s %s)", overview.Scanner.Name, overview.Scanner.Version)
+	}
+	if r.Status != "Success" {
+		return r, nil
+	}
+
+	var reports map[string]struct {
+		Vulnerabilities []struct {
+			ID          string   `json:"id"`
+			Package     string   `json:"package"`
+			Version     string   `json:"version"`
+			FixVersion  string   `json:"fix_version"`
+			Severity    string   `json:"severity"`
+			Description string   `json:"description"`
+			Links       []string `json:"links"`
+		} `json:"vulnerabilities"`
+	}
+	if err := h.get(ctx, path+"/additions/vulnerabilities", &reports); err != nil {
+		return nil, err
+	}
+	for _, rep := range reports {
+		for _, v := range rep.Vulnerabilities {
+			f := Finding{
+				ID: v.ID, Severity: strings.ToUpper(v.Severity), Package: v.Package, Version: v.Version, FixedIn: v.FixVersion,
+			}
+			f.Title, _, _ = strings.Cut(v.Description, "\n")
+			if len(v.Links) > 0 {
+				f.URL = v.Links[0]
+			}
+			r.Counts[f.Severity]++
+			r.Findings = append(r.Findings, f)
+		}
+	}
+	return r, nil
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (h *Harbor) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if h.username != "" {
+		req.SetBasicAuth(h.username, h.password)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Accept-Vulnerabilities", harborReportMIME)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			return fmt.Errorf("harbor API error (HTTP %d): %s", resp.StatusCode, apiErr.Errors[0].Message)
+		}
+		return fmt.Errorf("harbor API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package imagescan looks up the vulnerability scan results of container
+// images in the registries that scanned them (Amazon ECR, or Harbor with its
+// Trivy scanner), so deploy discussions can include an image's actual
+// vulnerability posture.
+package imagescan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scanner looks up the scan results of images in one registry.
+type Scanner interface {
+	// Name is the scanner's display name, e.g. "Amazon ECR".
+	Name() string
+	// Handles reports whether the image lives in this scanner's registry.
+	Handles(img Image) bool
+	// Scan returns the latest scan results of the image.
+	Scan(ctx context.Context, img Image) (*Report, error)
+}
+
+// Image is a parsed image reference such as
+// "123456789012.dkr.ecr.us-east-1.amazonaws.com/checkout:v1.2.3".
+type Image struct {
+	Registry   string // empty for references without a registry host
+	Repository string
+	Tag        string
+	Digest     string // "sha256:..."
+}
+
+// ParseImage parses an image reference. A reference without tag or digest
+// refers to "latest".
+func ParseImage(ref string) (Image, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Image{}, fmt.Errorf("empty image reference")
+	}
+	var img Image
+	if name, digest, ok := strings.Cut(ref, "@"); ok {
+		ref, img.Digest = name, digest
+	}
+	if first, rest, ok := strings.Cut(ref, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		img.Registry, ref = first, rest
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref, img.Tag = ref[:i], ref[i+1:]
+	}
+	img.Repository = ref
+	if img.Repository == "" {
+		return Image{}, fmt.Errorf("invalid image reference %q", ref)
+	}
+	if img.Tag == "" && img.Digest == "" {
+		img.Tag = "latest"
+	}
+	return img, nil
+}
+
+// Ref returns the digest if known, else the tag.
+func (i Image) Ref() string {
+	if i.Digest != "" {
+		return i.Digest
+	}
+	return i.Tag
+}
+
+func (i Image) String() string {
+	s := i.Repository
+	if i.Registry != "" {
+		s = i.Registry + "/" + s
+	}
+	if i.Tag != "" {
+		s += ":" + i.Tag
+	}
+	if i.Digest != "" {
+		s += "@" + i.Digest
+	}
+	return s
+}
+
+// Report is the scan result of an image.
+type Report struct {
+	Scanner     string // scanner display name, e.g. "Harbor (Trivy v0.50.1)"
+	Digest      string
+	Status      string // the scanner's scan status, e.g. COMPLETE or Success
+	Message     string // why a scan failed or is unavailable
+	ScannedAt   time.Time
+	DBUpdatedAt time.Time // vulnerability database age, when the scanner reports it
+	// Counts is the number of findings per severity (CRITICAL, HIGH,
+	// MEDIUM, LOW, ...).
+	Counts   map[string]int
+	Findings []Finding
+}
+
+// Finding is one vulnerability in an image.
+type Finding struct {
+	ID       string // e.g. CVE-2024-1234
+	Severity string // upper case: CRITICAL, HIGH, MEDIUM, LOW, INFORMATIONAL, UNKNOWN
+	Package  string
+	Version  string
+	FixedIn  string // empty if no fix is available
+	Title    string
+	URL      string
+}
+
+// Severities lists the severities from most to least severe.
+var Severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "INFORMATIONAL", "UNKNOWN"}
+
+// SeverityRank returns 0 for CRITICAL, 1 for HIGH and so on; unknown
+// severities rank last.
+func SeverityRank(severity string) int {
+	severity = strings.ToUpper(severity)
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities) - 1
+}
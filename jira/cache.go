@@ -0,0 +1,67 @@
+package jira
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMetadataTTL is how long project and field metadata is cached.
+const DefaultMetadataTTL = time.Hour
+
+// metadataCache holds Jira metadata that rarely changes (project statuses,
+// field definitions, resolved teams) so tools don't rediscover it on every
+// request. Safe for concurrent use.
+type metadataCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	return &metadataCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func (m *metadataCache) get(key string) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (m *metadataCache) set(key string, value any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttl <= 0 {
+		return
+	}
+	m.entries[key] = cacheEntry{value: value, expires: time.Now().Add(m.ttl)}
+}
+
+// cached returns the cached value for key, or calls fetch and caches its
+// result when it succeeds.
+func cached[T any](c *Client, key string, fetch func() (T, error)) (T, error) {
+	if v, ok := c.cache.get(key); ok {
+		return v.(T), nil
+	}
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+	c.cache.set(key, v)
+	return v, nil
+}
+
+// SetMetadataTTL changes how long metadata is cached and drops anything
+// already cached. A TTL of 0 disables caching.
+func (c *Client) SetMetadataTTL(ttl time.Duration) {
+	c.cache = newMetadataCache(ttl)
+}
@@ -5,13 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
 )
 
 // authMode controls how API requests are authenticated.
@@ -49,6 +51,9 @@ type Client struct {
 	extraFieldsOnce sync.Once
 	teamFieldID     string // e.g. "customfield_10001"
 	sprintFieldID   string // e.g. "customfield_10020"
+
+	// Project, field and team metadata, cached with a TTL.
+	cache *metadataCache
 }
 
 // NewClient creates a Jira API client using Basic Auth (email + API token).
@@ -60,8 +65,9 @@ func NewClient(baseURL, email, apiToken, defaultProject string) *Client {
 		email:      email,
 		apiToken:   apiToken,
 		projectKey: defaultProject,
-		httpClient: &http.Client{},
+		httpClient: &http.Client{Transport: &metrics.Transport{Integration: "jira"}},
 		mode:       authBasic,
+		cache:      newMetadataCache(DefaultMetadataTTL),
 	}
 }
 
@@ -75,13 +81,14 @@ func NewOAuthClient(baseURL, clientID, clientSecret, defaultProject string) (*Cl
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		projectKey:   defaultProject,
-		httpClient:   &http.Client{},
+		httpClient:   &http.Client{Transport: &metrics.Transport{Integration: "jira"}},
 		mode:         authOAuth,
+		cache:        newMetadataCache(DefaultMetadataTTL),
 	}
 	if err := c.refreshToken(); err != nil {
 		return nil, fmt.Errorf("initial OAuth token fetch failed: %w", err)
 	}
-	log.Printf("[jira] OAuth token acquired (expires %s)", c.tokenExpiry.Format(time.RFC3339))
+	logging.Infof("[jira] OAuth token acquired (expires %s)", c.tokenExpiry.Format(time.RFC3339))
 
 	// Resolve cloud ID so we use the correct OAuth API base URL.
 	cloudID, err := c.resolveCloudID()
@@ -90,7 +97,7 @@ func NewOAuthClient(baseURL, clientID, clientSecret, defaultProject string) (*Cl
 	}
 	c.cloudID = cloudID
 	c.baseURL = fmt.Sprintf("%s/%s", atlassianOAuthAPIBaseURL, cloudID)
-	log.Printf("[jira] OAuth cloud ID resolved: %s → %s", cleanURL, c.baseURL)
+	logging.Infof("[jira] OAuth cloud ID resolved: %s → %s", cleanURL, c.baseURL)
 
 	return c, nil
 }
@@ -137,14 +144,14 @@ func (c *Client) resolveCloudID() (string, error) {
 	siteNorm := strings.TrimRight(strings.ToLower(c.siteURL), "/")
 	for _, r := range resources {
 		if strings.TrimRight(strings.ToLower(r.URL), "/") == siteNorm {
-			log.Printf("[jira] matched site %q → cloud ID %s (name: %s)", c.siteURL, r.ID, r.Name)
+			logging.Infof("[jira] matched site %q → cloud ID %s (name: %s)", c.siteURL, r.ID, r.Name)
 			return r.ID, nil
 		}
 	}
 
 	// If only one site, use it.
 	if len(resources) == 1 {
-		log.Printf("[jira] WARN: site URL %q didn't match %q, using the only available site (cloud ID: %s)", c.siteURL, resources[0].URL, resources[0].ID)
+		logging.Infof("[jira] WARN: site URL %q didn't match %q, using the only available site (cloud ID: %s)", c.siteURL, resources[0].URL, resources[0].ID)
 		return resources[0].ID, nil
 	}
 
@@ -263,7 +270,7 @@ func (c *Client) getAccessToken() (string, error) {
 		return token, nil
 	}
 
-	log.Printf("[jira] OAuth token expired, refreshing...")
+	logging.Infof("[jira] OAuth token expired, refreshing...")
 	if err := c.refreshToken(); err != nil {
 		return "", err
 	}
@@ -308,6 +315,7 @@ type CreateIssueInput struct {
 	IssueType   string // e.g. "Task", "Bug", "Story"
 	Labels      []string
 	AssigneeID  string // Jira account ID of the assignee (optional)
+	Components  []string
 }
 
 // createIssuePayload is the JSON body sent to the Jira API.
@@ -323,6 +331,11 @@ type createIssueFields struct {
 	Description *adfDoc     `json:"description,omitempty"`
 	Labels      []string    `json:"labels,omitempty"`
 	Assignee    *accountRef `json:"assignee,omitempty"`
+	Components  []nameRef   `json:"components,omitempty"`
+}
+
+type nameRef struct {
+	Name string `json:"name"`
 }
 
 type accountRef struct {
@@ -722,6 +735,9 @@ func (c *Client) CreateIssue(input CreateIssueInput) (*Issue, error) {
 	if input.AssigneeID != "" {
 		payload.Fields.Assignee = &accountRef{AccountID: input.AssigneeID}
 	}
+	for _, name := range input.Components {
+		payload.Fields.Components = append(payload.Fields.Components, nameRef{Name: name})
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -779,6 +795,10 @@ func (c *Client) CreateIssue(input CreateIssueInput) (*Issue, error) {
 
 // ListProjects returns the keys of all projects visible to the authenticated user.
 func (c *Client) ListProjects() ([]string, error) {
+	return cached(c, "projects", c.listProjects)
+}
+
+func (c *Client) listProjects() ([]string, error) {
 	url := fmt.Sprintf("%s/rest/api/3/project/search?maxResults=100&status=live", c.baseURL)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
@@ -1016,6 +1036,10 @@ type TeamFieldInfo struct {
 // Results are sorted so that the actual Jira Teams field (clause "Team[Team]") comes first,
 // followed by dropdown/select variants.
 func (c *Client) FindTeamFields() ([]TeamFieldInfo, error) {
+	return cached(c, "team-fields", c.findTeamFields)
+}
+
+func (c *Client) findTeamFields() ([]TeamFieldInfo, error) {
 	reqURL := fmt.Sprintf("%s/rest/api/3/field", c.baseURL)
 	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
 	if err != nil {
@@ -1221,6 +1245,15 @@ func (c *Client) extractCustomFields(rawFields json.RawMessage) (team, sprint st
 // It discovers all team-like fields and tries multiple strategies for each.
 // Returns (fieldID, teamID, displayName, error).
 func (c *Client) ResolveTeam(teamName string) (string, string, string, error) {
+	type resolved struct{ fieldID, teamID, displayName string }
+	t, err := cached(c, "team:"+strings.ToLower(strings.TrimSpace(teamName)), func() (resolved, error) {
+		fieldID, teamID, displayName, err := c.resolveTeam(teamName)
+		return resolved{fieldID, teamID, displayName}, err
+	})
+	return t.fieldID, t.teamID, t.displayName, err
+}
+
+func (c *Client) resolveTeam(teamName string) (string, string, string, error) {
 	fields, err := c.FindTeamFields()
 	if err != nil {
 		return "", "", "", fmt.Errorf("find team fields: %w", err)
@@ -1831,6 +1864,70 @@ func (c *Client) UpdateIssueDescription(issueKey, description string) error {
 	return nil
 }
 
+// AddComment posts a comment on a Jira issue. Markdown is converted to ADF.
+func (c *Client) AddComment(issueKey, text string) error {
+	body, err := json.Marshal(map[string]interface{}{"body": textToADF(text)})
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/rest/api/3/issue/%s/comment", c.baseURL, issueKey)
+	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.authRequest(req); err != nil {
+		return fmt.Errorf("auth request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("jira API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// IssueExists reports whether an issue exists and is visible to the
+// authenticated user.
+func (c *Client) IssueExists(issueKey string) (bool, error) {
+	reqURL := fmt.Sprintf("%s/rest/api/3/issue/%s?fields=summary", c.baseURL, url.PathEscape(issueKey))
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	if err := c.authRequest(req); err != nil {
+		return false, fmt.Errorf("auth request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, fmt.Errorf("jira API error (HTTP %d)", resp.StatusCode)
+	}
+	return true, nil
+}
+
+// SiteURL returns the human-readable Jira site URL used for browse links.
+func (c *Client) SiteURL() string {
+	return c.siteURL
+}
+
 // IssueSummary represents a Jira issue with common fields.
 type IssueSummary struct {
 	Key         string   `json:"key"`
@@ -1919,3 +2016,80 @@ func (c *Client) UpdateIssueFields(issueKey string, fields map[string]interface{
 
 	return nil
 }
+
+// Transition is a workflow transition available on an issue.
+type Transition struct {
+	ID       string
+	Name     string // e.g. "Start Progress"
+	ToStatus string // e.g. "In Progress"
+}
+
+// ListTransitions returns the transitions currently available on an issue.
+func (c *Client) ListTransitions(issueKey string) ([]Transition, error) {
+	var resp struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := c.getJSON(fmt.Sprintf("/rest/api/3/issue/%s/transitions", issueKey), &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Transition, 0, len(resp.Transitions))
+	for _, t := range resp.Transitions {
+		out = append(out, Transition{ID: t.ID, Name: t.Name, ToStatus: t.To.Name})
+	}
+	return out, nil
+}
+
+// TransitionIssue moves an issue to the given status (or through the
+// transition of that name) and returns the status it ended up in.
+func (c *Client) TransitionIssue(issueKey, status string) (string, error) {
+	transitions, err := c.ListTransitions(issueKey)
+	if err != nil {
+		return "", err
+	}
+	var match *Transition
+	for i, t := range transitions {
+		if strings.EqualFold(t.ToStatus, status) || strings.EqualFold(t.Name, status) {
+			match = &transitions[i]
+			break
+		}
+	}
+	if match == nil {
+		var names []string
+		for _, t := range transitions {
+			names = append(names, fmt.Sprintf("%q (→ %s)", t.Name, t.ToStatus))
+		}
+		return "", fmt.Errorf("no transition to %q from the current status; available: %s", status, strings.Join(names, ", "))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"transition": map[string]string{"id": match.ID}})
+	if err != nil {
+		return "", fmt.Errorf("marshal payload: %w", err)
+	}
+	reqURL := fmt.Sprintf("%s/rest/api/3/issue/%s/transitions", c.baseURL, issueKey)
+	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.authRequest(req); err != nil {
+		return "", fmt.Errorf("auth request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("jira API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	return match.ToStatus, nil
+}
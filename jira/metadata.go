@@ -0,0 +1,175 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ProjectMetadata lists the values JQL clauses can use in a project.
+type ProjectMetadata struct {
+	Project    string
+	IssueTypes []string
+	Statuses   []string
+	Priorities []string
+	Components []string
+	// ComponentLeads maps component names to their lead, for components
+	// that have one.
+	ComponentLeads map[string]JiraUser
+}
+
+// FieldClause is a Jira field and the name JQL uses for it.
+type FieldClause struct {
+	Name   string // display name, e.g. "Story Points"
+	Clause string // e.g. "cf[10016]" or "\"Story Points\""
+	Custom bool
+}
+
+// GetProjectMetadata returns the issue types, statuses, components and
+// priorities available in project (default: the configured project).
+func (c *Client) GetProjectMetadata(project string) (*ProjectMetadata, error) {
+	if project == "" {
+		project = c.projectKey
+	}
+	if project == "" {
+		return nil, fmt.Errorf("no project specified and no default project configured")
+	}
+	project = strings.ToUpper(project)
+	return cached(c, "project:"+project, func() (*ProjectMetadata, error) {
+		return c.fetchProjectMetadata(project)
+	})
+}
+
+func (c *Client) fetchProjectMetadata(project string) (*ProjectMetadata, error) {
+	meta := &ProjectMetadata{Project: project, ComponentLeads: make(map[string]JiraUser)}
+	key := url.PathEscape(project)
+
+	var types []struct {
+		Name     string `json:"name"`
+		Statuses []struct {
+			Name string `json:"name"`
+		} `json:"statuses"`
+	}
+	if err := c.getJSON(fmt.Sprintf("/rest/api/3/project/%s/statuses", key), &types); err != nil {
+		return nil, fmt.Errorf("project statuses: %w", err)
+	}
+	for _, t := range types {
+		meta.IssueTypes = appendUnique(meta.IssueTypes, t.Name)
+		for _, s := range t.Statuses {
+			meta.Statuses = appendUnique(meta.Statuses, s.Name)
+		}
+	}
+
+	var components []struct {
+		Name string    `json:"name"`
+		Lead *JiraUser `json:"lead"`
+	}
+	if err := c.getJSON(fmt.Sprintf("/rest/api/3/project/%s/components", key), &components); err != nil {
+		return nil, fmt.Errorf("project components: %w", err)
+	}
+	for _, comp := range components {
+		meta.Components = appendUnique(meta.Components, comp.Name)
+		if comp.Lead != nil && comp.Lead.AccountID != "" {
+			meta.ComponentLeads[comp.Name] = *comp.Lead
+		}
+	}
+
+	var priorities []struct {
+		Name string `json:"name"`
+	}
+	if err := c.getJSON("/rest/api/3/priority", &priorities); err != nil {
+		return nil, fmt.Errorf("priorities: %w", err)
+	}
+	for _, p := range priorities {
+		meta.Priorities = appendUnique(meta.Priorities, p.Name)
+	}
+
+	sort.Strings(meta.IssueTypes)
+	sort.Strings(meta.Statuses)
+	sort.Strings(meta.Components)
+	return meta, nil
+}
+
+// FieldClauses lists every searchable field with its preferred JQL clause
+// name, sorted by display name.
+func (c *Client) FieldClauses() ([]FieldClause, error) {
+	return cached(c, "fields", c.fieldClauses)
+}
+
+func (c *Client) fieldClauses() ([]FieldClause, error) {
+	var fields []struct {
+		Name        string   `json:"name"`
+		Custom      bool     `json:"custom"`
+		Searchable  bool     `json:"searchable"`
+		ClauseNames []string `json:"clauseNames"`
+	}
+	if err := c.getJSON("/rest/api/3/field", &fields); err != nil {
+		return nil, err
+	}
+	var out []FieldClause
+	for _, f := range fields {
+		if !f.Searchable || len(f.ClauseNames) == 0 {
+			continue
+		}
+		clause := f.ClauseNames[0]
+		// Prefer the Teams clause ("Team[Team]") and the cf[NNNNN] form over
+		// ambiguous display-name clauses.
+		for _, cn := range f.ClauseNames {
+			if strings.HasPrefix(cn, "cf[") {
+				clause = cn
+			}
+		}
+		for _, cn := range f.ClauseNames {
+			if cn == "Team[Team]" {
+				clause = cn
+			}
+		}
+		out = append(out, FieldClause{Name: f.Name, Clause: clause, Custom: f.Custom})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// getJSON performs an authenticated GET against the Jira API and decodes
+// the response into out.
+func (c *Client) getJSON(path string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.authRequest(req); err != nil {
+		return fmt.Errorf("auth request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("jira API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func appendUnique(list []string, v string) []string {
+	if v == "" || slices.Contains(list, v) {
+		return list
+	}
+	return append(list, v)
+}
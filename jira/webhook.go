@@ -0,0 +1,146 @@
+package jira
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebhookEvent is the subset of a Jira webhook delivery the bot reacts to.
+type WebhookEvent struct {
+	Event       string // webhookEvent, e.g. "jira:issue_created", "jira:issue_updated"
+	IssueKey    string
+	Browse      string // issue URL, derived from the payload's REST link
+	Project     string
+	Summary     string
+	Status      string
+	IssueType   string
+	Priority    string
+	Reporter    string
+	Assignee    string
+	Description string
+	Labels      []string
+	Actor       string // display name of the user who made the change
+	FromStatus  string // set when the update changed the status
+	ToStatus    string
+	Comment     string // body of the comment for comment_* events
+}
+
+// Kind returns the event name without the "jira:" prefix, e.g.
+// "issue_created" or "comment_created".
+func (e *WebhookEvent) Kind() string {
+	return strings.TrimPrefix(e.Event, "jira:")
+}
+
+// StatusChanged reports whether the event moved the issue to a new status.
+func (e *WebhookEvent) StatusChanged() bool {
+	return e.ToStatus != "" && e.ToStatus != e.FromStatus
+}
+
+// ParseWebhook verifies the X-Hub-Signature HMAC that Jira Cloud adds to
+// webhooks registered with a secret, and decodes the delivery.
+func ParseWebhook(r *http.Request, secret string) (*WebhookEvent, error) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature"), "sha256=")
+	if !ok {
+		return nil, fmt.Errorf("missing sha256 X-Hub-Signature header")
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return nil, fmt.Errorf("malformed X-Hub-Signature header")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return nil, fmt.Errorf("signature mismatch")
+	}
+
+	var raw struct {
+		WebhookEvent string `json:"webhookEvent"`
+		User         struct {
+			DisplayName string `json:"displayName"`
+		} `json:"user"`
+		Issue struct {
+			Key    string `json:"key"`
+			Self   string `json:"self"`
+			Fields struct {
+				Summary     string          `json:"summary"`
+				Description json.RawMessage `json:"description"`
+				Labels      []string        `json:"labels"`
+				Status      namedField      `json:"status"`
+				IssueType   namedField      `json:"issuetype"`
+				Priority    namedField      `json:"priority"`
+				Reporter    userField       `json:"reporter"`
+				Assignee    userField       `json:"assignee"`
+				Project     struct {
+					Key string `json:"key"`
+				} `json:"project"`
+			} `json:"fields"`
+		} `json:"issue"`
+		Comment struct {
+			Body json.RawMessage `json:"body"`
+		} `json:"comment"`
+		Changelog struct {
+			Items []struct {
+				Field      string `json:"field"`
+				FromString string `json:"fromString"`
+				ToString   string `json:"toString"`
+			} `json:"items"`
+		} `json:"changelog"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	f := raw.Issue.Fields
+	ev := &WebhookEvent{
+		Event:       raw.WebhookEvent,
+		IssueKey:    raw.Issue.Key,
+		Project:     f.Project.Key,
+		Summary:     f.Summary,
+		Status:      f.Status.Name,
+		IssueType:   f.IssueType.Name,
+		Priority:    f.Priority.Name,
+		Reporter:    f.Reporter.DisplayName,
+		Assignee:    f.Assignee.DisplayName,
+		Description: webhookText(f.Description),
+		Labels:      f.Labels,
+		Actor:       raw.User.DisplayName,
+		Comment:     webhookText(raw.Comment.Body),
+	}
+	if site, _, ok := strings.Cut(raw.Issue.Self, "/rest/"); ok && ev.IssueKey != "" {
+		ev.Browse = site + "/browse/" + ev.IssueKey
+	}
+	for _, item := range raw.Changelog.Items {
+		if item.Field == "status" {
+			ev.FromStatus, ev.ToStatus = item.FromString, item.ToString
+		}
+	}
+	return ev, nil
+}
+
+type namedField struct {
+	Name string `json:"name"`
+}
+
+type userField struct {
+	DisplayName string `json:"displayName"`
+}
+
+// webhookText decodes a rich-text field, which webhooks send either as a
+// plain string or as an ADF document.
+func webhookText(data json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+	return adfToPlainText(data)
+}
@@ -0,0 +1,141 @@
+// Package logging sets up structured logging (log/slog) and carries a
+// per-request correlation ID from the chat command through the handlers,
+// tool calls and outgoing API requests.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader carries the request ID on outgoing API calls.
+const RequestIDHeader = "X-Request-ID"
+
+// Setup makes slog's default logger write at level ("debug", "info", "warn"
+// or "error"; default info) in format ("text" or "json"; default text) to
+// stderr. The standard log package (log.Fatalf, third-party code) is routed
+// through it at info level.
+func Setup(level, format string) error {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid log format %q: must be text or json", format)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+type requestIDKey struct{}
+
+// NewRequestID returns a random 16-character hex ID.
+func NewRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithRequestID returns ctx carrying the request ID id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Logger logs printf-style messages, tagged with a request ID when it has
+// one. The zero value logs untagged.
+type Logger struct {
+	requestID string
+}
+
+// Ctx returns a logger for the request carried by ctx.
+func Ctx(ctx context.Context) Logger {
+	return Logger{requestID: RequestID(ctx)}
+}
+
+// Request returns a logger for the request ID id.
+func Request(id string) Logger {
+	return Logger{requestID: id}
+}
+
+func (l Logger) logf(level slog.Level, format string, args ...any) {
+	logger := slog.Default()
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if l.requestID != "" {
+		logger.Log(context.Background(), level, msg, "request_id", l.requestID)
+		return
+	}
+	logger.Log(context.Background(), level, msg)
+}
+
+func (l Logger) Debugf(format string, args ...any) { l.logf(slog.LevelDebug, format, args...) }
+func (l Logger) Infof(format string, args ...any)  { l.logf(slog.LevelInfo, format, args...) }
+func (l Logger) Warnf(format string, args ...any)  { l.logf(slog.LevelWarn, format, args...) }
+func (l Logger) Errorf(format string, args ...any) { l.logf(slog.LevelError, format, args...) }
+
+// Debugf logs outside of a request.
+func Debugf(format string, args ...any) { Logger{}.Debugf(format, args...) }
+
+// Infof logs outside of a request.
+func Infof(format string, args ...any) { Logger{}.Infof(format, args...) }
+
+// Warnf logs outside of a request.
+func Warnf(format string, args ...any) { Logger{}.Warnf(format, args...) }
+
+// Errorf logs outside of a request.
+func Errorf(format string, args ...any) { Logger{}.Errorf(format, args...) }
+
+// Transport adds the request ID of the request's context to outgoing API
+// calls and logs them at debug level.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	id := RequestID(req.Context())
+	if id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		Request(id).Debugf("%s %s://%s%s failed after %s: %v", req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path, took, err)
+	} else {
+		Request(id).Debugf("%s %s://%s%s -> %d in %s", req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path, resp.StatusCode, took)
+	}
+	return resp, err
+}
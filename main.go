@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -9,17 +10,45 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/justmike1/ovad/ado"
+	"github.com/justmike1/ovad/agentsync"
+	"github.com/justmike1/ovad/argocd"
+	"github.com/justmike1/ovad/aws"
+	"github.com/justmike1/ovad/bitbucket"
+	"github.com/justmike1/ovad/cloudflare"
 	"github.com/justmike1/ovad/commands"
 	"github.com/justmike1/ovad/config"
+	"github.com/justmike1/ovad/confluence"
+	"github.com/justmike1/ovad/cost"
+	"github.com/justmike1/ovad/directory"
+	"github.com/justmike1/ovad/discord"
+	"github.com/justmike1/ovad/gcp"
 	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/gitlab"
+	"github.com/justmike1/ovad/imagescan"
 	"github.com/justmike1/ovad/jira"
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/notion"
 	"github.com/justmike1/ovad/nvd"
+	"github.com/justmike1/ovad/observability"
+	"github.com/justmike1/ovad/oncall"
+	"github.com/justmike1/ovad/osv"
+	"github.com/justmike1/ovad/pagerduty"
 	"github.com/justmike1/ovad/prompts"
+	"github.com/justmike1/ovad/redact"
+	"github.com/justmike1/ovad/scheduler"
+	"github.com/justmike1/ovad/scm"
 	"github.com/justmike1/ovad/slack"
+	"github.com/justmike1/ovad/teams"
+	"github.com/justmike1/ovad/terraform"
+	"github.com/justmike1/ovad/vault"
 )
 
 //go:embed ui/*
@@ -33,6 +62,7 @@ type permission struct {
 	Required    bool   `json:"required"`
 	Granted     *bool  `json:"granted,omitempty"` // nil = unknown, true/false = checked
 	Extra       bool   `json:"extra,omitempty"`   // true = scope exists on token but not needed by arbetern
+	Remediation string `json:"remediation,omitempty"`
 }
 
 type integration struct {
@@ -95,6 +125,38 @@ func hasScope(granted []string, scope string) bool {
 	return false
 }
 
+// fineGrainedPermissions builds the GitHub permission list for a fine-grained
+// PAT. Read permissions are probed live; write permissions can't be checked
+// without side effects and are left as unknown.
+func fineGrainedPermissions(ghClient *github.Client) []permission {
+	perms := []permission{
+		{Scope: "metadata:read", Description: "Read repository metadata and list repos", Required: true},
+		{Scope: "contents:read", Description: "Read files, directories, and code", Required: true},
+		{Scope: "contents:write", Description: "Create branches and commit file changes", Required: true},
+		{Scope: "pull_requests:read", Description: "Read pull requests and diffs", Required: true},
+		{Scope: "pull_requests:write", Description: "Open pull requests", Required: true},
+		{Scope: "actions:read", Description: "Read workflow runs, jobs, and logs (CI/CD debugging)", Required: false},
+		{Scope: "actions:write", Description: "Re-run workflow jobs (rerun failed jobs, rerun all)", Required: false},
+		{Scope: "checks:read", Description: "Read check run annotations for detailed CI feedback", Required: false},
+		{Scope: "members:read", Description: "Organization permission: read org membership", Required: false},
+	}
+	probes, err := ghClient.ProbeFineGrainedPermissions(context.Background())
+	if err != nil {
+		logging.Infof("GitHub fine-grained permission probe: %v", err)
+	}
+	for i := range perms {
+		p, ok := probes[perms[i].Scope]
+		if !ok {
+			continue
+		}
+		perms[i].Granted = boolPtr(p.Granted)
+		if !p.Granted && p.Accepted != "" {
+			perms[i].Description += fmt.Sprintf(" (GitHub expects: %s)", p.Accepted)
+		}
+	}
+	return perms
+}
+
 // refreshIntegrations queries each configured integration's API for live
 // permissions and stores the result in the in-memory cache.
 func refreshIntegrations(
@@ -155,7 +217,10 @@ func refreshIntegrations(
 		{Scope: "checks:read", Description: "Read check run annotations for detailed CI feedback", Required: false},
 	}
 	ghAuthMode := ""
-	if cfg.GitHubToken != "" {
+	if cfg.GitHubToken != "" && ghClient != nil && ghClient.IsFineGrained() {
+		ghAuthMode = "Fine-grained Personal Access Token"
+		ghPerms = fineGrainedPermissions(ghClient)
+	} else if cfg.GitHubToken != "" {
 		ghAuthMode = "Personal Access Token"
 		if ghClient != nil {
 			if scopes, err := ghClient.GetGrantedScopes(context.Background()); err == nil && scopes != nil {
@@ -174,6 +239,11 @@ func refreshIntegrations(
 						})
 					}
 				}
+			} else if err == nil {
+				// No X-OAuth-Scopes header: the token is not a classic PAT
+				// (e.g. a fine-grained token without the github_pat_ prefix).
+				ghAuthMode = "Fine-grained Personal Access Token"
+				ghPerms = fineGrainedPermissions(ghClient)
 			}
 		}
 	}
@@ -266,7 +336,7 @@ func refreshIntegrations(
 	}
 
 	// --- Azure OpenAI ---
-	if cfg.UseAzure() && modelsClient != nil {
+	if cfg.LLMProvider() == "azure" && modelsClient != nil {
 		azurePerms := []permission{
 			{Scope: "Cognitive Services OpenAI User", Description: "Azure RBAC role for chat completions inference", Required: true, Granted: boolPtr(true)},
 		}
@@ -310,12 +380,40 @@ func refreshIntegrations(
 			ID:           "azure-openai",
 			Name:         "Azure OpenAI",
 			Configured:   true,
-			AuthMode:     "API Key",
+			AuthMode:     modelsClient.AzureAuthMode(),
 			ActiveModels: activeModels,
 			Permissions:  azurePerms,
 		})
 	}
 
+	// --- OpenAI / Anthropic / Ollama ---
+	if p := cfg.LLMProvider(); (p == "openai" || p == "anthropic" || p == "ollama") && modelsClient != nil {
+		activeModels := map[string]string{"General": modelsClient.Model()}
+		if codeModelsClient != nil && codeModelsClient.Model() != modelsClient.Model() {
+			activeModels["Code"] = codeModelsClient.Model()
+		}
+		name, endpoint, authMode := "OpenAI", "chat/completions", "API Key"
+		switch p {
+		case "anthropic":
+			name, endpoint = "Anthropic", "messages"
+		case "ollama":
+			name, endpoint, authMode = "Ollama", "api/chat", "None (local)"
+			if cfg.OllamaAPIKey != "" {
+				authMode = "Bearer token"
+			}
+		}
+		result = append(result, integration{
+			ID:           p,
+			Name:         name,
+			Configured:   true,
+			AuthMode:     authMode,
+			ActiveModels: activeModels,
+			Permissions: []permission{
+				{Scope: endpoint, Description: "Model inference with tool calling", Required: true, Granted: boolPtr(true)},
+			},
+		})
+	}
+
 	// --- NVD (National Vulnerability Database) ---
 	{
 		nvdConfigured := cfg.NVDAPIKey != ""
@@ -345,10 +443,116 @@ func refreshIntegrations(
 		})
 	}
 
+	// --- GitLab ---
+	if cfg.SCMProvider == "gitlab" {
+		result = append(result, integration{
+			ID:         "gitlab",
+			Name:       "GitLab",
+			Configured: true,
+			AuthMode:   "Access Token",
+			Permissions: []permission{
+				{Scope: "api", Description: "Read and write projects, files, merge requests and pipelines in " + cfg.GitLabGroup, Required: true},
+			},
+		})
+	}
+
+	// --- Bitbucket ---
+	if cfg.SCMProvider == "bitbucket" {
+		authMode := "Access Token"
+		if cfg.BitbucketUsername != "" {
+			authMode = "API Token (" + cfg.BitbucketUsername + ")"
+		}
+		result = append(result, integration{
+			ID:         "bitbucket",
+			Name:       "Bitbucket",
+			Configured: true,
+			AuthMode:   authMode,
+			Permissions: []permission{
+				{Scope: "repository:write", Description: "Read repositories and files in " + cfg.BitbucketWorkspace + ", push branches and commits", Required: true},
+				{Scope: "pullrequest:write", Description: "Read and open pull requests", Required: true},
+				{Scope: "pipeline", Description: "Read Pipelines runs and step logs", Required: true},
+			},
+		})
+	}
+
+	// --- Microsoft Teams ---
+	if cfg.TeamsAppID != "" {
+		authMode := "Bot Framework (multi-tenant)"
+		if cfg.TeamsTenantID != "" {
+			authMode = "Bot Framework (tenant " + cfg.TeamsTenantID + ")"
+		}
+		result = append(result, integration{
+			ID:         "teams",
+			Name:       "Microsoft Teams",
+			Configured: true,
+			AuthMode:   authMode,
+			Permissions: []permission{
+				{Scope: "Messaging endpoint", Description: "Receive messages at " + strings.TrimRight(cfg.AppURL, "/") + "/teams/messages", Required: true},
+				{Scope: "ChannelMessage.Read.Group", Description: "Receive thread follow-ups in channels without an @mention (resource-specific consent in the app manifest)"},
+			},
+		})
+	}
+
+	// --- Discord ---
+	if cfg.DiscordBotToken != "" {
+		result = append(result, integration{
+			ID:         "discord",
+			Name:       "Discord",
+			Configured: true,
+			AuthMode:   "Bot Token",
+			Permissions: []permission{
+				{Scope: "bot, applications.commands", Description: "Join servers and register a slash command per agent", Required: true},
+				{Scope: "Send Messages, Create Public Threads, Send Messages in Threads, Read Message History, Attach Files", Description: "Answer in threads started from the request and read channel context", Required: true},
+				{Scope: "Message Content intent", Description: "Read thread follow-ups that do not @mention the bot (privileged; enable under Bot in the developer portal)"},
+			},
+		})
+	}
+
+	for i := range result {
+		addRemediation(cfg, &result[i])
+	}
+
 	integrationsMu.Lock()
 	integrationsCache = result
 	integrationsMu.Unlock()
-	log.Println("Integration permissions refreshed")
+	logging.Infof("Integration permissions refreshed")
+}
+
+// addRemediation fills in step-by-step fix instructions for every permission
+// that was checked and found missing.
+func addRemediation(cfg *config.Config, ig *integration) {
+	jiraURL := strings.TrimRight(cfg.JiraURL, "/")
+	for i := range ig.Permissions {
+		p := &ig.Permissions[i]
+		if p.Extra || p.Granted == nil || *p.Granted {
+			continue
+		}
+		switch ig.ID {
+		case "slack":
+			if strings.HasPrefix(p.Scope, "message.") {
+				p.Remediation = fmt.Sprintf("Open https://api.slack.com/apps → your app → Event Subscriptions → Subscribe to bot events, add `%s`, save, and reinstall the app to the workspace.", p.Scope)
+			} else {
+				p.Remediation = fmt.Sprintf("Open https://api.slack.com/apps → your app → OAuth & Permissions → Bot Token Scopes, add `%s`, then click \"Reinstall to Workspace\" and update SLACK_BOT_TOKEN if it changed.", p.Scope)
+			}
+		case "github":
+			if ig.AuthMode == "Fine-grained Personal Access Token" {
+				perm, access, _ := strings.Cut(p.Scope, ":")
+				p.Remediation = fmt.Sprintf("Edit the token at https://github.com/settings/personal-access-tokens and set the %q permission to %q (or higher). Fine-grained tokens keep their value when edited.", perm, access)
+			} else {
+				p.Remediation = fmt.Sprintf("Edit the token at https://github.com/settings/tokens and enable the `%s` scope. Classic tokens keep their value when edited.", p.Scope)
+			}
+		case "jira":
+			if p.Scope == "BROWSE_USERS" {
+				p.Remediation = fmt.Sprintf("Grant the \"Browse users and groups\" global permission to the service account's group at %s/secure/admin/GlobalPermissions!default.jspa.", jiraURL)
+			} else if cfg.JiraProject != "" {
+				p.Remediation = fmt.Sprintf("Grant %s to the service account in the permission scheme used by project %s: %s/plugins/servlet/project-config/%s/permissions.", p.Scope, cfg.JiraProject, jiraURL, cfg.JiraProject)
+			} else {
+				p.Remediation = fmt.Sprintf("Grant %s to the service account in the project's permission scheme (Project settings → Permissions).", p.Scope)
+			}
+		case "nvd":
+			p.Remediation = "Request a free API key at https://nvd.nist.gov/developers/request-an-api-key and set NVD_API_KEY."
+		}
+	}
 }
 
 // startIntegrationsRefresher runs refreshIntegrations once immediately and
@@ -372,36 +576,379 @@ func startIntegrationsRefresher(
 	}()
 }
 
+// triggerPayload is the JSON body accepted by /api/agents/{id}/trigger.
+type triggerPayload struct {
+	Source   string         `json:"source"`
+	Text     string         `json:"text"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// triggerHandler runs an externally submitted prompt through an agent's tool
+// loop, posting the result to the agent's trigger channel.
+func triggerHandler(token string, routers map[string]*commands.Router, channels map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		agentID := r.PathValue("id")
+		router, ok := routers[agentID]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown agent %q", agentID), http.StatusNotFound)
+			return
+		}
+		channelID := channels[agentID]
+		if channelID == "" {
+			http.Error(w, fmt.Sprintf("agent %q has no trigger.channel in its config.yaml", agentID), http.StatusConflict)
+			return
+		}
+
+		var p triggerPayload
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&p); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON payload: %v", err), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(p.Text) == "" {
+			http.Error(w, "text is required", http.StatusBadRequest)
+			return
+		}
+		if p.Source == "" {
+			p.Source = "webhook"
+		}
+
+		go router.RunTriggered(p.Source, channelID, p.Text, p.Metadata)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "accepted", "agent": agentID, "channel": channelID})
+	}
+}
+
+// githubRoute is an agent that handles /github/webhook deliveries.
+type githubRoute struct {
+	agentID string
+	router  *commands.Router
+	events  prompts.GitHubEvents
+}
+
+// githubWebhookHandler verifies GitHub webhook deliveries and hands failed
+// workflow runs and newly opened pull requests to every agent whose
+// github_events config matches the repository. Pull request events also feed
+// the Jira sync when issueSync is non-nil; branch pushes go to onPush when
+// it is non-nil.
+func githubWebhookHandler(secret string, routes []githubRoute, issueSync *commands.IssueSync, onPush func(*github.WebhookEvent)) http.HandlerFunc {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool) // delivery IDs, guards against GitHub redeliveries
+		ids  []string
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 5<<20)
+		ev, err := github.ParseWebhook(r, secret)
+		if err != nil {
+			logging.Infof("rejected GitHub webhook: %v", err)
+			http.Error(w, "invalid webhook", http.StatusUnauthorized)
+			return
+		}
+
+		var event string
+		switch {
+		case ev.WorkflowFailed():
+			event = "workflow_failed"
+		case ev.PullRequestOpened() && !ev.Draft:
+			event = "pull_request_opened"
+		case issueSync != nil && ev.Type == "pull_request":
+			event = "pull_request_" + ev.Action
+		case onPush != nil && ev.BranchPushed():
+			event = "push"
+		default:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		mu.Lock()
+		dup := ev.DeliveryID != "" && seen[ev.DeliveryID]
+		if !dup && ev.DeliveryID != "" {
+			seen[ev.DeliveryID] = true
+			ids = append(ids, ev.DeliveryID)
+			if len(ids) > 1000 {
+				delete(seen, ids[0])
+				ids = ids[1:]
+			}
+		}
+		mu.Unlock()
+		if dup {
+			logging.Infof("ignoring duplicate GitHub delivery %s", ev.DeliveryID)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if event == "push" {
+			go onPush(ev)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if issueSync != nil && ev.Type == "pull_request" {
+			go issueSync.HandlePullRequest(ev)
+		}
+		var agents []string
+		for _, rt := range routes {
+			if rt.events.Handles(event, ev.Repo) {
+				agents = append(agents, rt.agentID)
+				go rt.router.HandleGitHubEvent(rt.events.Channel, ev)
+			}
+		}
+		logging.Infof("GitHub webhook %s (%s) for %s dispatched to %d agent(s)", event, ev.DeliveryID, ev.Repo, len(agents))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "accepted", "event": event, "agents": agents})
+	}
+}
+
+// syncAgents pulls the agents repository and, when the branch moved,
+// reloads every agent's prompts, recording the commit in the prompt history.
+// Agents added or removed and config.yaml changes take effect on restart.
+func syncAgents(ctx context.Context, s *agentsync.Syncer, agentPrompts map[string]*prompts.AgentPrompts, trigger string) (agentsync.Commit, bool, error) {
+	head, changed, err := s.Sync(ctx)
+	if err != nil || !changed {
+		return head, changed, err
+	}
+	note := fmt.Sprintf("commit %s: %s", head.Short(), head.Message)
+	for id, ap := range agentPrompts {
+		if err := ap.Reload(head.Author, "sync", note); err != nil {
+			logging.Errorf("[agentsync] failed to reload prompts for agent %s: %v", id, err)
+		}
+	}
+	if agents, err := prompts.DiscoverAgents(""); err == nil {
+		synced := make(map[string]bool, len(agents))
+		for _, a := range agents {
+			synced[a.ID] = true
+			if agentPrompts[a.ID] == nil {
+				logging.Warnf("[agentsync] agent %s was added in %s; restart to load it", a.ID, head.Short())
+			}
+		}
+		for id := range agentPrompts {
+			if !synced[id] {
+				logging.Warnf("[agentsync] agent %s was removed in %s; it keeps running until a restart", id, head.Short())
+			}
+		}
+	}
+	logging.Infof("[agentsync] synced %s to %s by %s (%s, via %s)", s, head.Short(), head.Author, head.Message, trigger)
+	return head, true, nil
+}
+
+// jiraRoute is an agent rule that handles /jira/webhook deliveries.
+type jiraRoute struct {
+	agentID string
+	router  *commands.Router
+	rule    prompts.JiraEventRule
+}
+
+// jiraPromptCooldown limits prompt runs per agent and issue, so a triage
+// prompt that updates the issue can't retrigger itself.
+const jiraPromptCooldown = 10 * time.Minute
+
+// jiraWebhookHandler verifies Jira webhook deliveries, posts them to the
+// channels of matching agent rules, and feeds status changes to the
+// Jira ↔ GitHub sync when issueSync is non-nil.
+func jiraWebhookHandler(secret string, routes []jiraRoute, issueSync *commands.IssueSync) http.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		lastRun = make(map[string]time.Time) // agent/issue → last prompt run
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 5<<20)
+		ev, err := jira.ParseWebhook(r, secret)
+		if err != nil {
+			logging.Infof("rejected Jira webhook: %v", err)
+			http.Error(w, "invalid webhook", http.StatusUnauthorized)
+			return
+		}
+		if issueSync != nil && ev.Event == "jira:issue_updated" && ev.StatusChanged() {
+			go issueSync.HandleJiraIssue(ev)
+		}
+
+		// One post per agent: the first matching rule wins.
+		handled := make(map[string]bool)
+		var agents []string
+		for _, rt := range routes {
+			if handled[rt.agentID] || !rt.rule.Matches(ev.Kind(), ev.Project, ev.Labels) {
+				continue
+			}
+			handled[rt.agentID] = true
+			prompt := rt.rule.Prompt
+			if prompt != "" {
+				key := rt.agentID + "/" + ev.IssueKey
+				now := time.Now()
+				mu.Lock()
+				recent := now.Sub(lastRun[key]) < jiraPromptCooldown
+				if !recent {
+					lastRun[key] = now
+				}
+				for k, t := range lastRun {
+					if now.Sub(t) >= jiraPromptCooldown {
+						delete(lastRun, k)
+					}
+				}
+				mu.Unlock()
+				if recent {
+					logging.Infof("Jira %s on %s: agent %s ran a prompt for this issue recently, posting summary only", ev.Kind(), ev.IssueKey, rt.agentID)
+					prompt = ""
+				}
+			}
+			agents = append(agents, rt.agentID)
+			go rt.router.HandleJiraEvent(rt.rule.Channel, prompt, ev)
+		}
+		logging.Infof("Jira webhook %s on %s dispatched to %d agent(s)", ev.Kind(), ev.IssueKey, len(agents))
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// startUsageReporter posts the previous month's LLM usage summary to channelID
+// once the calendar month (UTC) rolls over. Usage is kept in memory, so a
+// restart mid-month only reports usage since the restart.
+func startUsageReporter(slackClient *slack.Client, usage *commands.UsageTracker, channelID string) {
+	go func() {
+		reported := time.Now().UTC().Format("2006-01")
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			current := time.Now().UTC().Format("2006-01")
+			if current == reported {
+				continue
+			}
+			if _, err := slackClient.PostMessage(channelID, commands.FormatUsageReport(usage.Report(reported))); err != nil {
+				logging.Errorf("[usage] failed to post monthly summary for %s: %v", reported, err)
+				continue
+			}
+			logging.Infof("[usage] posted monthly summary for %s to %s", reported, channelID)
+			reported = current
+		}
+	}()
+	logging.Infof("Monthly LLM usage summary will be posted to %s", channelID)
+}
+
+// newModelsClient builds an LLM client for model on the configured provider,
+// applying the shared retry and reasoning settings. azureCred is non-nil when
+// Azure OpenAI uses Entra ID auth and is shared so tokens are cached once.
+func newModelsClient(cfg *config.Config, azureCred *github.AzureCredential, model string) *github.ModelsClient {
+	var mc *github.ModelsClient
+	switch cfg.LLMProvider() {
+	case "openai":
+		mc = github.NewOpenAIModelsClient(cfg.OpenAIAPIKey, cfg.OpenAIBaseURL, model)
+	case "anthropic":
+		mc = github.NewAnthropicModelsClient(cfg.AnthropicAPIKey, cfg.AnthropicBaseURL, model)
+	case "ollama":
+		mc = github.NewOllamaModelsClient(cfg.OllamaEndpoint, cfg.OllamaAPIKey, model)
+	case "azure":
+		if azureCred != nil {
+			mc = github.NewAzureModelsClientWithCredential(cfg.AzureEndpoint, azureCred, model)
+		} else {
+			mc = github.NewAzureModelsClient(cfg.AzureEndpoint, cfg.AzureAPIKey, model)
+		}
+	default:
+		mc = github.NewModelsClient(cfg.GitHubToken, model)
+	}
+	if cfg.ReasoningEffort != "" {
+		mc.SetReasoningEffort(cfg.ReasoningEffort)
+	}
+	if cfg.LLMMaxRetries >= 0 {
+		mc.SetMaxRetries(cfg.LLMMaxRetries)
+	}
+	return mc
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:]))
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("configuration error: %v", err)
 	}
+	if err := logging.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("configuration error: %v", err)
+	}
+	// Every integration client ends up on the default transport, so this
+	// tags their API calls with the request ID and logs them at debug level.
+	http.DefaultTransport = &logging.Transport{Base: http.DefaultTransport}
 
 	slackClient := slack.NewClient(cfg.SlackBotToken)
 
+	// With SCM_PROVIDER=gitlab or bitbucket the repository tools use that host
+	// and the GitHub-only tools are off; GITHUB_TOKEN then only backs GitHub
+	// Models.
 	var ghClient *github.Client
-	if cfg.GitHubToken != "" {
+	var scmProvider scm.Provider
+	switch {
+	case cfg.SCMProvider == "gitlab", cfg.SCMProvider == "bitbucket":
+		logs, err := github.NewLogScanner(cfg.WorkflowLogPatterns, cfg.WorkflowLogBudget)
+		if err != nil {
+			log.Fatalf("Invalid WORKFLOW_LOG_ERROR_PATTERNS: %v", err)
+		}
+		if cfg.SCMProvider == "gitlab" {
+			scmProvider = scm.NewGitLab(gitlab.NewClient(cfg.GitLabURL, cfg.GitLabToken), cfg.GitLabGroup, logs)
+			logging.Infof("GitLab enabled (%s, group %s)", cfg.GitLabURL, cfg.GitLabGroup)
+		} else {
+			scmProvider = scm.NewBitbucket(bitbucket.NewClient(cfg.BitbucketUsername, cfg.BitbucketToken), cfg.BitbucketWorkspace, logs)
+			logging.Infof("Bitbucket enabled (workspace %s)", cfg.BitbucketWorkspace)
+		}
+	case cfg.GitHubToken != "":
 		ghClient = github.NewClient(cfg.GitHubToken)
+		if err := ghClient.SetJobLogOptions(cfg.WorkflowLogPatterns, cfg.WorkflowLogBudget); err != nil {
+			log.Fatalf("Invalid WORKFLOW_LOG_ERROR_PATTERNS: %v", err)
+		}
 	}
 
-	var modelsClient *github.ModelsClient
-	var codeModelsClient *github.ModelsClient
-	if cfg.UseAzure() {
-		modelsClient = github.NewAzureModelsClient(cfg.AzureEndpoint, cfg.AzureAPIKey, cfg.GeneralModel)
-		log.Printf("Using Azure OpenAI backend: %s (general: %s)", cfg.AzureEndpoint, cfg.GeneralModel)
-		codeModelsClient = github.NewAzureModelsClient(cfg.AzureEndpoint, cfg.AzureAPIKey, cfg.CodeModel)
-		if cfg.CodeModel != cfg.GeneralModel {
-			log.Printf("Code model (Azure): %s", cfg.CodeModel)
-		}
-	} else {
-		modelsClient = github.NewModelsClient(cfg.GitHubToken, cfg.GeneralModel)
-		log.Printf("Using GitHub Models backend (general: %s)", cfg.GeneralModel)
-		codeModelsClient = github.NewModelsClient(cfg.GitHubToken, cfg.CodeModel)
-		if cfg.CodeModel != cfg.GeneralModel {
-			log.Printf("Code model (GitHub): %s", cfg.CodeModel)
+	var azureCred *github.AzureCredential
+	if cfg.LLMProvider() == "azure" && cfg.AzureUseEntraID() {
+		azureCred = github.NewAzureCredentialFromEnv()
+	}
+	modelsClient := newModelsClient(cfg, azureCred, cfg.GeneralModel)
+	codeModelsClient := newModelsClient(cfg, azureCred, cfg.CodeModel)
+	switch cfg.LLMProvider() {
+	case "openai":
+		logging.Infof("Using OpenAI backend (general: %s)", cfg.GeneralModel)
+	case "anthropic":
+		logging.Infof("Using Anthropic backend (general: %s)", cfg.GeneralModel)
+	case "ollama":
+		logging.Infof("Using Ollama backend: %s (general: %s)", cfg.OllamaEndpoint, cfg.GeneralModel)
+	case "azure":
+		logging.Infof("Using Azure OpenAI backend: %s (general: %s, auth: %s)", cfg.AzureEndpoint, cfg.GeneralModel, modelsClient.AzureAuthMode())
+	default:
+		logging.Infof("Using GitHub Models backend (general: %s)", cfg.GeneralModel)
+	}
+	if cfg.CodeModel != cfg.GeneralModel {
+		logging.Infof("Code model (%s): %s", modelsClient.Provider(), cfg.CodeModel)
+	}
+
+	// LLM usage and cost tracking per user, channel, agent, and model.
+	prices, err := commands.ParseModelPrices(cfg.LLMPrices)
+	if err != nil {
+		log.Fatalf("invalid LLM_PRICES: %v", err)
+	}
+	usage := commands.NewUsageTracker(prices)
+	// Daily spend caps per user and channel count the same usage.
+	spendCaps, err := commands.NewSpendCaps(commands.SpendLimits{
+		UserTokens:     cfg.DailyUserTokens,
+		UserActions:    cfg.DailyUserActions,
+		ChannelTokens:  cfg.DailyChannelTokens,
+		ChannelActions: cfg.DailyChannelActions,
+	}, cfg.SpendCapFile)
+	if err != nil {
+		log.Fatalf("failed to load spend caps: %v", err)
+	}
+	var usageRecorder github.UsageRecorder = usage
+	if spendCaps != nil {
+		usageRecorder = github.UsageRecorders{usage, spendCaps}
+		logging.Infof("Daily spend caps enabled: %+v", spendCaps.Status().Limits)
+		if cfg.SpendCapFile != "" {
+			logging.Infof("Persisting spend cap counters to %s", cfg.SpendCapFile)
 		}
 	}
+	modelsClient.SetUsageRecorder(usageRecorder)
+	codeModelsClient.SetUsageRecorder(usageRecorder)
 
 	var jiraClient *jira.Client
 
@@ -409,12 +956,12 @@ func main() {
 	if err := modelsClient.ValidateModel(context.Background()); err != nil {
 		log.Fatalf("GENERAL_MODEL validation failed: %v", err)
 	}
-	log.Printf("GENERAL_MODEL validated: %s", cfg.GeneralModel)
+	logging.Infof("GENERAL_MODEL validated: %s", cfg.GeneralModel)
 	if cfg.CodeModel != cfg.GeneralModel {
 		if err := codeModelsClient.ValidateModel(context.Background()); err != nil {
 			log.Fatalf("CODE_MODEL validation failed: %v", err)
 		}
-		log.Printf("CODE_MODEL validated: %s", cfg.CodeModel)
+		logging.Infof("CODE_MODEL validated: %s", cfg.CodeModel)
 	}
 
 	if cfg.JiraConfigured() {
@@ -424,21 +971,171 @@ func main() {
 			if err != nil {
 				log.Fatalf("Jira OAuth initialization failed: %v", err)
 			}
-			log.Printf("Jira integration enabled (OAuth): %s (default project: %s)", cfg.JiraURL, cfg.JiraProject)
+			logging.Infof("Jira integration enabled (OAuth): %s (default project: %s)", cfg.JiraURL, cfg.JiraProject)
 		} else {
 			jiraClient = jira.NewClient(cfg.JiraURL, cfg.JiraEmail, cfg.JiraAPIToken, cfg.JiraProject)
-			log.Printf("Jira integration enabled (Basic Auth): %s (default project: %s)", cfg.JiraURL, cfg.JiraProject)
+			logging.Infof("Jira integration enabled (Basic Auth): %s (default project: %s)", cfg.JiraURL, cfg.JiraProject)
 		}
+		jiraClient.SetMetadataTTL(cfg.JiraMetadataTTL)
+	}
+
+	var adoClient *ado.Client
+	if cfg.ADOConfigured() {
+		adoClient = ado.NewClient(cfg.ADOOrgURL, cfg.ADOPAT, cfg.ADOProject)
+		logging.Infof("Azure DevOps integration enabled: %s (default project: %s)", cfg.ADOOrgURL, cfg.ADOProject)
+	}
+
+	var notionClient *notion.Client
+	if cfg.NotionToken != "" {
+		notionClient = notion.NewClient(cfg.NotionToken)
+		logging.Infof("Notion integration enabled")
+	}
+
+	postmortem := commands.PostmortemTarget{NotionDatabase: cfg.NotionPostmortemDB}
+	if cfg.ConfluenceSpace != "" {
+		postmortem.Confluence = confluence.NewClient(cfg.JiraURL, cfg.JiraEmail, cfg.JiraAPIToken)
+		postmortem.ConfluenceSpace = cfg.ConfluenceSpace
+		postmortem.ConfluenceParent = cfg.ConfluenceParentPage
+		logging.Infof("Confluence postmortems enabled (space %s)", cfg.ConfluenceSpace)
+	}
+
+	var dir directory.Provider
+	switch cfg.DirectoryProvider {
+	case "okta":
+		dir = directory.NewOkta(cfg.OktaOrgURL, cfg.OktaAPIToken)
+		logging.Infof("Directory integration enabled: Okta (%s)", cfg.OktaOrgURL)
+	case "azuread":
+		cred := github.NewAzureCredentialFromEnv()
+		dir = directory.NewAzureAD(cred)
+		logging.Infof("Directory integration enabled: Azure AD (%s)", cred.Method())
+	}
+
+	var metricsBackend observability.Backend
+	switch cfg.MetricsProvider {
+	case "datadog":
+		metricsBackend = observability.NewDatadog(cfg.DatadogSite, cfg.DatadogAPIKey, cfg.DatadogAppKey)
+		logging.Infof("Metrics integration enabled: Datadog")
+	case "prometheus":
+		metricsBackend = observability.NewPrometheus(cfg.PrometheusURL, cfg.PrometheusToken)
+		logging.Infof("Metrics integration enabled: Prometheus API at %s", cfg.PrometheusURL)
+	}
+
+	var vaultClient *vault.Client
+	if cfg.VaultAddr != "" && cfg.VaultToken != "" {
+		vaultClient = vault.NewClient(cfg.VaultAddr, cfg.VaultToken, cfg.VaultNamespace, cfg.VaultKVMount)
+		logging.Infof("Vault integration enabled: %s (metadata only)", cfg.VaultAddr)
+	}
+
+	var awsClient *aws.Client
+	if cfg.AWSTools {
+		cred := aws.NewCredentialFromEnv(cfg.AWSRegion, cfg.AWSToolsRoleARN)
+		awsClient = aws.NewClient(cfg.AWSRegion, cred)
+		logging.Infof("AWS tools enabled: %s (%s)", cfg.AWSRegion, cred.Method())
+	}
+
+	var gcpClient *gcp.Client
+	if cfg.GCPProject != "" {
+		ts, method, err := gcp.NewTokenSource()
+		if err != nil {
+			log.Fatalf("GCP tools: %v", err)
+		}
+		gcpClient = gcp.NewClient(cfg.GCPProject, cfg.GCPRegion, ts)
+		logging.Infof("GCP tools enabled: project %s (%s)", cfg.GCPProject, method)
+	}
+
+	var cloudflareClient *cloudflare.Client
+	if cfg.CloudflareAPIToken != "" {
+		cloudflareClient = cloudflare.NewClient(cfg.CloudflareAPIToken)
+		logging.Infof("Cloudflare integration enabled")
+	}
+
+	var terraformClient *terraform.Client
+	if cfg.TerraformToken != "" {
+		terraformClient = terraform.NewClient(cfg.TerraformAddress, cfg.TerraformToken, cfg.TerraformOrganization)
+		logging.Infof("Terraform Cloud integration enabled")
+	}
+
+	// ECR scans come with the AWS tools; Harbor is configured separately.
+	var imageScanners []imagescan.Scanner
+	if awsClient != nil {
+		imageScanners = append(imageScanners, imagescan.NewECR(awsClient))
+	}
+	if cfg.HarborURL != "" {
+		harbor, err := imagescan.NewHarbor(cfg.HarborURL, cfg.HarborUsername, cfg.HarborPassword)
+		if err != nil {
+			log.Fatalf("Harbor: %v", err)
+		}
+		imageScanners = append(imageScanners, harbor)
+		logging.Infof("Harbor image scan lookups enabled: %s", cfg.HarborURL)
+	}
+
+	var costSources []cost.Source
+	if cfg.AWSCostExplorer {
+		costSources = append(costSources, cost.NewAWS(awsClient))
+		logging.Infof("AWS Cost Explorer enabled")
+	}
+	if cfg.GCPBillingTable != "" {
+		costSources = append(costSources, cost.NewGCP(gcpClient, cfg.GCPBillingTable))
+		logging.Infof("GCP billing export enabled: %s", cfg.GCPBillingTable)
+	}
+
+	var argoCDClient *argocd.Client
+	if cfg.ArgoCDURL != "" && cfg.ArgoCDToken != "" {
+		argoCDClient = argocd.NewClient(cfg.ArgoCDURL, cfg.ArgoCDToken)
+		logging.Infof("Argo CD integration enabled: %s", cfg.ArgoCDURL)
+	}
+
+	var pagerDutyClient *pagerduty.Client
+	if cfg.PagerDutyToken != "" {
+		pagerDutyClient = pagerduty.NewClient(cfg.PagerDutyToken, cfg.PagerDutyFromEmail)
+		if cfg.PagerDutyFromEmail == "" {
+			logging.Infof("PagerDuty integration enabled (read-only: PAGERDUTY_FROM_EMAIL not set)")
+		} else {
+			logging.Infof("PagerDuty integration enabled (updates as %s)", cfg.PagerDutyFromEmail)
+		}
+	}
+
+	// On-call lookups — Opsgenie when configured, otherwise PagerDuty schedules.
+	var onCall oncall.Provider
+	if cfg.OpsgenieAPIKey != "" {
+		onCall = oncall.NewOpsgenie(cfg.OpsgenieAPIURL, cfg.OpsgenieAPIKey)
+		logging.Infof("On-call lookups enabled: Opsgenie")
+	} else if pagerDutyClient != nil {
+		onCall = oncall.NewPagerDuty(pagerDutyClient)
+		logging.Infof("On-call lookups enabled: PagerDuty")
 	}
 
 	// NVD CVE API client — enables CVE lookup for the security researcher agent.
 	var nvdClient *nvd.Client
 	if cfg.NVDAPIKey != "" {
 		nvdClient = nvd.NewClient(cfg.NVDAPIKey)
-		log.Printf("NVD integration enabled (API key set)")
+		logging.Infof("NVD integration enabled (API key set)")
 	} else {
 		nvdClient = nvd.NewClient("")
-		log.Printf("NVD integration enabled (no API key — rate-limited)")
+		logging.Infof("NVD integration enabled (no API key — rate-limited)")
+	}
+
+	// OSV vulnerability database — backs scan_dependencies; needs no credentials.
+	osvClient := osv.NewClient()
+
+	// Git-backed agents directory — synced before the first agent loads, so
+	// prompts and settings come from the reviewed branch, not the image.
+	var agentSync *agentsync.Syncer
+	if cfg.AgentsGitURL != "" {
+		dir := os.Getenv("AGENTS_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "agents")
+			os.Setenv("AGENTS_DIR", dir)
+		}
+		agentSync, err = agentsync.New(cfg.AgentsGitURL, cfg.AgentsGitProvider, cfg.AgentsGitBranch, cfg.AgentsGitToken, cfg.AgentsGitPath, dir)
+		if err != nil {
+			log.Fatalf("invalid AGENTS_GIT_URL: %v", err)
+		}
+		head, _, err := agentSync.Sync(context.Background())
+		if err != nil {
+			log.Fatalf("failed to sync agents: %v", err)
+		}
+		logging.Infof("Synced agents from %s at %s into %s", agentSync, head.Short(), dir)
 	}
 
 	// Discover agents and register per-agent webhook routes (/<agent>/webhook).
@@ -453,36 +1150,339 @@ func main() {
 	// Start background integration permission refresher (runs once now, then every hour).
 	startIntegrationsRefresher(cfg, slackClient, ghClient, jiraClient, modelsClient, codeModelsClient)
 
+	if cfg.UsageReportChannel != "" {
+		startUsageReporter(slackClient, usage, cfg.UsageReportChannel)
+	}
+
 	// Thread session store — enables follow-up replies in threads without /commands.
 	sessions := commands.NewSessionStore(cfg.ThreadSessionTTL)
-	log.Printf("Thread session TTL: %s", cfg.ThreadSessionTTL)
+	logging.Infof("Thread session TTL: %s", cfg.ThreadSessionTTL)
+	if cfg.ThreadSummaryMinSteps > 0 {
+		sessions.SetSummaryMinSteps(cfg.ThreadSummaryMinSteps)
+		logging.Infof("Expired thread sessions with %d+ steps get a recap", cfg.ThreadSummaryMinSteps)
+	}
+
+	// Change ledger — records every write-type tool execution for auditing.
+	ledger, err := commands.NewChangeLedger(cfg.ChangeLedgerFile)
+	if err != nil {
+		log.Fatalf("failed to load change ledger: %v", err)
+	}
+	if cfg.ChangeLedgerFile != "" {
+		logging.Infof("Persisting change ledger to %s (%d change(s) from the last 90 days loaded)", cfg.ChangeLedgerFile, ledger.Len())
+	}
+
+	// Tool result compression — large tool outputs are summarized by a cheap model.
+	var summarizer *github.ModelsClient
+	if cfg.CompressThreshold > 0 {
+		summarizer = newModelsClient(cfg, azureCred, cfg.SummarizerModel)
+		summarizer.SetUsageRecorder(usageRecorder)
+		logging.Infof("Tool result compression enabled: results over ~%d tokens summarized by %s", cfg.CompressThreshold, cfg.SummarizerModel)
+	}
+
+	// Benchmark corpus — records real requests so candidate models can be compared via /api/bench.
+	var benchRecorder *commands.BenchRecorder
+	if cfg.BenchCorpusFile != "" {
+		benchRecorder = commands.NewBenchRecorder(cfg.BenchCorpusFile)
+		logging.Infof("Recording requests to benchmark corpus: %s", cfg.BenchCorpusFile)
+	}
+
+	// Recent requests per user, shown on the App Home tab.
+	requestLog := commands.NewRequestLog()
+
+	// Activity store — feeds the per-channel activity reports.
+	activity, err := commands.NewActivityStore(cfg.ActivityFile)
+	if err != nil {
+		log.Fatalf("failed to load activity store: %v", err)
+	}
+	if cfg.ActivityFile != "" {
+		logging.Infof("Persisting activity to %s (%d recent event(s) loaded)", cfg.ActivityFile, activity.Len())
+	}
+
+	// Audit log — every tool invocation, queried via /api/audit.
+	auditLog, err := commands.NewAuditLog(cfg.AuditLogFile, time.Duration(cfg.AuditRetentionDays)*24*time.Hour)
+	if err != nil {
+		log.Fatalf("failed to load audit log: %v", err)
+	}
+	if cfg.AuditLogFile != "" {
+		logging.Infof("Persisting audit log to %s (%d entries loaded, retention %d days)", cfg.AuditLogFile, auditLog.Len(), cfg.AuditRetentionDays)
+	}
+
+	// Read-only mode — READ_ONLY and read_only in agent config.yaml, flipped
+	// at runtime through /api/read-only.
+	readOnly := commands.NewReadOnlySwitch(cfg.ReadOnly)
+	if cfg.ReadOnly {
+		logging.Infof("Read-only mode: write tools are disabled for every agent")
+	}
+
+	// Maintenance mode — defers (and optionally queues) new requests during
+	// planned downtime; switched through /api/maintenance.
+	maintenance := commands.NewMaintenance()
+
+	// Secret redaction — masks tokens and passwords in LLM inputs and posts.
+	var redactor *redact.Redactor
+	if cfg.SecretRedaction {
+		redactor, err = redact.New(cfg.RedactPatterns, cfg.RedactEntropy)
+		if err != nil {
+			log.Fatalf("Invalid REDACT_PATTERNS: %v", err)
+		}
+		logging.Infof("Secret redaction enabled (%d extra pattern(s), entropy detection %t)", len(cfg.RedactPatterns), cfg.RedactEntropy)
+	}
+
+	// FAQ — curated answers to questions that keep coming back.
+	var faq *commands.FAQStore
+	if cfg.EmbeddingModel != "" {
+		faq, err = commands.NewFAQStore(cfg.FAQFile, newModelsClient(cfg, azureCred, cfg.EmbeddingModel), cfg.FAQMinRepeats)
+		if err != nil {
+			log.Fatalf("failed to load FAQ store: %v", err)
+		}
+		logging.Infof("FAQ loop enabled with %s (%d saved answer(s), suggested after %d repeats)", cfg.EmbeddingModel, faq.Len(), cfg.FAQMinRepeats)
+	}
+
+	// Linked accounts — Slack users → GitHub logins and Jira account IDs.
+	identities, err := commands.NewIdentityStore(cfg.IdentityFile)
+	if err != nil {
+		log.Fatalf("failed to load identity store: %v", err)
+	}
+	if cfg.IdentityFile != "" {
+		logging.Infof("Persisting linked accounts to %s (%d loaded)", cfg.IdentityFile, len(identities.List()))
+	}
+
+	// CVE watchlists — per-channel products polled against NVD.
+	cveWatches, err := commands.NewCVEWatchStore(cfg.CVEWatchFile)
+	if err != nil {
+		log.Fatalf("failed to load CVE watch store: %v", err)
+	}
+	if cfg.CVEWatchFile != "" {
+		logging.Infof("Persisting CVE watchlists to %s (%d channel(s) loaded)", cfg.CVEWatchFile, len(cveWatches.List()))
+	}
+	commands.NewCVEWatcher(nvdClient, slackClient, cveWatches, cfg.CVEWatchInterval).Start(context.Background())
+	logging.Infof("Polling NVD for watched CVEs every %s", cfg.CVEWatchInterval)
+
+	// Prompt history — a version of every agent's prompts.yaml each time it
+	// changes, for /api/agents/{id}/prompt-history and rollbacks.
+	promptHistory, err := prompts.NewHistory(cfg.PromptHistoryFile)
+	if err != nil {
+		log.Fatalf("failed to load prompt history: %v", err)
+	}
+	if cfg.PromptHistoryFile != "" {
+		logging.Infof("Persisting prompt history to %s", cfg.PromptHistoryFile)
+	}
 
 	// Map of agentID → Router so the events handler can dispatch thread replies.
 	routers := make(map[string]*commands.Router, len(agents))
+	// Prompt stores per agent, reloaded through /api/prompts/reload.
+	agentPrompts := make(map[string]*prompts.AgentPrompts, len(agents))
+	// Channels claimed by agents in their config.yaml (channel ID → agent ID).
+	agentChannels := make(map[string]string)
+	// Slack channel per agent for /api/agents/{id}/trigger runs.
+	triggerChannels := make(map[string]string)
+	// Agents that react to /github/webhook and /jira/webhook events.
+	var githubRoutes []githubRoute
+	var jiraRoutes []jiraRoute
+	// Scheduled prompts from agent config.yaml, run through the agent's router.
+	sched := scheduler.New(func(job *scheduler.Job) {
+		routers[job.AgentID].RunScheduled(job.Name, job.Channel, job.UserID, job.Prompt)
+	})
+
+	// A per-agent signing secret for an agent that doesn't exist is a typo
+	// that would leave the real agent accepting the shared secret.
+	agentKeys := make(map[string]bool, len(agents))
+	for _, agent := range agents {
+		agentKeys[config.AgentSecretKey(agent.ID)] = true
+	}
+	for key := range cfg.AgentSigningSecrets {
+		if !agentKeys[key] {
+			logging.Warnf("SLACK_SIGNING_SECRET_%s matches no agent", key)
+		}
+	}
 
 	for _, agent := range agents {
 		ap, err := prompts.LoadAgent(agent.ID)
 		if err != nil {
 			log.Fatalf("failed to load prompts for agent %s: %v", agent.ID, err)
 		}
+		if err := ap.SetHistory(promptHistory); err != nil {
+			log.Fatalf("failed to record prompt version for agent %s: %v", agent.ID, err)
+		}
 
-		router := commands.NewRouter(slackClient, ghClient, modelsClient, codeModelsClient, jiraClient, nvdClient, ap, agent.ID, cfg.AppURL, sessions, cfg.MaxToolRounds)
+		settings, err := prompts.LoadAgentSettings(agent.ID)
+		if err != nil {
+			log.Fatalf("failed to load config for agent %s: %v", agent.ID, err)
+		}
+		if len(settings.Tools.Allow) > 0 || len(settings.Tools.Deny) > 0 {
+			logging.Infof("Agent %q tool policy: allow=%v deny=%v", agent.ID, settings.Tools.Allow, settings.Tools.Deny)
+		}
+		if settings.Repos.Restricted() {
+			logging.Infof("Agent %q repo policy: read=%v write=%v", agent.ID, settings.Repos.Read, settings.Repos.Write)
+		}
+		for _, ch := range settings.Channels {
+			agentChannels[ch] = agent.ID
+		}
+		if settings.Trigger.Channel != "" {
+			triggerChannels[agent.ID] = settings.Trigger.Channel
+		}
+		for i, sc := range settings.Schedules {
+			if sc.Name == "" {
+				sc.Name = fmt.Sprintf("schedule-%d", i+1)
+			}
+			if sc.Channel == "" || sc.Prompt == "" {
+				log.Fatalf("agent %s schedule %q: channel and prompt are required", agent.ID, sc.Name)
+			}
+			job, err := scheduler.NewJob(agent.ID, sc.Name, sc.Cron, sc.Prompt, sc.Channel, sc.Timezone)
+			if err != nil {
+				log.Fatalf("agent %s schedule %q: %v", agent.ID, sc.Name, err)
+			}
+			sched.Add(job)
+			logging.Infof("Agent %q schedule %q: cron=%q channel=%s next=%s", agent.ID, sc.Name, sc.Cron, sc.Channel, job.Next(time.Now()).Format(time.RFC3339))
+		}
+
+		router := commands.NewRouter(slackClient, ghClient, modelsClient, codeModelsClient, jiraClient, nvdClient, ap, settings, agent.ID, cfg.AppURL, sessions, ledger, cfg.MaxToolRounds)
+		if redactor != nil {
+			router.SetRedactor(redactor)
+		}
+		router.SetContextBudget(cfg.ContextTokenBudget)
+		router.SetResultCompression(summarizer, cfg.CompressThreshold)
+		router.SetThreadHistoryLimit(cfg.ThreadHistoryMaxTurns)
+		router.SetRequestLog(requestLog)
+		router.SetActivityStore(activity)
+		router.SetAuditLog(auditLog)
+		router.SetFAQStore(faq)
+		router.SetIdentityStore(identities)
+		router.SetADOClient(adoClient)
+		router.SetCVEWatchStore(cveWatches)
+		router.SetOSVClient(osvClient)
+		router.SetNotionClient(notionClient)
+		router.SetPostmortemTarget(postmortem)
+		router.SetPagerDutyClient(pagerDutyClient)
+		router.SetOnCallProvider(onCall)
+		router.SetDirectory(dir)
+		router.SetMetricsBackend(metricsBackend)
+		router.SetVaultClient(vaultClient)
+		router.SetAWSClient(awsClient)
+		router.SetGCPClient(gcpClient)
+		router.SetArgoCDClient(argoCDClient)
+		router.SetCloudflareClient(cloudflareClient)
+		router.SetTerraformClient(terraformClient)
+		router.SetImageScanners(imageScanners)
+		router.SetCostSources(costSources)
+		if scmProvider != nil {
+			router.SetSCMProvider(scmProvider)
+		}
+		router.SetScheduler(sched)
+		if settings.ReadOnly {
+			readOnly.Set(agent.ID, true)
+			logging.Infof("Agent %q is read-only", agent.ID)
+		}
+		router.SetReadOnly(readOnly)
+		router.SetSpendCaps(spendCaps)
+		if cfg.DryRun || settings.DryRun {
+			router.SetDryRun(true)
+			logging.Infof("Agent %q is in dry-run mode: write tools only preview their changes", agent.ID)
+		}
+		router.SetMaintenance(maintenance)
+		access, err := commands.NewAccessControl(settings.Access, slackClient.GetUserGroupMembers)
+		if err != nil {
+			log.Fatalf("agent %s: %v", agent.ID, err)
+		}
+		if access != nil {
+			router.SetAccessControl(access)
+			logging.Infof("Agent %q access rules: %d", agent.ID, len(settings.Access.Rules))
+		}
+		freezes, err := commands.NewFreezeWindows(settings.Freezes)
+		if err != nil {
+			log.Fatalf("agent %s: %v", agent.ID, err)
+		}
+		if len(freezes) > 0 {
+			router.SetFreezes(freezes)
+			logging.Infof("Agent %q change freezes: %d", agent.ID, len(freezes))
+		}
+		runbooks, err := commands.NewRunbooks(settings.Runbooks)
+		if err != nil {
+			log.Fatalf("agent %s: %v", agent.ID, err)
+		}
+		if len(runbooks) > 0 {
+			router.SetRunbooks(runbooks)
+			logging.Infof("Agent %q runbooks: %d", agent.ID, len(runbooks))
+		}
+		if benchRecorder != nil {
+			router.SetBenchRecorder(benchRecorder)
+		}
 		routers[agent.ID] = router
-		handler := slack.NewHandler(cfg.SlackSigningSecret, router.Handle)
+		agentPrompts[agent.ID] = ap
+		if settings.GitHub.Channel != "" {
+			githubRoutes = append(githubRoutes, githubRoute{agentID: agent.ID, router: router, events: settings.GitHub})
+		}
+		for _, rule := range settings.Jira {
+			if rule.Channel == "" {
+				log.Fatalf("agent %s jira_events: channel is required", agent.ID)
+			}
+			jiraRoutes = append(jiraRoutes, jiraRoute{agentID: agent.ID, router: router, rule: rule})
+		}
+		for i, rc := range settings.Reports {
+			if rc.Name == "" {
+				rc.Name = fmt.Sprintf("activity-report-%d", i+1)
+			}
+			if rc.Channel == "" {
+				log.Fatalf("agent %s report %q: channel is required", agent.ID, rc.Name)
+			}
+			if rc.Cron == "" {
+				rc.Cron = "0 9 * * mon"
+			}
+			if rc.Days <= 0 {
+				rc.Days = 7
+			}
+			job, err := scheduler.NewJob(agent.ID, rc.Name, rc.Cron, fmt.Sprintf("activity report (last %d days)", rc.Days), rc.Channel, rc.Timezone)
+			if err != nil {
+				log.Fatalf("agent %s report %q: %v", agent.ID, rc.Name, err)
+			}
+			job.Run = func() { router.PostActivityReport(rc.Name, rc.Channel, rc.Sources, rc.Days) }
+			sched.Add(job)
+			logging.Infof("Agent %q report %q: cron=%q channel=%s next=%s", agent.ID, rc.Name, rc.Cron, rc.Channel, job.Next(time.Now()).Format(time.RFC3339))
+		}
+		signingSecret := cfg.SigningSecretFor(agent.ID)
+		if signingSecret != cfg.SlackSigningSecret {
+			logging.Infof("Agent %q verifies slash commands with its own signing secret", agent.ID)
+		}
+		handler := slack.NewHandler(signingSecret, router.Handle)
 
 		webhookPath := fmt.Sprintf("/%s/webhook", agent.ID)
 		http.Handle(webhookPath, handler)
-		log.Printf("Registered agent %q at %s", agent.ID, webhookPath)
+		logging.Infof("Registered agent %q at %s", agent.ID, webhookPath)
 	}
 
+	// Always run: changes deferred past a freeze are added at runtime.
+	go sched.Start(context.Background())
+
+	// @mentions on Slack and Teams go to the agent mapped to the channel
+	// (config.yaml channels, overridden by CHANNEL_AGENTS), else DEFAULT_AGENT,
+	// else the first discovered agent.
+	defaultAgent := cfg.DefaultAgent
+	if _, ok := routers[defaultAgent]; !ok {
+		if defaultAgent != "" {
+			logging.Warnf("DEFAULT_AGENT %q not found (known: %v)", defaultAgent, routerKeys(routers))
+		}
+		defaultAgent = agents[0].ID
+	}
+	channelAgents := commands.NewChannelAgents(defaultAgent)
+	for _, mapping := range []map[string]string{agentChannels, cfg.ChannelAgents} {
+		for ch, agentID := range mapping {
+			if _, ok := routers[agentID]; !ok {
+				logging.Warnf("channel %s mapped to unknown agent %q (known: %v)", ch, agentID, routerKeys(routers))
+				continue
+			}
+			channelAgents.Map(ch, agentID)
+		}
+	}
+	logging.Infof("@mentions routed to agent %q (%d channel overrides)", defaultAgent, channelAgents.Len())
+
 	// Socket Mode — connects outbound to Slack for thread reply events.
 	// Requires SLACK_APP_TOKEN (xapp-...) with connections:write scope.
 	if cfg.SlackAppToken != "" {
 		botUserID, err := slackClient.GetBotUserID()
 		if err != nil {
-			log.Printf("Warning: could not get bot user ID (thread sessions may echo): %v", err)
+			logging.Warnf("could not get bot user ID (thread sessions may echo): %v", err)
 		} else {
-			log.Printf("Bot user ID: %s", botUserID)
+			logging.Infof("Bot user ID: %s", botUserID)
 		}
 
 		socketListener := slack.NewSocketListener(cfg.SlackAppToken, cfg.SlackBotToken, botUserID,
@@ -492,7 +1492,7 @@ func main() {
 				if sess == nil {
 					return // not a tracked thread
 				}
-				log.Printf("[session] thread reply channel=%s thread=%s user=%s text=%q",
+				logging.Infof("[session] thread reply channel=%s thread=%s user=%s text=%q",
 					channelID, threadTS, userID, text)
 				sess.Router.HandleThreadReply(channelID, threadTS, userID, text)
 			},
@@ -502,29 +1502,129 @@ func main() {
 				agentID := strings.TrimPrefix(command, "/")
 				router, ok := routers[agentID]
 				if !ok {
-					log.Printf("[socket-mode] unknown agent for command %q (known: %v)", command, routerKeys(routers))
+					logging.Warnf("[socket-mode] unknown agent for command %q (known: %v)", command, routerKeys(routers))
 					return
 				}
 				router.Handle(channelID, userID, text, responseURL)
 			},
 		)
+		socketListener.SetAppMentionHandler(func(channelID, ts, threadTS, userID, text string) {
+			// Mentions inside a tracked thread already arrive as thread replies.
+			if threadTS != "" && sessions.Lookup(channelID, threadTS) != nil {
+				return
+			}
+			if threadTS == "" {
+				threadTS = ts
+			}
+			routers[channelAgents.Resolve(channelID)].HandleMention(channelID, threadTS, userID, text)
+		})
+
+		home := &commands.AppHome{Agents: agents, Requests: requestLog, Sessions: sessions, Usage: usage}
+		socketListener.SetAppHomeHandler(home.Blocks)
 		go socketListener.Start()
-		log.Printf("Socket Mode enabled — listening for thread replies")
+		logging.Infof("Socket Mode enabled — listening for thread replies")
 	} else {
-		log.Printf("Warning: SLACK_APP_TOKEN not set — thread session follow-ups disabled")
+		logging.Warnf("SLACK_APP_TOKEN not set — thread session follow-ups disabled")
+	}
+
+	// Routers per chat platform, for restoring persisted thread sessions.
+	chatRouters := map[string]map[string]*commands.Router{"slack": routers}
+
+	// Microsoft Teams — the Bot Framework posts activities to /teams/messages.
+	// Each agent gets a copy of its router that replies through Teams.
+	if cfg.TeamsAppID != "" {
+		teamsClient := teams.NewClient(cfg.TeamsAppID, cfg.TeamsAppPassword, cfg.TeamsTenantID, cfg.TeamsServiceURL)
+		teamsRouters := make(map[string]*commands.Router, len(routers))
+		for id, r := range routers {
+			teamsRouters[id] = r.WithChat("teams", teamsClient)
+		}
+		chatRouters["teams"] = teamsRouters
+		http.Handle("/teams/messages", teams.NewHandler(teamsClient, func(channelID, threadTS, userID, text string, mentioned bool) {
+			if sess := sessions.Lookup(channelID, threadTS); sess != nil {
+				logging.Infof("[teams] thread reply channel=%s thread=%s user=%s text=%q", channelID, threadTS, userID, text)
+				sess.Router.HandleThreadReply(channelID, threadTS, userID, text)
+				return
+			}
+			if !mentioned {
+				return // channel message in a thread without a session
+			}
+			// "/<agent> …" picks the agent explicitly, like a Slack slash command.
+			agentID := channelAgents.Resolve(channelID)
+			if cmd, rest, _ := strings.Cut(text, " "); strings.HasPrefix(cmd, "/") {
+				if _, ok := teamsRouters[cmd[1:]]; ok {
+					agentID, text = cmd[1:], rest
+				}
+			}
+			teamsRouters[agentID].HandleMention(channelID, threadTS, userID, text)
+		}))
+		logging.Infof("Microsoft Teams enabled — messaging endpoint at /teams/messages")
+	}
+
+	// Discord — an outbound gateway connection, like Socket Mode. Each agent
+	// gets a slash command and a copy of its router that replies through Discord.
+	if cfg.DiscordBotToken != "" {
+		discordClient := discord.NewClient(cfg.DiscordBotToken)
+		discordRouters := make(map[string]*commands.Router, len(routers))
+		for id, r := range routers {
+			discordRouters[id] = r.WithChat("discord", discordClient)
+		}
+		chatRouters["discord"] = discordRouters
+		gateway := discord.NewGateway(discordClient, cfg.DiscordGuildID, routerKeys(routers),
+			func(channelID, threadTS, userID, text string, mentioned bool) {
+				if sess := sessions.Lookup(channelID, threadTS); sess != nil {
+					logging.Infof("[discord] thread reply channel=%s thread=%s user=%s text=%q", channelID, threadTS, userID, text)
+					sess.Router.HandleThreadReply(channelID, threadTS, userID, text)
+					return
+				}
+				if !mentioned {
+					return
+				}
+				discordRouters[channelAgents.Resolve(channelID)].HandleMention(channelID, threadTS, userID, text)
+			},
+			func(command, channelID, threadTS, userID, text string) {
+				router, ok := discordRouters[command]
+				if !ok {
+					logging.Warnf("[discord] unknown agent for command /%s (known: %v)", command, routerKeys(routers))
+					return
+				}
+				router.HandleMention(channelID, threadTS, userID, text)
+			},
+		)
+		go gateway.Start()
+		logging.Infof("Discord enabled — connecting to the gateway")
+	}
+
+	if cfg.ThreadSessionFile != "" {
+		restored, err := sessions.Restore(cfg.ThreadSessionFile, func(agentID, platform string) *commands.Router {
+			return chatRouters[platform][agentID]
+		})
+		if err != nil {
+			log.Fatalf("failed to restore thread sessions: %v", err)
+		}
+		logging.Infof("Persisting thread sessions to %s (%d restored)", cfg.ThreadSessionFile, restored)
 	}
 
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// Prometheus metrics — unauthenticated like /healthz so in-cluster scrapers can reach it.
+	metrics.NewGaugeFunc("arbetern_thread_sessions_active", "Currently active thread sessions.", func() float64 {
+		return float64(sessions.ActiveCount())
+	})
+	http.Handle("/metrics", metrics.Handler())
+
 	// Agent management UI (embedded static files) — behind IP whitelist if configured.
 	uiContent, _ := fs.Sub(uiFS, "ui")
 	uiCIDRs := parseCIDRs(cfg.UIAllowedCIDRs)
+	clientIPs := clientIPResolver{header: cfg.ClientIPHeader, trusted: parseCIDRs(cfg.TrustedProxyCIDRs)}
 	if len(uiCIDRs) > 0 {
-		log.Printf("UI IP whitelist enabled: %s", cfg.UIAllowedCIDRs)
+		logging.Infof("UI IP whitelist enabled: %s", cfg.UIAllowedCIDRs)
+		if len(clientIPs.trusted) == 0 {
+			logging.Infof("No TRUSTED_PROXY_CIDRS: the UI whitelist checks the connecting address and ignores %s; set TRUSTED_PROXY_CIDRS when running behind a load balancer", cfg.ClientIPHeader)
+		}
 	}
-	uiHandler := ipWhitelist(uiCIDRs, http.StripPrefix("/ui/", http.FileServer(http.FS(uiContent))))
+	uiHandler := ipWhitelist(uiCIDRs, clientIPs, http.StripPrefix("/ui/", http.FileServer(http.FS(uiContent))))
 	http.Handle("/ui/", uiHandler)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
@@ -565,23 +1665,503 @@ func main() {
 		_ = json.NewEncoder(w).Encode(data)
 	})
 
-	// API: thread session stats (observability).
+	// API: prompt reload — POST re-reads prompts.yaml for every agent or,
+	// with ?agent=<id>, for one agent, without a restart.
+	apiMux.HandleFunc("/api/prompts/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ids := routerKeys(routers)
+		if agentID := r.URL.Query().Get("agent"); agentID != "" {
+			if _, ok := agentPrompts[agentID]; !ok {
+				http.Error(w, fmt.Sprintf("unknown agent %q (known: %v)", agentID, ids), http.StatusBadRequest)
+				return
+			}
+			ids = []string{agentID}
+		}
+		author := apiAuthor(r)
+		for _, id := range ids {
+			if err := agentPrompts[id].Reload(author, "reload", ""); err != nil {
+				http.Error(w, fmt.Sprintf("failed to reload prompts for agent %s: %v", id, err), http.StatusInternalServerError)
+				return
+			}
+			logging.Infof("[prompts] reloaded prompts for agent %s (via API, by %s)", id, author)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string][]string{"reloaded": ids})
+	})
+
+	// API: agents sync — POST pulls the agents repository now instead of
+	// waiting for the next poll or push webhook.
+	apiMux.HandleFunc("POST /api/agents/sync", func(w http.ResponseWriter, r *http.Request) {
+		if agentSync == nil {
+			http.Error(w, "agents are not synced from Git (AGENTS_GIT_URL is not set)", http.StatusNotFound)
+			return
+		}
+		head, changed, err := syncAgents(r.Context(), agentSync, agentPrompts, "API, by "+apiAuthor(r))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("sync failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"commit": head, "changed": changed})
+	})
+
+	// API: prompt history — GET lists an agent's prompts.yaml versions,
+	// newest first; POST .../{version}/rollback restores one.
+	apiMux.HandleFunc("GET /api/agents/{id}/prompt-history", func(w http.ResponseWriter, r *http.Request) {
+		ap, ok := agentPrompts[r.PathValue("id")]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown agent %q", r.PathValue("id")), http.StatusNotFound)
+			return
+		}
+		versions := ap.History().List(ap.ID())
+		if versions == nil {
+			versions = []prompts.PromptVersion{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(versions)
+	})
+	apiMux.HandleFunc("POST /api/agents/{id}/prompt-history/{version}/rollback", func(w http.ResponseWriter, r *http.Request) {
+		ap, ok := agentPrompts[r.PathValue("id")]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown agent %q", r.PathValue("id")), http.StatusNotFound)
+			return
+		}
+		n, err := strconv.Atoi(r.PathValue("version"))
+		if err != nil {
+			http.Error(w, "version must be a number", http.StatusBadRequest)
+			return
+		}
+		if _, ok := ap.History().Get(ap.ID(), n); !ok {
+			http.Error(w, fmt.Sprintf("agent %s has no prompt version %d", ap.ID(), n), http.StatusNotFound)
+			return
+		}
+		author := apiAuthor(r)
+		v, err := ap.Rollback(n, author)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("rollback failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		logging.Infof("[prompts] rolled back agent %s to version %d as version %d (by %s)", ap.ID(), n, v.Version, author)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	})
+
+	// API: integrations refresh — POST re-checks every integration's
+	// permissions now instead of waiting for the hourly refresh.
+	apiMux.HandleFunc("/api/integrations/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		refreshIntegrations(cfg, slackClient, ghClient, jiraClient, modelsClient, codeModelsClient)
+		logging.Infof("[integrations] refreshed (via API)")
+		integrationsMu.RLock()
+		data := integrationsCache
+		integrationsMu.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(data)
+	})
+
+	// API: thread session stats (observability). DELETE closes sessions:
+	// one thread with ?channel=<id>&thread=<ts>, one agent's with
+	// ?agent=<id>, or all of them.
 	apiMux.HandleFunc("/api/sessions", func(w http.ResponseWriter, r *http.Request) {
+		closed := 0
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			q := r.URL.Query()
+			if channelID, threadTS := q.Get("channel"), q.Get("thread"); channelID != "" || threadTS != "" {
+				if sessions.Lookup(channelID, threadTS) != nil {
+					sessions.Close(channelID, threadTS, "closed via API")
+					closed = 1
+				}
+			} else {
+				closed = sessions.CloseAll(q.Get("agent"), "closed via API")
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 		active, opened, expired, explicit := sessions.Stats()
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		resp := map[string]interface{}{
 			"active":        active,
 			"total_opened":  opened,
 			"total_expired": expired,
 			"total_closed":  explicit,
 			"session_ttl":   cfg.ThreadSessionTTL.String(),
-		})
+		}
+		if r.Method == http.MethodDelete {
+			resp["closed"] = closed
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	// API: session browser — GET lists active and recently ended thread
+	// sessions; .../{channel}/{thread} returns one with its transcript.
+	apiMux.HandleFunc("GET /api/sessions/threads", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sessions.List())
+	})
+	apiMux.HandleFunc("GET /api/sessions/threads/{channel}/{thread}", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := sessions.Get(r.PathValue("channel"), r.PathValue("thread"))
+		if !ok {
+			http.Error(w, "no active or recent session for that thread", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	})
+
+	// API: model benchmark — replays the recorded corpus against two models and
+	// compares tool rounds, latency, and success. Write tools are stubbed.
+	// POST /api/bench?agent=<id>&model_b=<model>[&model_a=<model>][&limit=N]
+	apiMux.HandleFunc("/api/bench", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if cfg.BenchCorpusFile == "" {
+			http.Error(w, "benchmarking disabled: set BENCH_CORPUS_FILE", http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		router, ok := routers[q.Get("agent")]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown agent %q (known: %v)", q.Get("agent"), routerKeys(routers)), http.StatusBadRequest)
+			return
+		}
+		modelA, modelB := q.Get("model_a"), q.Get("model_b")
+		if modelA == "" {
+			modelA = cfg.GeneralModel
+		}
+		if modelB == "" {
+			http.Error(w, "model_b is required", http.StatusBadRequest)
+			return
+		}
+		limit := 20
+		if l := q.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid limit parameter", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		cases, err := commands.LoadBenchCorpus(cfg.BenchCorpusFile, q.Get("agent"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logging.Infof("[bench] agent=%s comparing %s vs %s on %d case(s)", q.Get("agent"), modelA, modelB, len(cases))
+		report := router.Bench(r.Context(), cases, newModelsClient(cfg, azureCred, modelA), newModelsClient(cfg, azureCred, modelB))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
 	})
 
-	http.Handle("/api/", ipWhitelist(uiCIDRs, apiMux))
+	// API: scheduled agent runs and when each will next fire.
+	apiMux.HandleFunc("/api/schedules", func(w http.ResponseWriter, r *http.Request) {
+		type scheduleInfo struct {
+			*scheduler.Job
+			Timezone string    `json:"timezone"`
+			NextRun  time.Time `json:"next_run"`
+		}
+		now := time.Now()
+		out := []scheduleInfo{}
+		for _, j := range sched.Jobs() {
+			out = append(out, scheduleInfo{Job: j, Timezone: j.Location.String(), NextRun: j.Next(now)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	})
+
+	// API: LLM usage and estimated cost for a month (default: current, UTC).
+	apiMux.HandleFunc("/api/usage", func(w http.ResponseWriter, r *http.Request) {
+		month := r.URL.Query().Get("month")
+		if month == "" {
+			month = time.Now().UTC().Format("2006-01")
+		} else if _, err := time.Parse("2006-01", month); err != nil {
+			http.Error(w, "invalid month parameter (expected YYYY-MM)", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(usage.Report(month))
+	})
+
+	// API: change ledger — write-type tool executions, filterable by user and age.
+	apiMux.HandleFunc("/api/changes", func(w http.ResponseWriter, r *http.Request) {
+		days := 7
+		if d := r.URL.Query().Get("days"); d != "" {
+			n, err := strconv.Atoi(d)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid days parameter", http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+		records := ledger.List(r.URL.Query().Get("user"), time.Now().AddDate(0, 0, -days))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(records)
+	})
+
+	// API: audit log — tool invocations filtered by agent, user, channel,
+	// tool, class and outcome, newest first. ?format=csv or jsonl exports them.
+	apiMux.HandleFunc("/api/audit", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := commands.AuditFilter{
+			AgentID:   q.Get("agent"),
+			UserID:    q.Get("user"),
+			ChannelID: q.Get("channel"),
+			Tool:      q.Get("tool"),
+			Outcome:   q.Get("outcome"),
+			Class:     q.Get("class"),
+			Limit:     1000,
+		}
+		days := 7
+		if d := q.Get("days"); d != "" {
+			n, err := strconv.Atoi(d)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid days parameter", http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+		filter.Since = time.Now().AddDate(0, 0, -days)
+		for _, p := range []struct {
+			name string
+			dst  *time.Time
+		}{{"since", &filter.Since}, {"until", &filter.Until}} {
+			if v := q.Get(p.name); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid %s parameter: must be RFC 3339", p.name), http.StatusBadRequest)
+					return
+				}
+				*p.dst = t
+			}
+		}
+		if l := q.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid limit parameter", http.StatusBadRequest)
+				return
+			}
+			filter.Limit = n
+		}
+		entries := auditLog.Query(filter)
+		switch q.Get("format") {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(entries)
+		case "jsonl":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+			enc := json.NewEncoder(w)
+			for _, e := range entries {
+				_ = enc.Encode(e)
+			}
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+			_ = commands.WriteAuditCSV(w, entries)
+		default:
+			http.Error(w, "invalid format parameter: must be json, jsonl or csv", http.StatusBadRequest)
+		}
+	})
+
+	// API: linked accounts — list, link (PUT a JSON identity) or unlink
+	// (DELETE ?slack_user_id=) Slack users' GitHub logins and Jira accounts.
+	apiMux.HandleFunc("/api/identities", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(identities.List())
+		case http.MethodPut, http.MethodPost:
+			var id commands.Identity
+			if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+				http.Error(w, fmt.Sprintf("invalid identity: %v", err), http.StatusBadRequest)
+				return
+			}
+			id.UpdatedBy = "api"
+			linked, err := identities.Link(id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logging.Infof("[identities] linked %s: github=%q jira=%q", linked.SlackUserID, linked.GitHubLogin, linked.JiraAccountID)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(linked)
+		case http.MethodDelete:
+			userID := r.URL.Query().Get("slack_user_id")
+			removed, err := identities.Unlink(userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !removed {
+				http.Error(w, fmt.Sprintf("no linked accounts for %q", userID), http.StatusNotFound)
+				return
+			}
+			logging.Infof("[identities] unlinked %s", userID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// API: daily spend caps — GET today's limits, usage and exemptions; PUT
+	// exempts ?user=<id> or ?channel=<id> for the rest of the UTC day and
+	// DELETE restores its caps.
+	apiMux.HandleFunc("/api/spend-caps", func(w http.ResponseWriter, r *http.Request) {
+		if spendCaps == nil {
+			http.Error(w, "spend caps disabled: set USER_DAILY_TOKEN_LIMIT or another daily limit", http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost, http.MethodDelete:
+			q := r.URL.Query()
+			kind, id := "user", q.Get("user")
+			if id == "" {
+				kind, id = "channel", q.Get("channel")
+			}
+			if id == "" {
+				http.Error(w, "missing ?user=<id> or ?channel=<id>", http.StatusBadRequest)
+				return
+			}
+			on := r.Method != http.MethodDelete
+			if err := spendCaps.Exempt(kind, id, on); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logging.Infof("[spend-caps] %s %s exempt=%t by %s (via API)", kind, id, on, apiAuthor(r))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(spendCaps.Status())
+	})
+
+	// API: read-only mode — GET the global and per-agent flags, PUT
+	// {"enabled": true|false} to switch it globally or, with ?agent=<id>,
+	// for one agent. Runtime changes last until the next restart.
+	apiMux.HandleFunc("/api/read-only", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			agentID := r.URL.Query().Get("agent")
+			if _, ok := routers[agentID]; agentID != "" && !ok {
+				http.Error(w, fmt.Sprintf("unknown agent %q (known: %v)", agentID, routerKeys(routers)), http.StatusBadRequest)
+				return
+			}
+			var body struct {
+				Enabled *bool `json:"enabled"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+				http.Error(w, `invalid body: expected {"enabled": true|false}`, http.StatusBadRequest)
+				return
+			}
+			readOnly.Set(agentID, *body.Enabled)
+			scope := "all agents"
+			if agentID != "" {
+				scope = "agent " + agentID
+			}
+			logging.Infof("[read-only] %s: enabled=%t (via API)", scope, *body.Enabled)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(readOnly.Status())
+	})
+
+	// API: maintenance mode — GET the state and queued requests, PUT
+	// {"enabled": true, "message": "...", "queue": true} to start it and
+	// {"enabled": false} to end it and replay the queue.
+	apiMux.HandleFunc("/api/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var body struct {
+				Enabled *bool  `json:"enabled"`
+				Message string `json:"message"`
+				Queue   bool   `json:"queue"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+				http.Error(w, `invalid body: expected {"enabled": true|false, "message": "...", "queue": true|false}`, http.StatusBadRequest)
+				return
+			}
+			if *body.Enabled {
+				maintenance.Start(body.Message, body.Queue)
+				logging.Infof("[maintenance] started (queue=%t): %s", body.Queue, body.Message)
+			} else {
+				n := maintenance.End()
+				logging.Infof("[maintenance] ended, replaying %d queued request(s)", n)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(maintenance.Status())
+	})
+
+	http.Handle("/api/", ipWhitelist(uiCIDRs, clientIPs, apiMux))
+
+	// Inbound trigger for external systems (Alertmanager, Sentry, ...). Not
+	// behind the UI allowlist — authenticated with TRIGGER_TOKEN instead.
+	if cfg.TriggerToken != "" {
+		http.HandleFunc("POST /api/agents/{id}/trigger", triggerHandler(cfg.TriggerToken, routers, triggerChannels))
+		logging.Infof("Trigger endpoint enabled at /api/agents/{id}/trigger (%d agent(s) with a trigger channel)", len(triggerChannels))
+	}
+
+	// GitHub webhook for proactive CI triage and PR reviews. Authenticated
+	// by the X-Hub-Signature-256 HMAC instead of the UI allowlist.
+	// Jira ↔ GitHub sync for bot-created tickets, driven by both webhooks.
+	var issueSync *commands.IssueSync
+	if cfg.JiraGitHubSync {
+		if jiraClient == nil || cfg.GitHubWebhookSecret == "" || cfg.JiraWebhookSecret == "" {
+			log.Fatalf("JIRA_GITHUB_SYNC requires Jira credentials, GITHUB_WEBHOOK_SECRET and JIRA_WEBHOOK_SECRET")
+		}
+		issueSync = commands.NewIssueSync(ghClient, jiraClient)
+		logging.Infof("Jira ↔ GitHub sync enabled")
+	}
+	var onPush func(*github.WebhookEvent)
+	if agentSync != nil {
+		onPush = func(ev *github.WebhookEvent) {
+			if !agentSync.Matches(ev.Repo, ev.Branch) {
+				return
+			}
+			if _, _, err := syncAgents(context.Background(), agentSync, agentPrompts, "push by "+ev.Sender); err != nil {
+				logging.Errorf("[agentsync] %v", err)
+			}
+		}
+		if cfg.AgentsGitInterval > 0 {
+			go func() {
+				for range time.Tick(cfg.AgentsGitInterval) {
+					if _, _, err := syncAgents(context.Background(), agentSync, agentPrompts, "poll"); err != nil {
+						logging.Errorf("[agentsync] %v", err)
+					}
+				}
+			}()
+			logging.Infof("Polling %s for agent changes every %s", agentSync, cfg.AgentsGitInterval)
+		}
+	}
+	if cfg.GitHubWebhookSecret != "" {
+		http.HandleFunc("POST /github/webhook", githubWebhookHandler(cfg.GitHubWebhookSecret, githubRoutes, issueSync, onPush))
+		logging.Infof("GitHub webhook enabled at /github/webhook (%d agent(s) with github_events)", len(githubRoutes))
+	}
+	if cfg.JiraWebhookSecret != "" {
+		http.HandleFunc("POST /jira/webhook", jiraWebhookHandler(cfg.JiraWebhookSecret, jiraRoutes, issueSync))
+		logging.Infof("Jira webhook enabled at /jira/webhook (%d jira_events rule(s))", len(jiraRoutes))
+	}
 
-	log.Printf("arbetern server starting on :%s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, nil); err != nil {
+	if err := serve(cfg); err != nil {
 		log.Fatalf("server failed: %v", err)
 	}
 }
@@ -0,0 +1,244 @@
+// Package metrics implements a minimal Prometheus-compatible metrics registry
+// (counters, histograms, and gauge callbacks) exposed in the text exposition
+// format at /metrics.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Application metrics. Labels are listed in the order values must be passed.
+var (
+	SlashCommands = NewCounter("arbetern_slash_commands_total", "Slash commands handled, by agent.", "agent")
+	ThreadReplies = NewCounter("arbetern_thread_replies_total", "Thread follow-up messages handled, by agent.", "agent")
+	Mentions      = NewCounter("arbetern_mentions_total", "@mentions handled, by agent.", "agent")
+	ScheduledRuns = NewCounter("arbetern_scheduled_runs_total", "Scheduled prompt runs started, by agent.", "agent")
+	Triggers      = NewCounter("arbetern_triggers_total", "Webhook-triggered runs started, by agent and source.", "agent", "source")
+	GitHubEvents  = NewCounter("arbetern_github_events_total", "GitHub webhook events handled, by agent and event.", "agent", "event")
+	JiraEvents    = NewCounter("arbetern_jira_events_total", "Jira webhook events handled, by agent and event.", "agent", "event")
+	ToolCalls     = NewCounter("arbetern_tool_calls_total", "LLM tool calls executed, by agent and tool.", "agent", "tool")
+	ToolErrors    = NewCounter("arbetern_tool_errors_total", "LLM tool calls that returned an error, by agent and tool.", "agent", "tool")
+	LLMLatency    = NewHistogram("arbetern_llm_request_duration_seconds", "Latency of LLM completion requests, by model.",
+		[]float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120}, "model")
+	LLMErrors            = NewCounter("arbetern_llm_errors_total", "Failed LLM completion requests, by model.", "model")
+	APIErrors            = NewCounter("arbetern_api_errors_total", "Failed outbound API requests (transport errors and HTTP status >= 400), by integration.", "integration")
+	FabricatedReferences = NewCounter("arbetern_fabricated_references_total", "GitHub links and Jira keys in answers that did not exist, by agent and kind (github or jira).", "agent", "kind")
+	Redactions           = NewCounter("arbetern_redactions_total", "Secrets masked in LLM inputs and chat output, by agent and source.", "agent", "source")
+	LowConfidenceAnswers = NewCounter("arbetern_low_confidence_answers_total", "Answers posted with the low-confidence disclaimer, by agent and reason.", "agent", "reason")
+)
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+type collector interface {
+	write(sb *strings.Builder)
+}
+
+func register(c collector) {
+	registryMu.Lock()
+	registry = append(registry, c)
+	registryMu.Unlock()
+}
+
+// Counter is a monotonically increasing value partitioned by label values.
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // key: label values joined with \xff
+}
+
+// NewCounter creates and registers a counter.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	register(c)
+	return c
+}
+
+// Inc increments the counter for the given label values by one.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by v.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(sb *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(sb, "%s%s %g\n", c.name, formatLabels(c.labels, key, ""), c.values[key])
+	}
+}
+
+// Histogram tracks the distribution of observed values in cumulative buckets.
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, non-cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates and registers a histogram with the given upper bounds.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+	register(h)
+	return h
+}
+
+// Observe records a single value for the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *Histogram) write(sb *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, fmt.Sprintf("%g", b)), cumulative)
+		}
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "+Inf"), s.count)
+		fmt.Fprintf(sb, "%s_sum%s %g\n", h.name, formatLabels(h.labels, key, ""), s.sum)
+		fmt.Fprintf(sb, "%s_count%s %d\n", h.name, formatLabels(h.labels, key, ""), s.count)
+	}
+}
+
+// gaugeFunc reports a value computed at scrape time.
+type gaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every scrape.
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(&gaugeFunc{name: name, help: help, fn: fn})
+}
+
+func (g *gaugeFunc) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", g.name, g.help, g.name, g.name, g.fn())
+}
+
+// Handler serves all registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sb strings.Builder
+		registryMu.Lock()
+		for _, c := range registry {
+			c.write(&sb)
+		}
+		registryMu.Unlock()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(sb.String()))
+	})
+}
+
+// Transport wraps an http.RoundTripper and counts failed requests in
+// APIErrors under the given integration label.
+type Transport struct {
+	Integration string
+	Base        http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 400 {
+		APIErrors.Inc(t.Integration)
+	}
+	return resp, err
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelEscaper escapes a label value as the Prometheus text format expects:
+// only backslash, double quote and newline.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders {name="value",...} for a series key. When le is
+// non-empty it is appended as the histogram bucket label.
+func formatLabels(names []string, key, le string) string {
+	var pairs []string
+	if len(names) > 0 {
+		values := strings.Split(key, "\xff")
+		for i, n := range names {
+			v := ""
+			if i < len(values) {
+				v = values[i]
+			}
+			pairs = append(pairs, n+`="`+labelEscaper.Replace(v)+`"`)
+		}
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+labelEscaper.Replace(le)+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
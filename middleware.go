@@ -1,23 +1,24 @@
 package main
 
 import (
-	"log"
 	"net"
 	"net/http"
 	"strings"
+
+	"github.com/justmike1/ovad/logging"
 )
 
 // ipWhitelist returns middleware that restricts access to the given parsed CIDR list.
 // If cidrs is empty, the next handler is returned as-is (whitelist disabled).
-// The middleware checks X-Forwarded-For first (for requests behind a load balancer),
-// then falls back to the direct remote address.
-func ipWhitelist(cidrs []*net.IPNet, next http.Handler) http.Handler {
+// The client address comes from ips, which only honors forwarding headers
+// set by trusted proxies.
+func ipWhitelist(cidrs []*net.IPNet, ips clientIPResolver, next http.Handler) http.Handler {
 	if len(cidrs) == 0 {
 		return next // No restriction configured.
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		clientIP := clientIP(r)
+		clientIP := ips.clientIP(r)
 		ip := net.ParseIP(clientIP)
 		if ip == nil {
 			http.Error(w, "Forbidden", http.StatusForbidden)
@@ -31,7 +32,7 @@ func ipWhitelist(cidrs []*net.IPNet, next http.Handler) http.Handler {
 			}
 		}
 
-		log.Printf("UI access denied for IP %s", clientIP)
+		logging.Infof("UI access denied for IP %s", clientIP)
 		http.Error(w, "Forbidden", http.StatusForbidden)
 	})
 }
@@ -56,7 +57,7 @@ func parseCIDRs(raw string) []*net.IPNet {
 		}
 		_, cidr, err := net.ParseCIDR(s)
 		if err != nil {
-			log.Printf("WARNING: ignoring invalid CIDR %q: %v", s, err)
+			logging.Warnf("ignoring invalid CIDR %q: %v", s, err)
 			continue
 		}
 		nets = append(nets, cidr)
@@ -64,18 +65,81 @@ func parseCIDRs(raw string) []*net.IPNet {
 	return nets
 }
 
-func clientIP(r *http.Request) string {
-	// X-Forwarded-For can contain multiple IPs; the first is the original client.
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		if idx := strings.Index(xff, ","); idx != -1 {
-			return strings.TrimSpace(xff[:idx])
+// clientIPResolver determines the client address of a request. The
+// forwarding header (X-Forwarded-For by default) is only honored when the
+// connection comes from a trusted proxy; otherwise anyone could spoof their
+// address by sending it.
+type clientIPResolver struct {
+	header  string
+	trusted []*net.IPNet
+}
+
+func (c clientIPResolver) clientIP(r *http.Request) string {
+	// Direct connection IP.
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !containsIP(c.trusted, peer) {
+		return peer
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values(c.header) {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
 		}
-		return strings.TrimSpace(xff)
 	}
-	// Fall back to direct connection IP.
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
+	if len(hops) == 0 {
+		return peer
+	}
+	// Single-address headers (X-Real-IP, CF-Connecting-IP, ...) are set by
+	// the proxy itself.
+	if !strings.EqualFold(c.header, "X-Forwarded-For") {
+		return hops[0]
+	}
+	// Each proxy appends the address it received the request from, so the
+	// client is the rightmost hop that isn't one of our proxies; anything to
+	// its left was sent by the client and can't be trusted.
+	for i := len(hops) - 1; i > 0; i-- {
+		if !containsIP(c.trusted, hops[i]) {
+			return hops[i]
+		}
+	}
+	return hops[0]
+}
+
+// containsIP reports whether ip is inside one of cidrs.
+func containsIP(cidrs []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if cidr.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorHeader names the operator making an API change; arbetern admin sets
+// it to $USER.
+const AuthorHeader = "X-Author"
+
+// apiAuthor identifies who made an API request for change history: the
+// common name of the mTLS client certificate, else the AuthorHeader, else
+// "api".
+func apiAuthor(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		if cn := r.TLS.PeerCertificates[0].Subject.CommonName; cn != "" {
+			return cn
+		}
+	}
+	if a := strings.TrimSpace(r.Header.Get(AuthorHeader)); a != "" {
+		return a
 	}
-	return host
+	return "api"
 }
@@ -0,0 +1,506 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+const (
+	baseURL       = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+
+	// maxRichText is the longest text Notion accepts in one rich text object.
+	maxRichText = 2000
+	// maxChildren is the most blocks one append or create call accepts.
+	maxChildren = 100
+)
+
+// Client provides access to the Notion API with an internal integration
+// token. The integration only sees pages and databases shared with it.
+type Client struct {
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a Notion API client.
+func NewClient(token string) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "notion"},
+		},
+	}
+}
+
+// SearchResult is a page or database found by Search.
+type SearchResult struct {
+	ID         string
+	Object     string // "page" or "database"
+	Title      string
+	URL        string
+	LastEdited time.Time
+}
+
+// Search finds pages and databases whose title matches query. kind limits
+// the results to "page" or "database" (both when empty).
+func (c *Client) Search(ctx context.Context, query, kind string, limit int) ([]SearchResult, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	body := map[string]any{"query": query, "page_size": limit}
+	if kind != "" {
+		body["filter"] = map[string]string{"property": "object", "value": kind}
+	}
+	var resp struct {
+		Results []object `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/search", body, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]SearchResult, 0, len(resp.Results))
+	for _, o := range resp.Results {
+		out = append(out, SearchResult{ID: o.ID, Object: o.Object, Title: o.title(), URL: o.URL, LastEdited: o.LastEditedTime})
+	}
+	return out, nil
+}
+
+// Page is a page's title and plain-text content.
+type Page struct {
+	ID      string
+	Title   string
+	URL     string
+	Content string // markdown-ish rendering of the top-level blocks
+}
+
+// GetPage returns a page with the text of its top-level blocks, truncated
+// to maxChars.
+func (c *Client) GetPage(ctx context.Context, id string, maxChars int) (*Page, error) {
+	var o object
+	if err := c.do(ctx, http.MethodGet, "/pages/"+id, nil, &o); err != nil {
+		return nil, err
+	}
+	page := &Page{ID: o.ID, Title: o.title(), URL: o.URL}
+	var sb strings.Builder
+	cursor := ""
+	for sb.Len() < maxChars {
+		path := "/blocks/" + id + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + cursor
+		}
+		var resp struct {
+			Results    []block `json:"results"`
+			HasMore    bool    `json:"has_more"`
+			NextCursor string  `json:"next_cursor"`
+		}
+		if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, b := range resp.Results {
+			sb.WriteString(b.text())
+		}
+		if !resp.HasMore {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	page.Content = sb.String()
+	if len(page.Content) > maxChars {
+		page.Content = page.Content[:maxChars] + "\n… (truncated)"
+	}
+	return page, nil
+}
+
+// AppendMarkdown appends markdown (headings, lists, code blocks, dividers,
+// paragraphs) to the end of a page.
+func (c *Client) AppendMarkdown(ctx context.Context, pageID, markdown string) error {
+	blocks := MarkdownToBlocks(markdown)
+	for start := 0; start < len(blocks); start += maxChildren {
+		batch := blocks[start:min(start+maxChildren, len(blocks))]
+		if err := c.do(ctx, http.MethodPatch, "/blocks/"+pageID+"/children", map[string]any{"children": batch}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateDatabaseEntry adds a page titled title to a database, with markdown
+// as its content and extra properties set by name. Only text, select,
+// multi-select, date, URL, number and checkbox properties can be set.
+func (c *Client) CreateDatabaseEntry(ctx context.Context, databaseID, title, markdown string, props map[string]string) (*SearchResult, error) {
+	var db struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/databases/"+databaseID, nil, &db); err != nil {
+		return nil, err
+	}
+	properties := make(map[string]any)
+	for name, p := range db.Properties {
+		if p.Type == "title" {
+			properties[name] = map[string]any{"title": richText(title)}
+		}
+	}
+	for name, value := range props {
+		p, ok := db.Properties[name]
+		if !ok {
+			return nil, fmt.Errorf("database has no property %q", name)
+		}
+		v, err := propertyValue(p.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", name, err)
+		}
+		properties[name] = v
+	}
+
+	blocks := MarkdownToBlocks(markdown)
+	body := map[string]any{
+		"parent":     map[string]string{"database_id": databaseID},
+		"properties": properties,
+		"children":   blocks[:min(len(blocks), maxChildren)],
+	}
+	var o object
+	if err := c.do(ctx, http.MethodPost, "/pages", body, &o); err != nil {
+		return nil, err
+	}
+	if len(blocks) > maxChildren {
+		for start := maxChildren; start < len(blocks); start += maxChildren {
+			batch := blocks[start:min(start+maxChildren, len(blocks))]
+			if err := c.do(ctx, http.MethodPatch, "/blocks/"+o.ID+"/children", map[string]any{"children": batch}, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &SearchResult{ID: o.ID, Object: o.Object, Title: title, URL: o.URL, LastEdited: o.LastEditedTime}, nil
+}
+
+// propertyValue builds the value of a database property of type typ.
+func propertyValue(typ, value string) (any, error) {
+	switch typ {
+	case "rich_text":
+		return map[string]any{"rich_text": richText(value)}, nil
+	case "select":
+		return map[string]any{"select": map[string]string{"name": value}}, nil
+	case "status":
+		return map[string]any{"status": map[string]string{"name": value}}, nil
+	case "multi_select":
+		var opts []map[string]string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				opts = append(opts, map[string]string{"name": v})
+			}
+		}
+		return map[string]any{"multi_select": opts}, nil
+	case "date":
+		return map[string]any{"date": map[string]string{"start": value}}, nil
+	case "url":
+		return map[string]any{"url": value}, nil
+	case "number":
+		var n float64
+		if _, err := fmt.Sscanf(value, "%g", &n); err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return map[string]any{"number": n}, nil
+	case "checkbox":
+		return map[string]any{"checkbox": value == "true" || value == "yes"}, nil
+	default:
+		return nil, fmt.Errorf("setting %s properties is not supported", typ)
+	}
+}
+
+// idPattern matches the 32-hex-digit ID at the end of a Notion URL.
+var idPattern = regexp.MustCompile(`([0-9a-f]{8})-?([0-9a-f]{4})-?([0-9a-f]{4})-?([0-9a-f]{4})-?([0-9a-f]{12})`)
+
+// ParseID extracts a page or database ID from an ID or a Notion URL.
+func ParseID(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+	matches := idPattern.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%q is not a Notion page ID or URL", s)
+	}
+	m := matches[len(matches)-1]
+	return strings.Join(m[1:], "-"), nil
+}
+
+// --------------------------------------------------------------------------
+// API types
+// --------------------------------------------------------------------------
+
+type richTextItem struct {
+	PlainText string `json:"plain_text"`
+}
+
+func plain(items []richTextItem) string {
+	var sb strings.Builder
+	for _, t := range items {
+		sb.WriteString(t.PlainText)
+	}
+	return sb.String()
+}
+
+// object is a page or database.
+type object struct {
+	ID             string                     `json:"id"`
+	Object         string                     `json:"object"`
+	URL            string                     `json:"url"`
+	LastEditedTime time.Time                  `json:"last_edited_time"`
+	Title          []richTextItem             `json:"title"` // databases
+	Properties     map[string]json.RawMessage `json:"properties"`
+}
+
+func (o object) title() string {
+	if len(o.Title) > 0 {
+		return plain(o.Title)
+	}
+	for _, raw := range o.Properties {
+		var p struct {
+			Type  string         `json:"type"`
+			Title []richTextItem `json:"title"`
+		}
+		if json.Unmarshal(raw, &p) == nil && p.Type == "title" {
+			return plain(p.Title)
+		}
+	}
+	return "(untitled)"
+}
+
+// block is a content block; only the rich text of each type is decoded.
+type block struct {
+	Type string
+	raw  json.RawMessage
+}
+
+func (b *block) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	b.Type = head.Type
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	b.raw = all[head.Type]
+	return nil
+}
+
+// text renders the block as a line of markdown-ish text.
+func (b block) text() string {
+	var body struct {
+		RichText []richTextItem `json:"rich_text"`
+		Checked  bool           `json:"checked"`
+		Title    string         `json:"title"`
+	}
+	_ = json.Unmarshal(b.raw, &body)
+	t := plain(body.RichText)
+	switch b.Type {
+	case "heading_1":
+		return "# " + t + "\n"
+	case "heading_2":
+		return "## " + t + "\n"
+	case "heading_3":
+		return "### " + t + "\n"
+	case "bulleted_list_item", "toggle":
+		return "- " + t + "\n"
+	case "numbered_list_item":
+		return "1. " + t + "\n"
+	case "to_do":
+		if body.Checked {
+			return "- [x] " + t + "\n"
+		}
+		return "- [ ] " + t + "\n"
+	case "code":
+		return "```\n" + t + "\n```\n"
+	case "quote", "callout":
+		return "> " + t + "\n"
+	case "divider":
+		return "---\n"
+	case "child_page", "child_database":
+		return "[" + b.Type + ": " + body.Title + "]\n"
+	default:
+		if t == "" {
+			return "\n"
+		}
+		return t + "\n"
+	}
+}
+
+// --------------------------------------------------------------------------
+// Markdown conversion
+// --------------------------------------------------------------------------
+
+// richText splits text into rich text objects under Notion's length limit.
+func richText(text string) []map[string]any {
+	var out []map[string]any
+	for len(text) > 0 || out == nil {
+		n := min(len(text), maxRichText)
+		out = append(out, map[string]any{"type": "text", "text": map[string]any{"content": text[:n]}})
+		text = text[n:]
+	}
+	return out
+}
+
+// inlineMarkup matches markdown links, **bold** and `code` spans.
+var inlineMarkup = regexp.MustCompile("\\[([^\\]]+)\\]\\((https?://[^)\\s]+)\\)|\\*\\*([^*]+)\\*\\*|`([^`]+)`")
+
+// inlineRichText converts a line of markdown to rich text, keeping links,
+// bold and inline code; other markup stays as written.
+func inlineRichText(text string) []map[string]any {
+	var out []map[string]any
+	add := func(s string, annotate func(map[string]any)) {
+		for _, rt := range richText(s) {
+			if annotate != nil {
+				annotate(rt)
+			}
+			out = append(out, rt)
+		}
+	}
+	last := 0
+	for _, m := range inlineMarkup.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > last {
+			add(text[last:m[0]], nil)
+		}
+		switch {
+		case m[2] >= 0:
+			url := text[m[4]:m[5]]
+			add(text[m[2]:m[3]], func(rt map[string]any) { rt["text"].(map[string]any)["link"] = map[string]string{"url": url} })
+		case m[6] >= 0:
+			add(text[m[6]:m[7]], func(rt map[string]any) { rt["annotations"] = map[string]bool{"bold": true} })
+		default:
+			add(text[m[8]:m[9]], func(rt map[string]any) { rt["annotations"] = map[string]bool{"code": true} })
+		}
+		last = m[1]
+	}
+	if last < len(text) || out == nil {
+		add(text[last:], nil)
+	}
+	return out
+}
+
+func textBlock(typ, text string) map[string]any {
+	rt := inlineRichText(text)
+	if typ == "code" {
+		rt = richText(text)
+	}
+	return map[string]any{"object": "block", "type": typ, typ: map[string]any{"rich_text": rt}}
+}
+
+// MarkdownToBlocks converts the markdown the model writes to Notion blocks:
+// headings, bulleted and numbered lists, to-dos, fenced code, quotes,
+// dividers and paragraphs, with links, bold and inline code.
+func MarkdownToBlocks(md string) []map[string]any {
+	var blocks []map[string]any
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "```"):
+			lang := strings.TrimSpace(strings.TrimPrefix(line, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b := textBlock("code", strings.Join(code, "\n"))
+			if lang == "" {
+				lang = "plain text"
+			}
+			b["code"].(map[string]any)["language"] = lang
+			blocks = append(blocks, b)
+		case line == "---" || line == "***":
+			blocks = append(blocks, map[string]any{"object": "block", "type": "divider", "divider": map[string]any{}})
+		case strings.HasPrefix(line, "### "):
+			blocks = append(blocks, textBlock("heading_3", line[4:]))
+		case strings.HasPrefix(line, "## "):
+			blocks = append(blocks, textBlock("heading_2", line[3:]))
+		case strings.HasPrefix(line, "# "):
+			blocks = append(blocks, textBlock("heading_1", line[2:]))
+		case strings.HasPrefix(line, "- [ ] ") || strings.HasPrefix(line, "- [x] "):
+			b := textBlock("to_do", line[6:])
+			b["to_do"].(map[string]any)["checked"] = line[3] == 'x'
+			blocks = append(blocks, b)
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			blocks = append(blocks, textBlock("bulleted_list_item", line[2:]))
+		case numberedItem.MatchString(line):
+			blocks = append(blocks, textBlock("numbered_list_item", numberedItem.ReplaceAllString(line, "")))
+		case strings.HasPrefix(line, "> "):
+			blocks = append(blocks, textBlock("quote", line[2:]))
+		default:
+			blocks = append(blocks, textBlock("paragraph", line))
+		}
+	}
+	return blocks
+}
+
+var numberedItem = regexp.MustCompile(`^\d+[.)]\s+`)
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			if apiErr.Code == "object_not_found" {
+				apiErr.Message += " (share the page or database with the integration)"
+			}
+			return fmt.Errorf("notion API error (HTTP %d): %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("notion API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
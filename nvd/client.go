@@ -7,8 +7,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
+
+	ovadmetrics "github.com/justmike1/ovad/metrics"
 )
 
 const (
@@ -19,6 +22,7 @@ const (
 type Client struct {
 	apiKey     string
 	httpClient *http.Client
+	kev        kevCatalog
 }
 
 // NewClient creates an NVD API client. apiKey may be empty (unauthenticated
@@ -27,7 +31,8 @@ func NewClient(apiKey string) *Client {
 	return &Client{
 		apiKey: apiKey,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: &ovadmetrics.Transport{Integration: "nvd"},
 		},
 	}
 }
@@ -70,6 +75,104 @@ func (c *Client) SearchCVE(ctx context.Context, keyword string, resultsPerPage i
 	return items, resp.TotalResults, nil
 }
 
+// nvdTimeFormat is the timestamp format of NVD date range parameters.
+const nvdTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// maxModifiedRange is the longest lastModStartDate–lastModEndDate window
+// NVD accepts in one query.
+const maxModifiedRange = 120 * 24 * time.Hour
+
+// ModifiedBetween returns every CVE published or modified in [start, end),
+// paging through the results. Windows longer than NVD's 120-day limit are
+// clamped to the most recent 120 days.
+func (c *Client) ModifiedBetween(ctx context.Context, start, end time.Time) ([]CVEItem, error) {
+	if end.Sub(start) > maxModifiedRange {
+		start = end.Add(-maxModifiedRange)
+	}
+	var items []CVEItem
+	for index := 0; ; {
+		params := url.Values{
+			"lastModStartDate": {start.UTC().Format(nvdTimeFormat)},
+			"lastModEndDate":   {end.UTC().Format(nvdTimeFormat)},
+			"resultsPerPage":   {"2000"},
+			"startIndex":       {fmt.Sprintf("%d", index)},
+		}
+		var resp cveResponse
+		if err := c.get(ctx, params, &resp); err != nil {
+			return nil, err
+		}
+		for _, v := range resp.Vulnerabilities {
+			items = append(items, v.CVE)
+		}
+		index += len(resp.Vulnerabilities)
+		if len(resp.Vulnerabilities) == 0 || index >= resp.TotalResults {
+			break
+		}
+	}
+	return items, nil
+}
+
+// Description returns the English description of the CVE.
+func (cve *CVEItem) Description() string {
+	for _, d := range cve.Descriptions {
+		if d.Lang == "en" {
+			return d.Value
+		}
+	}
+	return ""
+}
+
+// Severity returns the highest-version CVSS base score and severity of the
+// CVE, or 0 and "" when it has not been scored yet.
+func (cve *CVEItem) Severity() (float64, string) {
+	m := cve.Metrics
+	if m == nil {
+		return 0, ""
+	}
+	for _, entries := range [][]cvssEntry{m.CvssV40, m.CvssV31, m.CvssV30} {
+		if len(entries) > 0 {
+			return entries[0].CvssData.BaseScore, entries[0].CvssData.BaseSeverity
+		}
+	}
+	if len(m.CvssV2) > 0 {
+		score := m.CvssV2[0].CvssData.BaseScore
+		switch {
+		case score >= 7:
+			return score, "HIGH"
+		case score >= 4:
+			return score, "MEDIUM"
+		default:
+			return score, "LOW"
+		}
+	}
+	return 0, ""
+}
+
+// Mentions reports whether the CVE's description (as a whole word) or an
+// affected product's CPE vendor or product names keyword, ignoring case.
+func (cve *CVEItem) Mentions(keyword string) bool {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		return false
+	}
+	word := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(keyword) + `\b`)
+	if word.MatchString(cve.Description()) {
+		return true
+	}
+	for _, cfg := range cve.Configurations {
+		for _, node := range cfg.Nodes {
+			for _, match := range node.CpeMatch {
+				// cpe:2.3:part:vendor:product:version:...
+				parts := strings.Split(strings.ToLower(match.Criteria), ":")
+				if len(parts) > 4 && (parts[3] == keyword || parts[4] == keyword) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // --------------------------------------------------------------------------
 // Formatting helpers
 // --------------------------------------------------------------------------
@@ -111,6 +214,26 @@ func FormatCVE(cve *CVEItem) string {
 		}
 	}
 
+	// Exploitation signals: how likely and whether it is exploited in the wild.
+	if cve.EPSS != nil {
+		fmt.Fprintf(&sb, "• *EPSS:* %.1f%% probability of exploitation in the next 30 days (%s percentile)\n", cve.EPSS.Score*100, ordinalPercentile(cve.EPSS.Percentile))
+	}
+	if cve.KEV != nil {
+		fmt.Fprintf(&sb, "• *CISA KEV:* :warning: known exploited — added %s", cve.KEV.DateAdded)
+		if cve.KEV.DueDate != "" {
+			fmt.Fprintf(&sb, ", federal remediation due %s", cve.KEV.DueDate)
+		}
+		if strings.EqualFold(cve.KEV.Ransomware, "Known") {
+			sb.WriteString(", used in ransomware campaigns")
+		}
+		sb.WriteString("\n")
+		if cve.KEV.RequiredAction != "" {
+			fmt.Fprintf(&sb, "  – Required action: %s\n", cve.KEV.RequiredAction)
+		}
+	} else if cve.kevChecked {
+		sb.WriteString("• *CISA KEV:* not listed (no known exploitation reported to CISA)\n")
+	}
+
 	// Weaknesses (CWE IDs).
 	if len(cve.Weaknesses) > 0 {
 		var cwes []string
@@ -182,6 +305,23 @@ func FormatCVE(cve *CVEItem) string {
 	return sb.String()
 }
 
+// ordinalPercentile renders a 0–1 percentile as e.g. "97th".
+func ordinalPercentile(p float64) string {
+	n := int(p * 100)
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
 func nvdOr(val, fallback string) string {
 	if val == "" {
 		return fallback
@@ -260,6 +400,11 @@ type CVEItem struct {
 	Weaknesses     []weakness      `json:"weaknesses"`
 	Configurations []configuration `json:"configurations"`
 	References     []reference     `json:"references"`
+
+	// Exploitation signals, filled in by Client.Enrich.
+	EPSS       *EPSS     `json:"-"`
+	KEV        *KEVEntry `json:"-"` // nil when not listed (or not checked)
+	kevChecked bool
 }
 
 type langString struct {
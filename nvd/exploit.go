@@ -0,0 +1,167 @@
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	epssURL = "https://api.first.org/data/v1/epss"
+	kevURL  = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+
+	// kevTTL is how long the KEV catalog is cached. CISA updates it a few
+	// times a week.
+	kevTTL = 6 * time.Hour
+)
+
+// EPSS is the FIRST Exploit Prediction Scoring System score of a CVE: the
+// probability it is exploited in the wild in the next 30 days.
+type EPSS struct {
+	Score      float64 // 0–1
+	Percentile float64 // 0–1, relative to all scored CVEs
+	Date       string  // day the score was computed
+}
+
+// KEVEntry is a CVE's entry in the CISA Known Exploited Vulnerabilities
+// catalog.
+type KEVEntry struct {
+	CVEID             string `json:"cveID"`
+	VendorProject     string `json:"vendorProject"`
+	Product           string `json:"product"`
+	VulnerabilityName string `json:"vulnerabilityName"`
+	DateAdded         string `json:"dateAdded"`
+	RequiredAction    string `json:"requiredAction"`
+	DueDate           string `json:"dueDate"`
+	Ransomware        string `json:"knownRansomwareCampaignUse"` // "Known" or "Unknown"
+}
+
+// kevCatalog caches the KEV catalog by CVE ID.
+type kevCatalog struct {
+	mu      sync.Mutex
+	byCVE   map[string]KEVEntry
+	fetched time.Time
+}
+
+// EPSSScores returns the EPSS scores of the given CVEs. CVEs without a score
+// (e.g. too new) are absent from the map.
+func (c *Client) EPSSScores(ctx context.Context, cveIDs []string) (map[string]EPSS, error) {
+	scores := make(map[string]EPSS, len(cveIDs))
+	// The API accepts comma-separated IDs up to a 2000-character query.
+	for start := 0; start < len(cveIDs); start += 100 {
+		batch := cveIDs[start:min(start+100, len(cveIDs))]
+		var resp struct {
+			Data []struct {
+				CVE        string `json:"cve"`
+				EPSS       string `json:"epss"`
+				Percentile string `json:"percentile"`
+				Date       string `json:"date"`
+			} `json:"data"`
+		}
+		if err := c.getJSON(ctx, epssURL+"?"+url.Values{"cve": {strings.Join(batch, ",")}}.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("EPSS lookup failed: %w", err)
+		}
+		for _, d := range resp.Data {
+			score, _ := strconv.ParseFloat(d.EPSS, 64)
+			pct, _ := strconv.ParseFloat(d.Percentile, 64)
+			scores[d.CVE] = EPSS{Score: score, Percentile: pct, Date: d.Date}
+		}
+	}
+	return scores, nil
+}
+
+// KnownExploited returns the CISA KEV entry of a CVE, if it is listed. The
+// catalog is downloaded once and refreshed every few hours.
+func (c *Client) KnownExploited(ctx context.Context, cveID string) (*KEVEntry, error) {
+	c.kev.mu.Lock()
+	defer c.kev.mu.Unlock()
+	if c.kev.byCVE == nil || time.Since(c.kev.fetched) > kevTTL {
+		var catalog struct {
+			Vulnerabilities []KEVEntry `json:"vulnerabilities"`
+		}
+		if err := c.getJSON(ctx, kevURL, &catalog); err != nil {
+			if c.kev.byCVE == nil {
+				return nil, fmt.Errorf("KEV catalog download failed: %w", err)
+			}
+			// Keep serving the stale catalog rather than failing lookups.
+		} else {
+			c.kev.byCVE = make(map[string]KEVEntry, len(catalog.Vulnerabilities))
+			for _, v := range catalog.Vulnerabilities {
+				c.kev.byCVE[v.CVEID] = v
+			}
+			c.kev.fetched = time.Now()
+		}
+	}
+	if e, ok := c.kev.byCVE[cveID]; ok {
+		return &e, nil
+	}
+	return nil, nil
+}
+
+// Enrich fills in the EPSS score and KEV status of items. Enrichment is
+// best effort: a failing source leaves its fields empty and is reported in
+// the returned error, but never blocks the other source.
+func (c *Client) Enrich(ctx context.Context, items []CVEItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]string, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	var errs []string
+	if scores, err := c.EPSSScores(ctx, ids); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		for i := range items {
+			if s, ok := scores[items[i].ID]; ok {
+				items[i].EPSS = &s
+			}
+		}
+	}
+	for i := range items {
+		kev, err := c.KnownExploited(ctx, items[i].ID)
+		if err != nil {
+			errs = append(errs, err.Error())
+			break
+		}
+		items[i].KEV = kev
+		items[i].kevChecked = true
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// getJSON fetches a JSON document from a non-NVD source.
+func (c *Client) getJSON(ctx context.Context, u string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(body), 300))
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package observability queries time series from the team's monitoring
+// system (Datadog or a Prometheus-compatible API such as Grafana's data
+// source proxy) and summarizes them as numbers the model can reason about.
+package observability
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backend is a metrics query API.
+type Backend interface {
+	// Name is the backend's display name, e.g. "Datadog".
+	Name() string
+	// Query evaluates a query in the backend's language (Datadog metric
+	// query or PromQL) between start and end at roughly step resolution.
+	Query(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error)
+}
+
+// Series is one time series of a query result.
+type Series struct {
+	Name   string // metric and tags/labels, e.g. "http_requests{service=api}"
+	Points []Point
+}
+
+// Point is a sample of a series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Summary holds the statistics of a series.
+type Summary struct {
+	Count              int
+	Min, Max, Avg, P95 float64
+	First, Last        float64
+	MaxAt              time.Time
+	// Change is the relative change of the last quarter's average over the
+	// first quarter's, e.g. 0.5 for +50%; NaN when undefined.
+	Change float64
+}
+
+// Summarize computes the statistics of s, skipping NaN samples.
+func (s Series) Summarize() Summary {
+	var vals []float64
+	var sum Summary
+	sum.Change = math.NaN()
+	for _, p := range s.Points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		if len(vals) == 0 || p.Value > sum.Max {
+			sum.Max, sum.MaxAt = p.Value, p.Time
+		}
+		if len(vals) == 0 || p.Value < sum.Min {
+			sum.Min = p.Value
+		}
+		vals = append(vals, p.Value)
+	}
+	sum.Count = len(vals)
+	if sum.Count == 0 {
+		return sum
+	}
+	sum.First, sum.Last = vals[0], vals[len(vals)-1]
+	total := 0.0
+	for _, v := range vals {
+		total += v
+	}
+	sum.Avg = total / float64(len(vals))
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	sum.P95 = sorted[min(len(sorted)-1, int(math.Ceil(0.95*float64(len(sorted))))-1)]
+	if q := len(vals) / 4; q > 0 {
+		head, tail := mean(vals[:q]), mean(vals[len(vals)-q:])
+		if head != 0 {
+			sum.Change = (tail - head) / math.Abs(head)
+		}
+	}
+	return sum
+}
+
+func mean(vals []float64) float64 {
+	total := 0.0
+	for _, v := range vals {
+		total += v
+	}
+	return total / float64(len(vals))
+}
+
+// sparkTicks are the levels of a sparkline, lowest first.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the series as width characters, each the average of its
+// bucket of samples.
+func (s Series) Sparkline(width int) string {
+	var vals []float64
+	for _, p := range s.Points {
+		if !math.IsNaN(p.Value) && !math.IsInf(p.Value, 0) {
+			vals = append(vals, p.Value)
+		}
+	}
+	if len(vals) == 0 || width <= 0 {
+		return ""
+	}
+	width = min(width, len(vals))
+	buckets := make([]float64, width)
+	for i := range buckets {
+		lo, hi := i*len(vals)/width, (i+1)*len(vals)/width
+		buckets[i] = mean(vals[lo:max(hi, lo+1)])
+	}
+	lo, hi := buckets[0], buckets[0]
+	for _, b := range buckets {
+		lo, hi = math.Min(lo, b), math.Max(hi, b)
+	}
+	var sb strings.Builder
+	for _, b := range buckets {
+		i := 0
+		if hi > lo {
+			i = int((b - lo) / (hi - lo) * float64(len(sparkTicks)-1))
+		}
+		sb.WriteRune(sparkTicks[i])
+	}
+	return sb.String()
+}
+
+// FormatValue renders a number compactly: 1234567 → "1.23M", 0.00123 → "0.00123".
+func FormatValue(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case math.IsNaN(v):
+		return "n/a"
+	case abs >= 1e9:
+		return fmt.Sprintf("%.3gB", v/1e9)
+	case abs >= 1e6:
+		return fmt.Sprintf("%.3gM", v/1e6)
+	case abs >= 1e4:
+		return fmt.Sprintf("%.3gk", v/1e3)
+	default:
+		return fmt.Sprintf("%.4g", v)
+	}
+}
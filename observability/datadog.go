@@ -0,0 +1,110 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// Datadog queries the Datadog metrics API (v1 timeseries query).
+type Datadog struct {
+	baseURL    string
+	apiKey     string
+	appKey     string
+	httpClient *http.Client
+}
+
+// NewDatadog creates a Datadog backend. site is the Datadog site, e.g.
+// "datadoghq.com" or "datadoghq.eu".
+func NewDatadog(site, apiKey, appKey string) *Datadog {
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	return &Datadog{
+		baseURL: "https://api." + site,
+		apiKey:  apiKey,
+		appKey:  appKey,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "datadog"},
+		},
+	}
+}
+
+func (d *Datadog) Name() string { return "Datadog" }
+
+// Query runs a Datadog metric query such as
+// "avg:trace.http.request.duration{service:api} by {resource_name}". The
+// step is chosen by Datadog from the time range; append .rollup() to the
+// query to control it.
+func (d *Datadog) Query(ctx context.Context, query string, start, end time.Time, _ time.Duration) ([]Series, error) {
+	q := url.Values{
+		"query": {query},
+		"from":  {fmt.Sprint(start.Unix())},
+		"to":    {fmt.Sprint(end.Unix())},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/api/v1/query?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("DD-API-KEY", d.apiKey)
+	req.Header.Set("DD-APPLICATION-KEY", d.appKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var result struct {
+		Status string   `json:"status"`
+		Error  string   `json:"error"`
+		Errors []string `json:"errors"`
+		Series []struct {
+			Expression string        `json:"expression"`
+			Scope      string        `json:"scope"`
+			Metric     string        `json:"metric"`
+			Pointlist  [][2]*float64 `json:"pointlist"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Status == "error" {
+		msg := result.Error
+		if msg == "" && len(result.Errors) > 0 {
+			msg = result.Errors[0]
+		}
+		if msg == "" {
+			msg = string(body)
+		}
+		return nil, fmt.Errorf("datadog API error (HTTP %d): %s", resp.StatusCode, msg)
+	}
+
+	out := make([]Series, 0, len(result.Series))
+	for _, s := range result.Series {
+		name := s.Metric
+		if s.Scope != "" && s.Scope != "*" {
+			name += "{" + s.Scope + "}"
+		}
+		series := Series{Name: name}
+		for _, p := range s.Pointlist {
+			if p[0] == nil || p[1] == nil {
+				continue
+			}
+			series.Points = append(series.Points, Point{Time: time.UnixMilli(int64(*p[0])), Value: *p[1]})
+		}
+		out = append(out, series)
+	}
+	return out, nil
+}
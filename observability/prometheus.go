@@ -0,0 +1,141 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// maxPoints caps the samples per series requested from Prometheus, which
+// rejects ranges above 11000 points.
+const maxPoints = 1000
+
+// Prometheus queries a Prometheus-compatible HTTP API: Prometheus itself,
+// Thanos, Mimir, VictoriaMetrics, or Grafana's data source proxy
+// (https://grafana.example.com/api/datasources/proxy/uid/<uid>).
+type Prometheus struct {
+	baseURL    string
+	token      string // bearer token, e.g. a Grafana service account token
+	httpClient *http.Client
+}
+
+// NewPrometheus creates a Prometheus backend for baseURL (without /api/v1).
+func NewPrometheus(baseURL, token string) *Prometheus {
+	return &Prometheus{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "prometheus"},
+		},
+	}
+}
+
+func (p *Prometheus) Name() string { return "Prometheus" }
+
+// Query runs a PromQL range query.
+func (p *Prometheus) Query(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	if minStep := end.Sub(start) / maxPoints; step < minStep {
+		step = minStep
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+	form := url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatInt(int64(step.Seconds()), 10)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/v1/query_range", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var result struct {
+		Status    string `json:"status"`
+		ErrorType string `json:"errorType"`
+		Error     string `json:"error"`
+		Data      struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Values [][2]any          `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		if len(body) > 300 {
+			body = body[:300]
+		}
+		return nil, fmt.Errorf("prometheus API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("prometheus %s error: %s", result.ErrorType, result.Error)
+	}
+
+	out := make([]Series, 0, len(result.Data.Result))
+	for _, r := range result.Data.Result {
+		series := Series{Name: seriesName(r.Metric)}
+		for _, v := range r.Values {
+			ts, ok := v[0].(float64)
+			raw, ok2 := v[1].(string)
+			if !ok || !ok2 {
+				continue
+			}
+			val, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				continue
+			}
+			series.Points = append(series.Points, Point{Time: time.Unix(int64(ts), 0), Value: val})
+		}
+		out = append(out, series)
+	}
+	return out, nil
+}
+
+// seriesName renders labels as name{k="v",...} with sorted keys.
+func seriesName(labels map[string]string) string {
+	name := labels["__name__"]
+	var keys []string
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	if len(parts) == 0 {
+		if name == "" {
+			return "{}"
+		}
+		return name
+	}
+	return name + "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,151 @@
+package oncall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// DefaultOpsgenieURL is the Opsgenie API of the US instance; EU accounts use
+// https://api.eu.opsgenie.com.
+const DefaultOpsgenieURL = "https://api.opsgenie.com"
+
+// maxOpsgenieSchedules bounds how many schedules one lookup queries.
+const maxOpsgenieSchedules = 25
+
+// Opsgenie is the Provider backed by the Opsgenie Schedule API. It needs an
+// API key with read access (an integration or global API key).
+type Opsgenie struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie provider. An empty baseURL uses
+// DefaultOpsgenieURL.
+func NewOpsgenie(baseURL, apiKey string) *Opsgenie {
+	if baseURL == "" {
+		baseURL = DefaultOpsgenieURL
+	}
+	return &Opsgenie{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "opsgenie"},
+		},
+	}
+}
+
+func (o *Opsgenie) Name() string { return "Opsgenie" }
+
+// OnCall matches query against the schedule and owner team names, then asks
+// each matching enabled schedule for its current on-call users.
+func (o *Opsgenie) OnCall(ctx context.Context, query string) ([]Shift, error) {
+	var schedules struct {
+		Data []struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			Enabled   bool   `json:"enabled"`
+			OwnerTeam *struct {
+				Name string `json:"name"`
+			} `json:"ownerTeam"`
+		} `json:"data"`
+	}
+	if err := o.get(ctx, "/v2/schedules", &schedules); err != nil {
+		return nil, err
+	}
+
+	var out []Shift
+	queried := 0
+	names := make(map[string]string) // username → full name
+	for _, s := range schedules.Data {
+		team := ""
+		if s.OwnerTeam != nil {
+			team = s.OwnerTeam.Name
+		}
+		if !s.Enabled || !matches(query, s.Name, team) {
+			continue
+		}
+		if queried == maxOpsgenieSchedules {
+			break
+		}
+		queried++
+
+		var resp struct {
+			Data struct {
+				OnCallParticipants []struct {
+					Name string `json:"name"`
+					Type string `json:"type"`
+				} `json:"onCallParticipants"`
+			} `json:"data"`
+		}
+		q := url.Values{"scheduleIdentifierType": {"id"}, "flat": {"false"}}
+		if err := o.get(ctx, "/v2/schedules/"+url.PathEscape(s.ID)+"/on-calls?"+q.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		for _, p := range resp.Data.OnCallParticipants {
+			if p.Type != "user" {
+				continue
+			}
+			if _, ok := names[p.Name]; !ok {
+				names[p.Name] = o.fullName(ctx, p.Name)
+			}
+			out = append(out, Shift{Schedule: s.Name, Team: team, Level: 1, Name: names[p.Name], Email: p.Name})
+		}
+	}
+	return out, nil
+}
+
+// fullName returns a user's full name, or the username (their email) when
+// it can't be looked up.
+func (o *Opsgenie) fullName(ctx context.Context, username string) string {
+	var resp struct {
+		Data struct {
+			FullName string `json:"fullName"`
+		} `json:"data"`
+	}
+	if err := o.get(ctx, "/v2/users/"+url.PathEscape(username), &resp); err != nil || resp.Data.FullName == "" {
+		return username
+	}
+	return resp.Data.FullName
+}
+
+func (o *Opsgenie) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("opsgenie API error (HTTP %d): %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("opsgenie API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
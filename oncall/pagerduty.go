@@ -0,0 +1,47 @@
+package oncall
+
+import (
+	"context"
+
+	"github.com/justmike1/ovad/pagerduty"
+)
+
+// PagerDuty is the Provider backed by PagerDuty on-calls, reusing the
+// incident tools' client.
+type PagerDuty struct {
+	client *pagerduty.Client
+}
+
+// NewPagerDuty creates a PagerDuty on-call provider.
+func NewPagerDuty(client *pagerduty.Client) *PagerDuty {
+	return &PagerDuty{client: client}
+}
+
+func (p *PagerDuty) Name() string { return "PagerDuty" }
+
+// OnCall matches query against the schedule and escalation policy names.
+func (p *PagerDuty) OnCall(ctx context.Context, query string) ([]Shift, error) {
+	oncalls, err := p.client.OnCalls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Shift
+	for _, o := range oncalls {
+		if !matches(query, o.Schedule, o.EscalationPolicy) {
+			continue
+		}
+		schedule := o.Schedule
+		if schedule == "" {
+			schedule = o.EscalationPolicy
+		}
+		out = append(out, Shift{
+			Schedule: schedule,
+			Team:     o.EscalationPolicy,
+			Level:    o.Level,
+			Name:     o.User,
+			Email:    o.Email,
+			Until:    o.End,
+		})
+	}
+	return out, nil
+}
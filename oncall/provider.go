@@ -0,0 +1,44 @@
+// Package oncall looks up who is on call right now in the team's paging
+// tool (Opsgenie or PagerDuty), so requests like "page the on-call for
+// platform" can be resolved to a person.
+package oncall
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Provider is an on-call schedule backend.
+type Provider interface {
+	// Name is the backend's display name, e.g. "Opsgenie".
+	Name() string
+	// OnCall returns who is on call now on the schedules, teams or
+	// escalation policies whose name contains query (case-insensitive);
+	// an empty query returns all of them.
+	OnCall(ctx context.Context, query string) ([]Shift, error)
+}
+
+// Shift is a person currently on call.
+type Shift struct {
+	Schedule string // schedule, or escalation policy when the person is targeted directly
+	Team     string // owning team or escalation policy, when known
+	Level    int    // escalation level (1 = first responder); 0 when unknown
+	Name     string
+	Email    string
+	Until    time.Time // zero when unknown or open-ended
+}
+
+// matches reports whether any of names contains query, ignoring case.
+func matches(query string, names ...string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	for _, n := range names {
+		if n != "" && strings.Contains(strings.ToLower(n), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package osv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ovadmetrics "github.com/justmike1/ovad/metrics"
+)
+
+const (
+	baseURL = "https://api.osv.dev/v1"
+
+	// maxBatch is the most queries OSV accepts in one querybatch call.
+	maxBatch = 1000
+	// maxDetails caps the vulnerability records fetched per scan.
+	maxDetails = 100
+)
+
+// Client talks to the OSV.dev vulnerability API. It needs no credentials.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates an OSV API client.
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &ovadmetrics.Transport{Integration: "osv"},
+		},
+	}
+}
+
+// Finding is a known vulnerability affecting one dependency.
+type Finding struct {
+	Package  Package
+	ID       string   // OSV ID, e.g. "GHSA-xxxx-xxxx-xxxx" or "GO-2024-1234"
+	Aliases  []string // e.g. CVE IDs
+	Summary  string
+	Severity string   // "CRITICAL", "HIGH", "MODERATE", "LOW" or "UNKNOWN"
+	Fixed    []string // versions that fix it, when known
+}
+
+// severityRank orders severities from most to least severe.
+var severityRank = map[string]int{"CRITICAL": 0, "HIGH": 1, "MODERATE": 2, "MEDIUM": 2, "LOW": 3}
+
+// rank returns the sort rank of a severity (lower is more severe).
+func rank(severity string) int {
+	if r, ok := severityRank[strings.ToUpper(severity)]; ok {
+		return r
+	}
+	return len(severityRank)
+}
+
+// Scan looks up known vulnerabilities of pkgs and returns the findings,
+// most severe first. Details are fetched for at most maxDetails distinct
+// vulnerabilities; the rest are reported with severity UNKNOWN.
+func (c *Client) Scan(ctx context.Context, pkgs []Package) ([]Finding, error) {
+	type hit struct {
+		pkg Package
+		id  string
+	}
+	var hits []hit
+	for start := 0; start < len(pkgs); start += maxBatch {
+		batch := pkgs[start:min(start+maxBatch, len(pkgs))]
+		req := struct {
+			Queries []query `json:"queries"`
+		}{}
+		for _, p := range batch {
+			req.Queries = append(req.Queries, query{Version: p.Version, Package: queryPackage{Name: p.Name, Ecosystem: p.Ecosystem}})
+		}
+		var resp struct {
+			Results []struct {
+				Vulns []struct {
+					ID string `json:"id"`
+				} `json:"vulns"`
+			} `json:"results"`
+		}
+		if err := c.post(ctx, baseURL+"/querybatch", req, &resp); err != nil {
+			return nil, err
+		}
+		for i, r := range resp.Results {
+			if i >= len(batch) {
+				break
+			}
+			for _, v := range r.Vulns {
+				hits = append(hits, hit{pkg: batch[i], id: v.ID})
+			}
+		}
+	}
+
+	// Fetch each distinct vulnerability once, a few at a time.
+	details := make(map[string]*vuln)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for _, h := range hits {
+		mu.Lock()
+		_, seen := details[h.id]
+		if seen || len(details) >= maxDetails {
+			mu.Unlock()
+			continue
+		}
+		details[h.id] = nil
+		mu.Unlock()
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var v vuln
+			if err := c.get(ctx, baseURL+"/vulns/"+id, &v); err != nil {
+				return
+			}
+			mu.Lock()
+			details[id] = &v
+			mu.Unlock()
+		}(h.id)
+	}
+	wg.Wait()
+
+	findings := make([]Finding, 0, len(hits))
+	for _, h := range hits {
+		f := Finding{Package: h.pkg, ID: h.id, Severity: "UNKNOWN"}
+		if v := details[h.id]; v != nil {
+			f.Aliases = v.Aliases
+			f.Summary = v.Summary
+			if f.Summary == "" {
+				f.Summary = firstLine(v.Details)
+			}
+			if s := strings.ToUpper(v.DatabaseSpecific.Severity); s != "" {
+				f.Severity = s
+			}
+			f.Fixed = v.fixedFor(h.pkg)
+		}
+		findings = append(findings, f)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank(findings[i].Severity) < rank(findings[j].Severity)
+	})
+	return findings, nil
+}
+
+// --------------------------------------------------------------------------
+// OSV API types
+// --------------------------------------------------------------------------
+
+type query struct {
+	Version string       `json:"version,omitempty"`
+	Package queryPackage `json:"package"`
+}
+
+type queryPackage struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+type vuln struct {
+	ID               string   `json:"id"`
+	Summary          string   `json:"summary"`
+	Details          string   `json:"details"`
+	Aliases          []string `json:"aliases"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Affected []struct {
+		Package queryPackage `json:"package"`
+		Ranges  []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// fixedFor returns the fixed versions listed for pkg.
+func (v *vuln) fixedFor(pkg Package) []string {
+	var fixed []string
+	for _, a := range v.Affected {
+		if !strings.EqualFold(a.Package.Name, pkg.Name) {
+			continue
+		}
+		for _, r := range a.Ranges {
+			for _, e := range r.Events {
+				if e.Fixed != "" {
+					fixed = append(fixed, e.Fixed)
+				}
+			}
+		}
+	}
+	return fixed
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 200 {
+		s = s[:200] + "…"
+	}
+	return s
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) post(ctx context.Context, u string, body, target interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode OSV request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create OSV request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, target)
+}
+
+func (c *Client) get(ctx context.Context, u string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create OSV request: %w", err)
+	}
+	return c.do(req, target)
+}
+
+func (c *Client) do(req *http.Request, target interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("OSV API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read OSV response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body) > 300 {
+			body = body[:300]
+		}
+		return fmt.Errorf("OSV API returned %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse OSV response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package osv
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Package is a dependency declared in a manifest.
+type Package struct {
+	Name      string
+	Version   string // exact version queried; a range's lower bound for package.json
+	Ecosystem string // OSV ecosystem: "Go", "npm", "PyPI"
+	Manifest  string // manifest path it came from
+	Dev       bool   // devDependency (package.json only)
+}
+
+// Manifests are the file names ParseManifest understands.
+var Manifests = []string{"go.mod", "package.json", "requirements.txt"}
+
+// ParseManifest parses a dependency manifest by its file name. Dependencies
+// without a resolvable version are returned in skipped.
+func ParseManifest(file, content string) (pkgs []Package, skipped []string, err error) {
+	switch path.Base(file) {
+	case "go.mod":
+		pkgs = parseGoMod(content)
+	case "package.json":
+		pkgs, skipped, err = parsePackageJSON(content)
+	case "requirements.txt":
+		pkgs, skipped = parseRequirements(content)
+	default:
+		return nil, nil, fmt.Errorf("unsupported manifest %s (supported: %s)", file, strings.Join(Manifests, ", "))
+	}
+	for i := range pkgs {
+		pkgs[i].Manifest = file
+	}
+	return pkgs, skipped, err
+}
+
+// parseGoMod returns the modules required by a go.mod, direct and indirect.
+func parseGoMod(content string) []Package {
+	var pkgs []Package
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+			continue
+		case inBlock && fields[0] == ")":
+			inBlock = false
+			continue
+		case fields[0] == "require" && len(fields) >= 3:
+			fields = fields[1:]
+		case !inBlock:
+			continue
+		}
+		if len(fields) >= 2 {
+			// OSV's Go ranges use semver without the "v" prefix.
+			version := strings.TrimSuffix(strings.TrimPrefix(fields[1], "v"), "+incompatible")
+			pkgs = append(pkgs, Package{Name: fields[0], Version: version, Ecosystem: "Go"})
+		}
+	}
+	return pkgs
+}
+
+// npmVersion matches the first concrete version in an npm range such as
+// "^1.2.3", "~1.2", ">=2.0.0 <3".
+var npmVersion = regexp.MustCompile(`\d+(\.\d+){0,2}([-+][0-9A-Za-z.-]+)?`)
+
+// parsePackageJSON returns a package.json's dependencies and
+// devDependencies. Ranges are queried at their lower bound, which is what a
+// fresh install without a lockfile may resolve to in the worst case.
+func parsePackageJSON(content string) ([]Package, []string, error) {
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	var pkgs []Package
+	var skipped []string
+	add := func(deps map[string]string, dev bool) {
+		for name, spec := range deps {
+			v := npmVersion.FindString(spec)
+			if v == "" || strings.ContainsAny(spec, ":/") {
+				// "*", "latest", git URLs, file: and workspace: links.
+				skipped = append(skipped, fmt.Sprintf("%s@%s", name, spec))
+				continue
+			}
+			// "^1.2" → "1.2.0": OSV compares full semver.
+			for strings.Count(strings.SplitN(v, "-", 2)[0], ".") < 2 {
+				core, rest, _ := strings.Cut(v, "-")
+				v = core + ".0"
+				if rest != "" {
+					v += "-" + rest
+				}
+			}
+			pkgs = append(pkgs, Package{Name: name, Version: v, Ecosystem: "npm", Dev: dev})
+		}
+	}
+	add(manifest.Dependencies, false)
+	add(manifest.DevDependencies, true)
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	sort.Strings(skipped)
+	return pkgs, skipped, nil
+}
+
+// pinnedRequirement matches "name==version" with optional extras and
+// environment markers.
+var pinnedRequirement = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(\[[^\]]*\])?\s*===?\s*([^\s;#]+)`)
+
+// parseRequirements returns the pinned (==) requirements of a
+// requirements.txt. Unpinned ones are skipped since their version depends on
+// install time.
+func parseRequirements(content string) ([]Package, []string) {
+	var pkgs []Package
+	var skipped []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue // options such as -r other.txt or --index-url
+		}
+		if m := pinnedRequirement.FindStringSubmatch(line); m != nil {
+			pkgs = append(pkgs, Package{Name: m[1], Version: m[3], Ecosystem: "PyPI"})
+			continue
+		}
+		skipped = append(skipped, line)
+	}
+	return pkgs, skipped
+}
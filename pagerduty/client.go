@@ -0,0 +1,387 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+const baseURL = "https://api.pagerduty.com"
+
+// Client provides access to the PagerDuty REST API v2.
+type Client struct {
+	token      string
+	fromEmail  string // PagerDuty user that incident updates are attributed to
+	httpClient *http.Client
+}
+
+// NewClient creates a PagerDuty client. fromEmail is required by PagerDuty
+// for updates made with an account-level API token; it must belong to a
+// PagerDuty user.
+func NewClient(token, fromEmail string) *Client {
+	return &Client{
+		token:     token,
+		fromEmail: fromEmail,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "pagerduty"},
+		},
+	}
+}
+
+// Incident is a PagerDuty incident.
+type Incident struct {
+	ID               string
+	Number           int
+	Title            string
+	Status           string // "triggered", "acknowledged" or "resolved"
+	Urgency          string // "high" or "low"
+	Priority         string
+	Service          string
+	EscalationPolicy string
+	Assignees        []string
+	CreatedAt        time.Time
+	URL              string
+	Description      string
+}
+
+// LogEntry is one event of an incident's timeline.
+type LogEntry struct {
+	Type      string // e.g. "trigger_log_entry", "acknowledge_log_entry"
+	CreatedAt time.Time
+	Summary   string
+	Agent     string // who or what caused it
+}
+
+// Note is a note added to an incident.
+type Note struct {
+	CreatedAt time.Time
+	User      string
+	Content   string
+}
+
+// ListOptions filters ListIncidents.
+type ListOptions struct {
+	Statuses []string // default: triggered and acknowledged
+	Urgency  string   // "high" or "low"; empty = both
+	Service  string   // service name (substring match) or ID
+	Since    time.Time
+	Limit    int
+}
+
+// ListIncidents returns incidents, newest first. Service names are matched
+// on the client side since the API filters by service ID only.
+func (c *Client) ListIncidents(ctx context.Context, opts ListOptions) ([]Incident, error) {
+	if len(opts.Statuses) == 0 {
+		opts.Statuses = []string{"triggered", "acknowledged"}
+	}
+	if opts.Limit <= 0 || opts.Limit > 100 {
+		opts.Limit = 25
+	}
+	q := url.Values{"sort_by": {"created_at:desc"}, "limit": {"100"}}
+	for _, s := range opts.Statuses {
+		q.Add("statuses[]", s)
+	}
+	if opts.Urgency != "" {
+		q.Add("urgencies[]", opts.Urgency)
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	} else if slices.Contains(opts.Statuses, "resolved") {
+		// Without since, PagerDuty only looks back 30 days; keep it to a week.
+		q.Set("since", time.Now().Add(-7*24*time.Hour).UTC().Format(time.RFC3339))
+	}
+
+	var out []Incident
+	for offset := 0; len(out) < opts.Limit; offset += 100 {
+		q.Set("offset", fmt.Sprint(offset))
+		var resp struct {
+			Incidents []incident `json:"incidents"`
+			More      bool       `json:"more"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/incidents?"+q.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, in := range resp.Incidents {
+			if opts.Service != "" && in.Service.ID != opts.Service && !strings.Contains(strings.ToLower(in.Service.Summary), strings.ToLower(opts.Service)) {
+				continue
+			}
+			out = append(out, in.toIncident())
+			if len(out) == opts.Limit {
+				break
+			}
+		}
+		if !resp.More {
+			break
+		}
+	}
+	return out, nil
+}
+
+// GetIncident returns one incident by ID.
+func (c *Client) GetIncident(ctx context.Context, id string) (*Incident, error) {
+	var resp struct {
+		Incident incident `json:"incident"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/incidents/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	in := resp.Incident.toIncident()
+	return &in, nil
+}
+
+// Timeline returns the overview log entries of an incident, oldest first.
+func (c *Client) Timeline(ctx context.Context, id string) ([]LogEntry, error) {
+	var resp struct {
+		LogEntries []struct {
+			Type      string    `json:"type"`
+			CreatedAt time.Time `json:"created_at"`
+			Summary   string    `json:"summary"`
+			Agent     *ref      `json:"agent"`
+		} `json:"log_entries"`
+	}
+	q := url.Values{"is_overview": {"true"}, "limit": {"100"}}
+	if err := c.do(ctx, http.MethodGet, "/incidents/"+url.PathEscape(id)+"/log_entries?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]LogEntry, 0, len(resp.LogEntries))
+	for i := len(resp.LogEntries) - 1; i >= 0; i-- {
+		e := resp.LogEntries[i]
+		entry := LogEntry{Type: e.Type, CreatedAt: e.CreatedAt, Summary: e.Summary}
+		if e.Agent != nil {
+			entry.Agent = e.Agent.Summary
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// Notes returns the notes on an incident.
+func (c *Client) Notes(ctx context.Context, id string) ([]Note, error) {
+	var resp struct {
+		Notes []struct {
+			CreatedAt time.Time `json:"created_at"`
+			Content   string    `json:"content"`
+			User      ref       `json:"user"`
+		} `json:"notes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/incidents/"+url.PathEscape(id)+"/notes", nil, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Note, 0, len(resp.Notes))
+	for _, n := range resp.Notes {
+		out = append(out, Note{CreatedAt: n.CreatedAt, User: n.User.Summary, Content: n.Content})
+	}
+	return out, nil
+}
+
+// SetStatus acknowledges or resolves an incident.
+func (c *Client) SetStatus(ctx context.Context, id, status string) (*Incident, error) {
+	body := map[string]any{"incident": map[string]string{"type": "incident_reference", "status": status}}
+	var resp struct {
+		Incident incident `json:"incident"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/incidents/"+url.PathEscape(id), body, &resp); err != nil {
+		return nil, err
+	}
+	in := resp.Incident.toIncident()
+	return &in, nil
+}
+
+// AddNote adds a note to an incident's timeline.
+func (c *Client) AddNote(ctx context.Context, id, content string) error {
+	body := map[string]any{"note": map[string]string{"content": content}}
+	return c.do(ctx, http.MethodPost, "/incidents/"+url.PathEscape(id)+"/notes", body, nil)
+}
+
+// OnCall is a user's current on-call shift on one level of an escalation
+// policy.
+type OnCall struct {
+	User             string
+	Email            string
+	EscalationPolicy string
+	Level            int
+	Schedule         string    // empty when the level targets the user directly
+	End              time.Time // zero when the user is always on call at this level
+}
+
+// OnCalls returns who is on call right now, one entry per escalation
+// policy, level and user.
+func (c *Client) OnCalls(ctx context.Context) ([]OnCall, error) {
+	q := url.Values{"include[]": {"users"}, "earliest": {"true"}, "limit": {"100"}}
+	var out []OnCall
+	for offset := 0; ; offset += 100 {
+		q.Set("offset", fmt.Sprint(offset))
+		var resp struct {
+			OnCalls []struct {
+				User struct {
+					Summary string `json:"summary"`
+					Email   string `json:"email"`
+				} `json:"user"`
+				EscalationPolicy ref        `json:"escalation_policy"`
+				EscalationLevel  int        `json:"escalation_level"`
+				Schedule         *ref       `json:"schedule"`
+				End              *time.Time `json:"end"`
+			} `json:"oncalls"`
+			More bool `json:"more"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/oncalls?"+q.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, o := range resp.OnCalls {
+			oc := OnCall{
+				User:             o.User.Summary,
+				Email:            o.User.Email,
+				EscalationPolicy: o.EscalationPolicy.Summary,
+				Level:            o.EscalationLevel,
+			}
+			if o.Schedule != nil {
+				oc.Schedule = o.Schedule.Summary
+			}
+			if o.End != nil {
+				oc.End = *o.End
+			}
+			out = append(out, oc)
+		}
+		if !resp.More {
+			return out, nil
+		}
+	}
+}
+
+// incidentURLPattern matches the ID in an incident URL such as
+// https://acme.pagerduty.com/incidents/Q1ABCDEF2GHIJ.
+var incidentURLPattern = regexp.MustCompile(`/incidents/([A-Z0-9]+)`)
+
+// ParseIncidentID accepts an incident ID or URL and returns the ID.
+func ParseIncidentID(s string) string {
+	s = strings.TrimSpace(s)
+	if m := incidentURLPattern.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return s
+}
+
+// --------------------------------------------------------------------------
+// API types
+// --------------------------------------------------------------------------
+
+type ref struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+}
+
+type incident struct {
+	ID               string    `json:"id"`
+	IncidentNumber   int       `json:"incident_number"`
+	Title            string    `json:"title"`
+	Description      string    `json:"description"`
+	Status           string    `json:"status"`
+	Urgency          string    `json:"urgency"`
+	CreatedAt        time.Time `json:"created_at"`
+	HTMLURL          string    `json:"html_url"`
+	Service          ref       `json:"service"`
+	EscalationPolicy ref       `json:"escalation_policy"`
+	Priority         *ref      `json:"priority"`
+	Assignments      []struct {
+		Assignee ref `json:"assignee"`
+	} `json:"assignments"`
+}
+
+func (in incident) toIncident() Incident {
+	out := Incident{
+		ID:               in.ID,
+		Number:           in.IncidentNumber,
+		Title:            in.Title,
+		Status:           in.Status,
+		Urgency:          in.Urgency,
+		Service:          in.Service.Summary,
+		EscalationPolicy: in.EscalationPolicy.Summary,
+		CreatedAt:        in.CreatedAt,
+		URL:              in.HTMLURL,
+	}
+	if in.Description != in.Title {
+		out.Description = in.Description
+	}
+	if in.Priority != nil {
+		out.Priority = in.Priority.Summary
+	}
+	for _, a := range in.Assignments {
+		out.Assignees = append(out.Assignees, a.Assignee.Summary)
+	}
+	return out
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Token token="+c.token)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if method != http.MethodGet {
+		if c.fromEmail == "" {
+			return fmt.Errorf("PAGERDUTY_FROM_EMAIL is not set; it is required to update incidents")
+		}
+		req.Header.Set("From", c.fromEmail)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string   `json:"message"`
+				Errors  []string `json:"errors"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			msg := apiErr.Error.Message
+			if len(apiErr.Error.Errors) > 0 {
+				msg += ": " + strings.Join(apiErr.Error.Errors, "; ")
+			}
+			return fmt.Errorf("pagerduty API error (HTTP %d): %s", resp.StatusCode, msg)
+		}
+		return fmt.Errorf("pagerduty API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
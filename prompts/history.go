@@ -0,0 +1,157 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/fsutil"
+)
+
+// maxPromptVersions bounds the versions kept per agent; the oldest are
+// dropped first.
+const maxPromptVersions = 100
+
+// PromptVersion is a snapshot of an agent's prompts.yaml.
+type PromptVersion struct {
+	Agent   string    `json:"agent"`
+	Version int       `json:"version"`
+	Author  string    `json:"author,omitempty"`
+	Source  string    `json:"source"` // "startup", "reload", "rollback" or "sync"
+	Note    string    `json:"note,omitempty"`
+	Time    time.Time `json:"time"`
+	Hash    string    `json:"hash"` // SHA-256 of Content
+	Content string    `json:"content"`
+}
+
+// History keeps versioned snapshots of every agent's prompts.yaml. A
+// version is added whenever the content loaded differs from the agent's
+// latest one. When created with a path, versions are saved to that JSON
+// file on every change and loaded on startup. Safe for concurrent use.
+type History struct {
+	mu       sync.Mutex
+	path     string
+	versions []PromptVersion // oldest first
+}
+
+// NewHistory creates a history persisted to path (empty = in memory).
+func NewHistory(path string) (*History, error) {
+	h := &History{path: path}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.versions); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt history %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Record adds content as agentID's next version unless it matches the
+// latest one. added reports whether a version was added; v is the new or
+// unchanged latest version.
+func (h *History) Record(agentID string, content []byte, author, source, note string) (v PromptVersion, added bool, err error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	latest, ok := h.latestLocked(agentID)
+	if ok && latest.Hash == hash {
+		return latest, false, nil
+	}
+	v = PromptVersion{
+		Agent:   agentID,
+		Version: latest.Version + 1,
+		Author:  author,
+		Source:  source,
+		Note:    note,
+		Time:    time.Now().UTC(),
+		Hash:    hash,
+		Content: string(content),
+	}
+	h.versions = append(h.versions, v)
+	h.trimLocked(agentID)
+	return v, true, h.saveLocked()
+}
+
+// List returns agentID's versions, newest first.
+func (h *History) List(agentID string) []PromptVersion {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []PromptVersion
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		if h.versions[i].Agent == agentID {
+			out = append(out, h.versions[i])
+		}
+	}
+	return out
+}
+
+// Get returns version n of agentID's prompts.
+func (h *History) Get(agentID string, n int) (PromptVersion, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, v := range h.versions {
+		if v.Agent == agentID && v.Version == n {
+			return v, true
+		}
+	}
+	return PromptVersion{}, false
+}
+
+func (h *History) latestLocked(agentID string) (PromptVersion, bool) {
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		if h.versions[i].Agent == agentID {
+			return h.versions[i], true
+		}
+	}
+	return PromptVersion{}, false
+}
+
+// trimLocked drops agentID's oldest versions beyond maxPromptVersions.
+func (h *History) trimLocked(agentID string) {
+	n := 0
+	for _, v := range h.versions {
+		if v.Agent == agentID {
+			n++
+		}
+	}
+	if n <= maxPromptVersions {
+		return
+	}
+	drop := n - maxPromptVersions
+	kept := h.versions[:0]
+	for _, v := range h.versions {
+		if v.Agent == agentID && drop > 0 {
+			drop--
+			continue
+		}
+		kept = append(kept, v)
+	}
+	h.versions = kept
+}
+
+func (h *History) saveLocked() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h.versions)
+	if err != nil {
+		return fmt.Errorf("failed to encode prompt history: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(h.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save prompt history: %w", err)
+	}
+	return nil
+}
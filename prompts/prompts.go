@@ -3,9 +3,13 @@ package prompts
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/justmike1/ovad/fsutil"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,17 +25,369 @@ type AgentConfig struct {
 	ID      string            `json:"id"`
 	Name    string            `json:"name"`
 	Prompts map[string]string `json:"prompts"`
+	Tools   ToolPolicy        `json:"tools"`
+	Repos   RepoPolicy        `json:"repos"`
 }
 
-// agentMeta is the on-disk config.yaml structure for an agent.
-type agentMeta struct {
-	Name string `yaml:"name"`
+// AgentSettings is the on-disk config.yaml structure for an agent.
+type AgentSettings struct {
+	Name       string           `yaml:"name"`
+	Tools      ToolPolicy       `yaml:"tools"`
+	Repos      RepoPolicy       `yaml:"repos"`
+	Access     AccessConfig     `yaml:"access"`
+	Escalation EscalationConfig `yaml:"escalation"`
+	ReadOnly   bool             `yaml:"read_only"` // disables every write tool
+	DryRun     bool             `yaml:"dry_run"`   // write tools only preview their changes
+	Channels   []string         `yaml:"channels"`  // Slack channel IDs whose @mentions go to this agent
+	Schedules  []ScheduleConfig `yaml:"schedules"`
+	Freezes    []FreezeConfig   `yaml:"freezes"`
+	Runbooks   []RunbookConfig  `yaml:"runbooks"`
+	Trigger    TriggerConfig    `yaml:"trigger"`
+	GitHub     GitHubEvents     `yaml:"github_events"`
+	Jira       []JiraEventRule  `yaml:"jira_events"`
+	Reports    []ReportConfig   `yaml:"reports"`
+}
+
+// ReportConfig posts a periodic activity report to Channel, covering the
+// bot's activity in Sources (default: Channel) over the last Days days.
+type ReportConfig struct {
+	Name     string   `yaml:"name"`
+	Channel  string   `yaml:"channel"`
+	Sources  []string `yaml:"sources"`
+	Cron     string   `yaml:"cron"` // default: Mondays 09:00
+	Timezone string   `yaml:"timezone"`
+	Days     int      `yaml:"days"` // default: 7
+}
+
+// JiraEventRule routes /jira/webhook deliveries to a Slack channel. Projects
+// and Labels narrow the match (empty = any; a label rule matches when the
+// issue has any of the labels). Without a Prompt the agent posts a formatted
+// summary; with one it runs the prompt against the issue (e.g. triage).
+type JiraEventRule struct {
+	Channel  string   `yaml:"channel"`
+	Projects []string `yaml:"projects"`
+	Labels   []string `yaml:"labels"`
+	Events   []string `yaml:"events"` // "issue_created", "issue_updated", "comment_created"; empty = issue_created
+	Prompt   string   `yaml:"prompt"`
+}
+
+// Matches reports whether the rule applies to event on an issue in project
+// with the given labels.
+func (j JiraEventRule) Matches(event, project string, labels []string) bool {
+	if j.Channel == "" {
+		return false
+	}
+	events := j.Events
+	if len(events) == 0 {
+		events = []string{"issue_created"}
+	}
+	if !slices.Contains(events, event) {
+		return false
+	}
+	if len(j.Projects) > 0 && !slices.ContainsFunc(j.Projects, func(p string) bool { return strings.EqualFold(p, project) }) {
+		return false
+	}
+	if len(j.Labels) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(j.Labels, func(l string) bool { return slices.Contains(labels, l) })
+}
+
+// GitHubEvents configures which /github/webhook deliveries the agent handles.
+type GitHubEvents struct {
+	Channel string   `yaml:"channel"` // Slack channel ID that receives the analyses
+	Repos   []string `yaml:"repos"`   // owner/name globs; empty = all repos
+	Events  []string `yaml:"events"`  // "workflow_failed", "pull_request_opened"; empty = both
+}
+
+// Handles reports whether the agent handles event for repo fullName.
+func (g GitHubEvents) Handles(event, fullName string) bool {
+	if g.Channel == "" {
+		return false
+	}
+	if len(g.Events) > 0 && !slices.Contains(g.Events, event) {
+		return false
+	}
+	return RepoPolicy{Read: g.Repos}.AllowsRead(fullName)
+}
+
+// TriggerConfig configures /api/agents/{id}/trigger for the agent.
+type TriggerConfig struct {
+	Channel string `yaml:"channel"` // Slack channel ID that receives triggered runs
+}
+
+// FreezeConfig is a change-freeze window during which repository writes are
+// blocked. It either recurs (Start cron + Duration) or is fixed (From/Until,
+// RFC 3339). Repos and Paths use the RepoPolicy glob syntax and default to
+// everything. Users listed in Override may still make changes.
+type FreezeConfig struct {
+	Name     string   `yaml:"name"`
+	Reason   string   `yaml:"reason"`
+	Start    string   `yaml:"start"`    // cron expression, e.g. "0 16 * * fri"
+	Duration string   `yaml:"duration"` // e.g. "64h"
+	Timezone string   `yaml:"timezone"`
+	From     string   `yaml:"from"`
+	Until    string   `yaml:"until"`
+	Repos    []string `yaml:"repos"`
+	Paths    []string `yaml:"paths"`
+	Override []string `yaml:"override"` // Slack user IDs allowed to write during the freeze
+}
+
+// RunbookConfig is a named sequence of tool calls that users run by name
+// with parameters. Step arguments may reference parameters as {{name}}.
+type RunbookConfig struct {
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Params      []RunbookParam `yaml:"params"`
+	Steps       []RunbookStep  `yaml:"steps"`
+}
+
+// RunbookParam is a runbook input. Values are strings; Pattern, when set,
+// is a regular expression the whole value must match.
+type RunbookParam struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+	Default     string `yaml:"default"`
+	Pattern     string `yaml:"pattern"`
+}
+
+// RunbookStep is one tool call of a runbook. With Approval the runbook
+// pauses before the step until the user approves it.
+type RunbookStep struct {
+	Name            string         `yaml:"name"`
+	Tool            string         `yaml:"tool"`
+	Args            map[string]any `yaml:"args"`
+	Approval        bool           `yaml:"approval"`
+	ContinueOnError bool           `yaml:"continue_on_error"`
+}
+
+// ScheduleConfig is a prompt the agent runs on a cron schedule, posting the
+// result to a Slack channel.
+type ScheduleConfig struct {
+	Name     string `yaml:"name"`
+	Cron     string `yaml:"cron"`     // five-field cron expression, e.g. "0 9 * * mon-fri"
+	Timezone string `yaml:"timezone"` // IANA name, e.g. "Europe/Berlin" (default: server local time)
+	Channel  string `yaml:"channel"`  // Slack channel ID
+	Prompt   string `yaml:"prompt"`
+}
+
+// ToolPolicy restricts which LLM tools an agent may use. Entries are tool
+// names or path.Match patterns (e.g. "*_jira_*"). When Allow is empty every
+// tool is allowed; Deny always wins over Allow.
+type ToolPolicy struct {
+	Allow []string `yaml:"allow" json:"allow,omitempty"`
+	Deny  []string `yaml:"deny" json:"deny,omitempty"`
+}
+
+// Allows reports whether the named tool is permitted by the policy.
+func (p ToolPolicy) Allows(tool string) bool {
+	if matchAny(p.Deny, tool) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchAny(p.Allow, tool)
+}
+
+// AccessConfig maps Slack users, user groups and channels to the tool
+// categories they may use. Without rules every tool the ToolPolicy allows
+// may be used by anyone. With rules, a request may use the Default
+// categories (default: read) plus the Allow categories of every rule that
+// matches it. Categories are read, write-code, rerun-ci, jira-write and
+// write (every write tool), or tool names and patterns as in ToolPolicy.
+type AccessConfig struct {
+	Default []string     `yaml:"default"`
+	Rules   []AccessRule `yaml:"rules"`
+}
+
+// AccessRule grants tool categories. It matches a request when the user is
+// listed in Users or is a member of one of Groups (Slack user group IDs),
+// if either is set, and the channel is listed in Channels, if set.
+type AccessRule struct {
+	Name     string   `yaml:"name"`
+	Users    []string `yaml:"users"`
+	Groups   []string `yaml:"groups"`
+	Channels []string `yaml:"channels"`
+	Allow    []string `yaml:"allow"`
+}
+
+// EscalationConfig names the humans tagged under low-confidence answers so
+// someone reviews them: Slack user group IDs in Groups and user IDs in
+// Users. Empty = answers only carry the uncertainty disclaimer.
+type EscalationConfig struct {
+	Groups []string `yaml:"groups"`
+	Users  []string `yaml:"users"`
+}
+
+// RepoPolicy restricts which GitHub repositories an agent may touch.
+// Patterns are path.Match globs against "owner/repo" (e.g. "myorg/web-*"),
+// or against the bare repo name when the pattern has no slash (e.g. "docs-*").
+// An empty Read list allows reading any repo; an empty Write list falls back
+// to the Read list. Write access implies read access.
+//
+// Protected lists file path globs the bot must never modify, regardless of
+// write access. "**" matches any number of directories. A "repo:" prefix
+// (bare name or owner/repo) limits a pattern to one repository, e.g.
+// "infra:**/prod/**".
+type RepoPolicy struct {
+	Read      []string `yaml:"read" json:"read,omitempty"`
+	Write     []string `yaml:"write" json:"write,omitempty"`
+	Protected []string `yaml:"protected" json:"protected,omitempty"`
+}
+
+// Restricted reports whether the policy limits repository access at all.
+func (p RepoPolicy) Restricted() bool {
+	return len(p.Read) > 0 || len(p.Write) > 0
+}
+
+// AllowsRead reports whether the agent may read the given "owner/repo".
+func (p RepoPolicy) AllowsRead(fullName string) bool {
+	if len(p.Read) == 0 && len(p.Write) == 0 {
+		return true
+	}
+	if len(p.Read) == 0 {
+		return true // only writes are restricted
+	}
+	return matchRepo(p.Read, fullName) || matchRepo(p.Write, fullName)
+}
+
+// AllowsWrite reports whether the agent may modify the given "owner/repo".
+func (p RepoPolicy) AllowsWrite(fullName string) bool {
+	if len(p.Write) > 0 {
+		return matchRepo(p.Write, fullName)
+	}
+	if len(p.Read) > 0 {
+		return matchRepo(p.Read, fullName)
+	}
+	return true
+}
+
+// ProtectedMatch returns the Protected pattern that forbids modifying
+// filePath in the "owner/repo" fullName, or "" when the path may be changed.
+func (p RepoPolicy) ProtectedMatch(fullName, filePath string) string {
+	filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	for _, pat := range p.Protected {
+		glob := pat
+		if repo, rest, ok := strings.Cut(pat, ":"); ok {
+			if !matchRepo([]string{repo}, fullName) {
+				continue
+			}
+			glob = rest
+		}
+		if matchPathGlob(strings.TrimPrefix(glob, "/"), filePath) {
+			return pat
+		}
+	}
+	return ""
+}
+
+// matchPathGlob is path.Match with "**" matching zero or more whole path
+// segments.
+func matchPathGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pat[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func matchRepo(patterns []string, fullName string) bool {
+	name := fullName
+	if idx := strings.LastIndex(fullName, "/"); idx >= 0 {
+		name = fullName[idx+1:]
+	}
+	for _, pat := range patterns {
+		target := fullName
+		if !strings.Contains(pat, "/") {
+			target = name
+		}
+		if ok, err := path.Match(strings.ToLower(pat), strings.ToLower(target)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pat := range patterns {
+		if ok, err := path.Match(pat, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAgentSettings reads the optional config.yaml for the given agent.
+// A missing file yields zero-value settings (no restrictions). Protected
+// paths, freezes, runbooks and access rules from the global
+// agents/config.yaml apply to every agent; the agent's access default and
+// escalation contacts replace the global ones.
+func LoadAgentSettings(agentID string) (*AgentSettings, error) {
+	agentsDir := os.Getenv("AGENTS_DIR")
+	if agentsDir == "" {
+		agentsDir = defaultAgentsDir
+	}
+	settings, err := readAgentSettings(filepath.Join(agentsDir, agentID, agentConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	global, err := readAgentSettings(filepath.Join(agentsDir, agentConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	settings.Repos.Protected = append(global.Repos.Protected, settings.Repos.Protected...)
+	settings.Freezes = append(global.Freezes, settings.Freezes...)
+	settings.Runbooks = append(global.Runbooks, settings.Runbooks...)
+	settings.Access.Rules = append(global.Access.Rules, settings.Access.Rules...)
+	if len(settings.Access.Default) == 0 {
+		settings.Access.Default = global.Access.Default
+	}
+	if len(settings.Escalation.Groups) == 0 && len(settings.Escalation.Users) == 0 {
+		settings.Escalation = global.Escalation
+	}
+	return settings, nil
+}
+
+func readAgentSettings(configPath string) (*AgentSettings, error) {
+	settings := &AgentSettings{}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return nil, fmt.Errorf("failed to read agent config %s: %w", configPath, err)
+	}
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse agent config %s: %w", configPath, err)
+	}
+	return settings, nil
 }
 
 // AgentPrompts holds a per-agent prompt store with Get/MustGet methods.
+// Safe for concurrent use; Reload swaps in the files' current content.
 type AgentPrompts struct {
 	agentID string
+	mu      sync.RWMutex
 	store   map[string]string
+	// history receives a snapshot of the agent's prompts.yaml whenever it
+	// changes; nil disables versioning.
+	history *History
 }
 
 // loadGlobalPrompts reads the global prompts.yaml from the agents root directory.
@@ -54,42 +410,129 @@ func loadGlobalPrompts(agentsDir string) (map[string]string, error) {
 // LoadAgent reads the prompts.yaml for the given agent and returns an AgentPrompts.
 // Global prompts from agents/prompts.yaml are loaded first; agent-specific prompts override them.
 func LoadAgent(agentID string) (*AgentPrompts, error) {
-	agentsDir := os.Getenv("AGENTS_DIR")
-	if agentsDir == "" {
-		agentsDir = defaultAgentsDir
+	store, _, err := loadAgentStore(agentID, nil)
+	if err != nil {
+		return nil, err
 	}
+	return &AgentPrompts{agentID: agentID, store: store}, nil
+}
+
+// SetHistory versions the agent's prompts in h, starting with the current
+// prompts.yaml.
+func (ap *AgentPrompts) SetHistory(h *History) error {
+	data, err := os.ReadFile(agentPromptsPath(ap.agentID))
+	if err != nil {
+		return fmt.Errorf("failed to read prompts for agent %s: %w", ap.agentID, err)
+	}
+	ap.mu.Lock()
+	ap.history = h
+	ap.mu.Unlock()
+	_, _, err = h.Record(ap.agentID, data, "", "startup", "")
+	return err
+}
+
+// Reload re-reads the agent's prompts from disk. On error the current
+// prompts are kept. When the agent's prompts.yaml changed, a new version is
+// recorded with author, source ("reload" or "sync") and note.
+func (ap *AgentPrompts) Reload(author, source, note string) error {
+	store, data, err := loadAgentStore(ap.agentID, nil)
+	if err != nil {
+		return err
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.store = store
+	if ap.history == nil {
+		return nil
+	}
+	_, _, err = ap.history.Record(ap.agentID, data, author, source, note)
+	return err
+}
+
+// Rollback restores version n of the agent's prompts.yaml: it rewrites the
+// file, swaps in its prompts and records it as a new version.
+func (ap *AgentPrompts) Rollback(n int, author string) (PromptVersion, error) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.history == nil {
+		return PromptVersion{}, fmt.Errorf("prompt history is not enabled")
+	}
+	old, ok := ap.history.Get(ap.agentID, n)
+	if !ok {
+		return PromptVersion{}, fmt.Errorf("agent %s has no prompt version %d", ap.agentID, n)
+	}
+	store, _, err := loadAgentStore(ap.agentID, []byte(old.Content))
+	if err != nil {
+		return PromptVersion{}, err
+	}
+	path := agentPromptsPath(ap.agentID)
+	if err := fsutil.WriteFileAtomic(path, []byte(old.Content), 0o644); err != nil {
+		return PromptVersion{}, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	ap.store = store
+	v, _, err := ap.history.Record(ap.agentID, []byte(old.Content), author, "rollback", fmt.Sprintf("rollback to version %d", n))
+	return v, err
+}
+
+// History returns the agent's prompt history, or nil when it is disabled.
+func (ap *AgentPrompts) History() *History {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+	return ap.history
+}
+
+// agentsRoot returns the agents directory (AGENTS_DIR, default agents).
+func agentsRoot() string {
+	if dir := os.Getenv("AGENTS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultAgentsDir
+}
+
+// agentPromptsPath returns the path of agentID's prompts.yaml.
+func agentPromptsPath(agentID string) string {
+	return filepath.Join(agentsRoot(), agentID, "prompts.yaml")
+}
+
+// loadAgentStore merges the global and agent-specific prompts. The agent's
+// prompts.yaml is read from disk unless data is given; its raw content is
+// returned too.
+func loadAgentStore(agentID string, data []byte) (map[string]string, []byte, error) {
+	agentsDir := agentsRoot()
 
 	// Start with global prompts as the base.
 	merged, err := loadGlobalPrompts(agentsDir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if merged == nil {
 		merged = make(map[string]string)
 	}
 
 	// Layer agent-specific prompts on top (overrides globals).
-	path := filepath.Join(agentsDir, agentID, "prompts.yaml")
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read prompts for agent %s: %w", agentID, err)
+	if data == nil {
+		data, err = os.ReadFile(agentPromptsPath(agentID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read prompts for agent %s: %w", agentID, err)
+		}
 	}
 	parsed := make(map[string]string)
 	if err := yaml.Unmarshal(data, &parsed); err != nil {
-		return nil, fmt.Errorf("failed to parse prompts for agent %s: %w", agentID, err)
+		return nil, nil, fmt.Errorf("failed to parse prompts for agent %s: %w", agentID, err)
 	}
 	for k, v := range parsed {
 		merged[k] = v
 	}
-
-	return &AgentPrompts{agentID: agentID, store: merged}, nil
+	return merged, data, nil
 }
 
 // Get returns the prompt for the given key, or empty string if not found.
 func (ap *AgentPrompts) Get(key string) string {
-	if ap == nil || ap.store == nil {
+	if ap == nil {
 		return ""
 	}
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
 	return ap.store[key]
 }
 
@@ -104,7 +547,12 @@ func (ap *AgentPrompts) MustGet(key string) string {
 
 // GetAll returns a copy of all prompts in this agent store.
 func (ap *AgentPrompts) GetAll() map[string]string {
-	if ap == nil || ap.store == nil {
+	if ap == nil {
+		return nil
+	}
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+	if ap.store == nil {
 		return nil
 	}
 	cp := make(map[string]string, len(ap.store))
@@ -218,19 +666,24 @@ func DiscoverAgents(agentsDir string) ([]AgentConfig, error) {
 		name := entry.Name()
 		displayName := strings.ToUpper(name[:1]) + name[1:]
 
-		// Check for config.yaml with a custom display name.
+		// Check for config.yaml with a custom display name and tool policy.
+		var tools ToolPolicy
+		var repos RepoPolicy
 		configPath := filepath.Join(agentsDir, entry.Name(), agentConfigFile)
-		if cfgData, err := os.ReadFile(configPath); err == nil {
-			var meta agentMeta
-			if err := yaml.Unmarshal(cfgData, &meta); err == nil && meta.Name != "" {
-				displayName = meta.Name
+		if settings, err := readAgentSettings(configPath); err == nil {
+			if settings.Name != "" {
+				displayName = settings.Name
 			}
+			tools = settings.Tools
+			repos = settings.Repos
 		}
 
 		agents = append(agents, AgentConfig{
 			ID:      name,
 			Name:    displayName,
 			Prompts: merged,
+			Tools:   tools,
+			Repos:   repos,
 		})
 	}
 
@@ -0,0 +1,175 @@
+// Package scheduler runs agent prompts on cron schedules.
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+)
+
+// RunFunc executes a job's prompt. It is called in its own goroutine.
+type RunFunc func(job *Job)
+
+// Job is a prompt an agent runs on a schedule, posting the result to Channel.
+// A one-shot job (At set) runs once and is then removed.
+type Job struct {
+	AgentID  string         `json:"agent_id"`
+	Name     string         `json:"name"`
+	Cron     string         `json:"cron,omitempty"`
+	At       time.Time      `json:"at,omitempty"`
+	Prompt   string         `json:"prompt"`
+	Channel  string         `json:"channel"`
+	UserID   string         `json:"user_id,omitempty"` // requester of a deferred change; "" for config schedules
+	Location *time.Location `json:"-"`
+	// Run, when set, replaces the scheduler's RunFunc for this job. Used for
+	// built-in jobs that aren't prompts, such as activity reports.
+	Run func() `json:"-"`
+
+	schedule *Schedule
+	running  atomic.Bool
+}
+
+// NewJob validates the cron expression and timezone ("" = server local time).
+func NewJob(agentID, name, cronExpr, prompt, channel, timezone string) (*Job, error) {
+	sched, err := Parse(cronExpr)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+	return &Job{
+		AgentID:  agentID,
+		Name:     name,
+		Cron:     cronExpr,
+		Prompt:   prompt,
+		Channel:  channel,
+		Location: loc,
+		schedule: sched,
+	}, nil
+}
+
+// NewOnceJob creates a job that runs once at the given time on behalf of userID.
+func NewOnceJob(agentID, name string, at time.Time, prompt, channel, userID string) *Job {
+	return &Job{
+		AgentID:  agentID,
+		Name:     name,
+		At:       at,
+		Prompt:   prompt,
+		Channel:  channel,
+		UserID:   userID,
+		Location: at.Location(),
+	}
+}
+
+// Next returns the job's next run time after t (zero if a one-shot job is past due).
+func (j *Job) Next(t time.Time) time.Time {
+	if j.schedule == nil {
+		if j.At.After(t) {
+			return j.At
+		}
+		return time.Time{}
+	}
+	return j.schedule.Next(t.In(j.Location))
+}
+
+// due reports whether the job should run at minute t.
+func (j *Job) due(t time.Time) bool {
+	if j.schedule == nil {
+		return !t.Before(j.At.Truncate(time.Minute))
+	}
+	return j.schedule.Matches(t.In(j.Location))
+}
+
+// Scheduler checks every job once a minute and runs those that are due.
+// A job is skipped while its previous run is still in progress.
+type Scheduler struct {
+	mu   sync.RWMutex
+	jobs []*Job
+	run  RunFunc
+}
+
+// New creates a scheduler that executes due jobs with run.
+func New(run RunFunc) *Scheduler {
+	return &Scheduler{run: run}
+}
+
+// Add registers a job.
+func (s *Scheduler) Add(j *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, j)
+}
+
+// Jobs returns the registered jobs sorted by agent and name.
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.RLock()
+	out := append([]*Job(nil), s.jobs...)
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, k int) bool {
+		if out[i].AgentID != out[k].AgentID {
+			return out[i].AgentID < out[k].AgentID
+		}
+		return out[i].Name < out[k].Name
+	})
+	return out
+}
+
+// Start runs the scheduler loop until ctx is cancelled. Run it in a goroutine.
+func (s *Scheduler) Start(ctx context.Context) {
+	logging.Ctx(ctx).Infof("[scheduler] started with %d job(s)", len(s.Jobs()))
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-ctx.Done():
+			logging.Ctx(ctx).Infof("[scheduler] stopped")
+			return
+		case <-time.After(next.Sub(now)):
+		}
+		s.tick(next)
+	}
+}
+
+// remove unregisters a job.
+func (s *Scheduler) remove(j *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, cur := range s.jobs {
+		if cur == j {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Scheduler) tick(t time.Time) {
+	for _, j := range s.Jobs() {
+		if !j.due(t) {
+			continue
+		}
+		if j.schedule == nil {
+			s.remove(j)
+		}
+		if !j.running.CompareAndSwap(false, true) {
+			logging.Infof("[scheduler] skipping agent=%s job=%q: previous run still in progress", j.AgentID, j.Name)
+			continue
+		}
+		logging.Infof("[scheduler] running agent=%s job=%q channel=%s", j.AgentID, j.Name, j.Channel)
+		go func(j *Job) {
+			defer j.running.Store(false)
+			if j.Run != nil {
+				j.Run()
+				return
+			}
+			s.run(j)
+		}(j)
+	}
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a span of time, either recurring (a cron start plus a duration)
+// or fixed (from/until).
+type Window struct {
+	start    *Schedule
+	duration time.Duration
+	loc      *time.Location
+	from     time.Time
+	until    time.Time
+}
+
+// NewRecurringWindow creates a window that opens whenever cronExpr fires and
+// stays open for d. timezone "" means server local time.
+func NewRecurringWindow(cronExpr string, d time.Duration, timezone string) (*Window, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("window duration must be positive")
+	}
+	sched, err := Parse(cronExpr)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+	return &Window{start: sched, duration: d, loc: loc}, nil
+}
+
+// NewFixedWindow creates a one-off window from from to until.
+func NewFixedWindow(from, until time.Time) (*Window, error) {
+	if !until.After(from) {
+		return nil, fmt.Errorf("window end %s is not after its start %s", until.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+	return &Window{from: from, until: until}, nil
+}
+
+// ActiveAt reports whether t falls inside the window and, if so, when the
+// window closes.
+func (w *Window) ActiveAt(t time.Time) (end time.Time, active bool) {
+	if w.start == nil {
+		if !t.Before(w.from) && t.Before(w.until) {
+			return w.until, true
+		}
+		return time.Time{}, false
+	}
+	// Any start in (t-duration, t] means the window is open at t.
+	x := t.In(w.loc).Add(-w.duration)
+	for {
+		s := w.start.Next(x)
+		if s.IsZero() || s.After(t) {
+			return time.Time{}, false
+		}
+		if e := s.Add(w.duration); e.After(t) {
+			return e, true
+		}
+		x = s
+	}
+}
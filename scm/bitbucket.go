@@ -0,0 +1,301 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/justmike1/ovad/bitbucket"
+	"github.com/justmike1/ovad/github"
+)
+
+var (
+	bitbucketPRURLPattern       = regexp.MustCompile(`^https://bitbucket\.org/([^/]+)/([^/]+)/pull-requests/(\d+)`)
+	bitbucketPipelineURLPattern = regexp.MustCompile(`^https://bitbucket\.org/([^/]+)/([^/]+)/(?:pipelines|addon/pipelines/home#!)/results/(\d+)`)
+)
+
+// Bitbucket is the Provider backed by Bitbucket Cloud. Repositories belong
+// to a workspace, and Pipelines runs stand in for workflow runs.
+type Bitbucket struct {
+	client    *bitbucket.Client
+	workspace string
+	logs      *github.LogScanner
+}
+
+// NewBitbucket wraps a Bitbucket client as a Provider for the repositories
+// of workspace. Failed step logs are scanned with logs; nil uses the
+// defaults.
+func NewBitbucket(client *bitbucket.Client, workspace string, logs *github.LogScanner) *Bitbucket {
+	if logs == nil {
+		logs = &github.LogScanner{}
+	}
+	return &Bitbucket{client: client, workspace: workspace, logs: logs}
+}
+
+func (b *Bitbucket) Name() string { return "Bitbucket" }
+
+func (b *Bitbucket) Owner(_ context.Context) (string, error) { return b.workspace, nil }
+
+func (b *Bitbucket) ListRepos(ctx context.Context, owner string) ([]string, error) {
+	repos, err := b.client.ListRepositories(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repos))
+	for _, r := range repos {
+		names = append(names, r.FullName)
+	}
+	return names, nil
+}
+
+func (b *Bitbucket) DefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	r, err := b.client.GetRepository(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return r.MainBranch.Name, nil
+}
+
+func (b *Bitbucket) FileContent(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	return b.client.GetFile(ctx, owner, repo, ref, path)
+}
+
+func (b *Bitbucket) ListDirectory(ctx context.Context, owner, repo, path, ref string) ([]string, error) {
+	entries, err := b.client.ListDirectory(ctx, owner, repo, ref, path, 1)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Path
+		if e.Type == "commit_directory" {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// SearchFiles walks the tree with a deep listing; Bitbucket has no
+// recursive tree endpoint.
+func (b *Bitbucket) SearchFiles(ctx context.Context, owner, repo, ref, pattern string) ([]string, error) {
+	entries, err := b.client.ListDirectory(ctx, owner, repo, ref, "", 20)
+	if err != nil {
+		return nil, err
+	}
+	lowerPattern := strings.ToLower(pattern)
+	var matches []string
+	for _, e := range entries {
+		if strings.Contains(strings.ToLower(e.Path), lowerPattern) {
+			matches = append(matches, e.Path)
+		}
+	}
+	return matches, nil
+}
+
+func (b *Bitbucket) CreateBranch(ctx context.Context, owner, repo, base, branch string) error {
+	return b.client.CreateBranch(ctx, owner, repo, base, branch)
+}
+
+func (b *Bitbucket) CommitFiles(ctx context.Context, owner, repo, branch, message string, files []FileChange) error {
+	writes := make(map[string][]byte)
+	var deletes []string
+	for _, f := range files {
+		if f.Delete {
+			deletes = append(deletes, f.Path)
+		} else {
+			writes[f.Path] = f.Content
+		}
+	}
+	return b.client.Commit(ctx, owner, repo, branch, message, writes, deletes)
+}
+
+func (b *Bitbucket) CreateChangeRequest(ctx context.Context, owner, repo, base, head, title, body string) (string, error) {
+	pr, err := b.client.CreatePullRequest(ctx, owner, repo, head, base, title, body)
+	if err != nil {
+		return "", err
+	}
+	return pr.Links.HTML.Href, nil
+}
+
+func (b *Bitbucket) GetChangeRequest(ctx context.Context, owner, repo string, number int) (*ChangeRequest, error) {
+	pr, err := b.client.GetPullRequest(ctx, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	summary := pullRequestSummary(pr)
+
+	stats, err := b.client.PullRequestDiffStat(ctx, owner, repo, number)
+	if err != nil {
+		return &summary, nil // the description is still useful without the diff
+	}
+	var diff strings.Builder
+	for _, s := range stats {
+		path := ""
+		switch {
+		case s.New != nil:
+			path = s.New.Path
+		case s.Old != nil:
+			path = s.Old.Path
+		}
+		summary.FileNames = append(summary.FileNames, path)
+		fmt.Fprintf(&diff, "--- %s (%s, +%d -%d)\n", path, s.Status, s.LinesAdded, s.LinesRemoved)
+	}
+	if patch, err := b.client.PullRequestDiff(ctx, owner, repo, number); err == nil && patch != "" {
+		diff.WriteString("\n")
+		diff.WriteString(patch)
+	}
+	summary.Diff = diff.String()
+	return &summary, nil
+}
+
+// ListChangeRequests lists pull requests. Bitbucket splits closed ones into
+// merged, declined and superseded.
+func (b *Bitbucket) ListChangeRequests(ctx context.Context, owner, repo, state string, limit int) ([]ChangeRequest, error) {
+	if limit <= 0 || limit > 30 {
+		limit = 10
+	}
+	closed := []string{"MERGED", "DECLINED", "SUPERSEDED"}
+	var states []string
+	switch state {
+	case "open":
+		states = []string{"OPEN"}
+	case "closed":
+		states = closed
+	default:
+		states = append([]string{"OPEN"}, closed...)
+	}
+	prs, err := b.client.ListPullRequests(ctx, owner, repo, states, limit)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]ChangeRequest, 0, len(prs))
+	for i := range prs {
+		summaries = append(summaries, pullRequestSummary(&prs[i]))
+	}
+	return summaries, nil
+}
+
+func (b *Bitbucket) ParseChangeRequestURL(rawURL string) (string, string, int, error) {
+	m := bitbucketPRURLPattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return "", "", 0, fmt.Errorf("not a valid Bitbucket pull request URL: %s", rawURL)
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid pull request number in URL: %w", err)
+	}
+	return m[1], m[2], n, nil
+}
+
+// GetPipeline summarizes a Pipelines run by build number. Each step becomes
+// a job, and a failed step's log is scanned like a GitHub Actions job log.
+func (b *Bitbucket) GetPipeline(ctx context.Context, owner, repo string, id int64) (*Pipeline, error) {
+	p, err := b.client.GetPipeline(ctx, owner, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Pipeline{
+		RunID: id,
+		Name:  "pipeline for " + p.Target.RefName,
+		URL:   fmt.Sprintf("%s/%s/%s/pipelines/results/%d", bitbucket.WebURL, owner, repo, id),
+	}
+	summary.Status, summary.Conclusion = pipelineState(p.State)
+
+	steps, err := b.client.PipelineSteps(ctx, owner, repo, p.UUID)
+	if err != nil {
+		return summary, err
+	}
+	failed := 0
+	for _, s := range steps {
+		if _, c := pipelineState(s.State); c == "failure" {
+			failed++
+		}
+	}
+	perJob, withLogs := b.logs.Budget(failed)
+
+	for _, s := range steps {
+		js := github.WorkflowJobSummary{Name: s.Name}
+		js.Status, js.Conclusion = pipelineState(s.State)
+		if js.Conclusion == "failure" {
+			if withLogs == 0 {
+				js.LogError = "skipped: log budget used by other failed jobs"
+			} else if jl, logErr := b.stepLog(ctx, owner, repo, p.UUID, s.UUID, perJob); logErr != nil {
+				js.LogError = logErr.Error()
+			} else {
+				js.ErrorLines, js.ErrorTotal, js.LogTail, js.LogLines = jl.ErrorLines, jl.ErrorTotal, jl.Tail, jl.Lines
+			}
+			withLogs = max(withLogs-1, 0)
+		}
+		summary.Jobs = append(summary.Jobs, js)
+	}
+	return summary, nil
+}
+
+func (b *Bitbucket) stepLog(ctx context.Context, owner, repo, pipelineUUID, stepUUID string, budget int) (*github.JobLog, error) {
+	stepLog, err := b.client.StepLog(ctx, owner, repo, pipelineUUID, stepUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stepLog.Close() }()
+	return b.logs.Scan(stepLog, budget)
+}
+
+func (b *Bitbucket) ParsePipelineURL(rawURL string) (string, string, int64, error) {
+	m := bitbucketPipelineURLPattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return "", "", 0, fmt.Errorf("not a valid Bitbucket pipeline URL: %s", rawURL)
+	}
+	id, err := strconv.ParseInt(m[3], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid pipeline build number in URL: %w", err)
+	}
+	return m[1], m[2], id, nil
+}
+
+func pullRequestSummary(pr *bitbucket.PullRequest) ChangeRequest {
+	s := ChangeRequest{
+		Number: pr.ID,
+		Title:  pr.Title,
+		State:  strings.ToLower(pr.State),
+		Author: pr.Author.Nickname,
+		URL:    pr.Links.HTML.Href,
+		Body:   pr.Description,
+		Draft:  pr.Draft,
+	}
+	if s.Author == "" {
+		s.Author = pr.Author.DisplayName
+	}
+	return s
+}
+
+// pipelineState maps a Bitbucket pipeline or step state to the GitHub
+// Actions status and conclusion the summary format uses.
+func pipelineState(st bitbucket.State) (string, string) {
+	switch st.Name {
+	case "PENDING":
+		return "queued", ""
+	case "IN_PROGRESS", "RUNNING":
+		return "in_progress", ""
+	case "PAUSED", "HALTED":
+		return "waiting", "action_required"
+	}
+	if st.Result == nil {
+		return strings.ToLower(st.Name), ""
+	}
+	switch st.Result.Name {
+	case "SUCCESSFUL":
+		return "completed", "success"
+	case "FAILED", "ERROR":
+		return "completed", "failure"
+	case "STOPPED":
+		return "completed", "cancelled"
+	case "NOT_RUN", "SKIPPED":
+		return "completed", "skipped"
+	case "EXPIRED":
+		return "completed", "timed_out"
+	}
+	return "completed", strings.ToLower(st.Result.Name)
+}
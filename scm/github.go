@@ -0,0 +1,84 @@
+package scm
+
+import (
+	"context"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/logging"
+)
+
+// GitHub is the Provider backed by GitHub.
+type GitHub struct {
+	client *github.Client
+}
+
+// NewGitHub wraps a GitHub client as a Provider.
+func NewGitHub(client *github.Client) *GitHub {
+	return &GitHub{client: client}
+}
+
+func (g *GitHub) Name() string { return "GitHub" }
+
+func (g *GitHub) Owner(ctx context.Context) (string, error) {
+	return g.client.ResolveOwner(ctx)
+}
+
+func (g *GitHub) ListRepos(ctx context.Context, owner string) ([]string, error) {
+	return g.client.ListOrgRepos(ctx, owner)
+}
+
+func (g *GitHub) DefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	return g.client.GetDefaultBranch(ctx, owner, repo)
+}
+
+func (g *GitHub) FileContent(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	content, _, err := g.client.GetFileContent(ctx, owner, repo, path, ref)
+	return content, err
+}
+
+func (g *GitHub) ListDirectory(ctx context.Context, owner, repo, path, ref string) ([]string, error) {
+	return g.client.GetDirectoryContents(ctx, owner, repo, path, ref)
+}
+
+func (g *GitHub) SearchFiles(ctx context.Context, owner, repo, ref, pattern string) ([]string, error) {
+	return g.client.SearchFiles(ctx, owner, repo, ref, pattern)
+}
+
+func (g *GitHub) CreateBranch(ctx context.Context, owner, repo, base, branch string) error {
+	return g.client.CreateBranch(ctx, owner, repo, base, branch)
+}
+
+func (g *GitHub) CommitFiles(ctx context.Context, owner, repo, branch, message string, files []FileChange) error {
+	return g.client.CommitFiles(ctx, owner, repo, branch, message, files)
+}
+
+func (g *GitHub) CreateChangeRequest(ctx context.Context, owner, repo, base, head, title, body string) (string, error) {
+	return g.client.CreatePullRequest(ctx, owner, repo, base, head, title, body)
+}
+
+func (g *GitHub) GetChangeRequest(ctx context.Context, owner, repo string, number int) (*ChangeRequest, error) {
+	return g.client.GetPullRequest(ctx, owner, repo, number)
+}
+
+// ListChangeRequests uses GraphQL, which returns reviews and checks with the
+// list, and falls back to REST.
+func (g *GitHub) ListChangeRequests(ctx context.Context, owner, repo, state string, limit int) ([]ChangeRequest, error) {
+	prs, err := g.client.ListPullRequestDetails(ctx, owner, repo, state, limit)
+	if err != nil {
+		logging.Ctx(ctx).Warnf("GraphQL PR listing for %s/%s failed, falling back to REST: %v", owner, repo, err)
+		return g.client.ListPullRequests(ctx, owner, repo, state, limit)
+	}
+	return prs, nil
+}
+
+func (g *GitHub) ParseChangeRequestURL(rawURL string) (string, string, int, error) {
+	return github.ParsePRURL(rawURL)
+}
+
+func (g *GitHub) GetPipeline(ctx context.Context, owner, repo string, id int64) (*Pipeline, error) {
+	return g.client.GetWorkflowRunSummary(ctx, owner, repo, id)
+}
+
+func (g *GitHub) ParsePipelineURL(rawURL string) (string, string, int64, error) {
+	return github.ParseWorkflowRunURL(rawURL)
+}
@@ -0,0 +1,334 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/justmike1/ovad/github"
+	"github.com/justmike1/ovad/gitlab"
+)
+
+// GitLab is the Provider backed by GitLab. Merge requests stand in for pull
+// requests and pipelines for workflow runs.
+type GitLab struct {
+	client *gitlab.Client
+	group  string // top-level group the agent works in, e.g. "acme"
+	logs   *github.LogScanner
+
+	mrURLPattern       *regexp.Regexp
+	pipelineURLPattern *regexp.Regexp
+}
+
+// NewGitLab wraps a GitLab client as a Provider for the projects of group.
+// Failed job logs are scanned with logs; nil uses the defaults.
+func NewGitLab(client *gitlab.Client, group string, logs *github.LogScanner) *GitLab {
+	if logs == nil {
+		logs = &github.LogScanner{}
+	}
+	base := regexp.QuoteMeta(client.BaseURL())
+	return &GitLab{
+		client:             client,
+		group:              strings.Trim(group, "/"),
+		logs:               logs,
+		mrURLPattern:       regexp.MustCompile(`^` + base + `/(.+)/-/merge_requests/(\d+)`),
+		pipelineURLPattern: regexp.MustCompile(`^` + base + `/(.+)/-/pipelines/(\d+)`),
+	}
+}
+
+func (g *GitLab) Name() string { return "GitLab" }
+
+func (g *GitLab) Owner(_ context.Context) (string, error) { return g.group, nil }
+
+func (g *GitLab) ListRepos(ctx context.Context, owner string) ([]string, error) {
+	projects, err := g.client.ListGroupProjects(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]string, 0, len(projects))
+	for _, p := range projects {
+		repos = append(repos, p.PathWithNamespace)
+	}
+	return repos, nil
+}
+
+func (g *GitLab) DefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	p, err := g.client.GetProject(ctx, owner+"/"+repo)
+	if err != nil {
+		return "", err
+	}
+	return p.DefaultBranch, nil
+}
+
+func (g *GitLab) FileContent(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	return g.client.GetFile(ctx, owner+"/"+repo, path, ref)
+}
+
+func (g *GitLab) ListDirectory(ctx context.Context, owner, repo, path, ref string) ([]string, error) {
+	entries, err := g.client.ListTree(ctx, owner+"/"+repo, path, ref, false)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Path
+		switch e.Type {
+		case "tree":
+			name += "/"
+		case "commit":
+			name += " (submodule)"
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (g *GitLab) SearchFiles(ctx context.Context, owner, repo, ref, pattern string) ([]string, error) {
+	entries, err := g.client.ListTree(ctx, owner+"/"+repo, "", ref, true)
+	if err != nil {
+		return nil, err
+	}
+	lowerPattern := strings.ToLower(pattern)
+	var matches []string
+	for _, e := range entries {
+		if strings.Contains(strings.ToLower(e.Path), lowerPattern) {
+			matches = append(matches, e.Path)
+		}
+	}
+	return matches, nil
+}
+
+func (g *GitLab) CreateBranch(ctx context.Context, owner, repo, base, branch string) error {
+	return g.client.CreateBranch(ctx, owner+"/"+repo, branch, base)
+}
+
+// CommitFiles commits through the commits API, which needs to know whether
+// each written file already exists.
+func (g *GitLab) CommitFiles(ctx context.Context, owner, repo, branch, message string, files []FileChange) error {
+	project := owner + "/" + repo
+	actions := make([]gitlab.CommitAction, 0, len(files))
+	for _, f := range files {
+		action := "delete"
+		if !f.Delete {
+			exists, err := g.client.FileExists(ctx, project, f.Path, branch)
+			if err != nil {
+				return err
+			}
+			action = "create"
+			if exists {
+				action = "update"
+			}
+		}
+		actions = append(actions, gitlab.CommitAction{Action: action, FilePath: f.Path, Content: f.Content})
+	}
+	return g.client.CreateCommit(ctx, project, branch, message, actions)
+}
+
+func (g *GitLab) CreateChangeRequest(ctx context.Context, owner, repo, base, head, title, body string) (string, error) {
+	mr, err := g.client.CreateMergeRequest(ctx, owner+"/"+repo, head, base, title, body)
+	if err != nil {
+		return "", err
+	}
+	return mr.WebURL, nil
+}
+
+func (g *GitLab) GetChangeRequest(ctx context.Context, owner, repo string, number int) (*ChangeRequest, error) {
+	project := owner + "/" + repo
+	mr, err := g.client.GetMergeRequest(ctx, project, number)
+	if err != nil {
+		return nil, err
+	}
+	summary := mergeRequestSummary(mr)
+	if mr.HeadPipeline != nil {
+		summary.Checks = mr.HeadPipeline.Status
+	}
+
+	diffs, err := g.client.MergeRequestDiffs(ctx, project, number)
+	if err != nil {
+		return &summary, nil // the description is still useful without the diff
+	}
+	var diff strings.Builder
+	for _, d := range diffs {
+		status := "modified"
+		switch {
+		case d.NewFile:
+			status = "added"
+		case d.DeletedFile:
+			status = "removed"
+		case d.RenamedFile:
+			status = "renamed from " + d.OldPath
+		}
+		summary.FileNames = append(summary.FileNames, d.NewPath)
+		fmt.Fprintf(&diff, "--- %s (%s)\n", d.NewPath, status)
+		if d.Diff != "" {
+			diff.WriteString(d.Diff)
+			diff.WriteString("\n\n")
+		}
+	}
+	summary.Diff = diff.String()
+	return &summary, nil
+}
+
+// ListChangeRequests lists merge requests. GitLab keeps merged ones apart
+// from closed ones, so "closed" includes both, as on GitHub.
+func (g *GitLab) ListChangeRequests(ctx context.Context, owner, repo, state string, limit int) ([]ChangeRequest, error) {
+	if limit <= 0 || limit > 30 {
+		limit = 10
+	}
+	apiState, fetch := "all", limit
+	switch state {
+	case "open":
+		apiState = "opened"
+	case "closed":
+		fetch = 100 // filtered below
+	}
+	mrs, err := g.client.ListMergeRequests(ctx, owner+"/"+repo, apiState, fetch)
+	if err != nil {
+		return nil, err
+	}
+	var summaries []ChangeRequest
+	for i := range mrs {
+		if state == "closed" && mrs[i].State == "opened" {
+			continue
+		}
+		summaries = append(summaries, mergeRequestSummary(&mrs[i]))
+		if len(summaries) == limit {
+			break
+		}
+	}
+	return summaries, nil
+}
+
+func (g *GitLab) ParseChangeRequestURL(rawURL string) (string, string, int, error) {
+	m := g.mrURLPattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return "", "", 0, fmt.Errorf("not a valid GitLab merge request URL: %s", rawURL)
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid merge request number in URL: %w", err)
+	}
+	owner, repo := splitProject(m[1])
+	return owner, repo, n, nil
+}
+
+// GetPipeline summarizes a pipeline. Jobs have no steps on GitLab; a failed
+// job's failure reason becomes an annotation, and its log is scanned like a
+// GitHub Actions job log.
+func (g *GitLab) GetPipeline(ctx context.Context, owner, repo string, id int64) (*Pipeline, error) {
+	project := owner + "/" + repo
+	p, err := g.client.GetPipeline(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Pipeline{RunID: id, Name: p.Name, URL: p.WebURL}
+	if summary.Name == "" {
+		summary.Name = "pipeline for " + p.Ref
+	}
+	summary.Status, summary.Conclusion = runState(p.Status)
+
+	jobs, err := g.client.PipelineJobs(ctx, project, id)
+	if err != nil {
+		return summary, err
+	}
+	failed := 0
+	for _, j := range jobs {
+		if j.Status == "failed" && !j.AllowFailure {
+			failed++
+		}
+	}
+	perJob, withLogs := g.logs.Budget(failed)
+
+	for _, j := range jobs {
+		js := github.WorkflowJobSummary{Name: j.Stage + " / " + j.Name}
+		js.Status, js.Conclusion = runState(j.Status)
+		if j.Status == "failed" && j.AllowFailure {
+			js.Conclusion = "neutral" // failure allowed; does not fail the pipeline
+		}
+		if js.Conclusion == "failure" {
+			if j.FailureReason != "" {
+				summary.Annotations = append(summary.Annotations, github.WorkflowAnnotation{JobName: js.Name, Level: "failure", Message: j.FailureReason})
+			}
+			if withLogs == 0 {
+				js.LogError = "skipped: log budget used by other failed jobs"
+			} else if jl, logErr := g.jobLog(ctx, project, j.ID, perJob); logErr != nil {
+				js.LogError = logErr.Error()
+			} else {
+				js.ErrorLines, js.ErrorTotal, js.LogTail, js.LogLines = jl.ErrorLines, jl.ErrorTotal, jl.Tail, jl.Lines
+			}
+			withLogs = max(withLogs-1, 0)
+		}
+		summary.Jobs = append(summary.Jobs, js)
+	}
+	return summary, nil
+}
+
+func (g *GitLab) jobLog(ctx context.Context, project string, jobID int64, budget int) (*github.JobLog, error) {
+	trace, err := g.client.JobTrace(ctx, project, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = trace.Close() }()
+	return g.logs.Scan(trace, budget)
+}
+
+func (g *GitLab) ParsePipelineURL(rawURL string) (string, string, int64, error) {
+	m := g.pipelineURLPattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return "", "", 0, fmt.Errorf("not a valid GitLab pipeline URL: %s", rawURL)
+	}
+	id, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid pipeline ID in URL: %w", err)
+	}
+	owner, repo := splitProject(m[1])
+	return owner, repo, id, nil
+}
+
+func mergeRequestSummary(mr *gitlab.MergeRequest) ChangeRequest {
+	s := ChangeRequest{
+		Number: mr.IID,
+		Title:  mr.Title,
+		State:  mr.State,
+		Author: mr.Author.Username,
+		URL:    mr.WebURL,
+		Body:   mr.Description,
+		Draft:  mr.Draft,
+	}
+	if s.State == "opened" {
+		s.State = "open"
+	}
+	if mr.DetailedMergeStatus == "not_approved" {
+		s.ReviewDecision = "review required"
+	}
+	return s
+}
+
+// runState maps a GitLab pipeline or job status to the GitHub Actions
+// status and conclusion the summary format uses.
+func runState(status string) (string, string) {
+	switch status {
+	case "success":
+		return "completed", "success"
+	case "failed":
+		return "completed", "failure"
+	case "canceled":
+		return "completed", "cancelled"
+	case "skipped":
+		return "completed", "skipped"
+	case "manual":
+		return "waiting", "action_required"
+	}
+	return status, ""
+}
+
+// splitProject splits a project path into its namespace and name.
+func splitProject(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
@@ -0,0 +1,57 @@
+// Package scm abstracts source control hosts behind one Provider interface so
+// the core repository, pull request and pipeline tools work the same whether
+// code lives on GitHub, GitLab or Bitbucket.
+package scm
+
+import (
+	"context"
+
+	"github.com/justmike1/ovad/github"
+)
+
+// Provider is a source control host. Repositories are addressed as owner
+// (organization, group or workspace) and repo (name, or path within the group).
+type Provider interface {
+	// Name is the host's display name, e.g. "GitHub".
+	Name() string
+	// Owner returns the organization, group or workspace the agent works in.
+	Owner(ctx context.Context) (string, error)
+	// ListRepos returns the full names ("owner/repo") of owner's repositories.
+	ListRepos(ctx context.Context, owner string) ([]string, error)
+	// DefaultBranch returns a repository's default branch.
+	DefaultBranch(ctx context.Context, owner, repo string) (string, error)
+	// FileContent returns the content of a file at ref.
+	FileContent(ctx context.Context, owner, repo, path, ref string) (string, error)
+	// ListDirectory returns the paths in a directory at ref; subdirectories
+	// end in "/".
+	ListDirectory(ctx context.Context, owner, repo, path, ref string) ([]string, error)
+	// SearchFiles returns the paths at ref that contain pattern, ignoring case.
+	SearchFiles(ctx context.Context, owner, repo, ref, pattern string) ([]string, error)
+	// CreateBranch creates branch from base.
+	CreateBranch(ctx context.Context, owner, repo, base, branch string) error
+	// CommitFiles lands all changes on branch as a single commit.
+	CommitFiles(ctx context.Context, owner, repo, branch, message string, files []FileChange) error
+	// CreateChangeRequest opens a pull (merge) request from head into base
+	// and returns its URL.
+	CreateChangeRequest(ctx context.Context, owner, repo, base, head, title, body string) (string, error)
+	// GetChangeRequest returns a pull request with its changed files and diff.
+	GetChangeRequest(ctx context.Context, owner, repo string, number int) (*ChangeRequest, error)
+	// ListChangeRequests returns recently updated pull requests in state
+	// "open", "closed" or "all".
+	ListChangeRequests(ctx context.Context, owner, repo, state string, limit int) ([]ChangeRequest, error)
+	// ParseChangeRequestURL extracts the repository and number from a pull
+	// request URL.
+	ParseChangeRequestURL(rawURL string) (owner, repo string, number int, err error)
+	// GetPipeline returns a CI run with its jobs and the logs of failed jobs.
+	GetPipeline(ctx context.Context, owner, repo string, id int64) (*Pipeline, error)
+	// ParsePipelineURL extracts the repository and run ID from a CI run URL.
+	ParsePipelineURL(rawURL string) (owner, repo string, id int64, err error)
+}
+
+// The result types are the github package's, so every host shares its
+// formatting (github.FormatPRSummary, github.FormatWorkflowRunSummary).
+type (
+	FileChange    = github.FileChange
+	ChangeRequest = github.PRSummary
+	Pipeline      = github.WorkflowRunSummary
+)
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/justmike1/ovad/config"
+	"github.com/justmike1/ovad/logging"
+)
+
+// serve runs the HTTP server on cfg.Port: plain HTTP by default, HTTPS when
+// TLS_CERT_FILE and TLS_KEY_FILE are set, and mutual TLS when
+// TLS_CLIENT_CA_FILE is set too.
+func serve(cfg *config.Config) error {
+	srv := &http.Server{Addr: ":" + cfg.Port}
+	if cfg.TLSCertFile == "" {
+		logging.Infof("arbetern server starting on :%s", cfg.Port)
+		return srv.ListenAndServe()
+	}
+
+	srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading TLS_CLIENT_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("TLS_CLIENT_CA_FILE %s contains no PEM certificates", cfg.TLSClientCAFile)
+		}
+		srv.TLSConfig.ClientCAs = pool
+		srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		logging.Infof("mTLS enabled: clients must present a certificate signed by %s", cfg.TLSClientCAFile)
+	}
+	logging.Infof("arbetern server starting on :%s (TLS)", cfg.Port)
+	return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+}
@@ -50,6 +50,23 @@ func (c *Client) PostThreadReply(channelID, threadTS, text string) error {
 	return nil
 }
 
+// UploadFile uploads content as a file to channelID, in threadTS's thread
+// when threadTS is set.
+func (c *Client) UploadFile(channelID, threadTS, filename, title string, content []byte) error {
+	_, err := c.api.UploadFileV2(slack.UploadFileV2Parameters{
+		Reader:          bytes.NewReader(content),
+		FileSize:        len(content),
+		Filename:        filename,
+		Title:           title,
+		Channel:         channelID,
+		ThreadTimestamp: threadTS,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
 func (c *Client) FetchThreadReplies(channelID, threadTS string, limit int) ([]slack.Message, error) {
 	msgs, _, _, err := c.api.GetConversationReplies(&slack.GetConversationRepliesParameters{
 		ChannelID: channelID,
@@ -91,6 +108,16 @@ func (c *Client) GetUserInfo(userID string) (*slack.User, error) {
 	return user, nil
 }
 
+// GetUserGroupMembers returns the user IDs in a Slack user group (needs the
+// usergroups:read scope).
+func (c *Client) GetUserGroupMembers(groupID string) ([]string, error) {
+	members, err := c.api.GetUserGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user group members: %w", err)
+	}
+	return members, nil
+}
+
 // GetTeamURL returns the Slack workspace URL (e.g. "https://myorg.slack.com/").
 func (c *Client) GetTeamURL() (string, error) {
 	resp, err := c.api.AuthTest()
@@ -2,10 +2,11 @@ package slack
 
 import (
 	"io"
-	"log"
 	"net/http"
 
 	slacklib "github.com/slack-go/slack"
+
+	"github.com/justmike1/ovad/logging"
 )
 
 type CommandHandler func(channelID, userID, text, responseURL string)
@@ -30,7 +31,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	verifier, err := slacklib.NewSecretsVerifier(r.Header, h.signingSecret)
 	if err != nil {
-		log.Printf("failed to create secrets verifier: %v", err)
+		logging.Errorf("failed to create secrets verifier: %v", err)
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -39,13 +40,13 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	cmd, err := slacklib.SlashCommandParse(r)
 	if err != nil {
-		log.Printf("failed to parse slash command: %v", err)
+		logging.Errorf("failed to parse slash command: %v", err)
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
 
 	if err := verifier.Ensure(); err != nil {
-		log.Printf("signature verification failed: %v", err)
+		logging.Errorf("signature verification failed: %v", err)
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
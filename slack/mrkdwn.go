@@ -0,0 +1,62 @@
+package slack
+
+import "regexp"
+
+var (
+	mrkdwnLink    = regexp.MustCompile(`<(https?://[^|>]+)(?:\|([^>]+))?>`)
+	mrkdwnMention = regexp.MustCompile(`<@([^>|]+)(?:\|[^>]*)?>`)
+	mrkdwnBold    = regexp.MustCompile(`(^|[\s(])\*([^*\n]+)\*`)
+	mrkdwnStrike  = regexp.MustCompile(`(^|[\s(])~([^~\n]+)~`)
+	mrkdwnEmoji   = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+)
+
+// emoji maps the shortcodes the agents use to Unicode; other chat platforms
+// show shortcodes as plain text.
+var emoji = map[string]string{
+	":warning:":             "⚠️",
+	":no_entry:":            "⛔",
+	":mag:":                 "🔍",
+	":bar_chart:":           "📊",
+	":zap:":                 "⚡",
+	":x:":                   "❌",
+	":white_check_mark:":    "✅",
+	":white_circle:":        "⚪",
+	":red_circle:":          "🔴",
+	":large_yellow_circle:": "🟡",
+	":large_orange_circle:": "🟠",
+	":large_green_circle:":  "🟢",
+	":ticket:":              "🎫",
+	":thread:":              "🧵",
+	":speech_balloon:":      "💬",
+	":rotating_light:":      "🚨",
+	":pause_button:":        "⏸️",
+	":alarm_clock:":         "⏰",
+	":hourglass:":           "⌛",
+	":rocket:":              "🚀",
+	":lock:":                "🔒",
+	":memo:":                "📝",
+}
+
+// ToMarkdown converts Slack mrkdwn, which the prompts and handlers produce,
+// to standard markdown for the other chat platforms: links, bold,
+// strikethrough and emoji. User mentions (<@U123>) are written by mention.
+func ToMarkdown(text string, mention func(userID string) string) string {
+	text = mrkdwnLink.ReplaceAllStringFunc(text, func(m string) string {
+		g := mrkdwnLink.FindStringSubmatch(m)
+		if g[2] == "" {
+			return g[1]
+		}
+		return "[" + g[2] + "](" + g[1] + ")"
+	})
+	text = mrkdwnMention.ReplaceAllStringFunc(text, func(m string) string {
+		return mention(mrkdwnMention.FindStringSubmatch(m)[1])
+	})
+	text = mrkdwnBold.ReplaceAllString(text, "$1**$2**")
+	text = mrkdwnStrike.ReplaceAllString(text, "$1~~$2~~")
+	return mrkdwnEmoji.ReplaceAllStringFunc(text, func(m string) string {
+		if e, ok := emoji[m]; ok {
+			return e
+		}
+		return m
+	})
+}
@@ -1,14 +1,18 @@
 package slack
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"sync/atomic"
 
 	slacklib "github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
+
+	"github.com/justmike1/ovad/logging"
 )
 
 // ThreadReplyHandler is called when a user sends a message in a tracked thread.
@@ -22,6 +26,15 @@ type ThreadReplyHandler func(channelID, threadTS, userID, text string)
 // is the ephemeral response URL Slack provides.
 type SlashCommandHandler func(command, channelID, userID, text, responseURL string)
 
+// AppMentionHandler is called when a user @mentions the bot. ts is the
+// mention's own timestamp; threadTS is the enclosing thread ("" when the
+// mention was posted top-level). text has the leading bot mention removed.
+type AppMentionHandler func(channelID, ts, threadTS, userID, text string)
+
+// AppHomeHandler renders the App Home tab for userID. It is called every time
+// the user opens the Home tab and the returned blocks are published as-is.
+type AppHomeHandler func(userID string) []slacklib.Block
+
 // SocketListener connects to Slack via Socket Mode (outbound WebSocket) and
 // dispatches thread reply messages to a handler. No inbound URL configuration
 // is needed — the app connects to Slack, not the other way around.
@@ -30,6 +43,8 @@ type SocketListener struct {
 	botUserID           string
 	threadReplyHandler  ThreadReplyHandler
 	slashCommandHandler SlashCommandHandler
+	appHomeHandler      AppHomeHandler
+	appMentionHandler   AppMentionHandler
 	debug               bool
 	connected           atomic.Bool
 	eventCount          atomic.Int64
@@ -70,14 +85,26 @@ func NewSocketListener(appToken, botToken, botUserID string, handler ThreadReply
 	}
 }
 
+// SetAppHomeHandler enables the App Home tab. Requires the app_home_opened
+// event subscription in the Slack app configuration.
+func (sl *SocketListener) SetAppHomeHandler(h AppHomeHandler) {
+	sl.appHomeHandler = h
+}
+
+// SetAppMentionHandler enables @mention handling. Requires the app_mention
+// event subscription in the Slack app configuration.
+func (sl *SocketListener) SetAppMentionHandler(h AppMentionHandler) {
+	sl.appMentionHandler = h
+}
+
 // Start connects to Slack and begins listening for events in a blocking loop.
 // Run this in a goroutine. It reconnects automatically on disconnection.
 func (sl *SocketListener) Start() {
 	go sl.handleEvents()
 
-	log.Printf("[socket-mode] connecting to Slack (debug=%v)...", sl.debug)
+	logging.Infof("[socket-mode] connecting to Slack (debug=%v)...", sl.debug)
 	if err := sl.smClient.Run(); err != nil {
-		log.Printf("[socket-mode] fatal: %v", err)
+		logging.Infof("[socket-mode] fatal: %v", err)
 	}
 }
 
@@ -90,26 +117,26 @@ func (sl *SocketListener) handleEvents() {
 		case socketmode.EventTypeConnecting:
 			// Only log if we were previously connected (suppress initial spam).
 			if sl.connected.Load() {
-				log.Printf("[socket-mode] reconnecting...")
+				logging.Infof("[socket-mode] reconnecting...")
 			}
 
 		case socketmode.EventTypeConnected:
 			wasConnected := sl.connected.Swap(true)
 			if !wasConnected {
-				log.Printf("[socket-mode] connected (events processed: %d)", sl.eventCount.Load())
+				logging.Infof("[socket-mode] connected (events processed: %d)", sl.eventCount.Load())
 			}
 
 		case socketmode.EventTypeConnectionError:
 			sl.connected.Store(false)
-			log.Printf("[socket-mode] connection error, will retry...")
+			logging.Warnf("[socket-mode] connection error, will retry...")
 
 		case socketmode.EventTypeHello:
-			log.Printf("[socket-mode] received hello from Slack")
+			logging.Infof("[socket-mode] received hello from Slack")
 
 		case socketmode.EventTypeEventsAPI:
 			eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
 			if !ok {
-				log.Printf("[socket-mode] WARNING: EventsAPI event data is %T (expected slackevents.EventsAPIEvent), skipping",
+				logging.Infof("[socket-mode] WARNING: EventsAPI event data is %T (expected slackevents.EventsAPIEvent), skipping",
 					evt.Data)
 				if evt.Request != nil {
 					sl.smClient.Ack(*evt.Request)
@@ -125,7 +152,7 @@ func (sl *SocketListener) handleEvents() {
 			sl.handleEventsAPI(eventsAPIEvent)
 
 		case socketmode.EventTypeInteractive:
-			log.Printf("[socket-mode] interactive event received (ignoring)")
+			logging.Debugf("[socket-mode] interactive event received (ignoring)")
 			if evt.Request != nil {
 				sl.smClient.Ack(*evt.Request)
 			}
@@ -133,7 +160,7 @@ func (sl *SocketListener) handleEvents() {
 		case socketmode.EventTypeSlashCommand:
 			cmd, ok := evt.Data.(slacklib.SlashCommand)
 			if !ok {
-				log.Printf("[socket-mode] WARNING: slash command data is %T (expected slack.SlashCommand), skipping", evt.Data)
+				logging.Infof("[socket-mode] WARNING: slash command data is %T (expected slack.SlashCommand), skipping", evt.Data)
 				if evt.Request != nil {
 					sl.smClient.Ack(*evt.Request)
 				}
@@ -147,7 +174,7 @@ func (sl *SocketListener) handleEvents() {
 				})
 			}
 
-			log.Printf("[socket-mode] slash command: command=%s channel=%s user=%s text=%q",
+			logging.Infof("[socket-mode] slash command: command=%s channel=%s user=%s text=%q",
 				cmd.Command, cmd.ChannelID, cmd.UserID, truncate(cmd.Text, 80))
 
 			if sl.slashCommandHandler != nil {
@@ -155,7 +182,7 @@ func (sl *SocketListener) handleEvents() {
 			}
 
 		default:
-			log.Printf("[socket-mode] unhandled event type: %s (data type: %T)",
+			logging.Infof("[socket-mode] unhandled event type: %s (data type: %T)",
 				evt.Type, evt.Data)
 			// Acknowledge unknown event types to avoid retries.
 			if evt.Request != nil {
@@ -163,30 +190,34 @@ func (sl *SocketListener) handleEvents() {
 			}
 		}
 	}
-	log.Printf("[socket-mode] event channel closed — listener stopped")
+	logging.Infof("[socket-mode] event channel closed — listener stopped")
 }
 
 // handleEventsAPI processes Events API payloads delivered via Socket Mode.
 func (sl *SocketListener) handleEventsAPI(event slackevents.EventsAPIEvent) {
-	log.Printf("[socket-mode] events-api: type=%s inner=%s",
+	logging.Infof("[socket-mode] events-api: type=%s inner=%s",
 		event.Type, event.InnerEvent.Type)
 
 	if event.Type != slackevents.CallbackEvent {
-		log.Printf("[socket-mode] events-api: skipping non-callback event type %q", event.Type)
+		logging.Infof("[socket-mode] events-api: skipping non-callback event type %q", event.Type)
 		return
 	}
 
 	innerData := event.InnerEvent.Data
 	if innerData == nil {
-		log.Printf("[socket-mode] events-api: inner event data is nil (inner type=%s)", event.InnerEvent.Type)
+		logging.Infof("[socket-mode] events-api: inner event data is nil (inner type=%s)", event.InnerEvent.Type)
 		return
 	}
 
 	switch ev := innerData.(type) {
 	case *slackevents.MessageEvent:
 		sl.handleMessage(ev)
+	case *slackevents.AppMentionEvent:
+		sl.handleAppMention(ev)
+	case *slackevents.AppHomeOpenedEvent:
+		sl.handleAppHomeOpened(ev)
 	default:
-		log.Printf("[socket-mode] events-api: unhandled inner event type %T (event type: %s)",
+		logging.Infof("[socket-mode] events-api: unhandled inner event type %T (event type: %s)",
 			innerData, event.InnerEvent.Type)
 	}
 }
@@ -194,33 +225,72 @@ func (sl *SocketListener) handleEventsAPI(event slackevents.EventsAPIEvent) {
 // handleMessage processes a message event, filtering for actionable thread replies.
 func (sl *SocketListener) handleMessage(ev *slackevents.MessageEvent) {
 	// Log every message event for diagnostics.
-	log.Printf("[socket-mode] message: channel=%s user=%s thread_ts=%q sub_type=%q bot_id=%q text=%q",
+	logging.Infof("[socket-mode] message: channel=%s user=%s thread_ts=%q sub_type=%q bot_id=%q text=%q",
 		ev.Channel, ev.User, ev.ThreadTimeStamp, ev.SubType, ev.BotID, truncate(ev.Text, 80))
 
 	// Only handle regular user messages (no subtypes like message_changed, bot_message, etc.).
 	if ev.SubType != "" {
-		log.Printf("[socket-mode] message: skipping subtype=%q", ev.SubType)
+		logging.Infof("[socket-mode] message: skipping subtype=%q", ev.SubType)
 		return
 	}
 	if ev.ThreadTimeStamp == "" {
-		log.Printf("[socket-mode] message: skipping non-thread message")
+		logging.Infof("[socket-mode] message: skipping non-thread message")
 		return // not a thread reply
 	}
 	if ev.BotID != "" {
-		log.Printf("[socket-mode] message: skipping bot message (bot_id=%s)", ev.BotID)
+		logging.Infof("[socket-mode] message: skipping bot message (bot_id=%s)", ev.BotID)
 		return
 	}
 	if ev.User == sl.botUserID {
-		log.Printf("[socket-mode] message: skipping own message (user=%s)", ev.User)
+		logging.Infof("[socket-mode] message: skipping own message (user=%s)", ev.User)
 		return
 	}
 
-	log.Printf("[socket-mode] thread reply: channel=%s thread=%s user=%s",
+	logging.Infof("[socket-mode] thread reply: channel=%s thread=%s user=%s",
 		ev.Channel, ev.ThreadTimeStamp, ev.User)
 
 	go sl.threadReplyHandler(ev.Channel, ev.ThreadTimeStamp, ev.User, ev.Text)
 }
 
+// handleAppMention strips the bot mention and dispatches the remaining text.
+func (sl *SocketListener) handleAppMention(ev *slackevents.AppMentionEvent) {
+	logging.Infof("[socket-mode] app mention: channel=%s user=%s thread_ts=%q bot_id=%q text=%q",
+		ev.Channel, ev.User, ev.ThreadTimeStamp, ev.BotID, truncate(ev.Text, 80))
+
+	if sl.appMentionHandler == nil {
+		return
+	}
+	if ev.BotID != "" || ev.User == "" || ev.User == sl.botUserID {
+		logging.Infof("[socket-mode] app mention: skipping bot mention")
+		return
+	}
+
+	text := ev.Text
+	if sl.botUserID != "" {
+		text = strings.ReplaceAll(text, "<@"+sl.botUserID+">", "")
+	}
+	go sl.appMentionHandler(ev.Channel, ev.TimeStamp, ev.ThreadTimeStamp, ev.User, strings.TrimSpace(text))
+}
+
+// handleAppHomeOpened publishes a freshly rendered Home tab for the user.
+func (sl *SocketListener) handleAppHomeOpened(ev *slackevents.AppHomeOpenedEvent) {
+	if ev.Tab != "home" || sl.appHomeHandler == nil {
+		return
+	}
+	logging.Infof("[socket-mode] app home opened: user=%s", ev.User)
+
+	go func() {
+		view := slacklib.HomeTabViewRequest{
+			Type:   slacklib.VTHomeTab,
+			Blocks: slacklib.Blocks{BlockSet: sl.appHomeHandler(ev.User)},
+		}
+		req := slacklib.PublishViewContextRequest{UserID: ev.User, View: view}
+		if _, err := sl.smClient.PublishViewContext(context.Background(), req); err != nil {
+			logging.Errorf("[socket-mode] failed to publish app home for user=%s: %v", ev.User, err)
+		}
+	}()
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
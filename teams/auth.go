@@ -0,0 +1,215 @@
+package teams
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	openIDConfigURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	tokenIssuer     = "https://api.botframework.com"
+
+	keysTTL       = 24 * time.Hour
+	keysMinRetry  = 5 * time.Minute // refetch at most this often for an unknown key ID
+	clockSkew     = 5 * time.Minute
+	maxTokenBytes = 16 << 10
+)
+
+// verifier checks the bearer token the Bot Framework connector sends with
+// every activity: an RS256 JWT signed by a key from the Bot Framework OpenID
+// metadata, issued by api.botframework.com for this bot's app ID.
+type verifier struct {
+	appID      string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+type tokenClaims struct {
+	Issuer     string          `json:"iss"`
+	Audience   json.RawMessage `json:"aud"`
+	Expiry     int64           `json:"exp"`
+	NotBefore  int64           `json:"nbf"`
+	ServiceURL string          `json:"serviceurl"`
+}
+
+// verify validates the Authorization header and returns the token's claims.
+func (v *verifier) verify(ctx context.Context, authHeader string) (*tokenClaims, error) {
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || token == "" {
+		return nil, errors.New("missing bearer token")
+	}
+	if len(token) > maxTokenBytes {
+		return nil, errors.New("token too large")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, errors.New("invalid signature")
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+	if claims.Issuer != tokenIssuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if !audienceMatches(claims.Audience, v.appID) {
+		return nil, errors.New("token is not for this bot")
+	}
+	now := time.Now()
+	if claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(clockSkew)) {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errors.New("token not yet valid")
+	}
+	return &claims, nil
+}
+
+// audienceMatches accepts "aud" as a string or an array of strings.
+func audienceMatches(raw json.RawMessage, appID string) bool {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one == appID
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		for _, a := range many {
+			if a == appID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// key returns the signing key with ID kid, refreshing the cached key set
+// when it is stale or does not contain kid (keys are rotated).
+func (v *verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	k, ok := v.keys[kid]
+	age := time.Since(v.fetchedAt)
+	if ok && age < keysTTL {
+		return k, nil
+	}
+	if ok || v.keys == nil || age > keysMinRetry {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			if ok {
+				return k, nil // keep using the cached key until the metadata is reachable
+			}
+			return nil, fmt.Errorf("fetch signing keys: %w", err)
+		}
+		v.keys, v.fetchedAt = keys, time.Now()
+		if k, ok := keys[kid]; ok {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *verifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var meta struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, openIDConfigURL, &meta); err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA keys in the key set")
+	}
+	return keys, nil
+}
+
+func (v *verifier) getJSON(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bot framework metadata error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
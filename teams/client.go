@@ -0,0 +1,421 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+	"github.com/justmike1/ovad/slack"
+	slacklib "github.com/slack-go/slack"
+)
+
+const (
+	// DefaultServiceURL is the Bot Framework endpoint for Teams, used for
+	// conversations the bot has not received an activity from yet.
+	DefaultServiceURL = "https://smba.trafficmanager.net/teams/"
+
+	tokenScope = "https://api.botframework.com/.default"
+
+	// ChatThread is the thread key used for personal and group chats, which
+	// have no threads: the whole chat is one conversation.
+	ChatThread = "chat"
+
+	maxHistory = 200 // messages kept per channel and per thread
+)
+
+// Client posts to Microsoft Teams through the Bot Framework connector and
+// implements commands.ChatPlatform. The connector cannot read history, so
+// the client keeps the messages it sees and sends in memory; channel context
+// starts empty after a restart.
+type Client struct {
+	appID       string
+	appPassword string
+	tenantID    string
+	serviceURL  string
+	httpClient  *http.Client
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+
+	mu          sync.Mutex
+	serviceURLs map[string]string             // channel ID → service URL from inbound activities
+	users       map[string]string             // user ID → display name
+	channels    map[string][]slacklib.Message // channel ID → messages, oldest first
+	threads     map[string][]slacklib.Message // channel ID + thread → messages, oldest first
+}
+
+// NewClient creates a Teams client for the bot registered as appID. tenantID
+// is required for single-tenant bots; empty uses the multi-tenant
+// "botframework.com" authority. serviceURL defaults to DefaultServiceURL.
+func NewClient(appID, appPassword, tenantID, serviceURL string) *Client {
+	if tenantID == "" {
+		tenantID = "botframework.com"
+	}
+	if serviceURL == "" {
+		serviceURL = DefaultServiceURL
+	}
+	return &Client{
+		appID:       appID,
+		appPassword: appPassword,
+		tenantID:    tenantID,
+		serviceURL:  withSlash(serviceURL),
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "teams"},
+		},
+		serviceURLs: make(map[string]string),
+		users:       make(map[string]string),
+		channels:    make(map[string][]slacklib.Message),
+		threads:     make(map[string][]slacklib.Message),
+	}
+}
+
+// AppID returns the bot's Microsoft App ID.
+func (c *Client) AppID() string { return c.appID }
+
+// remember records an inbound message: its service URL, the sender's name
+// and the message itself for channel and thread context.
+func (c *Client) remember(channelID, threadTS, serviceURL string, msg slacklib.Message, userName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if serviceURL != "" {
+		c.serviceURLs[channelID] = withSlash(serviceURL)
+	}
+	if userName != "" {
+		c.users[msg.User] = userName
+	}
+	c.record(channelID, threadTS, msg)
+}
+
+// record appends msg to the channel and thread history. Callers hold c.mu.
+func (c *Client) record(channelID, threadTS string, msg slacklib.Message) {
+	if threadTS == "" || threadTS == msg.Timestamp || threadTS == ChatThread {
+		c.channels[channelID] = appendBounded(c.channels[channelID], msg)
+	}
+	if threadTS != "" {
+		key := channelID + "/" + threadTS
+		c.threads[key] = appendBounded(c.threads[key], msg)
+	}
+}
+
+func appendBounded(msgs []slacklib.Message, msg slacklib.Message) []slacklib.Message {
+	msgs = append(msgs, msg)
+	if len(msgs) > maxHistory {
+		msgs = msgs[len(msgs)-maxHistory:]
+	}
+	return msgs
+}
+
+// FetchChannelHistory returns the messages seen in channelID, newest first
+// like Slack's conversations.history.
+func (c *Client) FetchChannelHistory(channelID string, limit int) ([]slacklib.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	msgs := c.channels[channelID]
+	out := make([]slacklib.Message, 0, min(limit, len(msgs)))
+	for i := len(msgs) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, msgs[i])
+	}
+	return out, nil
+}
+
+// FetchThreadReplies returns the messages seen in a thread, oldest first
+// like Slack's conversations.replies.
+func (c *Client) FetchThreadReplies(channelID, threadTS string, limit int) ([]slacklib.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	msgs := c.threads[channelID+"/"+threadTS]
+	if len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+	return append([]slacklib.Message(nil), msgs...), nil
+}
+
+// PostMessage starts a new thread in a Teams channel, or posts to a chat.
+// It returns the new message's timestamp, which is also its thread key.
+func (c *Client) PostMessage(channelID, text string) (string, error) {
+	ctx := context.Background()
+	text = c.toMarkdown(text)
+	if !IsChannel(channelID) {
+		id, err := c.sendActivity(ctx, channelID, text, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to post message: %w", err)
+		}
+		ts := toTS(id)
+		c.recordSent(channelID, ChatThread, ts, text)
+		return ts, nil
+	}
+
+	body := map[string]any{
+		"isGroup":     true,
+		"channelData": map[string]any{"channel": map[string]string{"id": channelID}},
+		"activity":    newActivity(text, ""),
+	}
+	var created struct {
+		ID         string `json:"id"`
+		ActivityID string `json:"activityId"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.serviceURLFor(channelID)+"v3/conversations", body, &created); err != nil {
+		return "", fmt.Errorf("failed to post message: %w", err)
+	}
+	id := created.ActivityID
+	if _, root, ok := strings.Cut(created.ID, ";messageid="); ok && id == "" {
+		id = root
+	}
+	ts := toTS(id)
+	c.recordSent(channelID, ts, ts, text)
+	return ts, nil
+}
+
+// PostThreadReply replies in the channel thread started by threadTS, or
+// posts to the chat when channelID is a personal or group chat.
+func (c *Client) PostThreadReply(channelID, threadTS, text string) error {
+	text = c.toMarkdown(text)
+	conversation, replyTo := channelID, ""
+	if IsChannel(channelID) && threadTS != ChatThread {
+		replyTo = fromTS(threadTS)
+		conversation = channelID + ";messageid=" + replyTo
+	}
+	id, err := c.sendActivity(context.Background(), conversation, text, replyTo)
+	if err != nil {
+		return fmt.Errorf("failed to post thread reply: %w", err)
+	}
+	c.recordSent(channelID, threadTS, toTS(id), text)
+	return nil
+}
+
+// UploadFile posts the content inline as a code block. Bots can only send
+// files to personal chats, after the user accepts a consent card, so
+// uploads are not attempted.
+func (c *Client) UploadFile(channelID, threadTS, filename, title string, content []byte) error {
+	if title == "" {
+		title = filename
+	}
+	const maxInline = 20000
+	body := string(content)
+	if len(body) > maxInline {
+		body = body[:maxInline] + "\n… (truncated)"
+	}
+	text := fmt.Sprintf("**%s**\n```\n%s\n```", title, strings.ReplaceAll(body, "```", "'''"))
+	if threadTS == "" {
+		_, err := c.PostMessage(channelID, text)
+		return err
+	}
+	return c.PostThreadReply(channelID, threadTS, text)
+}
+
+// GetPermalink returns the Teams deep link to a message.
+func (c *Client) GetPermalink(channelID, messageTS string) (string, error) {
+	if messageTS == "" || messageTS == ChatThread {
+		return "", fmt.Errorf("no message to link to in %s", channelID)
+	}
+	id := fromTS(messageTS)
+	return fmt.Sprintf("https://teams.microsoft.com/l/message/%s/%s?parentMessageId=%s",
+		url.PathEscape(channelID), id, id), nil
+}
+
+// GetUserInfo returns the name Teams sent with the user's last message. The
+// connector does not expose email or title; those stay empty.
+func (c *Client) GetUserInfo(userID string) (*slacklib.User, error) {
+	c.mu.Lock()
+	name, ok := c.users[userID]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown Teams user %s", userID)
+	}
+	u := &slacklib.User{ID: userID, Name: name, RealName: name}
+	u.Profile.RealName = name
+	u.Profile.DisplayName = name
+	return u, nil
+}
+
+func (c *Client) recordSent(channelID, threadTS, ts, text string) {
+	msg := slacklib.Message{}
+	msg.Timestamp = ts
+	msg.ThreadTimestamp = threadTS
+	msg.BotID = c.appID
+	msg.Text = text
+	c.mu.Lock()
+	c.record(channelID, threadTS, msg)
+	c.mu.Unlock()
+}
+
+func (c *Client) serviceURLFor(channelID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if u, ok := c.serviceURLs[channelID]; ok {
+		return u
+	}
+	return c.serviceURL
+}
+
+func withSlash(u string) string { return strings.TrimRight(u, "/") + "/" }
+
+func newActivity(text, replyTo string) map[string]any {
+	a := map[string]any{"type": "message", "text": text, "textFormat": "markdown"}
+	if replyTo != "" {
+		a["replyToId"] = replyTo
+	}
+	return a
+}
+
+// sendActivity posts a message activity to a conversation and returns its ID.
+func (c *Client) sendActivity(ctx context.Context, conversationID, text, replyTo string) (string, error) {
+	channelID, _, _ := strings.Cut(conversationID, ";")
+	endpoint := c.serviceURLFor(channelID) + "v3/conversations/" + url.PathEscape(conversationID) + "/activities"
+	var sent struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, endpoint, newActivity(text, replyTo), &sent); err != nil {
+		return "", err
+	}
+	return sent.ID, nil
+}
+
+// IsChannel reports whether a conversation ID is a Teams channel (which has
+// threads) rather than a personal or group chat.
+func IsChannel(conversationID string) bool {
+	return strings.HasSuffix(conversationID, "@thread.tacv2") || strings.HasSuffix(conversationID, "@thread.skype")
+}
+
+// toTS converts a Teams message ID — epoch milliseconds in channels — to a
+// Slack-style "seconds.fraction" timestamp so thread keys and message times
+// work the same as on Slack. Other IDs are returned unchanged.
+func toTS(id string) string {
+	if len(id) != 13 {
+		return id
+	}
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return id
+	}
+	return id[:10] + "." + id[10:]
+}
+
+// fromTS reverses toTS.
+func fromTS(ts string) string {
+	if sec, ms, ok := strings.Cut(ts, "."); ok && len(sec) == 10 && len(ms) == 3 {
+		return sec + ms
+	}
+	return ts
+}
+
+// toMarkdown converts the agents' Slack mrkdwn for Teams, writing user
+// mentions as the bold display name.
+func (c *Client) toMarkdown(text string) string {
+	return slack.ToMarkdown(text, func(id string) string {
+		c.mu.Lock()
+		name, ok := c.users[id]
+		c.mu.Unlock()
+		if !ok {
+			return id
+		}
+		return "**" + name + "**"
+	})
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+// accessToken returns a cached Bot Framework token, fetching a new one with
+// the client credentials grant shortly before it expires.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != "" && time.Until(c.tokenExpiry) > time.Minute {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.appID},
+		"client_secret": {c.appPassword},
+		"scope":         {tokenScope},
+	}
+	tokenURL := "https://login.microsoftonline.com/" + url.PathEscape(c.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send token request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("teams token error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("unmarshal token response: %w", err)
+	}
+	c.token = tok.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("teams API error (HTTP %d): %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("teams API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package teams
+
+import (
+	"encoding/json"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/logging"
+	"github.com/justmike1/ovad/metrics"
+	slacklib "github.com/slack-go/slack"
+)
+
+// maxActivityBytes bounds the request body; message activities are small.
+const maxActivityBytes = 1 << 20
+
+// MessageHandler receives a user message. threadTS is the thread the message
+// belongs to (ChatThread in personal and group chats), and mentioned reports
+// whether the bot was @mentioned, which channel messages need to start a
+// session.
+type MessageHandler func(channelID, threadTS, userID, text string, mentioned bool)
+
+// Handler is the Bot Framework messaging endpoint. It verifies the
+// connector's token, records the message for context and hands it to the
+// MessageHandler in the background, so Teams gets its 200 right away.
+type Handler struct {
+	client   *Client
+	verifier *verifier
+	handler  MessageHandler
+}
+
+// NewHandler creates the messaging endpoint for client's bot.
+func NewHandler(client *Client, handler MessageHandler) *Handler {
+	return &Handler{
+		client: client,
+		verifier: &verifier{
+			appID: client.appID,
+			httpClient: &http.Client{
+				Timeout:   30 * time.Second,
+				Transport: &metrics.Transport{Integration: "teams"},
+			},
+		},
+		handler: handler,
+	}
+}
+
+type account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type activity struct {
+	Type         string  `json:"type"`
+	ID           string  `json:"id"`
+	ServiceURL   string  `json:"serviceUrl"`
+	From         account `json:"from"`
+	Recipient    account `json:"recipient"`
+	Conversation struct {
+		ID       string `json:"id"`
+		TenantID string `json:"tenantId"`
+	} `json:"conversation"`
+	Text     string `json:"text"`
+	Entities []struct {
+		Type      string  `json:"type"`
+		Mentioned account `json:"mentioned"`
+		Text      string  `json:"text"`
+	} `json:"entities"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, err := h.verifier.verify(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		logging.Errorf("[teams] token verification failed: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxActivityBytes))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var act activity
+	if err := json.Unmarshal(body, &act); err != nil {
+		logging.Errorf("[teams] failed to parse activity: %v", err)
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if claims.ServiceURL != "" && strings.TrimRight(claims.ServiceURL, "/") != strings.TrimRight(act.ServiceURL, "/") {
+		logging.Infof("[teams] service URL %q does not match the token's %q", act.ServiceURL, claims.ServiceURL)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if act.Type != "message" || act.From.ID == "" {
+		return
+	}
+	if h.client.tenantID != "botframework.com" && act.Conversation.TenantID != "" && act.Conversation.TenantID != h.client.tenantID {
+		logging.Warnf("[teams] ignoring message from tenant %s", act.Conversation.TenantID)
+		return
+	}
+
+	channelID, threadTS := splitConversation(act.Conversation.ID)
+	text, mentioned := h.messageText(&act)
+
+	msg := slacklib.Message{}
+	msg.User = act.From.ID
+	msg.Text = text
+	msg.Timestamp = toTS(act.ID)
+	msg.ThreadTimestamp = threadTS
+	h.client.remember(channelID, threadTS, act.ServiceURL, msg, act.From.Name)
+
+	if text == "" {
+		return
+	}
+	go h.handler(channelID, threadTS, act.From.ID, text, mentioned || !IsChannel(channelID))
+}
+
+// splitConversation splits a conversation ID into the channel and thread
+// keys. Channel threads have IDs like "19:…@thread.tacv2;messageid=<root>";
+// chats have no threads.
+func splitConversation(id string) (string, string) {
+	if channel, root, ok := strings.Cut(id, ";messageid="); ok {
+		return channel, toTS(root)
+	}
+	return id, ChatThread
+}
+
+var (
+	atTag   = regexp.MustCompile(`(?s)<at>(.*?)</at>`)
+	htmlTag = regexp.MustCompile(`<[^>]+>`)
+)
+
+// messageText returns the message as plain text with the bot's own
+// @mention removed and other mentions reduced to the name, and whether the
+// bot was mentioned.
+func (h *Handler) messageText(act *activity) (string, bool) {
+	text := act.Text
+	mentioned := false
+	for _, e := range act.Entities {
+		if e.Type == "mention" && e.Mentioned.ID == act.Recipient.ID {
+			mentioned = true
+			if e.Text != "" {
+				text = strings.ReplaceAll(text, e.Text, "")
+			}
+		}
+	}
+	text = atTag.ReplaceAllString(text, "$1")
+	text = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "</p>", "\n").Replace(text)
+	text = html.UnescapeString(htmlTag.ReplaceAllString(text, ""))
+	return strings.TrimSpace(strings.ReplaceAll(text, "\u00a0", " ")), mentioned
+}
@@ -0,0 +1,256 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// DefaultAddress is the Terraform Cloud address; Terraform Enterprise
+// installations use their own hostname.
+const DefaultAddress = "https://app.terraform.io"
+
+// Client provides read access to the Terraform Cloud / Enterprise API.
+type Client struct {
+	address      string
+	token        string
+	organization string
+	httpClient   *http.Client
+}
+
+// NewClient creates a Terraform Cloud client. address is the Terraform
+// Cloud or Enterprise URL (DefaultAddress if empty), token a user or team
+// API token, and organization the default organization for workspace
+// lookups (may be empty).
+func NewClient(address, token, organization string) *Client {
+	if address == "" {
+		address = DefaultAddress
+	}
+	return &Client{
+		address:      strings.TrimRight(address, "/"),
+		token:        token,
+		organization: organization,
+		httpClient: &http.Client{
+			Timeout:   60 * time.Second,
+			Transport: &metrics.Transport{Integration: "terraform"},
+		},
+	}
+}
+
+// Workspace is a Terraform Cloud workspace.
+type Workspace struct {
+	ID               string
+	Name             string
+	Organization     string
+	TerraformVersion string
+	ExecutionMode    string
+	AutoApply        bool
+	Locked           bool
+}
+
+// Run is a Terraform Cloud run and the resource counts of its plan.
+type Run struct {
+	ID           string
+	Status       string // pending, planning, planned, cost_estimated, policy_checked, applying, applied, errored, discarded, ...
+	Message      string
+	Source       string
+	IsDestroy    bool
+	HasChanges   bool
+	Confirmable  bool // waiting for someone to approve the apply
+	CreatedAt    time.Time
+	PlanID       string
+	PlanStatus   string
+	Additions    int
+	Changes      int
+	Destructions int
+	Imports      int
+}
+
+// GetWorkspace returns a workspace by name in organization (the default
+// organization if empty).
+func (c *Client) GetWorkspace(ctx context.Context, organization, name string) (*Workspace, error) {
+	if organization == "" {
+		organization = c.organization
+	}
+	if organization == "" {
+		return nil, fmt.Errorf("no organization given and TFE_ORGANIZATION is not set")
+	}
+	var resp struct {
+		Data struct {
+			ID         string `json:"id"`
+			Attributes struct {
+				Name             string `json:"name"`
+				TerraformVersion string `json:"terraform-version"`
+				ExecutionMode    string `json:"execution-mode"`
+				AutoApply        bool   `json:"auto-apply"`
+				Locked           bool   `json:"locked"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("/organizations/%s/workspaces/%s", url.PathEscape(organization), url.PathEscape(name))
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	a := resp.Data.Attributes
+	return &Workspace{
+		ID: resp.Data.ID, Name: a.Name, Organization: organization, TerraformVersion: a.TerraformVersion,
+		ExecutionMode: a.ExecutionMode, AutoApply: a.AutoApply, Locked: a.Locked,
+	}, nil
+}
+
+// ListRuns returns a workspace's most recent runs, newest first.
+func (c *Client) ListRuns(ctx context.Context, workspaceID string, limit int) ([]Run, error) {
+	q := url.Values{"page[size]": {fmt.Sprint(min(max(limit, 1), 100))}, "include": {"plan"}}
+	var resp struct {
+		Data     []run  `json:"data"`
+		Included []plan `json:"included"`
+	}
+	if err := c.get(ctx, "/workspaces/"+url.PathEscape(workspaceID)+"/runs?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	plans := make(map[string]plan, len(resp.Included))
+	for _, p := range resp.Included {
+		if p.Type == "plans" {
+			plans[p.ID] = p
+		}
+	}
+	out := make([]Run, 0, len(resp.Data))
+	for _, r := range resp.Data {
+		out = append(out, r.toRun(plans))
+	}
+	return out, nil
+}
+
+// GetRun returns a run by ID.
+func (c *Client) GetRun(ctx context.Context, runID string) (*Run, error) {
+	var resp struct {
+		Data     run    `json:"data"`
+		Included []plan `json:"included"`
+	}
+	if err := c.get(ctx, "/runs/"+url.PathEscape(runID)+"?include=plan", &resp); err != nil {
+		return nil, err
+	}
+	plans := map[string]plan{}
+	for _, p := range resp.Included {
+		if p.Type == "plans" {
+			plans[p.ID] = p
+		}
+	}
+	r := resp.Data.toRun(plans)
+	return &r, nil
+}
+
+// RunURL returns the web UI URL of a run.
+func (c *Client) RunURL(ws *Workspace, runID string) string {
+	return fmt.Sprintf("%s/app/%s/workspaces/%s/runs/%s", c.address, url.PathEscape(ws.Organization), url.PathEscape(ws.Name), url.PathEscape(runID))
+}
+
+// --------------------------------------------------------------------------
+// API types
+// --------------------------------------------------------------------------
+
+type run struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Status     string    `json:"status"`
+		Message    string    `json:"message"`
+		Source     string    `json:"source"`
+		IsDestroy  bool      `json:"is-destroy"`
+		HasChanges bool      `json:"has-changes"`
+		CreatedAt  time.Time `json:"created-at"`
+		Actions    struct {
+			IsConfirmable bool `json:"is-confirmable"`
+		} `json:"actions"`
+	} `json:"attributes"`
+	Relationships struct {
+		Plan struct {
+			Data *struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"plan"`
+	} `json:"relationships"`
+}
+
+type plan struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Status               string `json:"status"`
+		ResourceAdditions    int    `json:"resource-additions"`
+		ResourceChanges      int    `json:"resource-changes"`
+		ResourceDestructions int    `json:"resource-destructions"`
+		ResourceImports      int    `json:"resource-imports"`
+	} `json:"attributes"`
+}
+
+func (r run) toRun(plans map[string]plan) Run {
+	a := r.Attributes
+	out := Run{
+		ID: r.ID, Status: a.Status, Message: strings.TrimSpace(a.Message), Source: a.Source,
+		IsDestroy: a.IsDestroy, HasChanges: a.HasChanges, Confirmable: a.Actions.IsConfirmable, CreatedAt: a.CreatedAt,
+	}
+	if d := r.Relationships.Plan.Data; d != nil {
+		out.PlanID = d.ID
+		if p, ok := plans[d.ID]; ok {
+			out.PlanStatus = p.Attributes.Status
+			out.Additions = p.Attributes.ResourceAdditions
+			out.Changes = p.Attributes.ResourceChanges
+			out.Destructions = p.Attributes.ResourceDestructions
+			out.Imports = p.Attributes.ResourceImports
+		}
+	}
+	return out
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+"/api/v2"+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	// The JSON plan endpoint redirects to a pre-signed archive URL; the
+	// client drops the Authorization header when following it.
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []struct {
+				Title  string `json:"title"`
+				Detail string `json:"detail"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			msg := apiErr.Errors[0].Title
+			if apiErr.Errors[0].Detail != "" {
+				msg += ": " + apiErr.Errors[0].Detail
+			}
+			return fmt.Errorf("terraform API error (HTTP %d): %s", resp.StatusCode, msg)
+		}
+		return fmt.Errorf("terraform API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
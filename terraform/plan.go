@@ -0,0 +1,201 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ResourceChange is one resource in a plan that Terraform would change.
+type ResourceChange struct {
+	Address string
+	Action  string // create, update, delete, replace or read
+	Reason  string // why a resource is replaced or deleted, e.g. "replace_because_cannot_update"
+	// Fields lists the changed attributes of updates and replacements as
+	// "path: before → after"; sensitive values are masked.
+	Fields []string
+	// ForcesReplacement lists the attribute paths that force a replacement.
+	ForcesReplacement []string
+}
+
+// Destructive reports whether the change deletes the existing resource.
+func (rc ResourceChange) Destructive() bool {
+	return rc.Action == "delete" || rc.Action == "replace"
+}
+
+// PlanChanges returns the resource and output changes of a finished plan,
+// from its JSON representation. No-op resources are omitted.
+func (c *Client) PlanChanges(ctx context.Context, planID string) ([]ResourceChange, []string, error) {
+	var p struct {
+		ResourceChanges []struct {
+			Address      string `json:"address"`
+			ActionReason string `json:"action_reason"`
+			Change       struct {
+				Actions         []string          `json:"actions"`
+				Before          any               `json:"before"`
+				After           any               `json:"after"`
+				AfterUnknown    any               `json:"after_unknown"`
+				BeforeSensitive any               `json:"before_sensitive"`
+				AfterSensitive  any               `json:"after_sensitive"`
+				ReplacePaths    []json.RawMessage `json:"replace_paths"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+		OutputChanges map[string]struct {
+			Actions []string `json:"actions"`
+		} `json:"output_changes"`
+	}
+	if err := c.get(ctx, "/plans/"+url.PathEscape(planID)+"/json-output", &p); err != nil {
+		return nil, nil, err
+	}
+
+	var out []ResourceChange
+	for _, r := range p.ResourceChanges {
+		action := planAction(r.Change.Actions)
+		if action == "no-op" {
+			continue
+		}
+		rc := ResourceChange{Address: r.Address, Action: action, Reason: r.ActionReason}
+		if action == "update" || action == "replace" {
+			rc.Fields = diffValues(r.Change.Before, r.Change.After, r.Change.AfterUnknown, r.Change.BeforeSensitive, r.Change.AfterSensitive)
+			for _, raw := range r.Change.ReplacePaths {
+				rc.ForcesReplacement = append(rc.ForcesReplacement, replacePath(raw))
+			}
+		}
+		out = append(out, rc)
+	}
+
+	var outputs []string
+	for name, o := range p.OutputChanges {
+		if action := planAction(o.Actions); action != "no-op" {
+			outputs = append(outputs, name+" ("+action+")")
+		}
+	}
+	sort.Strings(outputs)
+	return out, outputs, nil
+}
+
+// planAction collapses a plan's action list into one word; ["delete",
+// "create"] and ["create", "delete"] are replacements.
+func planAction(actions []string) string {
+	switch {
+	case len(actions) == 2 && slices.Contains(actions, "delete") && slices.Contains(actions, "create"):
+		return "replace"
+	case len(actions) == 1:
+		return actions[0]
+	default:
+		return strings.Join(actions, "+")
+	}
+}
+
+// replacePath renders a replace_paths entry (e.g. ["network_interface", 0,
+// "subnet_id"]) in the dotted form diffValues uses.
+func replacePath(raw json.RawMessage) string {
+	var steps []any
+	if err := json.Unmarshal(raw, &steps); err != nil {
+		return string(raw)
+	}
+	var sb strings.Builder
+	for _, s := range steps {
+		switch v := s.(type) {
+		case float64:
+			fmt.Fprintf(&sb, "[%d]", int(v))
+		default:
+			if sb.Len() > 0 {
+				sb.WriteString(".")
+			}
+			fmt.Fprint(&sb, v)
+		}
+	}
+	return sb.String()
+}
+
+// --------------------------------------------------------------------------
+// Diff
+// --------------------------------------------------------------------------
+
+// diffValues compares a resource's before and after values leaf by leaf and
+// returns one line per changed leaf, sorted by path. Values only known after
+// apply and sensitive values are rendered as placeholders.
+func diffValues(before, after, afterUnknown, beforeSensitive, afterSensitive any) []string {
+	bf, af := map[string]string{}, map[string]string{}
+	flatten("", before, bf)
+	flatten("", after, af)
+	unknown, sensitive := map[string]string{}, map[string]string{}
+	flatten("", afterUnknown, unknown)
+	flatten("", beforeSensitive, sensitive)
+	flatten("", afterSensitive, sensitive)
+	for p, v := range unknown {
+		if v == "true" {
+			af[p] = "(known after apply)"
+		}
+	}
+
+	var out []string
+	for p, av := range af {
+		bv, ok := bf[p]
+		if (ok && bv == av) || (!ok && av == "null") {
+			continue
+		}
+		if isSensitive(p, sensitive) {
+			out = append(out, p+": (sensitive value changed)")
+			continue
+		}
+		if !ok {
+			out = append(out, fmt.Sprintf("+%s: %s", p, av))
+		} else {
+			out = append(out, fmt.Sprintf("%s: %s → %s", p, bv, av))
+		}
+	}
+	for p, bv := range bf {
+		if _, ok := af[p]; ok || bv == "null" {
+			continue
+		}
+		if isSensitive(p, sensitive) {
+			out = append(out, "-"+p+": (sensitive)")
+		} else {
+			out = append(out, fmt.Sprintf("-%s: %s", p, bv))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.TrimLeft(out[i], "+-") < strings.TrimLeft(out[j], "+-") })
+	return out
+}
+
+// isSensitive reports whether p or one of its parents is marked sensitive.
+func isSensitive(p string, sensitive map[string]string) bool {
+	for sp, v := range sensitive {
+		if v == "true" && (p == sp || sp == "" || strings.HasPrefix(p, sp+".") || strings.HasPrefix(p, sp+"[")) {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten records every leaf of v under its dotted path, e.g.
+// "network_interface[0].subnet_id".
+func flatten(prefix string, v any, out map[string]string) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			flatten(p, child, out)
+		}
+	case []any:
+		for i, child := range t {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	default:
+		b, _ := json.Marshal(t)
+		s := string(b)
+		if len(s) > 200 {
+			s = s[:200] + "…"
+		}
+		out[prefix] = s
+	}
+}
@@ -0,0 +1,143 @@
+package ticketing
+
+import (
+	"context"
+
+	"github.com/justmike1/ovad/jira"
+	"github.com/justmike1/ovad/logging"
+)
+
+// Jira is the Provider backed by Jira Cloud.
+type Jira struct {
+	client *jira.Client
+}
+
+// NewJira wraps a Jira client as a Provider.
+func NewJira(client *jira.Client) *Jira {
+	return &Jira{client: client}
+}
+
+func (j *Jira) Name() string { return "Jira" }
+
+// Create files a Jira issue. The assignee and team are resolved by name; a
+// name that does not resolve is logged and skipped rather than failing the
+// creation.
+func (j *Jira) Create(_ context.Context, in CreateInput) (*Ticket, error) {
+	var assigneeID string
+	if in.Assignee != "" {
+		users, err := j.client.SearchAssignableUsers(in.Assignee, in.Project)
+		if err != nil {
+			logging.Errorf("Jira user search failed for %q: %v", in.Assignee, err)
+		} else if len(users) > 0 {
+			if best, ok := jira.BestUserMatch(users, in.Assignee); ok {
+				assigneeID = best.AccountID
+				logging.Infof("resolved assignee %q to user %s (%s)", in.Assignee, best.DisplayName, assigneeID)
+			} else {
+				logging.Infof("user search for %q returned %d results but none matched well (top: %s)", in.Assignee, len(users), users[0].DisplayName)
+			}
+		} else {
+			logging.Infof("no Jira user found for %q", in.Assignee)
+		}
+	}
+
+	var teamFieldID, teamID, teamName string
+	if in.Team != "" {
+		fid, tid, dname, err := j.client.ResolveTeam(in.Team)
+		if err != nil {
+			logging.Errorf("team resolution failed for %q: %v", in.Team, err)
+		} else {
+			teamFieldID, teamID, teamName = fid, tid, dname
+			logging.Infof("resolved %q to team %s (field: %s)", in.Team, teamName, teamFieldID)
+		}
+	}
+
+	issue, err := j.client.CreateIssue(jira.CreateIssueInput{
+		Project:     in.Project,
+		Summary:     in.Title,
+		Description: in.Description,
+		IssueType:   in.Type,
+		Labels:      in.Labels,
+		AssigneeID:  assigneeID,
+		Components:  in.Components,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The team is a custom field, set after creation.
+	if teamFieldID != "" && teamID != "" {
+		if err := j.client.SetTeamField(issue.Key, teamFieldID, teamID); err != nil {
+			logging.Errorf("failed to set team %s on %s: %v", teamName, issue.Key, err)
+			teamName = ""
+		}
+	}
+	return &Ticket{Key: issue.Key, Title: in.Title, Type: in.Type, Labels: in.Labels, Team: teamName, URL: issue.Browse}, nil
+}
+
+// Search runs a JQL query.
+func (j *Jira) Search(_ context.Context, jql string, max int) ([]Ticket, error) {
+	issues, err := j.client.SearchIssuesJQL(jql, max)
+	if err != nil {
+		return nil, err
+	}
+	tickets := make([]Ticket, len(issues))
+	for i := range issues {
+		tickets[i] = fromIssue(&issues[i])
+	}
+	return tickets, nil
+}
+
+func (j *Jira) Get(_ context.Context, key string) (*Ticket, error) {
+	issue, err := j.client.GetIssue(key)
+	if err != nil {
+		return nil, err
+	}
+	t := fromIssue(issue)
+	return &t, nil
+}
+
+func (j *Jira) Update(_ context.Context, key string, in UpdateInput) error {
+	fields := map[string]interface{}{}
+	if in.Title != "" {
+		fields["summary"] = in.Title
+	}
+	if in.AssigneeID != "" {
+		fields["assignee"] = map[string]string{"accountId": in.AssigneeID}
+	}
+	if len(fields) > 0 {
+		if err := j.client.UpdateIssueFields(key, fields); err != nil {
+			return err
+		}
+	}
+	// The description is written separately as ADF.
+	if in.Description != "" {
+		return j.client.UpdateIssueDescription(key, in.Description)
+	}
+	return nil
+}
+
+func (j *Jira) Transition(_ context.Context, key, status string) (string, error) {
+	return j.client.TransitionIssue(key, status)
+}
+
+func (j *Jira) Comment(_ context.Context, key, text string) error {
+	return j.client.AddComment(key, text)
+}
+
+func fromIssue(i *jira.IssueSummary) Ticket {
+	return Ticket{
+		Key:         i.Key,
+		Title:       i.Summary,
+		Description: i.Description,
+		Status:      i.Status,
+		Type:        i.IssueType,
+		Priority:    i.Priority,
+		Assignee:    i.Assignee,
+		Reporter:    i.Reporter,
+		Labels:      i.Labels,
+		Team:        i.Team,
+		Sprint:      i.Sprint,
+		Updated:     i.Updated,
+		URL:         i.Browse,
+	}
+}
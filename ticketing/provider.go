@@ -0,0 +1,61 @@
+// Package ticketing abstracts issue trackers behind one Provider interface so
+// the ticket tools work the same whether tickets live in Jira or elsewhere.
+package ticketing
+
+import "context"
+
+// Provider is an issue tracker backend. Keys and queries are in the
+// backend's own syntax (e.g. "ENG-123" and JQL for Jira).
+type Provider interface {
+	// Name is the tracker's display name, e.g. "Jira".
+	Name() string
+	// Create files a new ticket. Description is markdown.
+	Create(ctx context.Context, in CreateInput) (*Ticket, error)
+	// Search runs a backend-specific query and returns up to max tickets.
+	Search(ctx context.Context, query string, max int) ([]Ticket, error)
+	// Get returns one ticket with its full description.
+	Get(ctx context.Context, key string) (*Ticket, error)
+	// Update changes the non-empty fields of in.
+	Update(ctx context.Context, key string, in UpdateInput) error
+	// Transition moves a ticket to status and returns the status it ended up in.
+	Transition(ctx context.Context, key, status string) (string, error)
+	// Comment adds a markdown comment to a ticket.
+	Comment(ctx context.Context, key, text string) error
+}
+
+// Ticket is a tracker-neutral view of an issue or work item.
+type Ticket struct {
+	Key         string
+	Title       string
+	Description string
+	Status      string
+	Type        string
+	Priority    string
+	Assignee    string
+	Reporter    string
+	Labels      []string
+	Team        string // tracker team or area, when the backend has one
+	Sprint      string // sprint or iteration, when the backend has one
+	Updated     string
+	URL         string
+}
+
+// CreateInput holds the fields of a new ticket.
+type CreateInput struct {
+	Project     string // project key or name; empty = the provider's default
+	Title       string
+	Description string // markdown
+	Type        string // e.g. "Task", "Bug"; empty = the provider's default
+	Labels      []string
+	Assignee    string // person's name, resolved by the provider
+	Team        string // team name, resolved by the provider
+	Components  []string
+}
+
+// UpdateInput holds the fields to change on a ticket; empty fields are left
+// as they are.
+type UpdateInput struct {
+	Title       string
+	Description string // markdown
+	AssigneeID  string // backend account ID
+}
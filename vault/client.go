@@ -0,0 +1,257 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justmike1/ovad/metrics"
+)
+
+// Client provides read-only access to HashiCorp Vault metadata: KV v2
+// secret listings and versions, and lease TTLs. It never reads secret
+// values; the token's policy should grant only "list" on <mount>/metadata/*,
+// "read" on <mount>/metadata/* and "update" on sys/leases/lookup (plus
+// "sudo" + "list" on sys/leases/lookup/* to list leases by prefix).
+type Client struct {
+	addr       string
+	token      string
+	namespace  string
+	kvMount    string
+	httpClient *http.Client
+}
+
+// NewClient creates a Vault client. kvMount is the default KV v2 mount
+// (default: "secret"); namespace is the Vault Enterprise namespace, if any.
+func NewClient(addr, token, namespace, kvMount string) *Client {
+	if kvMount == "" {
+		kvMount = "secret"
+	}
+	return &Client{
+		addr:      strings.TrimRight(addr, "/"),
+		token:     token,
+		namespace: namespace,
+		kvMount:   strings.Trim(kvMount, "/"),
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &metrics.Transport{Integration: "vault"},
+		},
+	}
+}
+
+// SecretMetadata is the non-sensitive metadata of a KV v2 secret.
+type SecretMetadata struct {
+	Path           string
+	CurrentVersion int
+	OldestVersion  int
+	MaxVersions    int
+	CreatedTime    time.Time
+	UpdatedTime    time.Time
+	DeleteAfter    string // "0s" = never
+	CustomMetadata map[string]string
+	Versions       []Version // newest first
+}
+
+// Version is one version of a KV v2 secret.
+type Version struct {
+	Number       int
+	CreatedTime  time.Time
+	DeletionTime time.Time // zero unless soft-deleted
+	Destroyed    bool
+}
+
+// Lease is a lease issued for a dynamic secret (e.g. a database credential).
+type Lease struct {
+	ID         string
+	IssueTime  time.Time
+	ExpireTime time.Time
+	LastRenew  time.Time
+	Renewable  bool
+	TTL        time.Duration
+}
+
+// List returns the keys under path in a KV v2 mount; keys ending in "/"
+// are folders. mount defaults to the client's KV mount.
+func (c *Client) List(ctx context.Context, mount, path string) ([]string, error) {
+	var resp struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, "LIST", "/v1/"+c.mount(mount)+"/metadata/"+escapePath(path), nil, &resp); err != nil {
+		return nil, err
+	}
+	sort.Strings(resp.Data.Keys)
+	return resp.Data.Keys, nil
+}
+
+// Metadata returns the metadata of a KV v2 secret. Secret data is never
+// requested.
+func (c *Client) Metadata(ctx context.Context, mount, path string) (*SecretMetadata, error) {
+	var resp struct {
+		Data struct {
+			CurrentVersion     int               `json:"current_version"`
+			OldestVersion      int               `json:"oldest_version"`
+			MaxVersions        int               `json:"max_versions"`
+			CreatedTime        time.Time         `json:"created_time"`
+			UpdatedTime        time.Time         `json:"updated_time"`
+			DeleteVersionAfter string            `json:"delete_version_after"`
+			CustomMetadata     map[string]string `json:"custom_metadata"`
+			Versions           map[string]struct {
+				CreatedTime  time.Time `json:"created_time"`
+				DeletionTime string    `json:"deletion_time"`
+				Destroyed    bool      `json:"destroyed"`
+			} `json:"versions"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/"+c.mount(mount)+"/metadata/"+escapePath(path), nil, &resp); err != nil {
+		return nil, err
+	}
+	d := resp.Data
+	md := &SecretMetadata{
+		Path:           strings.Trim(path, "/"),
+		CurrentVersion: d.CurrentVersion,
+		OldestVersion:  d.OldestVersion,
+		MaxVersions:    d.MaxVersions,
+		CreatedTime:    d.CreatedTime,
+		UpdatedTime:    d.UpdatedTime,
+		DeleteAfter:    d.DeleteVersionAfter,
+		CustomMetadata: d.CustomMetadata,
+	}
+	for k, v := range d.Versions {
+		var n int
+		if _, err := fmt.Sscan(k, &n); err != nil {
+			continue
+		}
+		ver := Version{Number: n, CreatedTime: v.CreatedTime, Destroyed: v.Destroyed}
+		if v.DeletionTime != "" {
+			ver.DeletionTime, _ = time.Parse(time.RFC3339Nano, v.DeletionTime)
+		}
+		md.Versions = append(md.Versions, ver)
+	}
+	sort.Slice(md.Versions, func(i, j int) bool { return md.Versions[i].Number > md.Versions[j].Number })
+	return md, nil
+}
+
+// LookupLease returns the TTL and expiry of a lease.
+func (c *Client) LookupLease(ctx context.Context, leaseID string) (*Lease, error) {
+	var resp struct {
+		Data struct {
+			ID         string    `json:"id"`
+			IssueTime  time.Time `json:"issue_time"`
+			ExpireTime time.Time `json:"expire_time"`
+			LastRenew  time.Time `json:"last_renewal"`
+			Renewable  bool      `json:"renewable"`
+			TTL        int       `json:"ttl"`
+		} `json:"data"`
+	}
+	body := map[string]string{"lease_id": leaseID}
+	if err := c.do(ctx, http.MethodPut, "/v1/sys/leases/lookup", body, &resp); err != nil {
+		return nil, err
+	}
+	d := resp.Data
+	return &Lease{
+		ID:         d.ID,
+		IssueTime:  d.IssueTime,
+		ExpireTime: d.ExpireTime,
+		LastRenew:  d.LastRenew,
+		Renewable:  d.Renewable,
+		TTL:        time.Duration(d.TTL) * time.Second,
+	}, nil
+}
+
+// ListLeases returns the lease IDs (or sub-prefixes, ending in "/") under a
+// lease prefix such as "database/creds/app-readonly/". Requires sudo.
+func (c *Client) ListLeases(ctx context.Context, prefix string) ([]string, error) {
+	var resp struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, "LIST", "/v1/sys/leases/lookup/"+escapePath(prefix), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data.Keys, nil
+}
+
+func (c *Client) mount(m string) string {
+	if m = strings.Trim(m, "/"); m != "" {
+		return escapePath(m)
+	}
+	return escapePath(c.kvMount)
+}
+
+// escapePath escapes each segment of a slash-separated path, keeping a
+// trailing slash (Vault uses it to denote folders).
+func escapePath(p string) string {
+	p = strings.TrimLeft(p, "/")
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// --------------------------------------------------------------------------
+// HTTP transport
+// --------------------------------------------------------------------------
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("not found (HTTP 404): the path does not exist or has no entries")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			return fmt.Errorf("vault API error (HTTP %d): %s", resp.StatusCode, strings.Join(apiErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault API error (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}